@@ -1,7 +1,6 @@
 package main
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"context"
@@ -12,20 +11,12 @@ import (
 // main is a deterministic boundary: it canonicalizes all CLI inputs into a
 // CLIInvocation before any engine logic is invoked.
 func main() {
-	inv, err := cli.ParseInvocation(os.Args[1:])
-	if err != nil {
-		var invErr *cli.InvocationError
-		if errors.As(err, &invErr) {
-			fmt.Fprintln(os.Stderr, invErr.Message)
-			os.Exit(invErr.ExitCode)
+	if code, handled, err := cli.Dispatch(os.Args[1:], os.Stdout); handled {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
 		}
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(cli.ExitInternalError)
+		os.Exit(code)
 	}
 
-	result, execErr := cli.Execute(context.Background(), inv)
-	if execErr != nil {
-		fmt.Fprintln(os.Stderr, execErr)
-	}
-	os.Exit(result.ExitCode)
-}
\ No newline at end of file
+	os.Exit(cli.RunCommand(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,168 @@
+// Package analyze derives scheduling properties of a task graph from recorded
+// task durations.
+//
+// Durations are host timings, so the numbers are only as stable as the runs
+// they come from; the analysis itself is deterministic for given durations.
+package analyze
+
+import (
+	"math"
+	"sort"
+
+	"scriptweaver/internal/dag"
+)
+
+// DefaultConcurrency is the set of concurrency levels reported when none are
+// given.
+var DefaultConcurrency = []int{1, 2, 4, 8, 16}
+
+// CriticalPathReport is the result of CriticalPath.
+type CriticalPathReport struct {
+	// Path is the longest weighted path through the graph, in execution
+	// order. Ties are broken by task name.
+	Path []string `json:"critical_path"`
+	// LengthMillis is the summed duration of Path: the shortest possible
+	// wall time with unlimited concurrency.
+	LengthMillis int64 `json:"length_ms"`
+	// WorkMillis is the summed duration of every task: the wall time with a
+	// concurrency of one.
+	WorkMillis int64 `json:"work_ms"`
+	// Tasks is in topological order.
+	Tasks []TaskSlack `json:"tasks"`
+	// Speedup is in the order of the requested concurrency levels.
+	Speedup []Speedup `json:"speedup"`
+}
+
+// TaskSlack is one task's schedule with unlimited concurrency.
+type TaskSlack struct {
+	Task           string `json:"task"`
+	DurationMillis int64  `json:"duration_ms"`
+	// Samples is how many recorded runs the duration is based on; a task
+	// without samples is assumed to take no time.
+	Samples int `json:"samples"`
+	// EarliestStartMillis and LatestStartMillis bound when the task can
+	// start without lengthening the critical path.
+	EarliestStartMillis int64 `json:"earliest_start_ms"`
+	LatestStartMillis   int64 `json:"latest_start_ms"`
+	// SlackMillis is LatestStartMillis - EarliestStartMillis; zero on the
+	// critical path.
+	SlackMillis int64 `json:"slack_ms"`
+	Critical    bool  `json:"critical"`
+}
+
+// Speedup is the theoretical speedup over serial execution at one
+// concurrency level.
+//
+// No schedule can beat max(work / concurrency, critical path length), so
+// Speedup is work divided by that bound. Real runs are slower: the bound
+// ignores scheduling order and per-task overhead.
+type Speedup struct {
+	Concurrency int     `json:"concurrency"`
+	Speedup     float64 `json:"speedup"`
+}
+
+// Duration is a task's expected duration and how many samples it rests on.
+type Duration struct {
+	Millis  int64
+	Samples int
+}
+
+// CriticalPath computes the critical path, per-task slack and theoretical
+// speedups of g. Tasks missing from durations count as taking no time.
+func CriticalPath(g *dag.TaskGraph, durations map[string]Duration, concurrency []int) CriticalPathReport {
+	order := g.TopologicalOrder()
+	preds := make(map[string][]string, len(order))
+	succs := make(map[string][]string, len(order))
+	for _, e := range g.Edges() {
+		preds[e.To] = append(preds[e.To], e.From)
+		succs[e.From] = append(succs[e.From], e.To)
+	}
+
+	rep := CriticalPathReport{Tasks: make([]TaskSlack, 0, len(order)), Speedup: []Speedup{}, Path: []string{}}
+	earliest := make(map[string]int64, len(order))
+	finish := make(map[string]int64, len(order))
+	for _, name := range order {
+		var start int64
+		for _, p := range preds[name] {
+			start = max(start, finish[p])
+		}
+		earliest[name] = start
+		finish[name] = start + durations[name].Millis
+		rep.LengthMillis = max(rep.LengthMillis, finish[name])
+		rep.WorkMillis += durations[name].Millis
+	}
+
+	latest := make(map[string]int64, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		name := order[i]
+		end := rep.LengthMillis
+		for _, s := range succs[name] {
+			end = min(end, latest[s])
+		}
+		latest[name] = end - durations[name].Millis
+	}
+
+	for _, name := range order {
+		d := durations[name]
+		slack := latest[name] - earliest[name]
+		rep.Tasks = append(rep.Tasks, TaskSlack{
+			Task:                name,
+			DurationMillis:      d.Millis,
+			Samples:             d.Samples,
+			EarliestStartMillis: earliest[name],
+			LatestStartMillis:   latest[name],
+			SlackMillis:         slack,
+			Critical:            slack == 0,
+		})
+	}
+	rep.Path = criticalPath(order, preds, earliest, finish, rep.LengthMillis)
+
+	for _, c := range concurrency {
+		rep.Speedup = append(rep.Speedup, Speedup{Concurrency: c, Speedup: speedup(rep.WorkMillis, rep.LengthMillis, c)})
+	}
+	return rep
+}
+
+// criticalPath walks back from the first task (by name) finishing at length,
+// each step taking the first predecessor (by name) that finishes exactly when
+// the current task can start.
+func criticalPath(order []string, preds map[string][]string, earliest, finish map[string]int64, length int64) []string {
+	var last string
+	for _, name := range order {
+		if finish[name] == length && (last == "" || name < last) {
+			last = name
+		}
+	}
+	if last == "" {
+		return []string{}
+	}
+	path := []string{last}
+	for cur := last; ; {
+		ps := append([]string(nil), preds[cur]...)
+		sort.Strings(ps)
+		next := ""
+		for _, p := range ps {
+			if finish[p] == earliest[cur] {
+				next = p
+				break
+			}
+		}
+		if next == "" {
+			break
+		}
+		path = append(path, next)
+		cur = next
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+func speedup(work, length int64, concurrency int) float64 {
+	if work == 0 || concurrency < 1 {
+		return 1
+	}
+	bound := math.Max(float64(work)/float64(concurrency), float64(length))
+	return round4(float64(work) / bound)
+}
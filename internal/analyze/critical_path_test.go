@@ -0,0 +1,68 @@
+package analyze
+
+import (
+	"reflect"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func TestCriticalPath_DiamondGraph(t *testing.T) {
+	// a -> b -> d, a -> c -> d; b is the long branch, e is independent.
+	g, err := dag.NewTaskGraph(
+		[]core.Task{{Name: "a", Run: "x"}, {Name: "b", Run: "x"}, {Name: "c", Run: "x"}, {Name: "d", Run: "x"}, {Name: "e", Run: "x"}},
+		[]dag.Edge{{From: "a", To: "b"}, {From: "a", To: "c"}, {From: "b", To: "d"}, {From: "c", To: "d"}},
+	)
+	if err != nil {
+		t.Fatalf("NewTaskGraph: %v", err)
+	}
+	durations := map[string]Duration{
+		"a": {Millis: 10, Samples: 3},
+		"b": {Millis: 50, Samples: 3},
+		"c": {Millis: 20, Samples: 3},
+		"d": {Millis: 10, Samples: 3},
+	}
+
+	rep := CriticalPath(g, durations, []int{1, 2, 100})
+
+	if want := []string{"a", "b", "d"}; !reflect.DeepEqual(rep.Path, want) {
+		t.Fatalf("path = %v, want %v", rep.Path, want)
+	}
+	if rep.LengthMillis != 70 || rep.WorkMillis != 90 {
+		t.Fatalf("length=%d work=%d, want 70 and 90", rep.LengthMillis, rep.WorkMillis)
+	}
+	slack := make(map[string]TaskSlack)
+	for _, ts := range rep.Tasks {
+		slack[ts.Task] = ts
+	}
+	if c := slack["c"]; c.EarliestStartMillis != 10 || c.LatestStartMillis != 40 || c.SlackMillis != 30 || c.Critical {
+		t.Fatalf("unexpected schedule for c: %+v", c)
+	}
+	if e := slack["e"]; e.SlackMillis != 70 || e.Samples != 0 {
+		t.Fatalf("unexpected schedule for e: %+v", e)
+	}
+	for _, name := range []string{"a", "b", "d"} {
+		if !slack[name].Critical {
+			t.Fatalf("%s should be critical: %+v", name, slack[name])
+		}
+	}
+	want := []Speedup{{Concurrency: 1, Speedup: 1}, {Concurrency: 2, Speedup: 1.2857}, {Concurrency: 100, Speedup: 1.2857}}
+	if !reflect.DeepEqual(rep.Speedup, want) {
+		t.Fatalf("speedup = %+v, want %+v", rep.Speedup, want)
+	}
+}
+
+func TestCriticalPath_NoDurations(t *testing.T) {
+	g, err := dag.NewTaskGraph([]core.Task{{Name: "b", Run: "x"}, {Name: "a", Run: "x"}}, nil)
+	if err != nil {
+		t.Fatalf("NewTaskGraph: %v", err)
+	}
+	rep := CriticalPath(g, nil, []int{4})
+	if !reflect.DeepEqual(rep.Path, []string{"a"}) || rep.LengthMillis != 0 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	if rep.Speedup[0].Speedup != 1 {
+		t.Fatalf("speedup without work should be 1: %+v", rep.Speedup)
+	}
+}
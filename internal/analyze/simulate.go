@@ -0,0 +1,88 @@
+package analyze
+
+import (
+	"math"
+	"sort"
+
+	"scriptweaver/internal/dag"
+)
+
+// SimulationReport is the result of Simulate.
+type SimulationReport struct {
+	// WorkMillis is the summed duration of every task.
+	WorkMillis int64 `json:"work_ms"`
+	// Levels is in the order of the requested concurrency levels.
+	Levels []SimulatedRun `json:"levels"`
+}
+
+// SimulatedRun is the projected outcome of a run at one concurrency level.
+type SimulatedRun struct {
+	Concurrency int `json:"concurrency"`
+	// MakespanMillis is the projected wall time of the run.
+	MakespanMillis int64 `json:"makespan_ms"`
+	// Speedup is WorkMillis / MakespanMillis.
+	Speedup float64 `json:"speedup"`
+	// Utilization is the share of worker time spent running tasks.
+	Utilization float64 `json:"utilization"`
+}
+
+// Simulate projects the wall time of running g at each concurrency level,
+// scheduling tasks exactly as dag.Executor.RunParallel does: in stages of
+// increasing topological depth, each stage finishing before the next starts,
+// and within a stage in lexical order, each task taking the first worker to
+// become free. Tasks missing from durations count as taking no time, and
+// every task is assumed to execute (no cache hits, skips or failures).
+func Simulate(g *dag.TaskGraph, durations map[string]Duration, concurrency []int) SimulationReport {
+	var stages [][]string
+	rep := SimulationReport{Levels: []SimulatedRun{}}
+	for _, name := range g.TopologicalOrder() {
+		depth, _ := g.Depth(name)
+		for len(stages) <= depth {
+			stages = append(stages, nil)
+		}
+		stages[depth] = append(stages[depth], name)
+		rep.WorkMillis += durations[name].Millis
+	}
+	for _, names := range stages {
+		sort.Strings(names)
+	}
+
+	for _, c := range concurrency {
+		if c < 1 {
+			continue
+		}
+		run := SimulatedRun{Concurrency: c, MakespanMillis: simulateStages(stages, durations, c), Speedup: 1, Utilization: 1}
+		if run.MakespanMillis > 0 {
+			run.Speedup = round4(float64(rep.WorkMillis) / float64(run.MakespanMillis))
+			run.Utilization = round4(float64(rep.WorkMillis) / float64(int64(c)*run.MakespanMillis))
+		}
+		rep.Levels = append(rep.Levels, run)
+	}
+	return rep
+}
+
+// simulateStages returns the summed makespan of the stages at concurrency c.
+func simulateStages(stages [][]string, durations map[string]Duration, c int) int64 {
+	var total int64
+	free := make([]int64, c)
+	for _, names := range stages {
+		clear(free)
+		var end int64
+		for _, name := range names {
+			w := 0
+			for i := range free {
+				if free[i] < free[w] {
+					w = i
+				}
+			}
+			free[w] += durations[name].Millis
+			end = max(end, free[w])
+		}
+		total += end
+	}
+	return total
+}
+
+func round4(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
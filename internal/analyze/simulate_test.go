@@ -0,0 +1,47 @@
+package analyze
+
+import (
+	"reflect"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func TestSimulate_DepthStagedSchedule(t *testing.T) {
+	// Stages: {a, e}, {b, c}, {d}.
+	g, err := dag.NewTaskGraph(
+		[]core.Task{{Name: "a", Run: "x"}, {Name: "b", Run: "x"}, {Name: "c", Run: "x"}, {Name: "d", Run: "x"}, {Name: "e", Run: "x"}},
+		[]dag.Edge{{From: "a", To: "b"}, {From: "a", To: "c"}, {From: "b", To: "d"}, {From: "c", To: "d"}},
+	)
+	if err != nil {
+		t.Fatalf("NewTaskGraph: %v", err)
+	}
+	durations := map[string]Duration{"a": {Millis: 10}, "b": {Millis: 50}, "c": {Millis: 20}, "d": {Millis: 10}, "e": {Millis: 30}}
+
+	rep := Simulate(g, durations, []int{1, 2, 4})
+
+	want := SimulationReport{WorkMillis: 120, Levels: []SimulatedRun{
+		{Concurrency: 1, MakespanMillis: 120, Speedup: 1, Utilization: 1},
+		// e overlaps a, c overlaps b, but each stage waits for its slowest task.
+		{Concurrency: 2, MakespanMillis: 90, Speedup: 1.3333, Utilization: 0.6667},
+		{Concurrency: 4, MakespanMillis: 90, Speedup: 1.3333, Utilization: 0.3333},
+	}}
+	if !reflect.DeepEqual(rep, want) {
+		t.Fatalf("report = %+v\nwant %+v", rep, want)
+	}
+}
+
+func TestSimulate_DispatchesInLexicalOrder(t *testing.T) {
+	g, err := dag.NewTaskGraph([]core.Task{{Name: "z", Run: "x"}, {Name: "y", Run: "x"}, {Name: "x", Run: "x"}}, nil)
+	if err != nil {
+		t.Fatalf("NewTaskGraph: %v", err)
+	}
+	durations := map[string]Duration{"x": {Millis: 10}, "y": {Millis: 10}, "z": {Millis: 30}}
+
+	// x and y start first; z waits for a free worker, as RunParallel would.
+	rep := Simulate(g, durations, []int{2})
+	if got := rep.Levels[0].MakespanMillis; got != 40 {
+		t.Fatalf("makespan = %d, want 40", got)
+	}
+}
@@ -0,0 +1,151 @@
+// Package attest builds in-toto statements carrying SLSA build provenance
+// for task artifacts.
+//
+// Statements contain only deterministic facts already known to the engine
+// (task hash, input and artifact digests, the command and the graph hash),
+// so the same task result always yields byte-identical statements. No
+// timestamps, hostnames or run IDs are recorded.
+package attest
+
+import (
+	"encoding/json"
+	"sort"
+
+	"scriptweaver/internal/core"
+)
+
+const (
+	// StatementType is the in-toto Statement v1 type.
+	StatementType = "https://in-toto.io/Statement/v1"
+
+	// PredicateType is the SLSA Provenance v1 predicate type.
+	PredicateType = "https://slsa.dev/provenance/v1"
+
+	// BuildType identifies a ScriptWeaver task build; its external
+	// parameters are the task definition and graph hash.
+	BuildType = "https://github.com/samgonzalez27/script-weaver/task@v1"
+
+	// BuilderID identifies ScriptWeaver as the builder.
+	BuilderID = "https://github.com/samgonzalez27/script-weaver"
+)
+
+// Statement is an in-toto v1 statement.
+type Statement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     Provenance           `json:"predicate"`
+}
+
+// ResourceDescriptor names a file and its digests (in-toto v1).
+type ResourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Provenance is the SLSA v1 provenance predicate.
+type Provenance struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   ExternalParameters   `json:"externalParameters"`
+	InternalParameters   InternalParameters   `json:"internalParameters"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies"`
+}
+
+// ExternalParameters are the task definition fields that determine the build.
+type ExternalParameters struct {
+	Task      string            `json:"task"`
+	Command   string            `json:"command"`
+	Env       map[string]string `json:"env,omitempty"`
+	Image     string            `json:"image,omitempty"`
+	Outputs   []string          `json:"outputs"`
+	GraphHash string            `json:"graphHash"`
+}
+
+// InternalParameters are values ScriptWeaver derived from the definition.
+type InternalParameters struct {
+	TaskHash string `json:"taskHash"`
+}
+
+type RunDetails struct {
+	Builder Builder `json:"builder"`
+}
+
+type Builder struct {
+	ID string `json:"id"`
+}
+
+// Digest is a file path (slash-separated, relative to the working
+// directory) and its hex SHA-256.
+type Digest struct {
+	Path   string
+	SHA256 string
+}
+
+// TaskBuild is everything a task's statement records.
+type TaskBuild struct {
+	Task      core.Task
+	TaskHash  string
+	GraphHash string
+	Inputs    []Digest
+	Artifacts []Digest
+}
+
+// NewStatement returns the statement for b, with subjects and dependencies
+// sorted by path.
+func NewStatement(b TaskBuild) Statement {
+	outputs := append([]string{}, b.Task.Outputs...)
+	sort.Strings(outputs)
+	return Statement{
+		Type:          StatementType,
+		Subject:       descriptors(b.Artifacts, false),
+		PredicateType: PredicateType,
+		Predicate: Provenance{
+			BuildDefinition: BuildDefinition{
+				BuildType: BuildType,
+				ExternalParameters: ExternalParameters{
+					Task:      b.Task.Name,
+					Command:   b.Task.Run,
+					Env:       b.Task.Env,
+					Image:     b.Task.Image,
+					Outputs:   outputs,
+					GraphHash: b.GraphHash,
+				},
+				InternalParameters:   InternalParameters{TaskHash: b.TaskHash},
+				ResolvedDependencies: descriptors(b.Inputs, true),
+			},
+			RunDetails: RunDetails{Builder: Builder{ID: BuilderID}},
+		},
+	}
+}
+
+func descriptors(digests []Digest, asURI bool) []ResourceDescriptor {
+	sorted := append([]Digest{}, digests...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+	out := make([]ResourceDescriptor, 0, len(sorted))
+	for _, d := range sorted {
+		rd := ResourceDescriptor{Digest: map[string]string{"sha256": d.SHA256}}
+		if asURI {
+			rd.URI = d.Path
+		} else {
+			rd.Name = d.Path
+		}
+		out = append(out, rd)
+	}
+	return out
+}
+
+// Marshal encodes s deterministically: fixed field order, sorted map keys
+// and a trailing newline.
+func (s Statement) Marshal() ([]byte, error) {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
@@ -0,0 +1,47 @@
+package attest
+
+import (
+	"bytes"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+func TestNewStatement_SortsAndIsStable(t *testing.T) {
+	b := TaskBuild{
+		Task:      core.Task{Name: "t", Run: "make", Outputs: []string{"z", "a"}, Env: map[string]string{"B": "2", "A": "1"}},
+		TaskHash:  "th",
+		GraphHash: "gh",
+		Inputs:    []Digest{{Path: "src/b", SHA256: "2"}, {Path: "src/a", SHA256: "1"}},
+		Artifacts: []Digest{{Path: "z/out", SHA256: "4"}, {Path: "a/out", SHA256: "3"}},
+	}
+	s := NewStatement(b)
+	if s.Subject[0].Name != "a/out" || s.Subject[1].Name != "z/out" {
+		t.Fatalf("subjects not sorted: %+v", s.Subject)
+	}
+	deps := s.Predicate.BuildDefinition.ResolvedDependencies
+	if deps[0].URI != "src/a" || deps[1].URI != "src/b" {
+		t.Fatalf("dependencies not sorted: %+v", deps)
+	}
+	if got := s.Predicate.BuildDefinition.ExternalParameters.Outputs; got[0] != "a" || got[1] != "z" {
+		t.Fatalf("outputs not sorted: %v", got)
+	}
+	if b.Task.Outputs[0] != "z" {
+		t.Fatalf("NewStatement must not reorder the task's outputs")
+	}
+
+	// Reordering the inputs must not change the encoding.
+	b.Inputs[0], b.Inputs[1] = b.Inputs[1], b.Inputs[0]
+	b.Artifacts[0], b.Artifacts[1] = b.Artifacts[1], b.Artifacts[0]
+	x, err := s.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	y, err := NewStatement(b).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(x, y) {
+		t.Fatalf("encoding depends on input order:\n%s\n%s", x, y)
+	}
+}
@@ -0,0 +1,253 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// errNothingAffected is returned by CLIInvocation.loadGraphAndHash when
+// --changed-files affects no task, so there is nothing to run.
+var errNothingAffected = errors.New("no task is affected by the changed files")
+
+// AffectedInvocation is the canonical form of `scriptweaver affected`.
+type AffectedInvocation struct {
+	WorkDir   string
+	GraphPath string
+	Params    map[string]string
+	// NormalizeRules come from scriptweaver.toml, as for a run.
+	NormalizeRules []core.NormalizeRule
+	// ChangedFiles is the file listing changed paths, one per line.
+	ChangedFiles string
+}
+
+// AffectedReport is what `scriptweaver affected` prints.
+type AffectedReport struct {
+	// ChangedFiles are the listed paths, relative to the working directory
+	// where possible, in the order given.
+	ChangedFiles []string `json:"changed_files"`
+	// Direct lists the tasks whose inputs match a changed file.
+	Direct []string `json:"direct"`
+	// Affected is Direct plus every task downstream of it. Both lists are in
+	// topological order.
+	Affected []string `json:"affected"`
+}
+
+// ParseAffectedInvocation parses the flags following `affected`. The graph
+// flags behave as for validate.
+func ParseAffectedInvocation(args []string) (AffectedInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver affected", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	var graphPath string
+	var changedFiles string
+	params := paramFlags{}
+
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+	fs.StringVar(&graphPath, "graph", "", "Graph source path. Required.")
+	fs.Var(params, "param", "Graph parameter key=value (repeatable).")
+	fs.StringVar(&changedFiles, "changed-files", "", "File listing changed paths, one per line, relative to --workdir. Required.")
+
+	if err := fs.Parse(args); err != nil {
+		return AffectedInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 0 {
+		return AffectedInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return AffectedInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return AffectedInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	cfg, err := applyConfig(fs, workDir, params)
+	if err != nil {
+		return AffectedInvocation{}, err
+	}
+	if graphPath == "" {
+		return AffectedInvocation{}, invalidInvocationf("--graph is required")
+	}
+	resolvedGraph, err := resolveUnderWorkDir(workDir, graphPath)
+	if err != nil {
+		return AffectedInvocation{}, err
+	}
+	if changedFiles == "" {
+		return AffectedInvocation{}, invalidInvocationf("--changed-files is required")
+	}
+	resolvedChanged, err := resolveUnderWorkDir(workDir, changedFiles)
+	if err != nil {
+		return AffectedInvocation{}, err
+	}
+	inv := AffectedInvocation{WorkDir: workDir, GraphPath: resolvedGraph, NormalizeRules: cfg.Normalize, ChangedFiles: resolvedChanged}
+	if len(params) > 0 {
+		inv.Params = params
+	}
+	return inv, nil
+}
+
+// Affected loads the graph and writes the tasks affected by the changed
+// files to stdout as JSON. Nothing is hashed or executed.
+func Affected(inv AffectedInvocation, stdout io.Writer) (int, error) {
+	g, _, err := loadGraphAndHash(inv.GraphPath, inv.Params, inv.NormalizeRules...)
+	if err != nil {
+		var invErr *InvocationError
+		if errors.As(err, &invErr) && invErr.ExitCode != 0 {
+			return invErr.ExitCode, err
+		}
+		return ExitConfigError, err
+	}
+	report, err := affectedTasks(g, inv.WorkDir, inv.ChangedFiles)
+	if err != nil {
+		return ExitCode(err), err
+	}
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return ExitInternalError, err
+	}
+	if _, err := fmt.Fprintln(stdout, string(b)); err != nil {
+		return ExitInternalError, err
+	}
+	return ExitSuccess, nil
+}
+
+// readChangedFiles reads one path per line, ignoring blank lines. Relative
+// paths are made relative to workDir's cleaned form; paths outside it are
+// kept as given.
+func readChangedFiles(workDir, path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, &InvocationError{ExitCode: ExitConfigError, Message: fmt.Sprintf("--changed-files: %v", err)}
+	}
+	defer f.Close()
+	changed := []string{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		p := filepath.Clean(filepath.FromSlash(line))
+		if filepath.IsAbs(p) {
+			if rel, err := filepath.Rel(workDir, p); err == nil && isWithin(workDir, p) {
+				p = rel
+			}
+		}
+		changed = append(changed, filepath.ToSlash(p))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, &InvocationError{ExitCode: ExitConfigError, Message: fmt.Sprintf("--changed-files: %v", err)}
+	}
+	return changed, nil
+}
+
+// affectedTasks maps the paths listed in changedFiles to the tasks whose
+// input patterns match them (see core.InputMatches) and adds everything
+// downstream of those.
+func affectedTasks(g *dag.TaskGraph, workDir, changedFiles string) (AffectedReport, error) {
+	changed, err := readChangedFiles(workDir, changedFiles)
+	if err != nil {
+		return AffectedReport{}, err
+	}
+	report := AffectedReport{ChangedFiles: changed, Direct: []string{}, Affected: []string{}}
+	var direct []string
+	for _, name := range g.TopologicalOrder() {
+		node, _ := g.Node(name)
+		for _, path := range changed {
+			ok, err := core.InputMatches(workDir, node.Task.Inputs, filepath.FromSlash(path))
+			if err != nil {
+				return AffectedReport{}, &InvocationError{ExitCode: ExitConfigError, Message: fmt.Sprintf("task %q: %v", name, err)}
+			}
+			if ok {
+				direct = append(direct, name)
+				break
+			}
+		}
+	}
+	closure := downstreamClosure(g, direct...)
+	for _, name := range g.TopologicalOrder() {
+		if closure[name] {
+			report.Affected = append(report.Affected, name)
+		}
+	}
+	report.Direct = append(report.Direct, direct...)
+	return report, nil
+}
+
+// narrowToAffected returns the subgraph of g made of the affected tasks and
+// everything upstream of them, which they need to run. Upstream tasks that
+// are not affected have unchanged inputs and are normally restored from the
+// cache.
+func narrowToAffected(g *dag.TaskGraph, affected []string) (*dag.TaskGraph, error) {
+	upstream := make(map[string][]string)
+	for _, e := range g.Edges() {
+		upstream[e.To] = append(upstream[e.To], e.From)
+	}
+	keep := make(map[string]bool)
+	for _, name := range affected {
+		keep[name] = true
+		for up := range reachable(upstream, name) {
+			keep[up] = true
+		}
+	}
+	var tasks []core.Task
+	for _, n := range g.Nodes() {
+		if keep[n.Name] {
+			tasks = append(tasks, n.Task)
+		}
+	}
+	var edges []dag.Edge
+	for _, e := range g.Edges() {
+		if keep[e.From] && keep[e.To] {
+			edges = append(edges, e)
+		}
+	}
+	return dag.NewTaskGraph(tasks, edges)
+}
+
+// loadGraphAndHash loads inv's graph, through the warm state, and narrows it
+// to the tasks affected by --changed-files when that is set. It returns
+// errNothingAffected when no task is.
+func (inv CLIInvocation) loadGraphAndHash() (*dag.TaskGraph, string, error) {
+	g, graphHash, err := inv.warm.loadGraphAndHash(inv.GraphPath, inv.Params, inv.NormalizeRules...)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := checkWorkDirPaths(g, inv.WorkDir, inv.AllowOutsideWorkDir); err != nil {
+		return nil, "", err
+	}
+	if inv.ChangedFiles == "" {
+		return g, graphHash, nil
+	}
+	report, err := affectedTasks(g, inv.WorkDir, inv.ChangedFiles)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(report.Affected) == 0 {
+		return nil, "", errNothingAffected
+	}
+	g, err = narrowToAffected(g, report.Affected)
+	if err != nil {
+		return nil, "", err
+	}
+	return g, g.Hash().String(), nil
+}
+
+func runAffected(args []string, stdout io.Writer) (int, error) {
+	inv, err := ParseAffectedInvocation(args)
+	if err != nil {
+		return ExitCode(err), err
+	}
+	return Affected(inv, stdout)
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func writeAffectedFixture(t *testing.T) (workDir, graphPath string) {
+	t.Helper()
+	workDir = t.TempDir()
+	for _, f := range []string{"src/a/one.txt", "src/b.txt"} {
+		p := filepath.Join(workDir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(f), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	graphPath = filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{
+		{Name: "a", Inputs: []string{"src/a/*.txt"}, Run: "echo a"},
+		{Name: "b", Inputs: []string{"src/b.txt"}, Run: "echo b"},
+		{Name: "c", Run: "echo c"},
+		{Name: "d", Run: "echo d"},
+	}, []dag.Edge{{From: "a", To: "c"}, {From: "b", To: "c"}, {From: "b", To: "d"}})
+	return workDir, graphPath
+}
+
+func TestAffected_ReportsDownstreamClosure(t *testing.T) {
+	workDir, _ := writeAffectedFixture(t)
+	changed := filepath.Join(workDir, "changed.txt")
+	// A deleted file still matches the pattern that used to resolve it.
+	if err := os.WriteFile(changed, []byte("src/a/gone.txt\n\nunrelated.md\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	code, handled, err := Dispatch([]string{"affected", "--workdir", workDir, "--graph", "graph.json", "--changed-files", "changed.txt"}, &out)
+	if !handled || err != nil || code != ExitSuccess {
+		t.Fatalf("affected: handled=%v code=%d err=%v", handled, code, err)
+	}
+	var rep AffectedReport
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out.String())
+	}
+	want := AffectedReport{ChangedFiles: []string{"src/a/gone.txt", "unrelated.md"}, Direct: []string{"a"}, Affected: []string{"a", "c"}}
+	if !reflect.DeepEqual(rep, want) {
+		t.Fatalf("report = %+v, want %+v", rep, want)
+	}
+}
+
+func TestExecute_ChangedFilesRunsOnlyAffectedTasks(t *testing.T) {
+	workDir, graphPath := writeAffectedFixture(t)
+	changed := filepath.Join(workDir, "changed.txt")
+	if err := os.WriteFile(changed, []byte("src/a/one.txt\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	inv := CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+		ChangedFiles:  changed,
+	}
+
+	res, err := Execute(context.Background(), inv)
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("exit=%d err=%v", res.ExitCode, err)
+	}
+	// c needs b, so b runs too; d is neither affected nor needed.
+	if got := res.GraphResult.ExecutionOrder; !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Fatalf("execution order = %v", got)
+	}
+
+	if err := os.WriteFile(changed, []byte("unrelated.md\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = Execute(context.Background(), inv)
+	if err != nil || res.ExitCode != ExitSuccess || res.GraphResult != nil {
+		t.Fatalf("nothing affected: exit=%d err=%v result=%+v", res.ExitCode, err, res.GraphResult)
+	}
+
+	inv.DryRun = true
+	res, err = Execute(context.Background(), inv)
+	if err != nil || res.DryRun == nil || len(res.DryRun.Tasks) != 0 {
+		t.Fatalf("dry run with nothing affected: %+v err=%v", res.DryRun, err)
+	}
+}
+
+func TestParseInvocation_ChangedFiles(t *testing.T) {
+	base := []string{"--workdir", "/w", "--graph", "g.json", "--cache-dir", "c", "--output-dir", "o"}
+	inv, err := ParseInvocation(append(base, "--changed-files", "changed.txt"))
+	if err != nil {
+		t.Fatalf("ParseInvocation: %v", err)
+	}
+	if inv.ChangedFiles != filepath.Join("/w", "changed.txt") {
+		t.Fatalf("ChangedFiles = %q", inv.ChangedFiles)
+	}
+	if _, err := ParseInvocation(append(base, "--changed-files", "changed.txt", "--verify-cache")); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected invalid invocation with --verify-cache, got %v", err)
+	}
+}
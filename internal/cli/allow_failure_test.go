@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+	"scriptweaver/internal/trace"
+)
+
+func TestExecute_AllowedFailureIsCheckpointedAndResumed(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "flag"), []byte("no\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "lint", Run: "echo lint >> lint.log; exit 1", AllowFailure: true},
+		{Name: "build", Inputs: []string{"flag"}, Run: "grep -q yes flag"},
+	}, []dag.Edge{{From: "lint", To: "build"}})
+	run := func() CLIResult {
+		t.Helper()
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--trace", "trace.json"})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err != nil && res.ExitCode != ExitGraphFailure {
+			t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+		}
+		return res
+	}
+
+	// build fails on its own; lint's failure is allowed and checkpointed.
+	if res := run(); res.ExitCode != ExitGraphFailure || res.GraphResult.FinalState["lint"] != dag.TaskFailedAllowed {
+		t.Fatalf("first run: exit %d", res.ExitCode)
+	}
+	st, _ := state.NewStore(workDir)
+	ids, _ := st.ListRunIDs()
+	checkpoints, err := st.LoadAllCheckpoints(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := checkpoints["lint"]; !ok {
+		t.Fatalf("expected a checkpoint for the allowed failure, got %v", checkpoints)
+	}
+
+	// The resumed run replays lint's failure instead of re-running it.
+	if err := os.WriteFile(filepath.Join(workDir, "flag"), []byte("yes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if res := run(); res.ExitCode != ExitSuccess {
+		t.Fatalf("resumed run: exit %d", res.ExitCode)
+	}
+	b, err := os.ReadFile(filepath.Join(workDir, "lint.log"))
+	if err != nil || string(b) != "lint\n" {
+		t.Fatalf("lint must run once, log=%q err=%v", b, err)
+	}
+	tr, err := os.ReadFile(filepath.Join(workDir, "trace.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := trace.ParseExecutionTrace(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var allowed bool
+	for _, ev := range parsed.Events {
+		if ev.TaskID == "lint" && ev.Kind == trace.EventTaskFailed && ev.Reason == trace.ReasonAllowedFailure {
+			allowed = true
+		}
+	}
+	if !allowed {
+		t.Fatalf("expected lint traced as an allowed failure: %s", tr)
+	}
+}
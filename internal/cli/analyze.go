@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"scriptweaver/internal/analyze"
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+	"scriptweaver/internal/stats"
+)
+
+// AnalyzeInvocation is the canonical form of `scriptweaver analyze
+// critical-path` and `scriptweaver analyze simulate`.
+type AnalyzeInvocation struct {
+	WorkDir   string
+	GraphPath string
+	Params    map[string]string
+	// NormalizeRules come from scriptweaver.toml, as for a run.
+	NormalizeRules []core.NormalizeRule
+	// Since is the number of most recent runs whose durations are used; 0
+	// means all runs.
+	Since int
+	// Concurrency lists the levels to report, ascending.
+	Concurrency []int
+}
+
+// ParseAnalyzeInvocation parses the flags following `analyze <command>`. The
+// graph flags behave as for validate.
+func ParseAnalyzeInvocation(command string, args []string) (AnalyzeInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver analyze "+command, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	var graphPath string
+	var since int
+	var concurrency string
+	params := paramFlags{}
+
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+	fs.StringVar(&graphPath, "graph", "", "Graph source path. Required.")
+	fs.Var(params, "param", "Graph parameter key=value (repeatable).")
+	fs.IntVar(&since, "since", 0, "Use durations from the N most recent runs (0 = all runs).")
+	fs.StringVar(&concurrency, "concurrency", "", "Comma-separated concurrency levels to report (default 1,2,4,8,16).")
+
+	if err := fs.Parse(args); err != nil {
+		return AnalyzeInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 0 {
+		return AnalyzeInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return AnalyzeInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return AnalyzeInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	cfg, err := applyConfig(fs, workDir, params)
+	if err != nil {
+		return AnalyzeInvocation{}, err
+	}
+	if graphPath == "" {
+		return AnalyzeInvocation{}, invalidInvocationf("--graph is required")
+	}
+	resolvedGraph, err := resolveUnderWorkDir(workDir, graphPath)
+	if err != nil {
+		return AnalyzeInvocation{}, err
+	}
+	if since < 0 {
+		return AnalyzeInvocation{}, invalidInvocationf("--since must be >= 0 (got %d)", since)
+	}
+	levels, err := parseConcurrencyLevels(concurrency)
+	if err != nil {
+		return AnalyzeInvocation{}, err
+	}
+
+	inv := AnalyzeInvocation{WorkDir: workDir, GraphPath: resolvedGraph, NormalizeRules: cfg.Normalize, Since: since, Concurrency: levels}
+	if len(params) > 0 {
+		inv.Params = params
+	}
+	return inv, nil
+}
+
+// parseConcurrencyLevels parses a comma-separated list of positive levels
+// into ascending order without duplicates.
+func parseConcurrencyLevels(s string) ([]int, error) {
+	if strings.TrimSpace(s) == "" {
+		return append([]int(nil), analyze.DefaultConcurrency...), nil
+	}
+	seen := make(map[int]bool)
+	var levels []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 1 {
+			return nil, invalidInvocationf("invalid --concurrency level %q (expected a positive integer)", part)
+		}
+		if !seen[n] {
+			seen[n] = true
+			levels = append(levels, n)
+		}
+	}
+	sort.Ints(levels)
+	return levels, nil
+}
+
+// recordedDurations loads the graph and takes each task's median executed
+// duration from recorded runs (see stats.Collect).
+func recordedDurations(inv AnalyzeInvocation) (*dag.TaskGraph, map[string]analyze.Duration, int, error) {
+	g, _, err := loadGraphAndHash(inv.GraphPath, inv.Params, inv.NormalizeRules...)
+	if err != nil {
+		var invErr *InvocationError
+		if errors.As(err, &invErr) && invErr.ExitCode != 0 {
+			return nil, nil, invErr.ExitCode, err
+		}
+		return nil, nil, ExitConfigError, err
+	}
+	st, err := state.NewStore(inv.WorkDir)
+	if err != nil {
+		return nil, nil, ExitInvalidInvocation, err
+	}
+	recorded, err := stats.Collect(st, inv.Since)
+	if err != nil {
+		return nil, nil, ExitConfigError, err
+	}
+	durations := make(map[string]analyze.Duration, len(recorded.Tasks))
+	for _, ts := range recorded.Tasks {
+		durations[ts.Task] = analyze.Duration{Millis: ts.Duration.P50, Samples: ts.Duration.Samples}
+	}
+	return g, durations, ExitSuccess, nil
+}
+
+// CriticalPath writes the critical path analysis of the graph, weighted by
+// recorded durations, to stdout as JSON.
+func CriticalPath(inv AnalyzeInvocation, stdout io.Writer) (int, error) {
+	g, durations, code, err := recordedDurations(inv)
+	if err != nil {
+		return code, err
+	}
+	return writeAnalysis(stdout, analyze.CriticalPath(g, durations, inv.Concurrency))
+}
+
+// Simulate writes the projected makespan of running the graph at each
+// concurrency level, using recorded durations, to stdout as JSON. Nothing is
+// executed.
+func Simulate(inv AnalyzeInvocation, stdout io.Writer) (int, error) {
+	g, durations, code, err := recordedDurations(inv)
+	if err != nil {
+		return code, err
+	}
+	return writeAnalysis(stdout, analyze.Simulate(g, durations, inv.Concurrency))
+}
+
+func writeAnalysis(stdout io.Writer, report any) (int, error) {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return ExitInternalError, err
+	}
+	if _, err := fmt.Fprintln(stdout, string(b)); err != nil {
+		return ExitInternalError, err
+	}
+	return ExitSuccess, nil
+}
+
+func runAnalyze(args []string, stdout io.Writer) (int, error) {
+	if len(args) == 0 || (args[0] != "critical-path" && args[0] != "simulate") {
+		return ExitInvalidInvocation, invalidInvocationf("usage: scriptweaver analyze critical-path|simulate --workdir <dir> --graph <path> [--param k=v] [--since <n runs>] [--concurrency 1,2,4]")
+	}
+	inv, err := ParseAnalyzeInvocation(args[0], args[1:])
+	if err != nil {
+		return ExitCode(err), err
+	}
+	if args[0] == "simulate" {
+		return Simulate(inv, stdout)
+	}
+	return CriticalPath(inv, stdout)
+}
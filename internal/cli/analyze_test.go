@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"scriptweaver/internal/analyze"
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func TestAnalyzeCriticalPath_UsesRecordedRuns(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{{Name: "a", Run: "echo a"}, {Name: "b", Run: "echo b"}, {Name: "c", Run: "echo c"}}, []dag.Edge{{From: "a", To: "b"}})
+
+	res, err := Execute(context.Background(), CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	})
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("exit=%d err=%v", res.ExitCode, err)
+	}
+
+	var out bytes.Buffer
+	code, handled, err := Dispatch([]string{"analyze", "critical-path", "--workdir", workDir, "--graph", "graph.json", "--concurrency", "4,1"}, &out)
+	if !handled || err != nil || code != ExitSuccess {
+		t.Fatalf("analyze: handled=%v code=%d err=%v", handled, code, err)
+	}
+	var rep analyze.CriticalPathReport
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out.String())
+	}
+	if len(rep.Tasks) != 3 || len(rep.Speedup) != 2 || rep.Speedup[0].Concurrency != 1 || rep.Speedup[1].Concurrency != 4 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	for _, ts := range rep.Tasks {
+		if ts.Samples != 1 {
+			t.Fatalf("task %s should have one sample: %+v", ts.Task, ts)
+		}
+	}
+}
+
+func TestParseAnalyzeInvocation_Validation(t *testing.T) {
+	for _, args := range [][]string{
+		{"--workdir", "/abs"},
+		{"--workdir", "relative", "--graph", "g.json"},
+		{"--workdir", "/abs", "--graph", "g.json", "--since", "-1"},
+		{"--workdir", "/abs", "--graph", "g.json", "--concurrency", "0"},
+		{"--workdir", "/abs", "--graph", "g.json", "--concurrency", "two"},
+	} {
+		if _, err := ParseAnalyzeInvocation("critical-path", args); ExitCode(err) != ExitInvalidInvocation {
+			t.Fatalf("args %q: expected invalid invocation, got %v", args, err)
+		}
+	}
+	inv, err := ParseAnalyzeInvocation("critical-path", []string{"--workdir", "/abs", "--graph", "g.json", "--concurrency", "8, 2,8"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(inv.Concurrency, []int{2, 8}) {
+		t.Fatalf("concurrency = %v", inv.Concurrency)
+	}
+}
+
+func TestAnalyzeSimulate_ProjectsMakespan(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{{Name: "a", Run: "echo a"}, {Name: "b", Run: "echo b"}}, nil)
+
+	var out bytes.Buffer
+	code, handled, err := Dispatch([]string{"analyze", "simulate", "--workdir", workDir, "--graph", "graph.json", "--concurrency", "1,2"}, &out)
+	if !handled || err != nil || code != ExitSuccess {
+		t.Fatalf("simulate: handled=%v code=%d err=%v", handled, code, err)
+	}
+	var rep analyze.SimulationReport
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out.String())
+	}
+	// No recorded runs: every task counts as taking no time.
+	if rep.WorkMillis != 0 || len(rep.Levels) != 2 || rep.Levels[1].Concurrency != 2 || rep.Levels[1].MakespanMillis != 0 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "out")); !os.IsNotExist(err) {
+		t.Fatalf("simulate must not execute anything: %v", err)
+	}
+}
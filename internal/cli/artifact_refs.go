@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// artifactRef matches "${<task>:<artifact>}", a reference to an artifact
+// declared by an upstream task. Param placeholders have no colon, so the two
+// never overlap. Shell expansions such as ${VAR:-default} match too, so a
+// match is only a reference when <task> names a task in the graph.
+var artifactRef = regexp.MustCompile(`\$\{([^}:]+):([^}]+)\}`)
+
+// applyArtifactRefs replaces artifact references in each task's run command,
+// inputs, env values, stdin and when operands with the artifact's path, and
+// adds an edge from the referenced task unless one is already declared.
+//
+// The artifact must match one of the referenced task's outputs: the same
+// path, a path under a declared output directory, or a path matching a
+// declared output glob. A reference to an undeclared artifact or to the
+// task itself fails the load, so renaming an output breaks the graph loudly
+// instead of leaving a stale path behind. A match whose task part names no
+// task is left for the shell. References are resolved within one graph
+// file, before a project manifest namespaces its task names.
+func applyArtifactRefs(gf graphFile) (graphFile, error) {
+	outputs := make(map[string][]string, len(gf.Tasks))
+	for _, t := range gf.Tasks {
+		outputs[t.Name] = t.Outputs
+	}
+
+	out := gf
+	out.Tasks = make([]core.Task, len(gf.Tasks))
+	out.Edges = append([]dag.Edge(nil), gf.Edges...)
+	have := make(map[dag.Edge]bool, len(out.Edges))
+	for _, e := range out.Edges {
+		have[e] = true
+	}
+	for i, t := range gf.Tasks {
+		var refErr error
+		resolve := func(s string) string {
+			return artifactRef.ReplaceAllStringFunc(s, func(m string) string {
+				sub := artifactRef.FindStringSubmatch(m)
+				from, artifact := sub[1], sub[2]
+				if _, ok := outputs[from]; !ok || refErr != nil {
+					return m
+				}
+				if err := checkArtifactRef(t.Name, from, artifact, outputs); err != nil {
+					refErr = fmt.Errorf("parse graph json: task %q: %s: %w", t.Name, m, err)
+					return m
+				}
+				if e := (dag.Edge{From: from, To: t.Name}); !have[e] {
+					have[e] = true
+					out.Edges = append(out.Edges, e)
+				}
+				return artifact
+			})
+		}
+
+		t.Run = resolve(t.Run)
+		if t.Inputs != nil {
+			inputs := make([]string, len(t.Inputs))
+			for j, in := range t.Inputs {
+				inputs[j] = resolve(in)
+			}
+			t.Inputs = inputs
+		}
+		if t.Env != nil {
+			env := make(map[string]string, len(t.Env))
+			for _, k := range sortedKeys(t.Env) {
+				env[k] = resolve(t.Env[k])
+			}
+			t.Env = env
+		}
+		if t.Stdin != nil {
+			t.Stdin = &core.Stdin{Text: resolve(t.Stdin.Text), File: resolve(t.Stdin.File)}
+		}
+		t.When = t.When.Replace(resolve, nil)
+		if refErr != nil {
+			return graphFile{}, refErr
+		}
+		out.Tasks[i] = t
+	}
+	return out, nil
+}
+
+// checkArtifactRef reports why task cannot reference artifact of from.
+func checkArtifactRef(task, from, artifact string, outputs map[string][]string) error {
+	if from == task {
+		return fmt.Errorf("a task cannot reference its own artifacts")
+	}
+	for _, o := range outputs[from] {
+		if artifact == o || strings.HasPrefix(artifact, strings.TrimSuffix(o, "/")+"/") {
+			return nil
+		}
+		if ok, _ := path.Match(o, artifact); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("task %q does not declare output %q", from, artifact)
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadGraphFromFile_ResolvesArtifactRefs(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "graph.json")
+	src := `{
+		"tasks": [
+			{"name": "gen", "run": "true", "outputs": ["out/a.txt", "dist", "logs/*.log"]},
+			{"name": "use", "run": "cat ${gen:out/a.txt}", "inputs": ["${gen:out/a.txt}", "${gen:dist/app.tar}"],
+			 "env": {"LOG": "${gen:logs/build.log}"}, "stdin": {"file": "${gen:out/a.txt}"}}
+		],
+		"edges": [{"From": "gen", "To": "use"}]
+	}`
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadGraphFromFile(p)
+	if err != nil {
+		t.Fatalf("load graph: %v", err)
+	}
+	n, _ := g.Node("use")
+	task := n.Task
+	if task.Run != "cat out/a.txt" || strings.Join(task.Inputs, ",") != "out/a.txt,dist/app.tar" || task.Env["LOG"] != "logs/build.log" || task.Stdin.File != "out/a.txt" {
+		t.Fatalf("references not resolved: %+v", task)
+	}
+	if len(g.Edges()) != 1 {
+		t.Fatalf("declared edge duplicated: %v", g.Edges())
+	}
+}
+
+func TestLoadGraphFromFile_ArtifactRefAddsEdge(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "graph.json")
+	src := `{"tasks": [
+		{"name": "use", "run": "cat ${gen:a.txt}"},
+		{"name": "gen", "run": "echo a > a.txt", "outputs": ["a.txt"]}
+	]}`
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadGraphFromFile(p)
+	if err != nil {
+		t.Fatalf("load graph: %v", err)
+	}
+	if edges := g.Edges(); len(edges) != 1 || edges[0].From != "gen" || edges[0].To != "use" {
+		t.Fatalf("edges = %v", edges)
+	}
+}
+
+func TestLoadGraphFromFile_LeavesShellExpansionsAlone(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "graph.json")
+	run := `echo ${VAR:-x} ${NAME:0:3} ${gen:a.txt}`
+	src := `{"tasks": [
+		{"name": "gen", "run": "echo a > a.txt", "outputs": ["a.txt"]},
+		{"name": "use", "run": "` + run + `", "env": {"OUT": "${OUT:-dist}"}}
+	]}`
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadGraphFromFile(p)
+	if err != nil {
+		t.Fatalf("load graph: %v", err)
+	}
+	n, _ := g.Node("use")
+	if n.Task.Run != "echo ${VAR:-x} ${NAME:0:3} a.txt" || n.Task.Env["OUT"] != "${OUT:-dist}" {
+		t.Fatalf("shell expansions rewritten: run=%q env=%v", n.Task.Run, n.Task.Env)
+	}
+	if edges := g.Edges(); len(edges) != 1 || edges[0].From != "gen" {
+		t.Fatalf("edges = %v", edges)
+	}
+}
+
+func TestLoadGraphFromFile_RejectsBadArtifactRefs(t *testing.T) {
+	cases := map[string]struct {
+		src  string
+		want string
+	}{
+		"undeclared artifact": {
+			src:  `{"tasks": [{"name": "a", "run": "true", "outputs": ["x"]}, {"name": "b", "run": "cat ${a:y}"}]}`,
+			want: `task "a" does not declare output "y"`,
+		},
+		"self reference": {
+			src:  `{"tasks": [{"name": "a", "run": "cat ${a:x}", "outputs": ["x"]}]}`,
+			want: "its own artifacts",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "graph.json")
+			if err := os.WriteFile(p, []byte(tc.src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadGraphFromFile(p)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"scriptweaver/internal/attest"
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// attestationsDir is the OutputDir subdirectory holding one in-toto
+// statement per task.
+const attestationsDir = "attestations"
+
+// writeAttestations writes an in-toto SLSA provenance statement for every
+// task that succeeded (or was restored from cache) and declares outputs.
+// Subjects are hashed from the artifacts now on disk; the task hash is
+// recomputed and must match the one the run recorded, so a statement never
+// describes inputs that changed after the task ran.
+func writeAttestations(inv CLIInvocation, g *dag.TaskGraph, gr *dag.GraphResult, runner *core.Runner) error {
+	dir := filepath.Join(inv.OutputDir, attestationsDir)
+	harvester := core.NewHarvester(inv.WorkDir)
+	for _, name := range g.TopologicalOrder() {
+		st := gr.FinalState[name]
+		if st != dag.TaskCompleted && st != dag.TaskCached {
+			continue
+		}
+		n, _ := g.Node(name)
+		task := n.Task
+		if len(task.Outputs) == 0 {
+			continue
+		}
+		hash, inputs, err := runner.TaskHash(&task)
+		if err != nil {
+			return fmt.Errorf("attest %s: %w", name, err)
+		}
+		if recorded := gr.TaskHashes[name]; recorded != "" && recorded != hash {
+			return fmt.Errorf("attest %s: inputs changed during the run (task hash %s, recorded %s)", name, hash, recorded)
+		}
+		set, err := harvester.Harvest(task.Outputs)
+		if err != nil {
+			return fmt.Errorf("attest %s: %w", name, err)
+		}
+		b := attest.TaskBuild{Task: task, TaskHash: string(hash), GraphHash: string(gr.GraphHash)}
+		for _, in := range inputs.Inputs {
+			p := filepath.FromSlash(in.Path)
+			if rel, err := filepath.Rel(inv.WorkDir, p); err == nil {
+				p = rel
+			}
+			b.Inputs = append(b.Inputs, attest.Digest{Path: filepath.ToSlash(p), SHA256: in.ContentDigest()})
+		}
+		for _, a := range set.Artifacts {
+			d, err := harvestedDigest(a)
+			if err != nil {
+				return fmt.Errorf("attest %s: hashing %s: %w", name, a.Path, err)
+			}
+			b.Artifacts = append(b.Artifacts, attest.Digest{Path: a.Path, SHA256: d})
+		}
+		data, err := attest.NewStatement(b).Marshal()
+		if err != nil {
+			return fmt.Errorf("attest %s: %w", name, err)
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(filepath.Join(dir, url.PathEscape(name)+".intoto.json"), data, 0o644); err != nil {
+			return fmt.Errorf("attest %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// harvestedDigest is the hex SHA-256 of a harvested artifact; symlinks are
+// hashed by their link target, as for expected outputs.
+func harvestedDigest(a core.Artifact) (string, error) {
+	if a.LinkTarget != "" {
+		sum := sha256.Sum256([]byte(a.LinkTarget))
+		return hex.EncodeToString(sum[:]), nil
+	}
+	src, err := a.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, src); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/attest"
+	"scriptweaver/internal/core"
+)
+
+func TestExecute_AttestWritesDeterministicStatements(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "src.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tasks := []core.Task{
+		{Name: "build", Inputs: []string{"src.txt"}, Run: "mkdir -p build && cp src.txt build/a.txt", Outputs: []string{"build"}},
+		{Name: "check", Run: "true"},
+	}
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), tasks, nil)
+	run := func() CLIResult {
+		t.Helper()
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--attest"})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+		}
+		return res
+	}
+	statementPath := filepath.Join(workDir, "out", "attestations", "build.intoto.json")
+
+	res := run()
+	first, err := os.ReadFile(statementPath)
+	if err != nil {
+		t.Fatalf("read statement: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "out", "attestations", "check.intoto.json")); !os.IsNotExist(err) {
+		t.Fatalf("tasks without outputs get no statement, stat err=%v", err)
+	}
+
+	var s attest.Statement
+	if err := json.Unmarshal(first, &s); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	sum := sha256.Sum256([]byte("hello"))
+	digest := hex.EncodeToString(sum[:])
+	if s.Type != attest.StatementType || s.PredicateType != attest.PredicateType {
+		t.Fatalf("unexpected types: %+v", s)
+	}
+	if len(s.Subject) != 1 || s.Subject[0].Name != "build/a.txt" || s.Subject[0].Digest["sha256"] != digest {
+		t.Fatalf("unexpected subject: %+v", s.Subject)
+	}
+	bd := s.Predicate.BuildDefinition
+	if deps := bd.ResolvedDependencies; len(deps) != 1 || deps[0].URI != "src.txt" || deps[0].Digest["sha256"] != digest {
+		t.Fatalf("unexpected dependencies: %+v", deps)
+	}
+	if bd.InternalParameters.TaskHash != string(res.GraphResult.TaskHashes["build"]) {
+		t.Fatalf("task hash %q, want %q", bd.InternalParameters.TaskHash, res.GraphResult.TaskHashes["build"])
+	}
+	if bd.ExternalParameters.GraphHash != string(res.GraphResult.GraphHash) || bd.ExternalParameters.Command != tasks[0].Run {
+		t.Fatalf("unexpected external parameters: %+v", bd.ExternalParameters)
+	}
+
+	// A cached rerun attests the same facts, byte for byte.
+	run()
+	second, err := os.ReadFile(statementPath)
+	if err != nil {
+		t.Fatalf("read statement: %v", err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Fatalf("statements differ across runs:\n%s\n%s", first, second)
+	}
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"scriptweaver/internal/core"
+)
+
+// auditDir is the OutputDir subdirectory holding one hermeticity report per
+// audited task.
+const auditDir = "audit"
+
+// auditLog collects the reports of tasks executed under --audit; the runner
+// may report from several tasks at once.
+type auditLog struct {
+	mu      sync.Mutex
+	reports []core.AuditReport
+}
+
+func (l *auditLog) add(r core.AuditReport) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reports = append(l.reports, r)
+}
+
+// enableAudit traces runner's tasks with the strace found on PATH and
+// returns the log their reports are collected in.
+func enableAudit(runner *core.Runner) (*auditLog, error) {
+	strace, err := exec.LookPath("strace")
+	if err != nil {
+		return nil, invalidInvocationf("--audit requires strace on PATH: %v", err)
+	}
+	log := &auditLog{}
+	runner.Executor.Strace = strace
+	runner.Audit = log.add
+	return log, nil
+}
+
+// writeAuditReports writes each collected report to
+// OutputDir/audit/<task>.json, in task order.
+func writeAuditReports(outputDir string, log *auditLog) error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	sort.Slice(log.reports, func(i, j int) bool { return log.reports[i].Task < log.reports[j].Task })
+	dir := filepath.Join(outputDir, auditDir)
+	for _, r := range log.reports {
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(filepath.Join(dir, url.PathEscape(r.Task)+".json"), append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("audit %s: %w", r.Task, err)
+		}
+	}
+	return nil
+}
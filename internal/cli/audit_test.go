@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+func TestExecute_AuditReportsUndeclaredReads(t *testing.T) {
+	workDir := t.TempDir()
+	graph := `{"tasks": [{"name": "build", "inputs": ["in.txt"], "outputs": ["out/a.txt"],
+		"run": "mkdir -p out && cat in.txt extra.txt > out/a.txt"}]}`
+	for name, content := range map[string]string{"graph.json": graph, "in.txt": "in\n", "extra.txt": "extra\n"} {
+		if err := os.WriteFile(filepath.Join(workDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "build", "--audit"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	res, err := Execute(context.Background(), inv)
+	if _, lookErr := exec.LookPath("strace"); lookErr != nil {
+		if res.ExitCode != ExitInvalidInvocation {
+			t.Fatalf("without strace: exit %d err %v, want %d", res.ExitCode, err, ExitInvalidInvocation)
+		}
+		t.Skip("strace not installed")
+	}
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, "build", "audit", "build.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report core.AuditReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"extra.txt"}; !reflect.DeepEqual(report.UndeclaredReads, want) {
+		t.Fatalf("undeclared reads = %v, want %v", report.UndeclaredReads, want)
+	}
+	if len(report.Network) != 0 || len(report.UndeclaredWrites) != 0 {
+		t.Fatalf("unexpected findings: %+v", report)
+	}
+}
+
+func TestParseInvocation_AuditRejectsRemoteWorkers(t *testing.T) {
+	_, err := ParseInvocation([]string{"--workdir", t.TempDir(), "--graph", "g.json", "--cache-dir", "c", "--output-dir", "o", "--audit", "--remote-worker", "http://localhost:1"})
+	if err == nil {
+		t.Fatal("expected --audit with --remote-worker to be rejected")
+	}
+}
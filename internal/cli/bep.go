@@ -0,0 +1,287 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// Build events follow the JSON form of Bazel's build event protocol
+// (build_event_stream.proto, as written by --build_event_json_file): one
+// BuildEvent per line, field names in lowerCamelCase, int64 values as
+// strings and timestamps in RFC 3339. Only the events and fields below are
+// produced; tasks are reported under their name as the target label.
+type bepEvent struct {
+	ID          bepEventID   `json:"id"`
+	Children    []bepEventID `json:"children,omitempty"`
+	LastMessage bool         `json:"lastMessage,omitempty"`
+
+	Started      *bepStarted      `json:"started,omitempty"`
+	Action       *bepAction       `json:"action,omitempty"`
+	Completed    *bepCompleted    `json:"completed,omitempty"`
+	Aborted      *bepAborted      `json:"aborted,omitempty"`
+	Finished     *bepFinished     `json:"finished,omitempty"`
+	BuildMetrics *bepBuildMetrics `json:"buildMetrics,omitempty"`
+}
+
+type bepEventID struct {
+	Started         *struct{}    `json:"started,omitempty"`
+	ActionCompleted *bepActionID `json:"actionCompleted,omitempty"`
+	TargetCompleted *bepTargetID `json:"targetCompleted,omitempty"`
+	BuildFinished   *struct{}    `json:"buildFinished,omitempty"`
+	BuildMetrics    *struct{}    `json:"buildMetrics,omitempty"`
+}
+
+type bepActionID struct {
+	PrimaryOutput string `json:"primaryOutput,omitempty"`
+	Label         string `json:"label"`
+}
+
+type bepTargetID struct {
+	Label string `json:"label"`
+}
+
+type bepStarted struct {
+	UUID               string `json:"uuid"`
+	StartTime          string `json:"startTime"`
+	BuildToolVersion   string `json:"buildToolVersion"`
+	Command            string `json:"command"`
+	WorkspaceDirectory string `json:"workspaceDirectory"`
+}
+
+// bepAction is an ActionExecuted event: one per task that executed or was
+// restored. Type is the action's mnemonic, "ScriptWeaverExecute" or
+// "ScriptWeaverCacheHit", so backends can tell cache hits apart.
+type bepAction struct {
+	Success   bool   `json:"success"`
+	Type      string `json:"type"`
+	ExitCode  int    `json:"exitCode"`
+	Label     string `json:"label"`
+	StartTime string `json:"startTime,omitempty"`
+	EndTime   string `json:"endTime,omitempty"`
+}
+
+// bepCompleted is a TargetComplete event. ImportantOutput lists the task's
+// declared outputs as they are on disk after the run.
+type bepCompleted struct {
+	Success         bool      `json:"success"`
+	Tag             []string  `json:"tag,omitempty"`
+	ImportantOutput []bepFile `json:"importantOutput,omitempty"`
+}
+
+type bepFile struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+	Length string `json:"length,omitempty"`
+}
+
+type bepAborted struct {
+	Reason      string `json:"reason"`
+	Description string `json:"description"`
+}
+
+type bepFinished struct {
+	OverallSuccess bool        `json:"overallSuccess"`
+	ExitCode       bepExitCode `json:"exitCode"`
+	FinishTime     string      `json:"finishTime"`
+}
+
+type bepExitCode struct {
+	Name string `json:"name"`
+	Code int    `json:"code"`
+}
+
+type bepBuildMetrics struct {
+	ActionSummary bepActionSummary `json:"actionSummary"`
+}
+
+type bepActionSummary struct {
+	ActionsCreated        string                   `json:"actionsCreated"`
+	ActionsExecuted       string                   `json:"actionsExecuted"`
+	ActionCacheStatistics bepActionCacheStatistics `json:"actionCacheStatistics"`
+}
+
+type bepActionCacheStatistics struct {
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+// bepExitCodeNames names scriptweaver's exit codes in the style of Bazel's
+// ExitCode names.
+var bepExitCodeNames = map[int]string{
+	ExitSuccess:           "SUCCESS",
+	ExitGraphFailure:      "BUILD_FAILURE",
+	ExitInvalidInvocation: "COMMAND_LINE_ERROR",
+	ExitConfigError:       "PARSING_FAILURE",
+	ExitInternalError:     "INTERNAL_ERROR",
+	ExitTraceMismatch:     "TRACE_MISMATCH",
+}
+
+// writeBuildEvents writes the run to path as a build event stream: started,
+// then for each task in topological order an action event (tasks that
+// executed or were restored) and a targetCompleted event (aborted with
+// SKIPPED for tasks that did not run), then buildFinished and buildMetrics.
+// Action times come from timed, and output digests are of the files on disk
+// after the run, so unlike JUnit reports the stream differs between runs.
+func writeBuildEvents(path string, inv CLIInvocation, runID string, start, end time.Time, exitCode int, g *dag.TaskGraph, gr *dag.GraphResult, timed *timingRunner) error {
+	timed.mu.Lock()
+	spans := make(map[string]timeSpan, len(timed.spans))
+	for name, s := range timed.spans {
+		spans[name] = s
+	}
+	timed.mu.Unlock()
+
+	order := g.TopologicalOrder()
+	started := bepEvent{
+		ID: bepEventID{Started: &struct{}{}},
+		Started: &bepStarted{
+			UUID:               runID,
+			StartTime:          bepTime(start),
+			BuildToolVersion:   toolVersion(),
+			Command:            "run",
+			WorkspaceDirectory: inv.WorkDir,
+		},
+	}
+	for _, name := range order {
+		started.Children = append(started.Children, bepEventID{TargetCompleted: &bepTargetID{Label: name}})
+	}
+	started.Children = append(started.Children, bepEventID{BuildFinished: &struct{}{}})
+	events := []bepEvent{started}
+
+	harvester := core.NewHarvester(inv.WorkDir)
+	var executed, hits int
+	for _, name := range order {
+		n, _ := g.Node(name)
+		target := bepEventID{TargetCompleted: &bepTargetID{Label: name}}
+		final := gr.FinalState[name]
+		switch final {
+		case dag.TaskCompleted, dag.TaskCached, dag.TaskFailed, dag.TaskFailedAllowed:
+		default:
+			reason := "not run"
+			switch final {
+			case dag.TaskSkipped:
+				reason = "an upstream task failed"
+			case dag.TaskConditionFalse:
+				reason = "condition is false"
+			}
+			events = append(events, bepEvent{ID: target, Aborted: &bepAborted{Reason: "SKIPPED", Description: reason}})
+			continue
+		}
+
+		action := &bepAction{Type: "ScriptWeaverExecute", Label: name, ExitCode: gr.ExitCode[name]}
+		action.Success = final == dag.TaskCompleted || final == dag.TaskCached
+		if final == dag.TaskCached {
+			action.Type = "ScriptWeaverCacheHit"
+			hits++
+		} else {
+			executed++
+		}
+		if s, ok := spans[name]; ok {
+			action.StartTime, action.EndTime = bepTime(s.start), bepTime(s.end)
+		}
+		actionID := bepActionID{Label: name}
+		if len(n.Task.Outputs) > 0 {
+			actionID.PrimaryOutput = n.Task.Outputs[0]
+		}
+		events = append(events, bepEvent{ID: bepEventID{ActionCompleted: &actionID}, Action: action})
+
+		// A failure the task allows fails its action but not its target.
+		completed := &bepCompleted{Success: final != dag.TaskFailed}
+		if n.Task.Group != "" {
+			completed.Tag = []string{n.Task.Group}
+		}
+		if action.Success && len(n.Task.Outputs) > 0 {
+			set, err := harvester.Harvest(n.Task.Outputs)
+			if err != nil {
+				return err
+			}
+			for _, a := range set.Artifacts {
+				f, err := bepArtifact(a)
+				if err != nil {
+					return err
+				}
+				completed.ImportantOutput = append(completed.ImportantOutput, f)
+			}
+		}
+		events = append(events, bepEvent{ID: target, Completed: completed})
+	}
+
+	name, ok := bepExitCodeNames[exitCode]
+	if !ok {
+		name = "UNKNOWN"
+	}
+	events = append(events,
+		bepEvent{
+			ID:       bepEventID{BuildFinished: &struct{}{}},
+			Children: []bepEventID{{BuildMetrics: &struct{}{}}},
+			Finished: &bepFinished{OverallSuccess: exitCode == ExitSuccess, ExitCode: bepExitCode{Name: name, Code: exitCode}, FinishTime: bepTime(end)},
+		},
+		bepEvent{
+			ID:          bepEventID{BuildMetrics: &struct{}{}},
+			LastMessage: true,
+			BuildMetrics: &bepBuildMetrics{ActionSummary: bepActionSummary{
+				ActionsCreated:        strconv.Itoa(executed + hits),
+				ActionsExecuted:       strconv.Itoa(executed),
+				ActionCacheStatistics: bepActionCacheStatistics{Hits: hits, Misses: executed},
+			}},
+		},
+	)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func bepTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// bepArtifact describes a harvested output as a File: its SHA-256 digest
+// and, for regular files, its length.
+func bepArtifact(a core.Artifact) (bepFile, error) {
+	f := bepFile{Name: a.Path}
+	if a.LinkTarget != "" {
+		sum := sha256.Sum256([]byte(a.LinkTarget))
+		f.Digest = hex.EncodeToString(sum[:])
+		return f, nil
+	}
+	src, err := a.Open()
+	if err != nil {
+		return bepFile{}, err
+	}
+	defer src.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, src)
+	if err != nil {
+		return bepFile{}, err
+	}
+	f.Digest = hex.EncodeToString(h.Sum(nil))
+	f.Length = strconv.FormatInt(n, 10)
+	return f, nil
+}
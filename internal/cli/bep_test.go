@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExecute_ReportBEP(t *testing.T) {
+	workDir := t.TempDir()
+	graph := `{
+		"tasks": [
+			{"name": "build", "run": "echo ok > build.out", "outputs": ["build.out"]},
+			{"name": "test", "inputs": ["build.out"], "run": "true"}
+		],
+		"edges": [{"from": "build", "to": "test"}]
+	}`
+	if err := os.WriteFile(filepath.Join(workDir, "graph.json"), []byte(graph), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func() []bepEvent {
+		t.Helper()
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--mode", "incremental", "--report-bep", "reports/events.json"})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("exit %d err %v", res.ExitCode, err)
+		}
+		b, err := os.ReadFile(filepath.Join(workDir, "reports", "events.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var events []bepEvent
+		sc := bufio.NewScanner(bytes.NewReader(b))
+		for sc.Scan() {
+			var e bepEvent
+			if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+				t.Fatalf("invalid event %q: %v", sc.Text(), err)
+			}
+			events = append(events, e)
+		}
+		return events
+	}
+
+	events := run()
+	if len(events) != 7 || events[0].Started == nil || events[0].Started.WorkspaceDirectory != workDir || len(events[0].Children) != 3 {
+		t.Fatalf("unexpected stream: %+v", events)
+	}
+	last := events[len(events)-1]
+	if !last.LastMessage || last.BuildMetrics == nil || last.BuildMetrics.ActionSummary.ActionsExecuted != "2" {
+		t.Fatalf("last event = %+v", last)
+	}
+	if f := events[5].Finished; f == nil || !f.OverallSuccess || f.ExitCode.Name != "SUCCESS" {
+		t.Fatalf("finished = %+v", events[5])
+	}
+	if a := events[1].Action; a == nil || a.Label != "build" || a.Type != "ScriptWeaverExecute" || !a.Success || a.StartTime == "" {
+		t.Fatalf("build action = %+v", events[1])
+	}
+	sum := sha256.Sum256([]byte("ok\n"))
+	want := bepFile{Name: "build.out", Digest: hex.EncodeToString(sum[:]), Length: "3"}
+	if c := events[2].Completed; c == nil || !c.Success || len(c.ImportantOutput) != 1 || c.ImportantOutput[0] != want {
+		t.Fatalf("build target = %+v", events[2])
+	}
+
+	events = run()
+	if a := events[1].Action; a == nil || a.Type != "ScriptWeaverCacheHit" {
+		t.Fatalf("second run build action = %+v", events[1])
+	}
+	if s := events[len(events)-1].BuildMetrics.ActionSummary; s.ActionCacheStatistics.Hits != 2 || s.ActionsExecuted != "0" {
+		t.Fatalf("second run summary = %+v", s)
+	}
+}
+
+func TestParseInvocation_ReportBEPRejectsOutputDir(t *testing.T) {
+	workDir := t.TempDir()
+	_, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--report-bep", "out/events.json"})
+	if ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,315 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/projectintegration/engine/workspace"
+	"scriptweaver/internal/recovery/state"
+)
+
+// CacheExportInvocation is the canonical form of `scriptweaver cache export`.
+type CacheExportInvocation struct {
+	WorkDir   string
+	GraphPath string
+	Params    map[string]string
+	// NormalizeRules come from scriptweaver.toml, as for a run.
+	NormalizeRules []core.NormalizeRule
+	CacheDir       string
+	// CacheNamespace is --cache-namespace, resolved for the graph as a run
+	// resolves it.
+	CacheNamespace string
+	// Tasks are the graph tasks whose latest cache entries are exported.
+	Tasks []string
+	// Output is the bundle path.
+	Output string
+}
+
+// CacheImportInvocation is the canonical form of `scriptweaver cache import`.
+type CacheImportInvocation struct {
+	WorkDir  string
+	CacheDir string
+	// CacheNamespace is the resolved namespace to import into; empty for
+	// none.
+	CacheNamespace string
+	// Bundle is the path of a bundle written by cache export.
+	Bundle string
+	// CacheSigning.Trusted, when set, rejects bundles with entries not signed
+	// by one of the keys.
+	CacheSigning CacheSigning
+}
+
+// CacheExportReport is what `scriptweaver cache export` prints.
+type CacheExportReport struct {
+	Bundle  string                  `json:"bundle"`
+	Entries []core.CacheBundleEntry `json:"entries"`
+}
+
+// ParseCacheExportInvocation parses the flags following `cache export`.
+func ParseCacheExportInvocation(args []string) (CacheExportInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver cache export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	var graphPath string
+	var cacheDir string
+	var cacheNamespace string
+	var tasks stringListFlag
+	var output string
+	params := paramFlags{}
+
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+	fs.StringVar(&graphPath, "graph", "", "Graph source path. Required.")
+	fs.Var(params, "param", "Graph parameter key=value (repeatable).")
+	fs.StringVar(&cacheDir, "cache-dir", "", "Cache directory. Required.")
+	fs.StringVar(&cacheNamespace, "cache-namespace", "", "Cache namespace the run used: \"graph\" or a fixed name. Default: none.")
+	fs.Var(&tasks, "tasks", "Comma-separated task names (repeatable) whose latest cache entries to export. Required.")
+	fs.StringVar(&output, "o", "", "Bundle path (gzipped tar, e.g. bundle.tar.gz). Required.")
+
+	if err := fs.Parse(args); err != nil {
+		return CacheExportInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 0 {
+		return CacheExportInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return CacheExportInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return CacheExportInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	cfg, err := applyConfig(fs, workDir, params)
+	if err != nil {
+		return CacheExportInvocation{}, err
+	}
+	if graphPath == "" {
+		return CacheExportInvocation{}, invalidInvocationf("--graph is required")
+	}
+	if cacheDir == "" {
+		return CacheExportInvocation{}, invalidInvocationf("--cache-dir is required")
+	}
+	if output == "" {
+		return CacheExportInvocation{}, invalidInvocationf("-o is required")
+	}
+	if strings.HasSuffix(output, ".zst") || strings.HasSuffix(output, ".tzst") {
+		return CacheExportInvocation{}, invalidInvocationf("-o %q: zstd is not supported in this build; bundles are gzipped tars (use .tar.gz)", output)
+	}
+	names, err := splitTaskList(tasks)
+	if err != nil {
+		return CacheExportInvocation{}, err
+	}
+	namespace, err := core.ParseCacheNamespace(cacheNamespace)
+	if err != nil {
+		return CacheExportInvocation{}, invalidInvocationf("--cache-namespace: %v", err)
+	}
+
+	inv := CacheExportInvocation{WorkDir: workDir, NormalizeRules: cfg.Normalize, CacheNamespace: namespace, Tasks: names}
+	if len(params) > 0 {
+		inv.Params = params
+	}
+	if inv.GraphPath, err = resolveUnderWorkDir(workDir, graphPath); err != nil {
+		return CacheExportInvocation{}, err
+	}
+	if inv.CacheDir, err = resolveUnderWorkDir(workDir, cacheDir); err != nil {
+		return CacheExportInvocation{}, err
+	}
+	if inv.Output, err = resolveUnderWorkDir(workDir, output); err != nil {
+		return CacheExportInvocation{}, err
+	}
+	if isWithin(inv.CacheDir, inv.Output) {
+		return CacheExportInvocation{}, invalidInvocationf("-o must not be inside --cache-dir")
+	}
+	return inv, nil
+}
+
+// splitTaskList splits the --tasks values on commas, dropping duplicates.
+func splitTaskList(values []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var names []string
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				return nil, invalidInvocationf("--tasks: empty task name in %q", v)
+			}
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil, invalidInvocationf("--tasks is required")
+	}
+	return names, nil
+}
+
+// ParseCacheImportInvocation parses the flags and the bundle path following
+// `cache import`. The bundle path may come before or after the flags.
+func ParseCacheImportInvocation(args []string) (CacheImportInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver cache import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	var cacheDir string
+	var cacheNamespace string
+	var trustedKeys stringListFlag
+
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+	fs.StringVar(&cacheDir, "cache-dir", "", "Cache directory. Required.")
+	fs.StringVar(&cacheNamespace, "namespace", "", "Resolved cache namespace to import into: a fixed name, or the graph hash for --cache-namespace graph. Default: none.")
+	fs.Var(&trustedKeys, "cache-trusted-key", "PEM ed25519 public key whose cache entries are trusted (repeatable); bundles with entries not signed by a trusted key are rejected.")
+
+	var positional []string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		positional, args = args[:1], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return CacheImportInvocation{}, flagParseError(fs, err)
+	}
+	positional = append(positional, fs.Args()...)
+	if len(positional) != 1 {
+		return CacheImportInvocation{}, invalidInvocationf("expected one bundle path, got %d", len(positional))
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return CacheImportInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return CacheImportInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	if _, err := applyConfig(fs, workDir, nil); err != nil {
+		return CacheImportInvocation{}, err
+	}
+	if cacheDir == "" {
+		return CacheImportInvocation{}, invalidInvocationf("--cache-dir is required")
+	}
+	signing, err := parseCacheSigning(workDir, "", trustedKeys)
+	if err != nil {
+		return CacheImportInvocation{}, err
+	}
+	if cacheNamespace == core.CacheNamespaceGraph {
+		return CacheImportInvocation{}, invalidInvocationf("--namespace %q: a bundle has no graph; pass the graph hash the namespace resolves to", cacheNamespace)
+	}
+	namespace, err := core.ParseCacheNamespace(cacheNamespace)
+	if err != nil {
+		return CacheImportInvocation{}, invalidInvocationf("--namespace: %v", err)
+	}
+	inv := CacheImportInvocation{WorkDir: workDir, CacheNamespace: namespace, CacheSigning: signing}
+	if inv.CacheDir, err = resolveUnderWorkDir(workDir, cacheDir); err != nil {
+		return CacheImportInvocation{}, err
+	}
+	if inv.Bundle, err = resolveUnderWorkDir(workDir, positional[0]); err != nil {
+		return CacheImportInvocation{}, err
+	}
+	return inv, nil
+}
+
+// CacheExport writes the latest cache entries of the selected tasks to a
+// bundle and prints its contents to stdout as JSON.
+//
+// A task's latest entry is the task hash recorded for it in the result index
+// of the graph, so the graph must have run in this workspace; nothing is
+// hashed or executed. A task without a recorded result or whose entry is
+// missing from or damaged in the cache fails the export with exit code 3.
+func CacheExport(inv CacheExportInvocation, stdout io.Writer) (int, error) {
+	g, graphHash, err := loadGraphAndHash(inv.GraphPath, inv.Params, inv.NormalizeRules...)
+	if err != nil {
+		var invErr *InvocationError
+		if errors.As(err, &invErr) && invErr.ExitCode != 0 {
+			return invErr.ExitCode, err
+		}
+		return ExitConfigError, err
+	}
+	st, err := state.NewStore(inv.WorkDir)
+	if err != nil {
+		return ExitInvalidInvocation, err
+	}
+	index, err := st.LoadResultIndex(graphHash)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	tasks := make(map[string]core.TaskHash, len(inv.Tasks))
+	for _, name := range inv.Tasks {
+		if _, ok := g.Node(name); !ok {
+			return ExitInvalidInvocation, invalidInvocationf("--tasks: unknown task %q", name)
+		}
+		r, ok := index.Tasks[name]
+		if !ok {
+			return ExitConfigError, fmt.Errorf("task %q has no recorded result for this graph; run it first", name)
+		}
+		tasks[name] = core.TaskHash(r.TaskHash)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(inv.Output), 0o755); err != nil {
+		return ExitConfigError, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(inv.Output), filepath.Base(inv.Output)+".tmp.*")
+	if err != nil {
+		return ExitConfigError, err
+	}
+	defer os.Remove(tmp.Name())
+	cacheDir := core.NamespacedCacheDir(inv.CacheDir, core.ResolveCacheNamespace(inv.CacheNamespace, graphHash))
+	manifest, err := core.NewFileCache(cacheDir).Export(tmp, tasks)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return ExitConfigError, err
+	}
+	if err := os.Rename(tmp.Name(), inv.Output); err != nil {
+		return ExitConfigError, err
+	}
+	return writeCacheBundleReport(stdout, CacheExportReport{Bundle: inv.Output, Entries: manifest.Entries})
+}
+
+// CacheImport adds the entries of a bundle to the cache and prints which
+// were imported and which were already present. A damaged, tampered-with
+// or (with --cache-trusted-key) untrusted bundle changes nothing and exits 3.
+func CacheImport(inv CacheImportInvocation, stdout io.Writer) (int, error) {
+	f, err := os.Open(inv.Bundle)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	defer f.Close()
+	ws, err := workspace.EnsureWorkspace(inv.WorkDir)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	lock, err := workspace.AcquireLock(ws)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	defer func() { _ = lock.Release() }()
+	cache := core.NewFileCache(core.NamespacedCacheDir(inv.CacheDir, inv.CacheNamespace))
+	inv.CacheSigning.apply(cache)
+	res, err := cache.Import(f)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	sort.Slice(res.Imported, func(i, j int) bool { return res.Imported[i] < res.Imported[j] })
+	sort.Slice(res.Skipped, func(i, j int) bool { return res.Skipped[i] < res.Skipped[j] })
+	return writeCacheBundleReport(stdout, res)
+}
+
+func writeCacheBundleReport(stdout io.Writer, v any) (int, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return ExitInternalError, err
+	}
+	if _, err := fmt.Fprintln(stdout, string(b)); err != nil {
+		return ExitInternalError, err
+	}
+	return ExitSuccess, nil
+}
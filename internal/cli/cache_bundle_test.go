@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/projectintegration/engine/workspace"
+)
+
+func TestCacheExportImport_RestoresOnAnotherCache(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{
+		{Name: "gen", Run: "mkdir -p out && printf data > out/a.txt", Outputs: []string{"out/a.txt"}},
+		{Name: "use", Run: "cat out/a.txt", Inputs: []string{"out/a.txt"}},
+	}, []dag.Edge{{From: "gen", To: "use"}})
+	inv := CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	}
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("exit=%d err=%v", res.ExitCode, err)
+	}
+
+	var out bytes.Buffer
+	code, _, err := Dispatch([]string{"cache", "export", "--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--tasks", "gen,use", "-o", "bundle.tar.gz"}, &out)
+	if err != nil || code != ExitSuccess {
+		t.Fatalf("cache export: code=%d err=%v", code, err)
+	}
+	var exported CacheExportReport
+	if err := json.Unmarshal(out.Bytes(), &exported); err != nil || len(exported.Entries) != 2 {
+		t.Fatalf("unexpected export report (%v): %s", err, out.String())
+	}
+
+	out.Reset()
+	code, _, err = Dispatch([]string{"cache", "import", "bundle.tar.gz", "--workdir", workDir, "--cache-dir", "imported"}, &out)
+	if err != nil || code != ExitSuccess {
+		t.Fatalf("cache import: code=%d err=%v", code, err)
+	}
+	var imported core.CacheImportResult
+	if err := json.Unmarshal(out.Bytes(), &imported); err != nil || len(imported.Imported) != 2 {
+		t.Fatalf("unexpected import result (%v): %s", err, out.String())
+	}
+
+	inv.CacheDir = filepath.Join(workDir, "imported")
+	res, err := Execute(context.Background(), inv)
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("exit=%d err=%v", res.ExitCode, err)
+	}
+	for _, name := range []string{"gen", "use"} {
+		if st := res.GraphResult.FinalState[name]; st != dag.TaskCached {
+			t.Fatalf("%s: state %v, want cached from the imported bundle", name, st)
+		}
+	}
+
+	code, _, err = Dispatch([]string{"cache", "export", "--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--tasks", "nope", "-o", "b.tar.gz"}, &bytes.Buffer{})
+	if code != ExitInvalidInvocation || err == nil {
+		t.Fatalf("unknown task: code=%d err=%v", code, err)
+	}
+	code, _, err = Dispatch([]string{"cache", "export", "--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--tasks", "gen", "-o", "b.tar.zst"}, &bytes.Buffer{})
+	if code != ExitInvalidInvocation || err == nil {
+		t.Fatalf("zstd bundle: code=%d err=%v", code, err)
+	}
+}
+
+func TestCacheMaintenance_RefusesLockedWorkspace(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "bundle.tar"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ws, err := workspace.EnsureWorkspace(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := workspace.AcquireLock(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lock.Release() }()
+
+	code, _, err := Dispatch([]string{"cache", "import", "--workdir", workDir, "--cache-dir", "cache", "bundle.tar"}, &bytes.Buffer{})
+	if code != ExitConfigError || !errors.Is(err, workspace.ErrWorkspaceLocked) {
+		t.Fatalf("cache import on a locked workspace: code=%d err=%v", code, err)
+	}
+
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--cache-dir", "cache", "--verify-cache", "--prune-corrupt"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	res, err := Execute(context.Background(), inv)
+	if res.ExitCode != ExitConfigError || !errors.Is(err, workspace.ErrWorkspaceLocked) {
+		t.Fatalf("--prune-corrupt on a locked workspace: code=%d err=%v", res.ExitCode, err)
+	}
+}
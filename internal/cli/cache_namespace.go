@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/recovery/state"
+)
+
+// cacheNamespace resolves --cache-namespace for the graph with graphHash.
+//
+// With --changed-files, graphHash is that of the narrowed graph, so the graph
+// namespace is resolved from the full graph's hash instead: narrowing a run
+// must not move it to another namespace.
+func (inv CLIInvocation) cacheNamespace(graphHash string) (string, error) {
+	if inv.CacheNamespace == core.CacheNamespaceGraph && inv.ChangedFiles != "" {
+		_, full, err := inv.warm.loadGraphAndHash(inv.GraphPath, inv.Params, inv.NormalizeRules...)
+		if err != nil {
+			return "", err
+		}
+		graphHash = full
+	}
+	return core.ResolveCacheNamespace(inv.CacheNamespace, graphHash), nil
+}
+
+// recordedCacheNamespace returns the namespace run stored its entries under:
+// the one in its cache metrics, or for runs recorded without them, the one
+// --cache-namespace resolves to for the run's graph.
+func recordedCacheNamespace(st *state.Store, inv CLIInvocation, runID string) string {
+	if m, err := st.LoadMetrics(runID); err == nil && m.Cache != nil {
+		return m.Cache.Namespace
+	}
+	run, err := st.LoadRun(runID)
+	if err != nil {
+		return inv.CacheNamespace
+	}
+	return core.ResolveCacheNamespace(inv.CacheNamespace, run.GraphHash)
+}
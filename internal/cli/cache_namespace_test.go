@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/stats"
+)
+
+func TestExecute_CacheNamespacePartitionsByGraph(t *testing.T) {
+	workDir := t.TempDir()
+	cacheDir := filepath.Join(workDir, "cache")
+	run := func(name, cmd string) CLIResult {
+		t.Helper()
+		graphPath := filepath.Join(workDir, name+".json")
+		writeGraphJSON(t, graphPath, []core.Task{{Name: "t", Run: cmd}}, nil)
+		res, err := Execute(context.Background(), CLIInvocation{
+			WorkDir:        workDir,
+			GraphPath:      graphPath,
+			CacheDir:       cacheDir,
+			OutputDir:      filepath.Join(workDir, "out"),
+			ExecutionMode:  ExecutionModeIncremental,
+			CacheNamespace: core.CacheNamespaceGraph,
+		})
+		if err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("%s: exit=%d err=%v", name, res.ExitCode, err)
+		}
+		return res
+	}
+
+	a := run("a", "echo a")
+	hashA := a.GraphResult.GraphHash.String()
+	if a.Metrics.Cache.Namespace != hashA {
+		t.Fatalf("namespace = %q, want graph hash %q", a.Metrics.Cache.Namespace, hashA)
+	}
+	if ok, err := core.NewFileCache(filepath.Join(cacheDir, "namespaces", hashA)).Has(a.GraphResult.TaskHashes["t"]); err != nil || !ok {
+		t.Fatalf("entry not stored in the graph's namespace (err=%v)", err)
+	}
+	if again := run("a", "echo a"); again.Metrics.Cache.Restores != 1 {
+		t.Fatalf("second run did not hit its namespace: %+v", again.Metrics.Cache)
+	}
+	b := run("b", "echo b")
+	hashB := b.GraphResult.GraphHash.String()
+	namespaces, _ := os.ReadDir(filepath.Join(cacheDir, "namespaces"))
+	if len(namespaces) != 2 {
+		t.Fatalf("expected one namespace per graph, got %v", namespaces)
+	}
+
+	var out bytes.Buffer
+	if code, _, err := Dispatch([]string{"cache", "stats", "--workdir", workDir}, &out); err != nil || code != ExitSuccess {
+		t.Fatalf("cache stats: code=%d err=%v", code, err)
+	}
+	var rep stats.CacheReport
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.PerNamespace) != 2 || rep.PerNamespace[0].Attempts+rep.PerNamespace[1].Attempts != 3 {
+		t.Fatalf("unexpected per-namespace stats: %+v", rep.PerNamespace)
+	}
+	out.Reset()
+	if code, _, err := Dispatch([]string{"cache", "stats", "--workdir", workDir, "--namespace", hashB}, &out); err != nil || code != ExitSuccess {
+		t.Fatalf("cache stats --namespace: code=%d err=%v", code, err)
+	}
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil || len(rep.PerRun) != 1 || rep.PerRun[0].Namespace != hashB {
+		t.Fatalf("unexpected filtered stats (%v): %s", err, out.String())
+	}
+
+	res, err := verifyCache(CLIInvocation{WorkDir: workDir, CacheDir: cacheDir})
+	if err != nil || res.ExitCode != ExitSuccess || res.CacheVerify.Checked != 2 {
+		t.Fatalf("verify-cache across namespaces: exit=%d err=%v report=%+v", res.ExitCode, err, res.CacheVerify)
+	}
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"crypto/ed25519"
+
+	"scriptweaver/internal/core"
+)
+
+// CacheSigning configures ed25519 signing of cache entries.
+type CacheSigning struct {
+	// Key signs entries written by this run; nil writes unsigned entries.
+	Key ed25519.PrivateKey
+
+	// Trusted are the keys an entry must be signed by to be restored. It
+	// always includes Key's public half; empty disables verification.
+	Trusted []ed25519.PublicKey
+}
+
+// parseCacheSigning loads the --cache-signing-key and --cache-trusted-key
+// files, resolved under workDir.
+func parseCacheSigning(workDir, signingKey string, trustedKeys []string) (CacheSigning, error) {
+	var cs CacheSigning
+	if signingKey != "" {
+		p, err := resolveUnderWorkDir(workDir, signingKey)
+		if err != nil {
+			return CacheSigning{}, err
+		}
+		if cs.Key, err = core.LoadSigningKey(p); err != nil {
+			return CacheSigning{}, invalidInvocationf("--cache-signing-key: %v", err)
+		}
+		cs.Trusted = append(cs.Trusted, cs.Key.Public().(ed25519.PublicKey))
+	}
+	for _, k := range trustedKeys {
+		p, err := resolveUnderWorkDir(workDir, k)
+		if err != nil {
+			return CacheSigning{}, err
+		}
+		pub, err := core.LoadVerifyKey(p)
+		if err != nil {
+			return CacheSigning{}, invalidInvocationf("--cache-trusted-key: %v", err)
+		}
+		cs.Trusted = append(cs.Trusted, pub)
+	}
+	return cs, nil
+}
+
+// apply configures c to sign and verify with cs. Caches other than
+// FileCache (such as the clean-mode no-op cache) are left alone.
+func (cs CacheSigning) apply(c core.Cache) {
+	if fc, ok := c.(*core.FileCache); ok {
+		fc.SigningKey = cs.Key
+		fc.TrustedKeys = cs.Trusted
+	}
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+func writeEd25519Keys(t *testing.T, dir, name string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	der, err = x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".pub"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExecute_CacheSigningRejectsUntrustedEntries(t *testing.T) {
+	workDir := t.TempDir()
+	writeEd25519Keys(t, workDir, "ci")
+	writeEd25519Keys(t, workDir, "laptop")
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{{Name: "a", Run: "echo hi > a.txt", Outputs: []string{"a.txt"}}}, nil)
+	run := func(extra ...string) (CLIResult, error) {
+		t.Helper()
+		inv, err := ParseInvocation(append([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"}, extra...))
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		return Execute(context.Background(), inv)
+	}
+
+	if res, err := run("--cache-signing-key", "ci.pem"); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("signed run: exit %d err %v", res.ExitCode, err)
+	}
+	res, err := run("--cache-trusted-key", "ci.pub")
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("trusted run: exit %d err %v", res.ExitCode, err)
+	}
+	if st := res.GraphResult.FinalState["a"]; st != "CACHED" {
+		t.Fatalf("expected a cache hit from a trusted entry, got %s", st)
+	}
+
+	res, err = run("--cache-trusted-key", "laptop.pub")
+	if res.ExitCode != ExitConfigError {
+		t.Fatalf("untrusted entry must be rejected: exit %d err %v", res.ExitCode, err)
+	}
+	if !core.IsCacheSignatureInvalid(err) {
+		t.Fatalf("expected a signature error, got %v", err)
+	}
+
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--cache-dir", "cache", "--verify-cache", "--cache-trusted-key", "laptop.pub"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	vres, err := Execute(context.Background(), inv)
+	if err != nil {
+		t.Fatalf("verify-cache: %v", err)
+	}
+	if f := vres.CacheVerify.Findings; len(f) != 1 || f[0].Status != CacheEntryUntrusted {
+		t.Fatalf("expected one untrusted finding, got %+v", f)
+	}
+}
+
+func TestParseInvocation_CacheSigningKeyErrors(t *testing.T) {
+	workDir := t.TempDir()
+	writeEd25519Keys(t, workDir, "k")
+	base := []string{"--workdir", workDir, "--graph", "g.json", "--cache-dir", "cache", "--output-dir", "out"}
+	for _, extra := range [][]string{
+		{"--cache-signing-key", "k.pub"},
+		{"--cache-trusted-key", "k.pem"},
+		{"--cache-trusted-key", "missing.pub"},
+	} {
+		_, err := ParseInvocation(append(append([]string{}, base...), extra...))
+		if ExitCode(err) != ExitInvalidInvocation {
+			t.Fatalf("%v: expected invalid invocation, got %v", extra, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/recovery/state"
+	"scriptweaver/internal/stats"
+)
+
+// CacheStatsInvocation is the canonical form of `scriptweaver cache stats`.
+type CacheStatsInvocation struct {
+	WorkDir string
+	// Since is the number of most recent runs to report; 0 means all runs.
+	Since int
+	// Namespace, when set, keeps only the runs that used this resolved cache
+	// namespace.
+	Namespace string
+	Format    string
+}
+
+// ParseCacheStatsInvocation parses the flags following `cache stats`.
+func ParseCacheStatsInvocation(args []string) (CacheStatsInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver cache stats", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	var since int
+	var format string
+	var namespace string
+
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+	fs.IntVar(&since, "since", 0, "Report the N most recent runs (0 = all runs).")
+	fs.StringVar(&format, "format", stats.FormatJSON, "Output format: json|csv")
+	fs.StringVar(&namespace, "namespace", "", "Report only runs that used this cache namespace (a name, or a graph hash for --cache-namespace graph).")
+
+	if err := fs.Parse(args); err != nil {
+		return CacheStatsInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 0 {
+		return CacheStatsInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return CacheStatsInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return CacheStatsInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	if since < 0 {
+		return CacheStatsInvocation{}, invalidInvocationf("--since must be >= 0 (got %d)", since)
+	}
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format != stats.FormatJSON && format != stats.FormatCSV {
+		return CacheStatsInvocation{}, invalidInvocationf("invalid --format %q (expected json|csv)", format)
+	}
+	if namespace == core.CacheNamespaceGraph {
+		return CacheStatsInvocation{}, invalidInvocationf("--namespace %q: pass the graph hash the namespace resolved to", namespace)
+	}
+	if _, err := core.ParseCacheNamespace(namespace); err != nil {
+		return CacheStatsInvocation{}, invalidInvocationf("--namespace: %v", err)
+	}
+	return CacheStatsInvocation{WorkDir: workDir, Since: since, Namespace: strings.TrimSpace(namespace), Format: format}, nil
+}
+
+// CacheStats writes the cache traffic and hit rate of recorded runs to stdout.
+func CacheStats(inv CacheStatsInvocation, stdout io.Writer) (int, error) {
+	st, err := state.NewStore(inv.WorkDir)
+	if err != nil {
+		return ExitInvalidInvocation, err
+	}
+	rep, err := stats.CollectCache(st, inv.Since, inv.Namespace)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	var buf bytes.Buffer
+	if err := rep.Write(&buf, inv.Format); err != nil {
+		return ExitInternalError, err
+	}
+	if _, err := stdout.Write(buf.Bytes()); err != nil {
+		return ExitInternalError, err
+	}
+	return ExitSuccess, nil
+}
+
+// runCache handles `cache stats`, `cache export`, `cache import` and
+// `cache warm`; `cache verify` is a run path (see RunArgs) and never reaches it.
+func runCache(args []string, stdout io.Writer) (int, error) {
+	if len(args) == 0 {
+		return ExitInvalidInvocation, invalidInvocationf("usage: scriptweaver cache stats|export|import|warm|verify [flags]")
+	}
+	switch args[0] {
+	case "stats":
+		inv, err := ParseCacheStatsInvocation(args[1:])
+		if err != nil {
+			return ExitCode(err), err
+		}
+		return CacheStats(inv, stdout)
+	case "export":
+		inv, err := ParseCacheExportInvocation(args[1:])
+		if err != nil {
+			return ExitCode(err), err
+		}
+		return CacheExport(inv, stdout)
+	case "import":
+		inv, err := ParseCacheImportInvocation(args[1:])
+		if err != nil {
+			return ExitCode(err), err
+		}
+		return CacheImport(inv, stdout)
+	case "warm":
+		inv, err := ParseCacheWarmInvocation(args[1:])
+		if err != nil {
+			return ExitCode(err), err
+		}
+		return CacheWarm(inv, stdout)
+	default:
+		return ExitInvalidInvocation, invalidInvocationf("unknown cache command %q (expected stats|export|import|warm|verify)", args[0])
+	}
+}
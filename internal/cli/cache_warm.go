@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/projectintegration/engine/workspace"
+	"scriptweaver/internal/recovery/state"
+)
+
+// CacheWarmInvocation is the canonical form of `scriptweaver cache warm`.
+type CacheWarmInvocation struct {
+	// WorkDir and CacheDir are the workspace and cache being warmed.
+	WorkDir  string
+	CacheDir string
+	// FromRun is the run whose checkpoints name the entries to restore.
+	FromRun string
+	// FromWorkDir holds .scriptweaver/runs/<FromRun>; it defaults to WorkDir.
+	FromWorkDir string
+	// FromCacheDir holds the run's cache entries; it defaults to CacheDir.
+	FromCacheDir string
+	// CacheSigning.Trusted, when set, rejects entries not signed by one of
+	// the keys.
+	CacheSigning CacheSigning
+}
+
+// CacheWarmReport is what `scriptweaver cache warm` prints.
+type CacheWarmReport struct {
+	RunID string `json:"run_id"`
+	// Tasks is sorted by name.
+	Tasks []CacheWarmTask `json:"tasks"`
+	// Imported and Skipped list the entries copied into CacheDir and those
+	// already in it; both are empty when the source cache is CacheDir.
+	Imported []core.TaskHash `json:"imported"`
+	Skipped  []core.TaskHash `json:"skipped"`
+}
+
+// CacheWarmTask is one checkpointed task whose artifacts were restored.
+type CacheWarmTask struct {
+	Task string `json:"task"`
+	Hash string `json:"hash"`
+	// Restored artifacts were written to the workspace; Verified ones were
+	// already present with the cached content and were left untouched.
+	Restored int `json:"restored"`
+	Verified int `json:"verified"`
+}
+
+// ParseCacheWarmInvocation parses the flags following `cache warm`.
+func ParseCacheWarmInvocation(args []string) (CacheWarmInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver cache warm", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	var cacheDir string
+	var fromRun string
+	var fromWorkDir string
+	var fromCacheDir string
+	var trustedKeys stringListFlag
+
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory to restore artifacts into. Required.")
+	fs.StringVar(&cacheDir, "cache-dir", "", "Cache directory to warm. Required.")
+	fs.StringVar(&fromRun, "from-run", "", "Run whose checkpointed cache entries to restore. Required.")
+	fs.StringVar(&fromWorkDir, "from-workdir", "", "Workspace holding the run's record (default: --workdir).")
+	fs.StringVar(&fromCacheDir, "from-cache-dir", "", "Cache holding the run's entries, e.g. a shared mount (default: --cache-dir).")
+	fs.Var(&trustedKeys, "cache-trusted-key", "PEM ed25519 public key whose cache entries are trusted (repeatable); entries not signed by a trusted key are rejected.")
+
+	if err := fs.Parse(args); err != nil {
+		return CacheWarmInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 0 {
+		return CacheWarmInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return CacheWarmInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return CacheWarmInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	if _, err := applyConfig(fs, workDir, nil); err != nil {
+		return CacheWarmInvocation{}, err
+	}
+	if cacheDir == "" {
+		return CacheWarmInvocation{}, invalidInvocationf("--cache-dir is required")
+	}
+	if fromRun == "" {
+		return CacheWarmInvocation{}, invalidInvocationf("--from-run is required")
+	}
+	if fromRun == "." || fromRun == ".." || strings.ContainsAny(fromRun, "/"+string(os.PathSeparator)) {
+		return CacheWarmInvocation{}, invalidInvocationf("invalid --from-run %q", fromRun)
+	}
+	signing, err := parseCacheSigning(workDir, "", trustedKeys)
+	if err != nil {
+		return CacheWarmInvocation{}, err
+	}
+
+	inv := CacheWarmInvocation{WorkDir: workDir, FromRun: fromRun, CacheSigning: signing}
+	if inv.CacheDir, err = resolveUnderWorkDir(workDir, cacheDir); err != nil {
+		return CacheWarmInvocation{}, err
+	}
+	inv.FromWorkDir, inv.FromCacheDir = workDir, inv.CacheDir
+	if fromWorkDir != "" {
+		if inv.FromWorkDir, err = resolveUnderWorkDir(workDir, fromWorkDir); err != nil {
+			return CacheWarmInvocation{}, err
+		}
+	}
+	if fromCacheDir != "" {
+		if inv.FromCacheDir, err = resolveUnderWorkDir(workDir, fromCacheDir); err != nil {
+			return CacheWarmInvocation{}, err
+		}
+	}
+	return inv, nil
+}
+
+// CacheWarm restores the cache entries named by a run's valid checkpoints:
+// entries missing from CacheDir are copied in from FromCacheDir, and every
+// entry's artifacts are restored into WorkDir, so the next incremental run
+// starts hot.
+//
+// Every entry is verified (and with --cache-trusted-key, its signature
+// checked) before anything is copied or restored; a missing, damaged or
+// untrusted entry fails the command with exit code 3 and changes nothing.
+func CacheWarm(inv CacheWarmInvocation, stdout io.Writer) (int, error) {
+	st, err := state.NewStore(inv.FromWorkDir)
+	if err != nil {
+		return ExitInvalidInvocation, err
+	}
+	if _, err := st.LoadRun(inv.FromRun); err != nil {
+		if os.IsNotExist(err) {
+			return ExitConfigError, fmt.Errorf("run %q not found in %s", inv.FromRun, inv.FromWorkDir)
+		}
+		return ExitConfigError, fmt.Errorf("run %s: %w", inv.FromRun, err)
+	}
+	cps, err := st.LoadAllCheckpoints(inv.FromRun)
+	if err != nil {
+		return ExitConfigError, fmt.Errorf("run %s: %w", inv.FromRun, err)
+	}
+	// The executor keys a task's entry by its first cache key.
+	tasks := make(map[string]core.TaskHash)
+	var nodes []string
+	for node, cp := range cps {
+		if cp.Valid && len(cp.CacheKeys) > 0 {
+			tasks[node] = core.TaskHash(cp.CacheKeys[0])
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Strings(nodes)
+
+	// Entries stay in the namespace the run used.
+	namespace := recordedCacheNamespace(st, CLIInvocation{}, inv.FromRun)
+	src := core.NewFileCache(core.NamespacedCacheDir(inv.FromCacheDir, namespace))
+	inv.CacheSigning.apply(src)
+	for _, node := range nodes {
+		if err := src.Verify(tasks[node]); err != nil {
+			return ExitConfigError, fmt.Errorf("task %q: %w", node, err)
+		}
+	}
+
+	// Restoring into the workspace and writing its cache would race with a
+	// run, so the lock is taken before anything changes.
+	ws, err := workspace.EnsureWorkspace(inv.WorkDir)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	lock, err := workspace.AcquireLock(ws)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	defer func() { _ = lock.Release() }()
+
+	report := CacheWarmReport{RunID: inv.FromRun, Tasks: []CacheWarmTask{}, Imported: []core.TaskHash{}, Skipped: []core.TaskHash{}}
+	dst := src
+	if filepath.Clean(inv.FromCacheDir) != filepath.Clean(inv.CacheDir) {
+		dst = core.NewFileCache(core.NamespacedCacheDir(inv.CacheDir, namespace))
+		inv.CacheSigning.apply(dst)
+		res, err := copyCacheEntries(src, dst, tasks)
+		if err != nil {
+			return ExitConfigError, err
+		}
+		report.Imported, report.Skipped = res.Imported, res.Skipped
+	}
+
+	replayer := core.NewReplayer(inv.WorkDir)
+	for _, node := range nodes {
+		hash := tasks[node]
+		entry, err := dst.Get(hash)
+		if err != nil {
+			return ExitConfigError, fmt.Errorf("task %q: %w", node, err)
+		}
+		if entry == nil {
+			return ExitConfigError, fmt.Errorf("task %q: cache entry %s: %w", node, hash, core.ErrCacheEntryMissing)
+		}
+		restored, verified, err := replayer.VerifyOrRestoreArtifacts(node, entry)
+		if err != nil {
+			return ExitConfigError, err
+		}
+		report.Tasks = append(report.Tasks, CacheWarmTask{Task: node, Hash: string(hash), Restored: restored, Verified: verified})
+	}
+	return writeCacheBundleReport(stdout, report)
+}
+
+// copyCacheEntries streams the entries for tasks from src to dst as a cache
+// bundle, so they get the same integrity checks as cache import.
+func copyCacheEntries(src, dst *core.FileCache, tasks map[string]core.TaskHash) (core.CacheImportResult, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := src.Export(pw, tasks)
+		pw.CloseWithError(err)
+	}()
+	res, err := dst.Import(pr)
+	// Unblock the exporter if Import stopped reading early.
+	pr.CloseWithError(io.ErrClosedPipe)
+	if err != nil {
+		return core.CacheImportResult{}, err
+	}
+	sort.Slice(res.Imported, func(i, j int) bool { return res.Imported[i] < res.Imported[j] })
+	sort.Slice(res.Skipped, func(i, j int) bool { return res.Skipped[i] < res.Skipped[j] })
+	return res, nil
+}
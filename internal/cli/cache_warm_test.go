@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/projectintegration/engine/workspace"
+)
+
+func TestCacheWarm_RestoresRunIntoFreshWorkspace(t *testing.T) {
+	tasks := []core.Task{
+		{Name: "gen", Run: "mkdir -p out && printf data > out/a.txt", Outputs: []string{"out/a.txt"}},
+		{Name: "use", Run: "cat out/a.txt > out/b.txt", Inputs: []string{"out/a.txt"}, Outputs: []string{"out/b.txt"}},
+	}
+	edges := []dag.Edge{{From: "gen", To: "use"}}
+	newWorkspace := func() CLIInvocation {
+		workDir := t.TempDir()
+		writeGraphJSON(t, filepath.Join(workDir, "graph.json"), tasks, edges)
+		return CLIInvocation{
+			WorkDir:       workDir,
+			GraphPath:     filepath.Join(workDir, "graph.json"),
+			CacheDir:      filepath.Join(workDir, "cache"),
+			OutputDir:     filepath.Join(workDir, "out"),
+			ExecutionMode: ExecutionModeIncremental,
+		}
+	}
+	clone := newWorkspace()
+	res, err := Execute(context.Background(), clone)
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("exit=%d err=%v", res.ExitCode, err)
+	}
+	runID := res.Metrics.RunID
+
+	// Task hashes depend on the workspace path, so the "other machine" is a
+	// fresh checkout at the same path: the run record and cache move to a
+	// shared location and everything else is wiped.
+	shared := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(shared, ".scriptweaver"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(clone.WorkDir, ".scriptweaver", "runs"), filepath.Join(shared, ".scriptweaver", "runs")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(clone.CacheDir, filepath.Join(shared, "cache")); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{".scriptweaver", "out"} {
+		if err := os.RemoveAll(filepath.Join(clone.WorkDir, p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	warm := []string{"cache", "warm", "--workdir", clone.WorkDir, "--cache-dir", "cache",
+		"--from-run", runID, "--from-workdir", shared, "--from-cache-dir", filepath.Join(shared, "cache")}
+
+	// A run holding the workspace lock keeps warm from restoring anything.
+	ws, err := workspace.EnsureWorkspace(clone.WorkDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := workspace.AcquireLock(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, _, err := Dispatch(warm, &bytes.Buffer{}); code != ExitConfigError || !errors.Is(err, workspace.ErrWorkspaceLocked) {
+		t.Fatalf("cache warm on a locked workspace: code=%d err=%v", code, err)
+	}
+	if _, err := os.Stat(filepath.Join(clone.WorkDir, "out")); !os.IsNotExist(err) {
+		t.Fatalf("cache warm restored artifacts without the lock (err=%v)", err)
+	}
+	_ = lock.Release()
+
+	var out bytes.Buffer
+	code, _, err := Dispatch(warm, &out)
+	if err != nil || code != ExitSuccess {
+		t.Fatalf("cache warm: code=%d err=%v", code, err)
+	}
+	var rep CacheWarmReport
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out.String())
+	}
+	if rep.RunID != runID || len(rep.Tasks) != 2 || len(rep.Imported) != 2 || rep.Tasks[0].Task != "gen" || rep.Tasks[0].Restored != 1 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	if b, err := os.ReadFile(filepath.Join(clone.WorkDir, "out", "b.txt")); err != nil || string(b) != "data" {
+		t.Fatalf("artifact not restored: %q, %v", b, err)
+	}
+
+	res, err = Execute(context.Background(), clone)
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("exit=%d err=%v", res.ExitCode, err)
+	}
+	for _, name := range []string{"gen", "use"} {
+		if st := res.GraphResult.FinalState[name]; st != dag.TaskCached {
+			t.Fatalf("%s: state %v, want cached after warm-up", name, st)
+		}
+	}
+
+	// A damaged source entry fails the warm-up before anything changes.
+	entries, _ := filepath.Glob(filepath.Join(shared, "cache", "*", "*", "artifacts", "0.blob"))
+	if len(entries) == 0 {
+		t.Fatal("no blobs in origin cache")
+	}
+	if err := os.WriteFile(entries[0], []byte("tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(clone.CacheDir); err != nil {
+		t.Fatal(err)
+	}
+	code, _, err = Dispatch(warm, &bytes.Buffer{})
+	if code != ExitConfigError || !core.IsCacheCorrupt(err) {
+		t.Fatalf("tampered entry: code=%d err=%v", code, err)
+	}
+	if _, err := os.Stat(clone.CacheDir); !os.IsNotExist(err) {
+		t.Fatalf("failed warm-up created the cache: %v", err)
+	}
+
+	code, _, _ = Dispatch([]string{"cache", "warm", "--workdir", clone.WorkDir, "--cache-dir", "cache", "--from-run", "missing"}, &bytes.Buffer{})
+	if code != ExitConfigError {
+		t.Fatalf("unknown run: code=%d", code)
+	}
+}
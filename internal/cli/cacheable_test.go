@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/trace"
+)
+
+func TestExecute_NotCacheableAlwaysExecutes(t *testing.T) {
+	workDir := t.TempDir()
+	no := false
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "build", Run: "echo b >> build.log && echo b > app.out", Outputs: []string{"app.out"}},
+		{Name: "deploy", Inputs: []string{"app.out"}, Run: "echo d >> deploy.log", Cacheable: &no},
+	}, []dag.Edge{{From: "build", To: "deploy"}})
+	args := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--trace", "trace.json"}
+	run := func(extra ...string) CLIResult {
+		t.Helper()
+		inv, err := ParseInvocation(append(append([]string(nil), args...), extra...))
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+		}
+		return res
+	}
+
+	run()
+	res := run()
+	for file, want := range map[string]int{"build.log": 1, "deploy.log": 2} {
+		b, _ := os.ReadFile(filepath.Join(workDir, file))
+		if n := strings.Count(string(b), "\n"); n != want {
+			t.Fatalf("%s has %d lines, want %d", file, n, want)
+		}
+	}
+	cache := core.NewFileCache(filepath.Join(workDir, "cache"))
+	if ok, err := cache.Has(res.GraphResult.TaskHashes["deploy"]); ok || err != nil {
+		t.Fatalf("expected no cache entry for deploy, has=%v err=%v", ok, err)
+	}
+	if ok, _ := cache.Has(res.GraphResult.TaskHashes["build"]); !ok {
+		t.Fatal("expected build to be cached")
+	}
+
+	tr, err := os.ReadFile(filepath.Join(workDir, "trace.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := trace.ParseExecutionTrace(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, ev := range parsed.Events {
+		if ev.TaskID == "deploy" && ev.Kind == trace.EventTaskExecuted {
+			found = ev.Reason == trace.ReasonNotCacheable
+		}
+	}
+	if !found {
+		t.Fatalf("expected deploy to execute with reason NotCacheable: %s", tr)
+	}
+
+	dry := run("--dry-run")
+	for _, task := range dry.DryRun.Tasks {
+		if task.Name == "deploy" && task.Reason != DryRunReasonNotCacheable {
+			t.Fatalf("dry run reports deploy as %s/%s", task.Action, task.Reason)
+		}
+	}
+}
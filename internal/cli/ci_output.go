@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+// CIOutput selects how RunCommand renders task results for a CI system.
+type CIOutput string
+
+const (
+	CIOutputNone   CIOutput = ""
+	CIOutputGitHub CIOutput = "github"
+	CIOutputGitLab CIOutput = "gitlab"
+)
+
+// writeCIOutput renders res for inv.CIOutput: every task that executed, was
+// restored or failed gets a collapsible group headed by its name and outcome
+// and holding its stdout and stderr, in execution order. Failed tasks are
+// annotated as errors against the graph file, and failures allowed by
+// allow_failure as warnings. Nothing is written for other invocations.
+func writeCIOutput(w io.Writer, inv CLIInvocation, res CLIResult) error {
+	gr := res.GraphResult
+	if inv.CIOutput == CIOutputNone || gr == nil || res.Metrics == nil {
+		return nil
+	}
+	order := make(map[string]int, len(gr.ExecutionOrder))
+	for i, name := range gr.ExecutionOrder {
+		order[name] = i + 1
+	}
+	tasks := make([]state.TaskMetric, 0, len(res.Metrics.Tasks))
+	for _, m := range res.Metrics.Tasks {
+		if m.Outcome != state.TaskOutcomeSkipped {
+			tasks = append(tasks, m)
+		}
+	}
+	// Started tasks in the order they started, then tasks restored without
+	// starting, by name.
+	sort.SliceStable(tasks, func(i, j int) bool {
+		oi, oj := order[tasks[i].NodeID], order[tasks[j].NodeID]
+		if (oi == 0) != (oj == 0) {
+			return oi != 0
+		}
+		return oi < oj
+	})
+
+	graphFile := inv.GraphPath
+	if rel, err := filepath.Rel(inv.WorkDir, graphFile); err == nil {
+		graphFile = filepath.ToSlash(rel)
+	}
+	ci := ciFormats[inv.CIOutput]
+	var b strings.Builder
+	for _, m := range tasks {
+		name := m.NodeID
+		title := fmt.Sprintf("%s (%s, %s)", name, m.Outcome, time.Duration(m.DurationMillis)*time.Millisecond)
+		output := string(gr.Stdout[name]) + string(gr.Stderr[name])
+		if output != "" && !strings.HasSuffix(output, "\n") {
+			output += "\n"
+		}
+		ci.group(&b, name, title, output)
+		switch gr.FinalState[name] {
+		case dag.TaskFailed:
+			ci.annotate(&b, "error", graphFile, fmt.Sprintf("Task %s failed", name), fmt.Sprintf("task %s exited with code %d", name, gr.ExitCode[name]))
+		case dag.TaskFailedAllowed:
+			ci.annotate(&b, "warning", graphFile, fmt.Sprintf("Task %s failed (allowed)", name), fmt.Sprintf("task %s exited with code %d; allow_failure is set", name, gr.ExitCode[name]))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ciFormat writes a CI system's log markup.
+type ciFormat struct {
+	group    func(b *strings.Builder, id, title, body string)
+	annotate func(b *strings.Builder, level, file, title, message string)
+}
+
+var ciFormats = map[CIOutput]ciFormat{
+	CIOutputGitHub: {group: githubGroup, annotate: githubAnnotate},
+	CIOutputGitLab: {group: gitlabSection, annotate: gitlabAnnotate},
+}
+
+// githubGroup writes a workflow-command group. Task output is fenced with
+// stop-commands, so text it prints cannot issue workflow commands.
+func githubGroup(b *strings.Builder, _, title, body string) {
+	fmt.Fprintf(b, "::group::%s\n", githubEscapeData(title))
+	if body != "" {
+		token := make([]byte, 16)
+		_, _ = rand.Read(token)
+		t := hex.EncodeToString(token)
+		fmt.Fprintf(b, "::stop-commands::%s\n%s::%s::\n", t, body, t)
+	}
+	b.WriteString("::endgroup::\n")
+}
+
+func githubAnnotate(b *strings.Builder, level, file, title, message string) {
+	fmt.Fprintf(b, "::%s file=%s,title=%s::%s\n", level, githubEscapeProperty(file), githubEscapeProperty(title), githubEscapeData(message))
+}
+
+func githubEscapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func githubEscapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// gitlabSection writes a collapsible GitLab job log section. Section names
+// may only hold letters, digits, '_', '.' and '-'.
+func gitlabSection(b *strings.Builder, id, title, body string) {
+	name := "task_" + strings.Map(func(r rune) rune {
+		if r == '_' || r == '.' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, id)
+	now := time.Now().Unix()
+	fmt.Fprintf(b, "\x1b[0Ksection_start:%d:%s[collapsed=true]\r\x1b[0K%s\n", now, name, strings.ReplaceAll(title, "\n", " "))
+	b.WriteString(body)
+	fmt.Fprintf(b, "\x1b[0Ksection_end:%d:%s\r\x1b[0K\n", now, name)
+}
+
+// gitlabAnnotate writes a highlighted line; GitLab has no log annotations.
+func gitlabAnnotate(b *strings.Builder, level, file, title, message string) {
+	color := "31"
+	if level == "warning" {
+		color = "33"
+	}
+	fmt.Fprintf(b, "\x1b[%s;1m%s: %s: %s: %s\x1b[0m\n", color, strings.ToUpper(level), file, title, message)
+}
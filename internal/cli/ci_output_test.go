@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func TestRunCommand_CIOutputGitHub(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "echo built > a.out; echo building", Outputs: []string{"a.out"}},
+		{Name: "b", Run: "echo '::error::injected'; exit 3"},
+		{Name: "c", Run: "true"},
+	}, []dag.Edge{{From: "a", To: "b"}, {From: "b", To: "c"}})
+	run := func() string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		code := RunCommand(context.Background(), []string{"run", "--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--ci-output", "github"}, &stdout, &stderr)
+		if code != ExitGraphFailure {
+			t.Fatalf("exit %d, stderr %s", code, stderr.String())
+		}
+		return stdout.String()
+	}
+
+	first := run()
+	if !regexp.MustCompile(`(?s)^::group::a \(executed, [^)]*\)\n::stop-commands::(\w+)\nbuilding\n::(\w+)::\n::endgroup::\n::group::b \(failed, `).MatchString(first) {
+		t.Fatalf("unexpected output:\n%s", first)
+	}
+	if !strings.Contains(first, "::error file=graph.json,title=Task b failed::task b exited with code 3\n") {
+		t.Fatalf("missing annotation:\n%s", first)
+	}
+	// b's own workflow command is fenced by stop-commands.
+	if m := regexp.MustCompile(`::stop-commands::(\w+)\n::error::injected\n::(\w+)::\n`).FindStringSubmatch(first); m == nil || m[1] != m[2] {
+		t.Fatalf("task output not fenced:\n%s", first)
+	}
+	if strings.Contains(first, "::group::c") {
+		t.Fatalf("skipped task rendered:\n%s", first)
+	}
+
+	if second := run(); !strings.Contains(second, "::group::a (cached, ") {
+		t.Fatalf("expected a to be reported as cached:\n%s", second)
+	}
+}
+
+func TestRunCommand_CIOutputGitLab(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{{Name: "lint:go", Run: "echo warn; exit 1", AllowFailure: true}}, nil)
+	var stdout, stderr bytes.Buffer
+	code := RunCommand(context.Background(), []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--ci-output", "gitlab"}, &stdout, &stderr)
+	if code != ExitSuccess {
+		t.Fatalf("exit %d, stderr %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !regexp.MustCompile(`^\x1b\[0Ksection_start:\d+:task_lint_go\[collapsed=true\]\r\x1b\[0Klint:go \(failed, [^)]*\)\nwarn\n\x1b\[0Ksection_end:\d+:task_lint_go\r\x1b\[0K\n`).MatchString(out) {
+		t.Fatalf("unexpected output %q", out)
+	}
+	if !strings.Contains(out, "WARNING: graph.json: Task lint:go failed (allowed)") {
+		t.Fatalf("missing warning %q", out)
+	}
+}
+
+func TestParseInvocation_CIOutputValues(t *testing.T) {
+	_, err := ParseInvocation([]string{"--workdir", t.TempDir(), "--graph", "g.json", "--cache-dir", "c", "--output-dir", "o", "--ci-output", "jenkins"})
+	if ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected invalid invocation, got %v", err)
+	}
+}
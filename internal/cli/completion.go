@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// completionShells lists the shells `scriptweaver completion` generates
+// scripts for.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+func runCompletion(args []string, stdout io.Writer) (int, error) {
+	if len(args) != 1 {
+		return ExitInvalidInvocation, invalidInvocationf("usage: scriptweaver completion bash|zsh|fish")
+	}
+	var script string
+	switch args[0] {
+	case "bash":
+		script = bashCompletion()
+	case "zsh":
+		script = zshCompletion()
+	case "fish":
+		script = fishCompletion()
+	default:
+		return ExitInvalidInvocation, invalidInvocationf("unsupported shell %q (want bash, zsh or fish)", args[0])
+	}
+	if _, err := io.WriteString(stdout, script); err != nil {
+		return ExitInternalError, err
+	}
+	return ExitSuccess, nil
+}
+
+// completionWords returns the top-level command words in help order and, for
+// each word that is only a prefix (cache, runs, ...), the words that may
+// follow it. completion and help get their arguments as follow-up words.
+func completionWords() (top []string, next map[string][]string) {
+	next = map[string][]string{"completion": completionShells}
+	seen := map[string]bool{}
+	for _, c := range commands {
+		first, rest, _ := strings.Cut(c.name, " ")
+		if !seen[first] {
+			seen[first] = true
+			top = append(top, first)
+		}
+		if rest != "" {
+			next[first] = append(next[first], rest)
+		}
+	}
+	next["help"] = top
+	return top, next
+}
+
+// commandFlags returns the flags of c in lexical order.
+func commandFlags(c command) []*flag.Flag {
+	if c.flags == nil {
+		return nil
+	}
+	fs := c.flags()
+	if fs == nil {
+		return nil
+	}
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) { flags = append(flags, f) })
+	return flags
+}
+
+// flagWords joins c's flags as --name words.
+func flagWords(c command) string {
+	var words []string
+	for _, f := range commandFlags(c) {
+		words = append(words, "--"+f.Name)
+	}
+	return strings.Join(words, " ")
+}
+
+// firstSentence shortens a usage or summary string for completion menus.
+func firstSentence(s string) string {
+	if i := strings.Index(s, ". "); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSuffix(s, ".")
+}
+
+func bashCompletion() string {
+	top, next := completionWords()
+	var b strings.Builder
+	b.WriteString("# bash completion for scriptweaver\n")
+	b.WriteString("# Generated by `scriptweaver completion bash`; do not edit.\n\n")
+	b.WriteString("_scriptweaver() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" cmd\n")
+	fmt.Fprintf(&b, "    if [ \"$COMP_CWORD\" -eq 1 ] && [[ \"$cur\" != -* ]]; then\n        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n        return\n    fi\n", strings.Join(top, " "))
+	b.WriteString("    if [ \"$COMP_CWORD\" -eq 2 ]; then\n        case \"${COMP_WORDS[1]}\" in\n")
+	for _, w := range top {
+		if words, ok := next[w]; ok {
+			fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", w, strings.Join(words, " "))
+		}
+	}
+	b.WriteString("        esac\n    fi\n")
+	b.WriteString("    case \"${COMP_WORDS[1]}\" in\n")
+	b.WriteString("    -*) cmd=run ;;\n")
+	for _, w := range top {
+		if _, ok := next[w]; ok && w != "completion" && w != "help" {
+			fmt.Fprintf(&b, "    %s) cmd=\"%s ${COMP_WORDS[2]}\" ;;\n", w, w)
+		}
+	}
+	b.WriteString("    *) cmd=\"${COMP_WORDS[1]}\" ;;\n    esac\n")
+	b.WriteString("    if [[ \"$cur\" == -* ]]; then\n        case \"$cmd\" in\n")
+	for _, c := range commands {
+		if words := flagWords(c); words != "" {
+			fmt.Fprintf(&b, "        %q) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", c.name, words)
+		}
+	}
+	b.WriteString("        esac\n        return\n    fi\n")
+	b.WriteString("    COMPREPLY=($(compgen -f -- \"$cur\"))\n}\n\n")
+	b.WriteString("complete -o filenames -F _scriptweaver scriptweaver\n")
+	return b.String()
+}
+
+func zshCompletion() string {
+	top, next := completionWords()
+	summaries := map[string]string{}
+	for _, c := range commands {
+		first, _, _ := strings.Cut(c.name, " ")
+		if _, ok := summaries[first]; !ok {
+			summaries[first] = c.summary
+		}
+	}
+	var b strings.Builder
+	b.WriteString("#compdef scriptweaver\n")
+	b.WriteString("# zsh completion for scriptweaver\n")
+	b.WriteString("# Generated by `scriptweaver completion zsh`; do not edit.\n\n")
+	b.WriteString("_scriptweaver() {\n")
+	b.WriteString("    local cmd\n")
+	b.WriteString("    if (( CURRENT == 2 )) && [[ $words[CURRENT] != -* ]]; then\n        local -a commands\n        commands=(\n")
+	for _, w := range top {
+		desc := firstSentence(summaries[w])
+		if _, ok := next[w]; ok && w != "completion" && w != "help" {
+			desc = "Subcommands: " + strings.Join(next[w], ", ")
+		}
+		fmt.Fprintf(&b, "            %s\n", zshQuote(w+":"+strings.ReplaceAll(desc, ":", "\\:")))
+	}
+	b.WriteString("        )\n        _describe -t commands command commands\n        return\n    fi\n")
+	b.WriteString("    if (( CURRENT == 3 )); then\n        case $words[2] in\n")
+	for _, w := range top {
+		if words, ok := next[w]; ok {
+			fmt.Fprintf(&b, "        %s) compadd -- %s; return ;;\n", w, strings.Join(words, " "))
+		}
+	}
+	b.WriteString("        esac\n    fi\n")
+	b.WriteString("    case $words[2] in\n")
+	b.WriteString("    -*) cmd=run ;;\n")
+	for _, w := range top {
+		if _, ok := next[w]; ok && w != "completion" && w != "help" {
+			fmt.Fprintf(&b, "    %s) cmd=\"%s $words[3]\" ;;\n", w, w)
+		}
+	}
+	b.WriteString("    *) cmd=$words[2] ;;\n    esac\n")
+	b.WriteString("    if [[ $words[CURRENT] == -* ]]; then\n        case $cmd in\n")
+	for _, c := range commands {
+		if words := flagWords(c); words != "" {
+			fmt.Fprintf(&b, "        %s) compadd -- %s ;;\n", zshQuote(c.name), words)
+		}
+	}
+	b.WriteString("        esac\n        return\n    fi\n")
+	b.WriteString("    _files\n}\n\n")
+	b.WriteString("if [ \"$funcstack[1]\" = \"_scriptweaver\" ]; then\n    _scriptweaver \"$@\"\nelse\n    compdef _scriptweaver scriptweaver\nfi\n")
+	return b.String()
+}
+
+func fishCompletion() string {
+	_, next := completionWords()
+	var b strings.Builder
+	b.WriteString("# fish completion for scriptweaver\n")
+	b.WriteString("# Generated by `scriptweaver completion fish`; do not edit.\n\n")
+	seen := map[string]bool{}
+	for _, c := range commands {
+		first, rest, _ := strings.Cut(c.name, " ")
+		if !seen[first] {
+			seen[first] = true
+			desc := firstSentence(c.summary)
+			if rest != "" {
+				desc = "Subcommands: " + strings.Join(next[first], ", ")
+			}
+			fmt.Fprintf(&b, "complete -c scriptweaver -n __fish_use_subcommand -f -a %s -d %s\n", first, fishQuote(desc))
+		}
+		if rest != "" {
+			fmt.Fprintf(&b, "complete -c scriptweaver -n %s -f -a %s -d %s\n", fishQuote("__fish_seen_subcommand_from "+first), rest, fishQuote(firstSentence(c.summary)))
+		}
+	}
+	for _, w := range []string{"completion", "help"} {
+		fmt.Fprintf(&b, "complete -c scriptweaver -n %s -f -a %s\n", fishQuote("__fish_seen_subcommand_from "+w), fishQuote(strings.Join(next[w], " ")))
+	}
+	for _, c := range commands {
+		cond := "__fish_seen_subcommand_from " + strings.ReplaceAll(c.name, " ", "; and __fish_seen_subcommand_from ")
+		for _, f := range commandFlags(c) {
+			fmt.Fprintf(&b, "complete -c scriptweaver -n %s -l %s -d %s\n", fishQuote(cond), f.Name, fishQuote(firstSentence(f.Usage)))
+			if c.name == "run" {
+				// The command name may be omitted for run.
+				fmt.Fprintf(&b, "complete -c scriptweaver -n __fish_use_subcommand -l %s -d %s\n", f.Name, fishQuote(firstSentence(f.Usage)))
+			}
+		}
+	}
+	return b.String()
+}
+
+// zshQuote single-quotes s for zsh.
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes s for fish, where only \ and ' are escaped.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
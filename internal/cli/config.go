@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"flag"
+	"sort"
+
+	"scriptweaver/internal/config"
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/recovery/state"
+)
+
+// configKeys lists the scriptweaver.toml settings and the flags they
+// default. Flags that describe a single invocation (--workdir, --dry-run,
+// --resume-from, ...) cannot be configured.
+var configKeys = map[string]string{
+	"graph":                 "graph",
+	"cache_dir":             "cache-dir",
+	"output_dir":            "output-dir",
+	"trace":                 "trace",
+	"mode":                  "mode",
+	"concurrency":           "concurrency",
+	"normalize_logs":        "normalize-logs",
+	"cache_compression":     "cache-compression",
+	"cache_namespace":       "cache-namespace",
+	"max_output_bytes":      "max-output-bytes",
+	"strict":                "strict",
+	"isolate":               "isolate",
+	"protect_inputs":        "protect-inputs",
+	"allow_outside_workdir": "allow-outside-workdir",
+	"absolute_workdir_hash": "absolute-workdir-hash",
+	"container_engine":      "container-engine",
+	"overwrite":             "overwrite",
+	"run_id_strategy":       "run-id-strategy",
+	"keep_runs":             "keep-runs",
+	"max_run_age":           "max-run-age",
+	"otel_endpoint":         "otel-endpoint",
+	"ci_output":             "ci-output",
+	"log_level":             "log-level",
+	"log_format":            "log-format",
+}
+
+// Sources of an InvocationSetting.
+const (
+	settingFromFlag    = "flag"
+	settingFromConfig  = "config"
+	settingFromDefault = "default"
+)
+
+// projectConfig is workDir's scriptweaver.toml applied to a parsed flag set.
+type projectConfig struct {
+	// Normalize rules are prepended to every task's normalize rules.
+	Normalize []core.NormalizeRule
+	// Notify are the webhooks posted to when a run ends.
+	Notify []config.Notifier
+	// Settings is every flag and param with its resolved value and source,
+	// sorted by name, for the run record.
+	Settings []state.InvocationSetting
+}
+
+// applyConfig loads workDir's scriptweaver.toml, if any, with the
+// precedence flags > config > defaults: each configured flag of fs not given
+// on the command line is set from the config, and configured params are
+// added to params unless given with --param. Keys whose flag fs does not
+// define are ignored, so commands share one config file.
+func applyConfig(fs *flag.FlagSet, workDir string, params paramFlags) (projectConfig, error) {
+	cfg, err := config.Load(workDir)
+	if err != nil {
+		return projectConfig{}, &InvocationError{ExitCode: ExitConfigError, Message: err.Error()}
+	}
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	paramSource := map[string]string{}
+	for k := range params {
+		paramSource[k] = settingFromFlag
+	}
+
+	fromConfig := map[string]bool{}
+	var out projectConfig
+	if cfg != nil {
+		for _, key := range cfg.Keys() {
+			name, ok := configKeys[key]
+			if !ok {
+				return projectConfig{}, &InvocationError{ExitCode: ExitConfigError, Message: "config: unknown key " + key}
+			}
+			if given[name] || fs.Lookup(name) == nil {
+				continue
+			}
+			if err := fs.Set(name, cfg.Settings[key]); err != nil {
+				return projectConfig{}, &InvocationError{ExitCode: ExitConfigError, Message: "config: " + key + ": " + err.Error()}
+			}
+			fromConfig[name] = true
+		}
+		if params != nil {
+			for k, v := range cfg.Params {
+				if _, ok := params[k]; !ok {
+					params[k] = v
+					paramSource[k] = settingFromConfig
+				}
+			}
+		}
+		out.Normalize = cfg.Normalize
+		out.Notify = cfg.Notify
+	}
+
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "param" {
+			return
+		}
+		source := settingFromDefault
+		switch {
+		case given[f.Name]:
+			source = settingFromFlag
+		case fromConfig[f.Name]:
+			source = settingFromConfig
+		}
+		out.Settings = append(out.Settings, state.InvocationSetting{Name: f.Name, Value: f.Value.String(), Source: source})
+	})
+	for k, source := range paramSource {
+		out.Settings = append(out.Settings, state.InvocationSetting{Name: "param." + k, Value: params[k], Source: source})
+	}
+	sort.Slice(out.Settings, func(i, j int) bool { return out.Settings[i].Name < out.Settings[j].Name })
+	return out, nil
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/recovery/state"
+)
+
+func writeConfig(t *testing.T, workDir, src string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(workDir, "scriptweaver.toml"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseInvocation_ConfigPrecedence(t *testing.T) {
+	workDir := t.TempDir()
+	writeConfig(t, workDir, `graph = "graph.json"
+cache_dir = ".cache"
+output_dir = "out"
+mode = "clean"
+concurrency = 3
+
+[params]
+release = "true"
+target = "linux"
+`)
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--mode", "incremental", "--param", "target=darwin"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if inv.GraphPath != filepath.Join(workDir, "graph.json") || inv.CacheDir != filepath.Join(workDir, ".cache") || inv.Concurrency != 3 {
+		t.Fatalf("config defaults not applied: %+v", inv)
+	}
+	if inv.ExecutionMode != ExecutionModeIncremental {
+		t.Fatalf("flag must win over config, got mode %q", inv.ExecutionMode)
+	}
+	if inv.Params["release"] != "true" || inv.Params["target"] != "darwin" {
+		t.Fatalf("params = %v", inv.Params)
+	}
+
+	sources := map[string]string{}
+	for _, s := range inv.Settings {
+		sources[s.Name] = s.Source + ":" + s.Value
+	}
+	for name, want := range map[string]string{
+		"mode":           "flag:incremental",
+		"cache-dir":      "config:.cache",
+		"concurrency":    "config:3",
+		"strict":         "default:false",
+		"param.release":  "config:true",
+		"param.target":   "flag:darwin",
+		"workdir":        "flag:" + workDir,
+		"normalize-logs": "default:false",
+	} {
+		if sources[name] != want {
+			t.Errorf("setting %s = %q, want %q", name, sources[name], want)
+		}
+	}
+}
+
+func TestParseInvocation_ConfigErrors(t *testing.T) {
+	for name, src := range map[string]string{
+		"unknown key":        "dry_run = true\n",
+		"invalid value":      "concurrency = \"many\"\n",
+		"malformed":          "mode = \n",
+		"invocation setting": "workdir = \"/elsewhere\"\n",
+	} {
+		workDir := t.TempDir()
+		writeConfig(t, workDir, src)
+		_, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "g.json", "--cache-dir", "c", "--output-dir", "o"})
+		if ExitCode(err) != ExitConfigError {
+			t.Errorf("%s: expected exit %d, got %v", name, ExitConfigError, err)
+		}
+	}
+}
+
+func TestExecute_ConfigNormalizeAndRunRecord(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "gen", Run: "echo build-$$ > gen.out", Outputs: []string{"gen.out"}},
+		{Name: "other", Run: "true"},
+	}, nil)
+	writeConfig(t, workDir, `graph = "graph.json"
+cache_dir = "cache"
+output_dir = "out"
+concurrency = 2
+
+[[normalize]]
+pattern = "build-[0-9]+"
+replacement = "build-N"
+`)
+	inv, err := ParseInvocation([]string{"--workdir", workDir})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	// Rules apply to what is cached; the second run restores that copy.
+	for i := 0; i < 2; i++ {
+		if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run %d: exit %d err %v", i, res.ExitCode, err)
+		}
+	}
+	if got, _ := os.ReadFile(filepath.Join(workDir, "gen.out")); string(got) != "build-N\n" {
+		t.Fatalf("artifact = %q", got)
+	}
+
+	st, err := state.NewStore(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, _ := st.ListRunIDs()
+	if len(ids) != 2 {
+		t.Fatalf("runs = %v", ids)
+	}
+	for _, id := range ids {
+		run, err := st.LoadRun(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, s := range run.Invocation {
+			if s.Name == "concurrency" {
+				found = s.Value == "2" && s.Source == "config"
+			}
+		}
+		if !found {
+			t.Fatalf("run %s does not echo concurrency from the config: %+v", id, run.Invocation)
+		}
+	}
+}
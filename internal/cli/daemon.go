@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"scriptweaver/internal/projectintegration/engine/workspace"
+)
+
+// Daemon endpoints, served over the workspace's Unix socket.
+const (
+	daemonRunPath  = "/run"
+	daemonStopPath = "/stop"
+)
+
+// DaemonInvocation is the canonical form of `scriptweaver daemon start` and
+// `scriptweaver daemon stop`.
+type DaemonInvocation struct {
+	WorkDir string
+}
+
+// ParseDaemonInvocation parses the flags following `daemon start` or
+// `daemon stop`.
+func ParseDaemonInvocation(args []string) (DaemonInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver daemon", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory whose .scriptweaver holds the daemon socket. Required.")
+
+	if err := fs.Parse(args); err != nil {
+		return DaemonInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 0 {
+		return DaemonInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
+	}
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return DaemonInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return DaemonInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	return DaemonInvocation{WorkDir: workDir}, nil
+}
+
+// daemonSocket is the socket of the daemon serving workDir.
+func daemonSocket(workDir string) string {
+	return filepath.Join(workDir, ".scriptweaver", workspace.DaemonSocketName)
+}
+
+// daemonRequest asks the daemon to run a run-path invocation; Args are as
+// passed to RunCommand, e.g. ["plan", "--workdir", ...].
+type daemonRequest struct {
+	Args []string `json:"args"`
+}
+
+// daemonResponse is what the invocation wrote and its exit code.
+type daemonResponse struct {
+	ExitCode int    `json:"exit_code"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+}
+
+// daemon serves run-path invocations with warm state (see warmState). It is
+// an http.Handler; invocations run one at a time, as they would contend for
+// the workspace lock anyway.
+type daemon struct {
+	mu   sync.Mutex
+	warm *warmState
+	// stop is closed by a request to daemonStopPath.
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// newDaemon returns a daemon with nothing warm yet.
+func newDaemon() *daemon {
+	return &daemon{warm: newWarmState(), stop: make(chan struct{})}
+}
+
+func (d *daemon) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(rw, "method must be POST", http.StatusMethodNotAllowed)
+		return
+	}
+	switch req.URL.Path {
+	case daemonRunPath:
+		var in daemonRequest
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			http.Error(rw, fmt.Sprintf("decoding request: %v", err), http.StatusBadRequest)
+			return
+		}
+		out := d.run(req.Context(), in.Args)
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(out)
+	case daemonStopPath:
+		d.stopOnce.Do(func() { close(d.stop) })
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(rw, "unknown endpoint "+req.URL.Path, http.StatusNotFound)
+	}
+}
+
+// run runs args as RunCommand would, with the daemon's warm state.
+func (d *daemon) run(ctx context.Context, args []string) daemonResponse {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var stdout, stderr bytes.Buffer
+	code := runCommand(ctx, args, &stdout, &stderr, d.warm)
+	return daemonResponse{ExitCode: code, Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// serve serves d on l until a stop request arrives or ctx is done.
+func (d *daemon) serve(ctx context.Context, l net.Listener) error {
+	srv := &http.Server{Handler: d}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(l) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-d.stop:
+	case <-ctx.Done():
+	}
+	return srv.Shutdown(context.Background())
+}
+
+// listenDaemon listens on workDir's daemon socket, replacing a socket left
+// behind by a daemon that is no longer running.
+func listenDaemon(workDir string) (net.Listener, error) {
+	if _, err := workspace.EnsureWorkspace(workDir); err != nil {
+		return nil, err
+	}
+	socket := daemonSocket(workDir)
+	if conn, err := net.Dial("unix", socket); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already listening on %s", socket)
+	}
+	if err := os.Remove(socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return net.Listen("unix", socket)
+}
+
+// daemonClient returns an HTTP client whose connections go to socket.
+func daemonClient(socket string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}}
+}
+
+// postDaemon sends body to the daemon serving workDir and decodes its reply
+// into out, if out is not nil.
+func postDaemon(ctx context.Context, workDir, path string, body, out any) error {
+	socket := daemonSocket(workDir)
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://daemon"+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	resp, err := daemonClient(socket).Do(req)
+	if err != nil {
+		return &InvocationError{ExitCode: ExitConfigError, Message: fmt.Sprintf("no daemon is listening on %s; start one with `scriptweaver daemon start --workdir %s`", socket, workDir)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("daemon: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func runDaemon(args []string, stdout io.Writer) (int, error) {
+	if len(args) == 0 {
+		return ExitInvalidInvocation, invalidInvocationf("usage: scriptweaver daemon start|stop --workdir <dir>, or scriptweaver daemon run|plan [flags]")
+	}
+	switch args[0] {
+	case "start":
+		inv, err := ParseDaemonInvocation(args[1:])
+		if err != nil {
+			return ExitCode(err), err
+		}
+		l, err := listenDaemon(inv.WorkDir)
+		if err != nil {
+			return ExitConfigError, fmt.Errorf("daemon: %w", err)
+		}
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		fmt.Fprintf(stdout, "scriptweaver daemon listening on %s\n", l.Addr())
+		if err := newDaemon().serve(ctx, l); err != nil {
+			return ExitConfigError, fmt.Errorf("daemon: %w", err)
+		}
+		return ExitSuccess, nil
+	case "stop":
+		inv, err := ParseDaemonInvocation(args[1:])
+		if err != nil {
+			return ExitCode(err), err
+		}
+		if err := postDaemon(context.Background(), inv.WorkDir, daemonStopPath, struct{}{}, nil); err != nil {
+			return ExitCode(err), err
+		}
+		return ExitSuccess, nil
+	case "run", "plan":
+		return runViaDaemon(args, stdout)
+	}
+	return ExitInvalidInvocation, invalidInvocationf("unknown daemon command %q", args[0])
+}
+
+// runViaDaemon sends `daemon run|plan` arguments to the daemon serving their
+// --workdir. They are parsed here first, so invalid invocations and help
+// requests never reach the daemon. The daemon's stderr is returned as the
+// error, for the caller to print.
+func runViaDaemon(args []string, stdout io.Writer) (int, error) {
+	_, runArgs := RunArgs(args)
+	inv, err := ParseInvocation(runArgs)
+	if err != nil {
+		return ExitCode(err), err
+	}
+	var out daemonResponse
+	if err := postDaemon(context.Background(), inv.WorkDir, daemonRunPath, daemonRequest{Args: args}, &out); err != nil {
+		return ExitCode(err), err
+	}
+	if _, err := io.WriteString(stdout, out.Stdout); err != nil {
+		return ExitInternalError, err
+	}
+	if out.Stderr != "" {
+		return out.ExitCode, errors.New(strings.TrimSuffix(out.Stderr, "\n"))
+	}
+	return out.ExitCode, nil
+}
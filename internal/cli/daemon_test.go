@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"scriptweaver/internal/core"
+)
+
+func TestDaemon_ServesRunAndPlanWithWarmState(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{{Name: "a", Run: "echo a >> a.log && echo a > a.out", Outputs: []string{"a.out"}}}, nil)
+
+	l, err := listenDaemon(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := newDaemon()
+	done := make(chan error, 1)
+	go func() { done <- d.serve(context.Background(), l) }()
+
+	if _, err := listenDaemon(workDir); err == nil || !strings.Contains(err.Error(), "already listening") {
+		t.Fatalf("expected a second daemon to be refused, got %v", err)
+	}
+
+	flags := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"}
+	via := func(command string) (int, string, error) {
+		t.Helper()
+		var stdout bytes.Buffer
+		code, err := runDaemon(append([]string{command}, flags...), &stdout)
+		return code, stdout.String(), err
+	}
+
+	if code, _, err := via("run"); code != ExitSuccess || err != nil {
+		t.Fatalf("run: exit %d err %v", code, err)
+	}
+	if len(d.warm.graphs) != 1 || len(d.warm.fingerprints) != 1 {
+		t.Fatalf("nothing kept warm: %d graphs, %d fingerprint caches", len(d.warm.graphs), len(d.warm.fingerprints))
+	}
+	var loaded *warmGraph
+	for _, g := range d.warm.graphs {
+		loaded = &g
+	}
+
+	// The daemon's plan matches a one-shot plan and reuses the loaded graph.
+	code, plan, err := via("plan")
+	if code != ExitSuccess || err != nil {
+		t.Fatalf("plan: exit %d err %v", code, err)
+	}
+	var direct bytes.Buffer
+	if code := RunCommand(context.Background(), append([]string{"plan"}, flags...), &direct, &direct); code != ExitSuccess || direct.String() != plan {
+		t.Fatalf("one-shot plan (exit %d):\n%s\ndaemon plan:\n%s", code, direct.String(), plan)
+	}
+	for _, g := range d.warm.graphs {
+		if g.graph != loaded.graph {
+			t.Fatal("expected the unchanged graph to be reused")
+		}
+	}
+
+	// Editing the graph file is picked up.
+	writeGraphJSON(t, graphPath, []core.Task{{Name: "a", Run: "echo b > a.out", Outputs: []string{"a.out"}}}, nil)
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(graphPath, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, err := via("run"); code != ExitSuccess || err != nil {
+		t.Fatalf("run after edit: exit %d err %v", code, err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(workDir, "a.out")); string(b) != "b\n" {
+		t.Fatalf("a.out = %q, want the edited graph's output", b)
+	}
+
+	// Failures come back with their exit code and report.
+	var stdout bytes.Buffer
+	code, err = runDaemon([]string{"run", "--workdir", workDir, "--graph", "missing.json", "--cache-dir", "cache", "--output-dir", "out"}, &stdout)
+	if code != ExitConfigError || err == nil || !strings.Contains(err.Error(), "missing.json") {
+		t.Fatalf("missing graph: exit %d err %v", code, err)
+	}
+
+	if code, err := runDaemon([]string{"stop", "--workdir", workDir}, &stdout); code != ExitSuccess || err != nil {
+		t.Fatalf("stop: exit %d err %v", code, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	if code, _, err := via("run"); code != ExitConfigError || err == nil {
+		t.Fatalf("expected no daemon after stop, got exit %d err %v", code, err)
+	}
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"errors"
+	"path/filepath"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/projectintegration/engine/workspace"
+)
+
+// Dry-run task actions.
+const (
+	DryRunExecute = "execute"
+	DryRunRestore = "restore"
+	DryRunSkip    = "skip"
+)
+
+// Dry-run reasons.
+const (
+	DryRunReasonCacheHit       = "cache_hit"
+	DryRunReasonCacheMiss      = "cache_miss"
+	DryRunReasonCacheCorrupt   = "cache_corrupt"
+	DryRunReasonStaleEntry     = "stale_entry"
+	DryRunReasonCleanMode      = "clean_mode"
+	DryRunReasonUpstreamRuns   = "upstream_executes"
+	DryRunReasonUpstreamFailed = "upstream_fails"
+	DryRunReasonConditionFalse = "condition_false"
+	DryRunReasonService        = "service"
+	DryRunReasonInvalidated    = "user_invalidated"
+	DryRunReasonNotCacheable   = "not_cacheable"
+)
+
+// DryRunReport is the result of --dry-run.
+//
+// Tasks are listed in the graph's topological order, so the report is
+// byte-identical for identical graph, inputs and cache contents.
+type DryRunReport struct {
+	GraphHash string       `json:"graph_hash"`
+	Tasks     []DryRunTask `json:"tasks"`
+}
+
+// DryRunTask is the predicted outcome of one task.
+//
+// Hash is empty when it is not known before running: the task depends on an
+// upstream task that would execute, or is skipped.
+type DryRunTask struct {
+	Name   string `json:"name"`
+	Hash   string `json:"hash"`
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+	Group  string `json:"group,omitempty"`
+
+	// CachedExitCode is the exit code a restored task replays.
+	CachedExitCode int `json:"cached_exit_code,omitempty"`
+}
+
+// dryRun resolves inputs, computes task hashes and probes the cache without
+// side effects: no output dir clearing, cache or state writes, or trace.
+//
+// Hashes downstream of restored tasks are computed against the cached
+// artifacts they would restore, as resume planning does. A restored task
+// whose cached exit code is not a success code would fail again, so its
+// downstream tasks are reported as skipped. Services are never cached, so
+// they always execute (reason service) without making their dependents
+// execute. Tasks matched by --invalidate execute (reason user_invalidated).
+func dryRun(inv CLIInvocation) (CLIResult, error) {
+	res := CLIResult{ExitCode: ExitInternalError}
+	g, graphHash, err := inv.loadGraphAndHash()
+	if errors.Is(err, errNothingAffected) {
+		return CLIResult{ExitCode: ExitSuccess, DryRun: &DryRunReport{Tasks: []DryRunTask{}}}, nil
+	}
+	if err != nil {
+		res.ExitCode = ExitConfigError
+		var invErr *InvocationError
+		if errors.As(err, &invErr) && invErr.ExitCode != 0 {
+			res.ExitCode = invErr.ExitCode
+		}
+		return res, err
+	}
+
+	// Unlike cacheForMode, a missing cache directory is not created: every
+	// probe simply misses.
+	var cache core.Cache = noCache{}
+	if inv.ExecutionMode != ExecutionModeClean {
+		namespace, err := inv.cacheNamespace(graphHash)
+		if err != nil {
+			res.ExitCode = ExitConfigError
+			return res, err
+		}
+		fc := core.NewFileCache(core.NamespacedCacheDir(inv.CacheDir, namespace))
+		fc.Compression = inv.CacheCompression
+		cache = inv.warm.cache(fc)
+	}
+	runner := core.NewRunner(inv.WorkDir, cache)
+	runner.AbsoluteHashPaths = inv.AbsoluteWorkDirHash
+	if !inv.NoFingerprintCache {
+		// Read-only: the cache is loaded but never saved.
+		runner.Resolver.Fingerprints = inv.warm.fingerprintCache(filepath.Join(inv.WorkDir, ".scriptweaver", workspace.FingerprintsFileName))
+	}
+	cacheRunner, err := dag.NewCacheAwareRunner(runner)
+	if err != nil {
+		return res, err
+	}
+	registry, err := localRegistry(runner, cacheRunner)
+	if err != nil {
+		return res, err
+	}
+	if err := registry.Validate(g); err != nil {
+		res.ExitCode = ExitConfigError
+		return res, err
+	}
+	invalidated, err := matchInvalidated(g, inv.Invalidate)
+	if err != nil {
+		res.ExitCode = ExitInvalidInvocation
+		return res, err
+	}
+
+	upstream := make(map[string][]string)
+	for _, e := range g.Edges() {
+		upstream[e.To] = append(upstream[e.To], e.From)
+	}
+
+	report := &DryRunReport{GraphHash: graphHash, Tasks: []DryRunTask{}}
+	byName := make(map[string]DryRunTask)
+	for _, name := range g.TopologicalOrder() {
+		n, _ := g.Node(name)
+		t := DryRunTask{Name: name, Group: n.Task.Group}
+		for _, p := range upstream[name] {
+			up := byName[p]
+			upNode, _ := g.Node(p)
+			if up.Action == DryRunSkip && up.Reason == DryRunReasonConditionFalse {
+				t.Action, t.Reason = DryRunSkip, DryRunReasonConditionFalse
+				break
+			}
+			if up.Action == DryRunSkip || (up.Action == DryRunRestore && !upNode.Task.Succeeded(up.CachedExitCode) && !upNode.Task.AllowFailure) {
+				t.Action, t.Reason = DryRunSkip, DryRunReasonUpstreamFailed
+				break
+			}
+			// Services always start but do not change their dependents' hashes.
+			if up.Action == DryRunExecute && up.Reason != DryRunReasonService {
+				t.Action, t.Reason = DryRunExecute, DryRunReasonUpstreamRuns
+			}
+		}
+		// Only the equals clause is known before anything runs.
+		if t.Action != DryRunSkip && !n.Task.When.EqualsHolds() {
+			t.Action, t.Reason = DryRunSkip, DryRunReasonConditionFalse
+		}
+		if t.Action == "" {
+			h, err := computeTaskHash(runner, n.Task)
+			if err != nil {
+				res.ExitCode = ExitConfigError
+				return res, err
+			}
+			t.Hash = h.String()
+			entry, err := probeCache(cache, t.Hash)
+			switch {
+			case n.Task.NormalizedKind() == core.KindService:
+				t.Action, t.Reason = DryRunExecute, DryRunReasonService
+			case invalidated[name]:
+				t.Action, t.Reason = DryRunExecute, DryRunReasonInvalidated
+			case !n.Task.IsCacheable():
+				t.Action, t.Reason = DryRunExecute, DryRunReasonNotCacheable
+			case inv.ExecutionMode == ExecutionModeClean:
+				t.Action, t.Reason = DryRunExecute, DryRunReasonCleanMode
+			case err != nil && core.IsCacheCorrupt(err):
+				t.Action, t.Reason = DryRunExecute, DryRunReasonCacheCorrupt
+			case err != nil:
+				res.ExitCode = ExitConfigError
+				return res, err
+			case entry == nil:
+				t.Action, t.Reason = DryRunExecute, DryRunReasonCacheMiss
+			case core.VerifyDeclaredOutputs(entry, n.Task.Outputs, inv.WorkDir) != nil:
+				t.Action, t.Reason = DryRunExecute, DryRunReasonStaleEntry
+			default:
+				t.Action, t.Reason, t.CachedExitCode = DryRunRestore, DryRunReasonCacheHit, entry.ExitCode
+				runner.Resolver.AddOverlay(entry)
+			}
+		}
+		byName[name] = t
+		report.Tasks = append(report.Tasks, t)
+	}
+
+	res.DryRun = report
+	res.ExitCode = ExitSuccess
+	return res, nil
+}
+
+// probeCache returns the entry stored under hash, or nil on a miss.
+func probeCache(cache core.Cache, hash string) (*core.CacheEntry, error) {
+	ok, err := cache.Has(core.TaskHash(hash))
+	if err != nil || !ok {
+		return nil, err
+	}
+	return cache.Get(core.TaskHash(hash))
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+func TestDryRun_ReportsWithoutSideEffects(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "mkdir -p out && echo a > out/a.txt", Outputs: []string{"out/a.txt"}},
+		{Name: "b", Inputs: []string{"out/a.txt"}, Run: "cat out/a.txt > out/b.txt", Outputs: []string{"out/b.txt"}},
+		{Name: "c", Inputs: []string{"out/b.txt"}, Run: "exit 3"},
+		{Name: "d", Run: "true"},
+	}, []dag.Edge{{From: "a", To: "b"}, {From: "b", To: "c"}, {From: "c", To: "d"}})
+	args := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--trace", "trace.json"}
+	inv, err := ParseInvocation(append(args, "--dry-run"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !inv.DryRun {
+		t.Fatalf("expected --dry-run to set DryRun")
+	}
+
+	res, err := Execute(context.Background(), inv)
+	if err != nil || res.ExitCode != ExitSuccess || res.DryRun == nil {
+		t.Fatalf("dry run: exit %d err %v report %v", res.ExitCode, err, res.DryRun)
+	}
+	got := res.DryRun.Tasks
+	if got[0].Name != "a" || got[0].Action != DryRunExecute || got[0].Reason != DryRunReasonCacheMiss || got[0].Hash == "" {
+		t.Fatalf("unexpected a: %+v", got[0])
+	}
+	for _, task := range got[1:] {
+		if task.Action != DryRunExecute || task.Reason != DryRunReasonUpstreamRuns || task.Hash != "" {
+			t.Fatalf("unexpected %s: %+v", task.Name, task)
+		}
+	}
+	for _, p := range []string{".scriptweaver", "cache", "out", "trace.json"} {
+		if _, err := os.Stat(filepath.Join(workDir, p)); !os.IsNotExist(err) {
+			t.Fatalf("dry run must not create %s, stat err=%v", p, err)
+		}
+	}
+
+	real, err := ParseInvocation(args)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if res, err := Execute(context.Background(), real); err != nil || res.ExitCode != ExitGraphFailure {
+		t.Fatalf("real run: exit %d err %v", res.ExitCode, err)
+	}
+	st, _ := state.NewStore(workDir)
+	ids, _ := st.ListRunIDs()
+	checkpoints, err := st.LoadAllCheckpoints(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Downstream hashes come from cached artifacts, not the files on disk.
+	if err := os.RemoveAll(filepath.Join(workDir, "out")); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = Execute(context.Background(), inv)
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("dry run: exit %d err %v", res.ExitCode, err)
+	}
+	want := []DryRunTask{
+		{Name: "a", Hash: checkpoints["a"].CacheKeys[0], Action: DryRunRestore, Reason: DryRunReasonCacheHit},
+		{Name: "b", Hash: checkpoints["b"].CacheKeys[0], Action: DryRunRestore, Reason: DryRunReasonCacheHit},
+		{Name: "c", Hash: res.DryRun.Tasks[2].Hash, Action: DryRunRestore, Reason: DryRunReasonCacheHit, CachedExitCode: 3},
+		{Name: "d", Action: DryRunSkip, Reason: DryRunReasonUpstreamFailed},
+	}
+	if !reflect.DeepEqual(res.DryRun.Tasks, want) {
+		t.Fatalf("report:\n got %+v\nwant %+v", res.DryRun.Tasks, want)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "out")); !os.IsNotExist(err) {
+		t.Fatalf("dry run must not restore outputs, stat err=%v", err)
+	}
+	if ids2, _ := st.ListRunIDs(); len(ids2) != len(ids) {
+		t.Fatalf("dry run must not record runs: %v -> %v", ids, ids2)
+	}
+}
+
+func TestParseInvocation_DryRunExcludesVerifyCache(t *testing.T) {
+	_, err := ParseInvocation([]string{"--workdir", t.TempDir(), "--cache-dir", "cache", "--verify-cache", "--dry-run"})
+	if ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected invalid invocation, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/trace"
+)
+
+func TestExecute_EffectKeyAppliesOnce(t *testing.T) {
+	workDir := t.TempDir()
+	no := false
+	writeDeploy := func(key string) {
+		t.Helper()
+		writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+			{Name: "deploy", Run: "echo " + key + " >> deploy.log", Cacheable: &no, EffectKey: key},
+			{Name: "notify", Run: "true", Cacheable: &no},
+		}, []dag.Edge{{From: "deploy", To: "notify"}})
+	}
+	run := func() string {
+		t.Helper()
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--trace", "trace.json"})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+		}
+		b, err := os.ReadFile(filepath.Join(workDir, "trace.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := trace.ParseExecutionTrace(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ev := range parsed.Events {
+			if ev.TaskID == "deploy" && ev.Kind == trace.EventTaskExecuted {
+				return ev.Reason
+			}
+		}
+		t.Fatalf("no TaskExecuted event for deploy: %s", b)
+		return ""
+	}
+
+	writeDeploy("v1")
+	if reason := run(); reason != trace.ReasonNotCacheable {
+		t.Fatalf("first run reason = %s", reason)
+	}
+	if reason := run(); reason != trace.ReasonEffectApplied {
+		t.Fatalf("second run reason = %s", reason)
+	}
+	writeDeploy("v2")
+	if reason := run(); reason != trace.ReasonNotCacheable {
+		t.Fatalf("new key reason = %s", reason)
+	}
+	if b, _ := os.ReadFile(filepath.Join(workDir, "deploy.log")); string(b) != "v1\nv2\n" {
+		t.Fatalf("deploy.log = %q, want each key applied once", b)
+	}
+}
+
+func TestExecute_EffectKeyNotRecordedOnFailure(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "deploy", Inputs: []string{"ok"}, Run: "echo x >> deploy.log; test -s ok", EffectKey: "prod"},
+	}, nil)
+	args := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"}
+	for i, content := range []string{"", "yes"} {
+		if err := os.WriteFile(filepath.Join(workDir, "ok"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		inv, err := ParseInvocation(args)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if want := []int{ExitGraphFailure, ExitSuccess}[i]; err != nil || res.ExitCode != want {
+			t.Fatalf("run %d: exit %d err %v, want exit %d", i, res.ExitCode, err, want)
+		}
+	}
+	if b, _ := os.ReadFile(filepath.Join(workDir, "deploy.log")); string(b) != "x\nx\n" {
+		t.Fatalf("deploy.log = %q, want the failed attempt retried", b)
+	}
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"scriptweaver/internal/recovery/state"
+)
+
+// Error classes reported in ErrorReport.Class. Each class maps to a fixed
+// exit code, except graph failures caused by bad --param values, which are
+// invocation errors (exit 2):
+//
+//	invocation -> ExitInvalidInvocation (2)
+//	graph      -> ExitConfigError (3)
+//	workspace  -> ExitConfigError (3)
+//	execution  -> ExitGraphFailure (1), or ExitConfigError (3) for ResumeIneligible
+//	system     -> ExitInternalError (4), or ExitConfigError (3) for TraceInit
+//	trace      -> ExitTraceMismatch (5)
+//	config     -> ExitConfigError (3), failures without a more specific class
+const (
+	ErrorClassInvocation = "invocation"
+	ErrorClassGraph      = "graph"
+	ErrorClassWorkspace  = "workspace"
+	ErrorClassExecution  = "execution"
+	ErrorClassSystem     = "system"
+	ErrorClassTrace      = "trace"
+	ErrorClassConfig     = "config"
+)
+
+// Error codes not recorded by the recovery store. Recorded failures use the
+// store's codes (e.g. SchemaViolation, CacheDir, NodeFailed).
+const (
+	ErrorCodeInvalidInvocation = "InvalidInvocation"
+	ErrorCodeNodeFailed        = "NodeFailed"
+	ErrorCodeTraceMismatch     = "TraceMismatch"
+	ErrorCodeConfigError       = "ConfigError"
+	ErrorCodeInternalError     = "InternalError"
+)
+
+// ErrorReport is the structured form of a failed invocation, written to
+// stderr with --errors-json. Class and Code are stable identifiers for
+// wrapping tools; Message is for humans and may change.
+type ErrorReport struct {
+	Class    string `json:"class"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	NodeID   string `json:"node_id,omitempty"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// classifiedError carries the recovery-store failure recorded for err so
+// DescribeError reports the same class and code. Its message is err's.
+type classifiedError struct {
+	err     error
+	failure error
+}
+
+func (e *classifiedError) Error() string { return e.err.Error() }
+
+func (e *classifiedError) Unwrap() []error { return []error{e.err, e.failure} }
+
+// classify attaches failure (one of the state failure types) to err.
+func classify(err error, failure error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{err: err, failure: failure}
+}
+
+// DescribeError classifies the outcome of ParseInvocation or Execute. It
+// returns nil when res succeeded and err is nil. A graph whose tasks failed
+// returns no error from Execute; it is reported as NodeFailed with the first
+// failed node.
+func DescribeError(res CLIResult, err error) *ErrorReport {
+	if err == nil && res.ExitCode == ExitSuccess {
+		return nil
+	}
+	rep := &ErrorReport{ExitCode: res.ExitCode}
+	if err != nil {
+		rep.Message = err.Error()
+	}
+
+	var invErr *InvocationError
+	var graphErr *state.GraphFailureError
+	var wsErr *state.WorkspaceFailureError
+	var execErr *state.ExecutionFailureError
+	var sysErr *state.SystemFailureError
+	switch {
+	case errors.As(err, &invErr):
+		rep.Class, rep.Code = ErrorClassInvocation, ErrorCodeInvalidInvocation
+	case errors.As(err, &graphErr):
+		rep.Class, rep.Code = ErrorClassGraph, graphErr.Code
+	case errors.As(err, &wsErr):
+		rep.Class, rep.Code = ErrorClassWorkspace, wsErr.Code
+	case errors.As(err, &execErr):
+		rep.Class, rep.Code, rep.NodeID = ErrorClassExecution, execErr.Code, execErr.NodeID
+	case errors.As(err, &sysErr):
+		rep.Class, rep.Code = ErrorClassSystem, sysErr.Code
+	case res.ExitCode == ExitGraphFailure:
+		rep.Class, rep.Code = ErrorClassExecution, ErrorCodeNodeFailed
+		rep.NodeID = firstFailedNode(res.GraphResult)
+		if rep.Message == "" {
+			rep.Message = fmt.Sprintf("node %s failed", rep.NodeID)
+		}
+	case res.ExitCode == ExitTraceMismatch:
+		rep.Class, rep.Code = ErrorClassTrace, ErrorCodeTraceMismatch
+	case res.ExitCode == ExitInvalidInvocation:
+		rep.Class, rep.Code = ErrorClassInvocation, ErrorCodeInvalidInvocation
+	case res.ExitCode == ExitConfigError:
+		rep.Class, rep.Code = ErrorClassConfig, ErrorCodeConfigError
+	default:
+		rep.Class, rep.Code = ErrorClassSystem, ErrorCodeInternalError
+	}
+	return rep
+}
+
+// WriteErrorReport writes rep to w: a single-line JSON object when asJSON is
+// set, otherwise "class/Code: message". A nil rep writes nothing.
+func WriteErrorReport(w io.Writer, rep *ErrorReport, asJSON bool) error {
+	if rep == nil {
+		return nil
+	}
+	if asJSON {
+		return json.NewEncoder(w).Encode(rep)
+	}
+	_, err := fmt.Fprintf(w, "%s/%s: %s\n", rep.Class, rep.Code, rep.Message)
+	return err
+}
+
+// WantsErrorsJSON reports whether args enable --errors-json. It lets callers
+// honour the flag for errors raised while parsing the rest of args.
+func WantsErrorsJSON(args []string) bool {
+	want := false
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "errors-json" {
+			continue
+		}
+		if !hasValue {
+			want = true
+			continue
+		}
+		if b, err := strconv.ParseBool(value); err == nil {
+			want = b
+		}
+	}
+	return want
+}
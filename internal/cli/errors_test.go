@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+func TestDescribeError_ClassifiesRecordedFailures(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "graph.json"), []byte(`{"tasks": [{"name": "a"}], "bogus": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	res, err := Execute(context.Background(), inv)
+	if err == nil {
+		t.Fatal("expected an invalid graph error")
+	}
+	rep := DescribeError(res, err)
+	if rep.Class != ErrorClassGraph || rep.Code != "GraphLoadError" || rep.ExitCode != ExitConfigError || rep.Message != err.Error() {
+		t.Fatalf("report = %+v", rep)
+	}
+}
+
+func TestDescribeError_FailedNode(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "ok", Run: "true"},
+		{Name: "broken", Run: "exit 7"},
+	}, nil)
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	res, err := Execute(context.Background(), inv)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	rep := DescribeError(res, err)
+	want := ErrorReport{Class: ErrorClassExecution, Code: ErrorCodeNodeFailed, Message: "node broken failed", NodeID: "broken", ExitCode: ExitGraphFailure}
+	if rep == nil || *rep != want {
+		t.Fatalf("report = %+v, want %+v", rep, want)
+	}
+}
+
+func TestDescribeError_InvocationAndSuccess(t *testing.T) {
+	_, err := ParseInvocation([]string{"--workdir", "relative"})
+	rep := DescribeError(CLIResult{ExitCode: ExitCode(err)}, err)
+	if rep.Class != ErrorClassInvocation || rep.Code != ErrorCodeInvalidInvocation || rep.ExitCode != ExitInvalidInvocation {
+		t.Fatalf("report = %+v", rep)
+	}
+	if rep := DescribeError(CLIResult{ExitCode: ExitSuccess}, nil); rep != nil {
+		t.Fatalf("expected no report for success, got %+v", rep)
+	}
+}
+
+func TestWriteErrorReport_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	in := &ErrorReport{Class: ErrorClassWorkspace, Code: "CacheDir", Message: "boom", ExitCode: ExitConfigError}
+	if err := WriteErrorReport(&buf, in, true); err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if out["class"] != "workspace" || out["code"] != "CacheDir" || out["exit_code"] != float64(3) {
+		t.Fatalf("unexpected report %v", out)
+	}
+	if _, ok := out["node_id"]; ok {
+		t.Fatalf("node_id should be omitted when empty: %v", out)
+	}
+}
+
+func TestWantsErrorsJSON(t *testing.T) {
+	cases := map[string]struct {
+		args []string
+		want bool
+	}{
+		"absent":         {[]string{"--workdir", "/w"}, false},
+		"double dash":    {[]string{"--errors-json", "--workdir", "/w"}, true},
+		"single dash":    {[]string{"-errors-json"}, true},
+		"explicit false": {[]string{"--errors-json=false"}, false},
+		"after operands": {[]string{"--", "--errors-json"}, false},
+	}
+	for name, tc := range cases {
+		if got := WantsErrorsJSON(tc.args); got != tc.want {
+			t.Errorf("%s: WantsErrorsJSON(%q) = %v, want %v", name, tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestExecute_RejectsPathsOutsideWorkDir(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "peek", Run: "true", Inputs: []string{"../../etc/passwd"}},
+	}, nil)
+	args := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"}
+	inv, err := ParseInvocation(args)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	res, err := Execute(context.Background(), inv)
+	rep := DescribeError(res, err)
+	if rep == nil || rep.Code != "PathOutsideWorkDir" || rep.ExitCode != ExitConfigError {
+		t.Fatalf("report = %+v (err=%v)", rep, err)
+	}
+	if code, _, _ := Dispatch([]string{"validate", "--workdir", workDir, "--graph", "graph.json"}, &bytes.Buffer{}); code != ExitConfigError {
+		t.Fatalf("validate: exit %d, want %d", code, ExitConfigError)
+	}
+
+	inv, err = ParseInvocation(append(args, "--allow-outside-workdir"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if _, _, err := inv.loadGraphAndHash(); err != nil {
+		t.Fatalf("allowed input rejected: %v", err)
+	}
+}
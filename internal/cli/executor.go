@@ -4,7 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
@@ -17,6 +17,7 @@ import (
 	"scriptweaver/internal/pluginengine"
 	"scriptweaver/internal/projectintegration/engine/workspace"
 	"scriptweaver/internal/recovery/state"
+	"scriptweaver/internal/remote"
 	"scriptweaver/internal/trace"
 )
 
@@ -43,6 +44,16 @@ func (defaultGraphExecutor) Run(ctx context.Context, graph *dag.TaskGraph, runne
 type cliGraphExecutor struct {
 	Plan     *incremental.IncrementalPlan
 	Observer dag.NodeObserver
+
+	// Observers receive every task state change (see dag.Executor.Observers).
+	Observers []dag.NodeEventObserver
+
+	// Parallelism above 1 runs ready tasks concurrently (one per remote
+	// worker); the trace and final state stay deterministic.
+	Parallelism int
+
+	// WorkDir is where task When conditions look for inputs.
+	WorkDir string
 }
 
 func (c cliGraphExecutor) Run(ctx context.Context, graph *dag.TaskGraph, runner dag.TaskRunner) (*dag.GraphResult, error) {
@@ -52,12 +63,48 @@ func (c cliGraphExecutor) Run(ctx context.Context, graph *dag.TaskGraph, runner
 	}
 	exec.Plan = c.Plan
 	exec.Observer = c.Observer
+	exec.Observers = c.Observers
+	exec.WorkDir = c.WorkDir
+	if c.Parallelism > 1 {
+		return exec.RunParallel(ctx, c.Parallelism)
+	}
 	return exec.RunSerial(ctx)
 }
 
+// remotePool builds the worker pool for --remote-worker URLs, sending token
+// to each worker.
+func remotePool(urls []string, token string) (*remote.Pool, error) {
+	clients := make([]*remote.Client, 0, len(urls))
+	for _, u := range urls {
+		c, err := remote.NewClient(u)
+		if err != nil {
+			return nil, err
+		}
+		c.Token = token
+		clients = append(clients, c)
+	}
+	return remote.NewPool(clients)
+}
+
 type CLIResult struct {
 	ExitCode   int
 	GraphResult *dag.GraphResult
+
+	// CacheVerify is set for --verify-cache invocations instead of GraphResult.
+	CacheVerify *CacheVerifyReport
+
+	// DryRun is set for --dry-run invocations instead of GraphResult.
+	DryRun *DryRunReport
+
+	// TraceVerify is set for --verify-trace invocations alongside GraphResult.
+	TraceVerify *TraceVerifyReport
+
+	// Metrics holds each task's outcome and wall time, alongside
+	// GraphResult.
+	Metrics *state.RunMetrics
+
+	// Timings is the run's non-canonical timing ledger.
+	Timings *state.TimingLedger
 }
 
 // Execute is the default entrypoint for running a canonical invocation.
@@ -68,7 +115,7 @@ func Execute(ctx context.Context, inv CLIInvocation) (CLIResult, error) {
 // Execute maps a canonical CLIInvocation to engine execution.
 //
 // Responsibilities:
-//   - Prepare OutputDir using the Overwrite policy (no stale files).
+//   - Prepare OutputDir using the Overwrite policy (see OverwritePolicy).
 //   - Select cache strategy based on ExecutionMode.
 //   - Initialize trace output before execution and finalize after execution,
 //     even on panic/failure.
@@ -78,85 +125,282 @@ func ExecuteWithExecutor(ctx context.Context, inv CLIInvocation, executor GraphE
 	if executor == nil {
 		return res, fmt.Errorf("nil executor")
 	}
+	logger := inv.logger()
+	if inv.ProfileDir != "" {
+		stop, err := startProfiling(inv.ProfileDir)
+		if err != nil {
+			res.ExitCode = ExitConfigError
+			return res, err
+		}
+		// Best-effort: profiles are diagnostics and never change the outcome.
+		defer func() { _ = stop() }()
+	}
+	if inv.VerifyCache {
+		return verifyCache(inv)
+	}
+	if inv.DryRun {
+		return dryRun(inv)
+	}
+	if inv.VerifyTrace != "" {
+		return verifyTrace(ctx, inv, executor)
+	}
 
 	// Initialize recovery store as early as possible so failures can be recorded.
 	st, _ := state.NewStore(inv.WorkDir)
 	rec := &state.FailureRecorder{Store: st}
 	runID, _ := rec.NewRunID()
+	var graphHash string
+	if len(inv.Notify) > 0 {
+		// Registered first so it runs last, once the exit code is final.
+		start := time.Now().UTC()
+		defer func() {
+			notifyRun(ctx, inv.Notify, runSummary(inv, runID, graphHash, start, time.Now().UTC(), res, execErr), logger)
+		}()
+	}
 
 	// Best-effort: validate/init .scriptweaver workspace; even if this fails,
 	// we still attempt to record a WorkspaceFailure.
-	_, wsErr := workspace.EnsureWorkspace(inv.WorkDir)
+	ws, wsErr := workspace.EnsureWorkspace(inv.WorkDir)
 	if wsErr != nil {
+		failure := &state.WorkspaceFailureError{Code: "WorkspaceInvalid", Message: wsErr.Error(), Cause: wsErr}
 		if runID != "" {
-			_ = rec.StartRun(state.Run{RunID: runID, GraphHash: "", StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil})
-			_ = rec.RecordFailure(runID, &state.WorkspaceFailureError{Code: "WorkspaceInvalid", Message: wsErr.Error(), Cause: wsErr})
+			_ = rec.StartRun(state.Run{RunID: runID, GraphHash: "", StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil, Labels: inv.Labels})
+			_ = rec.RecordFailure(runID, failure)
 		}
 		res.ExitCode = ExitConfigError
-		return res, wsErr
+		return res, classify(wsErr, failure)
 	}
 
+	// Concurrent runs would race on output dir clearing, cache writes and
+	// checkpoints. Nothing is recorded when the lock is held elsewhere: the
+	// state store belongs to the holder.
+	lock, lockErr := workspace.AcquireLock(ws)
+	if lockErr != nil {
+		res.ExitCode = ExitConfigError
+		return res, classify(lockErr, &state.WorkspaceFailureError{Code: "WorkspaceLocked", Message: lockErr.Error(), Cause: lockErr})
+	}
+	defer func() { _ = lock.Release() }()
+
+	// Best-effort: finalize runs left "running" by processes that died, so
+	// resume planning below sees them as crashed rather than in flight. With
+	// the lock held, no live run on this workspace can be among them.
+	_, _ = st.RecoverStaleRuns(state.ProcessAlive)
+
 	// Plugin registration occurs at engine startup.
 	// Discovery is deterministic and non-recursive; absence of plugins is valid.
 	pluginsRoot := filepath.Join(inv.WorkDir, pluginengine.DefaultPluginsRoot)
-	pluginLog := log.New(os.Stderr, "", 0)
-	_, _ = discoverPlugins(pluginsRoot, pluginLog)
+	pluginLog := slog.NewLogLogger(logger.Handler(), slog.LevelWarn)
+	plugins, _ := discoverPlugins(pluginsRoot, pluginLog)
+	logger.Debug("plugins discovered", "root", pluginsRoot, "count", len(plugins.ByID))
 
-	graphObj, graphHash, err := loadGraphAndHash(inv.GraphPath)
+	graphObj, graphHash, err := inv.loadGraphAndHash()
+	if errors.Is(err, errNothingAffected) {
+		logger.Info("--changed-files: nothing to run", "reason", err)
+		res.ExitCode = ExitSuccess
+		return res, nil
+	}
 	if err != nil {
+		failure := &state.GraphFailureError{Code: "GraphLoadError", Message: err.Error(), Cause: err}
+		var se *graph.SchemaError
+		var ste *graph.StructuralError
+		switch {
+		case errors.As(err, &se):
+			failure.Code = "SchemaViolation"
+		case errors.As(err, &ste), errors.Is(err, dag.ErrOutputOverlap):
+			failure.Code = "StructuralInvalidity"
+		case errors.Is(err, core.ErrPathOutsideWorkDir):
+			failure.Code = "PathOutsideWorkDir"
+		}
 		if runID != "" {
-			_ = rec.StartRun(state.Run{RunID: runID, GraphHash: "", StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil})
-			var se *graph.SchemaError
-			var ste *graph.StructuralError
-			switch {
-			case errors.As(err, &se):
-				_ = rec.RecordFailure(runID, &state.GraphFailureError{Code: "SchemaViolation", Message: err.Error(), Cause: err})
-			case errors.As(err, &ste):
-				_ = rec.RecordFailure(runID, &state.GraphFailureError{Code: "StructuralInvalidity", Message: err.Error(), Cause: err})
-			default:
-				_ = rec.RecordFailure(runID, &state.GraphFailureError{Code: "GraphLoadError", Message: err.Error(), Cause: err})
-			}
+			_ = rec.StartRun(state.Run{RunID: runID, GraphHash: "", StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil, Labels: inv.Labels})
+			_ = rec.RecordFailure(runID, failure)
+		}
+		res.ExitCode = ExitConfigError
+		var invErr *InvocationError
+		if errors.As(err, &invErr) {
+			// e.g. a missing or undeclared --param.
+			res.ExitCode = invErr.ExitCode
+		}
+		return res, classify(err, failure)
+	}
+	hooks, err := loadGraphHooks(inv.GraphPath)
+	if err != nil {
+		failure := &state.GraphFailureError{Code: "GraphLoadError", Message: err.Error(), Cause: err}
+		if runID != "" {
+			_ = rec.StartRun(state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil, Labels: inv.Labels})
+			_ = rec.RecordFailure(runID, failure)
 		}
 		res.ExitCode = ExitConfigError
+		return res, classify(err, failure)
+	}
+	if inv.RunIDStrategy == state.RunIDGraph {
+		// Allocated under the workspace lock, so concurrent invocations
+		// cannot take the same sequence number.
+		id, err := st.NextGraphRunID(graphHash)
+		if err != nil {
+			res.ExitCode = ExitConfigError
+			return res, classify(err, &state.WorkspaceFailureError{Code: "RunID", Message: err.Error(), Cause: err})
+		}
+		runID = id
+	}
+	logger.Debug("graph loaded", "run_id", runID, "graph_hash", graphHash, "tasks", len(graphObj.TopologicalOrder()), "mode", string(inv.ExecutionMode))
+	if inv.ResumeFrom != "" {
+		if _, ok := graphObj.Node(inv.ResumeFrom); !ok {
+			res.ExitCode = ExitInvalidInvocation
+			return res, invalidInvocationf("--resume-from: unknown task %q", inv.ResumeFrom)
+		}
+	}
+	invalidated, err := matchInvalidated(graphObj, inv.Invalidate)
+	if err != nil {
+		res.ExitCode = ExitInvalidInvocation
 		return res, err
 	}
 
 	traceWriter, err := newTraceWriter(inv, graphHash)
 	if err != nil {
+		failure := &state.SystemFailureError{Code: "TraceInit", Message: err.Error(), Cause: err}
 		if runID != "" {
-			_ = rec.RecordFailure(runID, &state.SystemFailureError{Code: "TraceInit", Message: err.Error(), Cause: err})
+			_ = rec.RecordFailure(runID, failure)
 		}
 		res.ExitCode = ExitConfigError
-		return res, err
+		return res, classify(err, failure)
 	}
 	defer func() {
 		// Always finalize trace output deterministically.
 		_ = traceWriter.Finalize(res.GraphResult)
 	}()
 
-	if err := prepareOutputDir(inv.OutputDir); err != nil {
+	if err := applyOverwritePolicy(inv, graphObj); err != nil {
+		failure := &state.WorkspaceFailureError{Code: "OutputDir", Message: err.Error(), Cause: err}
 		if runID != "" {
-			_ = rec.RecordFailure(runID, &state.WorkspaceFailureError{Code: "OutputDir", Message: err.Error(), Cause: err})
+			_ = rec.RecordFailure(runID, failure)
 		}
 		res.ExitCode = ExitConfigError
-		return res, err
+		return res, classify(err, failure)
 	}
 
-	cache, err := cacheForMode(inv.ExecutionMode, inv.CacheDir)
+	var cache core.Cache
+	namespace, err := inv.cacheNamespace(graphHash)
+	if err == nil {
+		cache, err = cacheForMode(inv.ExecutionMode, core.NamespacedCacheDir(inv.CacheDir, namespace), inv.CacheCompression)
+	}
 	if err != nil {
+		failure := &state.WorkspaceFailureError{Code: "CacheDir", Message: err.Error(), Cause: err}
 		if runID != "" {
-			_ = rec.RecordFailure(runID, &state.WorkspaceFailureError{Code: "CacheDir", Message: err.Error(), Cause: err})
+			_ = rec.RecordFailure(runID, failure)
 		}
 		res.ExitCode = ExitConfigError
-		return res, err
+		return res, classify(err, failure)
 	}
+	inv.CacheSigning.apply(cache)
+	cache = inv.warm.cache(cache)
+	counted := core.NewStatsCache(cache)
+	cache = counted
 
+	logger.Debug("cache selected", "dir", inv.CacheDir, "namespace", namespace, "mode", string(inv.ExecutionMode))
 	runner := core.NewRunner(inv.WorkDir, cache)
+	runner.Logger = logger
+	runner.Rerun = invalidated
+	if st != nil {
+		runner.Effects = st
+	}
+	// Outside resume-only, a corrupt cache entry is re-executed and rewritten
+	// rather than aborting the run; resume-only must not execute reused work.
+	runner.HealCorruptEntries = inv.ExecutionMode != ExecutionModeResumeOnly
+	runner.Isolated = inv.Isolated
+	runner.ProtectInputs = inv.ProtectInputs
+	runner.AbsoluteHashPaths = inv.AbsoluteWorkDirHash
+	runner.Downloads = core.NewDownloadCache(filepath.Join(ws.Dir, workspace.DownloadsDirName))
+	if inv.NormalizeLogs {
+		runner.StreamNormalizer = core.NewStreamNormalizer(core.NewDefaultNormalizer())
+	}
+	runner.CleanOutputs = inv.Overwrite == OverwriteOnConflict
+	runner.Executor.Container = &core.ContainerConfig{Engine: inv.ContainerEngine}
+	runner.Executor.OutputLimit = inv.MaxOutputBytes
+	runner.Executor.OutputDir = inv.OutputDir
+	if !inv.NoFingerprintCache {
+		fingerprints := inv.warm.fingerprintCache(filepath.Join(ws.Dir, workspace.FingerprintsFileName))
+		runner.Resolver.Fingerprints = fingerprints
+		// Best-effort: the fingerprint cache only saves re-hashing next time.
+		defer func() { _ = fingerprints.Save() }()
+	}
+	if inv.StrictOutputs {
+		runner.StrictOutputs = true
+		runner.StrictIgnore = []string{".scriptweaver", inv.CacheDir, inv.OutputDir}
+		if inv.Trace.Enabled {
+			runner.StrictIgnore = append(runner.StrictIgnore, inv.Trace.Path)
+		}
+	}
+	var audits *auditLog
+	if inv.Audit {
+		if audits, err = enableAudit(runner); err != nil {
+			res.ExitCode = ExitInvalidInvocation
+			return res, err
+		}
+	}
 	cacheRunner, err := dag.NewCacheAwareRunner(runner)
 	if err != nil {
 		res.ExitCode = ExitInternalError
 		return res, err
 	}
+	// Container tasks share the cache-aware runner; the executor switches to
+	// the container engine for tasks that declare an image.
+	var taskRunner restoringRunner = cacheRunner
+	parallelism := max(inv.Concurrency, 1)
+	if len(inv.RemoteWorkers) > 0 {
+		pool, cerr := remotePool(inv.RemoteWorkers, inv.RemoteWorkerToken)
+		if cerr == nil {
+			taskRunner, cerr = remote.NewRunner(runner, pool)
+		}
+		if cerr != nil {
+			res.ExitCode = ExitConfigError
+			return res, cerr
+		}
+		parallelism = pool.Size()
+	}
+	registry, err := dag.NewRunnerRegistry(taskRunner)
+	if err == nil {
+		err = registry.Register(core.KindContainer, taskRunner)
+	}
+	if err == nil {
+		// Services always run locally and are never cached.
+		var services *dag.ServiceRunner
+		if services, err = dag.NewServiceRunner(runner); err == nil {
+			err = registry.Register(core.KindService, services)
+		}
+	}
+	if err != nil {
+		res.ExitCode = ExitInternalError
+		return res, err
+	}
+	if err := registry.Validate(graphObj); err != nil {
+		failure := &state.GraphFailureError{Code: "UnknownTaskKind", Message: err.Error(), Cause: err}
+		if runID != "" {
+			_ = rec.StartRun(state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil, Labels: inv.Labels})
+			_ = rec.RecordFailure(runID, failure)
+		}
+		res.ExitCode = ExitConfigError
+		return res, classify(err, failure)
+	}
+
+	var observers []dag.NodeEventObserver
+	if inv.StatusAddr != "" {
+		status := newStatusServer(graphObj, runID, graphHash)
+		stop, err := serveStatus(inv.StatusAddr, status, logger)
+		if err != nil {
+			res.ExitCode = ExitConfigError
+			return res, err
+		}
+		defer stop()
+		observers = append(observers, status)
+	}
+	if inv.TUI {
+		display := newTUI(inv.errOut(), graphObj)
+		runner.Executor.LiveOutput = display.liveOutput
+		observers = append(observers, display)
+		display.start()
+		defer display.close()
+	}
 
 	// Create a checkpoint observer. Checkpoints are only meaningful for incremental/resume-only.
 	var obs dag.NodeObserver
@@ -165,116 +409,375 @@ func ExecuteWithExecutor(ctx context.Context, inv CLIInvocation, executor GraphE
 		obs = checkpointObserver{RunID: runID, Validator: validator}
 	}
 
+	// Whole-run memoization: an incremental run identical to the graph's
+	// last fully successful run restores every task and replays its trace.
+	var memo *state.GraphMemo
+	replayed := false
+	if _, ok := executor.(defaultGraphExecutor); ok && st != nil && inv.MemoizeGraph && inv.ResumeFrom == "" && len(invalidated) == 0 {
+		if m, found, err := st.LoadGraphMemo(graphHash); err != nil {
+			logger.Debug("graph memo unreadable", "error", err)
+		} else if found {
+			reason, err := matchGraphMemo(graphObj, runner, cache, m, inv.Params)
+			switch {
+			case err != nil:
+				logger.Debug("graph memo not replayed", "error", err)
+			case reason != "":
+				logger.Debug("graph memo not replayed", "memo_run", m.RunID, "reason", reason)
+			default:
+				logger.Info("replaying memoized run", "memo_run", m.RunID)
+				memo = &m
+			}
+		}
+	}
+
 	// Resume planning (incremental/resume-only): best-effort attempt to reuse prior work.
 	// Clean mode ignores all checkpoints.
 	var executorToUse GraphExecutor = executor
 	var previousRunID *string
 	retryCount := 0
 	var resumePlan *incremental.IncrementalPlan
-	if inv.ExecutionMode == ExecutionModeIncremental || inv.ExecutionMode == ExecutionModeResumeOnly {
-		prevID, perr := detectPreviousRunID(st, graphHash)
+	// An explicit --resume-from must resume, like resume-only mode.
+	mustResume := inv.ExecutionMode == ExecutionModeResumeOnly || inv.ResumeFrom != ""
+	if memo == nil && (inv.ExecutionMode == ExecutionModeIncremental || inv.ExecutionMode == ExecutionModeResumeOnly) {
+		// The report explains the decision; see state.ResumeReport.
+		report := state.ResumeReport{RunID: runID, Mode: state.ExecutionMode(inv.ExecutionMode), ResumeFrom: inv.ResumeFrom}
+		var reportPlan *incremental.IncrementalPlan
+		notes := make(map[string]string)
+		emitReport := func(decision state.ResumeDecision) {
+			report.Decision = decision
+			if reportPlan != nil {
+				report.Nodes, report.CheckpointNode, report.InvalidatedNode = resumeNodes(graphObj.TopologicalOrder(), reportPlan, notes)
+			}
+			emitResumeReport(st, logger, report)
+		}
+		var prevID string
+		var perr error
+		if inv.ResumeAcrossEdits {
+			prevID, perr = previousRunAcrossEdits(st)
+		} else {
+			prevID, perr = detectPreviousRunID(st, graphHash)
+		}
 		if perr != nil {
-			if inv.ExecutionMode == ExecutionModeResumeOnly {
+			report.Reason = "cannot look for a previous run: " + perr.Error()
+			if mustResume {
+				emitReport(state.ResumeRejected)
+				failure := &state.ExecutionFailureError{NodeID: "", Code: "ResumeIneligible", Message: perr.Error(), Cause: perr}
 				if runID != "" {
-					_ = rec.StartRun(state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil})
-					_ = rec.RecordFailure(runID, &state.ExecutionFailureError{NodeID: "", Code: "ResumeIneligible", Message: perr.Error(), Cause: perr})
+					_ = rec.StartRun(state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil, Labels: inv.Labels})
+					_ = rec.RecordFailure(runID, failure)
 				}
 				res.ExitCode = ExitConfigError
-				return res, perr
+				return res, classify(perr, failure)
+			}
+		} else if prevID == "" {
+			report.Reason = "no failed run of this graph"
+			if other, err := latestFailedRun(st, func(state.Run) bool { return true }); err == nil && other.RunID != "" {
+				report.PreviousRunFound, report.PreviousRunID = true, other.RunID
+				report.Reason = fmt.Sprintf("the latest failed run has graph hash %s, not %s", other.GraphHash, graphHash)
 			}
-		} else if prevID != "" {
+		} else {
+			report.PreviousRunFound, report.PreviousRunID = true, prevID
 			prevRun, lerr := st.LoadRun(prevID)
-			if lerr == nil && prevRun.GraphHash == graphHash {
+			switch {
+			case lerr != nil:
+				report.Reason = "previous run unreadable: " + lerr.Error()
+			case prevRun.GraphHash == graphHash:
+				report.GraphHashMatched = true
+			case !inv.ResumeAcrossEdits:
+				report.Reason = fmt.Sprintf("previous run has graph hash %s, not %s", prevRun.GraphHash, graphHash)
+			}
+			if lerr == nil && (prevRun.GraphHash == graphHash || inv.ResumeAcrossEdits) {
 				// Resume is only meaningful after a non-successful termination.
-				if _, ferr := st.LoadFailure(prevID); ferr == nil {
-					checkpoints, cerr := st.LoadAllCheckpoints(prevID)
-					if cerr == nil && len(checkpoints) > 0 {
-							plan, checkpointNode, snap, invMap, corruption := buildResumePlan(ctx, graphObj, runner, cacheRunner, cache, checkpoints)
+				if _, ferr := st.LoadFailure(prevID); ferr != nil {
+					report.Reason = "previous run recorded no failure"
+				} else {
+					checkpoints, cerr := resumeCheckpoints(st, prevRun)
+					report.Checkpoints = len(checkpoints)
+					var edited []string
+					var merr error
+					if cerr == nil && prevRun.GraphHash != graphHash {
+						checkpoints, edited, merr = editedGraphCheckpoints(st, graphObj, prevRun.GraphHash, checkpoints)
+					}
+					switch {
+					case cerr != nil:
+						report.Reason = "previous run checkpoints unreadable: " + cerr.Error()
+					case merr != nil:
+						report.Reason = "cannot match the edited graph to the previous run: " + merr.Error()
+					case len(checkpoints) == 0:
+						report.Reason = "previous run has no checkpoints"
+					}
+					if cerr == nil && merr == nil && len(checkpoints) > 0 {
+							plan, checkpointNode, snap, invMap, corruption := buildResumePlan(graphObj, runner, cache, checkpoints, notes)
+							for _, name := range edited {
+								notes[name] = "changed by the graph edit"
+							}
+							report.CheckpointsValid = corruption == nil
+							reportPlan = plan
 							if corruption != nil {
+								report.Reason = "previous run checkpoints corrupt: " + corruption.Error()
+								logger.Warn("resume: checkpoints corrupt", "previous_run", prevID, "error", corruption)
 								// Resume-only hard-fails; incremental falls back to scratch execution.
-								if inv.ExecutionMode == ExecutionModeResumeOnly {
+								if mustResume {
+									emitReport(state.ResumeRejected)
+									failure := &state.WorkspaceFailureError{Code: "WorkspaceCorrupt", Message: corruption.Error(), Cause: corruption}
 									if runID != "" {
-										_ = rec.StartRun(state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil})
-										_ = rec.RecordFailure(runID, &state.WorkspaceFailureError{Code: "WorkspaceCorrupt", Message: corruption.Error(), Cause: corruption})
+										_ = rec.StartRun(state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil, Labels: inv.Labels})
+										_ = rec.RecordFailure(runID, failure)
 									}
 									res.ExitCode = ExitConfigError
-									return res, corruption
+									return res, classify(corruption, failure)
 								}
 								// incremental: ignore resume plan
-							} else if plan != nil && checkpointNode != "" {
+							} else if serr := steerResumePlan(graphObj, plan, invMap, inv.ResumeFrom); serr != nil {
+								report.Reason = serr.Error()
+								emitReport(state.ResumeRejected)
+								failure := &state.ExecutionFailureError{NodeID: inv.ResumeFrom, Code: "ResumeIneligible", Message: serr.Error(), Cause: serr}
+								if runID != "" {
+									_ = rec.StartRun(state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil, Labels: inv.Labels})
+									_ = rec.RecordFailure(runID, failure)
+								}
+								res.ExitCode = ExitConfigError
+								return res, classify(serr, failure)
+							} else if plan != nil && (checkpointNode != "" || inv.ResumeFrom != "") {
+							if inv.ResumeFrom != "" {
+								checkpointNode = inv.ResumeFrom
+								for name := range downstreamClosure(graphObj, inv.ResumeFrom) {
+									notes[name] = "re-executed by --resume-from"
+								}
+							}
 							candidatePrevID := prevID
 							candidatePrevPtr := &candidatePrevID
 							candidateRetry := prevRun.RetryCount + 1
-							newRun := state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: candidateRetry, Status: "running", PreviousRunID: candidatePrevPtr}
+							newRun := state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: candidateRetry, Status: "running", PreviousRunID: candidatePrevPtr, Invocation: inv.Settings}
 							checker := &state.ResumeEligibilityChecker{Store: st, ProjectRoot: inv.WorkDir}
-							if err := checker.Check(state.ResumeEligibilityRequest{NewRun: newRun, ResumeFromNodeID: checkpointNode, Graph: snap, Invalidation: invMap}); err == nil {
+							err := checker.Check(state.ResumeEligibilityRequest{NewRun: newRun, ResumeFromNodeID: checkpointNode, Graph: snap, Invalidation: invMap, AllowGraphEdits: prevRun.GraphHash != graphHash})
+							if err != nil {
+								report.Reason = "previous run not eligible: " + err.Error()
+							}
+							if err == nil {
+								logger.Info("resuming previous run", "previous_run", prevID, "checkpoint_node", checkpointNode, "retry", candidateRetry)
 								resumePlan = plan
 								previousRunID = candidatePrevPtr
 								retryCount = candidateRetry
+								for name := range invalidated {
+									resumePlan.Decisions[name] = incremental.DecisionExecute
+									notes[name] = "invalidated by --invalidate"
+								}
+								if inv.ResumeFrom != "" {
+									// from and its dependents execute fresh, bypassing their cached results.
+									for name := range downstreamClosure(graphObj, inv.ResumeFrom) {
+										runner.Rerun[name] = true
+									}
+								}
 								if _, ok := executor.(defaultGraphExecutor); ok {
-									executorToUse = cliGraphExecutor{Plan: resumePlan, Observer: obs}
+									executorToUse = cliGraphExecutor{Plan: resumePlan, Observer: obs, Observers: observers, Parallelism: parallelism, WorkDir: inv.WorkDir}
 								}
-							} else if inv.ExecutionMode == ExecutionModeResumeOnly {
+							} else if mustResume {
+								emitReport(state.ResumeRejected)
+								failure := &state.ExecutionFailureError{NodeID: "", Code: "ResumeIneligible", Message: err.Error(), Cause: err}
 								if runID != "" {
-									_ = rec.StartRun(state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil})
-									_ = rec.RecordFailure(runID, &state.ExecutionFailureError{NodeID: "", Code: "ResumeIneligible", Message: err.Error(), Cause: err})
+									_ = rec.StartRun(state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil, Labels: inv.Labels})
+									_ = rec.RecordFailure(runID, failure)
 								}
 								res.ExitCode = ExitConfigError
-								return res, err
+								return res, classify(err, failure)
 							}
 						}
 					}
 				}
 			}
 		}
-		if inv.ExecutionMode == ExecutionModeResumeOnly && resumePlan == nil {
+		if report.Reason == "" && resumePlan == nil {
+			report.Reason = "no task can be reused"
+		}
+		if mustResume && resumePlan == nil {
 			err := fmt.Errorf("resume-only mode requires an eligible previous run with checkpoints")
+			if inv.ResumeFrom != "" {
+				err = fmt.Errorf("--resume-from %s requires an eligible previous run with checkpoints", inv.ResumeFrom)
+			}
+			emitReport(state.ResumeRejected)
+			failure := &state.ExecutionFailureError{NodeID: "", Code: "ResumeIneligible", Message: err.Error(), Cause: err}
 			if runID != "" {
-				_ = rec.StartRun(state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil})
-				_ = rec.RecordFailure(runID, &state.ExecutionFailureError{NodeID: "", Code: "ResumeIneligible", Message: err.Error(), Cause: err})
+				_ = rec.StartRun(state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: 0, Status: "failed", PreviousRunID: nil, Labels: inv.Labels})
+				_ = rec.RecordFailure(runID, failure)
 			}
 			res.ExitCode = ExitConfigError
-			return res, err
+			return res, classify(err, failure)
+		}
+		if resumePlan != nil {
+			emitReport(state.ResumeResumed)
+		} else {
+			emitReport(state.ResumeScratch)
 		}
 	}
 
+	// Enforce the retention policy before this run adds to the store. The
+	// run being resumed from stays, whatever its age. Pruning is best-effort.
+	if runID != "" && st != nil {
+		protected := []string{runID}
+		if previousRunID != nil {
+			protected = append(protected, *previousRunID)
+		}
+		_, _ = st.Prune(inv.Retention, time.Now().UTC(), protected...)
+	}
+
 	// Record the run metadata now that we know GraphHash and any run linkage.
 	if runID != "" {
-		_ = rec.StartRun(state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: retryCount, Status: "running", PreviousRunID: previousRunID})
+		_ = rec.StartRun(state.Run{RunID: runID, GraphHash: graphHash, StartTime: time.Now().UTC(), Mode: state.ExecutionMode(inv.ExecutionMode), RetryCount: retryCount, Status: "running", PreviousRunID: previousRunID, Invocation: inv.Settings, Labels: inv.Labels})
+		if st != nil {
+			// Best-effort: without them only resuming across edits is lost.
+			_ = st.RecordSubtrees(graphHash, subtreeHashes(graphObj))
+		}
+		if inv.Provenance && st != nil {
+			// Best-effort: provenance is informational only.
+			_ = st.SaveProvenance(collectProvenance(inv, runID, graphHash, graphObj))
+		}
 	}
 
+	hookRun := newHookRunner(hooks, inv, runID, graphHash, logger)
 	defer func() {
 		if r := recover(); r != nil {
 			res.ExitCode = ExitInternalError
 			res.GraphResult = nil
 			execErr = fmt.Errorf("panic: %v", r)
+			failure := &state.SystemFailureError{Code: "Panic", Message: execErr.Error(), Cause: execErr}
 			if runID != "" {
-				_ = rec.RecordFailure(runID, &state.SystemFailureError{Code: "Panic", Message: fmt.Sprintf("panic: %v", r), Cause: execErr})
+				_ = rec.RecordFailure(runID, failure)
+			}
+			execErr = classify(execErr, failure)
+		}
+		hookRun.finish(ctx, res.ExitCode)
+		// Every return from here on finalizes the run, including panics.
+		if runID != "" && st != nil {
+			_ = st.RecordHooks(runID, hookRun.ran)
+			status := state.RunStatusFailed
+			if res.ExitCode == ExitSuccess {
+				status = state.RunStatusSucceeded
 			}
+			_ = st.FinalizeRun(state.RunEnd{RunID: runID, Status: status, ExitCode: res.ExitCode, EndTime: time.Now().UTC()})
 		}
 	}()
 
 	// If the caller provided the default executor, always run through the CLI-owned executor
 	// so we can attach checkpoint observer (even when resume is not possible).
 	if _, ok := executor.(defaultGraphExecutor); ok {
-		executorToUse = cliGraphExecutor{Plan: resumePlan, Observer: obs}
+		executorToUse = cliGraphExecutor{Plan: resumePlan, Observer: obs, Observers: observers, Parallelism: parallelism, WorkDir: inv.WorkDir}
+		if memo != nil {
+			executorToUse = replayGraphExecutor{Memo: *memo, Fallback: executorToUse, Replayed: &replayed}
+		}
+	}
+
+	if err := hookRun.preRun(ctx); err != nil {
+		failure := &state.ExecutionFailureError{Code: "HookFailed", Message: err.Error(), Cause: err}
+		if runID != "" {
+			_ = rec.RecordFailure(runID, failure)
+		}
+		res.ExitCode = ExitGraphFailure
+		return res, classify(err, failure)
 	}
 
-	gr, err := executorToUse.Run(ctx, graphObj, cacheRunner)
+	timed := newTimingRunner(registry)
+	runStart := time.Now()
+	gr, err := executorToUse.Run(ctx, graphObj, timed)
+	if err != nil && core.IsCacheSignatureInvalid(err) {
+		// An untrusted cache is a workspace problem, not an engine fault.
+		failure := &state.WorkspaceFailureError{Code: "CacheUntrusted", Message: err.Error(), Cause: err}
+		if runID != "" {
+			_ = rec.RecordFailure(runID, failure)
+		}
+		res.ExitCode = ExitConfigError
+		return res, classify(err, failure)
+	}
 	if err != nil {
+		failure := &state.SystemFailureError{Code: "EngineError", Message: err.Error(), Cause: err}
 		if runID != "" {
-			_ = rec.RecordFailure(runID, &state.SystemFailureError{Code: "EngineError", Message: err.Error(), Cause: err})
+			_ = rec.RecordFailure(runID, failure)
 		}
 		res.ExitCode = ExitInternalError
-		return res, err
+		return res, classify(err, failure)
 	}
 	res.GraphResult = gr
 	res.ExitCode = translateGraphResultToExitCode(gr)
+	logger.Debug("graph executed", "run_id", runID, "exit_code", res.ExitCode, "duration", time.Since(runStart))
+	if audits != nil {
+		if err := writeAuditReports(inv.OutputDir, audits); err != nil {
+			failure := &state.SystemFailureError{Code: "Audit", Message: err.Error(), Cause: err}
+			if runID != "" {
+				_ = rec.RecordFailure(runID, failure)
+			}
+			res.ExitCode = ExitInternalError
+			return res, classify(err, failure)
+		}
+	}
+	if inv.Attest && res.ExitCode == ExitSuccess {
+		if err := writeAttestations(inv, graphObj, gr, runner); err != nil {
+			failure := &state.SystemFailureError{Code: "Attestation", Message: err.Error(), Cause: err}
+			if runID != "" {
+				_ = rec.RecordFailure(runID, failure)
+			}
+			res.ExitCode = ExitInternalError
+			return res, classify(err, failure)
+		}
+	}
+	if res.ExitCode == ExitSuccess {
+		if err := writeManifest(inv, graphObj, gr, logger); err != nil {
+			failure := &state.SystemFailureError{Code: "Manifest", Message: err.Error(), Cause: err}
+			if runID != "" {
+				_ = rec.RecordFailure(runID, failure)
+			}
+			res.ExitCode = ExitInternalError
+			return res, classify(err, failure)
+		}
+	}
+	metrics := timed.runMetrics(runID, graphObj, gr)
+	metrics.Cache = cacheMetrics(counted.Stats(), metrics)
+	metrics.Cache.Namespace = namespace
+	metrics.GraphReplayed = replayed
+	res.Metrics = &metrics
+	timings := timed.timingLedger(runID, runStart)
+	res.Timings = &timings
+	if runID != "" && st != nil {
+		// Best-effort: the metrics sidecar feeds reporting only.
+		_ = st.SaveMetrics(metrics)
+		// Likewise the timing ledger, which stays out of the trace.
+		_ = st.SaveTimings(timings)
+		// Best-effort: the index only spares later queries a scan of runs.
+		_ = st.UpdateResultIndex(graphHash, taskResults(runID, metrics, gr))
+		if inv.MemoizeGraph && res.ExitCode == ExitSuccess && !replayed {
+			// Best-effort too: without a memo the next run is scheduled.
+			if m, ok := graphMemo(runID, inv.Params, graphObj, gr); ok {
+				_ = st.SaveGraphMemo(m)
+			}
+		}
+		// Resume folds unindexed runs itself, so this only saves it the work.
+		_, _ = st.FoldCheckpoints(graphHash, runID)
+	}
+	if inv.OTelEndpoint != "" {
+		// Observation only: a failed export never changes the outcome.
+		if err := exportSpans(ctx, inv.OTelEndpoint, inv, runID, graphHash, runStart, time.Now(), res.ExitCode, timed, metrics, gr); err != nil {
+			logger.Warn("otel export failed", "endpoint", inv.OTelEndpoint, "error", err)
+		}
+	}
 	if res.ExitCode == ExitGraphFailure && runID != "" {
 		// Deterministically choose a representative failed node.
 		failed := firstFailedNode(gr)
 		_ = rec.RecordFailure(runID, &state.ExecutionFailureError{NodeID: failed, Code: "NodeFailed", Message: fmt.Sprintf("node %s failed", failed)})
 	}
+	if inv.ReportJUnit != "" {
+		// Like spans, the report observes the run and never changes its outcome.
+		if err := writeJUnitReport(inv.ReportJUnit, inv, graphObj, gr); err != nil {
+			logger.Warn("junit report failed", "path", inv.ReportJUnit, "error", err)
+		}
+	}
+	if inv.ReportBEP != "" {
+		if err := writeBuildEvents(inv.ReportBEP, inv, runID, runStart, time.Now(), res.ExitCode, graphObj, gr, timed); err != nil {
+			logger.Warn("bep report failed", "path", inv.ReportBEP, "error", err)
+		}
+	}
+	if res.ExitCode == ExitGraphFailure && inv.FailureBundle != "" {
+		// Best-effort: the bundle is a diagnostic and never changes the outcome.
+		_ = writeFailureBundle(inv.FailureBundle, inv, graphObj, gr, runner, st, runID)
+	}
 	return res, nil
 }
 
@@ -293,21 +796,35 @@ func (o checkpointObserver) OnTaskTerminal(task core.Task, result *dag.NodeResul
 	if result == nil {
 		return fmt.Errorf("checkpoint observer: nil result")
 	}
-	if result.ExitCode != 0 {
+	// Services are restarted, and uncacheable tasks re-executed, on every
+	// run, and a task whose effect was already applied produced nothing;
+	// there is nothing to resume.
+	if task.NormalizedKind() == core.KindService || !task.IsCacheable() || result.EffectApplied {
 		return nil
 	}
+	allowedFailure := !task.Succeeded(result.ExitCode)
+	if allowedFailure && !task.AllowFailure {
+		return nil
+	}
+	// A failure caches no artifacts, so there are no outputs to vouch for.
+	outputs := task.Outputs
+	if allowedFailure {
+		outputs = nil
+	}
 	if task.Name == "" {
 		return fmt.Errorf("checkpoint observer: task name is empty")
 	}
 	_, err := o.Validator.CreateAndSave(state.CheckpointInput{
-		RunID:           o.RunID,
-		NodeID:          task.Name,
-		When:            time.Now().UTC(),
-		TaskHash:        result.Hash,
-		DeclaredOutputs: task.Outputs,
-		ExitCode:        result.ExitCode,
-		FromCache:       result.FromCache,
-		TraceEvents:     traceEvents,
+		RunID:            o.RunID,
+		NodeID:           task.Name,
+		When:             time.Now().UTC(),
+		TaskHash:         result.Hash,
+		DeclaredOutputs:  outputs,
+		ExitCode:         result.ExitCode,
+		SuccessExitCodes: task.SuccessExitCodes,
+		AllowedFailure:   allowedFailure,
+		FromCache:        result.FromCache,
+		TraceEvents:      traceEvents,
 	})
 	return err
 }
@@ -319,36 +836,64 @@ func detectPreviousRunID(st *state.Store, graphHash string) (string, error) {
 	if graphHash == "" {
 		return "", fmt.Errorf("graph hash is empty")
 	}
+	// Resume is only meaningful after a non-successful termination.
+	r, err := latestFailedRun(st, func(r state.Run) bool { return r.GraphHash == graphHash })
+	return r.RunID, err
+}
+
+// latestFailedRun returns the most recent unsucceeded run satisfying match
+// that has a persisted failure (crashed runs get one during stale-run
+// recovery), or the zero Run when there is none.
+func latestFailedRun(st *state.Store, match func(state.Run) bool) (state.Run, error) {
 	ids, err := st.ListRunIDs()
 	if err != nil {
-		return "", err
+		return state.Run{}, err
 	}
-	// Resume is only meaningful after a non-successful termination.
-	// Prefer the most recent run with matching graph hash that has a persisted failure.
-	var bestID string
-	var bestTime time.Time
+	var best state.Run
 	for _, id := range ids {
 		r, err := st.LoadRun(id)
 		if err != nil {
 			continue
 		}
-		if r.GraphHash != graphHash {
+		if !match(r) || r.Status == state.RunStatusSucceeded {
 			continue
 		}
 		if _, ferr := st.LoadFailure(id); ferr != nil {
 			continue
 		}
-		if bestID == "" || r.StartTime.After(bestTime) || (r.StartTime.Equal(bestTime) && r.RunID < bestID) {
-			bestID = r.RunID
-			bestTime = r.StartTime
+		if best.RunID == "" || r.StartTime.After(best.StartTime) || (r.StartTime.Equal(best.StartTime) && r.RunID < best.RunID) {
+			best = r
 		}
 	}
-	return bestID, nil
+	return best, nil
 }
 
-func buildResumePlan(ctx context.Context, g *dag.TaskGraph, runner *core.Runner, restoreRunner interface {
-	Restore(ctx context.Context, task core.Task) (*dag.NodeResult, error)
-}, cache core.Cache, checkpoints map[string]state.Checkpoint) (*incremental.IncrementalPlan, string, *incremental.GraphSnapshot, incremental.InvalidationMap, error) {
+// resumeCheckpoints returns the checkpoints a resume of prev may reuse: the
+// newest valid checkpoint of each node across all runs of prev's graph, read
+// from the graph's checkpoint index, so work checkpointed by an older run is
+// not lost when the latest failed run got less far. prev's own checkpoints
+// must be readable; when the index cannot be updated, only they are used.
+func resumeCheckpoints(st *state.Store, prev state.Run) (map[string]state.Checkpoint, error) {
+	x, err := st.LoadCheckpointIndex(prev.GraphHash)
+	if _, folded := x.Runs[prev.RunID]; err == nil && !folded {
+		_, err = st.FoldCheckpoints(prev.GraphHash, prev.RunID)
+	}
+	if err == nil {
+		if checkpoints, err := st.LatestCheckpoints(prev.GraphHash); err == nil {
+			return checkpoints, nil
+		}
+	}
+	return st.LoadAllCheckpoints(prev.RunID)
+}
+
+// buildResumePlan decides which checkpointed tasks can be reused. The
+// returned checkpoint node ends the reusable prefix of the topological order;
+// it is empty when the first task cannot be reused.
+//
+// Planning is read-only: downstream hashes are computed against the cached
+// artifacts of reused upstream tasks (overlaid on the filesystem) rather than
+// restored files, so each artifact is restored exactly once, by the executor.
+func buildResumePlan(g *dag.TaskGraph, runner *core.Runner, cache core.Cache, checkpoints map[string]state.Checkpoint, notes map[string]string) (*incremental.IncrementalPlan, string, *incremental.GraphSnapshot, incremental.InvalidationMap, error) {
 	if g == nil {
 		return nil, "", nil, nil, fmt.Errorf("nil graph")
 	}
@@ -373,7 +918,10 @@ func buildResumePlan(ctx context.Context, g *dag.TaskGraph, runner *core.Runner,
 
 	computedHash := make(map[string]core.TaskHash, len(order))
 	canReuse := make(map[string]bool, len(order))
-	restored := make(map[string]bool, len(order))
+
+	planner := *runner
+	planner.Resolver = core.NewInputResolver(runner.Resolver.BaseDir)
+	planner.Resolver.Fingerprints = runner.Resolver.Fingerprints
 
 	plan := &incremental.IncrementalPlan{Order: append([]string(nil), order...), Decisions: make(map[string]incremental.NodeExecutionDecision, len(order))}
 	for _, name := range order {
@@ -381,29 +929,7 @@ func buildResumePlan(ctx context.Context, g *dag.TaskGraph, runner *core.Runner,
 		// Populate snapshot for eligibility checks (only Upstream is used today).
 		snap.Nodes[name] = incremental.NodeSnapshot{Name: name, Upstream: append([]string(nil), upstream[name]...)}
 
-		// If we plan to reuse upstream tasks, restore their outputs before hashing this task's inputs.
-		for _, p := range upstream[name] {
-			if plan.Decisions[p] != incremental.DecisionReuseCache {
-				continue
-			}
-			if restored[p] {
-				continue
-			}
-			if restoreRunner == nil {
-				return nil, "", nil, nil, fmt.Errorf("restore runner is required to build resume plan after output dir was cleared")
-			}
-			pn, _ := g.Node(p)
-			res, err := restoreRunner.Restore(ctx, pn.Task)
-			if err != nil {
-				return nil, "", nil, nil, err
-			}
-			if res == nil || res.ExitCode != 0 {
-				return nil, "", nil, nil, fmt.Errorf("restoring %q for resume plan failed", p)
-			}
-			restored[p] = true
-		}
-
-		h, err := computeTaskHash(runner, n.Task)
+		h, err := computeTaskHash(&planner, n.Task)
 		if err != nil {
 			return nil, "", nil, nil, err
 		}
@@ -411,6 +937,7 @@ func buildResumePlan(ctx context.Context, g *dag.TaskGraph, runner *core.Runner,
 
 		cp, ok := checkpoints[name]
 		if !ok || !cp.Valid {
+			note(notes, name, "no valid checkpoint")
 			invMap[name] = incremental.InvalidationEntry{Invalidated: false, Reasons: nil}
 			canReuse[name] = false
 			plan.Decisions[name] = incremental.DecisionExecute
@@ -425,6 +952,7 @@ func buildResumePlan(ctx context.Context, g *dag.TaskGraph, runner *core.Runner,
 		}
 		invMap[name] = incremental.InvalidationEntry{Invalidated: invalidated, Reasons: nil}
 		if invalidated {
+			note(notes, name, "task hash changed since the checkpoint")
 			canReuse[name] = false
 			plan.Decisions[name] = incremental.DecisionExecute
 			continue
@@ -436,30 +964,37 @@ func buildResumePlan(ctx context.Context, g *dag.TaskGraph, runner *core.Runner,
 		if !exists {
 			return nil, "", nil, nil, fmt.Errorf("cache entry missing for checkpointed task %q", name)
 		}
+		entry, err := cache.Get(h)
+		if err != nil {
+			if !runner.HealCorruptEntries || !core.IsCacheCorrupt(err) {
+				return nil, "", nil, nil, fmt.Errorf("checkpointed task %q: %w", name, err)
+			}
+			// Corrupt entry: re-execute (the runner rewrites the entry).
+			note(notes, name, "cache entry corrupt")
+			invMap[name] = incremental.InvalidationEntry{Invalidated: true, Reasons: nil}
+			canReuse[name] = false
+			plan.Decisions[name] = incremental.DecisionExecute
+			continue
+		}
 		canReuse[name] = true
 
 		allUpstreamReuse := true
 		for _, p := range upstream[name] {
+			// A service restarts every run without changing what its
+			// dependents produce, so it does not force them to re-execute.
+			if pn, _ := g.Node(p); pn.Task.NormalizedKind() == core.KindService {
+				continue
+			}
 			if plan.Decisions[p] != incremental.DecisionReuseCache {
+				note(notes, name, fmt.Sprintf("upstream task %q is not reused", p))
 				allUpstreamReuse = false
 				break
 			}
 		}
 		if allUpstreamReuse {
 			plan.Decisions[name] = incremental.DecisionReuseCache
-			if !restored[name] {
-				if restoreRunner == nil {
-					return nil, "", nil, nil, fmt.Errorf("restore runner is required to build resume plan after output dir was cleared")
-				}
-				res, err := restoreRunner.Restore(ctx, n.Task)
-				if err != nil {
-					return nil, "", nil, nil, err
-				}
-				if res == nil || res.ExitCode != 0 {
-					return nil, "", nil, nil, fmt.Errorf("restoring %q for resume plan failed", name)
-				}
-				restored[name] = true
-			}
+			// Downstream tasks hash against what the executor will restore.
+			planner.Resolver.AddOverlay(entry)
 		} else {
 			plan.Decisions[name] = incremental.DecisionExecute
 		}
@@ -473,22 +1008,22 @@ func buildResumePlan(ctx context.Context, g *dag.TaskGraph, runner *core.Runner,
 		}
 		break
 	}
-	if checkpointNode == "" {
-		return nil, "", snap, invMap, nil
-	}
 	return plan, checkpointNode, snap, invMap, nil
 }
 
+// note records why task is not reused, when notes is non-nil.
+func note(notes map[string]string, task, reason string) {
+	if notes != nil {
+		notes[task] = reason
+	}
+}
+
 func computeTaskHash(r *core.Runner, task core.Task) (core.TaskHash, error) {
 	if r == nil {
 		return "", fmt.Errorf("nil runner")
 	}
-	inputSet, err := r.Resolver.Resolve(task.Inputs)
-	if err != nil {
-		return "", fmt.Errorf("resolving inputs: %w", err)
-	}
-	hashInput := core.HashInput{Inputs: inputSet, Command: task.Run, Env: task.Env, Outputs: task.Outputs, WorkingDir: r.WorkingDir}
-	return r.Hasher.ComputeHash(hashInput), nil
+	hash, _, err := r.TaskHash(&task)
+	return hash, err
 }
 
 func firstFailedNode(gr *dag.GraphResult) string {
@@ -520,7 +1055,7 @@ func translateGraphResultToExitCode(gr *dag.GraphResult) int {
 	return ExitSuccess
 }
 
-func cacheForMode(mode ExecutionMode, cacheDir string) (core.Cache, error) {
+func cacheForMode(mode ExecutionMode, cacheDir, compression string) (core.Cache, error) {
 	switch mode {
 	case ExecutionModeIncremental:
 		if cacheDir == "" {
@@ -529,7 +1064,9 @@ func cacheForMode(mode ExecutionMode, cacheDir string) (core.Cache, error) {
 		if err := os.MkdirAll(cacheDir, 0o755); err != nil {
 			return nil, fmt.Errorf("create cache dir: %w", err)
 		}
-		return core.NewFileCache(cacheDir), nil
+		cache := core.NewFileCache(cacheDir)
+		cache.Compression = compression
+		return cache, nil
 	case ExecutionModeResumeOnly:
 		if cacheDir == "" {
 			return nil, fmt.Errorf("cache dir is empty")
@@ -537,7 +1074,9 @@ func cacheForMode(mode ExecutionMode, cacheDir string) (core.Cache, error) {
 		if err := os.MkdirAll(cacheDir, 0o755); err != nil {
 			return nil, fmt.Errorf("create cache dir: %w", err)
 		}
-		return core.NewFileCache(cacheDir), nil
+		cache := core.NewFileCache(cacheDir)
+		cache.Compression = compression
+		return cache, nil
 	case ExecutionModeClean:
 		return noCache{}, nil
 	default:
@@ -582,8 +1121,8 @@ func prepareOutputDir(dir string) error {
 	return nil
 }
 
-func loadGraphAndHash(path string) (*dag.TaskGraph, string, error) {
-	g, err := LoadGraphFromFile(path)
+func loadGraphAndHash(path string, params map[string]string, normalize ...core.NormalizeRule) (*dag.TaskGraph, string, error) {
+	g, err := loadGraph(path, params, normalize)
 	if err != nil {
 		return nil, "", err
 	}
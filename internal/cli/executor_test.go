@@ -3,12 +3,19 @@ package cli
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"scriptweaver/internal/core"
 	"scriptweaver/internal/dag"
+	"scriptweaver/internal/incremental"
+	"scriptweaver/internal/projectintegration/engine/workspace"
+	"scriptweaver/internal/recovery/state"
 )
 
 type panicExecutor struct{}
@@ -174,3 +181,336 @@ func TestExecute_Panic_ExitCodeInternalAndTraceFinalized(t *testing.T) {
 		t.Fatalf("expected graphHash in trace")
 	}
 }
+
+func TestExecute_StrictOutputsFailsUndeclaredWrites(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{
+		{Name: "leaky", Run: "echo a > a.txt && echo x > stray.txt", Outputs: []string{"a.txt"}},
+		{Name: "after", Run: "echo b > b.txt", Outputs: []string{"b.txt"}},
+	}, []dag.Edge{{From: "leaky", To: "after"}})
+
+	inv, err := ParseInvocation([]string{
+		"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out",
+		"--trace", "trace.json", "--strict",
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !inv.StrictOutputs {
+		t.Fatalf("expected --strict to enable StrictOutputs")
+	}
+
+	res, err := Execute(context.Background(), inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ExitCode != ExitGraphFailure {
+		t.Fatalf("expected exit %d, got %d", ExitGraphFailure, res.ExitCode)
+	}
+	if res.GraphResult.FinalState["leaky"] != dag.TaskFailed || res.GraphResult.FinalState["after"] != dag.TaskSkipped {
+		t.Fatalf("unexpected final state: %v", res.GraphResult.FinalState)
+	}
+	traceBytes, err := os.ReadFile(filepath.Join(workDir, "trace.json"))
+	if err != nil {
+		t.Fatalf("read trace: %v", err)
+	}
+	var tr struct {
+		Events []struct {
+			Kind   string `json:"kind"`
+			TaskID string `json:"taskId"`
+			Reason string `json:"reason"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(traceBytes, &tr); err != nil {
+		t.Fatalf("unmarshal trace: %v", err)
+	}
+	found := false
+	for _, e := range tr.Events {
+		if e.Kind == "TaskFailed" && e.TaskID == "leaky" && e.Reason == "UndeclaredOutputs" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected TaskFailed/UndeclaredOutputs in trace: %s", traceBytes)
+	}
+}
+
+func TestExecute_ProtectInputsFailsInputMutation(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "in.txt"), []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{
+		{Name: "mutate", Run: "chmod u+w in.txt; echo v2 > in.txt; cp in.txt a.txt", Inputs: []string{"in.txt"}, Outputs: []string{"a.txt"}},
+		{Name: "after", Run: "cat in.txt > b.txt", Inputs: []string{"in.txt"}, Outputs: []string{"b.txt"}},
+	}, []dag.Edge{{From: "mutate", To: "after"}})
+
+	inv, err := ParseInvocation([]string{
+		"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out",
+		"--trace", "trace.json", "--protect-inputs",
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !inv.ProtectInputs {
+		t.Fatalf("expected --protect-inputs to enable ProtectInputs")
+	}
+
+	res, err := Execute(context.Background(), inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ExitCode != ExitGraphFailure {
+		t.Fatalf("expected exit %d, got %d", ExitGraphFailure, res.ExitCode)
+	}
+	if res.GraphResult.FinalState["mutate"] != dag.TaskFailed || res.GraphResult.FinalState["after"] != dag.TaskSkipped {
+		t.Fatalf("unexpected final state: %v", res.GraphResult.FinalState)
+	}
+	traceBytes, err := os.ReadFile(filepath.Join(workDir, "trace.json"))
+	if err != nil {
+		t.Fatalf("read trace: %v", err)
+	}
+	if !strings.Contains(string(traceBytes), `"reason":"InputsModified"`) {
+		t.Fatalf("expected TaskFailed/InputsModified in trace: %s", traceBytes)
+	}
+}
+
+func TestExecute_UnknownTaskKindIsConfigError(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "echo a > a.txt", Outputs: []string{"a.txt"}},
+		{Name: "b", Run: "call", Kind: "http-call"},
+	}, nil)
+
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	res, err := Execute(context.Background(), inv)
+	if err == nil || !strings.Contains(err.Error(), `unknown kind "http-call"`) {
+		t.Fatalf("expected unknown kind error, got %v", err)
+	}
+	if res.ExitCode != ExitConfigError {
+		t.Fatalf("expected exit %d, got %d", ExitConfigError, res.ExitCode)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("no task may run when a kind is unknown")
+	}
+}
+
+func TestExecute_FinalizesRunAndResumesFromCrashedRun(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "echo a > a.txt", Outputs: []string{"a.txt"}},
+		{Name: "b", Inputs: []string{"*.flag"}, Run: "test -f go.flag && echo b > b.txt", Outputs: []string{"b.txt"}},
+	}, []dag.Edge{{From: "a", To: "b"}})
+	parse := func(mode string) CLIInvocation {
+		t.Helper()
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--mode", mode})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		return inv
+	}
+	st, err := state.NewStore(workDir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	res, err := Execute(context.Background(), parse("incremental"))
+	if err != nil || res.ExitCode != ExitGraphFailure {
+		t.Fatalf("expected graph failure, got exit %d err %v", res.ExitCode, err)
+	}
+	ids, _ := st.ListRunIDs()
+	if len(ids) != 1 {
+		t.Fatalf("expected one run, got %v", ids)
+	}
+	first := ids[0]
+	run, _ := st.LoadRun(first)
+	end, err := st.LoadRunEnd(first)
+	if run.Status != state.RunStatusFailed || err != nil || end.ExitCode != ExitGraphFailure {
+		t.Fatalf("expected finalized failed run, got status %q end %+v (err=%v)", run.Status, end, err)
+	}
+
+	// Simulate the process dying mid-run: still "running", no end record or
+	// failure, owned by a process that has exited.
+	dead := exec.Command("true")
+	if err := dead.Run(); err != nil {
+		t.Fatalf("run true: %v", err)
+	}
+	run.Status = state.RunStatusRunning
+	if err := st.SaveRun(run); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"end.json", "failure.json"} {
+		if err := os.Remove(filepath.Join(workDir, ".scriptweaver", "runs", first, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.SaveOwner(first, state.RunOwner{PID: dead.ProcessState.Pid(), Hostname: state.CurrentOwner().Hostname}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(workDir, "go.flag"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = Execute(context.Background(), parse("resume-only"))
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("expected resume to succeed, got exit %d err %v", res.ExitCode, err)
+	}
+	if run, _ := st.LoadRun(first); run.Status != state.RunStatusCrashed {
+		t.Fatalf("expected stale run marked crashed, got %q", run.Status)
+	}
+	ids, _ = st.ListRunIDs()
+	for _, id := range ids {
+		if id == first {
+			continue
+		}
+		run, _ := st.LoadRun(id)
+		if run.Status != state.RunStatusSucceeded || run.PreviousRunID == nil || *run.PreviousRunID != first {
+			t.Fatalf("expected succeeded run resumed from %s, got %+v", first, run)
+		}
+	}
+}
+
+func TestExecute_WorkspaceLockedIsConfigError(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "echo a > a.txt", Outputs: []string{"a.txt"}},
+	}, nil)
+	ws, err := workspace.EnsureWorkspace(workDir)
+	if err != nil {
+		t.Fatalf("EnsureWorkspace: %v", err)
+	}
+	lock, err := workspace.AcquireLock(ws)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	res, err := Execute(context.Background(), inv)
+	if !errors.Is(err, workspace.ErrWorkspaceLocked) || res.ExitCode != ExitConfigError {
+		t.Fatalf("expected locked config error, got exit %d err %v", res.ExitCode, err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("no task may run while the workspace is locked")
+	}
+	if ids, _ := os.ReadDir(ws.RunsDir); len(ids) != 0 {
+		t.Fatalf("a locked-out invocation must not record runs, got %d", len(ids))
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	res, err = Execute(context.Background(), inv)
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("expected success after release, got exit %d err %v", res.ExitCode, err)
+	}
+	if _, err := os.Stat(filepath.Join(ws.Dir, workspace.LockFileName)); !os.IsNotExist(err) {
+		t.Fatalf("expected lock released at the end of the run, stat err=%v", err)
+	}
+}
+
+func TestBuildResumePlan_DoesNotRestoreArtifacts(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{
+		{Name: "a", Run: "mkdir -p out && echo a > out/a.txt", Outputs: []string{"out/a.txt"}},
+		{Name: "b", Inputs: []string{"out/a.txt"}, Run: "cat out/a.txt > out/b.txt", Outputs: []string{"out/b.txt"}},
+		{Name: "c", Inputs: []string{"out/b.txt", "*.flag"}, Run: "test -f go.flag && cat out/b.txt > out/c.txt", Outputs: []string{"out/c.txt"}},
+	}, []dag.Edge{{From: "a", To: "b"}, {From: "b", To: "c"}})
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitGraphFailure {
+		t.Fatalf("expected graph failure, got exit %d err %v", res.ExitCode, err)
+	}
+	st, err := state.NewStore(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, _ := st.ListRunIDs()
+	checkpoints, err := st.LoadAllCheckpoints(ids[0])
+	if err != nil || len(checkpoints) != 2 {
+		t.Fatalf("expected checkpoints for a and b, got %v (err=%v)", checkpoints, err)
+	}
+
+	// As after prepareOutputDir: the reused outputs are gone from disk.
+	if err := os.RemoveAll(filepath.Join(workDir, "out")); err != nil {
+		t.Fatal(err)
+	}
+	g, _, err := loadGraphAndHash(graphPath, nil)
+	if err != nil {
+		t.Fatalf("load graph: %v", err)
+	}
+	cache, err := cacheForMode(inv.ExecutionMode, inv.CacheDir, inv.CacheCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, checkpointNode, _, _, err := buildResumePlan(g, core.NewRunner(workDir, cache), cache, checkpoints, nil)
+	if err != nil {
+		t.Fatalf("buildResumePlan: %v", err)
+	}
+	if checkpointNode != "b" || plan.Decisions["a"] != incremental.DecisionReuseCache || plan.Decisions["b"] != incremental.DecisionReuseCache || plan.Decisions["c"] != incremental.DecisionExecute {
+		t.Fatalf("unexpected plan: checkpoint %q decisions %v", checkpointNode, plan.Decisions)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "out")); !os.IsNotExist(err) {
+		t.Fatalf("planning must not restore artifacts, stat err=%v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(workDir, "go.flag"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	inv.ExecutionMode = ExecutionModeResumeOnly
+	res, err := Execute(context.Background(), inv)
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("expected resume to succeed, got exit %d err %v", res.ExitCode, err)
+	}
+	if b, err := os.ReadFile(filepath.Join(workDir, "out", "c.txt")); err != nil || string(b) != "a\n" {
+		t.Fatalf("expected c built from restored upstream outputs, got %q (err=%v)", b, err)
+	}
+}
+
+func TestExecute_FingerprintCache(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		workDir := t.TempDir()
+		input := filepath.Join(workDir, "in.txt")
+		if err := os.WriteFile(input, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		old := time.Now().Add(-time.Hour)
+		if err := os.Chtimes(input, old, old); err != nil {
+			t.Fatal(err)
+		}
+		writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+			{Name: "a", Inputs: []string{"in.txt"}, Run: "cat in.txt > a.txt", Outputs: []string{"a.txt"}},
+		}, nil)
+		args := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"}
+		if disabled {
+			args = append(args, "--no-fingerprint-cache")
+		}
+		inv, err := ParseInvocation(args)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		if inv.NoFingerprintCache != disabled {
+			t.Fatalf("expected NoFingerprintCache=%v", disabled)
+		}
+		for i := 0; i < 2; i++ {
+			res, err := Execute(context.Background(), inv)
+			if err != nil || res.ExitCode != ExitSuccess {
+				t.Fatalf("run %d: exit %d err %v", i, res.ExitCode, err)
+			}
+		}
+		fp := core.LoadFingerprintCache(filepath.Join(workDir, ".scriptweaver", workspace.FingerprintsFileName))
+		if want := map[bool]int{false: 1, true: 0}[disabled]; fp.Len() != want {
+			t.Fatalf("disabled=%v: expected %d fingerprints, got %d", disabled, want, fp.Len())
+		}
+	}
+}
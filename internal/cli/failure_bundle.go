@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+	"scriptweaver/internal/trace"
+)
+
+// bundleInput is one entry of a failure bundle's inputs.json.
+type bundleInput struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// bundleCheckpoint is a checkpoint without its timestamp, so bundles of
+// identical runs are byte-identical.
+type bundleCheckpoint struct {
+	NodeID     string   `json:"node_id"`
+	CacheKeys  []string `json:"cache_keys"`
+	OutputHash string   `json:"output_hash"`
+	Valid      bool     `json:"valid"`
+}
+
+// bundleSummary is a failure bundle's summary.json.
+type bundleSummary struct {
+	GraphHash   string             `json:"graph_hash"`
+	FailedTasks []string           `json:"failed_tasks"`
+	Checkpoints []bundleCheckpoint `json:"checkpoints"`
+}
+
+// writeFailureBundle replaces dir with a failure bundle for gr:
+//
+//	summary.json               graph hash, failed tasks and the run's checkpoints
+//	tasks/<name>/task.json     the failed task's definition
+//	tasks/<name>/stdout|stderr its output, normalized as with --normalize-logs
+//	tasks/<name>/inputs.json   its resolved inputs with SHA-256 digests
+//	tasks/<name>/trace.json    trace events of the task and those it caused
+//
+// Nothing in the bundle depends on timestamps or run IDs, so rerunning the
+// same failure produces the same bytes.
+func writeFailureBundle(dir string, inv CLIInvocation, g *dag.TaskGraph, gr *dag.GraphResult, runner *core.Runner, st *state.Store, runID string) error {
+	var failed []string
+	for _, name := range g.TopologicalOrder() {
+		if gr.FinalState[name] == dag.TaskFailed {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failure bundle: %w", err)
+	}
+	summary := bundleSummary{GraphHash: string(gr.GraphHash), FailedTasks: failed, Checkpoints: []bundleCheckpoint{}}
+	if st != nil && runID != "" {
+		checkpoints, _ := st.LoadAllCheckpoints(runID)
+		for _, c := range checkpoints {
+			summary.Checkpoints = append(summary.Checkpoints, bundleCheckpoint{NodeID: c.NodeID, CacheKeys: c.CacheKeys, OutputHash: c.OutputHash, Valid: c.Valid})
+		}
+		sort.Slice(summary.Checkpoints, func(i, j int) bool { return summary.Checkpoints[i].NodeID < summary.Checkpoints[j].NodeID })
+	}
+	if err := writeBundleJSON(filepath.Join(dir, "summary.json"), summary); err != nil {
+		return err
+	}
+
+	var events []trace.TraceEvent
+	if len(gr.TraceBytes) > 0 {
+		parsed, err := trace.ParseExecutionTrace(gr.TraceBytes)
+		if err != nil {
+			return fmt.Errorf("failure bundle: %w", err)
+		}
+		events = parsed.Events
+	}
+	for _, name := range failed {
+		n, _ := g.Node(name)
+		if err := writeTaskBundle(filepath.Join(dir, "tasks", bundleTaskDir(name)), inv, n.Task, gr, runner, events); err != nil {
+			return fmt.Errorf("failure bundle %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// bundleTaskDir is the directory name of task name within a bundle's tasks
+// directory: the name path-escaped, with "." and ".." escaped as well, so
+// no name reaches outside it.
+func bundleTaskDir(name string) string {
+	escaped := url.PathEscape(name)
+	if escaped == "." || escaped == ".." {
+		escaped = strings.ReplaceAll(escaped, ".", "%2E")
+	}
+	return escaped
+}
+
+func writeTaskBundle(dir string, inv CLIInvocation, task core.Task, gr *dag.GraphResult, runner *core.Runner, events []trace.TraceEvent) error {
+	if err := writeBundleJSON(filepath.Join(dir, "task.json"), task); err != nil {
+		return err
+	}
+
+	normalizer, err := core.NewRuleNormalizer(task.Normalize, core.NewStreamNormalizer(core.NewDefaultNormalizer()))
+	if err != nil {
+		return err
+	}
+	for file, out := range map[string][]byte{"stdout": gr.Stdout[task.Name], "stderr": gr.Stderr[task.Name]} {
+		if err := os.WriteFile(filepath.Join(dir, file), normalizer.Normalize(out), 0o644); err != nil {
+			return err
+		}
+	}
+
+	inputs := []bundleInput{}
+	_, set, err := runner.TaskHash(&task)
+	if err != nil {
+		return err
+	}
+	for _, in := range set.Inputs {
+		p := filepath.FromSlash(in.Path)
+		if rel, err := filepath.Rel(inv.WorkDir, p); err == nil {
+			p = rel
+		}
+		inputs = append(inputs, bundleInput{Path: filepath.ToSlash(p), SHA256: in.ContentDigest()})
+	}
+	if err := writeBundleJSON(filepath.Join(dir, "inputs.json"), inputs); err != nil {
+		return err
+	}
+
+	slice := trace.ExecutionTrace{GraphHash: string(gr.GraphHash)}
+	for _, ev := range events {
+		if ev.TaskID == task.Name || ev.CauseTaskID == task.Name {
+			slice.Events = append(slice.Events, ev)
+		}
+	}
+	b, err := slice.CanonicalJSON()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "trace.json"), b, 0o644)
+}
+
+func writeBundleJSON(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
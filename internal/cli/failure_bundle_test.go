@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func TestExecute_FailureBundle(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "in.txt"), []byte("data\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "prep", Run: "echo ok > prep.out", Outputs: []string{"prep.out"}},
+		{Name: "broken", Inputs: []string{"in.txt"}, Run: "echo started at $(date -u +%Y-%m-%dT%H:%M:%SZ); echo boom >&2; exit 2"},
+		{Name: "after", Run: "true"},
+	}, []dag.Edge{{From: "prep", To: "broken"}, {From: "broken", To: "after"}})
+	bundle := filepath.Join(t.TempDir(), "bundle")
+	run := func() map[string]string {
+		t.Helper()
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--mode", "clean", "--failure-bundle", bundle})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitGraphFailure {
+			t.Fatalf("expected a graph failure, got exit %d err %v", res.ExitCode, err)
+		}
+		files := map[string]string{}
+		err = filepath.WalkDir(bundle, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			b, err := os.ReadFile(p)
+			rel, _ := filepath.Rel(bundle, p)
+			files[filepath.ToSlash(rel)] = string(b)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return files
+	}
+
+	first := run()
+	var want []string
+	for _, f := range []string{"summary.json", "inputs.json", "stderr", "stdout", "task.json", "trace.json"} {
+		if f != "summary.json" {
+			f = "tasks/broken/" + f
+		}
+		want = append(want, f)
+		if _, ok := first[f]; !ok {
+			t.Fatalf("bundle lacks %s; has %v", f, first)
+		}
+	}
+	if len(first) != len(want) {
+		t.Fatalf("bundle has %d files, want %d: %v", len(first), len(want), first)
+	}
+	var summary bundleSummary
+	if err := json.Unmarshal([]byte(first["summary.json"]), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.FailedTasks) != 1 || summary.FailedTasks[0] != "broken" {
+		t.Fatalf("failed tasks = %v", summary.FailedTasks)
+	}
+	if first["tasks/broken/stderr"] != "boom\n" || first["tasks/broken/stdout"] != "started at <TIMESTAMP>\n" {
+		t.Fatalf("unexpected output: stdout %q stderr %q", first["tasks/broken/stdout"], first["tasks/broken/stderr"])
+	}
+	if !strings.Contains(first["tasks/broken/inputs.json"], `"path": "in.txt"`) {
+		t.Fatalf("inputs.json = %s", first["tasks/broken/inputs.json"])
+	}
+	tr := first["tasks/broken/trace.json"]
+	if !strings.Contains(tr, `"TaskFailed"`) || !strings.Contains(tr, `"TaskSkipped"`) || strings.Contains(tr, `"prep"`) {
+		t.Fatalf("trace slice = %s", tr)
+	}
+
+	if second := run(); !maps.Equal(first, second) {
+		t.Fatalf("bundles differ between identical runs:\n%v\n%v", first, second)
+	}
+}
+
+func TestExecute_FailureBundleEscapesTaskNames(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "../../escape", Run: "exit 1"},
+		{Name: "..", Run: "exit 1"},
+	}, nil)
+	bundle := filepath.Join(workDir, "bundle")
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--failure-bundle", "bundle"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitGraphFailure {
+		t.Fatalf("expected a graph failure, got exit %d err %v", res.ExitCode, err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "escape")); !os.IsNotExist(err) {
+		t.Fatalf("bundle escaped its directory: %v", err)
+	}
+	for _, dir := range []string{"..%2F..%2Fescape", "%2E%2E"} {
+		if _, err := os.Stat(filepath.Join(bundle, "tasks", dir, "task.json")); err != nil {
+			t.Fatalf("missing bundle of task dir %s: %v", dir, err)
+		}
+	}
+}
+
+func TestParseInvocation_FailureBundleMustNotOverlap(t *testing.T) {
+	workDir := t.TempDir()
+	for _, bundle := range []string{".", "out", "out/bundle", "cache"} {
+		_, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--failure-bundle", bundle})
+		if ExitCode(err) != ExitInvalidInvocation {
+			t.Fatalf("--failure-bundle %s: expected invalid invocation, got %v", bundle, err)
+		}
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
 
 	"scriptweaver/internal/core"
 	"scriptweaver/internal/dag"
@@ -14,40 +17,231 @@ import (
 type graphFile struct {
 	Tasks []core.Task `json:"tasks"`
 	Edges []dag.Edge  `json:"edges"`
+
+	// Params declares typed parameters substituted into tasks (see params.go).
+	Params map[string]paramDecl `json:"params,omitempty"`
+
+	// Matrix holds template tasks expanded into Tasks at load time (see matrix.go).
+	Matrix []matrixTemplate `json:"matrix,omitempty"`
+
+	// Normalize holds rules prepended to the normalize rules of every task
+	// in this file (see core.NormalizeRule).
+	Normalize []core.NormalizeRule `json:"normalize,omitempty"`
+
+	// SourceDateEpoch pins SOURCE_DATE_EPOCH for every task in this file
+	// that does not declare it, instead of the value derived from each
+	// task's hash (see applySourceDateEpoch).
+	SourceDateEpoch *int64 `json:"source_date_epoch,omitempty"`
+
+	// Groups declares task groups and their barriers (see groups.go).
+	Groups []groupDecl `json:"groups,omitempty"`
+
+	// Graphs turns the file into a project manifest (see project.go).
+	// A manifest must not declare tasks of its own.
+	Graphs []projectGraphRef `json:"graphs,omitempty"`
+
+	// Hooks declares graph-level commands run around the run (see
+	// hooks.go). Only the top-level file may declare them.
+	Hooks *graphHooks `json:"hooks,omitempty"`
+
+	// Includes merges other graph files, pinned by graph hash, under a
+	// namespace (see include.go).
+	Includes []graphInclude `json:"includes,omitempty"`
 }
 
 // LoadGraphFromFile reads and parses the graph definition at path.
 //
-// Current supported format: JSON.
+// Current supported format: JSON. The file is either a single graph
+// (tasks + edges, optionally with matrix templates) or a project manifest
+// referencing namespaced graph files.
 //
 // The loader is deterministic:
 //   - Disallows unknown fields (to avoid silent divergence).
 //   - Does not consult environment variables.
 func LoadGraphFromFile(path string) (*dag.TaskGraph, error) {
+	return LoadGraphFromFileWithParams(path, nil)
+}
+
+// LoadGraphFromFileWithParams is LoadGraphFromFile with --param values.
+//
+// Every value must be declared by a loaded graph file; declared params without
+// a value fall back to their default. Substitution happens before the graph is
+// built, so parameter values flow into task and graph hashes.
+func LoadGraphFromFileWithParams(path string, params map[string]string) (*dag.TaskGraph, error) {
+	return loadGraph(path, params, nil)
+}
+
+// loadGraph is LoadGraphFromFileWithParams with project-wide normalize rules
+// (scriptweaver.toml) prepended to every task's rules, ahead of the rules of
+// its graph file.
+func loadGraph(path string, params map[string]string, normalize []core.NormalizeRule) (*dag.TaskGraph, error) {
+	return loadGraphWith(readGraphFile, path, params, normalize)
+}
+
+// loadGraphWith is loadGraph reading every graph file through read.
+func loadGraphWith(read func(path string) (graphFile, error), path string, params map[string]string, normalize []core.NormalizeRule) (*dag.TaskGraph, error) {
+	return loadIncludedGraph(read, path, params, normalize, nil)
+}
+
+// loadIncludedGraph is loadGraphWith for a file included, directly or
+// transitively, by the files in includedBy.
+func loadIncludedGraph(read func(path string) (graphFile, error), path string, params map[string]string, normalize []core.NormalizeRule, includedBy []string) (*dag.TaskGraph, error) {
+	used := make(map[string]bool, len(params))
+	load := func(p string) (graphFile, error) {
+		gf, err := read(p)
+		if err != nil {
+			return graphFile{}, err
+		}
+		return applyParams(gf, params, used)
+	}
+
+	gf, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	if gf.Hooks != nil && len(includedBy) > 0 {
+		return nil, fmt.Errorf("parse graph json: hooks must be declared by the including graph")
+	}
+	includes := gf.Includes
+	if len(gf.Graphs) > 0 {
+		if len(gf.Tasks) > 0 {
+			return nil, fmt.Errorf("parse graph json: project manifest must not declare tasks")
+		}
+		if len(gf.Groups) > 0 {
+			return nil, fmt.Errorf("parse graph json: project manifest must not declare groups")
+		}
+		gf, err = mergeProject(path, gf, load)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range sortedKeys(params) {
+		if !used[name] {
+			return nil, invalidInvocationf("--param %s is not declared by the graph", name)
+		}
+	}
+	// After namespacing, so "${task}" names the task as the graph does.
+	for i, t := range gf.Tasks {
+		gf.Tasks[i] = t.ExpandTaskName()
+	}
+	if len(includes) > 0 {
+		chain := append(includedBy[:len(includedBy):len(includedBy)], path)
+		included, err := loadIncludes(path, includes, func(p string) (*dag.TaskGraph, error) {
+			if slices.Contains(chain, p) {
+				return nil, fmt.Errorf("parse graph json: include cycle through %s", p)
+			}
+			return loadIncludedGraph(read, p, nil, nil, chain)
+		})
+		if err != nil {
+			return nil, err
+		}
+		gf.Tasks = append(gf.Tasks, included.Tasks...)
+		gf.Edges = append(gf.Edges, included.Edges...)
+	}
+	if len(gf.Tasks) == 0 {
+		return nil, fmt.Errorf("parse graph json: no tasks")
+	}
+	gf = applyGraphNormalize(graphFile{Tasks: gf.Tasks, Edges: gf.Edges, Normalize: normalize})
+	g, err := dag.NewTaskGraph(gf.Tasks, gf.Edges)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// checkWorkDirPaths rejects the first task, in topological order, with an
+// input or output resolving outside workDir (see
+// core.Task.ValidateWorkDirPaths).
+func checkWorkDirPaths(g *dag.TaskGraph, workDir string, allowInputs bool) error {
+	for _, name := range g.TopologicalOrder() {
+		n, _ := g.Node(name)
+		if err := n.Task.ValidateWorkDirPaths(workDir, allowInputs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readGraphFile strictly decodes one graph file, expands its matrix templates
+// and groups, and resolves its artifact references.
+func readGraphFile(path string) (graphFile, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("read graph: %w", err)
+		return graphFile{}, fmt.Errorf("read graph: %w", err)
 	}
 	var gf graphFile
 	dec := json.NewDecoder(bytes.NewReader(b))
 	dec.DisallowUnknownFields()
 	if err := dec.Decode(&gf); err != nil {
-		return nil, fmt.Errorf("parse graph json: %w", err)
+		return graphFile{}, fmt.Errorf("parse graph json: %w", err)
 	}
 	// Ensure there is no trailing garbage (including a second JSON value).
 	var trailing any
 	if err := dec.Decode(&trailing); err != io.EOF {
 		if err == nil {
-			return nil, fmt.Errorf("parse graph json: trailing data")
+			return graphFile{}, fmt.Errorf("parse graph json: trailing data")
 		}
-		return nil, fmt.Errorf("parse graph json: %w", err)
+		return graphFile{}, fmt.Errorf("parse graph json: %w", err)
 	}
-	if len(gf.Tasks) == 0 {
-		return nil, fmt.Errorf("parse graph json: no tasks")
+	gf, err = expandMatrix(gf)
+	if err != nil {
+		return graphFile{}, err
 	}
-	g, err := dag.NewTaskGraph(gf.Tasks, gf.Edges)
+	gf, err = applySourceDateEpoch(gf)
 	if err != nil {
-		return nil, err
+		return graphFile{}, err
 	}
-	return g, nil
+	gf, err = applyGroups(applyGraphNormalize(gf))
+	if err != nil {
+		return graphFile{}, err
+	}
+	return applyArtifactRefs(gf)
+}
+
+// applyGraphNormalize prepends the file's normalize rules to each of its
+// tasks' rules, so graph-wide scrubbing runs first and is hashed per task.
+func applyGraphNormalize(gf graphFile) graphFile {
+	if len(gf.Normalize) == 0 {
+		return gf
+	}
+	for i, t := range gf.Tasks {
+		rules := make([]core.NormalizeRule, 0, len(gf.Normalize)+len(t.Normalize))
+		rules = append(append(rules, gf.Normalize...), t.Normalize...)
+		gf.Tasks[i].Normalize = rules
+	}
+	return gf
+}
+
+// applySourceDateEpoch declares the file's SOURCE_DATE_EPOCH pin in the env
+// of each of its tasks that does not declare one, so the pin is hashed per
+// task and overrides the executor's hash-derived value.
+func applySourceDateEpoch(gf graphFile) (graphFile, error) {
+	if gf.SourceDateEpoch == nil {
+		return gf, nil
+	}
+	if *gf.SourceDateEpoch < 0 {
+		return graphFile{}, fmt.Errorf("parse graph json: source_date_epoch must not be negative")
+	}
+	epoch := strconv.FormatInt(*gf.SourceDateEpoch, 10)
+	for i, t := range gf.Tasks {
+		if _, ok := t.Env[core.SourceDateEpochEnvVar]; ok {
+			continue
+		}
+		env := make(map[string]string, len(t.Env)+1)
+		for k, v := range t.Env {
+			env[k] = v
+		}
+		env[core.SourceDateEpochEnvVar] = epoch
+		gf.Tasks[i].Env = env
+	}
+	return gf, nil
 }
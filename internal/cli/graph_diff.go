@@ -0,0 +1,386 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// GraphDiffInvocation is the canonical form of `scriptweaver graph diff`.
+type GraphDiffInvocation struct {
+	WorkDir string
+	Old     string
+	New     string
+	Params  map[string]string
+	// NormalizeRules come from scriptweaver.toml, as for a run.
+	NormalizeRules []core.NormalizeRule
+
+	// CacheDir, when set, is probed for results of the old graph that the
+	// new graph would not reuse; CacheNamespace and AbsoluteWorkDirHash
+	// select entries as for a run.
+	CacheDir            string
+	CacheNamespace      string
+	AbsoluteWorkDirHash bool
+}
+
+// GraphDiffReport is what `scriptweaver graph diff` prints. Every list is
+// sorted, so the report is byte-identical for identical graphs and cache.
+type GraphDiffReport struct {
+	OldGraphHash string `json:"old_graph_hash"`
+	NewGraphHash string `json:"new_graph_hash"`
+
+	Added   []string        `json:"added"`
+	Removed []string        `json:"removed"`
+	Changed []GraphDiffTask `json:"changed"`
+
+	AddedEdges   []dag.Edge `json:"added_edges"`
+	RemovedEdges []dag.Edge `json:"removed_edges"`
+
+	// Invalidated lists the old graph's cached results the new graph would
+	// not reuse. It is only reported with --cache-dir.
+	Invalidated []GraphDiffInvalidation `json:"invalidated,omitempty"`
+}
+
+// GraphDiffTask is a task whose definition differs between the graphs.
+type GraphDiffTask struct {
+	Name string `json:"name"`
+	// HashInputs names the core.HashInput components that changed; any
+	// change there gives the task a new task hash.
+	HashInputs []string `json:"hash_inputs"`
+	// Fields names the other changed task fields (e.g. group, when), by
+	// their graph file key. They do not change the task hash.
+	Fields []string `json:"fields"`
+}
+
+// Reasons a cached result is invalidated by the new graph.
+const (
+	// GraphDiffTaskRemoved: the task is not in the new graph.
+	GraphDiffTaskRemoved = "removed"
+	// GraphDiffHashChanged: the task's definition gives it a new task hash.
+	GraphDiffHashChanged = "hash_changed"
+	// GraphDiffUpstreamChanged: an upstream task was added or changed, so
+	// the task's inputs, and with them its hash, may change once it runs.
+	GraphDiffUpstreamChanged = "upstream_changed"
+)
+
+// GraphDiffInvalidation is a cached result of the old graph the new graph
+// would not, or may not, reuse.
+type GraphDiffInvalidation struct {
+	Task   string `json:"task"`
+	Hash   string `json:"hash"`
+	Reason string `json:"reason"`
+}
+
+// ParseGraphDiffInvocation parses the flags and the two graph paths
+// following `graph diff`. The paths resolve under --workdir.
+func ParseGraphDiffInvocation(args []string) (GraphDiffInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver graph diff", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	var cacheDir string
+	var cacheNamespace string
+	var absoluteHash bool
+	params := paramFlags{}
+
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+	fs.Var(params, "param", "Graph parameter key=value (repeatable), applied to both graphs.")
+	fs.StringVar(&cacheDir, "cache-dir", "", "Cache directory to report the cached results the new graph invalidates from. Optional.")
+	fs.StringVar(&cacheNamespace, "cache-namespace", "", "Namespace of --cache-dir holding the old graph's entries, as for a run.")
+	fs.BoolVar(&absoluteHash, "absolute-workdir-hash", false, "Hash tasks as a run with --absolute-workdir-hash does.")
+
+	if err := fs.Parse(args); err != nil {
+		return GraphDiffInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 2 {
+		return GraphDiffInvocation{}, invalidInvocationf("expected two graph paths, got %d", fs.NArg())
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return GraphDiffInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return GraphDiffInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	cfg, err := applyConfig(fs, workDir, params)
+	if err != nil {
+		return GraphDiffInvocation{}, err
+	}
+	namespace, err := core.ParseCacheNamespace(cacheNamespace)
+	if err != nil {
+		return GraphDiffInvocation{}, invalidInvocationf("--cache-namespace: %v", err)
+	}
+	inv := GraphDiffInvocation{WorkDir: workDir, NormalizeRules: cfg.Normalize, CacheNamespace: namespace, AbsoluteWorkDirHash: absoluteHash}
+	if inv.Old, err = resolveUnderWorkDir(workDir, fs.Arg(0)); err != nil {
+		return GraphDiffInvocation{}, err
+	}
+	if inv.New, err = resolveUnderWorkDir(workDir, fs.Arg(1)); err != nil {
+		return GraphDiffInvocation{}, err
+	}
+	if cacheDir != "" {
+		if inv.CacheDir, err = resolveUnderWorkDir(workDir, cacheDir); err != nil {
+			return GraphDiffInvocation{}, err
+		}
+	}
+	if len(params) > 0 {
+		inv.Params = params
+	}
+	return inv, nil
+}
+
+// GraphDiff loads both graphs and writes the GraphDiffReport to stdout as
+// JSON. Nothing is executed and the cache is only probed.
+func GraphDiff(inv GraphDiffInvocation, stdout io.Writer) (int, error) {
+	load := func(path string) (*dag.TaskGraph, error) {
+		g, _, err := loadGraphAndHash(path, inv.Params, inv.NormalizeRules...)
+		return g, err
+	}
+	oldGraph, err := load(inv.Old)
+	if err == nil {
+		var newGraph *dag.TaskGraph
+		if newGraph, err = load(inv.New); err == nil {
+			return writeGraphDiff(inv, oldGraph, newGraph, stdout)
+		}
+	}
+	var invErr *InvocationError
+	if errors.As(err, &invErr) && invErr.ExitCode != 0 {
+		return invErr.ExitCode, err
+	}
+	return ExitConfigError, err
+}
+
+func writeGraphDiff(inv GraphDiffInvocation, oldGraph, newGraph *dag.TaskGraph, stdout io.Writer) (int, error) {
+	report := diffGraphs(oldGraph, newGraph)
+	if inv.CacheDir != "" {
+		invalidated, err := invalidatedResults(inv, oldGraph, newGraph, report)
+		if err != nil {
+			return ExitConfigError, err
+		}
+		report.Invalidated = invalidated
+	}
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return ExitInternalError, err
+	}
+	if _, err := fmt.Fprintln(stdout, string(b)); err != nil {
+		return ExitInternalError, err
+	}
+	return ExitSuccess, nil
+}
+
+// hashInputComponents maps each core.HashInput component fed by a task
+// field to the graph file key of that field and a comparison that ignores
+// differences the hash ignores (input and output order, the default kind).
+var hashInputComponents = []struct {
+	name  string
+	key   string
+	equal func(a, b *core.Task) bool
+}{
+	{"Inputs", "inputs", func(a, b *core.Task) bool { return slices.Equal(sortedCopy(a.Inputs), sortedCopy(b.Inputs)) }},
+	{"Command", "run", func(a, b *core.Task) bool { return a.Run == b.Run }},
+	{"Env", "env", func(a, b *core.Task) bool { return maps.Equal(a.Env, b.Env) }},
+	{"Outputs", "outputs", func(a, b *core.Task) bool { return slices.Equal(sortedCopy(a.Outputs), sortedCopy(b.Outputs)) }},
+	{"Image", "image", func(a, b *core.Task) bool { return a.Image == b.Image }},
+	{"Kind", "kind", func(a, b *core.Task) bool { return a.NormalizedKind() == b.NormalizedKind() }},
+	{"Normalize", "normalize", func(a, b *core.Task) bool { return slices.Equal(a.Normalize, b.Normalize) }},
+	{"MaxOutputBytes", "max_output_bytes", func(a, b *core.Task) bool { return a.MaxOutputBytes == b.MaxOutputBytes }},
+	{"SuccessExitCodes", "success_exit_codes", func(a, b *core.Task) bool { return slices.Equal(a.SuccessExitCodes, b.SuccessExitCodes) }},
+	{"NotCacheable", "cacheable", func(a, b *core.Task) bool { return a.IsCacheable() == b.IsCacheable() }},
+	{"Stdin", "stdin", func(a, b *core.Task) bool { return reflect.DeepEqual(a.Stdin, b.Stdin) }},
+	{"Tools", "tools", func(a, b *core.Task) bool {
+		return len(a.Tools) == 0 && len(b.Tools) == 0 || reflect.DeepEqual(a.Tools, b.Tools)
+	}},
+	{"Network", "network", func(a, b *core.Task) bool { return a.Network == b.Network }},
+}
+
+func sortedCopy(s []string) []string {
+	out := append([]string(nil), s...)
+	sort.Strings(out)
+	return out
+}
+
+// diffGraphs compares the tasks and edges of two graphs by task name.
+func diffGraphs(oldGraph, newGraph *dag.TaskGraph) *GraphDiffReport {
+	report := &GraphDiffReport{
+		OldGraphHash: oldGraph.Hash().String(),
+		NewGraphHash: newGraph.Hash().String(),
+		Added:        []string{},
+		Removed:      []string{},
+		Changed:      []GraphDiffTask{},
+		AddedEdges:   []dag.Edge{},
+		RemovedEdges: []dag.Edge{},
+	}
+	for _, n := range oldGraph.Nodes() {
+		if _, ok := newGraph.Node(n.Name); !ok {
+			report.Removed = append(report.Removed, n.Name)
+		}
+	}
+	for _, n := range newGraph.Nodes() {
+		o, ok := oldGraph.Node(n.Name)
+		if !ok {
+			report.Added = append(report.Added, n.Name)
+			continue
+		}
+		if d := diffTask(&o.Task, &n.Task); len(d.HashInputs) > 0 || len(d.Fields) > 0 {
+			report.Changed = append(report.Changed, d)
+		}
+	}
+	sort.Strings(report.Added)
+	sort.Strings(report.Removed)
+	sort.Slice(report.Changed, func(i, j int) bool { return report.Changed[i].Name < report.Changed[j].Name })
+
+	report.AddedEdges = edgesNotIn(newGraph.Edges(), oldGraph.Edges())
+	report.RemovedEdges = edgesNotIn(oldGraph.Edges(), newGraph.Edges())
+	return report
+}
+
+// diffTask names the hash inputs and other fields that differ between two
+// definitions of a task.
+func diffTask(a, b *core.Task) GraphDiffTask {
+	d := GraphDiffTask{Name: b.Name, HashInputs: []string{}, Fields: []string{}}
+	hashed := make(map[string]bool, len(hashInputComponents))
+	for _, c := range hashInputComponents {
+		hashed[c.key] = true
+		if !c.equal(a, b) {
+			d.HashInputs = append(d.HashInputs, c.name)
+		}
+	}
+	fa, fb := taskFields(a), taskFields(b)
+	keys := make(map[string]bool)
+	for k := range fa {
+		keys[k] = true
+	}
+	for k := range fb {
+		keys[k] = true
+	}
+	for k := range keys {
+		if !hashed[k] && k != "name" && !bytes.Equal(fa[k], fb[k]) {
+			d.Fields = append(d.Fields, k)
+		}
+	}
+	sort.Strings(d.Fields)
+	return d
+}
+
+// taskFields returns t's graph file fields as encoded JSON.
+func taskFields(t *core.Task) map[string]json.RawMessage {
+	b, _ := json.Marshal(t)
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(b, &fields)
+	return fields
+}
+
+// edgesNotIn returns the edges of a missing from b, sorted.
+func edgesNotIn(a, b []dag.Edge) []dag.Edge {
+	in := make(map[dag.Edge]bool, len(b))
+	for _, e := range b {
+		in[e] = true
+	}
+	out := []dag.Edge{}
+	for _, e := range a {
+		if !in[e] {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].From != out[j].From {
+			return out[i].From < out[j].From
+		}
+		return out[i].To < out[j].To
+	})
+	return out
+}
+
+// invalidatedResults hashes the old graph's tasks against the working
+// directory as it is now and reports those whose result is cached but
+// would not be reused under the new graph: removed tasks, tasks whose hash
+// changes, and tasks downstream of an added or changed task. Tasks that
+// cannot be hashed now (e.g. their inputs are gone) have no usable entry
+// and are left out.
+func invalidatedResults(inv GraphDiffInvocation, oldGraph, newGraph *dag.TaskGraph, report *GraphDiffReport) ([]GraphDiffInvalidation, error) {
+	namespace := core.ResolveCacheNamespace(inv.CacheNamespace, oldGraph.Hash().String())
+	cache := core.NewFileCache(core.NamespacedCacheDir(inv.CacheDir, namespace))
+	hasher := func() *core.Runner {
+		r := core.NewRunner(inv.WorkDir, cache)
+		r.AbsoluteHashPaths = inv.AbsoluteWorkDirHash
+		return r
+	}
+	oldRunner, newRunner := hasher(), hasher()
+
+	// Tasks of the new graph whose upstream was added or changed.
+	roots := append([]string(nil), report.Added...)
+	for _, c := range report.Changed {
+		if len(c.HashInputs) > 0 {
+			roots = append(roots, c.Name)
+		}
+	}
+	upstreamChanged := downstreamClosure(newGraph, roots...)
+	for _, r := range roots {
+		delete(upstreamChanged, r)
+	}
+
+	out := []GraphDiffInvalidation{}
+	for _, name := range oldGraph.TopologicalOrder() {
+		o, _ := oldGraph.Node(name)
+		oldHash, err := computeTaskHash(oldRunner, o.Task)
+		if err != nil {
+			continue
+		}
+		cached, err := cache.Has(oldHash)
+		if err != nil {
+			return nil, err
+		}
+		if !cached {
+			continue
+		}
+		reason := ""
+		n, ok := newGraph.Node(name)
+		switch {
+		case !ok:
+			reason = GraphDiffTaskRemoved
+		default:
+			newHash, err := computeTaskHash(newRunner, n.Task)
+			switch {
+			case err != nil || newHash != oldHash:
+				reason = GraphDiffHashChanged
+			case upstreamChanged[name]:
+				reason = GraphDiffUpstreamChanged
+			}
+		}
+		if reason != "" {
+			out = append(out, GraphDiffInvalidation{Task: name, Hash: oldHash.String(), Reason: reason})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Task < out[j].Task })
+	return out, nil
+}
+
+func runGraph(args []string, stdout io.Writer) (int, error) {
+	if len(args) == 0 || (args[0] != "diff" && args[0] != "import") {
+		return ExitInvalidInvocation, invalidInvocationf("usage: scriptweaver graph diff --workdir <dir> <old> <new> | graph import --workdir <dir> --from make|compose|github-actions [--output <path>] <source>")
+	}
+	if args[0] == "import" {
+		inv, err := ParseGraphImportInvocation(args[1:])
+		if err != nil {
+			return ExitCode(err), err
+		}
+		return GraphImport(inv, stdout)
+	}
+	inv, err := ParseGraphDiffInvocation(args[1:])
+	if err != nil {
+		return ExitCode(err), err
+	}
+	return GraphDiff(inv, stdout)
+}
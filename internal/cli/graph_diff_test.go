@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func TestGraphDiff_ReportsTaskEdgeAndCacheChanges(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "old.json"), []core.Task{
+		{Name: "a", Run: "echo a > a.out", Outputs: []string{"a.out"}},
+		{Name: "b", Inputs: []string{"a.out"}, Run: "echo b"},
+		{Name: "c", Run: "echo c"},
+		{Name: "d", Run: "echo d"},
+		{Name: "f", Run: "echo f"},
+	}, []dag.Edge{{From: "a", To: "b"}, {From: "b", To: "c"}, {From: "a", To: "d"}})
+	writeGraphJSON(t, filepath.Join(workDir, "new.json"), []core.Task{
+		{Name: "a", Run: "echo a > a.out", Outputs: []string{"a.out"}, AllowFailure: true},
+		{Name: "b", Inputs: []string{"a.out"}, Run: "echo b2", Env: map[string]string{"X": "1"}},
+		{Name: "c", Run: "echo c"},
+		{Name: "e", Run: "echo e"},
+		{Name: "f", Run: "echo f", Network: core.NetworkNone},
+	}, []dag.Edge{{From: "a", To: "b"}, {From: "b", To: "c"}, {From: "e", To: "c"}})
+
+	res, err := Execute(context.Background(), CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     filepath.Join(workDir, "old.json"),
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	})
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("run old graph: exit=%d err=%v", res.ExitCode, err)
+	}
+
+	var out bytes.Buffer
+	code, handled, err := Dispatch([]string{"graph", "diff", "--workdir", workDir, "--cache-dir", "cache", "old.json", "new.json"}, &out)
+	if !handled || err != nil || code != ExitSuccess {
+		t.Fatalf("graph diff: handled=%v code=%d err=%v", handled, code, err)
+	}
+	var rep GraphDiffReport
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out.String())
+	}
+	if rep.OldGraphHash == rep.NewGraphHash {
+		t.Fatalf("graph hashes are equal: %s", rep.OldGraphHash)
+	}
+	if !reflect.DeepEqual(rep.Added, []string{"e"}) || !reflect.DeepEqual(rep.Removed, []string{"d"}) {
+		t.Fatalf("added=%v removed=%v", rep.Added, rep.Removed)
+	}
+	wantChanged := []GraphDiffTask{
+		{Name: "a", HashInputs: []string{}, Fields: []string{"allow_failure"}},
+		{Name: "b", HashInputs: []string{"Command", "Env"}, Fields: []string{}},
+		{Name: "f", HashInputs: []string{"Network"}, Fields: []string{}},
+	}
+	if !reflect.DeepEqual(rep.Changed, wantChanged) {
+		t.Fatalf("changed = %+v, want %+v", rep.Changed, wantChanged)
+	}
+	if !reflect.DeepEqual(rep.AddedEdges, []dag.Edge{{From: "e", To: "c"}}) || !reflect.DeepEqual(rep.RemovedEdges, []dag.Edge{{From: "a", To: "d"}}) {
+		t.Fatalf("added edges=%v removed edges=%v", rep.AddedEdges, rep.RemovedEdges)
+	}
+
+	reasons := map[string]string{}
+	for _, inv := range rep.Invalidated {
+		if inv.Hash == "" {
+			t.Fatalf("invalidation without hash: %+v", inv)
+		}
+		reasons[inv.Task] = inv.Reason
+	}
+	wantReasons := map[string]string{"b": GraphDiffHashChanged, "c": GraphDiffUpstreamChanged, "d": GraphDiffTaskRemoved, "f": GraphDiffHashChanged}
+	if !reflect.DeepEqual(reasons, wantReasons) {
+		t.Fatalf("invalidated = %+v, want reasons %v", rep.Invalidated, wantReasons)
+	}
+}
+
+func TestGraphDiff_RequiresTwoGraphs(t *testing.T) {
+	code, handled, err := Dispatch([]string{"graph", "diff", "--workdir", t.TempDir(), "old.json"}, &bytes.Buffer{})
+	if !handled || code != ExitInvalidInvocation || err == nil {
+		t.Fatalf("handled=%v code=%d err=%v", handled, code, err)
+	}
+}
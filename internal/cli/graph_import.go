@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"scriptweaver/internal/importer"
+)
+
+// Source formats of `scriptweaver graph import`.
+const (
+	ImportMake          = "make"
+	ImportCompose       = "compose"
+	ImportGitHubActions = "github-actions"
+)
+
+// importers maps each --from format to its converter.
+var importers = map[string]func(src []byte) (*importer.Graph, error){
+	ImportMake:          importer.ParseMakefile,
+	ImportCompose:       importer.ParseCompose,
+	ImportGitHubActions: importer.ParseWorkflow,
+}
+
+// GraphImportInvocation is the canonical form of `scriptweaver graph import`.
+type GraphImportInvocation struct {
+	WorkDir string
+	// From is the source format: ImportMake, ImportCompose or
+	// ImportGitHubActions.
+	From   string
+	Source string
+	// OutputPath is where the graph file is written; empty means stdout.
+	OutputPath string
+}
+
+// ParseGraphImportInvocation parses the flags and the source path following
+// `graph import`. Paths resolve under --workdir.
+func ParseGraphImportInvocation(args []string) (GraphImportInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver graph import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	var from string
+	var output string
+
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+	fs.StringVar(&from, "from", "", "Source format: make|compose|github-actions. Required.")
+	fs.StringVar(&output, "output", "", "Graph file to write (optional, defaults to stdout).")
+
+	if err := fs.Parse(args); err != nil {
+		return GraphImportInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 1 {
+		return GraphImportInvocation{}, invalidInvocationf("expected one source file, got %d", fs.NArg())
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return GraphImportInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return GraphImportInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	if _, ok := importers[from]; !ok {
+		return GraphImportInvocation{}, invalidInvocationf("invalid --from %q (expected make, compose or github-actions)", from)
+	}
+	inv := GraphImportInvocation{WorkDir: workDir, From: from}
+	var err error
+	if inv.Source, err = resolveUnderWorkDir(workDir, fs.Arg(0)); err != nil {
+		return GraphImportInvocation{}, err
+	}
+	if strings.TrimSpace(output) != "" {
+		if inv.OutputPath, err = resolveUnderWorkDir(workDir, output); err != nil {
+			return GraphImportInvocation{}, err
+		}
+	}
+	return inv, nil
+}
+
+// GraphImport converts the source file into a graph file. A source outside
+// the importer's subset exits 3 and writes nothing.
+func GraphImport(inv GraphImportInvocation, stdout io.Writer) (int, error) {
+	src, err := os.ReadFile(inv.Source)
+	if err != nil {
+		return ExitConfigError, fmt.Errorf("read import source: %w", err)
+	}
+	g, err := importers[inv.From](src)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	b, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return ExitInternalError, err
+	}
+	b = append(b, '\n')
+	if inv.OutputPath == "" {
+		if _, err := stdout.Write(b); err != nil {
+			return ExitInternalError, err
+		}
+		return ExitSuccess, nil
+	}
+	if err := os.WriteFile(inv.OutputPath, b, 0o644); err != nil {
+		return ExitConfigError, fmt.Errorf("write graph: %w", err)
+	}
+	return ExitSuccess, nil
+}
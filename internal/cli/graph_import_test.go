@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGraphImport_MakefileRunsAsGraph(t *testing.T) {
+	workDir := t.TempDir()
+	makefile := "OUT = out.txt\n\n$(OUT): gen.txt\n\tcat gen.txt gen.txt > $@\n\ngen.txt: src.txt\n\tcp $< $@\n"
+	if err := os.WriteFile(filepath.Join(workDir, "Makefile"), []byte(makefile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "src.txt"), []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, handled, err := Dispatch([]string{"graph", "import", "--workdir", workDir, "--from", "make", "--output", "graph.json", "Makefile"}, &bytes.Buffer{})
+	if !handled || err != nil || code != ExitSuccess {
+		t.Fatalf("graph import: handled=%v code=%d err=%v", handled, code, err)
+	}
+	res, err := Execute(context.Background(), CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     filepath.Join(workDir, "graph.json"),
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	})
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("run imported graph: exit=%d err=%v", res.ExitCode, err)
+	}
+	got, err := os.ReadFile(filepath.Join(workDir, "out.txt"))
+	if err != nil || string(got) != "x\nx\n" {
+		t.Fatalf("out.txt = %q, %v", got, err)
+	}
+}
+
+func TestGraphImport_UnsupportedMakefileExits3(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "Makefile"), []byte("%.o: %.c\n\tcc -c $<\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	code, _, err := Dispatch([]string{"graph", "import", "--workdir", workDir, "--from", "make", "Makefile"}, &out)
+	if code != ExitConfigError || err == nil || out.Len() != 0 {
+		t.Fatalf("code=%d err=%v output=%q", code, err, out.String())
+	}
+	if _, err := ParseGraphImportInvocation([]string{"--workdir", workDir, "--from", "ninja", "build.ninja"}); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("--from ninja: err = %v", err)
+	}
+}
+
+func TestGraphImport_WorkflowRunsAsGraph(t *testing.T) {
+	workDir := t.TempDir()
+	workflow := "on: push\njobs:\n  gen:\n    steps:\n      - uses: actions/checkout@v4\n      - run: echo \"$GREETING\" > gen.txt\n    env:\n      GREETING: hi\n  use:\n    needs: gen\n    steps:\n      - run: |\n          cat gen.txt > out.txt\n          echo done >> out.txt\n"
+	if err := os.WriteFile(filepath.Join(workDir, "ci.yml"), []byte(workflow), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code, _, err := Dispatch([]string{"graph", "import", "--workdir", workDir, "--from", "github-actions", "--output", "graph.json", "ci.yml"}, &bytes.Buffer{})
+	if err != nil || code != ExitSuccess {
+		t.Fatalf("graph import: code=%d err=%v", code, err)
+	}
+	res, err := Execute(context.Background(), CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     filepath.Join(workDir, "graph.json"),
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeClean,
+	})
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("run imported graph: exit=%d err=%v", res.ExitCode, err)
+	}
+	got, err := os.ReadFile(filepath.Join(workDir, "out.txt"))
+	if err != nil || string(got) != "hi\ndone\n" {
+		t.Fatalf("out.txt = %q, %v", got, err)
+	}
+}
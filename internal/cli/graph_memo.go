@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"strconv"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+// graphMemo describes gr as the memo of its graph, reporting false when the
+// run cannot be replayed: it did not complete every task, ran a task that
+// is not cached (services and non-cacheable tasks), or produced no trace.
+func graphMemo(runID string, params map[string]string, g *dag.TaskGraph, gr *dag.GraphResult) (state.GraphMemo, bool) {
+	if gr == nil || len(gr.TraceBytes) == 0 {
+		return state.GraphMemo{}, false
+	}
+	memo := state.GraphMemo{GraphHash: string(gr.GraphHash), RunID: runID, Params: maps.Clone(params), TaskHashes: make(map[string]string, len(gr.FinalState)), Outcome: memoOutcome(g), Trace: gr.TraceBytes}
+	for _, n := range g.Nodes() {
+		switch gr.FinalState[n.Name] {
+		case dag.TaskCompleted, dag.TaskCached:
+		default:
+			return state.GraphMemo{}, false
+		}
+		h := gr.TaskHashes[n.Name]
+		if !n.Task.IsCacheable() || n.Task.NormalizedKind() == core.KindService || h == "" {
+			return state.GraphMemo{}, false
+		}
+		memo.TaskHashes[n.Name] = string(h)
+	}
+	return memo, true
+}
+
+// memoOutcome digests, per task in topological order, the fields that
+// affect a run's outcome or trace but are in neither the graph hash nor the
+// task hash: expected output digests and the group label.
+func memoOutcome(g *dag.TaskGraph) string {
+	h := sha256.New()
+	write := func(s string) { fmt.Fprintf(h, "%d:%s", len(s), s) }
+	for _, name := range g.TopologicalOrder() {
+		n, _ := g.Node(name)
+		write(name)
+		write(n.Task.Group)
+		paths := sortedKeys(n.Task.ExpectedOutputs)
+		write(strconv.Itoa(len(paths)))
+		for _, p := range paths {
+			write(p)
+			write(n.Task.ExpectedOutputs[p])
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// matchGraphMemo reports why g cannot replay memo, or "" when it can: the
+// params, every task hash and the outcome fields (see memoOutcome) are those
+// of the memoized run, and each task's cache entry is present. Like resume planning, downstream hashes are
+// computed against the cached artifacts of their upstream tasks rather than
+// the files on disk, so nothing is restored before the run is known to
+// match.
+func matchGraphMemo(g *dag.TaskGraph, runner *core.Runner, cache core.Cache, memo state.GraphMemo, params map[string]string) (string, error) {
+	if !maps.Equal(memo.Params, params) {
+		return "params differ", nil
+	}
+	if len(memo.TaskHashes) != len(g.TopologicalOrder()) {
+		return "tasks differ", nil
+	}
+	if memo.Outcome != memoOutcome(g) {
+		return "expected outputs or groups differ", nil
+	}
+	planner := *runner
+	planner.Resolver = core.NewInputResolver(runner.Resolver.BaseDir)
+	planner.Resolver.Fingerprints = runner.Resolver.Fingerprints
+	for _, name := range g.TopologicalOrder() {
+		n, _ := g.Node(name)
+		want, ok := memo.TaskHashes[name]
+		if !ok {
+			return fmt.Sprintf("task %q is not memoized", name), nil
+		}
+		h, err := computeTaskHash(&planner, n.Task)
+		if err != nil {
+			return "", err
+		}
+		if h.String() != want {
+			return fmt.Sprintf("task %q changed", name), nil
+		}
+		entry, err := cache.Get(h)
+		if err != nil || entry == nil {
+			return fmt.Sprintf("cache entry of task %q is unusable", name), nil
+		}
+		planner.Resolver.AddOverlay(entry)
+	}
+	return "", nil
+}
+
+// replayGraphExecutor restores every task of a graph matching Memo from the
+// cache, in topological order, and returns Memo's trace, without
+// scheduling. Should any task not restore successfully from its entry, the
+// graph is run by Fallback instead, where the tasks restored so far are
+// cache hits.
+type replayGraphExecutor struct {
+	Memo     state.GraphMemo
+	Fallback GraphExecutor
+
+	// Replayed is set once a run replayed the memo.
+	Replayed *bool
+}
+
+func (r replayGraphExecutor) Run(ctx context.Context, graph *dag.TaskGraph, runner dag.TaskRunner) (*dag.GraphResult, error) {
+	restorer, ok := runner.(restoringRunner)
+	if !ok {
+		return r.Fallback.Run(ctx, graph, runner)
+	}
+	order := graph.TopologicalOrder()
+	sum := sha256.Sum256(r.Memo.Trace)
+	gr := &dag.GraphResult{
+		GraphHash:      graph.Hash(),
+		TraceHash:      hex.EncodeToString(sum[:]),
+		TraceBytes:     r.Memo.Trace,
+		FinalState:     make(dag.ExecutionState, len(order)),
+		ExecutionOrder: []string{},
+		TaskHashes:     make(map[string]core.TaskHash, len(order)),
+		Stdout:         make(map[string][]byte, len(order)),
+		Stderr:         make(map[string][]byte, len(order)),
+		ExitCode:       make(map[string]int, len(order)),
+	}
+	for _, name := range order {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, _ := graph.Node(name)
+		res, err := restorer.Restore(ctx, n.Task)
+		if err != nil {
+			return nil, err
+		}
+		if !res.FromCache || res.Invalidated || !n.Task.Succeeded(res.ExitCode) || len(res.OutputMismatches) > 0 {
+			return r.Fallback.Run(ctx, graph, runner)
+		}
+		gr.FinalState[name] = dag.TaskCached
+		gr.TaskHashes[name] = res.Hash
+		gr.Stdout[name] = res.Stdout
+		gr.Stderr[name] = res.Stderr
+		gr.ExitCode[name] = res.ExitCode
+	}
+	*r.Replayed = true
+	return gr, nil
+}
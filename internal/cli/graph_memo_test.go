@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+func TestExecute_MemoizeGraphReplaysIdenticalRun(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{
+		{Name: "a", Inputs: []string{"src.txt"}, Run: "cat src.txt > a.txt", Outputs: []string{"a.txt"}},
+		{Name: "b", Inputs: []string{"a.txt"}, Run: "cat a.txt a.txt > b.txt", Outputs: []string{"b.txt"}},
+	}, []dag.Edge{{From: "a", To: "b"}})
+	writeSrc := func(s string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(workDir, "src.txt"), []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inv := CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+		MemoizeGraph:  true,
+	}
+	run := func() CLIResult {
+		t.Helper()
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitSuccess || res.Metrics == nil {
+			t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+		}
+		return res
+	}
+
+	writeSrc("x\n")
+	first := run()
+	if first.Metrics.GraphReplayed {
+		t.Fatalf("first run replayed")
+	}
+	for _, f := range []string{"a.txt", "b.txt"} {
+		if err := os.Remove(filepath.Join(workDir, f)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	second := run()
+	if !second.Metrics.GraphReplayed || len(second.GraphResult.ExecutionOrder) != 0 {
+		t.Fatalf("second run: replayed=%v started=%v", second.Metrics.GraphReplayed, second.GraphResult.ExecutionOrder)
+	}
+	if !bytes.Equal(second.GraphResult.TraceBytes, first.GraphResult.TraceBytes) {
+		t.Fatalf("replayed trace differs:\n%s\nwant\n%s", second.GraphResult.TraceBytes, first.GraphResult.TraceBytes)
+	}
+	if got, err := os.ReadFile(filepath.Join(workDir, "b.txt")); err != nil || string(got) != "x\nx\n" {
+		t.Fatalf("b.txt = %q, %v", got, err)
+	}
+
+	// A changed input is scheduled as usual.
+	writeSrc("y\n")
+	if third := run(); third.Metrics.GraphReplayed {
+		t.Fatalf("run with a changed input replayed")
+	}
+
+	if _, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--mode", "clean", "--memoize-graph"}); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("--memoize-graph with --mode clean: err = %v", err)
+	}
+}
+
+func TestExecute_MemoizeGraphHonoursExpectedOutputs(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	task := core.Task{Name: "a", Run: "printf 'x\\n' > a.txt", Outputs: []string{"a.txt"}}
+	writeGraphJSON(t, graphPath, []core.Task{task}, nil)
+	inv := CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+		MemoizeGraph:  true,
+	}
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("first run: exit %d err %v", res.ExitCode, err)
+	}
+
+	// A mismatching expectation changes neither the graph nor the task hash.
+	task.ExpectedOutputs = map[string]string{"a.txt": strings.Repeat("0", 64)}
+	writeGraphJSON(t, graphPath, []core.Task{task}, nil)
+	res, err := Execute(context.Background(), inv)
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if res.ExitCode != ExitGraphFailure || res.Metrics == nil || res.Metrics.GraphReplayed {
+		t.Fatalf("second run: exit %d, want %d without replay", res.ExitCode, ExitGraphFailure)
+	}
+}
+
+func TestReplayGraphExecutor_FallsBackOnFailedRestore(t *testing.T) {
+	g, err := dag.NewTaskGraph([]core.Task{{Name: "a", Run: "true"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fallback := &countingGraphExecutor{}
+	replayed := false
+	ex := replayGraphExecutor{Memo: state.GraphMemo{Trace: []byte("{}")}, Fallback: fallback, Replayed: &replayed}
+	runner := fixedRestorer{res: &dag.NodeResult{FromCache: true, ExitCode: core.ExpectedOutputsExitCode}}
+	if _, err := ex.Run(context.Background(), g, runner); err != nil {
+		t.Fatal(err)
+	}
+	if replayed || fallback.runs != 1 {
+		t.Fatalf("replayed=%v fallback runs=%d, want a fallback", replayed, fallback.runs)
+	}
+}
+
+// fixedRestorer restores every task with res.
+type fixedRestorer struct{ res *dag.NodeResult }
+
+func (r fixedRestorer) Probe(ctx context.Context, task core.Task) (*dag.NodeResult, bool, error) {
+	return r.res, true, nil
+}
+
+func (r fixedRestorer) Run(ctx context.Context, task core.Task) (*dag.NodeResult, error) {
+	return r.res, nil
+}
+
+func (r fixedRestorer) Restore(ctx context.Context, task core.Task) (*dag.NodeResult, error) {
+	return r.res, nil
+}
+
+// countingGraphExecutor counts its runs and returns an empty result.
+type countingGraphExecutor struct{ runs int }
+
+func (c *countingGraphExecutor) Run(ctx context.Context, graph *dag.TaskGraph, runner dag.TaskRunner) (*dag.GraphResult, error) {
+	c.runs++
+	return &dag.GraphResult{}, nil
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+
+	"scriptweaver/internal/dag"
+)
+
+// groupDecl declares a task group (stage). Every task of each group named
+// in After runs before every task of this group.
+type groupDecl struct {
+	Name  string   `json:"name"`
+	After []string `json:"after,omitempty"`
+}
+
+// applyGroups expands the file's group barriers into task edges. Task groups
+// must be declared. A group with no tasks is looked through, so barriers stay
+// transitive: with "lint" < "test" < "package" and no tests, lint still runs
+// before packaging. Edges already declared are not duplicated.
+func applyGroups(gf graphFile) (graphFile, error) {
+	if len(gf.Groups) == 0 {
+		for _, t := range gf.Tasks {
+			if t.Group != "" {
+				return graphFile{}, fmt.Errorf("parse graph json: task %q: undeclared group %q", t.Name, t.Group)
+			}
+		}
+		return gf, nil
+	}
+
+	decls := make(map[string]groupDecl, len(gf.Groups))
+	for _, g := range gf.Groups {
+		if g.Name == "" {
+			return graphFile{}, fmt.Errorf("parse graph json: group name is required")
+		}
+		if _, dup := decls[g.Name]; dup {
+			return graphFile{}, fmt.Errorf("parse graph json: duplicate group %q", g.Name)
+		}
+		decls[g.Name] = g
+	}
+	for _, g := range gf.Groups {
+		for _, a := range g.After {
+			if _, ok := decls[a]; !ok {
+				return graphFile{}, fmt.Errorf("parse graph json: group %q: after undeclared group %q", g.Name, a)
+			}
+		}
+	}
+	if cycle := groupCycle(gf.Groups, decls); cycle != "" {
+		return graphFile{}, fmt.Errorf("parse graph json: group cycle through %q", cycle)
+	}
+
+	members := make(map[string][]string, len(decls))
+	for _, t := range gf.Tasks {
+		if t.Group == "" {
+			continue
+		}
+		if _, ok := decls[t.Group]; !ok {
+			return graphFile{}, fmt.Errorf("parse graph json: task %q: undeclared group %q", t.Name, t.Group)
+		}
+		members[t.Group] = append(members[t.Group], t.Name)
+	}
+
+	// predecessors are the non-empty groups g waits for, looking through
+	// empty ones, in declaration order without repeats.
+	var predecessors func(g string, seen map[string]bool) []string
+	predecessors = func(g string, seen map[string]bool) []string {
+		var out []string
+		for _, a := range decls[g].After {
+			if seen[a] {
+				continue
+			}
+			seen[a] = true
+			if len(members[a]) > 0 {
+				out = append(out, a)
+			} else {
+				out = append(out, predecessors(a, seen)...)
+			}
+		}
+		return out
+	}
+
+	out := gf
+	out.Edges = append([]dag.Edge(nil), gf.Edges...)
+	have := make(map[dag.Edge]bool, len(out.Edges))
+	for _, e := range out.Edges {
+		have[e] = true
+	}
+	for _, g := range gf.Groups {
+		for _, a := range predecessors(g.Name, map[string]bool{}) {
+			for _, from := range members[a] {
+				for _, to := range members[g.Name] {
+					e := dag.Edge{From: from, To: to}
+					if !have[e] {
+						have[e] = true
+						out.Edges = append(out.Edges, e)
+					}
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// groupCycle returns a group on an "after" cycle, or "".
+func groupCycle(groups []groupDecl, decls map[string]groupDecl) string {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(groups))
+	var visit func(g string) string
+	visit = func(g string) string {
+		switch state[g] {
+		case visiting:
+			return g
+		case done:
+			return ""
+		}
+		state[g] = visiting
+		for _, a := range decls[g].After {
+			if c := visit(a); c != "" {
+				return c
+			}
+		}
+		state[g] = done
+		return ""
+	}
+	for _, g := range groups {
+		if c := visit(g.Name); c != "" {
+			return c
+		}
+	}
+	return ""
+}
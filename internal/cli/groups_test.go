@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLoadGraphFromFile_GroupBarriersBecomeEdges(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "graph.json")
+	src := `{
+		"groups": [
+			{"name": "lint"},
+			{"name": "test", "after": ["lint"]},
+			{"name": "package", "after": ["test"]}
+		],
+		"tasks": [
+			{"name": "vet", "run": "true", "group": "lint"},
+			{"name": "fmt", "run": "true", "group": "lint"},
+			{"name": "tar", "run": "true", "group": "package"},
+			{"name": "notes", "run": "true"}
+		],
+		"edges": [{"From": "vet", "To": "tar"}]
+	}`
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadGraphFromFile(p)
+	if err != nil {
+		t.Fatalf("load graph: %v", err)
+	}
+	var edges []string
+	for _, e := range g.Edges() {
+		edges = append(edges, e.From+"->"+e.To)
+	}
+	sort.Strings(edges)
+	// The empty "test" group is looked through; vet->tar is not duplicated.
+	if got, want := strings.Join(edges, " "), "fmt->tar vet->tar"; got != want {
+		t.Fatalf("edges = %s, want %s", got, want)
+	}
+	n, _ := g.Node("vet")
+	if n.Task.Group != "lint" {
+		t.Fatalf("group label lost: %+v", n.Task)
+	}
+}
+
+func TestLoadGraphFromFile_RejectsInvalidGroups(t *testing.T) {
+	cases := map[string]struct {
+		src  string
+		want string
+	}{
+		"undeclared task group": {
+			src:  `{"tasks": [{"name": "a", "run": "true", "group": "x"}]}`,
+			want: `undeclared group "x"`,
+		},
+		"duplicate group": {
+			src:  `{"groups": [{"name": "x"}, {"name": "x"}], "tasks": [{"name": "a", "run": "true"}]}`,
+			want: `duplicate group "x"`,
+		},
+		"undeclared after": {
+			src:  `{"groups": [{"name": "x", "after": ["y"]}], "tasks": [{"name": "a", "run": "true"}]}`,
+			want: `after undeclared group "y"`,
+		},
+		"cycle": {
+			src:  `{"groups": [{"name": "x", "after": ["y"]}, {"name": "y", "after": ["x"]}], "tasks": [{"name": "a", "run": "true"}]}`,
+			want: "group cycle",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "graph.json")
+			if err := os.WriteFile(p, []byte(tc.src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadGraphFromFile(p)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestExecute_TraceCarriesTaskGroups(t *testing.T) {
+	workDir := t.TempDir()
+	graph := `{
+		"groups": [{"name": "build"}, {"name": "ship", "after": ["build"]}],
+		"tasks": [
+			{"name": "compile", "run": "true", "group": "build"},
+			{"name": "upload", "run": "true", "group": "ship"}
+		]
+	}`
+	if err := os.WriteFile(filepath.Join(workDir, "graph.json"), []byte(graph), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "build", "--trace", "trace.json"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	res, err := Execute(context.Background(), inv)
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+	}
+	b, err := os.ReadFile(filepath.Join(workDir, "trace.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"taskId":"compile","group":"build"`, `"taskId":"upload","group":"ship"`} {
+		if !strings.Contains(string(b), want) {
+			t.Fatalf("trace missing %s:\n%s", want, b)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// HelpRequest is returned by the invocation parsers when their arguments ask
+// for -h or --help. It is not a failure: callers print the command's usage
+// (see WriteUsage) and exit with ExitSuccess.
+type HelpRequest struct {
+	flags *flag.FlagSet
+}
+
+func (h *HelpRequest) Error() string { return "help requested" }
+
+// flagParseError converts a flag parsing error into an invocation error, or
+// into a HelpRequest carrying fs when help was asked for.
+func flagParseError(fs *flag.FlagSet, err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return &HelpRequest{flags: fs}
+	}
+	return invalidInvocationf("%v", err)
+}
+
+// command describes a subcommand for help and shell completion.
+type command struct {
+	// name is the command as typed, e.g. "runs prune".
+	name string
+	// args is the usage synopsis following the name.
+	args    string
+	summary string
+	// flags returns the command's flag set, or nil if it takes no flags.
+	flags func() *flag.FlagSet
+}
+
+// commands lists every subcommand in the order help shows them.
+var commands = []command{
+	{"run", "[flags]", "Execute the graph. The command name may be omitted.", flagsOf(func(a []string) error { _, err := ParseInvocation(a); return err })},
+	{"plan", "[flags]", "Report task hashes and which tasks would execute, restore or be skipped, without running anything (run --dry-run).", flagsOf(func(a []string) error { _, err := ParseInvocation(a); return err })},
+	{"validate", "[flags]", "Load and validate the graph without running it, and print its hash and tasks.", flagsOf(func(a []string) error { _, err := ParseValidateInvocation(a); return err })},
+	{"affected", "[flags]", "List the tasks affected by a list of changed files (--changed-files).", flagsOf(func(a []string) error { _, err := ParseAffectedInvocation(a); return err })},
+	{"cache stats", "[flags]", "Report cache hits, misses, bytes and restores of recorded runs.", flagsOf(func(a []string) error { _, err := ParseCacheStatsInvocation(a); return err })},
+	{"cache export", "[flags]", "Write the latest cache entries of selected tasks to a portable bundle.", flagsOf(func(a []string) error { _, err := ParseCacheExportInvocation(a); return err })},
+	{"cache import", "[flags] <bundle>", "Add the entries of a bundle written by cache export to the cache, after verifying them.", flagsOf(func(a []string) error { _, err := ParseCacheImportInvocation(a); return err })},
+	{"cache warm", "[flags]", "Restore the cache entries and artifacts checkpointed by a run (--from-run), after verifying them.", flagsOf(func(a []string) error { _, err := ParseCacheWarmInvocation(a); return err })},
+	{"cache verify", "[flags]", "Verify the cache entries referenced by checkpoints (run --verify-cache).", flagsOf(func(a []string) error { _, err := ParseInvocation(a); return err })},
+	{"runs list", "[flags]", "List recorded runs with their status and labels, optionally filtered by --label.", flagsOf(func(a []string) error { _, err := ParseRunsListInvocation(a); return err })},
+	{"runs prune", "[flags]", "Delete recorded runs outside the retention policy.", flagsOf(func(a []string) error { _, err := ParseRunsPruneInvocation(a); return err })},
+	{"runs repair", "[flags]", "Move corrupt checkpoints and cache entries into .scriptweaver/quarantine so resumed runs reuse the remaining checkpoints.", flagsOf(func(a []string) error { _, err := ParseRunsRepairInvocation(a); return err })},
+	{"runs compact", "[flags]", "Rebuild the per-graph checkpoint indexes that resume planning reads.", flagsOf(func(a []string) error { _, err := ParseRunsCompactInvocation(a); return err })},
+	{"trace diff", "[flags] <expected> <actual>", "Compare two trace files; exit 5 and list the diverging events if they differ.", flagsOf(func(a []string) error { _, err := ParseTraceDiffInvocation(a); return err })},
+	{"graph diff", "[flags] <old> <new>", "Compare two graph files: added, removed and changed tasks, edge changes and the cached results the new graph would invalidate.", flagsOf(func(a []string) error { _, err := ParseGraphDiffInvocation(a); return err })},
+	{"graph import", "[flags] <source>", "Convert a Makefile, docker-compose file or GitHub Actions workflow (--from make|compose|github-actions) into a graph file.", flagsOf(func(a []string) error { _, err := ParseGraphImportInvocation(a); return err })},
+	{"analyze critical-path", "[flags]", "Report the critical path, slack and speedups from recorded durations.", flagsOf(func(a []string) error { _, err := ParseAnalyzeInvocation("critical-path", a); return err })},
+	{"analyze simulate", "[flags]", "Project the run's wall time at each concurrency level from recorded durations.", flagsOf(func(a []string) error { _, err := ParseAnalyzeInvocation("simulate", a); return err })},
+	{"stats export", "[flags]", "Export metrics aggregated over recorded runs.", flagsOf(func(a []string) error { _, err := ParseStatsExportInvocation(a); return err })},
+	{"worker", "[flags]", "Serve remote execution of cache misses for --remote-worker.", flagsOf(func(a []string) error { _, err := ParseWorkerInvocation(a); return err })},
+	{"daemon start", "[flags]", "Serve run and plan requests on a socket under .scriptweaver, keeping graphs, input fingerprints and cache entries warm between them.", flagsOf(func(a []string) error { _, err := ParseDaemonInvocation(a); return err })},
+	{"daemon run", "[flags]", "Execute the graph through the workspace's daemon (see daemon start).", flagsOf(func(a []string) error { _, err := ParseInvocation(a); return err })},
+	{"daemon plan", "[flags]", "Plan through the workspace's daemon, as plan does.", flagsOf(func(a []string) error { _, err := ParseInvocation(a); return err })},
+	{"daemon stop", "[flags]", "Stop the workspace's daemon.", flagsOf(func(a []string) error { _, err := ParseDaemonInvocation(a); return err })},
+	{"completion", "bash|zsh|fish", "Print a shell completion script.", nil},
+	{"help", "[command]", "Show help for a command.", nil},
+}
+
+// flagsOf returns a function yielding the flag set parse defines, obtained
+// by asking parse for help, so help and completion never drift from the
+// parsers.
+func flagsOf(parse func(args []string) error) func() *flag.FlagSet {
+	return func() *flag.FlagSet {
+		var help *HelpRequest
+		if errors.As(parse([]string{"-h"}), &help) {
+			return help.flags
+		}
+		return nil
+	}
+}
+
+// lookupCommand returns the command named by the leading words of args.
+func lookupCommand(args []string) (command, bool) {
+	for _, c := range commands {
+		words := strings.Fields(c.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == c.name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// WriteHelp writes the list of commands.
+func WriteHelp(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("Usage: scriptweaver <command> [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "  %-14s %s\n", c.name, c.summary)
+	}
+	b.WriteString("\nRun \"scriptweaver help <command>\" for the flags of a command.\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteUsage writes the synopsis, summary and flags of the named command.
+func WriteUsage(w io.Writer, name string) error {
+	c, ok := lookupCommand(strings.Fields(name))
+	if !ok {
+		return invalidInvocationf("unknown command %q", name)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage: scriptweaver %s %s\n\n%s\n", c.name, c.args, c.summary)
+	if c.flags != nil {
+		if fs := c.flags(); fs != nil {
+			b.WriteString("\nFlags:\n")
+			fs.SetOutput(&b)
+			fs.PrintDefaults()
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func runHelp(args []string, stdout io.Writer) (int, error) {
+	if len(args) == 0 {
+		return ExitSuccess, WriteHelp(stdout)
+	}
+	if err := WriteUsage(stdout, strings.Join(args, " ")); err != nil {
+		return ExitCode(err), err
+	}
+	return ExitSuccess, nil
+}
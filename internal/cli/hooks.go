@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"scriptweaver/internal/recovery/state"
+)
+
+// Graph hook phases, as recorded in state.HookResult.Phase.
+const (
+	hookPreRun    = "pre_run"
+	hookPostRun   = "post_run"
+	hookOnFailure = "on_failure"
+)
+
+// graphHooks are shell commands run around the graph, declared by the
+// "hooks" object of the top-level graph file. They are not tasks: they are
+// never cached, hashed or traced, and may depend on the environment (e.g.
+// fetching credentials or posting status to a dashboard).
+//
+// The commands of a phase run in order in the working directory and the
+// phase stops at the first failing one. pre_run runs before the first task;
+// its failure fails the run before any task starts. on_failure runs when the
+// run failed, then post_run runs whatever the outcome. Failures of those two
+// are recorded but never change the run's outcome.
+type graphHooks struct {
+	PreRun    []string `json:"pre_run,omitempty"`
+	PostRun   []string `json:"post_run,omitempty"`
+	OnFailure []string `json:"on_failure,omitempty"`
+}
+
+// validate rejects blank commands.
+func (h *graphHooks) validate() error {
+	if h == nil {
+		return nil
+	}
+	for phase, cmds := range map[string][]string{hookPreRun: h.PreRun, hookPostRun: h.PostRun, hookOnFailure: h.OnFailure} {
+		for i, cmd := range cmds {
+			if strings.TrimSpace(cmd) == "" {
+				return fmt.Errorf("parse graph json: hooks.%s[%d] is empty", phase, i)
+			}
+		}
+	}
+	return nil
+}
+
+// loadGraphHooks returns the hooks declared by the graph file at path, or
+// nil when it declares none.
+func loadGraphHooks(path string) (*graphHooks, error) {
+	gf, err := readGraphFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return gf.Hooks, gf.Hooks.validate()
+}
+
+// hookRunner runs the hook phases of one run and collects their results.
+type hookRunner struct {
+	hooks *graphHooks
+	dir   string
+	env   []string // added to the process environment
+	out   io.Writer
+	log   *slog.Logger
+	ran   []state.HookResult
+}
+
+func newHookRunner(hooks *graphHooks, inv CLIInvocation, runID, graphHash string, logger *slog.Logger) *hookRunner {
+	return &hookRunner{
+		hooks: hooks,
+		dir:   inv.WorkDir,
+		env:   []string{"SCRIPTWEAVER_RUN_ID=" + runID, "SCRIPTWEAVER_GRAPH_HASH=" + graphHash},
+		out:   inv.errOut(),
+		log:   logger,
+	}
+}
+
+// preRun runs the pre_run phase.
+func (r *hookRunner) preRun(ctx context.Context) error {
+	if r.hooks == nil {
+		return nil
+	}
+	return r.run(ctx, hookPreRun, r.hooks.PreRun, nil)
+}
+
+// finish runs on_failure when exitCode is not ExitSuccess, then post_run.
+// They run even when ctx was cancelled, so teardown is not skipped on
+// interruption; their errors are only logged as warnings.
+func (r *hookRunner) finish(ctx context.Context, exitCode int) {
+	if r.hooks == nil {
+		return
+	}
+	if ctx != nil {
+		ctx = context.WithoutCancel(ctx)
+	}
+	extra := []string{"SCRIPTWEAVER_EXIT_CODE=" + strconv.Itoa(exitCode)}
+	if exitCode != ExitSuccess {
+		if err := r.run(ctx, hookOnFailure, r.hooks.OnFailure, extra); err != nil {
+			r.log.Warn("hook failed", "phase", hookOnFailure, "error", err)
+		}
+	}
+	if err := r.run(ctx, hookPostRun, r.hooks.PostRun, extra); err != nil {
+		r.log.Warn("hook failed", "phase", hookPostRun, "error", err)
+	}
+}
+
+func (r *hookRunner) run(ctx context.Context, phase string, cmds []string, extra []string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, command := range cmds {
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Dir = r.dir
+		cmd.Env = append(append(append(os.Environ(), r.env...), "SCRIPTWEAVER_HOOK="+phase), extra...)
+		// Hook output is not task output; keep it off stdout.
+		cmd.Stdout, cmd.Stderr = r.out, r.out
+		r.log.Debug("running hook", "phase", phase, "command", command)
+		res := state.HookResult{Phase: phase, Command: command}
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		switch {
+		case errors.As(err, &exitErr):
+			res.ExitCode = exitErr.ExitCode()
+		case err != nil:
+			res.ExitCode = -1
+			res.Error = err.Error()
+		}
+		r.ran = append(r.ran, res)
+		if err != nil {
+			return fmt.Errorf("%s hook %q failed: %w", phase, command, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/recovery/state"
+)
+
+func writeHookedGraph(t *testing.T, path string, tasks []core.Task, hooks graphHooks) {
+	t.Helper()
+	b, err := json.Marshal(map[string]any{"tasks": tasks, "edges": []any{}, "hooks": hooks})
+	if err != nil {
+		t.Fatalf("marshal graph: %v", err)
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatalf("write graph: %v", err)
+	}
+}
+
+func TestExecute_GraphHooks(t *testing.T) {
+	hooks := graphHooks{
+		PreRun:    []string{"echo pre >> hooks.log"},
+		OnFailure: []string{"echo failed $SCRIPTWEAVER_EXIT_CODE >> hooks.log"},
+		PostRun:   []string{"echo post >> hooks.log"},
+	}
+	run := func(t *testing.T, task core.Task, hooks graphHooks) (CLIResult, string, state.Run) {
+		t.Helper()
+		workDir := t.TempDir()
+		writeHookedGraph(t, filepath.Join(workDir, "graph.json"), []core.Task{task}, hooks)
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, _ := Execute(context.Background(), inv)
+		log, err := os.ReadFile(filepath.Join(workDir, "hooks.log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		st, err := state.NewStore(workDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids, err := st.ListRunIDs()
+		if err != nil || len(ids) != 1 {
+			t.Fatalf("expected one run, got %v (err=%v)", ids, err)
+		}
+		record, err := st.LoadRun(ids[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res, string(log), record
+	}
+
+	t.Run("success", func(t *testing.T) {
+		res, log, record := run(t, core.Task{Name: "t", Run: "echo task >> hooks.log"}, hooks)
+		if res.ExitCode != ExitSuccess {
+			t.Fatalf("expected success, got %d", res.ExitCode)
+		}
+		if log != "pre\ntask\npost\n" {
+			t.Fatalf("unexpected hook order %q", log)
+		}
+		var phases []string
+		for _, h := range record.Hooks {
+			phases = append(phases, h.Phase)
+		}
+		if !reflect.DeepEqual(phases, []string{hookPreRun, hookPostRun}) {
+			t.Fatalf("expected hooks recorded in the run record, got %+v", record.Hooks)
+		}
+	})
+
+	t.Run("task failure", func(t *testing.T) {
+		res, log, record := run(t, core.Task{Name: "t", Run: "exit 2"}, hooks)
+		if res.ExitCode != ExitGraphFailure {
+			t.Fatalf("expected graph failure, got %d", res.ExitCode)
+		}
+		if log != "pre\nfailed 1\npost\n" {
+			t.Fatalf("unexpected hook order %q", log)
+		}
+		if len(record.Hooks) != 3 || record.Hooks[1].Phase != hookOnFailure {
+			t.Fatalf("expected on_failure recorded, got %+v", record.Hooks)
+		}
+	})
+
+	t.Run("pre_run failure", func(t *testing.T) {
+		failing := hooks
+		failing.PreRun = []string{"echo pre >> hooks.log; exit 7", "echo unreachable >> hooks.log"}
+		res, log, record := run(t, core.Task{Name: "t", Run: "echo task >> hooks.log"}, failing)
+		if res.ExitCode != ExitGraphFailure {
+			t.Fatalf("expected graph failure, got %d", res.ExitCode)
+		}
+		if strings.Contains(log, "task") || strings.Contains(log, "unreachable") {
+			t.Fatalf("expected the run to stop at the failing pre_run hook, got %q", log)
+		}
+		if log != "pre\nfailed 1\npost\n" {
+			t.Fatalf("unexpected hook order %q", log)
+		}
+		if record.Hooks[0].ExitCode != 7 || record.Status != state.RunStatusFailed {
+			t.Fatalf("unexpected run record %+v", record)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"scriptweaver/internal/dag"
+)
+
+// graphInclude pins another graph file merged into the including graph.
+//
+// Path is resolved relative to the directory containing the including file.
+// Hash is the graph hash the included file must have on its own (as printed
+// by validate), so a shared pipeline library cannot change underneath the
+// graphs that use it.
+type graphInclude struct {
+	Namespace string `json:"namespace"`
+	Path      string `json:"path"`
+	Hash      string `json:"hash"`
+}
+
+// loadIncludes loads every graph pinned by includes of the file at path,
+// checks its hash and returns the tasks and edges of all of them, named
+// "<namespace>/<name>".
+//
+// Each included file is loaded by load as a complete graph, with its own
+// param defaults, matrix templates, groups, project members and includes,
+// but no --param values or project-wide normalize rules. Its tasks are then
+// taken exactly as hashed; only their names, groups and condition references
+// gain the namespace, so "${task}" keeps naming the task as the included
+// graph does. Includes are loaded in namespace order so the result does not
+// depend on declaration order.
+func loadIncludes(path string, includes []graphInclude, load func(path string) (*dag.TaskGraph, error)) (graphFile, error) {
+	refs := append([]graphInclude(nil), includes...)
+	seen := make(map[string]struct{}, len(refs))
+	for _, ref := range refs {
+		if err := validateNamespace(ref.Namespace); err != nil {
+			return graphFile{}, fmt.Errorf("parse graph json: include: invalid namespace %q", ref.Namespace)
+		}
+		if _, ok := seen[ref.Namespace]; ok {
+			return graphFile{}, fmt.Errorf("parse graph json: duplicate include namespace %q", ref.Namespace)
+		}
+		seen[ref.Namespace] = struct{}{}
+		if ref.Path == "" {
+			return graphFile{}, fmt.Errorf("parse graph json: include %q has empty path", ref.Namespace)
+		}
+		if ref.Hash == "" {
+			return graphFile{}, fmt.Errorf("parse graph json: include %q has empty hash", ref.Namespace)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Namespace < refs[j].Namespace })
+
+	baseDir := filepath.Dir(path)
+	var out graphFile
+	for _, ref := range refs {
+		p := ref.Path
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(baseDir, p)
+		}
+		g, err := load(p)
+		if err != nil {
+			return graphFile{}, fmt.Errorf("include %q: %w", ref.Namespace, err)
+		}
+		if got := g.Hash().String(); got != ref.Hash {
+			return graphFile{}, fmt.Errorf("include %q: %s has graph hash %s, want %s", ref.Namespace, ref.Path, got, ref.Hash)
+		}
+		for _, n := range g.Nodes() {
+			t := n.Task
+			t.Name = qualifyTaskName(ref.Namespace, t.Name)
+			if t.Group != "" {
+				t.Group = qualifyTaskName(ref.Namespace, t.Group)
+			}
+			t.When = t.When.Replace(nil, func(name string) string {
+				return qualifyTaskName(ref.Namespace, name)
+			})
+			out.Tasks = append(out.Tasks, t)
+		}
+		for _, e := range g.Edges() {
+			out.Edges = append(out.Edges, dag.Edge{
+				From: qualifyTaskName(ref.Namespace, e.From),
+				To:   qualifyTaskName(ref.Namespace, e.To),
+			})
+		}
+	}
+	return out, nil
+}
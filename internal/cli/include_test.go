@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/dag"
+)
+
+func TestLoadGraphFromFile_IncludeMergesPinnedGraph(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib", "go.json")
+	if err := os.MkdirAll(filepath.Dir(lib), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lib, []byte(`{
+		"params": {"pkg": {"type": "string", "default": "./..."}},
+		"tasks": [
+			{"name": "vet", "run": "echo ${task} && go vet ${params.pkg}"},
+			{"name": "test", "run": "go test ${params.pkg}", "when": {"upstream_succeeded": ["vet"]}}
+		],
+		"edges": [{"from": "vet", "to": "test"}]
+	}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	libGraph, err := LoadGraphFromFile(lib)
+	if err != nil {
+		t.Fatalf("load library: %v", err)
+	}
+
+	main := filepath.Join(dir, "graph.json")
+	if err := os.WriteFile(main, []byte(fmt.Sprintf(`{
+		"includes": [{"namespace": "go", "path": "lib/go.json", "hash": %q}],
+		"tasks": [{"name": "release", "run": "echo release"}],
+		"edges": [{"from": "go/test", "to": "release"}]
+	}`, libGraph.Hash())), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadGraphFromFile(main)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	vet, ok := g.Node("go/vet")
+	if !ok || vet.Task.Run != "echo vet && go vet ./..." {
+		t.Fatalf("go/vet = %+v", vet)
+	}
+	edges := make(map[dag.Edge]bool)
+	for _, e := range g.Edges() {
+		edges[e] = true
+	}
+	if !edges[dag.Edge{From: "go/vet", To: "go/test"}] || !edges[dag.Edge{From: "go/test", To: "release"}] {
+		t.Fatalf("edges = %v", g.Edges())
+	}
+
+	// Any change to the library breaks the pin.
+	b, err := os.ReadFile(lib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lib, []byte(strings.Replace(string(b), "go vet", "go vet -all", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadGraphFromFile(main); err == nil || !strings.Contains(err.Error(), "has graph hash") {
+		t.Fatalf("err = %v, want hash mismatch", err)
+	}
+}
+
+func TestLoadGraphFromFile_IncludeRejectsInvalidIncludes(t *testing.T) {
+	cases := map[string]struct {
+		files map[string]string
+		want  string
+	}{
+		"empty hash": {
+			files: map[string]string{
+				"graph.json": `{"includes": [{"namespace": "a", "path": "a.json"}], "tasks": [{"name": "x", "run": "true"}]}`,
+			},
+			want: "empty hash",
+		},
+		"duplicate namespace": {
+			files: map[string]string{
+				"graph.json": `{"includes": [{"namespace": "a", "path": "a.json", "hash": "h"}, {"namespace": "a", "path": "b.json", "hash": "h"}]}`,
+			},
+			want: "duplicate include namespace",
+		},
+		"cycle": {
+			files: map[string]string{
+				"graph.json": `{"includes": [{"namespace": "a", "path": "a.json", "hash": "h"}]}`,
+				"a.json":     `{"includes": [{"namespace": "b", "path": "graph.json", "hash": "h"}], "tasks": [{"name": "x", "run": "true"}]}`,
+			},
+			want: "include cycle",
+		},
+		"hooks in included graph": {
+			files: map[string]string{
+				"graph.json": `{"includes": [{"namespace": "a", "path": "a.json", "hash": "h"}]}`,
+				"a.json":     `{"hooks": {"pre_run": ["true"]}, "tasks": [{"name": "x", "run": "true"}]}`,
+			},
+			want: "hooks must be declared by the including graph",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for f, content := range tc.files {
+				if err := os.WriteFile(filepath.Join(dir, f), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, err := LoadGraphFromFile(filepath.Join(dir, "graph.json"))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("err = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -5,8 +5,16 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net/url"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
+
+	"scriptweaver/internal/config"
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/recovery/state"
+	"scriptweaver/internal/remote"
 )
 
 const (
@@ -15,6 +23,7 @@ const (
 	ExitInvalidInvocation = 2
 	ExitConfigError       = 3
 	ExitInternalError     = 4
+	ExitTraceMismatch     = 5
 )
 
 type ExecutionMode string
@@ -48,6 +57,192 @@ type CLIInvocation struct {
 	OriginalCache  string
 	OriginalOutput string
 	OriginalTrace  string
+
+	// Params holds --param key=value pairs, resolved against the graph's
+	// declared params at load time.
+	Params map[string]string
+
+	// CacheCompression is the blob codec for new cache entries
+	// (core.CompressionNone or core.CompressionGzip).
+	CacheCompression string
+
+	// CacheNamespace partitions CacheDir (see core.NamespacedCacheDir):
+	// empty for none, core.CacheNamespaceGraph for one namespace per graph
+	// hash, or a fixed name.
+	CacheNamespace string
+
+	// CacheSigning signs new cache entries and rejects restored ones not
+	// signed by a trusted key.
+	CacheSigning CacheSigning
+
+	// NormalizeLogs scrubs timestamps, durations, PIDs and addresses (see
+	// core.DefaultNormalizer) plus each task's normalize rules from captured
+	// stdout/stderr before they are cached or reported.
+	NormalizeLogs bool
+
+	// MaxOutputBytes caps each of stdout and stderr for tasks without their
+	// own max_output_bytes; zero is unlimited.
+	MaxOutputBytes int64
+
+	// StrictOutputs fails tasks that write outside their declared outputs.
+	StrictOutputs bool
+
+	// Isolated runs each task in a scratch directory holding only its inputs.
+	Isolated bool
+
+	// ProtectInputs fails tasks that modify their declared inputs.
+	ProtectInputs bool
+
+	// AllowOutsideWorkDir lets input patterns resolve outside WorkDir.
+	// Declared outputs must stay under it regardless.
+	AllowOutsideWorkDir bool
+
+	// AbsoluteWorkDirHash hashes the absolute WorkDir and input paths
+	// instead of paths relative to WorkDir (see
+	// core.Runner.AbsoluteHashPaths).
+	AbsoluteWorkDirHash bool
+
+	// Concurrency is how many ready tasks run at once locally; zero means
+	// one. With remote workers, one task runs per worker instead.
+	Concurrency int
+
+	// NormalizeRules come from scriptweaver.toml and are prepended to every
+	// task's normalize rules at load time, so they are hashed per task.
+	NormalizeRules []core.NormalizeRule
+
+	// Notify are the scriptweaver.toml webhooks told about the run once
+	// its exit code is known (see notify.go).
+	Notify []config.Notifier
+
+	// Settings echoes every flag and param with its resolved value and
+	// source (flag, config or default) into the run record.
+	Settings []state.InvocationSetting
+
+	// ContainerEngine is the CLI used to run tasks that declare an Image.
+	ContainerEngine string
+
+	// RemoteWorkers are the URLs of workers that execute cache misses; empty
+	// executes locally. With several workers, ready tasks run concurrently,
+	// one per worker.
+	RemoteWorkers []string
+
+	// RemoteWorkerToken is sent to every remote worker (see
+	// `scriptweaver worker --token-file`); empty sends none.
+	RemoteWorkerToken string
+
+	// Overwrite selects how OutputDir is prepared; empty means OverwriteAlways.
+	Overwrite OverwritePolicy
+
+	// Labels tag the run record (state.Run.Labels); nil when none are given.
+	Labels map[string]string
+
+	// RunIDStrategy selects how the run's ID is formed; empty means
+	// state.RunIDRandom.
+	RunIDStrategy state.RunIDStrategy
+
+	// NoFingerprintCache re-reads every input file to hash it instead of
+	// trusting .scriptweaver/fingerprints.json for unchanged files.
+	NoFingerprintCache bool
+
+	// Provenance records the run's environment (tool versions, platform,
+	// graph and params) in its run directory; see state.Provenance.
+	Provenance bool
+
+	// Attest writes an in-toto SLSA provenance statement per task with
+	// outputs under OutputDir/attestations; see writeAttestations.
+	Attest bool
+
+	// Audit runs executed tasks under strace and writes a hermeticity report
+	// per task under OutputDir/audit; see writeAuditReports.
+	Audit bool
+
+	// Retention prunes old runs from the state store at run start.
+	Retention state.RetentionPolicy
+
+	// ResumeFrom names the task to resume the last failed run from instead
+	// of the automatically chosen resume point; see steerResumePlan.
+	ResumeFrom string
+
+	// ResumeAcrossEdits lets a run resume the latest failed run of an
+	// edited graph, reusing the checkpoints of the tasks the edit left
+	// unchanged; see editedGraphCheckpoints.
+	ResumeAcrossEdits bool
+
+	// MemoizeGraph records fully successful runs as the graph's memo and
+	// replays the memo when a run matches it; see replayGraphExecutor.
+	MemoizeGraph bool
+
+	// Invalidate lists task names and globs whose tasks, and everything
+	// downstream of them, execute regardless of cached results; see
+	// matchInvalidated.
+	Invalidate []string
+
+	// ChangedFiles, when set, lists changed paths one per line; the run is
+	// narrowed to the tasks they affect and their upstream tasks. See
+	// CLIInvocation.loadGraphAndHash.
+	ChangedFiles string
+
+	// VerifyTrace is the path of an expected trace; the run exits with
+	// ExitTraceMismatch if its trace differs. Empty disables verification.
+	VerifyTrace string
+
+	// ProfileDir, when set, receives pprof CPU (cpu.pprof) and heap
+	// (heap.pprof) profiles of the run; see startProfiling.
+	ProfileDir string
+
+	// OTelEndpoint, when set, is the OTLP/HTTP traces URL the run is
+	// exported to as OpenTelemetry spans; see exportSpans.
+	OTelEndpoint string
+
+	// StatusAddr, when set, is the TCP address the live status API is
+	// served on while the run executes; see statusServer.
+	StatusAddr string
+
+	// TUI renders the run interactively on stderr (see tui); the run's
+	// artifacts are the same as without it.
+	TUI bool
+
+	// LogLevel and LogFormat select the diagnostics logged on stderr; see
+	// newLogger.
+	LogLevel  LogLevel
+	LogFormat LogFormat
+
+	// ErrorsJSON reports a failed run as an ErrorReport JSON object on
+	// stderr instead of plain text; see DescribeError.
+	ErrorsJSON bool
+
+	// CIOutput renders task output and failure annotations for a CI system
+	// after the run; see writeCIOutput.
+	CIOutput CIOutput
+
+	// ReportJUnit, when set, receives a JUnit XML report after every run
+	// that executes the graph; see writeJUnitReport.
+	ReportJUnit string
+
+	// ReportBEP, when set, receives the run as a build event stream in the
+	// JSON form of Bazel's build event protocol; see writeBuildEvents.
+	ReportBEP string
+
+	// FailureBundle, when set, is replaced with a failure bundle after a run
+	// whose tasks failed; see writeFailureBundle.
+	FailureBundle string
+
+	// DryRun reports each task's hash and whether it would execute, restore
+	// from cache or be skipped, without side effects.
+	DryRun bool
+
+	// VerifyCache selects cache verification instead of graph execution;
+	// only WorkDir and CacheDir are required. PruneCorrupt removes corrupt
+	// entries found during verification.
+	VerifyCache  bool
+	PruneCorrupt bool
+
+	// warm, set only by the daemon, supplies graphs, fingerprints and cache
+	// entries kept from earlier invocations; see warmState.
+	warm *warmState
+
+	// stderr receives diagnostics and the TUI; nil means os.Stderr.
+	stderr io.Writer
 }
 
 type InvocationError struct {
@@ -62,6 +257,66 @@ func (e *InvocationError) Error() string {
 	return e.Message
 }
 
+// paramFlags collects repeated --param key=value flags.
+type paramFlags map[string]string
+
+func (p paramFlags) String() string { return "" }
+
+func (p paramFlags) Set(v string) error {
+	key, value, ok := strings.Cut(v, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", v)
+	}
+	if _, dup := p[key]; dup {
+		return fmt.Errorf("duplicate param %q", key)
+	}
+	p[key] = value
+	return nil
+}
+
+// labelFlags collects repeated --label key=value flags. Keys start with a
+// letter or digit and may contain letters, digits and "_.-/".
+type labelFlags map[string]string
+
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_./-]*$`)
+
+// String lists the labels as sorted key=value pairs, so the echoed setting
+// is stable.
+func (l labelFlags) String() string {
+	pairs := make([]string, 0, len(l))
+	for _, k := range sortedKeys(l) {
+		pairs = append(pairs, k+"="+l[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (l labelFlags) Set(v string) error {
+	key, value, ok := strings.Cut(v, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", v)
+	}
+	if !labelKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid label key %q", key)
+	}
+	if _, dup := l[key]; dup {
+		return fmt.Errorf("duplicate label %q", key)
+	}
+	l[key] = value
+	return nil
+}
+
+// stringListFlag collects a repeated string flag in order.
+type stringListFlag []string
+
+func (s *stringListFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *stringListFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
 func invalidInvocationf(format string, args ...any) error {
 	return &InvocationError{ExitCode: ExitInvalidInvocation, Message: fmt.Sprintf(format, args...)}
 }
@@ -72,6 +327,10 @@ func invalidInvocationf(format string, args ...any) error {
 //   - Does not read env vars.
 //   - Does not read/assume the process CWD.
 //   - Requires WorkDir to be explicit and absolute.
+//
+// Defaults may come from WorkDir/scriptweaver.toml, with the precedence
+// flags > config > built-in defaults (see applyConfig); the file is found
+// through WorkDir, never the process CWD.
 func ParseInvocation(args []string) (CLIInvocation, error) {
 	fs := flag.NewFlagSet("scriptweaver", flag.ContinueOnError)
 	fs.SetOutput(io.Discard) // parsing errors are returned, not printed
@@ -81,19 +340,109 @@ func ParseInvocation(args []string) (CLIInvocation, error) {
 	var cacheDir string
 	var outputDir string
 	var tracePath string
+	var verifyTracePath string
 	var mode string
+	params := paramFlags{}
+	labels := labelFlags{}
+	var dryRun bool
+	var verifyCache bool
+	var pruneCorrupt bool
+	var cacheCompression string
+	var cacheNamespace string
+	var strict bool
+	var normalizeLogs bool
+	var maxOutputBytes int64
+	var isolate bool
+	var protectInputs bool
+	var allowOutside bool
+	var absoluteHash bool
+	var concurrency int
+	var containerEngine string
+	var runIDStrategy string
+	var remoteWorkers stringListFlag
+	var remoteTokenFile string
+	var cacheSigningKey string
+	var cacheTrustedKeys stringListFlag
+	var overwrite string
+	var noFingerprints bool
+	var provenance bool
+	var attestFlag bool
+	var audit bool
+	var keepRuns int
+	var maxRunAge time.Duration
+	var profileDir string
+	var otelEndpoint string
+	var statusAddr string
+	var tuiMode bool
+	var logLevel string
+	var logFormat string
+	var errorsJSON bool
+	var failureBundle string
+	var reportJUnit string
+	var reportBEP string
+	var changedFiles string
+	var ciOutput string
+	var resumeFromTask string
+	var resumeAcrossEdits bool
+	var memoizeGraph bool
+	var invalidate stringListFlag
 
 	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
 	fs.StringVar(&graphPath, "graph", "", "Graph source path. Required.")
 	fs.StringVar(&cacheDir, "cache-dir", "", "Cache directory. Required.")
 	fs.StringVar(&outputDir, "output-dir", "", "Output directory. Required.")
 	fs.StringVar(&tracePath, "trace", "", "Trace output path (optional).")
+	fs.StringVar(&resumeFromTask, "resume-from", "", "Resume the last failed run from this task: restore everything upstream from checkpoints and execute it and its dependents fresh.")
+	fs.BoolVar(&resumeAcrossEdits, "resume-across-edits", false, "When the graph changed since the last failed run, resume it anyway, reusing the checkpoints of tasks whose definition and upstream closure are unchanged.")
+	fs.BoolVar(&memoizeGraph, "memoize-graph", false, "Memoize fully successful runs; a run whose graph, params and task hashes match the last one restores every task and replays its trace without scheduling.")
+	fs.Var(&invalidate, "invalidate", "Comma-separated task names or globs (repeatable) to execute again with their dependents, ignoring cached results.")
+	fs.StringVar(&verifyTracePath, "verify-trace", "", "Expected trace path; exit 5 and list the diverging events if this run's trace differs.")
 	fs.StringVar(&mode, "mode", string(ExecutionModeIncremental), "Execution mode: clean|incremental|resume-only")
+	fs.Var(params, "param", "Graph parameter key=value (repeatable).")
+	fs.Var(labels, "label", "Tag the run record with key=value, e.g. branch=main (repeatable); filter runs by label with runs list.")
+	fs.StringVar(&cacheCompression, "cache-compression", "none", "Cache blob compression: none|gzip")
+	fs.StringVar(&cacheNamespace, "cache-namespace", "", "Keep entries in their own namespace of --cache-dir: \"graph\" (one per graph hash) or a fixed name. Default: none.")
+	fs.StringVar(&cacheSigningKey, "cache-signing-key", "", "PEM ed25519 private key; sign new cache entries and trust entries it signed.")
+	fs.Var(&cacheTrustedKeys, "cache-trusted-key", "PEM ed25519 public key whose cache entries are trusted (repeatable); entries not signed by a trusted key are rejected.")
+	fs.BoolVar(&normalizeLogs, "normalize-logs", false, "Normalize task stdout/stderr (timestamps, durations, PIDs, CRLF and task normalize rules) before caching and reporting.")
+	fs.Int64Var(&maxOutputBytes, "max-output-bytes", 0, "Keep at most this many bytes of each task's stdout and stderr, marking the rest as truncated (0 = unlimited; a task's max_output_bytes wins).")
+	fs.BoolVar(&strict, "strict", false, "Fail tasks that write files outside their declared outputs.")
+	fs.BoolVar(&isolate, "isolate", false, "Run each task in a scratch directory containing only its declared inputs.")
+	fs.BoolVar(&protectInputs, "protect-inputs", false, "Make each task's input files read-only while it runs and fail tasks that modify them.")
+	fs.BoolVar(&allowOutside, "allow-outside-workdir", false, "Allow input patterns that resolve outside --workdir (declared outputs must stay under it).")
+	fs.BoolVar(&absoluteHash, "absolute-workdir-hash", false, "Hash the absolute working directory and input paths instead of relative ones, so caches are not shared across checkout paths.")
+	fs.IntVar(&concurrency, "concurrency", 1, "Run up to this many ready tasks at once (with --remote-worker, one per worker instead).")
+	fs.StringVar(&containerEngine, "container-engine", "docker", "Container engine for tasks that declare an image: docker|podman (or a path to a compatible CLI).")
+	fs.Var(&remoteWorkers, "remote-worker", "Execute cache misses on the worker at this http(s) URL (repeatable; see `scriptweaver worker`).")
+	fs.StringVar(&remoteTokenFile, "remote-worker-token-file", "", "File holding the token sent to --remote-worker workers.")
+	fs.StringVar(&runIDStrategy, "run-id-strategy", string(state.RunIDRandom), "How run IDs are formed: random | graph (graph hash plus a per-graph sequence number, reproducible across identical reruns)")
+	fs.StringVar(&overwrite, "overwrite", string(OverwriteAlways), "Output dir policy: always (empty it first) | on-conflict (remove only stale files) | never")
+	fs.BoolVar(&noFingerprints, "no-fingerprint-cache", false, "Re-read every input file to hash it instead of trusting recorded size/mtime fingerprints.")
+	fs.BoolVar(&attestFlag, "attest", false, "Write an in-toto SLSA provenance statement per task with outputs to <output>/attestations.")
+	fs.BoolVar(&audit, "audit", false, "Trace executed tasks with strace (Linux) and report undeclared file reads and writes and network use per task to <output>/audit.")
+	fs.BoolVar(&provenance, "provenance", false, "Record tool versions, platform, graph and params in .scriptweaver/runs/<run>/provenance.json.")
+	fs.IntVar(&keepRuns, "keep-runs", state.DefaultKeepRuns, "Runs kept per graph in .scriptweaver/runs; older ones are pruned at run start (0 = unlimited).")
+	fs.DurationVar(&maxRunAge, "max-run-age", 0, "Prune recorded runs older than this at run start, e.g. 720h (0 = unlimited).")
+	fs.StringVar(&profileDir, "profile-dir", "", "Write pprof CPU and heap profiles of the run (cpu.pprof, heap.pprof) to this directory.")
+	fs.StringVar(&otelEndpoint, "otel-endpoint", "", "Export the run as OpenTelemetry spans with wall-clock timings to this OTLP/HTTP traces URL, e.g. http://localhost:4318/v1/traces. Never affects the trace or the outcome.")
+	fs.StringVar(&statusAddr, "status-addr", "", "Serve the run's live task states and events as JSON on this address while it executes, e.g. :8731 (GET /status, /events, /tasks/<name>).")
+	fs.BoolVar(&tuiMode, "tui", false, "Show task counts, the ready frontier and the latest output of running tasks on stderr while the run executes.")
+	fs.StringVar(&ciOutput, "ci-output", "", "Print task output in collapsible groups with failure annotations for a CI system: github|gitlab.")
+	fs.StringVar(&changedFiles, "changed-files", "", "Run only the tasks whose inputs match a path listed in this file (one per line), their dependents and the tasks they depend on.")
+	fs.StringVar(&reportJUnit, "report-junit", "", "Write a JUnit XML report with one testcase per task to this path after the run.")
+	fs.StringVar(&reportBEP, "report-bep", "", "Write the run as newline-delimited JSON build events, in the form of Bazel's build event protocol, to this path.")
+	fs.StringVar(&failureBundle, "failure-bundle", "", "After a run with failed tasks, replace this directory with a bug-report bundle: task definitions, normalized output, input digests, trace slices and checkpoints.")
+	fs.StringVar(&logLevel, "log-level", string(LogInfo), "Diagnostics logged on stderr: quiet (warnings only), info or debug (also orchestration, resume planning decisions and cache operations).")
+	fs.StringVar(&logFormat, "log-format", string(LogFormatText), "Format of logged diagnostics: text|json.")
+	fs.BoolVar(&errorsJSON, "errors-json", false, "Report failures on stderr as a JSON object with a stable class, code and exit code.")
+	fs.BoolVar(&dryRun, "dry-run", false, "Report task hashes and which tasks would execute, restore or be skipped, without running anything.")
+	fs.BoolVar(&verifyCache, "verify-cache", false, "Verify cache entries referenced by checkpoints instead of running the graph.")
+	fs.BoolVar(&pruneCorrupt, "prune-corrupt", false, "With --verify-cache, remove corrupt and untrusted entries.")
 
 	// We intentionally do not accept environment-derived defaults.
 	if err := fs.Parse(args); err != nil {
 		// flag package returns errors like: "flag provided but not defined: -x"
-		return CLIInvocation{}, invalidInvocationf("%v", err)
+		return CLIInvocation{}, flagParseError(fs, err)
 	}
 	if fs.NArg() != 0 {
 		return CLIInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
@@ -106,6 +455,53 @@ func ParseInvocation(args []string) (CLIInvocation, error) {
 	if !filepath.IsAbs(workDir) {
 		return CLIInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
 	}
+	cfg, err := applyConfig(fs, workDir, params)
+	if err != nil {
+		return CLIInvocation{}, err
+	}
+
+	if pruneCorrupt && !verifyCache {
+		return CLIInvocation{}, invalidInvocationf("--prune-corrupt requires --verify-cache")
+	}
+	if dryRun && verifyCache {
+		return CLIInvocation{}, invalidInvocationf("--dry-run and --verify-cache are mutually exclusive")
+	}
+	resumeFromTask = strings.TrimSpace(resumeFromTask)
+	if resumeFromTask != "" && (dryRun || verifyCache) {
+		return CLIInvocation{}, invalidInvocationf("--resume-from requires a graph run; it cannot be combined with --dry-run or --verify-cache")
+	}
+	var invalidatePatterns []string
+	for _, list := range invalidate {
+		for _, p := range strings.Split(list, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				invalidatePatterns = append(invalidatePatterns, p)
+			}
+		}
+	}
+	if changedFiles != "" && verifyCache {
+		return CLIInvocation{}, invalidInvocationf("--changed-files cannot be combined with --verify-cache")
+	}
+	if len(invalidatePatterns) > 0 && verifyCache {
+		return CLIInvocation{}, invalidInvocationf("--invalidate cannot be combined with --verify-cache")
+	}
+	if verifyTracePath != "" && (dryRun || verifyCache) {
+		return CLIInvocation{}, invalidInvocationf("--verify-trace requires a graph run; it cannot be combined with --dry-run or --verify-cache")
+	}
+	signing, err := parseCacheSigning(workDir, cacheSigningKey, cacheTrustedKeys)
+	if err != nil {
+		return CLIInvocation{}, err
+	}
+	namespace, err := core.ParseCacheNamespace(cacheNamespace)
+	if err != nil {
+		return CLIInvocation{}, invalidInvocationf("--cache-namespace: %v", err)
+	}
+	if verifyCache {
+		inv, err := parseVerifyCacheInvocation(workDir, cacheDir, pruneCorrupt)
+		inv.CacheSigning = signing
+		inv.CacheNamespace = namespace
+		inv.ErrorsJSON = errorsJSON
+		return inv, err
+	}
 
 	if graphPath == "" {
 		return CLIInvocation{}, invalidInvocationf("--graph is required")
@@ -121,6 +517,27 @@ func ParseInvocation(args []string) (CLIInvocation, error) {
 	if err != nil {
 		return CLIInvocation{}, err
 	}
+	if resumeFromTask != "" && parsedMode == ExecutionModeClean {
+		return CLIInvocation{}, invalidInvocationf("--resume-from cannot be used with --mode clean")
+	}
+	if memoizeGraph && parsedMode != ExecutionModeIncremental {
+		return CLIInvocation{}, invalidInvocationf("--memoize-graph requires --mode incremental")
+	}
+	if resumeAcrossEdits && (parsedMode == ExecutionModeClean || dryRun || verifyCache) {
+		return CLIInvocation{}, invalidInvocationf("--resume-across-edits requires a graph run in incremental or resume-only mode")
+	}
+	overwritePolicy, err := parseOverwritePolicy(overwrite)
+	if err != nil {
+		return CLIInvocation{}, err
+	}
+	idStrategy, err := state.ParseRunIDStrategy(runIDStrategy)
+	if err != nil {
+		return CLIInvocation{}, invalidInvocationf("--run-id-strategy: %v", err)
+	}
+	compression, err := core.ParseCacheCompression(cacheCompression)
+	if err != nil {
+		return CLIInvocation{}, invalidInvocationf("--cache-compression: %v", err)
+	}
 
 	resolvedGraph, err := resolveUnderWorkDir(workDir, graphPath)
 	if err != nil {
@@ -146,6 +563,88 @@ func ParseInvocation(args []string) (CLIInvocation, error) {
 		OriginalOutput: outputDir,
 		OriginalTrace:  tracePath,
 	}
+	if len(params) > 0 {
+		inv.Params = params
+	}
+	inv.CacheCompression = compression
+	inv.CacheNamespace = namespace
+	inv.CacheSigning = signing
+	inv.StrictOutputs = strict
+	inv.NormalizeLogs = normalizeLogs
+	if maxOutputBytes < 0 {
+		return CLIInvocation{}, invalidInvocationf("--max-output-bytes must not be negative")
+	}
+	inv.MaxOutputBytes = maxOutputBytes
+	inv.Isolated = isolate
+	inv.ProtectInputs = protectInputs
+	inv.AllowOutsideWorkDir = allowOutside
+	inv.AbsoluteWorkDirHash = absoluteHash
+	if concurrency < 1 {
+		return CLIInvocation{}, invalidInvocationf("--concurrency must be at least 1 (got %d)", concurrency)
+	}
+	if concurrency > 1 && strict {
+		// Strict mode attributes workdir changes to the running task.
+		return CLIInvocation{}, invalidInvocationf("--strict cannot be combined with --concurrency above 1")
+	}
+	inv.Concurrency = concurrency
+	inv.NormalizeRules = cfg.Normalize
+	inv.Notify = cfg.Notify
+	inv.Settings = cfg.Settings
+	if strings.TrimSpace(containerEngine) == "" {
+		return CLIInvocation{}, invalidInvocationf("--container-engine must not be empty")
+	}
+	inv.ContainerEngine = containerEngine
+	for _, w := range remoteWorkers {
+		if _, err := remote.NewClient(w); err != nil {
+			return CLIInvocation{}, invalidInvocationf("--remote-worker: %v", err)
+		}
+	}
+	if len(remoteWorkers) > 0 {
+		// Remote misses bypass the local executor, so its write checks and
+		// scratch directories would silently not apply.
+		for name, set := range map[string]bool{"--strict": strict, "--isolate": isolate, "--protect-inputs": protectInputs} {
+			if set {
+				return CLIInvocation{}, invalidInvocationf("%s cannot be combined with --remote-worker", name)
+			}
+		}
+		inv.RemoteWorkers = remoteWorkers
+	}
+	if remoteTokenFile != "" {
+		if len(remoteWorkers) == 0 {
+			return CLIInvocation{}, invalidInvocationf("--remote-worker-token-file requires --remote-worker")
+		}
+		token, err := readTokenFile("--remote-worker-token-file", remoteTokenFile)
+		if err != nil {
+			return CLIInvocation{}, err
+		}
+		inv.RemoteWorkerToken = token
+	}
+	if otelEndpoint != "" {
+		u, err := url.Parse(otelEndpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return CLIInvocation{}, invalidInvocationf("--otel-endpoint must be an http(s) URL (got %q)", otelEndpoint)
+		}
+		inv.OTelEndpoint = otelEndpoint
+	}
+	inv.StatusAddr = statusAddr
+	inv.TUI = tuiMode
+	inv.Overwrite = overwritePolicy
+	inv.RunIDStrategy = idStrategy
+	if len(labels) > 0 {
+		inv.Labels = labels
+	}
+	inv.NoFingerprintCache = noFingerprints
+	inv.Provenance = provenance
+	inv.Attest = attestFlag
+	if audit && len(remoteWorkers) > 0 {
+		return CLIInvocation{}, invalidInvocationf("--audit cannot be combined with --remote-worker")
+	}
+	inv.Audit = audit
+	inv.DryRun = dryRun
+	inv.Retention = state.RetentionPolicy{KeepLast: keepRuns, MaxAge: maxRunAge}
+	if err := inv.Retention.Validate(); err != nil {
+		return CLIInvocation{}, invalidInvocationf("retention: %v", err)
+	}
 
 	if strings.TrimSpace(tracePath) != "" {
 		resolvedTrace, err := resolveUnderWorkDir(workDir, tracePath)
@@ -154,10 +653,109 @@ func ParseInvocation(args []string) (CLIInvocation, error) {
 		}
 		inv.Trace = TraceConfig{Enabled: true, Path: resolvedTrace}
 	}
+	if strings.TrimSpace(verifyTracePath) != "" {
+		resolved, err := resolveUnderWorkDir(workDir, verifyTracePath)
+		if err != nil {
+			return CLIInvocation{}, err
+		}
+		if inv.Trace.Enabled && resolved == inv.Trace.Path {
+			return CLIInvocation{}, invalidInvocationf("--verify-trace must not name the --trace output")
+		}
+		inv.VerifyTrace = resolved
+	}
+	if strings.TrimSpace(profileDir) != "" {
+		resolved, err := resolveUnderWorkDir(workDir, profileDir)
+		if err != nil {
+			return CLIInvocation{}, err
+		}
+		inv.ProfileDir = resolved
+	}
+	if strings.TrimSpace(failureBundle) != "" {
+		resolved, err := resolveUnderWorkDir(workDir, failureBundle)
+		if err != nil {
+			return CLIInvocation{}, err
+		}
+		// The bundle directory is replaced wholesale.
+		if isWithin(resolved, workDir) || isWithin(resolved, inv.GraphPath) ||
+			isWithin(resolved, inv.CacheDir) || isWithin(inv.CacheDir, resolved) ||
+			isWithin(resolved, inv.OutputDir) || isWithin(inv.OutputDir, resolved) {
+			return CLIInvocation{}, invalidInvocationf("--failure-bundle must not contain the working directory or graph, nor overlap the cache or output dir (got %q)", failureBundle)
+		}
+		inv.FailureBundle = resolved
+	}
+	switch CIOutput(ciOutput) {
+	case CIOutputNone, CIOutputGitHub, CIOutputGitLab:
+		inv.CIOutput = CIOutput(ciOutput)
+	default:
+		return CLIInvocation{}, invalidInvocationf("--ci-output must be github or gitlab (got %q)", ciOutput)
+	}
+	if strings.TrimSpace(reportJUnit) != "" {
+		resolved, err := resolveUnderWorkDir(workDir, reportJUnit)
+		if err != nil {
+			return CLIInvocation{}, err
+		}
+		if resolved == inv.GraphPath || isWithin(inv.CacheDir, resolved) || isWithin(inv.OutputDir, resolved) {
+			return CLIInvocation{}, invalidInvocationf("--report-junit must not be the graph or lie in the cache or output dir (got %q)", reportJUnit)
+		}
+		inv.ReportJUnit = resolved
+	}
+	if strings.TrimSpace(reportBEP) != "" {
+		resolved, err := resolveUnderWorkDir(workDir, reportBEP)
+		if err != nil {
+			return CLIInvocation{}, err
+		}
+		if resolved == inv.GraphPath || isWithin(inv.CacheDir, resolved) || isWithin(inv.OutputDir, resolved) {
+			return CLIInvocation{}, invalidInvocationf("--report-bep must not be the graph or lie in the cache or output dir (got %q)", reportBEP)
+		}
+		inv.ReportBEP = resolved
+	}
+	if strings.TrimSpace(changedFiles) != "" {
+		resolved, err := resolveUnderWorkDir(workDir, changedFiles)
+		if err != nil {
+			return CLIInvocation{}, err
+		}
+		inv.ChangedFiles = resolved
+	}
+	switch LogLevel(logLevel) {
+	case LogQuiet, LogInfo, LogDebug:
+		inv.LogLevel = LogLevel(logLevel)
+	default:
+		return CLIInvocation{}, invalidInvocationf("--log-level must be quiet, info or debug (got %q)", logLevel)
+	}
+	switch LogFormat(logFormat) {
+	case LogFormatText, LogFormatJSON:
+		inv.LogFormat = LogFormat(logFormat)
+	default:
+		return CLIInvocation{}, invalidInvocationf("--log-format must be text or json (got %q)", logFormat)
+	}
+	inv.ResumeFrom = resumeFromTask
+	inv.ResumeAcrossEdits = resumeAcrossEdits
+	inv.MemoizeGraph = memoizeGraph
+	inv.Invalidate = invalidatePatterns
+	inv.ErrorsJSON = errorsJSON
 
 	return inv, nil
 }
 
+// parseVerifyCacheInvocation builds the invocation for --verify-cache, which
+// only needs the workspace (for checkpoints) and the cache directory.
+func parseVerifyCacheInvocation(workDir, cacheDir string, prune bool) (CLIInvocation, error) {
+	if cacheDir == "" {
+		return CLIInvocation{}, invalidInvocationf("--cache-dir is required")
+	}
+	resolvedCache, err := resolveUnderWorkDir(workDir, cacheDir)
+	if err != nil {
+		return CLIInvocation{}, err
+	}
+	return CLIInvocation{
+		WorkDir:       workDir,
+		CacheDir:      resolvedCache,
+		OriginalCache: cacheDir,
+		VerifyCache:   true,
+		PruneCorrupt:  prune,
+	}, nil
+}
+
 func parseExecutionMode(raw string) (ExecutionMode, error) {
 	n := strings.ToLower(strings.TrimSpace(raw))
 	switch ExecutionMode(n) {
@@ -191,7 +789,12 @@ func resolveUnderWorkDir(workDir, p string) (string, error) {
 
 // ExitCode extracts a semantic exit code from a ParseInvocation error.
 // If the error is not a known invocation error, it returns ExitInternalError.
+// A HelpRequest is not a failure and maps to ExitSuccess.
 func ExitCode(err error) int {
+	var help *HelpRequest
+	if errors.As(err, &help) {
+		return ExitSuccess
+	}
 	var invErr *InvocationError
 	if errors.As(err, &invErr) && invErr != nil {
 		if invErr.ExitCode != 0 {
@@ -148,3 +148,72 @@ func TestParseInvocation_WorkDirIsMandatoryAndAbsolute(t *testing.T) {
 		t.Fatalf("expected exit code %d, got %d", ExitInvalidInvocation, ExitCode(err))
 	}
 }
+
+func TestParseInvocation_CacheCompression(t *testing.T) {
+	workDir := t.TempDir()
+	base := []string{"--workdir", workDir, "--graph", "g.json", "--cache-dir", "cache", "--output-dir", "out"}
+
+	inv, err := ParseInvocation(append(base, "--cache-compression", "gzip"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inv.CacheCompression != "gzip" {
+		t.Fatalf("expected gzip, got %q", inv.CacheCompression)
+	}
+
+	inv, err = ParseInvocation(base)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inv.CacheCompression != "" {
+		t.Fatalf("expected no compression by default, got %q", inv.CacheCompression)
+	}
+
+	if _, err := ParseInvocation(append(append([]string(nil), base...), "--cache-compression", "zstd")); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected invalid invocation, got %v", err)
+	}
+}
+
+func TestParseInvocation_Isolate(t *testing.T) {
+	workDir := t.TempDir()
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "g.json", "--cache-dir", "cache", "--output-dir", "out", "--isolate"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !inv.Isolated {
+		t.Fatalf("expected --isolate to set Isolated")
+	}
+}
+
+func TestParseInvocation_NormalizeLogs(t *testing.T) {
+	workDir := t.TempDir()
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "g.json", "--cache-dir", "cache", "--output-dir", "out", "--normalize-logs"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !inv.NormalizeLogs {
+		t.Fatalf("expected --normalize-logs to set NormalizeLogs")
+	}
+}
+
+func TestParseInvocation_ContainerEngine(t *testing.T) {
+	workDir := t.TempDir()
+	base := []string{"--workdir", workDir, "--graph", "g.json", "--cache-dir", "cache", "--output-dir", "out"}
+	inv, err := ParseInvocation(base)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inv.ContainerEngine != "docker" {
+		t.Fatalf("expected default engine docker, got %q", inv.ContainerEngine)
+	}
+	inv, err = ParseInvocation(append(base, "--container-engine", "podman"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inv.ContainerEngine != "podman" {
+		t.Fatalf("expected podman, got %q", inv.ContainerEngine)
+	}
+	if _, err := ParseInvocation(append(base, "--container-engine", "")); err == nil {
+		t.Fatalf("expected error for empty engine")
+	}
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"path"
+
+	"scriptweaver/internal/dag"
+)
+
+// matchInvalidated resolves --invalidate patterns (task names or path.Match
+// globs) against g and returns the matching tasks with everything downstream
+// of them. A malformed pattern, or one that matches no task, is an invocation
+// error so that a typo never silently invalidates nothing.
+func matchInvalidated(g *dag.TaskGraph, patterns []string) (map[string]bool, error) {
+	var roots []string
+	for _, p := range patterns {
+		matched := false
+		for _, name := range g.TopologicalOrder() {
+			ok, err := path.Match(p, name)
+			if err != nil {
+				return nil, invalidInvocationf("--invalidate %q: %v", p, err)
+			}
+			if ok {
+				roots = append(roots, name)
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, invalidInvocationf("--invalidate %q matches no task", p)
+		}
+	}
+	return downstreamClosure(g, roots...), nil
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/trace"
+)
+
+func TestExecute_InvalidateRerunsMatchesAndDependents(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "gen-a", Run: "echo a >> a.log && echo a > a.out", Outputs: []string{"a.out"}},
+		{Name: "use-a", Inputs: []string{"a.out"}, Run: "echo b >> b.log"},
+		{Name: "other", Run: "echo c >> c.log"},
+	}, []dag.Edge{{From: "gen-a", To: "use-a"}})
+	run := func(extra ...string) (CLIResult, error) {
+		t.Helper()
+		args := append([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--trace", "trace.json"}, extra...)
+		inv, err := ParseInvocation(args)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		return Execute(context.Background(), inv)
+	}
+
+	for _, extra := range [][]string{nil, {"--invalidate", "gen-*"}} {
+		if res, err := run(extra...); err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run %v: exit %d err %v", extra, res.ExitCode, err)
+		}
+	}
+	for file, want := range map[string]int{"a.log": 2, "b.log": 2, "c.log": 1} {
+		b, _ := os.ReadFile(filepath.Join(workDir, file))
+		if n := strings.Count(string(b), "\n"); n != want {
+			t.Fatalf("%s has %d lines, want %d", file, n, want)
+		}
+	}
+	tr, err := os.ReadFile(filepath.Join(workDir, "trace.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := trace.ParseExecutionTrace(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalidated := map[string]bool{}
+	for _, ev := range parsed.Events {
+		if ev.Kind == trace.EventTaskInvalidated && ev.Reason == trace.ReasonUserInvalidated {
+			invalidated[ev.TaskID] = true
+		}
+	}
+	if len(invalidated) != 2 || !invalidated["gen-a"] || !invalidated["use-a"] {
+		t.Fatalf("UserInvalidated events for %v: %s", invalidated, tr)
+	}
+
+	// Later runs use the rewritten entries again.
+	if res, err := run(); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("rerun: exit %d err %v", res.ExitCode, err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(workDir, "a.log")); strings.Count(string(b), "\n") != 2 {
+		t.Fatalf("gen-a executed again without --invalidate")
+	}
+
+	res, err := run("--invalidate", "other,nope*")
+	if err == nil || res.ExitCode != ExitInvalidInvocation {
+		t.Fatalf("expected a pattern matching nothing to be rejected, got exit %d err %v", res.ExitCode, err)
+	}
+}
+
+func TestDryRun_Invalidate(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "echo a > a.out", Outputs: []string{"a.out"}},
+		{Name: "b", Inputs: []string{"a.out"}, Run: "true"},
+		{Name: "c", Run: "true"},
+	}, []dag.Edge{{From: "a", To: "b"}})
+	args := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"}
+	inv, err := ParseInvocation(args)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+	}
+
+	inv, err = ParseInvocation(append(args, "--dry-run", "--invalidate", "a"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	res, err := Execute(context.Background(), inv)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	got := map[string]string{}
+	for _, task := range res.DryRun.Tasks {
+		got[task.Name] = task.Action + "/" + task.Reason
+	}
+	want := map[string]string{"a": "execute/user_invalidated", "b": "execute/upstream_executes", "c": "restore/cache_hit"}
+	for name, w := range want {
+		if got[name] != w {
+			t.Fatalf("%s: %s, want %s (all: %v)", name, got[name], w, got)
+		}
+	}
+}
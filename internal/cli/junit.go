@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// junitTestSuites is the root of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnitReport writes gr to path as a JUnit XML report: one testsuite
+// named after the graph file, with one testcase per task in topological
+// order, classed by the task's group. A failed task's failure carries its
+// exit code and its stderr, normalized as with --normalize-logs; a task
+// that failed but allows failure passes with its stderr as system-err.
+// Tasks skipped after an upstream failure or whose condition was false are
+// skipped.
+//
+// Like failure bundles, reports record no timings, so identical runs yield
+// identical bytes.
+func writeJUnitReport(path string, inv CLIInvocation, g *dag.TaskGraph, gr *dag.GraphResult) error {
+	suite := junitTestSuite{
+		Name:       filepath.Base(inv.GraphPath),
+		Properties: []junitProperty{{Name: "graph_hash", Value: string(gr.GraphHash)}},
+	}
+	for _, name := range g.TopologicalOrder() {
+		n, _ := g.Node(name)
+		tc := junitTestCase{Name: name, ClassName: "scriptweaver"}
+		if n.Task.Group != "" {
+			tc.ClassName += "." + n.Task.Group
+		}
+		switch gr.FinalState[name] {
+		case dag.TaskFailed:
+			stderr, err := normalizedStderr(n.Task, gr.Stderr[name])
+			if err != nil {
+				return err
+			}
+			tc.Failure = &junitFailure{Message: fmt.Sprintf("exit code %d", gr.ExitCode[name]), Type: "TaskFailed", Body: stderr}
+			suite.Failures++
+		case dag.TaskFailedAllowed:
+			stderr, err := normalizedStderr(n.Task, gr.Stderr[name])
+			if err != nil {
+				return err
+			}
+			tc.SystemErr = stderr
+		case dag.TaskSkipped:
+			tc.Skipped = &junitSkipped{Message: "an upstream task failed"}
+			suite.Skipped++
+		case dag.TaskConditionFalse:
+			tc.Skipped = &junitSkipped{Message: "condition is false"}
+			suite.Skipped++
+		case dag.TaskCompleted, dag.TaskCached:
+		default:
+			tc.Skipped = &junitSkipped{Message: "not run"}
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Tests = len(suite.Cases)
+	report := junitTestSuites{Name: "scriptweaver", Tests: suite.Tests, Failures: suite.Failures, Skipped: suite.Skipped, Suites: []junitTestSuite{suite}}
+
+	b, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(b, '\n')...), 0o644)
+}
+
+// normalizedStderr normalizes a task's stderr as --normalize-logs does.
+func normalizedStderr(task core.Task, stderr []byte) (string, error) {
+	normalizer, err := core.NewRuleNormalizer(task.Normalize, core.NewStreamNormalizer(core.NewDefaultNormalizer()))
+	if err != nil {
+		return "", err
+	}
+	return string(normalizer.Normalize(stderr)), nil
+}
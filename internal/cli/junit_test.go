@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExecute_ReportJUnit(t *testing.T) {
+	workDir := t.TempDir()
+	graph := `{
+		"groups": [{"name": "ci"}],
+		"tasks": [
+			{"name": "build", "run": "echo ok > build.out", "outputs": ["build.out"], "group": "ci"},
+			{"name": "lint", "run": "echo lint failed at $(date -u +%Y-%m-%dT%H:%M:%SZ) >&2; exit 1", "allow_failure": true},
+			{"name": "test", "run": "echo 'FAIL: TestX <bad>' >&2; exit 2"},
+			{"name": "deploy", "run": "true"}
+		],
+		"edges": [{"from": "build", "to": "test"}, {"from": "test", "to": "deploy"}]
+	}`
+	if err := os.WriteFile(filepath.Join(workDir, "graph.json"), []byte(graph), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report := filepath.Join(workDir, "reports", "junit.xml")
+	run := func() []byte {
+		t.Helper()
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--report-junit", "reports/junit.xml"})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitGraphFailure {
+			t.Fatalf("exit %d err %v", res.ExitCode, err)
+		}
+		b, err := os.ReadFile(report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	first := run()
+	var got junitTestSuites
+	if err := xml.Unmarshal(first, &got); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, first)
+	}
+	if got.Tests != 4 || got.Failures != 1 || got.Skipped != 1 || len(got.Suites) != 1 || got.Suites[0].Name != "graph.json" {
+		t.Fatalf("unexpected totals: %+v", got)
+	}
+	cases := map[string]junitTestCase{}
+	for _, tc := range got.Suites[0].Cases {
+		cases[tc.Name] = tc
+	}
+	if tc := cases["build"]; tc.Failure != nil || tc.Skipped != nil || tc.ClassName != "scriptweaver.ci" {
+		t.Fatalf("build = %+v", tc)
+	}
+	if tc := cases["test"]; tc.Failure == nil || tc.Failure.Message != "exit code 2" || tc.Failure.Body != "FAIL: TestX <bad>\n" {
+		t.Fatalf("test = %+v", tc)
+	}
+	if tc := cases["lint"]; tc.Failure != nil || tc.SystemErr != "lint failed at <TIMESTAMP>\n" {
+		t.Fatalf("lint = %+v", tc)
+	}
+	if tc := cases["deploy"]; tc.Skipped == nil {
+		t.Fatalf("deploy = %+v", tc)
+	}
+
+	// The second run replays build from cache and must report identically.
+	if second := run(); string(first) != string(second) {
+		t.Fatalf("reports differ between runs:\n%s\n%s", first, second)
+	}
+}
+
+func TestParseInvocation_ReportJUnitMustNotOverlap(t *testing.T) {
+	workDir := t.TempDir()
+	for _, path := range []string{"graph.json", "out/junit.xml", "cache/junit.xml"} {
+		_, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--report-junit", path})
+		if ExitCode(err) != ExitInvalidInvocation {
+			t.Fatalf("--report-junit %s: expected invalid invocation, got %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LogLevel selects which diagnostics a run logs on stderr.
+type LogLevel string
+
+const (
+	// LogQuiet logs warnings only, such as failed span exports or hooks.
+	LogQuiet LogLevel = "quiet"
+	// LogInfo also logs notices about the run (the default).
+	LogInfo LogLevel = "info"
+	// LogDebug also logs orchestration steps, every resume planning
+	// decision and every cache lookup and store.
+	LogDebug LogLevel = "debug"
+)
+
+// LogFormat selects how diagnostics are written.
+type LogFormat string
+
+const (
+	// LogFormatText writes "scriptweaver: message key=value ..." lines.
+	LogFormatText LogFormat = "text"
+	// LogFormatJSON writes one JSON object per record, with time, level,
+	// msg and the record's attributes as keys.
+	LogFormatJSON LogFormat = "json"
+)
+
+func (l LogLevel) slogLevel() slog.Level {
+	switch l {
+	case LogQuiet:
+		return slog.LevelWarn
+	case LogDebug:
+		return slog.LevelDebug
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// newLogger returns the logger a run writes its diagnostics to.
+func newLogger(w io.Writer, level LogLevel, format LogFormat) *slog.Logger {
+	if format == LogFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level.slogLevel()}))
+	}
+	return slog.New(&textHandler{w: w, level: level.slogLevel(), mu: &sync.Mutex{}})
+}
+
+// logger returns the logger for inv's diagnostics on its stderr.
+func (inv CLIInvocation) logger() *slog.Logger {
+	return newLogger(inv.errOut(), inv.LogLevel, inv.LogFormat)
+}
+
+// errOut is where inv's diagnostics and interactive output go.
+func (inv CLIInvocation) errOut() io.Writer {
+	if inv.stderr != nil {
+		return inv.stderr
+	}
+	return os.Stderr
+}
+
+// textHandler writes records as "scriptweaver: message key=value ..."
+// lines, the form diagnostics have always had; debug records are marked
+// "debug:". Time is left out: the lines interleave with task output.
+type textHandler struct {
+	w     io.Writer
+	level slog.Level
+	attrs string // preformatted " key=value" pairs from WithAttrs
+	group string // key prefix from WithGroup, with a trailing dot
+
+	mu *sync.Mutex // shared by handlers derived from one another
+}
+
+func (h *textHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString("scriptweaver: ")
+	if r.Level < slog.LevelInfo {
+		b.WriteString("debug: ")
+	}
+	b.WriteString(r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&b, h.group, a)
+		return true
+	})
+	b.WriteByte('\n')
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		appendAttr(&b, h.group, a)
+	}
+	h2 := *h
+	h2.attrs += b.String()
+	return &h2
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.group += name + "."
+	return &h2
+}
+
+// appendAttr writes a as " key=value", flattening groups into dotted keys
+// and quoting values that are empty or contain spaces, quotes or "=".
+func appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendAttr(b, prefix, ga)
+		}
+		return
+	}
+	v := a.Value.String()
+	if v == "" || strings.ContainsAny(v, " \t\r\n\"=") {
+		v = strconv.Quote(v)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, v)
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func TestNewLogger_TextLevels(t *testing.T) {
+	for _, tc := range []struct {
+		level LogLevel
+		want  string
+	}{
+		{LogQuiet, "scriptweaver: notify failed url=http://x error=\"connection refused\"\n"},
+		{LogInfo, "scriptweaver: status API listening url=http://127.0.0.1:1\n" +
+			"scriptweaver: notify failed url=http://x error=\"connection refused\"\n"},
+		{LogDebug, "scriptweaver: debug: cache hit run=r1 task=a hash=\"\"\n" +
+			"scriptweaver: status API listening url=http://127.0.0.1:1\n" +
+			"scriptweaver: notify failed url=http://x error=\"connection refused\"\n"},
+	} {
+		var out bytes.Buffer
+		logger := newLogger(&out, tc.level, LogFormatText)
+		logger.With("run", "r1").Debug("cache hit", "task", "a", "hash", "")
+		logger.Info("status API listening", "url", "http://127.0.0.1:1")
+		logger.Warn("notify failed", "url", "http://x", "error", errors.New("connection refused"))
+		if out.String() != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.level, out.String(), tc.want)
+		}
+	}
+}
+
+func TestParseInvocation_RejectsUnknownLogSettings(t *testing.T) {
+	base := []string{"--workdir", t.TempDir(), "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"}
+	for _, extra := range [][]string{{"--log-level", "verbose"}, {"--log-format", "xml"}} {
+		if _, err := ParseInvocation(append(append([]string(nil), base...), extra...)); ExitCode(err) != ExitInvalidInvocation {
+			t.Errorf("%v: err = %v, want an invalid invocation", extra, err)
+		}
+	}
+}
+
+// With --log-format json, resume planning explains each decision in records
+// an operator can filter, and cache operations are logged at debug level.
+func TestRunCommand_JSONLogsExplainResumeDecisions(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "echo a > a.out", Outputs: []string{"a.out"}},
+		{Name: "b", Inputs: []string{"a.out"}, Run: "exit 1"},
+	}, []dag.Edge{{From: "a", To: "b"}})
+	args := []string{"run", "--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out",
+		"--mode", "incremental", "--log-level", "debug", "--log-format", "json"}
+
+	run := func() []map[string]any {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if code := RunCommand(context.Background(), args, &stdout, &stderr); code != ExitGraphFailure {
+			t.Fatalf("exit = %d, want %d; stderr:\n%s", code, ExitGraphFailure, stderr.String())
+		}
+		var records []map[string]any
+		sc := bufio.NewScanner(&stderr)
+		for sc.Scan() {
+			var rec map[string]any
+			if json.Unmarshal(sc.Bytes(), &rec) != nil {
+				continue // the plain error report
+			}
+			records = append(records, rec)
+		}
+		return records
+	}
+	find := func(records []map[string]any, msg string) map[string]any {
+		t.Helper()
+		for _, rec := range records {
+			if rec["msg"] == msg {
+				return rec
+			}
+		}
+		t.Fatalf("no %q record in %v", msg, records)
+		return nil
+	}
+
+	first := run()
+	if rec := find(first, "resume report"); rec["level"] != "DEBUG" || rec["decision"] != "scratch" || rec["reason"] != "no failed run of this graph" {
+		t.Fatalf("resume report record = %v", rec)
+	}
+	if rec := find(first, "cache store"); rec["task"] == nil || rec["hash"] == "" {
+		t.Fatalf("cache store record = %v", rec)
+	}
+
+	second := run()
+	resumed := find(second, "resuming previous run")
+	if resumed["level"] != "INFO" || resumed["previous_run"] == "" || resumed["checkpoint_node"] != "a" {
+		t.Fatalf("resume record = %v", resumed)
+	}
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// manifestFileName is the artifact manifest written to OutputDir after a
+// successful run.
+const manifestFileName = "manifest.json"
+
+// ArtifactManifest lists the artifacts of a successful run. It is canonical:
+// the same graph and task results always produce the same bytes.
+type ArtifactManifest struct {
+	GraphHash string `json:"graph_hash"`
+	// Artifacts is sorted by path, then task.
+	Artifacts []ManifestArtifact `json:"artifacts"`
+}
+
+// ManifestArtifact is one declared output file of a task.
+type ManifestArtifact struct {
+	// Path is relative to the working directory, with forward slashes.
+	Path string `json:"path"`
+	// SHA256 is the hex digest of the file content, or of the link target
+	// for symlinks.
+	SHA256     string `json:"sha256"`
+	LinkTarget string `json:"link_target,omitempty"`
+	Task       string `json:"task"`
+	TaskHash   string `json:"task_hash"`
+}
+
+// writeManifest writes OutputDir/manifest.json listing the declared outputs
+// of every task that completed or was restored from cache, hashed from the
+// files now on disk. When a task declares an output covering the manifest
+// path, nothing is written: the manifest would overwrite that output or be
+// harvested into it.
+func writeManifest(inv CLIInvocation, g *dag.TaskGraph, gr *dag.GraphResult, logger *slog.Logger) error {
+	path := filepath.Join(inv.OutputDir, manifestFileName)
+	if coveredByOutput(path, declaredOutputs(inv.WorkDir, g)) {
+		logger.Info("not writing manifest: it is covered by a declared task output", "path", path)
+		return nil
+	}
+	m := ArtifactManifest{GraphHash: string(gr.GraphHash), Artifacts: []ManifestArtifact{}}
+	harvester := core.NewHarvester(inv.WorkDir)
+	for _, name := range g.TopologicalOrder() {
+		st := gr.FinalState[name]
+		if st != dag.TaskCompleted && st != dag.TaskCached {
+			continue
+		}
+		n, _ := g.Node(name)
+		if len(n.Task.Outputs) == 0 {
+			continue
+		}
+		set, err := harvester.Harvest(n.Task.Outputs)
+		if err != nil {
+			return fmt.Errorf("manifest %s: %w", name, err)
+		}
+		for _, a := range set.Artifacts {
+			d, err := harvestedDigest(a)
+			if err != nil {
+				return fmt.Errorf("manifest %s: hashing %s: %w", name, a.Path, err)
+			}
+			m.Artifacts = append(m.Artifacts, ManifestArtifact{
+				Path:       a.Path,
+				SHA256:     d,
+				LinkTarget: a.LinkTarget,
+				Task:       name,
+				TaskHash:   string(gr.TaskHashes[name]),
+			})
+		}
+	}
+	sort.Slice(m.Artifacts, func(i, j int) bool {
+		if m.Artifacts[i].Path != m.Artifacts[j].Path {
+			return m.Artifacts[i].Path < m.Artifacts[j].Path
+		}
+		return m.Artifacts[i].Task < m.Artifacts[j].Task
+	})
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(inv.OutputDir, 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func readManifest(t *testing.T, outDir string) ArtifactManifest {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(outDir, manifestFileName))
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	var m ArtifactManifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	return m
+}
+
+func TestExecute_WritesArtifactManifest(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{
+		{Name: "gen", Run: "mkdir -p out && printf hello > out/b.txt && printf x > out/a.txt", Outputs: []string{"out/a.txt", "out/b.txt"}},
+		{Name: "use", Run: "cat out/b.txt > out/c.txt", Inputs: []string{"out/b.txt"}, Outputs: []string{"out/c.txt"}},
+	}, []dag.Edge{{From: "gen", To: "use"}})
+	inv := CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	}
+
+	res, err := Execute(context.Background(), inv)
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("exit=%d err=%v", res.ExitCode, err)
+	}
+	m := readManifest(t, inv.OutputDir)
+	if m.GraphHash != res.GraphResult.GraphHash.String() || len(m.Artifacts) != 3 {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+	hello := sha256.Sum256([]byte("hello"))
+	for i, want := range []ManifestArtifact{
+		{Path: "out/a.txt", Task: "gen"},
+		{Path: "out/b.txt", Task: "gen", SHA256: hex.EncodeToString(hello[:])},
+		{Path: "out/c.txt", Task: "use", SHA256: hex.EncodeToString(hello[:])},
+	} {
+		got := m.Artifacts[i]
+		if got.Path != want.Path || got.Task != want.Task || got.TaskHash != string(res.GraphResult.TaskHashes[want.Task]) {
+			t.Fatalf("artifact %d = %+v, want %+v", i, got, want)
+		}
+		if want.SHA256 != "" && got.SHA256 != want.SHA256 {
+			t.Fatalf("artifact %d digest = %s, want %s", i, got.SHA256, want.SHA256)
+		}
+	}
+
+	// A fully cached run restores the same outputs and rewrites the same bytes.
+	first, _ := os.ReadFile(filepath.Join(inv.OutputDir, manifestFileName))
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("second run: exit=%d err=%v", res.ExitCode, err)
+	}
+	second, _ := os.ReadFile(filepath.Join(inv.OutputDir, manifestFileName))
+	if string(first) != string(second) {
+		t.Fatalf("manifest is not canonical:\n%s\n%s", first, second)
+	}
+}
+
+func TestExecute_NoManifestAfterFailureOrWhenCovered(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{{Name: "bad", Run: "exit 1"}}, nil)
+	inv := CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	}
+	if res, _ := Execute(context.Background(), inv); res.ExitCode != ExitGraphFailure {
+		t.Fatalf("exit=%d, want %d", res.ExitCode, ExitGraphFailure)
+	}
+	if _, err := os.Stat(filepath.Join(inv.OutputDir, manifestFileName)); !os.IsNotExist(err) {
+		t.Fatalf("failed run wrote a manifest: %v", err)
+	}
+
+	writeGraphJSON(t, graphPath, []core.Task{{Name: "all", Run: "mkdir -p out && echo mine > out/manifest.json", Outputs: []string{"out/*"}}}, nil)
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("exit=%d err=%v", res.ExitCode, err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(inv.OutputDir, manifestFileName)); string(b) != "mine\n" {
+		t.Fatalf("task output was overwritten: %q", b)
+	}
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"scriptweaver/internal/core"
+)
+
+// matrixTemplate declares a template task expanded once per combination of
+// parameter values, e.g.
+//
+//	{"task": {"name": "build-${target}", "run": "make ${target}", "outputs": ["bin/${target}"]},
+//	 "params": {"target": ["linux", "darwin"]}}
+//
+// "${param}" is substituted in the name, run command, inputs, outputs and env
+// values. Every parameter must appear in the name so expanded names are unique,
+// and a "${...}" left in the name after substitution is an undeclared param.
+// Elsewhere it is left for the shell or the executor.
+type matrixTemplate struct {
+	Task   core.Task           `json:"task"`
+	Params map[string][]string `json:"params"`
+}
+
+var (
+	matrixParamName   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	matrixPlaceholder = regexp.MustCompile(`\$?\$\{[A-Za-z_][A-Za-z0-9_]*\}`)
+)
+
+// expandMatrix appends the concrete tasks for every template in gf.Matrix and
+// clears the templates.
+//
+// Expansion is deterministic: parameters are iterated in name order and values
+// in declared order, so the first parameter (by name) varies slowest.
+func expandMatrix(gf graphFile) (graphFile, error) {
+	if len(gf.Matrix) == 0 {
+		return gf, nil
+	}
+	out := gf
+	out.Tasks = append([]core.Task(nil), gf.Tasks...)
+	out.Matrix = nil
+	for i, m := range gf.Matrix {
+		tasks, err := m.expand()
+		if err != nil {
+			return graphFile{}, fmt.Errorf("parse graph json: matrix[%d]: %w", i, err)
+		}
+		out.Tasks = append(out.Tasks, tasks...)
+	}
+	return out, nil
+}
+
+func (m matrixTemplate) expand() ([]core.Task, error) {
+	if m.Task.Name == "" {
+		return nil, fmt.Errorf("template task name is required")
+	}
+	if len(m.Params) == 0 {
+		return nil, fmt.Errorf("template %q declares no params", m.Task.Name)
+	}
+	names := make([]string, 0, len(m.Params))
+	for name, values := range m.Params {
+		if !matrixParamName.MatchString(name) {
+			return nil, fmt.Errorf("template %q: invalid param name %q", m.Task.Name, name)
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("template %q: param %q has no values", m.Task.Name, name)
+		}
+		if !strings.Contains(m.Task.Name, "${"+name+"}") {
+			return nil, fmt.Errorf("template %q: name must reference param %q", m.Task.Name, name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tasks []core.Task
+	seen := make(map[string]struct{})
+	idx := make([]int, len(names))
+	for {
+		pairs := make([]string, 0, 2*len(names))
+		for i, name := range names {
+			pairs = append(pairs, "${"+name+"}", m.Params[name][idx[i]])
+		}
+		t, err := instantiate(m.Task, strings.NewReplacer(pairs...))
+		if err != nil {
+			return nil, fmt.Errorf("template %q: %w", m.Task.Name, err)
+		}
+		if _, ok := seen[t.Name]; ok {
+			return nil, fmt.Errorf("template %q: duplicate expanded task name %q", m.Task.Name, t.Name)
+		}
+		seen[t.Name] = struct{}{}
+		tasks = append(tasks, t)
+
+		// Advance the odometer; the last param varies fastest.
+		i := len(idx) - 1
+		for ; i >= 0; i-- {
+			idx[i]++
+			if idx[i] < len(m.Params[names[i]]) {
+				break
+			}
+			idx[i] = 0
+		}
+		if i < 0 {
+			return tasks, nil
+		}
+	}
+}
+
+func instantiate(tmpl core.Task, r *strings.Replacer) (core.Task, error) {
+	t := core.Task{
+		Name:    r.Replace(tmpl.Name),
+		Run:     r.Replace(tmpl.Run),
+		Image:   r.Replace(tmpl.Image),
+		Kind:    tmpl.Kind,
+		Network: r.Replace(tmpl.Network),
+		Group:   r.Replace(tmpl.Group),
+		When:    tmpl.When.Replace(r.Replace, r.Replace),
+		// Rules are regular expressions, so they are copied verbatim.
+		Normalize:        tmpl.Normalize,
+		MaxOutputBytes:   tmpl.MaxOutputBytes,
+		SuccessExitCodes: tmpl.SuccessExitCodes,
+		AllowFailure:     tmpl.AllowFailure,
+		Cacheable:        tmpl.Cacheable,
+		EffectKey:        r.Replace(tmpl.EffectKey),
+	}
+	if tmpl.Stdin != nil {
+		t.Stdin = &core.Stdin{Text: r.Replace(tmpl.Stdin.Text), File: r.Replace(tmpl.Stdin.File)}
+	}
+	if tmpl.Tools != nil {
+		t.Tools = make([]core.Tool, len(tmpl.Tools))
+		for i, tool := range tmpl.Tools {
+			t.Tools[i] = core.Tool{Name: r.Replace(tool.Name), Version: r.Replace(tool.Version), VersionArgs: tool.VersionArgs, SHA256: r.Replace(tool.SHA256)}
+		}
+	}
+	if tmpl.Inputs != nil {
+		t.Inputs = make([]string, len(tmpl.Inputs))
+		for i, in := range tmpl.Inputs {
+			t.Inputs[i] = r.Replace(in)
+		}
+	}
+	if tmpl.Outputs != nil {
+		t.Outputs = make([]string, len(tmpl.Outputs))
+		for i, o := range tmpl.Outputs {
+			t.Outputs[i] = r.Replace(o)
+		}
+	}
+	if tmpl.Env != nil {
+		t.Env = make(map[string]string, len(tmpl.Env))
+		for k, v := range tmpl.Env {
+			t.Env[k] = r.Replace(v)
+		}
+	}
+	if tmpl.Service != nil {
+		svc := *tmpl.Service
+		svc.Ready = r.Replace(svc.Ready)
+		t.Service = &svc
+	}
+	if tmpl.ExpectedOutputs != nil {
+		t.ExpectedOutputs = make(map[string]string, len(tmpl.ExpectedOutputs))
+		for p, digest := range tmpl.ExpectedOutputs {
+			t.ExpectedOutputs[r.Replace(p)] = digest
+		}
+	}
+
+	// A placeholder left in the name names an undeclared param (likely a
+	// typo). Other fields are not checked: "${HOME}" and the like belong to
+	// the shell. The executor's placeholders and their "$$" escapes are not
+	// params either.
+	for _, p := range matrixPlaceholder.FindAllString(t.Name, -1) {
+		switch {
+		case strings.HasPrefix(p, "$$"), p == core.TaskPlaceholder, p == core.WorkDirPlaceholder, p == core.OutputDirPlaceholder:
+			continue
+		}
+		return core.Task{}, fmt.Errorf("undeclared param %s", p)
+	}
+	return t, nil
+}
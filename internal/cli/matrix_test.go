@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+func TestLoadGraphFromFile_MatrixExpandsDeterministically(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "graph.json")
+	src := `{
+		"tasks": [{"name": "package", "run": "tar cf out.tar bin"}],
+		"matrix": [{
+			"task": {
+				"name": "build-${os}-${arch}",
+				"run": "GOOS=${os} GOARCH=${arch} go build -o bin/${os}-${arch}",
+				"inputs": ["src/${os}.go"],
+				"outputs": ["bin/${os}-${arch}"],
+				"env": {"TARGET": "${os}/${arch}"}
+			},
+			"params": {"os": ["linux", "darwin"], "arch": ["amd64", "arm64"]}
+		}],
+		"edges": [{"From": "build-darwin-arm64", "To": "package"}]
+	}`
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatalf("write graph: %v", err)
+	}
+
+	gf, err := readGraphFile(p)
+	if err != nil {
+		t.Fatalf("read graph: %v", err)
+	}
+	var names []string
+	for _, task := range gf.Tasks {
+		names = append(names, task.Name)
+	}
+	want := "package build-linux-amd64 build-darwin-amd64 build-linux-arm64 build-darwin-arm64"
+	if got := strings.Join(names, " "); got != want {
+		t.Fatalf("expansion order:\n got %s\nwant %s", got, want)
+	}
+	b := gf.Tasks[4]
+	if b.Run != "GOOS=darwin GOARCH=arm64 go build -o bin/darwin-arm64" || b.Inputs[0] != "src/darwin.go" || b.Outputs[0] != "bin/darwin-arm64" || b.Env["TARGET"] != "darwin/arm64" {
+		t.Fatalf("unexpected substitution: %+v", b)
+	}
+
+	g1, err := LoadGraphFromFile(p)
+	if err != nil {
+		t.Fatalf("load graph: %v", err)
+	}
+	g2, err := LoadGraphFromFile(p)
+	if err != nil {
+		t.Fatalf("load graph: %v", err)
+	}
+	if g1.Hash() != g2.Hash() || len(g1.Nodes()) != 5 {
+		t.Fatalf("expected stable 5-node graph, got %d nodes", len(g1.Nodes()))
+	}
+}
+
+func TestLoadGraphFromFile_MatrixKeepsNetworkMode(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "graph.json")
+	src := `{"tasks": [], "matrix": [{
+		"task": {"name": "test-${os}", "run": "go test ./...", "network": "none"},
+		"params": {"os": ["linux", "darwin"]}
+	}]}`
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatalf("write graph: %v", err)
+	}
+	g, err := LoadGraphFromFile(p)
+	if err != nil {
+		t.Fatalf("load graph: %v", err)
+	}
+	for _, name := range []string{"test-linux", "test-darwin"} {
+		n, ok := g.Node(name)
+		if !ok {
+			t.Fatalf("missing task %q", name)
+		}
+		if n.Task.Network != core.NetworkNone {
+			t.Fatalf("%s: network = %q, want %q", name, n.Task.Network, core.NetworkNone)
+		}
+	}
+}
+
+func TestLoadGraphFromFile_MatrixLeavesShellVariables(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "graph.json")
+	src := `{"tasks": [], "matrix": [{
+		"task": {"name": "build-${os}", "run": "cd ${HOME} && make ${os}", "env": {"CACHE": "${HOME}/.cache/${os}"}},
+		"params": {"os": ["linux"]}
+	}]}`
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatalf("write graph: %v", err)
+	}
+	g, err := LoadGraphFromFile(p)
+	if err != nil {
+		t.Fatalf("load graph: %v", err)
+	}
+	n, ok := g.Node("build-linux")
+	if !ok {
+		t.Fatalf("missing task build-linux")
+	}
+	if n.Task.Run != "cd ${HOME} && make linux" || n.Task.Env["CACHE"] != "${HOME}/.cache/linux" {
+		t.Fatalf("unexpected task: run=%q env=%v", n.Task.Run, n.Task.Env)
+	}
+}
+
+func TestLoadGraphFromFile_MatrixRejectsInvalidTemplates(t *testing.T) {
+	cases := map[string]struct {
+		matrix string
+		want   string
+	}{
+		"param missing from name": {
+			matrix: `{"task":{"name":"build","run":"make ${t}"},"params":{"t":["a","b"]}}`,
+			want:   "must reference param",
+		},
+		"undeclared placeholder": {
+			matrix: `{"task":{"name":"build-${t}-${target}","run":"make ${t}"},"params":{"t":["a"]}}`,
+			want:   "undeclared param ${target}",
+		},
+		"empty values": {
+			matrix: `{"task":{"name":"build-${t}","run":"make"},"params":{"t":[]}}`,
+			want:   "has no values",
+		},
+		"duplicate names": {
+			matrix: `{"task":{"name":"build-${t}","run":"make"},"params":{"t":["a","a"]}}`,
+			want:   "duplicate expanded task name",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "graph.json")
+			if err := os.WriteFile(p, []byte(`{"tasks":[],"edges":[],"matrix":[`+tc.matrix+`]}`), 0o644); err != nil {
+				t.Fatalf("write graph: %v", err)
+			}
+			_, err := LoadGraphFromFile(p)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
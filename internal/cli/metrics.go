@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+// restoringRunner is a TaskRunner that also supports planned cache reuse.
+type restoringRunner interface {
+	dag.TaskRunner
+	Restore(ctx context.Context, task core.Task) (*dag.NodeResult, error)
+}
+
+// timingRunner wraps the task runner to measure per-task wall time for
+// the run's metrics sidecar and exported spans. Timing never feeds hashing,
+// traces or outputs.
+type timingRunner struct {
+	inner restoringRunner
+	now   func() time.Time
+
+	mu        sync.Mutex
+	durations map[string]time.Duration
+	fromCache map[string]bool
+	// spans holds each task's first start and last end.
+	spans map[string]timeSpan
+}
+
+type timeSpan struct {
+	start, end time.Time
+}
+
+func newTimingRunner(inner restoringRunner) *timingRunner {
+	return &timingRunner{
+		inner:     inner,
+		now:       time.Now,
+		durations: make(map[string]time.Duration),
+		fromCache: make(map[string]bool),
+		spans:     make(map[string]timeSpan),
+	}
+}
+
+func (t *timingRunner) record(name string, start time.Time, res *dag.NodeResult) {
+	end := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.durations[name] += end.Sub(start)
+	span, ok := t.spans[name]
+	if !ok {
+		span.start = start
+	}
+	span.end = end
+	t.spans[name] = span
+	if res != nil {
+		t.fromCache[name] = res.FromCache
+	}
+}
+
+func (t *timingRunner) Probe(ctx context.Context, task core.Task) (*dag.NodeResult, bool, error) {
+	start := t.now()
+	res, cached, err := t.inner.Probe(ctx, task)
+	if err == nil && cached {
+		t.record(task.Name, start, res)
+	}
+	return res, cached, err
+}
+
+func (t *timingRunner) Run(ctx context.Context, task core.Task) (*dag.NodeResult, error) {
+	start := t.now()
+	res, err := t.inner.Run(ctx, task)
+	if err == nil {
+		t.record(task.Name, start, res)
+	}
+	return res, err
+}
+
+func (t *timingRunner) Restore(ctx context.Context, task core.Task) (*dag.NodeResult, error) {
+	start := t.now()
+	res, err := t.inner.Restore(ctx, task)
+	if err == nil {
+		t.record(task.Name, start, res)
+	}
+	return res, err
+}
+
+// TaskHash forwards to the inner runner, if it computes hashes ahead of
+// running (see dag.HashRunner).
+func (t *timingRunner) TaskHash(task core.Task) (core.TaskHash, error) {
+	hasher, ok := t.inner.(dag.HashRunner)
+	if !ok {
+		return "", nil
+	}
+	return hasher.TaskHash(task)
+}
+
+// StopService forwards service teardown to the inner runner, if it starts
+// services (see dag.ServiceStopper).
+func (t *timingRunner) StopService(ctx context.Context, task core.Task) (bool, error) {
+	stopper, ok := t.inner.(dag.ServiceStopper)
+	if !ok {
+		return false, nil
+	}
+	return stopper.StopService(ctx, task)
+}
+
+// runMetrics builds the metrics sidecar from the final graph state.
+//
+// Completed tasks served from cache (planned reuse) count as cached. Tasks
+// carry their group label, if any, from g.
+func (t *timingRunner) runMetrics(runID string, g *dag.TaskGraph, gr *dag.GraphResult) state.RunMetrics {
+	m := state.RunMetrics{RunID: runID, Tasks: []state.TaskMetric{}}
+	if gr == nil {
+		return m
+	}
+	names := make([]string, 0, len(gr.FinalState))
+	for n := range gr.FinalState {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, name := range names {
+		var outcome state.TaskOutcome
+		switch gr.FinalState[name] {
+		case dag.TaskCompleted:
+			outcome = state.TaskOutcomeExecuted
+			if t.fromCache[name] {
+				outcome = state.TaskOutcomeCached
+			}
+		case dag.TaskCached:
+			outcome = state.TaskOutcomeCached
+		case dag.TaskFailed, dag.TaskFailedAllowed:
+			outcome = state.TaskOutcomeFailed
+		case dag.TaskSkipped, dag.TaskConditionFalse:
+			outcome = state.TaskOutcomeSkipped
+		default:
+			continue
+		}
+		metric := state.TaskMetric{
+			NodeID:         name,
+			Outcome:        outcome,
+			DurationMillis: t.durations[name].Milliseconds(),
+		}
+		if g != nil {
+			if n, ok := g.Node(name); ok {
+				metric.Group = n.Task.Group
+			}
+		}
+		m.Tasks = append(m.Tasks, metric)
+	}
+	return m
+}
+
+// timingLedger builds the run's timing ledger from the recorded task spans,
+// as offsets from runStart. Tasks that never reached the runner have no span
+// and are left out.
+func (t *timingRunner) timingLedger(runID string, runStart time.Time) state.TimingLedger {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tasks := make([]state.TaskTiming, 0, len(t.spans))
+	var total int64
+	for name, s := range t.spans {
+		tt := state.TaskTiming{
+			NodeID:         name,
+			StartMillis:    max(s.start.Sub(runStart).Milliseconds(), 0),
+			EndMillis:      max(s.end.Sub(runStart).Milliseconds(), 0),
+			DurationMillis: t.durations[name].Milliseconds(),
+		}
+		tt.EndMillis = max(tt.EndMillis, tt.StartMillis)
+		total = max(total, tt.EndMillis)
+		tasks = append(tasks, tt)
+	}
+	return state.NewTimingLedger(runID, total, tasks)
+}
+
+// taskResults maps each task that reached the runner to its result in gr,
+// for the state store's result index. Skipped tasks have no result.
+func taskResults(runID string, m state.RunMetrics, gr *dag.GraphResult) map[string]state.TaskResult {
+	results := make(map[string]state.TaskResult, len(m.Tasks))
+	if gr == nil {
+		return results
+	}
+	for _, t := range m.Tasks {
+		hash := gr.TaskHashes[t.NodeID]
+		if t.Outcome == state.TaskOutcomeSkipped || hash == "" {
+			continue
+		}
+		results[t.NodeID] = state.TaskResult{TaskHash: string(hash), RunID: runID, Outcome: t.Outcome, ExitCode: gr.ExitCode[t.NodeID]}
+	}
+	return results
+}
+
+// cacheMetrics combines the run's cache counters with the number of tasks
+// whose result was restored from the cache, as recorded in m.
+func cacheMetrics(s core.CacheStats, m state.RunMetrics) *state.CacheMetrics {
+	c := &state.CacheMetrics{
+		Lookups:      s.Lookups,
+		Hits:         s.Hits,
+		Misses:       s.Misses,
+		Gets:         s.Gets,
+		Puts:         s.Puts,
+		BytesRead:    s.BytesRead,
+		BytesWritten: s.BytesWritten,
+	}
+	for _, t := range m.Tasks {
+		if t.Outcome == state.TaskOutcomeCached {
+			c.Restores++
+		}
+	}
+	return c
+}
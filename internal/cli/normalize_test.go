@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExecute_NormalizeRulesScrubCachedArtifacts(t *testing.T) {
+	workDir := t.TempDir()
+	graph := `{
+		"normalize": [{"pattern": "/tmp/[A-Za-z0-9]+", "replacement": "<TMP>"}],
+		"tasks": [{
+			"name": "gen",
+			"run": "mkdir -p out && echo \"id=$(od -An -N4 -tx4 /dev/urandom | tr -d ' ') dir=/tmp/$$\" > out/info.txt",
+			"outputs": ["out/info.txt"],
+			"normalize": [{"pattern": "id=[0-9a-f]+", "replacement": "id=<ID>"}]
+		}]
+	}`
+	if err := os.WriteFile(filepath.Join(workDir, "graph.json"), []byte(graph), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func() {
+		t.Helper()
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "build"})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+		}
+	}
+	// Rules apply to what is cached; the second run restores that copy.
+	run()
+	run()
+	got, err := os.ReadFile(filepath.Join(workDir, "out", "info.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "id=<ID> dir=<TMP>\n"; string(got) != want {
+		t.Fatalf("artifact = %q, want %q", got, want)
+	}
+
+	g, err := LoadGraphFromFile(filepath.Join(workDir, "graph.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := g.Node("gen")
+	if len(n.Task.Normalize) != 2 || n.Task.Normalize[0].Replacement != "<TMP>" {
+		t.Fatalf("graph rules must precede task rules: %+v", n.Task.Normalize)
+	}
+}
+
+func TestLoadGraphFromFile_RejectsInvalidNormalizeRule(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "graph.json")
+	src := `{"tasks": [{"name": "a", "run": "true", "normalize": [{"pattern": "(", "replacement": "x"}]}]}`
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadGraphFromFile(p); err == nil {
+		t.Fatalf("expected invalid pattern to be rejected")
+	}
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"scriptweaver/internal/config"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+// notifyTimeout bounds each webhook post at the end of a run.
+const notifyTimeout = 10 * time.Second
+
+// RunSummary is the JSON body posted to "json" notifiers when a run ends.
+type RunSummary struct {
+	RunID     string            `json:"run_id,omitempty"`
+	GraphHash string            `json:"graph_hash,omitempty"`
+	Status    state.RunStatus   `json:"status"`
+	ExitCode  int               `json:"exit_code"`
+	Error     *ErrorReport      `json:"error,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	StartTime time.Time         `json:"start_time"`
+	EndTime   time.Time         `json:"end_time"`
+
+	// Tasks counts the tasks in each final state; FailedTasks lists the
+	// failed ones, sorted. Both are empty when no graph ran.
+	Tasks       map[dag.TaskState]int `json:"tasks,omitempty"`
+	FailedTasks []string              `json:"failed_tasks,omitempty"`
+
+	// GraphReplayed marks a run that replayed a memoized run of its graph.
+	GraphReplayed bool `json:"graph_replayed,omitempty"`
+}
+
+// runSummary describes the finished run for notifiers.
+func runSummary(inv CLIInvocation, runID, graphHash string, start, end time.Time, res CLIResult, err error) RunSummary {
+	s := RunSummary{
+		RunID:     runID,
+		GraphHash: graphHash,
+		Status:    state.RunStatusSucceeded,
+		ExitCode:  res.ExitCode,
+		Error:     DescribeError(res, err),
+		Labels:    inv.Labels,
+		StartTime: start,
+		EndTime:   end,
+	}
+	if s.Error != nil {
+		s.Status = state.RunStatusFailed
+	}
+	if gr := res.GraphResult; gr != nil {
+		s.Tasks = make(map[dag.TaskState]int)
+		for name, st := range gr.FinalState {
+			s.Tasks[st]++
+			if st == dag.TaskFailed {
+				s.FailedTasks = append(s.FailedTasks, name)
+			}
+		}
+		sort.Strings(s.FailedTasks)
+	}
+	if res.Metrics != nil {
+		s.GraphReplayed = res.Metrics.GraphReplayed
+	}
+	return s
+}
+
+// slackText renders s as a one-line Slack message.
+func slackText(s RunSummary) string {
+	var b strings.Builder
+	b.WriteString("scriptweaver run")
+	if s.RunID != "" {
+		fmt.Fprintf(&b, " %s", s.RunID)
+	}
+	if s.Error == nil {
+		b.WriteString(" succeeded")
+	} else {
+		fmt.Fprintf(&b, " failed with exit code %d (%s/%s): %s", s.ExitCode, s.Error.Class, s.Error.Code, s.Error.Message)
+	}
+	if len(s.FailedTasks) > 0 {
+		fmt.Fprintf(&b, "\nFailed tasks: %s", strings.Join(s.FailedTasks, ", "))
+	}
+	if len(s.Labels) > 0 {
+		fmt.Fprintf(&b, "\nLabels: %s", labelFlags(s.Labels).String())
+	}
+	return b.String()
+}
+
+// notifyRun posts s to every notifier whose trigger matches. Like span
+// export it observes the run only: failures are logged as warnings and
+// never change the outcome.
+func notifyRun(ctx context.Context, notifiers []config.Notifier, s RunSummary, logger *slog.Logger) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// Teams want to hear about interrupted runs too.
+	ctx = context.WithoutCancel(ctx)
+	for _, n := range notifiers {
+		if !n.Wants(s.Error == nil) {
+			continue
+		}
+		if err := postNotification(ctx, n, s); err != nil {
+			logger.Warn("notify failed", "url", n.URL, "error", err)
+			continue
+		}
+		logger.Debug("notified", "url", n.URL, "format", n.Format)
+	}
+}
+
+func postNotification(ctx context.Context, n config.Notifier, s RunSummary) error {
+	var payload any = s
+	if n.Format == config.NotifyFormatSlack {
+		payload = map[string]string{"text": slackText(s)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
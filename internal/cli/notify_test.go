@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+func TestExecute_NotifiesWebhooksOnCompletion(t *testing.T) {
+	var mu sync.Mutex
+	posts := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		posts[r.URL.Path] = body
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	workDir := t.TempDir()
+	writeConfig(t, workDir, `[[notify]]
+url = "`+srv.URL+`/json"
+
+[[notify]]
+url = "`+srv.URL+`/slack"
+format = "slack"
+on = "failure"
+
+[[notify]]
+url = "`+srv.URL+`/success"
+on = "success"
+`)
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "true"},
+		{Name: "b", Run: "exit 3"},
+		{Name: "c", Run: "true"},
+	}, []dag.Edge{{From: "a", To: "b"}, {From: "b", To: "c"}})
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--label", "branch=main"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if res, _ := Execute(context.Background(), inv); res.ExitCode != ExitGraphFailure {
+		t.Fatalf("expected graph failure, got %d", res.ExitCode)
+	}
+
+	if _, ok := posts["/success"]; ok {
+		t.Fatalf("success-only notifier was told about a failed run")
+	}
+	var summary RunSummary
+	if err := json.Unmarshal(posts["/json"], &summary); err != nil {
+		t.Fatalf("json notifier: %v (body %s)", err, posts["/json"])
+	}
+	if summary.Status != state.RunStatusFailed || summary.ExitCode != ExitGraphFailure || summary.RunID == "" || summary.GraphHash == "" {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if !reflect.DeepEqual(summary.FailedTasks, []string{"b"}) || summary.Tasks[dag.TaskSkipped] != 1 || summary.Labels["branch"] != "main" {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if summary.Error == nil || summary.Error.Code != ErrorCodeNodeFailed {
+		t.Fatalf("expected a NodeFailed error, got %+v", summary.Error)
+	}
+	var slack struct{ Text string }
+	if err := json.Unmarshal(posts["/slack"], &slack); err != nil {
+		t.Fatalf("slack notifier: %v", err)
+	}
+	if !strings.Contains(slack.Text, "failed with exit code 1") || !strings.Contains(slack.Text, "Failed tasks: b") {
+		t.Fatalf("unexpected slack text %q", slack.Text)
+	}
+}
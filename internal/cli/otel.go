@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/otel"
+	"scriptweaver/internal/recovery/state"
+)
+
+// otelExportTimeout bounds the span export at the end of a run.
+const otelExportTimeout = 10 * time.Second
+
+// exportSpans sends the run as an OpenTelemetry trace to endpoint: a root
+// span for the run and one child per task that ran or was restored, timed
+// by timed. It observes the run only; callers ignore its error beyond
+// reporting it.
+func exportSpans(ctx context.Context, endpoint string, inv CLIInvocation, runID, graphHash string, start, end time.Time, exitCode int, timed *timingRunner, metrics state.RunMetrics, gr *dag.GraphResult) error {
+	root := otel.Span{
+		Name:  "scriptweaver run",
+		Start: start,
+		End:   end,
+		Attributes: []otel.Attribute{
+			{Key: "scriptweaver.graph_hash", Value: graphHash},
+			{Key: "scriptweaver.mode", Value: string(inv.ExecutionMode)},
+			{Key: "scriptweaver.exit_code", Value: exitCode},
+		},
+		Failed: exitCode != ExitSuccess,
+	}
+	if runID != "" {
+		root.Attributes = append(root.Attributes, otel.Attribute{Key: "scriptweaver.run_id", Value: runID})
+	}
+
+	timed.mu.Lock()
+	spans := make(map[string]timeSpan, len(timed.spans))
+	for name, s := range timed.spans {
+		spans[name] = s
+	}
+	timed.mu.Unlock()
+
+	var children []otel.Span
+	for _, m := range metrics.Tasks {
+		s, ok := spans[m.NodeID]
+		if !ok {
+			continue
+		}
+		span := otel.Span{
+			Name:  m.NodeID,
+			Start: s.start,
+			End:   s.end,
+			Attributes: []otel.Attribute{
+				{Key: "scriptweaver.task", Value: m.NodeID},
+				{Key: "scriptweaver.outcome", Value: string(m.Outcome)},
+				{Key: "scriptweaver.task_hash", Value: string(gr.TaskHashes[m.NodeID])},
+				{Key: "scriptweaver.exit_code", Value: gr.ExitCode[m.NodeID]},
+			},
+			Failed: m.Outcome == state.TaskOutcomeFailed,
+		}
+		if m.Group != "" {
+			span.Attributes = append(span.Attributes, otel.Attribute{Key: "scriptweaver.group", Value: m.Group})
+		}
+		children = append(children, span)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, otelExportTimeout)
+	defer cancel()
+	e := &otel.Exporter{Endpoint: endpoint, ServiceName: "scriptweaver"}
+	return e.Export(ctx, root, children)
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func TestExecute_OTelEndpointExportsSpans(t *testing.T) {
+	type span struct {
+		Name              string `json:"name"`
+		ParentSpanID      string `json:"parentSpanId"`
+		StartTimeUnixNano string `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string `json:"endTimeUnixNano"`
+		Status            struct {
+			Code int `json:"code"`
+		} `json:"status"`
+	}
+	var spans []span
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []span `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		spans = req.ResourceSpans[0].ScopeSpans[0].Spans
+	}))
+	defer srv.Close()
+
+	run := func(workDir string, extra ...string) []byte {
+		t.Helper()
+		writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+			{Name: "a", Run: "echo a > a.out", Outputs: []string{"a.out"}},
+			{Name: "b", Run: "exit 3"},
+		}, []dag.Edge{{From: "a", To: "b"}})
+		args := append([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--trace", "trace.json"}, extra...)
+		inv, err := ParseInvocation(args)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitGraphFailure {
+			t.Fatalf("exit %d err %v", res.ExitCode, err)
+		}
+		b, err := os.ReadFile(filepath.Join(workDir, "trace.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	plain := run(t.TempDir())
+	exported := run(t.TempDir(), "--otel-endpoint", srv.URL+"/v1/traces")
+	if string(plain) != string(exported) {
+		t.Fatalf("exporting spans changed the trace:\n%s\n%s", plain, exported)
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Name < spans[j].Name })
+	if len(spans) != 3 || spans[0].Name != "a" || spans[1].Name != "b" || spans[2].Name != "scriptweaver run" {
+		t.Fatalf("unexpected spans %+v", spans)
+	}
+	if spans[0].ParentSpanID == "" || spans[2].ParentSpanID != "" {
+		t.Fatalf("task spans must be children of the run span: %+v", spans)
+	}
+	if spans[0].Status.Code != 1 || spans[1].Status.Code != 2 || spans[2].Status.Code != 2 {
+		t.Fatalf("unexpected statuses %+v", spans)
+	}
+	for _, s := range spans {
+		if s.StartTimeUnixNano == "0" || s.EndTimeUnixNano < s.StartTimeUnixNano {
+			t.Fatalf("span %s lacks wall-clock timing: %+v", s.Name, s)
+		}
+	}
+}
+
+func TestExecute_OTelExportFailureDoesNotChangeOutcome(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{{Name: "a", Run: "true"}}, nil)
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--otel-endpoint", srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("exit %d err %v", res.ExitCode, err)
+	}
+}
+
+func TestParseInvocation_OTelEndpointMustBeHTTP(t *testing.T) {
+	for _, endpoint := range []string{"localhost:4318", "grpc://collector:4317", "http://"} {
+		_, err := ParseInvocation([]string{"--workdir", t.TempDir(), "--graph", "g.json", "--cache-dir", "c", "--output-dir", "o", "--otel-endpoint", endpoint})
+		if ExitCode(err) != ExitInvalidInvocation {
+			t.Fatalf("%q: expected invalid invocation, got %v", endpoint, err)
+		}
+	}
+}
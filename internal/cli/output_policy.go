@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"scriptweaver/internal/dag"
+)
+
+// OverwritePolicy selects how OutputDir is prepared before a run.
+type OverwritePolicy string
+
+const (
+	// OverwriteAlways empties OutputDir before every run (the default).
+	OverwriteAlways OverwritePolicy = "always"
+
+	// OverwriteOnConflict keeps OutputDir and removes only what could make
+	// the result differ from OverwriteAlways: entries no task declares as an
+	// output are removed up front, and each task's declared outputs are
+	// cleaned before it executes or pruned to its cached artifacts before a
+	// restore. Files already matching the cache are not rewritten, so fully
+	// cached runs touch almost nothing.
+	OverwriteOnConflict OverwritePolicy = "on-conflict"
+
+	// OverwriteNever leaves OutputDir as it is; stale files may remain.
+	OverwriteNever OverwritePolicy = "never"
+)
+
+func parseOverwritePolicy(raw string) (OverwritePolicy, error) {
+	switch p := OverwritePolicy(strings.ToLower(strings.TrimSpace(raw))); p {
+	case OverwriteAlways, OverwriteOnConflict, OverwriteNever:
+		return p, nil
+	default:
+		return "", invalidInvocationf("invalid --overwrite %q (expected always|on-conflict|never)", raw)
+	}
+}
+
+// applyOverwritePolicy prepares OutputDir for the run under inv.Overwrite.
+//
+// Policies that delete refuse an OutputDir that contains the working
+// directory, the graph, the cache or the .scriptweaver workspace.
+func applyOverwritePolicy(inv CLIInvocation, g *dag.TaskGraph) error {
+	policy := inv.Overwrite
+	if policy == "" {
+		policy = OverwriteAlways
+	}
+	if policy != OverwriteNever {
+		if err := checkOutputDirSafe(inv); err != nil {
+			return err
+		}
+	}
+	switch policy {
+	case OverwriteAlways:
+		return prepareOutputDir(inv.OutputDir)
+	case OverwriteOnConflict:
+		if err := os.MkdirAll(inv.OutputDir, 0o755); err != nil {
+			return fmt.Errorf("create output dir: %w", err)
+		}
+		return removeUndeclared(inv.OutputDir, declaredOutputs(inv.WorkDir, g))
+	case OverwriteNever:
+		info, err := os.Stat(inv.OutputDir)
+		if os.IsNotExist(err) {
+			return os.MkdirAll(inv.OutputDir, 0o755)
+		}
+		if err != nil {
+			return fmt.Errorf("stat output dir: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("output dir is not a directory: %s", inv.OutputDir)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown overwrite policy %q", policy)
+	}
+}
+
+func checkOutputDirSafe(inv CLIInvocation) error {
+	out := filepath.Clean(inv.OutputDir)
+	protected := []struct{ what, path string }{
+		{"working directory", inv.WorkDir},
+		{"graph", inv.GraphPath},
+		{"workspace", filepath.Join(inv.WorkDir, ".scriptweaver")},
+	}
+	if inv.ExecutionMode != ExecutionModeClean {
+		protected = append(protected, struct{ what, path string }{"cache dir", inv.CacheDir})
+	}
+	for _, p := range protected {
+		if p.path != "" && isWithin(out, p.path) {
+			return fmt.Errorf("refusing to overwrite output dir %s: it contains the %s %s", out, p.what, p.path)
+		}
+	}
+	return nil
+}
+
+// isWithin reports whether path is dir or lies under it.
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// declaredOutputs returns every task's declared outputs as absolute paths
+// (or patterns), sorted.
+func declaredOutputs(workDir string, g *dag.TaskGraph) []string {
+	var out []string
+	for _, name := range g.TopologicalOrder() {
+		n, _ := g.Node(name)
+		for _, o := range n.Task.Outputs {
+			if !filepath.IsAbs(o) {
+				o = filepath.Join(workDir, o)
+			}
+			out = append(out, filepath.Clean(o))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// removeUndeclared removes the entries under dir that no declared output
+// covers. Covered entries are left to the runner (see core.Runner.CleanOutputs).
+func removeUndeclared(dir string, outputs []string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read output dir: %w", err)
+	}
+	for _, e := range entries {
+		p := filepath.Join(dir, e.Name())
+		switch {
+		case coveredByOutput(p, outputs):
+			continue
+		case e.IsDir() && leadsToOutput(p, outputs):
+			if err := removeUndeclared(p, outputs); err != nil {
+				return err
+			}
+		default:
+			if err := os.RemoveAll(p); err != nil {
+				return fmt.Errorf("clear output dir: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// coveredByOutput reports whether p is, or lies under, a declared output.
+func coveredByOutput(p string, outputs []string) bool {
+	for _, o := range outputs {
+		if isWithin(o, p) {
+			return true
+		}
+		pc, oc := splitPath(p), splitPath(o)
+		if len(pc) >= len(oc) && matchComponents(oc, pc[:len(oc)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// leadsToOutput reports whether directory p is an ancestor of a declared output.
+func leadsToOutput(p string, outputs []string) bool {
+	pc := splitPath(p)
+	for _, o := range outputs {
+		oc := splitPath(o)
+		if len(oc) > len(pc) && matchComponents(oc[:len(pc)], pc) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitPath(p string) []string {
+	return strings.Split(filepath.ToSlash(filepath.Clean(p)), "/")
+}
+
+// matchComponents matches path components against glob components pairwise.
+func matchComponents(patterns, names []string) bool {
+	for i := range patterns {
+		if ok, err := filepath.Match(patterns[i], names[i]); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"scriptweaver/internal/core"
+)
+
+func listTree(t *testing.T, root string) []string {
+	t.Helper()
+	var out []string
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			rel, _ := filepath.Rel(root, p)
+			out = append(out, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk %s: %v", root, err)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestExecute_OverwriteOnConflict(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "mkdir -p out/dir && echo a > out/a.txt && echo x > out/dir/x.txt", Outputs: []string{"out/a.txt", "out/dir"}},
+		{Name: "b", Run: "mkdir -p out/gen && echo b > out/gen/b.log", Outputs: []string{"out/gen/*.log"}},
+	}, nil)
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--overwrite", "on-conflict"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if inv.Overwrite != OverwriteOnConflict {
+		t.Fatalf("expected on-conflict policy, got %q", inv.Overwrite)
+	}
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("first run: exit %d err %v", res.ExitCode, err)
+	}
+	out := filepath.Join(workDir, "out")
+	want := listTree(t, out)
+
+	// Unchanged cached outputs must not be rewritten.
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(filepath.Join(out, "a.txt"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	// Stale files: undeclared, inside a declared directory, and a glob miss.
+	for _, p := range []string{"stale.txt", "junk/old.bin", "dir/extra.txt", "gen/old.log", "gen/keep.me"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(out, p)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(out, p), []byte("stale"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("second run: exit %d err %v", res.ExitCode, err)
+	}
+	if got := listTree(t, out); !reflect.DeepEqual(got, want) {
+		t.Fatalf("output tree differs from a clean run:\n got %v\nwant %v", got, want)
+	}
+	info, err := os.Stat(filepath.Join(out, "a.txt"))
+	if err != nil || !info.ModTime().Equal(old) {
+		t.Fatalf("expected a.txt left untouched, mtime %v (err=%v)", info.ModTime(), err)
+	}
+}
+
+func TestExecute_OverwriteNeverKeepsStaleFiles(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{{Name: "a", Run: "mkdir -p out && echo a > out/a.txt", Outputs: []string{"out/a.txt"}}}, nil)
+	stale := filepath.Join(workDir, "out", "stale.txt")
+	if err := os.MkdirAll(filepath.Dir(stale), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--overwrite", "never"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatalf("never must keep stale files: %v", err)
+	}
+}
+
+func TestExecute_OverwriteRefusesUnsafeOutputDir(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{{Name: "a", Run: "true"}}, nil)
+	for _, policy := range []string{"always", "on-conflict"} {
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", workDir, "--overwrite", policy})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err == nil || res.ExitCode != ExitConfigError {
+			t.Fatalf("%s: expected config error, got exit %d err %v", policy, res.ExitCode, err)
+		}
+		if _, err := os.Stat(filepath.Join(workDir, "graph.json")); err != nil {
+			t.Fatalf("%s: graph must survive: %v", policy, err)
+		}
+	}
+}
+
+func TestParseInvocation_RejectsUnknownOverwritePolicy(t *testing.T) {
+	_, err := ParseInvocation([]string{"--workdir", t.TempDir(), "--graph", "g", "--cache-dir", "c", "--output-dir", "o", "--overwrite", "sometimes"})
+	if ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected invalid invocation, got %v", err)
+	}
+}
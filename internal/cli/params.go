@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"scriptweaver/internal/core"
+)
+
+// Param types accepted in a graph file's "params" section.
+const (
+	paramTypeString = "string"
+	paramTypeInt    = "int"
+	paramTypeBool   = "bool"
+)
+
+// paramDecl declares a typed graph parameter, e.g.
+//
+//	"params": {"target": {"type": "string", "default": "linux"}, "jobs": {"type": "int"}}
+//
+// A parameter without a default must be supplied with --param. Values are
+// substituted as "${params.<name>}" into task run commands, env values and
+// inputs before hashing, so changing a parameter invalidates exactly the tasks
+// that reference it.
+type paramDecl struct {
+	Type    string `json:"type"`
+	Default any    `json:"default,omitempty"`
+}
+
+var (
+	paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	paramPlaceholder = regexp.MustCompile(`\$\{params\.([^}]*)\}`)
+)
+
+// canonicalParamValue validates raw against typ and returns its canonical
+// string form, so equivalent spellings ("007" vs "7", "TRUE" vs "true") hash
+// identically.
+func canonicalParamValue(typ, raw string) (string, error) {
+	switch typ {
+	case paramTypeString:
+		return raw, nil
+	case paramTypeInt:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("expected int, got %q", raw)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case paramTypeBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return "", fmt.Errorf("expected bool, got %q", raw)
+		}
+		return strconv.FormatBool(b), nil
+	default:
+		return "", fmt.Errorf("unknown type %q", typ)
+	}
+}
+
+// defaultParamValue converts a JSON default to its canonical string form.
+func defaultParamValue(typ string, v any) (string, error) {
+	switch d := v.(type) {
+	case string:
+		if typ != paramTypeString {
+			return "", fmt.Errorf("default %q does not match type %s", d, typ)
+		}
+		return d, nil
+	case float64:
+		if typ != paramTypeInt || d != float64(int64(d)) {
+			return "", fmt.Errorf("default %v does not match type %s", d, typ)
+		}
+		return strconv.FormatInt(int64(d), 10), nil
+	case bool:
+		if typ != paramTypeBool {
+			return "", fmt.Errorf("default %v does not match type %s", d, typ)
+		}
+		return strconv.FormatBool(d), nil
+	default:
+		return "", fmt.Errorf("unsupported default %v", v)
+	}
+}
+
+// applyParams resolves gf's declared params from values (falling back to
+// defaults) and substitutes them into every task. Names of values consumed are
+// recorded in used so the caller can reject parameters no graph declares.
+func applyParams(gf graphFile, values map[string]string, used map[string]bool) (graphFile, error) {
+	resolved := make(map[string]string, len(gf.Params))
+	names := make([]string, 0, len(gf.Params))
+	for name := range gf.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		decl := gf.Params[name]
+		if !paramNamePattern.MatchString(name) {
+			return graphFile{}, fmt.Errorf("parse graph json: invalid param name %q", name)
+		}
+		switch decl.Type {
+		case paramTypeString, paramTypeInt, paramTypeBool:
+		default:
+			return graphFile{}, fmt.Errorf("parse graph json: param %q: unknown type %q (expected string|int|bool)", name, decl.Type)
+		}
+		if raw, ok := values[name]; ok {
+			v, err := canonicalParamValue(decl.Type, raw)
+			if err != nil {
+				return graphFile{}, invalidInvocationf("--param %s: %v", name, err)
+			}
+			resolved[name] = v
+			used[name] = true
+			continue
+		}
+		if decl.Default == nil {
+			return graphFile{}, invalidInvocationf("--param %s is required (no default declared)", name)
+		}
+		v, err := defaultParamValue(decl.Type, decl.Default)
+		if err != nil {
+			return graphFile{}, fmt.Errorf("parse graph json: param %q: %w", name, err)
+		}
+		resolved[name] = v
+	}
+
+	var substErr error
+	subst := func(s string) string {
+		return paramPlaceholder.ReplaceAllStringFunc(s, func(m string) string {
+			name := paramPlaceholder.FindStringSubmatch(m)[1]
+			v, ok := resolved[name]
+			if !ok && substErr == nil {
+				substErr = fmt.Errorf("parse graph json: undeclared param %q", name)
+			}
+			return v
+		})
+	}
+
+	out := gf
+	out.Params = nil
+	out.Tasks = make([]core.Task, len(gf.Tasks))
+	for i, t := range gf.Tasks {
+		t.Run = subst(t.Run)
+		t.Image = subst(t.Image)
+		t.EffectKey = subst(t.EffectKey)
+		if t.Stdin != nil {
+			t.Stdin = &core.Stdin{Text: subst(t.Stdin.Text), File: subst(t.Stdin.File)}
+		}
+		if t.Tools != nil {
+			tools := make([]core.Tool, len(t.Tools))
+			for j, tool := range t.Tools {
+				tools[j] = core.Tool{Name: subst(tool.Name), Version: subst(tool.Version), VersionArgs: tool.VersionArgs, SHA256: subst(tool.SHA256)}
+			}
+			t.Tools = tools
+		}
+		if t.Inputs != nil {
+			inputs := make([]string, len(t.Inputs))
+			for j, in := range t.Inputs {
+				inputs[j] = subst(in)
+			}
+			t.Inputs = inputs
+		}
+		if t.Env != nil {
+			env := make(map[string]string, len(t.Env))
+			for k, v := range t.Env {
+				env[k] = subst(v)
+			}
+			t.Env = env
+		}
+		if t.Service != nil {
+			svc := *t.Service
+			svc.Ready = subst(svc.Ready)
+			t.Service = &svc
+		}
+		// Task names are not substituted, so neither are upstream references.
+		t.When = t.When.Replace(subst, nil)
+		out.Tasks[i] = t
+	}
+	if substErr != nil {
+		return graphFile{}, substErr
+	}
+	return out, nil
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const paramGraphJSON = `{
+	"params": {
+		"target": {"type": "string", "default": "linux"},
+		"jobs": {"type": "int", "default": 4},
+		"release": {"type": "bool"}
+	},
+	"tasks": [
+		{"name": "build", "run": "echo ${params.target} -j${params.jobs}", "env": {"RELEASE": "${params.release}"}},
+		{"name": "lint", "run": "echo lint"}
+	],
+	"edges": []
+}`
+
+func writeParamGraph(t *testing.T, src string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "graph.json")
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatalf("write graph: %v", err)
+	}
+	return p
+}
+
+func TestLoadGraphFromFileWithParams_SubstitutesBeforeHashing(t *testing.T) {
+	p := writeParamGraph(t, paramGraphJSON)
+
+	g, err := LoadGraphFromFileWithParams(p, map[string]string{"release": "TRUE", "jobs": "08"})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	build, _ := g.Node("build")
+	if build.Task.Run != "echo linux -j8" || build.Task.Env["RELEASE"] != "true" {
+		t.Fatalf("unexpected substitution: %+v", build.Task)
+	}
+
+	// Equivalent spellings canonicalize to the same graph.
+	g2, err := LoadGraphFromFileWithParams(p, map[string]string{"release": "1", "jobs": "8"})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if g.Hash() != g2.Hash() {
+		t.Fatalf("canonical param values should hash identically")
+	}
+
+	// Changing a param changes only the tasks that reference it.
+	g3, err := LoadGraphFromFileWithParams(p, map[string]string{"release": "true", "target": "darwin"})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if g.Hash() == g3.Hash() {
+		t.Fatalf("expected graph hash to change with param value")
+	}
+	lint1, _ := g.Node("lint")
+	lint3, _ := g3.Node("lint")
+	if lint1.DefinitionHash != lint3.DefinitionHash {
+		t.Fatalf("unrelated task must not be invalidated")
+	}
+}
+
+func TestLoadGraphFromFileWithParams_Errors(t *testing.T) {
+	p := writeParamGraph(t, paramGraphJSON)
+	cases := []struct {
+		params map[string]string
+		want   string
+		code   int
+	}{
+		{params: nil, want: "--param release is required", code: ExitInvalidInvocation},
+		{params: map[string]string{"release": "maybe"}, want: "expected bool", code: ExitInvalidInvocation},
+		{params: map[string]string{"release": "true", "jobs": "x"}, want: "expected int", code: ExitInvalidInvocation},
+		{params: map[string]string{"release": "true", "nope": "1"}, want: "not declared", code: ExitInvalidInvocation},
+	}
+	for _, tc := range cases {
+		_, err := LoadGraphFromFileWithParams(p, tc.params)
+		if err == nil || !strings.Contains(err.Error(), tc.want) || ExitCode(err) != tc.code {
+			t.Fatalf("params %v: expected %q (exit %d), got %v", tc.params, tc.want, tc.code, err)
+		}
+	}
+
+	bad := writeParamGraph(t, `{"params":{"n":{"type":"int","default":"x"}},"tasks":[{"name":"a","run":"echo ${params.n}"}],"edges":[]}`)
+	if _, err := LoadGraphFromFileWithParams(bad, nil); err == nil || !strings.Contains(err.Error(), "does not match type") {
+		t.Fatalf("expected default type error, got %v", err)
+	}
+	undeclared := writeParamGraph(t, `{"tasks":[{"name":"a","run":"echo ${params.n}"}],"edges":[]}`)
+	if _, err := LoadGraphFromFileWithParams(undeclared, nil); err == nil || !strings.Contains(err.Error(), "undeclared param") {
+		t.Fatalf("expected undeclared param error, got %v", err)
+	}
+}
+
+func TestParseInvocation_Params(t *testing.T) {
+	workDir := t.TempDir()
+	base := []string{"--workdir", workDir, "--graph", "g.json", "--cache-dir", "c", "--output-dir", "o"}
+
+	inv, err := ParseInvocation(append(base, "--param", "target=darwin", "--param", "flags=a=b"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inv.Params["target"] != "darwin" || inv.Params["flags"] != "a=b" {
+		t.Fatalf("unexpected params: %v", inv.Params)
+	}
+
+	for _, bad := range [][]string{{"--param", "novalue"}, {"--param", "=x"}, {"--param", "a=1", "--param", "a=2"}} {
+		if _, err := ParseInvocation(append(append([]string(nil), base...), bad...)); ExitCode(err) != ExitInvalidInvocation {
+			t.Fatalf("args %q: expected invalid invocation, got %v", bad, err)
+		}
+	}
+}
+
+func TestExecute_MissingParamIsInvalidInvocation(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	if err := os.WriteFile(graphPath, []byte(paramGraphJSON), 0o644); err != nil {
+		t.Fatalf("write graph: %v", err)
+	}
+	res, err := Execute(context.Background(), CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeClean,
+	})
+	if err == nil || res.ExitCode != ExitInvalidInvocation {
+		t.Fatalf("expected exit %d, got %d (err=%v)", ExitInvalidInvocation, res.ExitCode, err)
+	}
+}
+
+func TestExecute_WhenEqualsParamGatesTask(t *testing.T) {
+	workDir := t.TempDir()
+	graph := `{
+		"params": {"target": {"type": "string", "default": "linux"}},
+		"tasks": [
+			{"name": "mac", "run": "touch mac.txt", "when": {"equals": ["${params.target}", "darwin"]}},
+			{"name": "sign", "run": "touch sign.txt"},
+			{"name": "linux", "run": "touch linux.txt", "when": {"equals": ["${params.target}", "linux"]}}
+		],
+		"edges": [{"From": "mac", "To": "sign"}]
+	}`
+	if err := os.WriteFile(filepath.Join(workDir, "graph.json"), []byte(graph), 0o644); err != nil {
+		t.Fatalf("write graph: %v", err)
+	}
+	args := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"}
+	dry, err := ParseInvocation(append(args, "--dry-run"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	res, err := Execute(context.Background(), dry)
+	if err != nil || res.DryRun == nil {
+		t.Fatalf("dry run: exit %d err %v", res.ExitCode, err)
+	}
+	for _, task := range res.DryRun.Tasks {
+		skipped := task.Action == DryRunSkip && task.Reason == DryRunReasonConditionFalse
+		if skipped != (task.Name != "linux") {
+			t.Fatalf("unexpected dry-run outcome for %s: %+v", task.Name, task)
+		}
+	}
+
+	inv, err := ParseInvocation(args)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+	}
+	for name, want := range map[string]bool{"mac.txt": false, "sign.txt": false, "linux.txt": true} {
+		if _, err := os.Stat(filepath.Join(workDir, name)); (err == nil) != want {
+			t.Fatalf("%s exists=%v, want %v", name, err == nil, want)
+		}
+	}
+}
+
+func TestLoadGraph_ExpandsTaskNameAndKeepsPathPlaceholders(t *testing.T) {
+	p := writeParamGraph(t, `{
+		"tasks": [
+			{"name": "a", "run": "echo ${task} > ${workdir}/${task}.txt", "env": {"TASK": "${task}", "OUT": "${output_dir}"}},
+			{"name": "b", "run": "echo ${task} > ${workdir}/${task}.txt", "env": {"TASK": "${task}", "OUT": "${output_dir}"}}
+		],
+		"matrix": [{
+			"task": {"name": "m-${os}", "run": "echo ${task} $${task} > ${workdir}/${os}.txt", "env": {"TASK": "${task}", "OUT": "${output_dir}"}},
+			"params": {"os": ["linux"]}
+		}],
+		"edges": []
+	}`)
+
+	g, err := LoadGraphFromFile(p)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	a, _ := g.Node("a")
+	b, _ := g.Node("b")
+	if a.Task.Run != "echo a > ${workdir}/a.txt" || a.Task.Env["TASK"] != "a" || a.Task.Env["OUT"] != "${output_dir}" {
+		t.Fatalf("unexpected expansion: %+v", a.Task)
+	}
+	m, ok := g.Node("m-linux")
+	if !ok {
+		t.Fatalf("missing matrix task m-linux")
+	}
+	if m.Task.Run != "echo m-linux ${task} > ${workdir}/linux.txt" || m.Task.Env["TASK"] != "m-linux" || m.Task.Env["OUT"] != "${output_dir}" {
+		t.Fatalf("unexpected matrix expansion: %+v", m.Task)
+	}
+	// Expanded names make otherwise identical tasks distinct work.
+	if a.DefinitionHash == b.DefinitionHash {
+		t.Fatalf("tasks differing by ${task} should not share a definition hash")
+	}
+}
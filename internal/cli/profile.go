@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+)
+
+// Profile file names written under CLIInvocation.ProfileDir.
+const (
+	cpuProfileName  = "cpu.pprof"
+	heapProfileName = "heap.pprof"
+)
+
+// startProfiling starts a CPU profile in dir and returns a function that
+// stops it and writes a heap profile taken after a GC. Both cover the whole
+// process, so scheduling, hashing and cache IO show up alongside each other;
+// time spent inside task commands does not.
+func startProfiling(dir string) (func() error, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("profile dir: %w", err)
+	}
+	cpu, err := os.Create(filepath.Join(dir, cpuProfileName))
+	if err != nil {
+		return nil, fmt.Errorf("profile dir: %w", err)
+	}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		_ = cpu.Close()
+		return nil, fmt.Errorf("cpu profile: %w", err)
+	}
+	return func() error {
+		pprof.StopCPUProfile()
+		if err := cpu.Close(); err != nil {
+			return err
+		}
+		heap, err := os.Create(filepath.Join(dir, heapProfileName))
+		if err != nil {
+			return err
+		}
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(heap); err != nil {
+			_ = heap.Close()
+			return err
+		}
+		return heap.Close()
+	}, nil
+}
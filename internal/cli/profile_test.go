@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+func TestExecute_ProfileDirWritesCPUAndHeapProfiles(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{{Name: "a", Run: "true"}}, nil)
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--profile-dir", "prof"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if inv.ProfileDir != filepath.Join(workDir, "prof") {
+		t.Fatalf("ProfileDir = %q", inv.ProfileDir)
+	}
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+	}
+	for _, name := range []string{cpuProfileName, heapProfileName} {
+		info, err := os.Stat(filepath.Join(workDir, "prof", name))
+		if err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+		if info.Size() == 0 {
+			t.Fatalf("%s is empty", name)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"scriptweaver/internal/dag"
+)
+
+// projectGraphRef references one member graph of a project manifest.
+//
+// Path is resolved relative to the directory containing the manifest.
+type projectGraphRef struct {
+	Namespace string `json:"namespace"`
+	Path      string `json:"path"`
+}
+
+// namespaceSeparator joins a namespace and a member-local task name.
+const namespaceSeparator = "/"
+
+// mergeProject loads every member graph of manifest and merges them into a
+// single graph definition.
+//
+// Task names become "<namespace>/<name>" and member-local edges are rewritten
+// the same way. Manifest edges are cross-graph edges and must already use
+// qualified names. Members are merged in namespace order so the result does
+// not depend on declaration order; NewTaskGraph canonicalizes the rest.
+//
+// Task inputs and outputs are not rewritten: they stay relative to the
+// working directory, exactly as for a single graph file. Members are read with
+// load, which applies the same per-file processing as the top-level file.
+func mergeProject(manifestPath string, manifest graphFile, load func(path string) (graphFile, error)) (graphFile, error) {
+	refs := append([]projectGraphRef(nil), manifest.Graphs...)
+	seen := make(map[string]struct{}, len(refs))
+	for _, ref := range refs {
+		if err := validateNamespace(ref.Namespace); err != nil {
+			return graphFile{}, err
+		}
+		if _, ok := seen[ref.Namespace]; ok {
+			return graphFile{}, fmt.Errorf("parse project: duplicate namespace %q", ref.Namespace)
+		}
+		seen[ref.Namespace] = struct{}{}
+		if ref.Path == "" {
+			return graphFile{}, fmt.Errorf("parse project: namespace %q has empty path", ref.Namespace)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Namespace < refs[j].Namespace })
+
+	baseDir := filepath.Dir(manifestPath)
+	var merged graphFile
+	for _, ref := range refs {
+		p := ref.Path
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(baseDir, p)
+		}
+		member, err := load(p)
+		if err != nil {
+			return graphFile{}, fmt.Errorf("namespace %q: %w", ref.Namespace, err)
+		}
+		if len(member.Graphs) > 0 {
+			return graphFile{}, fmt.Errorf("namespace %q: nested project manifests are not supported", ref.Namespace)
+		}
+		if member.Hooks != nil {
+			return graphFile{}, fmt.Errorf("namespace %q: hooks must be declared by the project manifest", ref.Namespace)
+		}
+		if len(member.Includes) > 0 {
+			return graphFile{}, fmt.Errorf("namespace %q: includes must be declared by the project manifest", ref.Namespace)
+		}
+		if len(member.Tasks) == 0 {
+			return graphFile{}, fmt.Errorf("namespace %q: parse graph json: no tasks", ref.Namespace)
+		}
+		for _, t := range member.Tasks {
+			t.Name = qualifyTaskName(ref.Namespace, t.Name)
+			// Member barriers are already edges; the label keeps its namespace.
+			if t.Group != "" {
+				t.Group = qualifyTaskName(ref.Namespace, t.Group)
+			}
+			t.When = t.When.Replace(nil, func(name string) string {
+				return qualifyTaskName(ref.Namespace, name)
+			})
+			merged.Tasks = append(merged.Tasks, t)
+		}
+		for _, e := range member.Edges {
+			merged.Edges = append(merged.Edges, dag.Edge{
+				From: qualifyTaskName(ref.Namespace, e.From),
+				To:   qualifyTaskName(ref.Namespace, e.To),
+			})
+		}
+	}
+	merged.Edges = append(merged.Edges, manifest.Edges...)
+	// Manifest-wide rules run before each member file's own.
+	merged.Normalize = manifest.Normalize
+	// A member's own pin was applied when it loaded and takes precedence.
+	merged.SourceDateEpoch = manifest.SourceDateEpoch
+	merged, err := applySourceDateEpoch(merged)
+	if err != nil {
+		return graphFile{}, err
+	}
+	return applyGraphNormalize(merged), nil
+}
+
+func qualifyTaskName(namespace, name string) string {
+	return namespace + namespaceSeparator + name
+}
+
+// validateNamespace accepts [A-Za-z0-9_-]+ so qualified names stay unambiguous.
+func validateNamespace(ns string) error {
+	if ns == "" {
+		return fmt.Errorf("parse project: empty namespace")
+	}
+	for _, c := range ns {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '-':
+		default:
+			return fmt.Errorf("parse project: invalid namespace %q", ns)
+		}
+	}
+	return nil
+}
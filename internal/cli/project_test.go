@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func writeProjectFixture(t *testing.T, dir string, manifest string) string {
+	t.Helper()
+	writeGraphJSON(t, filepath.Join(dir, "build.json"),
+		[]core.Task{{Name: "compile", Run: "echo compile"}, {Name: "link", Run: "echo link"}},
+		[]dag.Edge{{From: "compile", To: "link"}},
+	)
+	writeGraphJSON(t, filepath.Join(dir, "test.json"),
+		[]core.Task{{Name: "unit", Run: "echo unit"}},
+		nil,
+	)
+	p := filepath.Join(dir, "project.json")
+	if err := os.WriteFile(p, []byte(manifest), 0o644); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	return p
+}
+
+func TestLoadGraphFromFile_ProjectMergesNamespacedGraphs(t *testing.T) {
+	dir := t.TempDir()
+	p := writeProjectFixture(t, dir, `{
+		"graphs": [
+			{"namespace": "test", "path": "test.json"},
+			{"namespace": "build", "path": "build.json"}
+		],
+		"edges": [{"From": "build/link", "To": "test/unit"}]
+	}`)
+
+	g, err := LoadGraphFromFile(p)
+	if err != nil {
+		t.Fatalf("load project: %v", err)
+	}
+	for _, name := range []string{"build/compile", "build/link", "test/unit"} {
+		if _, ok := g.Node(name); !ok {
+			t.Fatalf("missing task %q", name)
+		}
+	}
+	edges := make(map[dag.Edge]bool)
+	for _, e := range g.Edges() {
+		edges[e] = true
+	}
+	if !edges[dag.Edge{From: "build/link", To: "test/unit"}] {
+		t.Fatalf("cross-graph edge missing: %v", g.Edges())
+	}
+	if !edges[dag.Edge{From: "build/compile", To: "build/link"}] {
+		t.Fatalf("local edge not qualified: %v", g.Edges())
+	}
+
+	// Declaration order of members must not affect the graph hash.
+	dir2 := t.TempDir()
+	p2 := writeProjectFixture(t, dir2, `{
+		"graphs": [
+			{"namespace": "build", "path": "build.json"},
+			{"namespace": "test", "path": "test.json"}
+		],
+		"edges": [{"From": "build/link", "To": "test/unit"}]
+	}`)
+	g2, err := LoadGraphFromFile(p2)
+	if err != nil {
+		t.Fatalf("load project: %v", err)
+	}
+	if g.Hash() != g2.Hash() {
+		t.Fatalf("graph hash depends on member order: %s vs %s", g.Hash(), g2.Hash())
+	}
+}
+
+func TestLoadGraphFromFile_ProjectRejectsInvalidManifests(t *testing.T) {
+	cases := map[string]struct {
+		manifest string
+		want     string
+	}{
+		"duplicate namespace": {
+			manifest: `{"graphs":[{"namespace":"build","path":"build.json"},{"namespace":"build","path":"test.json"}]}`,
+			want:     "duplicate namespace",
+		},
+		"invalid namespace": {
+			manifest: `{"graphs":[{"namespace":"a/b","path":"build.json"}]}`,
+			want:     "invalid namespace",
+		},
+		"tasks alongside graphs": {
+			manifest: `{"tasks":[{"name":"x","run":"true"}],"graphs":[{"namespace":"build","path":"build.json"}]}`,
+			want:     "must not declare tasks",
+		},
+		"unqualified cross edge": {
+			manifest: `{"graphs":[{"namespace":"build","path":"build.json"}],"edges":[{"From":"link","To":"build/compile"}]}`,
+			want:     "link",
+		},
+		"nested project": {
+			manifest: `{"graphs":[{"namespace":"inner","path":"project.json"}]}`,
+			want:     "nested",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := writeProjectFixture(t, t.TempDir(), tc.manifest)
+			_, err := LoadGraphFromFile(p)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+// toolVersionTimeout bounds each "<tool> --version" probe.
+const toolVersionTimeout = 5 * time.Second
+
+// collectProvenance describes the environment of run runID. Probes are
+// best-effort: a tool that cannot be queried is recorded as "unavailable".
+func collectProvenance(inv CLIInvocation, runID, graphHash string, g *dag.TaskGraph) state.Provenance {
+	p := state.Provenance{
+		RunID:       runID,
+		CreatedAt:   time.Now().UTC(),
+		ToolVersion: toolVersion(),
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		Mode:        state.ExecutionMode(inv.ExecutionMode),
+		GraphPath:   inv.OriginalGraph,
+		GraphHash:   graphHash,
+		Params:      inv.Params,
+	}
+	if b, err := os.ReadFile(inv.GraphPath); err == nil {
+		sum := sha256.Sum256(b)
+		p.GraphFileSHA256 = hex.EncodeToString(sum[:])
+	}
+	if usesContainers(g) {
+		p.Tools = map[string]string{inv.ContainerEngine: probeToolVersion(inv.ContainerEngine)}
+	}
+	return p
+}
+
+// toolVersion is the scriptweaver module version, "(devel)" for local builds.
+func toolVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+func usesContainers(g *dag.TaskGraph) bool {
+	for _, name := range g.TopologicalOrder() {
+		n, _ := g.Node(name)
+		if n.Task.Image != "" || n.Task.NormalizedKind() == core.KindContainer {
+			return true
+		}
+	}
+	return false
+}
+
+// probeToolVersion returns the first line of "<tool> --version".
+func probeToolVersion(tool string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), toolVersionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, tool, "--version").Output()
+	if err != nil {
+		return "unavailable"
+	}
+	line, _, _ := strings.Cut(string(out), "\n")
+	if v := strings.TrimSpace(line); v != "" {
+		return v
+	}
+	return "unavailable"
+}
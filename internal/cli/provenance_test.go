@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/recovery/state"
+)
+
+func TestExecute_ProvenanceIsRecordedOutsideTheTrace(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{{Name: "a", Run: "true"}}, nil)
+	run := func(extra ...string) CLIResult {
+		t.Helper()
+		inv, err := ParseInvocation(append([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--mode", "clean"}, extra...))
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+		}
+		return res
+	}
+
+	plain := run()
+	st, _ := state.NewStore(workDir)
+	ids, _ := st.ListRunIDs()
+	if _, err := st.LoadProvenance(ids[0]); !os.IsNotExist(err) {
+		t.Fatalf("provenance must be opt-in, got err=%v", err)
+	}
+
+	withProvenance := run("--provenance")
+	if !bytes.Equal(plain.GraphResult.TraceBytes, withProvenance.GraphResult.TraceBytes) {
+		t.Fatalf("provenance must not affect the trace")
+	}
+	ids2, _ := st.ListRunIDs()
+	var p state.Provenance
+	found := false
+	for _, id := range ids2 {
+		if id == ids[0] {
+			continue
+		}
+		var err error
+		if p, err = st.LoadProvenance(id); err != nil {
+			t.Fatalf("load provenance of %s: %v", id, err)
+		}
+		found = true
+	}
+	if !found {
+		t.Fatalf("expected a second run, got %v", ids2)
+	}
+	_, graphHash, err := loadGraphAndHash(filepath.Join(workDir, "graph.json"), nil)
+	if err != nil {
+		t.Fatalf("load graph: %v", err)
+	}
+	if p.GraphHash != graphHash || p.GraphPath != "graph.json" || p.GraphFileSHA256 == "" {
+		t.Fatalf("unexpected graph provenance: %+v", p)
+	}
+	if p.OS != runtime.GOOS || p.Arch != runtime.GOARCH || p.GoVersion != runtime.Version() || p.ToolVersion == "" || p.Mode != state.ExecutionModeClean {
+		t.Fatalf("unexpected environment provenance: %+v", p)
+	}
+	if p.Tools != nil {
+		t.Fatalf("no container tasks, so no tools expected: %v", p.Tools)
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+func TestExecute_ResumeMergesCheckpointsAcrossRuns(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{
+		{Name: "a", Run: "echo a > a.txt", Outputs: []string{"a.txt"}},
+		{Name: "b", Inputs: []string{"a.txt"}, Run: "cat a.txt > b.txt", Outputs: []string{"b.txt"}},
+		{Name: "c", Inputs: []string{"b.txt", "gate.txt"}, Run: "grep -q open gate.txt"},
+	}, []dag.Edge{{From: "a", To: "b"}, {From: "b", To: "c"}})
+	if err := os.WriteFile(filepath.Join(workDir, "gate.txt"), []byte("closed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	inv := CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	}
+	res, err := Execute(context.Background(), inv)
+	if err != nil || res.ExitCode != ExitGraphFailure {
+		t.Fatalf("first run: exit %d err %v", res.ExitCode, err)
+	}
+	st, err := state.NewStore(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := st.LoadRun(res.Metrics.RunID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A later run of the same graph crashed after checkpointing only a, so
+	// it is the latest failed run.
+	crash := "crash"
+	if err := st.SaveRun(state.Run{RunID: crash, GraphHash: first.GraphHash, StartTime: first.StartTime.Add(time.Minute), Mode: state.ExecutionModeIncremental, Status: "failed"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SaveFailure(crash, state.Failure{FailureClass: state.FailureClassSystem, ErrorCode: "Crash", ErrorMessage: "crash", Resumable: true}); err != nil {
+		t.Fatal(err)
+	}
+	cp, err := st.LoadCheckpoint(first.RunID, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp.Timestamp = cp.Timestamp.Add(time.Minute)
+	if err := st.SaveCheckpoint(crash, cp); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(workDir, "gate.txt"), []byte("open\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resumeOnly := inv
+	resumeOnly.ExecutionMode = ExecutionModeResumeOnly
+	res, err = Execute(context.Background(), resumeOnly)
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("resume: exit %d err %v", res.ExitCode, err)
+	}
+	report, err := st.LoadResumeReport(res.Metrics.RunID)
+	if err != nil {
+		t.Fatalf("LoadResumeReport: %v", err)
+	}
+	// b's checkpoint comes from the first run, so the resume gets past it.
+	if report.Decision != state.ResumeResumed || report.PreviousRunID != crash || report.Checkpoints != 2 || report.CheckpointNode != "b" {
+		t.Fatalf("report = %+v", report)
+	}
+}
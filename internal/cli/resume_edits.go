@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+// previousRunAcrossEdits returns the run --resume-across-edits resumes: the
+// latest failed run of any graph, skipping rejected resume attempts, which
+// executed nothing and would hide the run the user meant to resume.
+func previousRunAcrossEdits(st *state.Store) (string, error) {
+	r, err := latestFailedRun(st, func(r state.Run) bool {
+		f, err := st.LoadFailure(r.RunID)
+		return err == nil && !rejectedResumeCodes[f.ErrorCode]
+	})
+	return r.RunID, err
+}
+
+// editedGraphCheckpoints matches the checkpoints of a run of the graph with
+// prevGraphHash to the edited graph g. A task of g keeps its checkpoint when
+// the previous graph had a task of the same name with the same subtree hash,
+// that is the same definition and the same upstream closure; the others are
+// returned, sorted, as edited. The task hash check of buildResumePlan still
+// applies to every kept checkpoint.
+func editedGraphCheckpoints(st *state.Store, g *dag.TaskGraph, prevGraphHash string, checkpoints map[string]state.Checkpoint) (map[string]state.Checkpoint, []string, error) {
+	x, err := st.LoadCheckpointIndex(prevGraphHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(x.Subtrees) == 0 {
+		return nil, nil, fmt.Errorf("no run of graph %s recorded its task subtrees", prevGraphHash)
+	}
+	kept := make(map[string]state.Checkpoint, len(checkpoints))
+	var edited []string
+	for name, h := range subtreeHashes(g) {
+		if x.Subtrees[name] != h {
+			edited = append(edited, name)
+			continue
+		}
+		if cp, ok := checkpoints[name]; ok {
+			kept[name] = cp
+		}
+	}
+	sort.Strings(edited)
+	return kept, edited, nil
+}
+
+// subtreeHashes maps each task of g to its subtree hash.
+func subtreeHashes(g *dag.TaskGraph) map[string]string {
+	hashes := make(map[string]string, len(g.Nodes()))
+	for _, n := range g.Nodes() {
+		h, _ := g.SubtreeHash(n.Name)
+		hashes[n.Name] = h.String()
+	}
+	return hashes
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+func TestExecute_ResumeAcrossEditsReusesUnchangedTasks(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	chain := []core.Task{
+		{Name: "a", Run: "echo a > a.txt", Outputs: []string{"a.txt"}},
+		{Name: "b", Inputs: []string{"a.txt"}, Run: "cat a.txt > b.txt", Outputs: []string{"b.txt"}},
+		{Name: "c", Inputs: []string{"b.txt"}, Run: "exit 1"},
+	}
+	edges := []dag.Edge{{From: "a", To: "b"}, {From: "b", To: "c"}}
+	writeGraphJSON(t, graphPath, chain, edges)
+	inv := CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	}
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitGraphFailure {
+		t.Fatalf("first run: exit %d err %v", res.ExitCode, err)
+	}
+
+	// Fix c and add d after it: a and b keep their definitions and
+	// upstream closures.
+	chain[2].Run = "cat b.txt > c.txt"
+	chain[2].Outputs = []string{"c.txt"}
+	chain = append(chain, core.Task{Name: "d", Inputs: []string{"c.txt"}, Run: "cat c.txt > d.txt", Outputs: []string{"d.txt"}})
+	writeGraphJSON(t, graphPath, chain, append(edges, dag.Edge{From: "c", To: "d"}))
+
+	strict := inv
+	strict.ExecutionMode = ExecutionModeResumeOnly
+	if res, _ := Execute(context.Background(), strict); res.ExitCode != ExitConfigError {
+		t.Fatalf("resume-only without --resume-across-edits: exit %d", res.ExitCode)
+	}
+
+	strict.ResumeAcrossEdits = true
+	res, err := Execute(context.Background(), strict)
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("resume across edits: exit %d err %v", res.ExitCode, err)
+	}
+	st, err := state.NewStore(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := st.LoadResumeReport(res.Metrics.RunID)
+	if err != nil {
+		t.Fatalf("LoadResumeReport: %v", err)
+	}
+	if report.Decision != state.ResumeResumed || report.GraphHashMatched || report.CheckpointNode != "b" || report.InvalidatedNode != "c" {
+		t.Fatalf("report = %+v", report)
+	}
+	for _, n := range report.Nodes {
+		if (n.Name == "c" || n.Name == "d") && n.Reason != "changed by the graph edit" {
+			t.Fatalf("node %s: reason %q", n.Name, n.Reason)
+		}
+	}
+	if got, err := os.ReadFile(filepath.Join(workDir, "d.txt")); err != nil || string(got) != "a\n" {
+		t.Fatalf("d.txt = %q, %v", got, err)
+	}
+
+	if _, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--mode", "clean", "--resume-across-edits"}); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("--resume-across-edits with --mode clean: err = %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/incremental"
+)
+
+// steerResumePlan moves plan's resume point to from (--resume-from): every
+// task upstream of from must be reusable from its checkpoint, and from and
+// everything downstream of it execute fresh. Tasks unrelated to from keep
+// their planned decisions. Services are ignored upstream, as in
+// buildResumePlan, since they are never checkpointed. An empty from leaves
+// plan as it is.
+//
+// from executes fresh, so its own invalidation is cleared from invMap and
+// does not block resume eligibility.
+func steerResumePlan(g *dag.TaskGraph, plan *incremental.IncrementalPlan, invMap incremental.InvalidationMap, from string) error {
+	if from == "" {
+		return nil
+	}
+	upstream := make(map[string][]string)
+	for _, e := range g.Edges() {
+		upstream[e.To] = append(upstream[e.To], e.From)
+	}
+	ancestors := reachable(upstream, from)
+
+	for _, name := range g.TopologicalOrder() {
+		if !ancestors[name] {
+			continue
+		}
+		if n, _ := g.Node(name); n.Task.NormalizedKind() == core.KindService {
+			continue
+		}
+		if plan.Decisions[name] != incremental.DecisionReuseCache {
+			return fmt.Errorf("cannot resume from %q: upstream task %q has no reusable checkpoint", from, name)
+		}
+	}
+	for name := range downstreamClosure(g, from) {
+		plan.Decisions[name] = incremental.DecisionExecute
+	}
+	invMap[from] = incremental.InvalidationEntry{}
+	return nil
+}
+
+// downstreamClosure returns roots and every task downstream of them.
+func downstreamClosure(g *dag.TaskGraph, roots ...string) map[string]bool {
+	downstream := make(map[string][]string)
+	for _, e := range g.Edges() {
+		downstream[e.From] = append(downstream[e.From], e.To)
+	}
+	closure := make(map[string]bool)
+	for _, root := range roots {
+		closure[root] = true
+		for name := range reachable(downstream, root) {
+			closure[name] = true
+		}
+	}
+	return closure
+}
+
+// reachable returns the tasks reachable from start along next, excluding
+// start itself.
+func reachable(next map[string][]string, start string) map[string]bool {
+	seen := make(map[string]bool)
+	stack := append([]string(nil), next[start]...)
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		stack = append(stack, next[n]...)
+	}
+	return seen
+}
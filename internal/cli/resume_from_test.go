@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/trace"
+)
+
+func TestExecute_ResumeFrom_RestoresUpstreamAndRerunsFromTask(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "echo a >> a.log && echo a > a.out", Outputs: []string{"a.out"}},
+		{Name: "b", Inputs: []string{"a.out"}, Run: "echo b >> b.log && cat a.out > b.out", Outputs: []string{"b.out"}},
+		{Name: "c", Inputs: []string{"b.out"}, Run: "test -f go.flag && echo c >> c.log"},
+	}, []dag.Edge{{From: "a", To: "b"}, {From: "b", To: "c"}})
+	run := func(extra ...string) (CLIResult, error) {
+		t.Helper()
+		args := append([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--trace", "trace.json"}, extra...)
+		inv, err := ParseInvocation(args)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		return Execute(context.Background(), inv)
+	}
+	logLines := func(file string) int {
+		t.Helper()
+		b, _ := os.ReadFile(filepath.Join(workDir, file))
+		return strings.Count(string(b), "\n")
+	}
+
+	if res, err := run(); err != nil || res.ExitCode != ExitGraphFailure {
+		t.Fatalf("first run: exit %d err %v", res.ExitCode, err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "go.flag"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err := run("--resume-from", "b")
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("resume: exit %d err %v", res.ExitCode, err)
+	}
+	// a is restored; b re-executes although its checkpoint is reusable.
+	if got := []int{logLines("a.log"), logLines("b.log"), logLines("c.log")}; got[0] != 1 || got[1] != 2 || got[2] != 1 {
+		t.Fatalf("a/b/c executions = %v, want [1 2 1]", got)
+	}
+	tr, err := os.ReadFile(filepath.Join(workDir, "trace.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := trace.ParseExecutionTrace(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[string]trace.TraceEventKind{}
+	for _, ev := range parsed.Events {
+		if ev.Kind != trace.EventTaskCached {
+			kinds[ev.TaskID] = ev.Kind
+		}
+	}
+	if kinds["a"] != trace.EventTaskArtifactsRestored || kinds["b"] != trace.EventTaskExecuted || kinds["c"] != trace.EventTaskExecuted {
+		t.Fatalf("unexpected trace events %v: %s", kinds, tr)
+	}
+}
+
+func TestExecute_ResumeFrom_RequiresReusableUpstream(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "echo a > a.out", Outputs: []string{"a.out"}},
+		{Name: "b", Inputs: []string{"a.out"}, Run: "exit 1"},
+		{Name: "c", Run: "true"},
+	}, []dag.Edge{{From: "a", To: "b"}, {From: "b", To: "c"}})
+	run := func(from string) (CLIResult, error) {
+		t.Helper()
+		args := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"}
+		if from != "" {
+			args = append(args, "--resume-from", from)
+		}
+		inv, err := ParseInvocation(args)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		return Execute(context.Background(), inv)
+	}
+
+	if res, err := run(""); err != nil || res.ExitCode != ExitGraphFailure {
+		t.Fatalf("first run: exit %d err %v", res.ExitCode, err)
+	}
+	res, err := run("c")
+	if err == nil || res.ExitCode != ExitConfigError || !strings.Contains(err.Error(), `upstream task "b"`) {
+		t.Fatalf("expected b to block resuming from c, got exit %d err %v", res.ExitCode, err)
+	}
+	if rep := DescribeError(res, err); rep.Code != "ResumeIneligible" || rep.NodeID != "c" {
+		t.Fatalf("report = %+v", rep)
+	}
+	res, err = run("missing")
+	if err == nil || res.ExitCode != ExitInvalidInvocation {
+		t.Fatalf("expected an unknown task to be an invocation error, got exit %d err %v", res.ExitCode, err)
+	}
+}
+
+func TestParseInvocation_ResumeFromRejectsCleanMode(t *testing.T) {
+	_, err := ParseInvocation([]string{"--workdir", t.TempDir(), "--graph", "g.json", "--cache-dir", "c", "--output-dir", "o", "--mode", "clean", "--resume-from", "a"})
+	if ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected invalid invocation, got %v", err)
+	}
+}
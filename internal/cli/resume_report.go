@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"log/slog"
+
+	"scriptweaver/internal/incremental"
+	"scriptweaver/internal/recovery/state"
+)
+
+// resumeNodes reports plan's decision for every task in order, with the
+// reasons in notes for tasks that are not reused. It also returns the last
+// task of the reused prefix and the first task after it.
+func resumeNodes(order []string, plan *incremental.IncrementalPlan, notes map[string]string) (nodes []state.ResumeNodeReport, checkpointNode, invalidatedNode string) {
+	prefix := true
+	for _, name := range order {
+		n := state.ResumeNodeReport{Name: name, Reused: plan.Decisions[name] == incremental.DecisionReuseCache}
+		if !n.Reused {
+			n.Reason = notes[name]
+			if n.Reason == "" {
+				n.Reason = "no reusable checkpoint"
+			}
+		}
+		switch {
+		case prefix && n.Reused:
+			checkpointNode = name
+		case prefix:
+			invalidatedNode = name
+			prefix = false
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, checkpointNode, invalidatedNode
+}
+
+// emitResumeReport logs r and saves it in its run directory. A run that
+// could have resumed but does not is reported at info level, since the
+// fallback to scratch execution is otherwise silent; other reports are
+// debug records. Saving is best-effort.
+func emitResumeReport(st *state.Store, logger *slog.Logger, r state.ResumeReport) {
+	level := slog.LevelDebug
+	if r.PreviousRunFound && r.Decision != state.ResumeResumed {
+		level = slog.LevelInfo
+	}
+	args := []any{
+		"decision", string(r.Decision),
+		"previous_run_found", r.PreviousRunFound,
+		"previous_run", r.PreviousRunID,
+		"graph_hash_matched", r.GraphHashMatched,
+		"checkpoints", r.Checkpoints,
+		"checkpoints_valid", r.CheckpointsValid,
+		"checkpoint_node", r.CheckpointNode,
+		"invalidated_node", r.InvalidatedNode,
+	}
+	for _, n := range r.Nodes {
+		if n.Name == r.InvalidatedNode {
+			args = append(args, "invalidated_reason", n.Reason)
+		}
+	}
+	if r.Reason != "" {
+		args = append(args, "reason", r.Reason)
+	}
+	logger.Log(context.Background(), level, "resume report", args...)
+	if st == nil || r.RunID == "" {
+		return
+	}
+	if err := st.SaveResumeReport(r); err != nil {
+		logger.Warn("resume report not saved", "run_id", r.RunID, "error", err)
+	}
+}
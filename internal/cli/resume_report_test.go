@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+// An incremental run that falls back to scratch execution says why on
+// stderr and in its run directory.
+func TestExecute_ResumeReportExplainsScratchFallback(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Inputs: []string{"src.txt"}, Run: "cat src.txt > a.out", Outputs: []string{"a.out"}},
+		{Name: "b", Inputs: []string{"a.out"}, Run: "exit 1"},
+	}, []dag.Edge{{From: "a", To: "b"}})
+	writeSrc := func(s string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(workDir, "src.txt"), []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func() (CLIResult, string) {
+		t.Helper()
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		var stderr bytes.Buffer
+		inv.stderr = &stderr
+		res, _ := Execute(context.Background(), inv)
+		if res.ExitCode != ExitGraphFailure || res.Metrics == nil {
+			t.Fatalf("exit = %d, want %d; stderr:\n%s", res.ExitCode, ExitGraphFailure, stderr.String())
+		}
+		return res, stderr.String()
+	}
+
+	writeSrc("v1")
+	if _, stderr := run(); strings.Contains(stderr, "resume report") {
+		t.Fatalf("a run with nothing to resume reported at info level:\n%s", stderr)
+	}
+	writeSrc("v2")
+	res, stderr := run()
+	if !strings.Contains(stderr, "scriptweaver: resume report decision=scratch previous_run_found=true") ||
+		!strings.Contains(stderr, `invalidated_node=a invalidated_reason="task hash changed since the checkpoint"`) {
+		t.Fatalf("stderr does not explain the fallback:\n%s", stderr)
+	}
+
+	st, err := state.NewStore(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := st.LoadResumeReport(res.Metrics.RunID)
+	if err != nil {
+		t.Fatalf("LoadResumeReport: %v", err)
+	}
+	if report.Decision != state.ResumeScratch || !report.GraphHashMatched || !report.CheckpointsValid || report.Checkpoints != 1 ||
+		report.CheckpointNode != "" || report.InvalidatedNode != "a" || report.Reason != "no task can be reused" {
+		t.Fatalf("report = %+v", report)
+	}
+	want := []state.ResumeNodeReport{
+		{Name: "a", Reason: "task hash changed since the checkpoint"},
+		{Name: "b", Reason: "no valid checkpoint"},
+	}
+	if len(report.Nodes) != len(want) || report.Nodes[0] != want[0] || report.Nodes[1] != want[1] {
+		t.Fatalf("nodes = %+v, want %+v", report.Nodes, want)
+	}
+}
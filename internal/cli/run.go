@@ -1,6 +1,12 @@
 package cli
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
 
 // Run is a high-level CLI entrypoint suitable for black-box tests.
 // It accepts the argument slice (excluding argv[0]) and returns the semantic
@@ -12,3 +18,50 @@ func Run(ctx context.Context, args []string) (CLIResult, error) {
 	}
 	return Execute(ctx, inv)
 }
+
+// RunCommand runs a run-path invocation (run, plan, cache verify or the flat
+// form; see RunArgs) as the scriptweaver binary does: usage, CI output (see
+// writeCIOutput) and JSON reports go to stdout, error reports to stderr. It
+// returns the exit code.
+func RunCommand(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	return runCommand(ctx, args, stdout, stderr, nil)
+}
+
+// runCommand is RunCommand with the daemon's warm state.
+func runCommand(ctx context.Context, args []string, stdout, stderr io.Writer, warm *warmState) int {
+	command, args := RunArgs(args)
+	inv, err := ParseInvocation(args)
+	var help *HelpRequest
+	if errors.As(err, &help) {
+		_ = WriteUsage(stdout, command)
+		return ExitSuccess
+	}
+	if err != nil {
+		code := ExitCode(err)
+		_ = WriteErrorReport(stderr, DescribeError(CLIResult{ExitCode: code}, err), WantsErrorsJSON(args))
+		return code
+	}
+	inv.warm = warm
+	inv.stderr = stderr
+
+	result, execErr := Execute(ctx, inv)
+	_ = writeCIOutput(stdout, inv, result)
+	if execErr != nil || inv.ErrorsJSON {
+		_ = WriteErrorReport(stderr, DescribeError(result, execErr), inv.ErrorsJSON)
+	}
+	if result.CacheVerify != nil {
+		writeReportJSON(stdout, result.CacheVerify)
+	}
+	if result.DryRun != nil {
+		writeReportJSON(stdout, result.DryRun)
+	}
+	if result.TraceVerify != nil {
+		writeReportJSON(stdout, result.TraceVerify)
+	}
+	return result.ExitCode
+}
+
+func writeReportJSON(w io.Writer, report any) {
+	b, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Fprintln(w, string(b))
+}
@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"scriptweaver/internal/projectintegration/engine/workspace"
+	"scriptweaver/internal/recovery/state"
+)
+
+// RunsPruneInvocation is the canonical form of `scriptweaver runs prune`.
+type RunsPruneInvocation struct {
+	WorkDir   string
+	Retention state.RetentionPolicy
+	// DryRun lists the runs that would be pruned without deleting them.
+	DryRun bool
+}
+
+// ParseRunsPruneInvocation parses the flags following `runs prune`.
+func ParseRunsPruneInvocation(args []string) (RunsPruneInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver runs prune", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	var keep int
+	var maxAge time.Duration
+	var dryRun bool
+
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+	fs.IntVar(&keep, "keep", state.DefaultKeepRuns, "Runs kept per graph (0 = unlimited).")
+	fs.DurationVar(&maxAge, "max-age", 0, "Prune runs older than this, e.g. 720h (0 = unlimited).")
+	fs.BoolVar(&dryRun, "dry-run", false, "List the runs that would be pruned without deleting them.")
+
+	if err := fs.Parse(args); err != nil {
+		return RunsPruneInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 0 {
+		return RunsPruneInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return RunsPruneInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return RunsPruneInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	policy := state.RetentionPolicy{KeepLast: keep, MaxAge: maxAge}
+	if err := policy.Validate(); err != nil {
+		return RunsPruneInvocation{}, invalidInvocationf("%v", err)
+	}
+	return RunsPruneInvocation{WorkDir: workDir, Retention: policy, DryRun: dryRun}, nil
+}
+
+// RunsPrune applies the retention policy to the state store and writes the
+// pruned run IDs to stdout, one per line in sorted order.
+func RunsPrune(inv RunsPruneInvocation, now time.Time, stdout io.Writer) (int, error) {
+	st, err := state.NewStore(inv.WorkDir)
+	if err != nil {
+		return ExitInvalidInvocation, err
+	}
+	var ids []string
+	if inv.DryRun {
+		ids, err = st.SelectPrunable(inv.Retention, now)
+	} else {
+		ws, err := workspace.EnsureWorkspace(inv.WorkDir)
+		if err != nil {
+			return ExitConfigError, err
+		}
+		lock, err := workspace.AcquireLock(ws)
+		if err != nil {
+			return ExitConfigError, err
+		}
+		defer func() { _ = lock.Release() }()
+		ids, err = st.Prune(inv.Retention, now)
+	}
+	for _, id := range ids {
+		fmt.Fprintln(stdout, id)
+	}
+	if err != nil {
+		return ExitConfigError, err
+	}
+	return ExitSuccess, nil
+}
+
+// RunsListInvocation is the canonical form of `scriptweaver runs list`.
+type RunsListInvocation struct {
+	WorkDir string
+	// Labels keeps only runs carrying every one of these labels with the
+	// same value.
+	Labels map[string]string
+}
+
+// RunListEntry is one run in the `runs list` report.
+type RunListEntry struct {
+	RunID     string            `json:"run_id"`
+	GraphHash string            `json:"graph_hash"`
+	StartTime time.Time         `json:"start_time"`
+	Mode      string            `json:"mode"`
+	Status    string            `json:"status"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// ParseRunsListInvocation parses the flags following `runs list`.
+func ParseRunsListInvocation(args []string) (RunsListInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver runs list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	labels := labelFlags{}
+
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+	fs.Var(labels, "label", "Only list runs labelled key=value (repeatable; all must match).")
+
+	if err := fs.Parse(args); err != nil {
+		return RunsListInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 0 {
+		return RunsListInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return RunsListInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return RunsListInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	inv := RunsListInvocation{WorkDir: workDir}
+	if len(labels) > 0 {
+		inv.Labels = labels
+	}
+	return inv, nil
+}
+
+// RunsList writes the recorded runs matching inv's labels to stdout as a
+// JSON array, oldest first (ties broken by run ID). Unreadable run records
+// are skipped.
+func RunsList(inv RunsListInvocation, stdout io.Writer) (int, error) {
+	st, err := state.NewStore(inv.WorkDir)
+	if err != nil {
+		return ExitInvalidInvocation, err
+	}
+	ids, err := st.ListRunIDs()
+	if err != nil {
+		return ExitConfigError, err
+	}
+	entries := []RunListEntry{}
+	for _, id := range ids {
+		r, err := st.LoadRun(id)
+		if err != nil || !hasLabels(r.Labels, inv.Labels) {
+			continue
+		}
+		entries = append(entries, RunListEntry{RunID: r.RunID, GraphHash: r.GraphHash, StartTime: r.StartTime, Mode: string(r.Mode), Status: string(r.Status), Labels: r.Labels})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].StartTime.Equal(entries[j].StartTime) {
+			return entries[i].StartTime.Before(entries[j].StartTime)
+		}
+		return entries[i].RunID < entries[j].RunID
+	})
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return ExitInternalError, err
+	}
+	if _, err := fmt.Fprintln(stdout, string(b)); err != nil {
+		return ExitInternalError, err
+	}
+	return ExitSuccess, nil
+}
+
+// hasLabels reports whether labels carries every pair in want.
+func hasLabels(labels, want map[string]string) bool {
+	for k, v := range want {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+func runRuns(args []string, stdout io.Writer) (int, error) {
+	if len(args) == 0 {
+		return ExitInvalidInvocation, invalidInvocationf("usage: scriptweaver runs list|prune|repair|compact --workdir <dir> [flags]")
+	}
+	switch args[0] {
+	case "list":
+		inv, err := ParseRunsListInvocation(args[1:])
+		if err != nil {
+			return ExitCode(err), err
+		}
+		return RunsList(inv, stdout)
+	case "prune":
+		inv, err := ParseRunsPruneInvocation(args[1:])
+		if err != nil {
+			return ExitCode(err), err
+		}
+		return RunsPrune(inv, time.Now().UTC(), stdout)
+	case "repair":
+		inv, err := ParseRunsRepairInvocation(args[1:])
+		if err != nil {
+			return ExitCode(err), err
+		}
+		return RunsRepair(inv, stdout)
+	case "compact":
+		inv, err := ParseRunsCompactInvocation(args[1:])
+		if err != nil {
+			return ExitCode(err), err
+		}
+		return RunsCompact(inv, stdout)
+	default:
+		return ExitInvalidInvocation, invalidInvocationf("usage: scriptweaver runs list|prune|repair|compact --workdir <dir> [flags]")
+	}
+}
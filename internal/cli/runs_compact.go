@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"scriptweaver/internal/projectintegration/engine/workspace"
+	"scriptweaver/internal/recovery/state"
+)
+
+// RunsCompactInvocation is the canonical form of `scriptweaver runs compact`.
+type RunsCompactInvocation struct {
+	WorkDir string
+}
+
+// RunsCompactReport is what `scriptweaver runs compact` prints.
+type RunsCompactReport struct {
+	// Graphs lists the rebuilt checkpoint indexes, sorted by graph hash.
+	Graphs []RunsCompactGraph `json:"graphs"`
+}
+
+// RunsCompactGraph summarizes the checkpoint index of one graph.
+type RunsCompactGraph struct {
+	GraphHash string `json:"graph_hash"`
+	Runs      int    `json:"runs"`
+	Nodes     int    `json:"nodes"`
+}
+
+// ParseRunsCompactInvocation parses the flags following `runs compact`.
+func ParseRunsCompactInvocation(args []string) (RunsCompactInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver runs compact", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+
+	if err := fs.Parse(args); err != nil {
+		return RunsCompactInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 0 {
+		return RunsCompactInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return RunsCompactInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return RunsCompactInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	if _, err := applyConfig(fs, workDir, nil); err != nil {
+		return RunsCompactInvocation{}, err
+	}
+	return RunsCompactInvocation{WorkDir: workDir}, nil
+}
+
+// RunsCompact rebuilds the checkpoint index of every graph from the runs in
+// the workspace, so each holds the newest valid checkpoint per node, and
+// drops the indexes of graphs whose runs are all gone. Runs fold themselves
+// into the index as they finish; compacting is only needed after runs were
+// pruned or their state was edited by hand. The report is written to stdout
+// as JSON.
+func RunsCompact(inv RunsCompactInvocation, stdout io.Writer) (int, error) {
+	st, err := state.NewStore(inv.WorkDir)
+	if err != nil {
+		return ExitInvalidInvocation, err
+	}
+	ws, err := workspace.EnsureWorkspace(inv.WorkDir)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	lock, err := workspace.AcquireLock(ws)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	defer func() { _ = lock.Release() }()
+
+	indexes, err := st.CompactCheckpoints()
+	if err != nil {
+		return ExitConfigError, err
+	}
+	report := RunsCompactReport{Graphs: []RunsCompactGraph{}}
+	for _, x := range indexes {
+		report.Graphs = append(report.Graphs, RunsCompactGraph{GraphHash: x.GraphHash, Runs: len(x.Runs), Nodes: len(x.Nodes)})
+	}
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return ExitInternalError, err
+	}
+	if _, err := fmt.Fprintln(stdout, string(b)); err != nil {
+		return ExitInternalError, err
+	}
+	return ExitSuccess, nil
+}
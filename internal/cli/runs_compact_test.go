@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+func TestRunsCompact_RebuildsCheckpointIndex(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{
+		{Name: "a", Run: "echo a > a.txt", Outputs: []string{"a.txt"}},
+		{Name: "b", Inputs: []string{"a.txt"}, Run: "cat a.txt > b.txt", Outputs: []string{"b.txt"}},
+	}, []dag.Edge{{From: "a", To: "b"}})
+	inv := CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	}
+	for i := 0; i < 2; i++ {
+		if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run %d: exit %d err %v", i, res.ExitCode, err)
+		}
+	}
+
+	// Both runs fold into one entry per unchanged task.
+	st, err := state.NewStore(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := st.ListRunIDs()
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("runs = %v, %v", ids, err)
+	}
+	r, err := st.LoadRun(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := st.LoadCheckpointIndex(r.GraphHash)
+	if err != nil || len(x.Runs) != 2 || len(x.Nodes) != 2 || len(x.Nodes["a"].Runs) != 2 {
+		t.Fatalf("index = %+v err=%v", x, err)
+	}
+
+	var out bytes.Buffer
+	code, handled, err := Dispatch([]string{"runs", "compact", "--workdir", workDir}, &out)
+	if !handled || err != nil || code != ExitSuccess {
+		t.Fatalf("runs compact: handled=%v code=%d err=%v", handled, code, err)
+	}
+	var report RunsCompactReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("report: %v\n%s", err, out.String())
+	}
+	if len(report.Graphs) != 1 || report.Graphs[0] != (RunsCompactGraph{GraphHash: r.GraphHash, Runs: 2, Nodes: 2}) {
+		t.Fatalf("report = %+v", report)
+	}
+	if _, err := ParseRunsCompactInvocation([]string{"--workdir", "rel"}); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("relative --workdir: err = %v", err)
+	}
+}
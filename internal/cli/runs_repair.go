@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/projectintegration/engine/workspace"
+	"scriptweaver/internal/recovery/state"
+)
+
+// RunsRepairInvocation is the canonical form of `scriptweaver runs repair`.
+type RunsRepairInvocation struct {
+	WorkDir  string
+	CacheDir string
+	// CacheNamespace is the namespace of runs that recorded none, as for
+	// --verify-cache.
+	CacheNamespace string
+	// CacheSigning.Trusted, when set, also quarantines entries not signed by
+	// one of the keys.
+	CacheSigning CacheSigning
+	// DryRun reports what would be quarantined without moving anything.
+	DryRun bool
+}
+
+// RunsRepairReport is what `scriptweaver runs repair` prints.
+type RunsRepairReport struct {
+	// Runs lists the runs with damaged state, sorted by run ID.
+	Runs   []RunsRepairRun `json:"runs"`
+	DryRun bool            `json:"dry_run"`
+}
+
+// RunsRepairRun is the state of one run moved, or with --dry-run to be
+// moved, into Quarantine (relative to the working directory).
+type RunsRepairRun struct {
+	RunID      string                  `json:"run_id"`
+	Quarantine string                  `json:"quarantine"`
+	Entries    []state.QuarantineEntry `json:"entries"`
+}
+
+// ParseRunsRepairInvocation parses the flags following `runs repair`.
+func ParseRunsRepairInvocation(args []string) (RunsRepairInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver runs repair", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	var cacheDir string
+	var cacheNamespace string
+	var trustedKeys stringListFlag
+	var dryRun bool
+
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+	fs.StringVar(&cacheDir, "cache-dir", "", "Cache directory holding the runs' entries. Required.")
+	fs.StringVar(&cacheNamespace, "cache-namespace", "", "Namespace of --cache-dir for runs that recorded none, as for a run.")
+	fs.Var(&trustedKeys, "cache-trusted-key", "PEM ed25519 public key whose cache entries are trusted (repeatable); entries not signed by a trusted key are quarantined.")
+	fs.BoolVar(&dryRun, "dry-run", false, "Report the damaged state without moving it; exit 3 if there is any.")
+
+	if err := fs.Parse(args); err != nil {
+		return RunsRepairInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 0 {
+		return RunsRepairInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return RunsRepairInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return RunsRepairInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	if _, err := applyConfig(fs, workDir, nil); err != nil {
+		return RunsRepairInvocation{}, err
+	}
+	if cacheDir == "" {
+		return RunsRepairInvocation{}, invalidInvocationf("--cache-dir is required")
+	}
+	signing, err := parseCacheSigning(workDir, "", trustedKeys)
+	if err != nil {
+		return RunsRepairInvocation{}, err
+	}
+	namespace, err := core.ParseCacheNamespace(cacheNamespace)
+	if err != nil {
+		return RunsRepairInvocation{}, invalidInvocationf("--cache-namespace: %v", err)
+	}
+	inv := RunsRepairInvocation{WorkDir: workDir, CacheNamespace: namespace, CacheSigning: signing, DryRun: dryRun}
+	if inv.CacheDir, err = resolveUnderWorkDir(workDir, cacheDir); err != nil {
+		return RunsRepairInvocation{}, err
+	}
+	return inv, nil
+}
+
+// rejectedResumeCodes are the failure codes of a run that stopped because
+// it could not resume. Such a run executed nothing, so once it has no
+// checkpoints it is quarantined too.
+var rejectedResumeCodes = map[string]bool{"ResumeIneligible": true, "WorkspaceCorrupt": true}
+
+// runRepair is the damaged state found in one run.
+type runRepair struct {
+	id string
+	// run is set when the whole run directory is quarantined: its record
+	// is unreadable, or it is a rejected resume attempt.
+	run         bool
+	checkpoints []string
+	cache       []checkpointRef
+	entries     []state.QuarantineEntry
+}
+
+// RunsRepair moves the state that makes resuming fail into
+// .scriptweaver/quarantine/<run>: run directories whose run.json cannot be
+// read, checkpoints that cannot be loaded or whose cache entry is missing,
+// corrupt or untrusted, those cache entries themselves, and the runs of
+// rejected resume attempts, which would otherwise be resumed from instead. What is left is
+// consistent, so a resumed run reuses the remaining checkpoints and executes
+// the tasks whose checkpoints were quarantined.
+//
+// Every run is scanned before anything moves, so a read error leaves the
+// workspace untouched. Each move is a rename, and a run's checkpoints move
+// before the cache entries they reference, so an interrupted repair never
+// leaves a checkpoint pointing at a quarantined entry; running repair again
+// finishes the job. The checkpoint indexes are then rebuilt from what is
+// left. Each run's quarantine keeps a repair.json log of what was
+// moved and why. The report is written to stdout as JSON.
+func RunsRepair(inv RunsRepairInvocation, stdout io.Writer) (int, error) {
+	st, err := state.NewStore(inv.WorkDir)
+	if err != nil {
+		return ExitInvalidInvocation, err
+	}
+	if !inv.DryRun {
+		ws, err := workspace.EnsureWorkspace(inv.WorkDir)
+		if err != nil {
+			return ExitConfigError, err
+		}
+		lock, err := workspace.AcquireLock(ws)
+		if err != nil {
+			return ExitConfigError, err
+		}
+		defer func() { _ = lock.Release() }()
+	}
+	ids, err := st.ListRunIDs()
+	if err != nil {
+		return ExitConfigError, err
+	}
+
+	caches := make(map[string]*core.FileCache)
+	type refKey struct{ hash, namespace string }
+	verified := make(map[refKey]error)
+	claimed := make(map[refKey]bool)
+	var repairs []*runRepair
+	for _, id := range ids {
+		r := &runRepair{id: id}
+		if _, err := st.LoadRun(id); err != nil {
+			r.run = true
+			r.entries = append(r.entries, state.QuarantineEntry{Kind: state.QuarantinedRun, Reason: "run record unreadable: " + err.Error()})
+			repairs = append(repairs, r)
+			continue
+		}
+		files, err := st.ScanCheckpoints(id)
+		if err != nil {
+			return ExitConfigError, fmt.Errorf("run %s: %w", id, err)
+		}
+		if f, err := st.LoadFailure(id); err == nil && rejectedResumeCodes[f.ErrorCode] && len(files) == 0 {
+			r.run = true
+			r.entries = append(r.entries, state.QuarantineEntry{Kind: state.QuarantinedRun, Reason: fmt.Sprintf("resume attempt rejected (%s); as the latest failed run it hides the run it tried to resume", f.ErrorCode)})
+			repairs = append(repairs, r)
+			continue
+		}
+		namespace := recordedCacheNamespace(st, CLIInvocation{CacheNamespace: inv.CacheNamespace}, id)
+		cache, ok := caches[namespace]
+		if !ok {
+			cache = core.NewFileCache(core.NamespacedCacheDir(inv.CacheDir, namespace))
+			inv.CacheSigning.apply(cache)
+			caches[namespace] = cache
+		}
+		for _, f := range files {
+			if f.Err != nil {
+				r.checkpoints = append(r.checkpoints, f.Name)
+				r.entries = append(r.entries, state.QuarantineEntry{Kind: state.QuarantinedCheckpoint, Node: f.NodeID, Reason: "checkpoint unreadable: " + f.Err.Error()})
+				continue
+			}
+			for _, key := range f.Checkpoint.CacheKeys {
+				k := refKey{key, namespace}
+				verr, ok := verified[k]
+				if !ok {
+					verr = cache.Verify(core.TaskHash(key))
+					verified[k] = verr
+				}
+				if verr == nil {
+					continue
+				}
+				var corrupt *core.CacheCorruptError
+				var untrusted *core.CacheSignatureError
+				var reason string
+				switch {
+				case errors.Is(verr, core.ErrCacheEntryMissing):
+					reason = "cache entry missing"
+				case errors.As(verr, &corrupt):
+					reason = "cache entry corrupt: " + corrupt.Reason
+				case errors.As(verr, &untrusted):
+					reason = "cache entry untrusted: " + untrusted.Reason
+				default:
+					return ExitConfigError, fmt.Errorf("run %s: checkpoint %s: %w", id, f.NodeID, verr)
+				}
+				r.checkpoints = append(r.checkpoints, f.Name)
+				r.entries = append(r.entries, state.QuarantineEntry{Kind: state.QuarantinedCheckpoint, Node: f.NodeID, Hash: key, Namespace: namespace, Reason: reason})
+				// The first run referencing a damaged entry takes it into
+				// its quarantine.
+				if !errors.Is(verr, core.ErrCacheEntryMissing) && !claimed[k] {
+					claimed[k] = true
+					r.cache = append(r.cache, checkpointRef{hash: key, namespace: namespace})
+					r.entries = append(r.entries, state.QuarantineEntry{Kind: state.QuarantinedCacheEntry, Hash: key, Namespace: namespace, Reason: strings.TrimPrefix(reason, "cache entry ")})
+				}
+				break
+			}
+		}
+		if len(r.entries) > 0 {
+			repairs = append(repairs, r)
+		}
+	}
+
+	report := RunsRepairReport{Runs: []RunsRepairRun{}, DryRun: inv.DryRun}
+	for _, r := range repairs {
+		dir := st.QuarantineDir(r.id)
+		if rel, err := filepath.Rel(inv.WorkDir, dir); err == nil {
+			dir = rel
+		}
+		report.Runs = append(report.Runs, RunsRepairRun{RunID: r.id, Quarantine: filepath.ToSlash(dir), Entries: r.entries})
+		if inv.DryRun {
+			continue
+		}
+		if err := applyRunRepair(st, caches, r); err != nil {
+			return ExitConfigError, fmt.Errorf("run %s: %w", r.id, err)
+		}
+	}
+	if !inv.DryRun && len(repairs) > 0 {
+		// The checkpoint indexes may still serve what was just quarantined.
+		if _, err := st.CompactCheckpoints(); err != nil {
+			return ExitConfigError, err
+		}
+	}
+
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return ExitInternalError, err
+	}
+	if _, err := fmt.Fprintln(stdout, string(b)); err != nil {
+		return ExitInternalError, err
+	}
+	if inv.DryRun && len(report.Runs) > 0 {
+		return ExitConfigError, nil
+	}
+	return ExitSuccess, nil
+}
+
+// applyRunRepair moves r's damaged state into its quarantine and logs it.
+func applyRunRepair(st *state.Store, caches map[string]*core.FileCache, r *runRepair) error {
+	if r.run {
+		if err := st.QuarantineRun(r.id); err != nil {
+			return err
+		}
+		return st.AppendQuarantineLog(r.id, r.entries)
+	}
+	for _, name := range r.checkpoints {
+		if err := st.QuarantineCheckpoint(r.id, name); err != nil {
+			return err
+		}
+	}
+	for _, ref := range r.cache {
+		dst := filepath.Join(st.QuarantineDir(r.id), "cache", ref.namespace, ref.hash)
+		if err := caches[ref.namespace].MoveEntry(core.TaskHash(ref.hash), dst); err != nil && !errors.Is(err, core.ErrCacheEntryMissing) {
+			return err
+		}
+	}
+	return st.AppendQuarantineLog(r.id, r.entries)
+}
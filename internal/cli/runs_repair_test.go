@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+)
+
+func TestRunsRepair_QuarantinesCorruptStateSoResumeProceeds(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	tasks := []core.Task{
+		{Name: "a", Run: "echo a > a.txt", Outputs: []string{"a.txt"}},
+		{Name: "b", Run: "echo b > b.txt", Outputs: []string{"b.txt"}},
+		{Name: "c", Inputs: []string{"a.txt", "b.txt", "gate.txt"}, Run: "grep -q open gate.txt"},
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "gate.txt"), []byte("closed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeGraphJSON(t, graphPath, tasks, []dag.Edge{{From: "a", To: "c"}, {From: "b", To: "c"}})
+	inv := CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	}
+	res, err := Execute(context.Background(), inv)
+	if err != nil || res.ExitCode != ExitGraphFailure {
+		t.Fatalf("first run: exit %d err %v", res.ExitCode, err)
+	}
+	st, err := state.NewStore(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := st.ListRunIDs()
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("runs = %v, %v", ids, err)
+	}
+	runID := ids[0]
+
+	// Damage b's cache entry and add an unreadable checkpoint.
+	bHash := res.GraphResult.TaskHashes["b"]
+	cache := core.NewFileCache(inv.CacheDir)
+	if err := os.WriteFile(filepath.Join(inv.CacheDir, string(bHash)[:2], string(bHash), "metadata.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cpDir := filepath.Join(workDir, ".scriptweaver", "runs", runID, "checkpoints")
+	if err := os.WriteFile(filepath.Join(cpDir, "ghost.json"), []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resumeOnly := inv
+	resumeOnly.ExecutionMode = ExecutionModeResumeOnly
+	if res, _ := Execute(context.Background(), resumeOnly); res.ExitCode != ExitConfigError {
+		t.Fatalf("resume-only over corrupt state: exit %d", res.ExitCode)
+	}
+
+	var out bytes.Buffer
+	code, _, err := Dispatch([]string{"runs", "repair", "--workdir", workDir, "--cache-dir", "cache", "--dry-run"}, &out)
+	if code != ExitConfigError || err != nil {
+		t.Fatalf("dry run: code=%d err=%v", code, err)
+	}
+	if _, err := os.Stat(filepath.Join(cpDir, "b.json")); err != nil {
+		t.Fatalf("dry run moved a checkpoint: %v", err)
+	}
+
+	out.Reset()
+	code, _, err = Dispatch([]string{"runs", "repair", "--workdir", workDir, "--cache-dir", "cache"}, &out)
+	if code != ExitSuccess || err != nil {
+		t.Fatalf("repair: code=%d err=%v", code, err)
+	}
+	var report RunsRepairReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	// The rejected resume-only run is quarantined whole.
+	var kinds []string
+	for _, run := range report.Runs {
+		for _, e := range run.Entries {
+			if run.RunID != runID {
+				e.Node = "rejected"
+			}
+			kinds = append(kinds, e.Kind+":"+e.Node+e.Hash)
+		}
+	}
+	slices.Sort(kinds)
+	want := []string{
+		state.QuarantinedCacheEntry + ":" + string(bHash),
+		state.QuarantinedCheckpoint + ":b" + string(bHash),
+		state.QuarantinedCheckpoint + ":ghost",
+		state.QuarantinedRun + ":rejected",
+	}
+	if len(report.Runs) != 2 || !slices.Equal(kinds, want) {
+		t.Fatalf("report = %s", out.String())
+	}
+	quarantine := filepath.Join(workDir, ".scriptweaver", "quarantine", runID)
+	for _, p := range []string{"checkpoints/b.json", "checkpoints/ghost.json", "cache/" + string(bHash) + "/metadata.json", "repair.json"} {
+		if _, err := os.Stat(filepath.Join(quarantine, p)); err != nil {
+			t.Fatalf("quarantine missing %s: %v", p, err)
+		}
+	}
+	if ok, _ := cache.Has(bHash); ok {
+		t.Fatalf("corrupt entry still served")
+	}
+
+	// The remaining checkpoint of a is reused; b executes again.
+	if err := os.WriteFile(filepath.Join(workDir, "gate.txt"), []byte("open\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = Execute(context.Background(), resumeOnly)
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("resume after repair: exit %d err %v", res.ExitCode, err)
+	}
+	outcomes := map[string]state.TaskOutcome{}
+	for _, m := range res.Metrics.Tasks {
+		outcomes[m.NodeID] = m.Outcome
+	}
+	if outcomes["a"] != state.TaskOutcomeCached || outcomes["b"] != state.TaskOutcomeExecuted {
+		t.Fatalf("outcomes = %v", outcomes)
+	}
+
+	// Nothing is left to repair.
+	out.Reset()
+	if code, _, err := Dispatch([]string{"runs", "repair", "--workdir", workDir, "--cache-dir", "cache", "--dry-run"}, &out); code != ExitSuccess || err != nil {
+		t.Fatalf("second dry run: code=%d err=%v\n%s", code, err, out.String())
+	}
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/recovery/state"
+)
+
+func TestRetention_PrunesAtRunStartAndViaCommand(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{{Name: "t1", Run: "echo hi"}}, nil)
+
+	args := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--keep-runs", "2"}
+	inv, err := ParseInvocation(args)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run %d: exit=%d err=%v", i, res.ExitCode, err)
+		}
+	}
+	st, err := state.NewStore(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := st.ListRunIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two kept by the policy plus the run that started after pruning.
+	if len(ids) != 3 {
+		t.Fatalf("expected 3 runs after pruning at run start, got %v", ids)
+	}
+
+	var dry bytes.Buffer
+	code, handled, err := Dispatch([]string{"runs", "prune", "--workdir", workDir, "--keep", "1", "--dry-run"}, &dry)
+	if !handled || err != nil || code != ExitSuccess {
+		t.Fatalf("dry run: handled=%v code=%d err=%v", handled, code, err)
+	}
+	listed := strings.Fields(dry.String())
+	if len(listed) != 2 {
+		t.Fatalf("expected 2 prunable runs, got %q", dry.String())
+	}
+	if after, _ := st.ListRunIDs(); len(after) != 3 {
+		t.Fatalf("dry run must not delete, got %v", after)
+	}
+
+	var out bytes.Buffer
+	if code, _, err := Dispatch([]string{"runs", "prune", "--workdir", workDir, "--keep", "1"}, &out); err != nil || code != ExitSuccess {
+		t.Fatalf("prune: code=%d err=%v", code, err)
+	}
+	if !reflect.DeepEqual(strings.Fields(out.String()), listed) {
+		t.Fatalf("prune deleted %q, dry run listed %q", out.String(), listed)
+	}
+	if after, _ := st.ListRunIDs(); len(after) != 1 {
+		t.Fatalf("expected one run left, got %v", after)
+	}
+
+	if _, err := ParseInvocation(append(args, "--keep-runs", "-1")); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected negative --keep-runs to be rejected, got %v", err)
+	}
+	if _, _, err := Dispatch([]string{"runs", "compact"}, &out); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected usage error, got %v", err)
+	}
+}
+
+func TestExecute_GraphRunIDsAreReproducible(t *testing.T) {
+	runIDs := func(runs int) []string {
+		t.Helper()
+		workDir := t.TempDir()
+		writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{{Name: "t1", Run: "echo hi"}}, nil)
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--run-id-strategy", "graph"})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		for i := 0; i < runs; i++ {
+			if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+				t.Fatalf("run %d: exit=%d err=%v", i, res.ExitCode, err)
+			}
+		}
+		st, err := state.NewStore(workDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids, err := st.ListRunIDs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ids
+	}
+
+	first, second := runIDs(2), runIDs(2)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("identical reruns got different run IDs: %v vs %v", first, second)
+	}
+	if len(first) != 2 || !strings.HasSuffix(first[0], "-000001") || !strings.HasSuffix(first[1], "-000002") {
+		t.Fatalf("expected sequential graph run IDs, got %v", first)
+	}
+}
+
+func TestRunsList_FiltersByLabel(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{{Name: "t1", Run: "echo hi"}}, nil)
+	for _, labels := range [][]string{
+		{"--label", "branch=main", "--label", "sha=abc123"},
+		{"--label", "branch=feature"},
+		nil,
+	} {
+		args := append([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"}, labels...)
+		inv, err := ParseInvocation(args)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run: exit=%d err=%v", res.ExitCode, err)
+		}
+	}
+
+	list := func(args ...string) []RunListEntry {
+		t.Helper()
+		var out bytes.Buffer
+		code, _, err := Dispatch(append([]string{"runs", "list", "--workdir", workDir}, args...), &out)
+		if err != nil || code != ExitSuccess {
+			t.Fatalf("runs list: code=%d err=%v", code, err)
+		}
+		var entries []RunListEntry
+		if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+			t.Fatalf("decode %q: %v", out.String(), err)
+		}
+		return entries
+	}
+
+	if all := list(); len(all) != 3 {
+		t.Fatalf("expected 3 runs, got %+v", all)
+	}
+	main := list("--label", "branch=main")
+	if len(main) != 1 || !reflect.DeepEqual(main[0].Labels, map[string]string{"branch": "main", "sha": "abc123"}) || main[0].Status != string(state.RunStatusSucceeded) {
+		t.Fatalf("unexpected runs for branch=main: %+v", main)
+	}
+	if none := list("--label", "branch=main", "--label", "sha=other"); len(none) != 0 {
+		t.Fatalf("expected every label to have to match, got %+v", none)
+	}
+
+	if _, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--label", "bad key=x"}); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected an invalid label key to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+	"scriptweaver/internal/trace"
+)
+
+func TestExecute_ServiceIsRestartedButNotCheckpointed(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{
+			Name:    "db",
+			Kind:    core.KindService,
+			Run:     "trap 'rm -f db.up; exit 0' TERM; echo start >> db.log; touch db.up; while :; do sleep 0.05; done",
+			Service: &core.ServiceConfig{Ready: "test -f db.up", ReadyIntervalMillis: 10},
+		},
+		{Name: "it", Run: "test -f db.up && echo it >> it.log && echo ok > it.out", Outputs: []string{"it.out"}},
+	}, []dag.Edge{{From: "db", To: "it"}})
+	run := func() CLIResult {
+		t.Helper()
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--trace", "trace.json"})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+		}
+		return res
+	}
+
+	run()
+	st, _ := state.NewStore(workDir)
+	ids, _ := st.ListRunIDs()
+	checkpoints, err := st.LoadAllCheckpoints(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := checkpoints["db"]; ok {
+		t.Fatalf("services must not be checkpointed, got %v", checkpoints)
+	}
+
+	// The second run restarts the service; its dependent is still cached.
+	run()
+	for file, want := range map[string]int{"db.log": 2, "it.log": 1} {
+		b, err := os.ReadFile(filepath.Join(workDir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(string(b), "\n"); n != want {
+			t.Fatalf("%s has %d lines, want %d", file, n, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "db.up")); !os.IsNotExist(err) {
+		t.Fatalf("expected the service to be torn down, stat err=%v", err)
+	}
+	tr, err := os.ReadFile(filepath.Join(workDir, "trace.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := trace.ParseExecutionTrace(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ready, stopped bool
+	for _, ev := range parsed.Events {
+		if ev.TaskID != "db" {
+			continue
+		}
+		ready = ready || (ev.Kind == trace.EventTaskExecuted && ev.Reason == trace.ReasonServiceReady)
+		stopped = stopped || ev.Kind == trace.EventServiceStopped
+	}
+	if !ready || !stopped {
+		t.Fatalf("expected db ServiceReady and ServiceStopped events: %s", tr)
+	}
+}
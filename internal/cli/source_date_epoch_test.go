@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExecute_GraphPinsSourceDateEpoch(t *testing.T) {
+	workDir := t.TempDir()
+	graph := `{
+		"source_date_epoch": 1700000000,
+		"tasks": [
+			{"name": "pinned", "run": "mkdir -p out && echo \"$SOURCE_DATE_EPOCH $TZ $LC_ALL\" > out/pinned.txt", "outputs": ["out/pinned.txt"]},
+			{"name": "own", "run": "mkdir -p out && echo \"$SOURCE_DATE_EPOCH\" > out/own.txt", "outputs": ["out/own.txt"], "env": {"SOURCE_DATE_EPOCH": "1"}}
+		]
+	}`
+	if err := os.WriteFile(filepath.Join(workDir, "graph.json"), []byte(graph), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "build"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	res, err := Execute(context.Background(), inv)
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+	}
+	for file, want := range map[string]string{"pinned.txt": "1700000000 UTC C\n", "own.txt": "1\n"} {
+		got, err := os.ReadFile(filepath.Join(workDir, "out", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Fatalf("%s = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestLoadGraphFromFile_RejectsNegativeSourceDateEpoch(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "graph.json")
+	src := `{"source_date_epoch": -1, "tasks": [{"name": "a", "run": "true"}]}`
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadGraphFromFile(p); err == nil {
+		t.Fatal("expected a negative source_date_epoch to be rejected")
+	}
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"scriptweaver/internal/recovery/state"
+	"scriptweaver/internal/stats"
+)
+
+// StatsExportInvocation is the canonical form of `scriptweaver stats export`.
+type StatsExportInvocation struct {
+	WorkDir string
+	// Since is the number of most recent runs to aggregate; 0 means all runs.
+	Since  int
+	Format string
+	// OutputPath is empty when the report is written to stdout.
+	OutputPath string
+}
+
+// ParseStatsExportInvocation parses the flags following `stats export`.
+//
+// As with ParseInvocation, --workdir is required and absolute and relative
+// paths resolve under it.
+func ParseStatsExportInvocation(args []string) (StatsExportInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver stats export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	var since int
+	var format string
+	var output string
+
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+	fs.IntVar(&since, "since", 0, "Aggregate the N most recent runs (0 = all runs).")
+	fs.StringVar(&format, "format", stats.FormatJSON, "Output format: json|csv")
+	fs.StringVar(&output, "output", "", "Output path (optional, defaults to stdout).")
+
+	if err := fs.Parse(args); err != nil {
+		return StatsExportInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 0 {
+		return StatsExportInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return StatsExportInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return StatsExportInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	if since < 0 {
+		return StatsExportInvocation{}, invalidInvocationf("--since must be >= 0 (got %d)", since)
+	}
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format != stats.FormatJSON && format != stats.FormatCSV {
+		return StatsExportInvocation{}, invalidInvocationf("invalid --format %q (expected json|csv)", format)
+	}
+
+	inv := StatsExportInvocation{WorkDir: workDir, Since: since, Format: format}
+	if strings.TrimSpace(output) != "" {
+		resolved, err := resolveUnderWorkDir(workDir, output)
+		if err != nil {
+			return StatsExportInvocation{}, err
+		}
+		inv.OutputPath = resolved
+	}
+	return inv, nil
+}
+
+// StatsExport aggregates recorded runs and writes the report to OutputPath,
+// or to stdout when no path is given.
+func StatsExport(inv StatsExportInvocation, stdout io.Writer) (int, error) {
+	st, err := state.NewStore(inv.WorkDir)
+	if err != nil {
+		return ExitInvalidInvocation, err
+	}
+	rep, err := stats.Collect(st, inv.Since)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	var buf bytes.Buffer
+	if err := rep.Write(&buf, inv.Format); err != nil {
+		return ExitInternalError, err
+	}
+	if inv.OutputPath == "" {
+		if _, err := stdout.Write(buf.Bytes()); err != nil {
+			return ExitInternalError, err
+		}
+		return ExitSuccess, nil
+	}
+	if err := os.WriteFile(inv.OutputPath, buf.Bytes(), 0o644); err != nil {
+		return ExitConfigError, fmt.Errorf("write stats: %w", err)
+	}
+	return ExitSuccess, nil
+}
+
+func runStats(args []string, stdout io.Writer) (int, error) {
+	if len(args) == 0 || args[0] != "export" {
+		return ExitInvalidInvocation, invalidInvocationf("usage: scriptweaver stats export --workdir <dir> [--since <n runs>] [--format json|csv] [--output <path>]")
+	}
+	inv, err := ParseStatsExportInvocation(args[1:])
+	if err != nil {
+		return ExitCode(err), err
+	}
+	return StatsExport(inv, stdout)
+}
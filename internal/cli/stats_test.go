@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/recovery/state"
+	"scriptweaver/internal/stats"
+)
+
+func TestStatsExport_AggregatesRecordedRuns(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{{Name: "t1", Run: "echo hi"}}, nil)
+
+	inv := CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	}
+	for i := 0; i < 2; i++ {
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run %d: exit=%d err=%v", i, res.ExitCode, err)
+		}
+	}
+
+	var out bytes.Buffer
+	code, handled, err := Dispatch([]string{"stats", "export", "--workdir", workDir, "--since", "5"}, &out)
+	if !handled || err != nil || code != ExitSuccess {
+		t.Fatalf("stats export: handled=%v code=%d err=%v", handled, code, err)
+	}
+	var rep stats.Report
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out.String())
+	}
+	if rep.Runs != 2 || rep.RunsWithMetrics != 2 || len(rep.Tasks) != 1 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	if ts := rep.Tasks[0]; ts.Task != "t1" || ts.Executed != 1 || ts.Cached != 1 || ts.CacheHitRate != 0.5 {
+		t.Fatalf("unexpected task stats: %+v", ts)
+	}
+}
+
+func TestParseStatsExportInvocation_Validation(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"--workdir", "relative"},
+		{"--workdir", "/abs", "--since", "-1"},
+		{"--workdir", "/abs", "--format", "xml"},
+		{"--workdir", "/abs", "extra"},
+	} {
+		if _, err := ParseStatsExportInvocation(args); ExitCode(err) != ExitInvalidInvocation {
+			t.Fatalf("args %q: expected invalid invocation, got %v", args, err)
+		}
+	}
+
+	inv, err := ParseStatsExportInvocation([]string{"--workdir", "/abs", "--format", "CSV", "--output", "stats.csv"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inv.Format != stats.FormatCSV || inv.OutputPath != filepath.Join("/abs", "stats.csv") {
+		t.Fatalf("unexpected invocation: %+v", inv)
+	}
+
+	if _, handled, _ := Dispatch([]string{"--workdir", "/abs"}, &bytes.Buffer{}); handled {
+		t.Fatalf("flags must not be treated as a subcommand")
+	}
+}
+
+func TestCacheStats_ReportsRecordedRuns(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{{Name: "t1", Run: "echo hi"}}, nil)
+
+	inv := CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	}
+	for i := 0; i < 2; i++ {
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run %d: exit=%d err=%v", i, res.ExitCode, err)
+		}
+		if c := res.Metrics.Cache; c == nil || c.Lookups == 0 || c.Hits+c.Misses != c.Lookups {
+			t.Fatalf("run %d: unexpected cache metrics %+v", i, c)
+		}
+	}
+
+	var out bytes.Buffer
+	code, handled, err := Dispatch([]string{"cache", "stats", "--workdir", workDir}, &out)
+	if !handled || err != nil || code != ExitSuccess {
+		t.Fatalf("cache stats: handled=%v code=%d err=%v", handled, code, err)
+	}
+	var rep stats.CacheReport
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out.String())
+	}
+	if rep.Runs != 2 || len(rep.PerRun) != 2 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	if tot := rep.Total; tot.Puts != 1 || tot.Restores != 1 || tot.Attempts != 2 || tot.HitRate != 0.5 || tot.BytesWritten == 0 {
+		t.Fatalf("unexpected total: %+v", tot)
+	}
+
+	if _, handled, _ := Dispatch([]string{"cache", "verify", "--workdir", workDir}, &bytes.Buffer{}); handled {
+		t.Fatalf("cache verify must stay on the run path")
+	}
+}
+
+func TestExecute_WritesTimingLedgerOutsideTrace(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{{Name: "a", Run: "echo a"}, {Name: "b", Run: "echo b"}}, []dag.Edge{{From: "a", To: "b"}})
+
+	res, err := Execute(context.Background(), CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	})
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("exit=%d err=%v", res.ExitCode, err)
+	}
+	if res.Timings == nil || len(res.Timings.Tasks) != 2 || res.Timings.Canonical {
+		t.Fatalf("unexpected timings: %+v", res.Timings)
+	}
+	a, b := res.Timings.Tasks[0], res.Timings.Tasks[1]
+	if a.NodeID != "a" || b.NodeID != "b" || b.StartMillis < a.EndMillis || res.Timings.TotalMillis < b.EndMillis {
+		t.Fatalf("spans do not follow the edge: %+v", res.Timings.Tasks)
+	}
+
+	st, err := state.NewStore(workDir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	loaded, err := st.LoadTimings(res.Metrics.RunID)
+	if err != nil {
+		t.Fatalf("LoadTimings: %v", err)
+	}
+	if len(loaded.Slowest) != 2 {
+		t.Fatalf("unexpected ledger: %+v", loaded)
+	}
+}
+
+func TestExecute_UpdatesResultIndex(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	writeGraphJSON(t, graphPath, []core.Task{{Name: "t1", Run: "echo hi"}}, nil)
+
+	inv := CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      filepath.Join(workDir, "cache"),
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	}
+	st, err := state.NewStore(workDir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	for i, want := range []state.TaskOutcome{state.TaskOutcomeExecuted, state.TaskOutcomeCached} {
+		res, err := Execute(context.Background(), inv)
+		if err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run %d: exit=%d err=%v", i, res.ExitCode, err)
+		}
+		got, ok, err := st.LatestTaskResult(res.GraphResult.GraphHash.String(), "t1")
+		if err != nil || !ok {
+			t.Fatalf("run %d: LatestTaskResult ok=%v err=%v", i, ok, err)
+		}
+		if got.RunID != res.Metrics.RunID || got.Outcome != want || got.TaskHash != string(res.GraphResult.TaskHashes["t1"]) {
+			t.Fatalf("run %d: unexpected result %+v", i, got)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"scriptweaver/internal/dag"
+)
+
+// StatusSnapshot is the body of GET /status on the --status-addr API.
+type StatusSnapshot struct {
+	RunID     string    `json:"run_id,omitempty"`
+	GraphHash string    `json:"graph_hash"`
+	StartTime time.Time `json:"start_time"`
+
+	// Tasks is every task's current state; Counts tallies them.
+	Tasks  map[string]dag.TaskState `json:"tasks"`
+	Counts map[dag.TaskState]int    `json:"counts"`
+
+	// Events is how many events have been recorded so far.
+	Events int `json:"events"`
+}
+
+// StatusEvent is one task state change served by GET /events.
+type StatusEvent struct {
+	// Seq numbers events from 1 in the order they happened.
+	Seq      int               `json:"seq"`
+	Time     time.Time         `json:"time"`
+	Kind     dag.NodeEventKind `json:"kind"`
+	Task     string            `json:"task"`
+	From     dag.TaskState     `json:"from"`
+	To       dag.TaskState     `json:"to"`
+	ExitCode *int              `json:"exit_code,omitempty"`
+}
+
+// TaskStatus is the body of GET /tasks/{name}.
+type TaskStatus struct {
+	Name   string        `json:"name"`
+	State  dag.TaskState `json:"state"`
+	Events []StatusEvent `json:"events"`
+}
+
+// statusServer tracks a run through the executor's node events and serves
+// it read-only over HTTP:
+//
+//	GET /status            StatusSnapshot
+//	GET /events?since=N    events with Seq > N, oldest first
+//	GET /tasks/{name}      TaskStatus
+//
+// It observes the run only and never affects the trace or the outcome.
+type statusServer struct {
+	mu       sync.Mutex
+	snapshot StatusSnapshot
+	events   []StatusEvent
+	now      func() time.Time
+}
+
+func newStatusServer(graph *dag.TaskGraph, runID, graphHash string) *statusServer {
+	s := &statusServer{now: time.Now}
+	s.snapshot = StatusSnapshot{
+		RunID:     runID,
+		GraphHash: graphHash,
+		StartTime: s.now().UTC(),
+		Tasks:     make(map[string]dag.TaskState),
+		Counts:    make(map[dag.TaskState]int),
+	}
+	for _, name := range graph.TopologicalOrder() {
+		s.snapshot.Tasks[name] = dag.TaskPending
+		s.snapshot.Counts[dag.TaskPending]++
+	}
+	return s
+}
+
+// OnNodeEvent records ev; see dag.NodeEventObserver.
+func (s *statusServer) OnNodeEvent(ev dag.NodeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := ev.Task.Name
+	s.snapshot.Counts[s.snapshot.Tasks[name]]--
+	s.snapshot.Tasks[name] = ev.To
+	s.snapshot.Counts[ev.To]++
+	se := StatusEvent{Seq: len(s.events) + 1, Time: s.now().UTC(), Kind: ev.Kind, Task: name, From: ev.From, To: ev.To}
+	if ev.HasResult {
+		code := ev.ExitCode
+		se.ExitCode = &code
+	}
+	s.events = append(s.events, se)
+	s.snapshot.Events = len(s.events)
+}
+
+func (s *statusServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(rw http.ResponseWriter, _ *http.Request) {
+		s.mu.Lock()
+		snap := s.snapshot
+		snap.Tasks = make(map[string]dag.TaskState, len(s.snapshot.Tasks))
+		for k, v := range s.snapshot.Tasks {
+			snap.Tasks[k] = v
+		}
+		snap.Counts = make(map[dag.TaskState]int, len(s.snapshot.Counts))
+		for k, v := range s.snapshot.Counts {
+			if v > 0 {
+				snap.Counts[k] = v
+			}
+		}
+		s.mu.Unlock()
+		writeStatusJSON(rw, snap)
+	})
+	mux.HandleFunc("GET /events", func(rw http.ResponseWriter, req *http.Request) {
+		since := 0
+		if raw := req.URL.Query().Get("since"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 {
+				http.Error(rw, "since must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			since = n
+		}
+		s.mu.Lock()
+		events := []StatusEvent{}
+		if since < len(s.events) {
+			events = append(events, s.events[since:]...)
+		}
+		s.mu.Unlock()
+		writeStatusJSON(rw, events)
+	})
+	mux.HandleFunc("GET /tasks/{name...}", func(rw http.ResponseWriter, req *http.Request) {
+		name := req.PathValue("name")
+		s.mu.Lock()
+		st, ok := s.snapshot.Tasks[name]
+		ts := TaskStatus{Name: name, State: st, Events: []StatusEvent{}}
+		for _, ev := range s.events {
+			if ev.Task == name {
+				ts.Events = append(ts.Events, ev)
+			}
+		}
+		s.mu.Unlock()
+		if !ok {
+			http.Error(rw, "unknown task "+name, http.StatusNotFound)
+			return
+		}
+		writeStatusJSON(rw, ts)
+	})
+	return mux
+}
+
+func writeStatusJSON(rw http.ResponseWriter, v any) {
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(v)
+}
+
+// serveStatus starts serving s on addr and logs the address. The returned
+// function stops the server.
+func serveStatus(addr string, s *statusServer, logger *slog.Logger) (stop func(), err error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("--status-addr: %w", err)
+	}
+	srv := &http.Server{Handler: s.handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() { _ = srv.Serve(l) }()
+	logger.Info("status API listening", "url", "http://"+l.Addr().String())
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}, nil
+}
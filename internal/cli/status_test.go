@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func TestStatusServer_ServesLiveState(t *testing.T) {
+	g, err := dag.NewTaskGraph([]core.Task{{Name: "a", Run: "true"}, {Name: "b", Run: "true"}}, []dag.Edge{{From: "a", To: "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newStatusServer(g, "run-1", "hash")
+	a, _ := g.Node("a")
+	s.OnNodeEvent(dag.NodeEvent{Kind: dag.NodeStarted, Task: a.Task, From: dag.TaskPending, To: dag.TaskRunning})
+	s.OnNodeEvent(dag.NodeEvent{Kind: dag.NodeFinished, Task: a.Task, From: dag.TaskRunning, To: dag.TaskCompleted, HasResult: true})
+	srv := httptest.NewServer(s.handler())
+	defer srv.Close()
+
+	get := func(path string, v any) int {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	var snap StatusSnapshot
+	get("/status", &snap)
+	if snap.RunID != "run-1" || snap.Tasks["a"] != dag.TaskCompleted || snap.Tasks["b"] != dag.TaskPending || snap.Events != 2 {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+	if snap.Counts[dag.TaskCompleted] != 1 || snap.Counts[dag.TaskPending] != 1 || len(snap.Counts) != 2 {
+		t.Fatalf("unexpected counts %v", snap.Counts)
+	}
+
+	var events []StatusEvent
+	get("/events?since=1", &events)
+	if len(events) != 1 || events[0].Seq != 2 || events[0].Kind != dag.NodeFinished || events[0].ExitCode == nil || *events[0].ExitCode != 0 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	var task TaskStatus
+	if code := get("/tasks/a", &task); code != http.StatusOK || len(task.Events) != 2 {
+		t.Fatalf("unexpected task status %d %+v", code, task)
+	}
+	if code := get("/tasks/missing", nil); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown task, got %d", code)
+	}
+	if code := get("/events?since=x", nil); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad since, got %d", code)
+	}
+}
+
+func TestExecute_StatusAddrObservesRun(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{{Name: "t1", Run: "echo hi"}}, nil)
+	inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--status-addr", "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("run: exit=%d err=%v", res.ExitCode, err)
+	}
+
+	inv.StatusAddr = "not-an-address"
+	if res, err := Execute(context.Background(), inv); err == nil || res.ExitCode != ExitConfigError {
+		t.Fatalf("expected a config error for a bad address, got exit=%d err=%v", res.ExitCode, err)
+	}
+}
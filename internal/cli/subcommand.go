@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"errors"
+	"io"
+)
+
+// subcommands maps a leading positional argument to its handler. The run
+// path (run, plan, cache verify and the flat form without a command) is not
+// listed: see RunArgs. "cache" is listed for `cache stats`, `cache export`,
+// `cache import` and `cache warm`; Dispatch leaves `cache verify` to the run
+// path.
+var subcommands = map[string]func(args []string, stdout io.Writer) (int, error){
+	"validate":   runValidate,
+	"affected":   runAffected,
+	"runs":       runRuns,
+	"trace":      runTrace,
+	"graph":      runGraph,
+	"stats":      runStats,
+	"analyze":    runAnalyze,
+	"cache":      runCache,
+	"worker":     runWorker,
+	"daemon":     runDaemon,
+	"completion": runCompletion,
+	"help":       runHelp,
+}
+
+// Dispatch runs the subcommand named by args[0], if any. A leading -h or
+// --help prints the list of commands, and a help request inside a
+// subcommand prints that command's usage.
+//
+// handled is false when args do not start with a known subcommand; the caller
+// then falls back to RunArgs, ParseInvocation and Execute.
+func Dispatch(args []string, stdout io.Writer) (exitCode int, handled bool, err error) {
+	if len(args) == 0 {
+		return 0, false, nil
+	}
+	if isHelpFlag(args[0]) {
+		return ExitSuccess, true, WriteHelp(stdout)
+	}
+	cmd, ok := subcommands[args[0]]
+	if command, _ := RunArgs(args); !ok || command == "cache verify" {
+		return 0, false, nil
+	}
+	exitCode, err = cmd(args[1:], stdout)
+	var help *HelpRequest
+	if errors.As(err, &help) {
+		c, _ := lookupCommand(args)
+		return ExitSuccess, true, WriteUsage(stdout, c.name)
+	}
+	return exitCode, true, err
+}
+
+// RunArgs maps a run-path invocation to the command it names and the
+// arguments ParseInvocation should parse: "run" and the flat form without a
+// command execute the graph, "plan" implies --dry-run and "cache verify"
+// implies --verify-cache. Every run path goes through ParseInvocation, so
+// its determinism guarantees hold whichever spelling is used.
+func RunArgs(args []string) (command string, runArgs []string) {
+	switch {
+	case len(args) > 0 && args[0] == "run":
+		return "run", append([]string(nil), args[1:]...)
+	case len(args) > 0 && args[0] == "plan":
+		return "plan", append(append([]string(nil), args[1:]...), "--dry-run")
+	case len(args) > 1 && args[0] == "cache" && args[1] == "verify":
+		return "cache verify", append(append([]string(nil), args[2:]...), "--verify-cache")
+	}
+	return "run", args
+}
+
+func isHelpFlag(arg string) bool {
+	switch arg {
+	case "-h", "-help", "--help", "--h":
+		return true
+	}
+	return false
+}
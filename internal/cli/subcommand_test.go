@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func TestDispatch_HelpExitsSuccessfully(t *testing.T) {
+	for _, args := range [][]string{{"--help"}, {"help"}, {"help", "runs", "prune"}, {"runs", "prune", "-h"}, {"validate", "--help"}} {
+		var out bytes.Buffer
+		code, handled, err := Dispatch(args, &out)
+		if !handled || err != nil || code != ExitSuccess {
+			t.Fatalf("%q: handled %v exit %d err %v", args, handled, code, err)
+		}
+		if !strings.HasPrefix(out.String(), "Usage: scriptweaver") {
+			t.Fatalf("%q: unexpected help %q", args, out.String())
+		}
+	}
+	var out bytes.Buffer
+	if _, _, err := Dispatch([]string{"runs", "prune", "-h"}, &out); err != nil || !strings.Contains(out.String(), "-max-age") {
+		t.Fatalf("runs prune help lacks its flags: %q (err %v)", out.String(), err)
+	}
+	if _, _, err := Dispatch([]string{"help", "bogus"}, &out); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected an unknown command to be an invocation error, got %v", err)
+	}
+}
+
+func TestRunArgs(t *testing.T) {
+	cases := []struct {
+		args    []string
+		command string
+		want    []string
+	}{
+		{[]string{"--workdir", "/w"}, "run", []string{"--workdir", "/w"}},
+		{[]string{"run", "--workdir", "/w"}, "run", []string{"--workdir", "/w"}},
+		{[]string{"plan", "--workdir", "/w"}, "plan", []string{"--workdir", "/w", "--dry-run"}},
+		{[]string{"cache", "verify", "--workdir", "/w"}, "cache verify", []string{"--workdir", "/w", "--verify-cache"}},
+	}
+	for _, tc := range cases {
+		command, got := RunArgs(tc.args)
+		if command != tc.command || strings.Join(got, " ") != strings.Join(tc.want, " ") {
+			t.Errorf("RunArgs(%q) = %q %q, want %q %q", tc.args, command, got, tc.command, tc.want)
+		}
+	}
+
+	_, args := RunArgs([]string{"plan", "-h"})
+	if _, err := ParseInvocation(args); ExitCode(err) != ExitSuccess {
+		t.Fatalf("expected plan -h to request help, got %v", err)
+	}
+}
+
+func TestDispatch_Validate(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "true"},
+		{Name: "b", Run: "true"},
+	}, []dag.Edge{{From: "b", To: "a"}})
+
+	var out bytes.Buffer
+	code, _, err := Dispatch([]string{"validate", "--workdir", workDir, "--graph", "graph.json"}, &out)
+	if err != nil || code != ExitSuccess {
+		t.Fatalf("validate: exit %d err %v", code, err)
+	}
+	var rep ValidateReport
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if rep.GraphHash == "" || strings.Join(rep.Tasks, ",") != "b,a" {
+		t.Fatalf("report = %+v", rep)
+	}
+
+	writeGraphJSON(t, filepath.Join(workDir, "bad.json"), []core.Task{{Name: "a", Run: "true"}}, []dag.Edge{{From: "a", To: "missing"}})
+	if code, _, _ := Dispatch([]string{"validate", "--workdir", workDir, "--graph", "bad.json"}, &out); code != ExitConfigError {
+		t.Fatalf("expected an invalid graph to exit %d, got %d", ExitConfigError, code)
+	}
+}
+
+func TestDispatch_TraceDiff(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "echo a > a.out", Outputs: []string{"a.out"}},
+	}, nil)
+	for _, tr := range []string{"first.json", "second.json"} {
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--trace", tr})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run: exit %d err %v", res.ExitCode, err)
+		}
+	}
+
+	var out bytes.Buffer
+	code, _, err := Dispatch([]string{"trace", "diff", "--workdir", workDir, "first.json", "first.json"}, &out)
+	if err != nil || code != ExitSuccess || !strings.Contains(out.String(), `"match": true`) {
+		t.Fatalf("identical traces: exit %d err %v out %s", code, err, out.String())
+	}
+	// The second run restored a from the cache, so its trace differs.
+	out.Reset()
+	code, _, err = Dispatch([]string{"trace", "diff", "--workdir", workDir, "first.json", "second.json"}, &out)
+	if code != ExitTraceMismatch || err == nil {
+		t.Fatalf("differing traces: exit %d err %v", code, err)
+	}
+	var rep TraceVerifyReport
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil || rep.Match || len(rep.Missing)+len(rep.Unexpected) == 0 {
+		t.Fatalf("report = %+v (err %v)", rep, err)
+	}
+}
+
+func TestDispatch_Completion(t *testing.T) {
+	for _, shell := range completionShells {
+		var out bytes.Buffer
+		code, _, err := Dispatch([]string{"completion", shell}, &out)
+		if err != nil || code != ExitSuccess {
+			t.Fatalf("%s: exit %d err %v", shell, code, err)
+		}
+		for _, want := range []string{"validate", "prune", "diff", "workdir", "max-age"} {
+			if !strings.Contains(out.String(), want) {
+				t.Fatalf("%s completion lacks %q:\n%s", shell, want, out.String())
+			}
+		}
+		var again bytes.Buffer
+		_, _, _ = Dispatch([]string{"completion", shell}, &again)
+		if again.String() != out.String() {
+			t.Fatalf("%s completion is not deterministic", shell)
+		}
+	}
+	if code, _, _ := Dispatch([]string{"completion", "tcsh"}, &bytes.Buffer{}); code != ExitInvalidInvocation {
+		t.Fatalf("expected an unsupported shell to exit %d, got %d", ExitInvalidInvocation, code)
+	}
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"scriptweaver/internal/trace"
+)
+
+// TraceDiffInvocation is the canonical form of `scriptweaver trace diff`.
+type TraceDiffInvocation struct {
+	WorkDir  string
+	Expected string
+	Actual   string
+}
+
+// ParseTraceDiffInvocation parses the flags and the two trace paths
+// following `trace diff`. The paths resolve under --workdir.
+func ParseTraceDiffInvocation(args []string) (TraceDiffInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver trace diff", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+
+	if err := fs.Parse(args); err != nil {
+		return TraceDiffInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 2 {
+		return TraceDiffInvocation{}, invalidInvocationf("expected two trace paths, got %d", fs.NArg())
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return TraceDiffInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return TraceDiffInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	inv := TraceDiffInvocation{WorkDir: workDir}
+	var err error
+	if inv.Expected, err = resolveUnderWorkDir(workDir, fs.Arg(0)); err != nil {
+		return TraceDiffInvocation{}, err
+	}
+	if inv.Actual, err = resolveUnderWorkDir(workDir, fs.Arg(1)); err != nil {
+		return TraceDiffInvocation{}, err
+	}
+	return inv, nil
+}
+
+// TraceDiff compares two trace files as --verify-trace does and writes the
+// TraceVerifyReport to stdout. Traces that differ exit ExitTraceMismatch.
+func TraceDiff(inv TraceDiffInvocation, stdout io.Writer) (int, error) {
+	expected, err := readTraceFile(inv.Expected)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	actual, err := readTraceFile(inv.Actual)
+	if err != nil {
+		return ExitConfigError, err
+	}
+	report, err := compareTraces(expected, actual)
+	if err != nil {
+		return ExitInternalError, err
+	}
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return ExitInternalError, err
+	}
+	if _, err := fmt.Fprintln(stdout, string(b)); err != nil {
+		return ExitInternalError, err
+	}
+	if !report.Match {
+		return ExitTraceMismatch, traceMismatchError(inv.Expected, report)
+	}
+	return ExitSuccess, nil
+}
+
+func readTraceFile(path string) (trace.ExecutionTrace, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return trace.ExecutionTrace{}, fmt.Errorf("read trace: %w", err)
+	}
+	t, err := trace.ParseExecutionTrace(b)
+	if err != nil {
+		return trace.ExecutionTrace{}, fmt.Errorf("trace %s: %w", path, err)
+	}
+	return t, nil
+}
+
+func runTrace(args []string, stdout io.Writer) (int, error) {
+	if len(args) == 0 || args[0] != "diff" {
+		return ExitInvalidInvocation, invalidInvocationf("usage: scriptweaver trace diff --workdir <dir> <expected> <actual>")
+	}
+	inv, err := ParseTraceDiffInvocation(args[1:])
+	if err != nil {
+		return ExitCode(err), err
+	}
+	return TraceDiff(inv, stdout)
+}
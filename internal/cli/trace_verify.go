@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"scriptweaver/internal/trace"
+)
+
+// TraceVerifyReport is the result of --verify-trace.
+type TraceVerifyReport struct {
+	ExpectedHash string `json:"expected_hash"`
+	ActualHash   string `json:"actual_hash"`
+	Match        bool   `json:"match"`
+
+	GraphHashChanged bool     `json:"graph_hash_changed,omitempty"`
+	Missing          []string `json:"missing,omitempty"`
+	Unexpected       []string `json:"unexpected,omitempty"`
+}
+
+// verifyTrace runs the graph as usual, then compares its trace with the
+// expected one. A mismatch turns a successful or graph-failure exit into
+// ExitTraceMismatch; the run itself is recorded with its own outcome.
+func verifyTrace(ctx context.Context, inv CLIInvocation, executor GraphExecutor) (CLIResult, error) {
+	expectedPath := inv.VerifyTrace
+	b, err := os.ReadFile(expectedPath)
+	if err != nil {
+		return CLIResult{ExitCode: ExitConfigError}, fmt.Errorf("read expected trace: %w", err)
+	}
+	expected, err := trace.ParseExecutionTrace(b)
+	if err != nil {
+		return CLIResult{ExitCode: ExitConfigError}, fmt.Errorf("expected trace %s: %w", expectedPath, err)
+	}
+
+	inv.VerifyTrace = ""
+	res, err := ExecuteWithExecutor(ctx, inv, executor)
+	if res.GraphResult == nil || (res.ExitCode != ExitSuccess && res.ExitCode != ExitGraphFailure) {
+		// No trace to compare: report the run's own failure.
+		return res, err
+	}
+	actual, perr := trace.ParseExecutionTrace(res.GraphResult.TraceBytes)
+	if perr != nil {
+		res.ExitCode = ExitInternalError
+		return res, fmt.Errorf("decode run trace: %w", perr)
+	}
+	report, cerr := compareTraces(expected, actual)
+	if cerr != nil {
+		res.ExitCode = ExitInternalError
+		return res, cerr
+	}
+	res.TraceVerify = report
+	if report.Match {
+		return res, err
+	}
+	res.ExitCode = ExitTraceMismatch
+	return res, traceMismatchError(expectedPath, report)
+}
+
+// compareTraces reports whether two traces have the same canonical hash and,
+// if not, which events each lacks.
+func compareTraces(expected, actual trace.ExecutionTrace) (*TraceVerifyReport, error) {
+	expectedBytes, err := expected.CanonicalJSON()
+	if err != nil {
+		return nil, err
+	}
+	actualBytes, err := actual.CanonicalJSON()
+	if err != nil {
+		return nil, err
+	}
+	diff, err := trace.Diff(expected, actual)
+	if err != nil {
+		return nil, err
+	}
+	report := &TraceVerifyReport{
+		ExpectedHash:     trace.ComputeTraceHash(expectedBytes),
+		ActualHash:       trace.ComputeTraceHash(actualBytes),
+		GraphHashChanged: diff.GraphHashChanged,
+	}
+	for _, e := range diff.Missing {
+		report.Missing = append(report.Missing, e.String())
+	}
+	for _, e := range diff.Unexpected {
+		report.Unexpected = append(report.Unexpected, e.String())
+	}
+	report.Match = report.ExpectedHash == report.ActualHash
+	return report, nil
+}
+
+func traceMismatchError(path string, r *TraceVerifyReport) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "trace differs from %s: expected %s, got %s", path, r.ExpectedHash, r.ActualHash)
+	if r.GraphHashChanged {
+		b.WriteString("\n  graph hash changed")
+	}
+	for _, e := range r.Missing {
+		b.WriteString("\n  - " + e)
+	}
+	for _, e := range r.Unexpected {
+		b.WriteString("\n  + " + e)
+	}
+	return fmt.Errorf("%s", b.String())
+}
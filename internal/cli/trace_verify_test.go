@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+func TestExecute_VerifyTrace(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+		{Name: "a", Run: "mkdir -p out && echo a > out/a.txt", Outputs: []string{"out/a.txt"}},
+	}, nil)
+	base := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"}
+	run := func(extra ...string) (CLIResult, error) {
+		t.Helper()
+		inv, err := ParseInvocation(append(append([]string{}, base...), extra...))
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		return Execute(context.Background(), inv)
+	}
+
+	// Record the golden trace of a fresh execution.
+	if res, err := run("--mode", "clean", "--trace", "golden.json"); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("golden run: exit %d err %v", res.ExitCode, err)
+	}
+
+	res, err := run("--mode", "clean", "--verify-trace", "golden.json")
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("matching run: exit %d err %v", res.ExitCode, err)
+	}
+	if res.TraceVerify == nil || !res.TraceVerify.Match || res.TraceVerify.ExpectedHash != res.GraphResult.TraceHash {
+		t.Fatalf("expected a matching report, got %+v", res.TraceVerify)
+	}
+
+	// Populate the cache, then a cached run's trace diverges from the golden one.
+	if res, err := run(); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("cache run: exit %d err %v", res.ExitCode, err)
+	}
+	res, err = run("--verify-trace", "golden.json")
+	if res.ExitCode != ExitTraceMismatch || err == nil {
+		t.Fatalf("expected trace mismatch exit, got %d err %v", res.ExitCode, err)
+	}
+	if !strings.Contains(err.Error(), "- TaskExecuted a (FreshWork)") || !strings.Contains(err.Error(), "+ TaskCached a (CacheHit)") {
+		t.Fatalf("expected diverging events in error, got %v", err)
+	}
+	if res.TraceVerify == nil || res.TraceVerify.Match || len(res.TraceVerify.Missing) == 0 || len(res.TraceVerify.Unexpected) == 0 {
+		t.Fatalf("expected a mismatch report, got %+v", res.TraceVerify)
+	}
+}
+
+func TestExecute_VerifyTraceRejectsUnreadableExpectation(t *testing.T) {
+	workDir := t.TempDir()
+	writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{{Name: "a", Run: "true"}}, nil)
+	if err := os.WriteFile(filepath.Join(workDir, "bad.json"), []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"bad.json", "missing.json"} {
+		inv, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--verify-trace", expected})
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err == nil || res.ExitCode != ExitConfigError {
+			t.Fatalf("%s: expected config error, got exit %d err %v", expected, res.ExitCode, err)
+		}
+	}
+
+	if _, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--trace", "t.json", "--verify-trace", "t.json"}); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected invalid invocation for --verify-trace naming the --trace output, got %v", err)
+	}
+}
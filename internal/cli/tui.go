@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"scriptweaver/internal/dag"
+)
+
+const (
+	// tuiRefresh is how often --tui redraws.
+	tuiRefresh = 200 * time.Millisecond
+	// tuiLogLines is how many recent output lines --tui shows per running task.
+	tuiLogLines = 5
+	// tuiWidth is where --tui cuts long lines, so none wraps and breaks
+	// the redraw.
+	tuiWidth = 120
+)
+
+// tui renders a run on a terminal for --tui: the task counts, the frontier
+// (pending tasks whose dependencies have all succeeded) and the latest output
+// lines of every running task. It is fed by the executor's node events and
+// the core executor's live output, and only ever writes to out, so the run
+// produces the same artifacts as headless.
+type tui struct {
+	out io.Writer
+
+	mu     sync.Mutex
+	order  []string // topological
+	deps   map[string][]string
+	states map[string]dag.TaskState
+	logs   map[string]*tailLines
+	drawn  int // lines of the last frame
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newTUI(out io.Writer, g *dag.TaskGraph) *tui {
+	t := &tui{
+		out:    out,
+		order:  g.TopologicalOrder(),
+		deps:   make(map[string][]string),
+		states: make(map[string]dag.TaskState),
+		logs:   make(map[string]*tailLines),
+	}
+	for _, name := range t.order {
+		t.states[name] = dag.TaskPending
+	}
+	for _, e := range g.Edges() {
+		t.deps[e.To] = append(t.deps[e.To], e.From)
+	}
+	return t
+}
+
+// OnNodeEvent records ev; see dag.NodeEventObserver.
+func (t *tui) OnNodeEvent(ev dag.NodeEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.states[ev.Task.Name] = ev.To
+	if ev.Kind == dag.NodeStarted {
+		t.logs[ev.Task.Name] = &tailLines{max: tuiLogLines}
+	}
+}
+
+// liveOutput is the core.Executor.LiveOutput hook: task output feeds the
+// task's log tail.
+func (t *tui) liveOutput(task string) io.Writer {
+	return tuiLogWriter{t: t, task: task}
+}
+
+type tuiLogWriter struct {
+	t    *tui
+	task string
+}
+
+func (w tuiLogWriter) Write(p []byte) (int, error) {
+	w.t.mu.Lock()
+	defer w.t.mu.Unlock()
+	tail := w.t.logs[w.task]
+	if tail == nil {
+		tail = &tailLines{max: tuiLogLines}
+		w.t.logs[w.task] = tail
+	}
+	tail.write(p)
+	return len(p), nil
+}
+
+// start redraws every tuiRefresh until close.
+func (t *tui) start() {
+	t.stop, t.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(t.done)
+		tick := time.NewTicker(tuiRefresh)
+		defer tick.Stop()
+		for {
+			t.draw()
+			select {
+			case <-t.stop:
+				return
+			case <-tick.C:
+			}
+		}
+	}()
+}
+
+// close stops redrawing and leaves the final frame on screen.
+func (t *tui) close() {
+	if t.stop != nil {
+		close(t.stop)
+		<-t.done
+	}
+	t.draw()
+}
+
+// draw replaces the previous frame with the current one.
+func (t *tui) draw() {
+	t.mu.Lock()
+	frame := t.frame()
+	var b strings.Builder
+	if t.drawn > 0 {
+		// Cursor up to the first line of the last frame, then clear below.
+		fmt.Fprintf(&b, "\x1b[%dA\x1b[J", t.drawn)
+	}
+	b.WriteString(frame)
+	t.drawn = strings.Count(frame, "\n")
+	t.mu.Unlock()
+	_, _ = io.WriteString(t.out, b.String())
+}
+
+// frame renders the current state. Callers hold t.mu.
+func (t *tui) frame() string {
+	counts := make(map[dag.TaskState]int)
+	var running, frontier []string
+	for _, name := range t.order {
+		st := t.states[name]
+		counts[st]++
+		switch {
+		case st == dag.TaskRunning:
+			running = append(running, name)
+		case st == dag.TaskPending && t.ready(name):
+			frontier = append(frontier, name)
+		}
+	}
+	done := 0
+	for st, n := range counts {
+		if dag.IsTerminal(st) {
+			done += n
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "scriptweaver  %d/%d done  running %d  completed %d  cached %d  failed %d  skipped %d  pending %d\n",
+		done, len(t.order), counts[dag.TaskRunning], counts[dag.TaskCompleted], counts[dag.TaskCached],
+		counts[dag.TaskFailed]+counts[dag.TaskFailedAllowed], counts[dag.TaskSkipped]+counts[dag.TaskConditionFalse], counts[dag.TaskPending])
+	if len(frontier) > 0 {
+		b.WriteString(tuiLine("frontier: "+strings.Join(frontier, ", ")) + "\n")
+	}
+	for _, name := range running {
+		b.WriteString(tuiLine("> "+name) + "\n")
+		if tail := t.logs[name]; tail != nil {
+			for _, l := range tail.lines() {
+				b.WriteString(tuiLine("    "+l) + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// ready reports whether every dependency of name has succeeded.
+func (t *tui) ready(name string) bool {
+	for _, d := range t.deps[name] {
+		if !dag.IsSuccessful(t.states[d]) {
+			return false
+		}
+	}
+	return true
+}
+
+// tuiLine strips control characters from s, so task output cannot move
+// the cursor, and cuts it to tuiWidth.
+func tuiLine(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return ' '
+		case unicode.IsControl(r):
+			return -1
+		default:
+			return r
+		}
+	}, s)
+	if r := []rune(s); len(r) > tuiWidth {
+		s = string(r[:tuiWidth-3]) + "..."
+	}
+	return s
+}
+
+// tailLines keeps the last max lines written to it, plus the line being
+// written.
+type tailLines struct {
+	max     int
+	full    []string
+	partial []byte
+}
+
+func (t *tailLines) write(p []byte) {
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			t.partial = append(t.partial, p...)
+			return
+		}
+		t.full = append(t.full, string(append(t.partial, p[:i]...)))
+		t.partial = t.partial[:0]
+		if len(t.full) > t.max {
+			t.full = t.full[len(t.full)-t.max:]
+		}
+		p = p[i+1:]
+	}
+}
+
+func (t *tailLines) lines() []string {
+	out := append([]string(nil), t.full...)
+	if len(t.partial) > 0 {
+		out = append(out, string(t.partial))
+	}
+	if len(out) > t.max {
+		out = out[len(out)-t.max:]
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func TestTUI_FrameShowsFrontierAndRunningLogs(t *testing.T) {
+	g, err := dag.NewTaskGraph([]core.Task{
+		{Name: "a", Run: "true"}, {Name: "b", Run: "true"}, {Name: "c", Run: "true"}, {Name: "d", Run: "true"},
+	}, []dag.Edge{{From: "a", To: "b"}, {From: "a", To: "c"}, {From: "b", To: "d"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	ui := newTUI(&out, g)
+	event := func(name string, kind dag.NodeEventKind, to dag.TaskState) {
+		n, _ := g.Node(name)
+		ui.OnNodeEvent(dag.NodeEvent{Kind: kind, Task: n.Task, To: to})
+	}
+	event("a", dag.NodeStarted, dag.TaskRunning)
+	event("a", dag.NodeFinished, dag.TaskCompleted)
+	event("b", dag.NodeStarted, dag.TaskRunning)
+	w := ui.liveOutput("b")
+	for _, line := range []string{"1\n", "2\n3\n4\n5\n6\n", "\x1b[2Kpartial"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ui.draw()
+	want := "scriptweaver  1/4 done  running 1  completed 1  cached 0  failed 0  skipped 0  pending 2\n" +
+		"frontier: c\n" +
+		"> b\n" +
+		"    3\n    4\n    5\n    6\n    [2Kpartial\n"
+	if out.String() != want {
+		t.Fatalf("frame = %q, want %q", out.String(), want)
+	}
+
+	// The next frame replaces this one in place.
+	out.Reset()
+	event("b", dag.NodeFinished, dag.TaskCompleted)
+	ui.draw()
+	if !strings.HasPrefix(out.String(), "\x1b[8A\x1b[J") || !strings.Contains(out.String(), "frontier: c, d") {
+		t.Fatalf("redraw = %q", out.String())
+	}
+}
+
+func TestExecute_TUIWritesSameArtifacts(t *testing.T) {
+	trace := func(tuiMode bool) ([]byte, string) {
+		t.Helper()
+		workDir := t.TempDir()
+		writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+			{Name: "a", Run: "echo a > a.out", Outputs: []string{"a.out"}},
+			{Name: "b", Run: "echo b"},
+		}, []dag.Edge{{From: "a", To: "b"}})
+		args := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--trace", "trace.json"}
+		if tuiMode {
+			args = append(args, "--tui")
+		}
+		inv, err := ParseInvocation(args)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		var display bytes.Buffer
+		inv.stderr = &display
+		if res, err := Execute(context.Background(), inv); err != nil || res.ExitCode != ExitSuccess {
+			t.Fatalf("run: exit=%d err=%v", res.ExitCode, err)
+		}
+		b, err := os.ReadFile(filepath.Join(workDir, "trace.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b, display.String()
+	}
+	headless, none := trace(false)
+	interactive, display := trace(true)
+	if !bytes.Equal(headless, interactive) {
+		t.Fatalf("--tui changed the trace:\n%s\nvs\n%s", headless, interactive)
+	}
+	if none != "" || !strings.Contains(display, "2/2 done") {
+		t.Fatalf("unexpected display: headless %q, tui %q", none, display)
+	}
+}
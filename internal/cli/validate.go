@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// ValidateInvocation is the canonical form of `scriptweaver validate`.
+type ValidateInvocation struct {
+	WorkDir   string
+	GraphPath string
+	Params    map[string]string
+	// NormalizeRules come from scriptweaver.toml, as for a run.
+	NormalizeRules []core.NormalizeRule
+	// AllowOutsideWorkDir lets input patterns resolve outside WorkDir.
+	AllowOutsideWorkDir bool
+}
+
+// ValidateReport is what `scriptweaver validate` prints for a valid graph.
+type ValidateReport struct {
+	GraphHash string `json:"graph_hash"`
+	// Tasks lists the task names in topological order.
+	Tasks []string `json:"tasks"`
+}
+
+// ParseValidateInvocation parses the flags following `validate`.
+//
+// As with ParseInvocation, --workdir is required and absolute, --graph
+// resolves under it and scriptweaver.toml supplies defaults.
+func ParseValidateInvocation(args []string) (ValidateInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver validate", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var workDir string
+	var graphPath string
+	params := paramFlags{}
+	var allowOutside bool
+
+	fs.StringVar(&workDir, "workdir", "", "Absolute working directory. Required.")
+	fs.StringVar(&graphPath, "graph", "", "Graph source path. Required.")
+	fs.Var(params, "param", "Graph parameter key=value (repeatable).")
+	fs.BoolVar(&allowOutside, "allow-outside-workdir", false, "Allow input patterns that resolve outside --workdir (declared outputs must stay under it).")
+
+	if err := fs.Parse(args); err != nil {
+		return ValidateInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 0 {
+		return ValidateInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
+	}
+
+	workDir = filepath.Clean(workDir)
+	if workDir == "" || workDir == "." {
+		return ValidateInvocation{}, invalidInvocationf("--workdir is required")
+	}
+	if !filepath.IsAbs(workDir) {
+		return ValidateInvocation{}, invalidInvocationf("--workdir must be an absolute path (got %q)", workDir)
+	}
+	cfg, err := applyConfig(fs, workDir, params)
+	if err != nil {
+		return ValidateInvocation{}, err
+	}
+	if graphPath == "" {
+		return ValidateInvocation{}, invalidInvocationf("--graph is required")
+	}
+	resolvedGraph, err := resolveUnderWorkDir(workDir, graphPath)
+	if err != nil {
+		return ValidateInvocation{}, err
+	}
+	inv := ValidateInvocation{WorkDir: workDir, GraphPath: resolvedGraph, NormalizeRules: cfg.Normalize, AllowOutsideWorkDir: allowOutside}
+	if len(params) > 0 {
+		inv.Params = params
+	}
+	return inv, nil
+}
+
+// Validate loads the graph and checks that a runner is registered for every
+// task kind, without touching the cache, workspace or any task. A valid
+// graph's hash and tasks are written to stdout as JSON.
+func Validate(inv ValidateInvocation, stdout io.Writer) (int, error) {
+	g, graphHash, err := loadGraphAndHash(inv.GraphPath, inv.Params, inv.NormalizeRules...)
+	if err != nil {
+		var invErr *InvocationError
+		if errors.As(err, &invErr) && invErr.ExitCode != 0 {
+			return invErr.ExitCode, err
+		}
+		return ExitConfigError, err
+	}
+	if err := checkWorkDirPaths(g, inv.WorkDir, inv.AllowOutsideWorkDir); err != nil {
+		return ExitConfigError, err
+	}
+	runner := core.NewRunner(inv.WorkDir, noCache{})
+	cacheRunner, err := dag.NewCacheAwareRunner(runner)
+	if err != nil {
+		return ExitInternalError, err
+	}
+	registry, err := localRegistry(runner, cacheRunner)
+	if err != nil {
+		return ExitInternalError, err
+	}
+	if err := registry.Validate(g); err != nil {
+		return ExitConfigError, err
+	}
+
+	report := ValidateReport{GraphHash: graphHash, Tasks: g.TopologicalOrder()}
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return ExitInternalError, err
+	}
+	if _, err := fmt.Fprintln(stdout, string(b)); err != nil {
+		return ExitInternalError, err
+	}
+	return ExitSuccess, nil
+}
+
+// localRegistry registers taskRunner for shell and container tasks and a
+// local service runner over runner for services.
+func localRegistry(runner *core.Runner, taskRunner dag.TaskRunner) (*dag.RunnerRegistry, error) {
+	registry, err := dag.NewRunnerRegistry(taskRunner)
+	if err != nil {
+		return nil, err
+	}
+	if err := registry.Register(core.KindContainer, taskRunner); err != nil {
+		return nil, err
+	}
+	services, err := dag.NewServiceRunner(runner)
+	if err != nil {
+		return nil, err
+	}
+	if err := registry.Register(core.KindService, services); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+func runValidate(args []string, stdout io.Writer) (int, error) {
+	inv, err := ParseValidateInvocation(args)
+	if err != nil {
+		return ExitCode(err), err
+	}
+	return Validate(inv, stdout)
+}
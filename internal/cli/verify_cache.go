@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/projectintegration/engine/workspace"
+	"scriptweaver/internal/recovery/state"
+)
+
+// Cache verification finding statuses.
+const (
+	CacheEntryCorrupt = "corrupt"
+	CacheEntryMissing = "missing"
+	// CacheEntryUntrusted is an entry not signed by a --cache-trusted-key.
+	CacheEntryUntrusted = "untrusted"
+)
+
+// CacheVerifyReport is the result of --verify-cache.
+//
+// Findings are sorted by hash, then namespace; node and run lists are sorted,
+// so the report is byte-identical for identical workspace and cache contents.
+type CacheVerifyReport struct {
+	// Checked is the number of distinct cache entries referenced by checkpoints.
+	Checked  int                  `json:"checked"`
+	Findings []CacheVerifyFinding `json:"findings"`
+	// Pruned is true when corrupt and untrusted entries were removed
+	// (--prune-corrupt).
+	Pruned bool `json:"pruned"`
+}
+
+// CacheVerifyFinding is one referenced entry that failed verification.
+type CacheVerifyFinding struct {
+	Hash string `json:"hash"`
+	// Namespace is the cache namespace the entry is in; empty without one.
+	Namespace string   `json:"namespace,omitempty"`
+	Status    string   `json:"status"`
+	Reason    string   `json:"reason,omitempty"`
+	Nodes     []string `json:"nodes"`
+	Runs      []string `json:"runs"`
+}
+
+type checkpointRef struct {
+	hash      string
+	namespace string
+	nodes     map[string]struct{}
+	runs      map[string]struct{}
+}
+
+// verifyCache walks every cache entry referenced by recorded checkpoints and
+// verifies it with FileCache.Verify. With prune set, corrupt entries are
+// removed so the next run re-executes the affected tasks. Each run's entries
+// are looked up in the cache namespace it used (see recordedCacheNamespace).
+func verifyCache(inv CLIInvocation) (CLIResult, error) {
+	res := CLIResult{ExitCode: ExitInternalError}
+	if inv.CacheDir == "" {
+		res.ExitCode = ExitInvalidInvocation
+		return res, invalidInvocationf("--cache-dir is required")
+	}
+	st, err := state.NewStore(inv.WorkDir)
+	if err != nil {
+		return res, err
+	}
+	if inv.PruneCorrupt {
+		ws, err := workspace.EnsureWorkspace(inv.WorkDir)
+		if err != nil {
+			res.ExitCode = ExitConfigError
+			return res, err
+		}
+		lock, err := workspace.AcquireLock(ws)
+		if err != nil {
+			res.ExitCode = ExitConfigError
+			return res, err
+		}
+		defer func() { _ = lock.Release() }()
+	}
+	ids, err := st.ListRunIDs()
+	if err != nil {
+		res.ExitCode = ExitConfigError
+		return res, err
+	}
+
+	type refKey struct{ hash, namespace string }
+	refs := make(map[refKey]*checkpointRef)
+	for _, id := range ids {
+		cps, err := st.LoadAllCheckpoints(id)
+		if err != nil {
+			res.ExitCode = ExitConfigError
+			return res, fmt.Errorf("run %s: %w", id, err)
+		}
+		if len(cps) == 0 {
+			continue
+		}
+		namespace := recordedCacheNamespace(st, inv, id)
+		for node, cp := range cps {
+			for _, key := range cp.CacheKeys {
+				k := refKey{key, namespace}
+				r, ok := refs[k]
+				if !ok {
+					r = &checkpointRef{hash: key, namespace: namespace, nodes: map[string]struct{}{}, runs: map[string]struct{}{}}
+					refs[k] = r
+				}
+				r.nodes[node] = struct{}{}
+				r.runs[id] = struct{}{}
+			}
+		}
+	}
+	ordered := make([]*checkpointRef, 0, len(refs))
+	for _, r := range refs {
+		ordered = append(ordered, r)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].hash != ordered[j].hash {
+			return ordered[i].hash < ordered[j].hash
+		}
+		return ordered[i].namespace < ordered[j].namespace
+	})
+
+	caches := make(map[string]*core.FileCache)
+	report := &CacheVerifyReport{Checked: len(ordered), Findings: []CacheVerifyFinding{}, Pruned: inv.PruneCorrupt}
+	for _, r := range ordered {
+		cache, ok := caches[r.namespace]
+		if !ok {
+			cache = core.NewFileCache(core.NamespacedCacheDir(inv.CacheDir, r.namespace))
+			inv.CacheSigning.apply(cache)
+			caches[r.namespace] = cache
+		}
+		h := r.hash
+		verr := cache.Verify(core.TaskHash(h))
+		if verr == nil {
+			continue
+		}
+		f := CacheVerifyFinding{Hash: h, Namespace: r.namespace, Nodes: sortedSet(r.nodes), Runs: sortedSet(r.runs)}
+		var corrupt *core.CacheCorruptError
+		var untrusted *core.CacheSignatureError
+		switch {
+		case errors.Is(verr, core.ErrCacheEntryMissing):
+			f.Status = CacheEntryMissing
+		case errors.As(verr, &corrupt):
+			f.Status = CacheEntryCorrupt
+			f.Reason = corrupt.Reason
+		case errors.As(verr, &untrusted):
+			f.Status = CacheEntryUntrusted
+			f.Reason = untrusted.Reason
+		default:
+			res.ExitCode = ExitConfigError
+			return res, verr
+		}
+		if inv.PruneCorrupt && f.Status != CacheEntryMissing {
+			if err := cache.Remove(core.TaskHash(h)); err != nil {
+				res.ExitCode = ExitConfigError
+				return res, err
+			}
+		}
+		report.Findings = append(report.Findings, f)
+	}
+
+	res.CacheVerify = report
+	res.ExitCode = ExitSuccess
+	if len(report.Findings) > 0 && !inv.PruneCorrupt {
+		res.ExitCode = ExitConfigError
+	}
+	return res, nil
+}
+
+func sortedSet(m map[string]struct{}) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+func TestExecute_VerifyCache_ReportsAndPrunesCorruptEntries(t *testing.T) {
+	workDir := t.TempDir()
+	graphPath := filepath.Join(workDir, "graph.json")
+	cacheDir := filepath.Join(workDir, "cache")
+	writeGraphJSON(t, graphPath, []core.Task{
+		{Name: "a", Run: "echo a > a.txt", Outputs: []string{"a.txt"}},
+		{Name: "b", Run: "echo b > b.txt", Outputs: []string{"b.txt"}},
+	}, nil)
+
+	res, err := Execute(context.Background(), CLIInvocation{
+		WorkDir:       workDir,
+		GraphPath:     graphPath,
+		CacheDir:      cacheDir,
+		OutputDir:     filepath.Join(workDir, "out"),
+		ExecutionMode: ExecutionModeIncremental,
+	})
+	if err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("run: exit=%d err=%v", res.ExitCode, err)
+	}
+
+	verify := func(prune bool) CLIResult {
+		t.Helper()
+		args := []string{"--workdir", workDir, "--cache-dir", "cache", "--verify-cache"}
+		if prune {
+			args = append(args, "--prune-corrupt")
+		}
+		inv, err := ParseInvocation(args)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		return res
+	}
+
+	clean := verify(false)
+	if clean.ExitCode != ExitSuccess || clean.CacheVerify == nil || clean.CacheVerify.Checked != 2 || len(clean.CacheVerify.Findings) != 0 {
+		t.Fatalf("expected clean report, got exit=%d report=%+v", clean.ExitCode, clean.CacheVerify)
+	}
+
+	hashA := res.GraphResult.TaskHashes["a"]
+	blob := filepath.Join(cacheDir, string(hashA)[:2], string(hashA), "artifacts", "0.blob")
+	if err := os.WriteFile(blob, []byte("tampered\n"), 0o644); err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+
+	bad := verify(false)
+	if bad.ExitCode != ExitConfigError || len(bad.CacheVerify.Findings) != 1 {
+		t.Fatalf("expected one finding, got exit=%d report=%+v", bad.ExitCode, bad.CacheVerify)
+	}
+	f := bad.CacheVerify.Findings[0]
+	if f.Hash != string(hashA) || f.Status != CacheEntryCorrupt || len(f.Nodes) != 1 || f.Nodes[0] != "a" {
+		t.Fatalf("unexpected finding: %+v", f)
+	}
+
+	pruned := verify(true)
+	if pruned.ExitCode != ExitSuccess || !pruned.CacheVerify.Pruned {
+		t.Fatalf("expected prune to succeed, got exit=%d report=%+v", pruned.ExitCode, pruned.CacheVerify)
+	}
+	if ok, _ := core.NewFileCache(cacheDir).Has(hashA); ok {
+		t.Fatalf("expected corrupt entry removed")
+	}
+
+	after := verify(false)
+	if len(after.CacheVerify.Findings) != 1 || after.CacheVerify.Findings[0].Status != CacheEntryMissing {
+		t.Fatalf("expected pruned entry to be reported missing, got %+v", after.CacheVerify)
+	}
+}
+
+func TestParseInvocation_VerifyCacheFlags(t *testing.T) {
+	workDir := t.TempDir()
+	if _, err := ParseInvocation([]string{"--workdir", workDir, "--verify-cache"}); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected --cache-dir to be required, got %v", err)
+	}
+	if _, err := ParseInvocation([]string{"--workdir", workDir, "--graph", "g", "--cache-dir", "c", "--output-dir", "o", "--prune-corrupt"}); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected --prune-corrupt without --verify-cache to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// maxWarmEntries bounds the cache entries a warmState keeps in memory per
+// cache directory; the set is dropped and refilled when it is exceeded.
+const maxWarmEntries = 4096
+
+// warmState is what `scriptweaver daemon` keeps between invocations: loaded
+// graphs, input fingerprint caches and recently read cache entries. A nil
+// warmState loads everything afresh, as a one-shot invocation does.
+type warmState struct {
+	mu           sync.Mutex
+	graphs       map[string]warmGraph
+	fingerprints map[string]*core.FingerprintCache
+	entries      map[string]*warmEntries
+}
+
+func newWarmState() *warmState {
+	return &warmState{
+		graphs:       make(map[string]warmGraph),
+		fingerprints: make(map[string]*core.FingerprintCache),
+		entries:      make(map[string]*warmEntries),
+	}
+}
+
+// warmGraph is a loaded graph and the stamps of the files it was read from.
+type warmGraph struct {
+	graph *dag.TaskGraph
+	hash  string
+	files map[string]fileStamp
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func stampFile(path string) (fileStamp, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, false
+	}
+	return fileStamp{size: info.Size(), modTime: info.ModTime()}, true
+}
+
+// loadGraphAndHash is the package-level loadGraphAndHash, reusing the graph
+// loaded by an earlier invocation with the same path, params and normalize
+// rules while none of the graph files it read changed size or mtime.
+func (w *warmState) loadGraphAndHash(path string, params map[string]string, normalize ...core.NormalizeRule) (*dag.TaskGraph, string, error) {
+	if w == nil {
+		return loadGraphAndHash(path, params, normalize...)
+	}
+	keyBytes, err := json.Marshal(struct {
+		Path      string
+		Params    map[string]string
+		Normalize []core.NormalizeRule
+	}{path, params, normalize})
+	if err != nil {
+		return loadGraphAndHash(path, params, normalize...)
+	}
+	key := string(keyBytes)
+
+	w.mu.Lock()
+	cached, ok := w.graphs[key]
+	w.mu.Unlock()
+	if ok && unchanged(cached.files) {
+		return cached.graph, cached.hash, nil
+	}
+
+	files := make(map[string]fileStamp)
+	read := func(p string) (graphFile, error) {
+		// Stamp before reading, so a write racing the read invalidates the
+		// entry next time rather than going unnoticed.
+		if st, ok := stampFile(p); ok {
+			files[p] = st
+		}
+		return readGraphFile(p)
+	}
+	g, err := loadGraphWith(read, path, params, normalize)
+	if err != nil {
+		return nil, "", err
+	}
+	hash := g.Hash().String()
+	w.mu.Lock()
+	w.graphs[key] = warmGraph{graph: g, hash: hash, files: files}
+	w.mu.Unlock()
+	return g, hash, nil
+}
+
+func unchanged(files map[string]fileStamp) bool {
+	for p, want := range files {
+		got, ok := stampFile(p)
+		if !ok || got.size != want.size || !got.modTime.Equal(want.modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// fingerprintCache returns the fingerprint cache stored at path, loading it
+// only the first time.
+func (w *warmState) fingerprintCache(path string) *core.FingerprintCache {
+	if w == nil {
+		return core.LoadFingerprintCache(path)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fc, ok := w.fingerprints[path]
+	if !ok {
+		fc = core.LoadFingerprintCache(path)
+		w.fingerprints[path] = fc
+	}
+	return fc
+}
+
+// cache puts an in-memory layer holding recently read entries in front of
+// c. Only file caches without signature verification are layered: a
+// remembered entry would skip the signature check.
+func (w *warmState) cache(c core.Cache) core.Cache {
+	fc, ok := c.(*core.FileCache)
+	if w == nil || !ok || len(fc.TrustedKeys) > 0 {
+		return c
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	e, ok := w.entries[fc.CacheDir]
+	if !ok {
+		e = &warmEntries{entries: core.NewMemoryCache()}
+		w.entries[fc.CacheDir] = e
+	}
+	return &warmCache{Cache: c, warm: e}
+}
+
+// warmEntries are the remembered entries of one cache directory.
+type warmEntries struct {
+	mu      sync.Mutex
+	entries *core.MemoryCache
+	count   int
+}
+
+// warmCache serves Get from remembered entries when the underlying cache
+// still has them, and remembers what it reads. Entries with streamed
+// artifacts are not remembered, so large artifacts are never held in memory.
+type warmCache struct {
+	core.Cache
+	warm *warmEntries
+}
+
+func (c *warmCache) Get(hash core.TaskHash) (*core.CacheEntry, error) {
+	c.warm.mu.Lock()
+	entry, _ := c.warm.entries.Get(hash)
+	c.warm.mu.Unlock()
+	if entry != nil {
+		// The entry may have been pruned or rewritten on disk since.
+		if has, err := c.Cache.Has(hash); err == nil && has {
+			return entry, nil
+		}
+		c.forget(hash)
+	}
+
+	entry, err := c.Cache.Get(hash)
+	if err != nil || entry == nil {
+		return entry, err
+	}
+	for _, a := range entry.Artifacts {
+		if a.Streamed() {
+			return entry, nil
+		}
+	}
+	c.warm.mu.Lock()
+	defer c.warm.mu.Unlock()
+	if c.warm.count >= maxWarmEntries {
+		c.warm.entries, c.warm.count = core.NewMemoryCache(), 0
+	}
+	if err := c.warm.entries.Put(entry); err == nil {
+		c.warm.count++
+	}
+	return entry, nil
+}
+
+func (c *warmCache) Put(entry *core.CacheEntry) error {
+	if entry != nil {
+		c.forget(entry.Hash)
+	}
+	return c.Cache.Put(entry)
+}
+
+func (c *warmCache) forget(hash core.TaskHash) {
+	c.warm.mu.Lock()
+	defer c.warm.mu.Unlock()
+	if has, _ := c.warm.entries.Has(hash); has {
+		c.warm.entries.Delete(hash)
+		c.warm.count--
+	}
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/remote"
+)
+
+// WorkerInvocation is the canonical form of `scriptweaver worker`.
+type WorkerInvocation struct {
+	// Listen is the TCP address the worker serves on.
+	Listen string
+	// ScratchRoot holds per-task scratch directories; empty means the system
+	// temp directory.
+	ScratchRoot     string
+	ContainerEngine string
+	// Token is the shared secret clients must present; it is required
+	// unless Listen is a loopback address.
+	Token string
+}
+
+// ParseWorkerInvocation parses the flags following `worker`.
+func ParseWorkerInvocation(args []string) (WorkerInvocation, error) {
+	fs := flag.NewFlagSet("scriptweaver worker", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var listen string
+	var scratchRoot string
+	var containerEngine string
+	var tokenFile string
+
+	fs.StringVar(&listen, "listen", "", "Address to serve remote execution on, e.g. 127.0.0.1:8420. Required.")
+	fs.StringVar(&scratchRoot, "scratch-root", "", "Absolute directory for per-task scratch directories (optional).")
+	fs.StringVar(&containerEngine, "container-engine", "docker", "Container engine for tasks that declare an image.")
+	fs.StringVar(&tokenFile, "token-file", "", "File holding the token clients must present. Required unless --listen is a loopback address.")
+
+	if err := fs.Parse(args); err != nil {
+		return WorkerInvocation{}, flagParseError(fs, err)
+	}
+	if fs.NArg() != 0 {
+		return WorkerInvocation{}, invalidInvocationf("unexpected positional arguments: %q", strings.Join(fs.Args(), " "))
+	}
+	if strings.TrimSpace(listen) == "" {
+		return WorkerInvocation{}, invalidInvocationf("--listen is required")
+	}
+	if scratchRoot != "" && !filepath.IsAbs(scratchRoot) {
+		return WorkerInvocation{}, invalidInvocationf("--scratch-root must be an absolute path (got %q)", scratchRoot)
+	}
+	if strings.TrimSpace(containerEngine) == "" {
+		return WorkerInvocation{}, invalidInvocationf("--container-engine must not be empty")
+	}
+	inv := WorkerInvocation{Listen: listen, ScratchRoot: scratchRoot, ContainerEngine: containerEngine}
+	if tokenFile != "" {
+		token, err := readTokenFile("--token-file", tokenFile)
+		if err != nil {
+			return WorkerInvocation{}, err
+		}
+		inv.Token = token
+	} else if !loopbackAddr(listen) {
+		return WorkerInvocation{}, invalidInvocationf("--listen %q accepts connections from other hosts; pass --token-file or listen on a loopback address", listen)
+	}
+	return inv, nil
+}
+
+// readTokenFile reads a shared worker token from path, trimming surrounding
+// whitespace. flagName names the flag in errors.
+func readTokenFile(flagName, path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", invalidInvocationf("%s: %v", flagName, err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", invalidInvocationf("%s: %s is empty", flagName, path)
+	}
+	return token, nil
+}
+
+// loopbackAddr reports whether the listen address addr only accepts local
+// connections. An empty host listens on every interface.
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func runWorker(args []string, stdout io.Writer) (int, error) {
+	inv, err := ParseWorkerInvocation(args)
+	if err != nil {
+		return ExitCode(err), err
+	}
+	w := &remote.Worker{ScratchRoot: inv.ScratchRoot, Container: &core.ContainerConfig{Engine: inv.ContainerEngine}, Token: inv.Token}
+	fmt.Fprintf(stdout, "scriptweaver worker listening on %s\n", inv.Listen)
+	if err := remote.NewServer(inv.Listen, w).ListenAndServe(); err != nil {
+		return ExitConfigError, fmt.Errorf("worker: %w", err)
+	}
+	return ExitSuccess, nil
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/remote"
+)
+
+func TestParseWorkerInvocation_Validation(t *testing.T) {
+	inv, err := ParseWorkerInvocation([]string{"--listen", "127.0.0.1:8420"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inv.Listen != "127.0.0.1:8420" || inv.ContainerEngine != "docker" || inv.Token != "" {
+		t.Fatalf("unexpected invocation: %+v", inv)
+	}
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	inv, err = ParseWorkerInvocation([]string{"--listen", ":8420", "--token-file", tokenFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inv.Token != "s3cret" {
+		t.Fatalf("expected trimmed token, got %q", inv.Token)
+	}
+	emptyToken := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(emptyToken, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{},
+		{"--listen", ":8420"},
+		{"--listen", "0.0.0.0:8420"},
+		{"--listen", ":8420", "--token-file", emptyToken},
+		{"--listen", ":8420", "--token-file", filepath.Join(t.TempDir(), "missing")},
+		{"--listen", "127.0.0.1:8420", "--scratch-root", "rel"},
+		{"--listen", "127.0.0.1:8420", "extra"},
+	} {
+		if _, err := ParseWorkerInvocation(args); ExitCode(err) != ExitInvalidInvocation {
+			t.Fatalf("args %q: expected invalid invocation, got %v", args, err)
+		}
+	}
+}
+
+func TestExecute_RemoteWorkers(t *testing.T) {
+	var inFlight, maxInFlight int32
+	var workers []string
+	for i := 0; i < 2; i++ {
+		w := &remote.Worker{ScratchRoot: t.TempDir()}
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				m := atomic.LoadInt32(&maxInFlight)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+					break
+				}
+			}
+			w.ServeHTTP(rw, req)
+		}))
+		defer srv.Close()
+		workers = append(workers, srv.URL)
+	}
+
+	run := func() *CLIResult {
+		workDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(workDir, "in.txt"), []byte("hi"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+			{Name: "a", Inputs: []string{"in.txt"}, Run: "sleep 0.2; cat in.txt > a.txt", Outputs: []string{"a.txt"}},
+			{Name: "b", Inputs: []string{"in.txt"}, Run: "sleep 0.2; cat in.txt in.txt > b.txt", Outputs: []string{"b.txt"}},
+			{Name: "c", Inputs: []string{"a.txt", "b.txt"}, Run: "cat a.txt b.txt > c.txt", Outputs: []string{"c.txt"}},
+		}, []dag.Edge{{From: "a", To: "c"}, {From: "b", To: "c"}})
+
+		args := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--trace", "trace.json"}
+		for _, w := range workers {
+			args = append(args, "--remote-worker", w)
+		}
+		inv, err := ParseInvocation(args)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Execute(context.Background(), inv)
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+		if res.ExitCode != ExitSuccess {
+			t.Fatalf("expected success, got %d", res.ExitCode)
+		}
+		if b, err := os.ReadFile(filepath.Join(workDir, "c.txt")); err != nil || string(b) != "hihihi" {
+			t.Fatalf("expected downstream output built from remote artifacts, got %q (err=%v)", b, err)
+		}
+		return &res
+	}
+
+	first, second := run(), run()
+	if atomic.LoadInt32(&maxInFlight) < 2 {
+		t.Fatalf("expected independent tasks to run on workers concurrently")
+	}
+	if first.GraphResult.TraceHash == "" || first.GraphResult.TraceHash != second.GraphResult.TraceHash {
+		t.Fatalf("expected identical canonical traces, got %q and %q", first.GraphResult.TraceHash, second.GraphResult.TraceHash)
+	}
+
+	workDir := t.TempDir()
+	base := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out"}
+	if _, err := ParseInvocation(append(base, "--remote-worker", "not a url")); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected invalid --remote-worker to be rejected, got %v", err)
+	}
+	for _, flag := range []string{"--strict", "--isolate", "--protect-inputs"} {
+		if _, err := ParseInvocation(append(base, "--remote-worker", workers[0], flag)); ExitCode(err) != ExitInvalidInvocation {
+			t.Fatalf("expected %s with --remote-worker to be rejected, got %v", flag, err)
+		}
+	}
+}
+
+func TestExecute_RemoteWorkerToken(t *testing.T) {
+	srv := httptest.NewServer(&remote.Worker{ScratchRoot: t.TempDir(), Token: "s3cret"})
+	defer srv.Close()
+
+	run := func(token string) (CLIResult, error) {
+		workDir := t.TempDir()
+		writeGraphJSON(t, filepath.Join(workDir, "graph.json"), []core.Task{
+			{Name: "a", Run: "echo hi > a.txt", Outputs: []string{"a.txt"}},
+		}, nil)
+		args := []string{"--workdir", workDir, "--graph", "graph.json", "--cache-dir", "cache", "--output-dir", "out", "--remote-worker", srv.URL}
+		if token != "" {
+			tokenFile := filepath.Join(workDir, "token")
+			if err := os.WriteFile(tokenFile, []byte(token), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			args = append(args, "--remote-worker-token-file", tokenFile)
+		}
+		inv, err := ParseInvocation(args)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		return Execute(context.Background(), inv)
+	}
+
+	if res, err := run("s3cret"); err != nil || res.ExitCode != ExitSuccess {
+		t.Fatalf("expected success with the worker's token, got %d (err=%v)", res.ExitCode, err)
+	}
+	for _, token := range []string{"", "wrong"} {
+		if res, _ := run(token); res.ExitCode == ExitSuccess {
+			t.Fatalf("token %q: expected the worker to refuse the run", token)
+		}
+	}
+
+	workDir := t.TempDir()
+	tokenFile := filepath.Join(workDir, "token")
+	if err := os.WriteFile(tokenFile, []byte("s3cret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"--workdir", workDir, "--graph", "graph.json", "--remote-worker-token-file", tokenFile}
+	if _, err := ParseInvocation(args); ExitCode(err) != ExitInvalidInvocation {
+		t.Fatalf("expected --remote-worker-token-file without --remote-worker to be rejected, got %v", err)
+	}
+}
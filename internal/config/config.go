@@ -0,0 +1,138 @@
+// Package config reads scriptweaver.toml, the optional project config file
+// holding defaults for run flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"scriptweaver/internal/core"
+)
+
+// FileName is the config file looked up at the root of the working
+// directory.
+const FileName = "scriptweaver.toml"
+
+// Config is a decoded scriptweaver.toml:
+//
+//	cache_dir = ".cache"      # top-level keys default run flags
+//	mode = "incremental"
+//	concurrency = 4
+//
+//	[params]                  # defaults for --param
+//	release = true
+//
+//	[[normalize]]             # rules prepended to every task's normalize rules
+//	pattern = "build-[0-9]+"
+//	replacement = "build-N"
+//
+//	[[notify]]                # webhooks posted to when a run ends
+//	url = "https://example.com/hook"
+type Config struct {
+	// Settings maps top-level keys to their values in flag syntax.
+	Settings map[string]string
+	// Params maps param names to their values in --param syntax.
+	Params    map[string]string
+	Normalize []core.NormalizeRule
+	Notify    []Notifier
+}
+
+// Keys returns the setting keys in sorted order.
+func (c *Config) Keys() []string {
+	keys := make([]string, 0, len(c.Settings))
+	for k := range c.Settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Load reads FileName from workDir. A missing file is not an error: Load
+// returns nil.
+func Load(workDir string) (*Config, error) {
+	path := filepath.Join(workDir, FileName)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	cfg, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse decodes a config file. Tables other than params, normalize and
+// notify, and keys those tables do not define, are rejected.
+func Parse(b []byte) (*Config, error) {
+	doc, err := decodeTOML(b)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{Settings: map[string]string{}, Params: map[string]string{}}
+	for k, v := range doc.values {
+		cfg.Settings[k] = flagValue(v)
+	}
+	for name := range doc.tables {
+		if name != "params" {
+			return nil, fmt.Errorf("unknown table [%s]", name)
+		}
+	}
+	for k, v := range doc.tables["params"] {
+		cfg.Params[k] = flagValue(v)
+	}
+	for name := range doc.tableArrays {
+		if name != "normalize" && name != "notify" {
+			return nil, fmt.Errorf("unknown table [[%s]]", name)
+		}
+	}
+	for i, t := range doc.tableArrays["normalize"] {
+		var rule core.NormalizeRule
+		for k, v := range t {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("normalize rule %d: %s must be a string", i, k)
+			}
+			switch k {
+			case "pattern":
+				rule.Pattern = s
+			case "replacement":
+				rule.Replacement = s
+			default:
+				return nil, fmt.Errorf("normalize rule %d: unknown key %q", i, k)
+			}
+		}
+		cfg.Normalize = append(cfg.Normalize, rule)
+	}
+	for i, t := range doc.tableArrays["notify"] {
+		n, err := parseNotifier(i, t)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Notify = append(cfg.Notify, n)
+	}
+	// Compile the rules now so a bad pattern is reported against the config
+	// rather than against the first task it is applied to.
+	if _, err := core.NewRuleNormalizer(cfg.Normalize, nil); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func flagValue(v any) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return v.(string)
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(`# project defaults
+cache_dir = ".cache"   # relative to the workdir
+mode = 'clean'
+concurrency = 4
+normalize_logs = true
+max_output_bytes = 1_000
+
+[params]
+release = false
+target = "linux\u002Damd64"
+
+[[normalize]]
+pattern = "build-[0-9]+"
+replacement = "build-N"
+
+[[normalize]]
+pattern = 'C:\\tmp'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSettings := map[string]string{"cache_dir": ".cache", "mode": "clean", "concurrency": "4", "normalize_logs": "true", "max_output_bytes": "1000"}
+	if !reflect.DeepEqual(cfg.Settings, wantSettings) {
+		t.Fatalf("settings = %v", cfg.Settings)
+	}
+	if !reflect.DeepEqual(cfg.Params, map[string]string{"release": "false", "target": "linux-amd64"}) {
+		t.Fatalf("params = %v", cfg.Params)
+	}
+	wantRules := []core.NormalizeRule{{Pattern: "build-[0-9]+", Replacement: "build-N"}, {Pattern: `C:\\tmp`}}
+	if !reflect.DeepEqual(cfg.Normalize, wantRules) {
+		t.Fatalf("normalize = %v", cfg.Normalize)
+	}
+	if got := strings.Join(cfg.Keys(), ","); got != "cache_dir,concurrency,max_output_bytes,mode,normalize_logs" {
+		t.Fatalf("keys = %s", got)
+	}
+}
+
+func TestParse_Notify(t *testing.T) {
+	cfg, err := Parse([]byte(`[[notify]]
+url = "https://example.com/hook"
+
+[[notify]]
+url = "https://hooks.slack.com/services/T0/B0/x"
+format = "slack"
+on = "failure"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Notifier{
+		{URL: "https://example.com/hook", Format: NotifyFormatJSON, On: NotifyAlways},
+		{URL: "https://hooks.slack.com/services/T0/B0/x", Format: NotifyFormatSlack, On: NotifyOnFailure},
+	}
+	if !reflect.DeepEqual(cfg.Notify, want) {
+		t.Fatalf("notify = %+v", cfg.Notify)
+	}
+	if !want[1].Wants(false) || want[1].Wants(true) {
+		t.Fatalf("on = failure must only notify failed runs")
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := map[string]string{
+		"duplicate key":    "mode = \"a\"\nmode = \"b\"\n",
+		"unknown table":    "[remote]\nurl = \"x\"\n",
+		"unknown rule key": "[[normalize]]\npattern = \"a\"\nflags = \"i\"\n",
+		"array value":      "remote = [\"a\"]\n",
+		"float value":      "concurrency = 1.5\n",
+		"unterminated":     "mode = \"clean\n",
+		"trailing data":    "mode = \"clean\" x\n",
+		"bad pattern":      "[[normalize]]\npattern = \"(\"\n",
+		"notify url":       "[[notify]]\nurl = \"ftp://x\"\n",
+		"notify format":    "[[notify]]\nurl = \"https://x\"\nformat = \"xml\"\n",
+		"notify trigger":   "[[notify]]\nurl = \"https://x\"\non = \"never\"\n",
+		"missing equals":   "mode\n",
+	}
+	for name, src := range cases {
+		if _, err := Parse([]byte(src)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	_, err := Parse([]byte("mode = \"clean\"\n\nmode = 1\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("expected the error to name line 3, got %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if cfg, err := Load(dir); cfg != nil || err != nil {
+		t.Fatalf("missing file: cfg %v err %v", cfg, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("mode = \n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); err == nil || !strings.Contains(err.Error(), FileName) {
+		t.Fatalf("expected the error to name the file, got %v", err)
+	}
+}
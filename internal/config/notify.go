@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Notifier formats, selecting the payload posted to Notifier.URL.
+const (
+	// NotifyFormatJSON posts the run summary as JSON (the default).
+	NotifyFormatJSON = "json"
+	// NotifyFormatSlack posts a Slack incoming-webhook message ({"text": ...}).
+	NotifyFormatSlack = "slack"
+)
+
+// Notifier triggers, selecting which runs Notifier.URL is told about.
+const (
+	NotifyAlways    = "always" // the default
+	NotifyOnFailure = "failure"
+	NotifyOnSuccess = "success"
+)
+
+// Notifier is one [[notify]] table: a webhook posted to when a run ends.
+//
+//	[[notify]]
+//	url = "https://hooks.slack.com/services/..."
+//	format = "slack"          # or "json" (default)
+//	on = "failure"            # or "success", "always" (default)
+type Notifier struct {
+	URL    string
+	Format string
+	On     string
+}
+
+// Wants reports whether n is notified of a run that succeeded or not.
+func (n Notifier) Wants(succeeded bool) bool {
+	switch n.On {
+	case NotifyOnFailure:
+		return !succeeded
+	case NotifyOnSuccess:
+		return succeeded
+	default:
+		return true
+	}
+}
+
+// parseNotifier decodes the i'th [[notify]] table, filling in defaults.
+func parseNotifier(i int, t map[string]any) (Notifier, error) {
+	n := Notifier{Format: NotifyFormatJSON, On: NotifyAlways}
+	for k, v := range t {
+		s, ok := v.(string)
+		if !ok {
+			return Notifier{}, fmt.Errorf("notify %d: %s must be a string", i, k)
+		}
+		switch k {
+		case "url":
+			n.URL = s
+		case "format":
+			n.Format = s
+		case "on":
+			n.On = s
+		default:
+			return Notifier{}, fmt.Errorf("notify %d: unknown key %q", i, k)
+		}
+	}
+	if u, err := url.Parse(n.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Notifier{}, fmt.Errorf("notify %d: url must be an http(s) URL, got %q", i, n.URL)
+	}
+	switch n.Format {
+	case NotifyFormatJSON, NotifyFormatSlack:
+	default:
+		return Notifier{}, fmt.Errorf("notify %d: unknown format %q (expected json|slack)", i, n.Format)
+	}
+	switch n.On {
+	case NotifyAlways, NotifyOnFailure, NotifyOnSuccess:
+	default:
+		return Notifier{}, fmt.Errorf("notify %d: unknown trigger %q (expected always|failure|success)", i, n.On)
+	}
+	return n, nil
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is a decoded TOML file: top-level values, tables and arrays of
+// tables. Values are string, int64 or bool.
+type document struct {
+	values      map[string]any
+	tables      map[string]map[string]any
+	tableArrays map[string][]map[string]any
+}
+
+// decodeTOML decodes the subset of TOML that scriptweaver.toml needs:
+// comments, bare keys, basic and literal strings, integers, booleans,
+// [table] and [[array of tables]] headers. Anything else is an error rather
+// than silently ignored, so a config never means something different from
+// what it says.
+func decodeTOML(src []byte) (document, error) {
+	doc := document{
+		values:      map[string]any{},
+		tables:      map[string]map[string]any{},
+		tableArrays: map[string][]map[string]any{},
+	}
+	if !utf8.Valid(src) {
+		return doc, fmt.Errorf("not valid UTF-8")
+	}
+	current := doc.values
+	for i, line := range strings.Split(string(src), "\n") {
+		lineNo := i + 1
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			header, rest, err := parseHeader(line)
+			if err != nil {
+				return doc, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if err := checkTrailing(rest); err != nil {
+				return doc, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			name := strings.Trim(header, "[]")
+			if _, dup := doc.values[name]; dup {
+				return doc, fmt.Errorf("line %d: %q is already a key", lineNo, name)
+			}
+			if strings.HasPrefix(header, "[[") {
+				if _, dup := doc.tables[name]; dup {
+					return doc, fmt.Errorf("line %d: %q is already a table", lineNo, name)
+				}
+				current = map[string]any{}
+				doc.tableArrays[name] = append(doc.tableArrays[name], current)
+				continue
+			}
+			if _, dup := doc.tables[name]; dup {
+				return doc, fmt.Errorf("line %d: duplicate table %q", lineNo, name)
+			}
+			if _, dup := doc.tableArrays[name]; dup {
+				return doc, fmt.Errorf("line %d: %q is already an array of tables", lineNo, name)
+			}
+			current = map[string]any{}
+			doc.tables[name] = current
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !isBareKey(key) {
+			return doc, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		value, rest, err := parseValue(strings.TrimSpace(raw))
+		if err != nil {
+			return doc, fmt.Errorf("line %d: %s: %w", lineNo, key, err)
+		}
+		if err := checkTrailing(rest); err != nil {
+			return doc, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if _, dup := current[key]; dup {
+			return doc, fmt.Errorf("line %d: duplicate key %q", lineNo, key)
+		}
+		current[key] = value
+	}
+	return doc, nil
+}
+
+func parseHeader(line string) (header, rest string, err error) {
+	open, closing := "[", "]"
+	if strings.HasPrefix(line, "[[") {
+		open, closing = "[[", "]]"
+	}
+	end := strings.Index(line, closing)
+	if end < 0 {
+		return "", "", fmt.Errorf("unterminated table header")
+	}
+	name := strings.TrimSpace(line[len(open):end])
+	if !isBareKey(name) {
+		return "", "", fmt.Errorf("unsupported table name %q", name)
+	}
+	return open + name + closing, line[end+len(closing):], nil
+}
+
+// checkTrailing accepts only whitespace and a comment after a value.
+func checkTrailing(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected %q", rest)
+	}
+	return nil
+}
+
+func isBareKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseValue parses the value at the start of s and returns what follows it.
+func parseValue(s string) (any, string, error) {
+	switch {
+	case s == "":
+		return nil, "", fmt.Errorf("missing value")
+	case strings.HasPrefix(s, `"""`), strings.HasPrefix(s, "'''"):
+		return nil, "", fmt.Errorf("multi-line strings are not supported")
+	case s[0] == '"':
+		return parseBasicString(s)
+	case s[0] == '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return nil, "", fmt.Errorf("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	}
+	token, rest, _ := strings.Cut(s, " ")
+	token, comment, hasComment := strings.Cut(token, "#")
+	if hasComment {
+		rest = "#" + comment + " " + rest
+	}
+	switch token {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	digits := strings.TrimLeft(token, "+-")
+	if digits == "" || strings.HasPrefix(digits, "_") || strings.HasSuffix(digits, "_") || strings.Contains(digits, "__") ||
+		(len(digits) > 1 && digits[0] == '0') {
+		return nil, "", fmt.Errorf("unsupported value %q", token)
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(token, "_", ""), 10, 64)
+	if err != nil {
+		return nil, "", fmt.Errorf("unsupported value %q", token)
+	}
+	return n, rest, nil
+}
+
+func parseBasicString(s string) (string, string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			return b.String(), s[i+1:], nil
+		case c == '\\':
+			if i+1 >= len(s) {
+				return "", "", fmt.Errorf("unterminated string")
+			}
+			i++
+			switch s[i] {
+			case 'b':
+				b.WriteByte('\b')
+			case 't':
+				b.WriteByte('\t')
+			case 'n':
+				b.WriteByte('\n')
+			case 'f':
+				b.WriteByte('\f')
+			case 'r':
+				b.WriteByte('\r')
+			case '"':
+				b.WriteByte('"')
+			case '\\':
+				b.WriteByte('\\')
+			case 'u', 'U':
+				size := 4
+				if s[i] == 'U' {
+					size = 8
+				}
+				if i+size >= len(s) {
+					return "", "", fmt.Errorf("invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(r)) {
+					return "", "", fmt.Errorf("invalid unicode escape")
+				}
+				b.WriteRune(rune(r))
+				i += size
+			default:
+				return "", "", fmt.Errorf("invalid escape \\%c", s[i])
+			}
+		case c < 0x20 && c != '\t':
+			return "", "", fmt.Errorf("control character in string")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string")
+}
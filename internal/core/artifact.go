@@ -1,6 +1,8 @@
 // Package core defines the domain models for deterministic task execution.
 package core
 
+import "os"
+
 // Artifact represents a file or directory produced by a task
 // and explicitly declared in outputs.
 //
@@ -18,6 +20,18 @@ type Artifact struct {
 	// Content is the normalized file content.
 	// Timestamps and other nondeterministic data are stripped.
 	Content []byte
+
+	// SourcePath, when set, is the on-disk file holding the content of a
+	// streamed artifact; Content is then nil. Use Open to read either form.
+	SourcePath string
+
+	// Mode is the normalized permission (ArtifactModeRegular or
+	// ArtifactModeExecutable); zero for symlinks.
+	Mode os.FileMode
+
+	// LinkTarget is the target of a symlink artifact, recorded verbatim and
+	// not followed. Content is empty for symlinks.
+	LinkTarget string
 }
 
 // ArtifactSet represents the complete set of artifacts produced by a task.
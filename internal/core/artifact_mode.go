@@ -0,0 +1,98 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Artifact permissions. Harvested modes are reduced to one of these so the
+// recorded metadata does not depend on the umask of the machine that ran the
+// task; only the executable bit survives.
+const (
+	ArtifactModeRegular    os.FileMode = 0o644
+	ArtifactModeExecutable os.FileMode = 0o755
+)
+
+// normalizeArtifactMode maps a file's mode to ArtifactModeExecutable if any
+// execute bit is set, otherwise ArtifactModeRegular.
+func normalizeArtifactMode(m os.FileMode) os.FileMode {
+	if m.Perm()&0o111 != 0 {
+		return ArtifactModeExecutable
+	}
+	return ArtifactModeRegular
+}
+
+// artifactPerm returns the permissions to restore an artifact with. Entries
+// written before modes were recorded restore as ArtifactModeRegular.
+func artifactPerm(m os.FileMode) os.FileMode {
+	if m == 0 {
+		return ArtifactModeRegular
+	}
+	return m.Perm()
+}
+
+// artifactMetadataField is the stable encoding of an artifact's mode and
+// symlink target used when hashing artifact sets.
+func artifactMetadataField(mode os.FileMode, linkTarget string) []byte {
+	if linkTarget != "" {
+		return []byte("symlink:" + linkTarget)
+	}
+	return []byte(fmt.Sprintf("mode:%04o", artifactPerm(mode)))
+}
+
+// ArtifactMetadata returns the stable encoding of a's mode and symlink target,
+// for callers that hash artifact sets.
+func (a Artifact) ArtifactMetadata() []byte {
+	return artifactMetadataField(a.Mode, a.LinkTarget)
+}
+
+// existingFileState inspects the file at path without following symlinks. It
+// reports whether a regular file is there with content digest wantHash, and
+// whether its permissions equal perm.
+func existingFileState(path, wantHash string, perm os.FileMode) (contentOK, modeOK bool, err error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	if !info.Mode().IsRegular() {
+		return false, false, nil
+	}
+	haveHash, ok, err := fileSHA256HexIfExists(path)
+	if err != nil || !ok {
+		return false, false, err
+	}
+	return haveHash == wantHash, info.Mode().Perm() == perm, nil
+}
+
+// restoreSymlink makes path a symlink to linkTarget, replacing whatever is
+// there atomically. It reports whether anything changed. Targets that leave
+// workDir (see LinkWithinWorkDir) are rejected with ErrPathOutsideWorkDir.
+func restoreSymlink(workDir, path, linkTarget string) (bool, error) {
+	if !LinkWithinWorkDir(workDir, path, linkTarget) {
+		return false, fmt.Errorf("target %q: %w", linkTarget, ErrPathOutsideWorkDir)
+	}
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if have, err := os.Readlink(path); err == nil && have == linkTarget {
+			return false, nil
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp.*")
+	if err != nil {
+		return false, err
+	}
+	tmpName := tmp.Name()
+	_ = tmp.Close()
+	_ = os.Remove(tmpName)
+	if err := os.Symlink(linkTarget, tmpName); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunner_PreservesModesAndSymlinks(t *testing.T) {
+	workDir := t.TempDir()
+	cache := NewFileCache(t.TempDir())
+	runner := NewRunner(workDir, cache)
+	task := &Task{
+		Name:    "build",
+		Run:     "mkdir -p bin && printf '#!/bin/sh\\n' > bin/tool && chmod 700 bin/tool && printf doc > bin/README && ln -s tool bin/latest",
+		Outputs: []string{"bin"},
+	}
+
+	if _, err := runner.Run(context.Background(), task); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(workDir, "bin")); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := runner.Run(context.Background(), task)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !res.FromCache || res.ArtifactsRestored != 3 {
+		t.Fatalf("expected 3 artifacts restored from cache, got %+v", res)
+	}
+	assertMode(t, filepath.Join(workDir, "bin", "tool"), ArtifactModeExecutable)
+	assertMode(t, filepath.Join(workDir, "bin", "README"), ArtifactModeRegular)
+	if target, err := os.Readlink(filepath.Join(workDir, "bin", "latest")); err != nil || target != "tool" {
+		t.Fatalf("expected symlink to tool, got %q (err=%v)", target, err)
+	}
+
+	// A mode-only drift is repaired without rewriting anything else.
+	if err := os.Chmod(filepath.Join(workDir, "bin", "tool"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = runner.Run(context.Background(), task)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.ArtifactsRestored != 1 {
+		t.Fatalf("expected only the mode to be restored, got %d", res.ArtifactsRestored)
+	}
+	assertMode(t, filepath.Join(workDir, "bin", "tool"), ArtifactModeExecutable)
+}
+
+func TestHarvester_RecordsModeAndLinkTarget(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "run.sh"), "#!/bin/sh\n")
+	if err := os.Chmod(filepath.Join(dir, "run.sh"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("missing-target", filepath.Join(dir, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := NewHarvester(dir).Harvest([]string{"run.sh", "dangling"})
+	if err != nil {
+		t.Fatalf("Harvest: %v", err)
+	}
+	link, script := set.Artifacts[0], set.Artifacts[1]
+	if link.LinkTarget != "missing-target" || link.Mode != 0 || len(link.Content) != 0 {
+		t.Fatalf("unexpected symlink artifact: %+v", link)
+	}
+	if script.Mode != ArtifactModeExecutable || script.LinkTarget != "" {
+		t.Fatalf("unexpected script artifact: %+v", script)
+	}
+	if string(link.ArtifactMetadata()) == string(script.ArtifactMetadata()) {
+		t.Fatalf("expected distinct metadata encodings")
+	}
+}
+
+func TestSymlinkArtifacts_RejectTargetsOutsideWorkDir(t *testing.T) {
+	for _, target := range []string{"/etc/passwd", "../escape", "sub/../../escape"} {
+		dir := t.TempDir()
+		if err := os.Symlink(target, filepath.Join(dir, "link")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewHarvester(dir).Harvest([]string{"link"}); !errors.Is(err, ErrPathOutsideWorkDir) {
+			t.Fatalf("harvest %q: expected ErrPathOutsideWorkDir, got %v", target, err)
+		}
+
+		restoreDir := t.TempDir()
+		entry := &CacheEntry{Hash: TaskHash("h"), Artifacts: []CachedArtifact{{Path: "link", Content: []byte{}, LinkTarget: target}}}
+		if _, err := NewReplayer(restoreDir).RestoreArtifacts("t", entry); !errors.Is(err, ErrPathOutsideWorkDir) {
+			t.Fatalf("restore %q: expected ErrPathOutsideWorkDir, got %v", target, err)
+		}
+		if _, err := os.Lstat(filepath.Join(restoreDir, "link")); !os.IsNotExist(err) {
+			t.Fatalf("restore %q: symlink was created (err=%v)", target, err)
+		}
+	}
+}
+
+func assertMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatalf("stat %s: %v", path, err)
+	}
+	if info.Mode().Perm() != want {
+		t.Fatalf("%s: expected mode %o, got %o", path, want, info.Mode().Perm())
+	}
+}
@@ -0,0 +1,345 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FileAccess is one file a traced task opened, executed or removed.
+type FileAccess struct {
+	// Path is absolute and clean.
+	Path string
+
+	// Write is set for opens with a write or create flag, and for unlinks
+	// and renames.
+	Write bool
+}
+
+// TaskAccesses is what a task did while traced (see Executor.Strace).
+type TaskAccesses struct {
+	// Files lists successful file accesses in trace order.
+	Files []FileAccess
+
+	// Network lists distinct network operations, sorted, such as
+	// "socket AF_INET" or "connect 10.0.0.1:443". Failed attempts count.
+	Network []string
+}
+
+// AuditReport classifies a traced task's accesses against its declaration
+// (see Runner.Audit). Paths under the working directory are relative to it.
+type AuditReport struct {
+	Task string `json:"task"`
+
+	// UndeclaredReads are files under the working directory that the task
+	// read but that are neither resolved inputs nor declared outputs.
+	UndeclaredReads []string `json:"undeclared_reads,omitempty"`
+
+	// UndeclaredWrites are files under the working directory that the task
+	// wrote, removed or renamed outside its declared outputs.
+	UndeclaredWrites []string `json:"undeclared_writes,omitempty"`
+
+	// ExternalReads are files outside the working directory the task read
+	// and that are not declared inputs, such as tools and system libraries.
+	// /proc, /sys and /dev are left out.
+	ExternalReads []string `json:"external_reads,omitempty"`
+
+	// Network lists the task's network operations (see TaskAccesses).
+	Network []string `json:"network,omitempty"`
+}
+
+// Hermetic reports whether the task touched nothing beyond its declaration
+// within the working directory and used no network.
+func (a AuditReport) Hermetic() bool {
+	return len(a.UndeclaredReads) == 0 && len(a.UndeclaredWrites) == 0 && len(a.Network) == 0
+}
+
+// straceArgs are the arguments tracing cmd under strace, logging to logPath.
+// -y annotates file descriptors with the paths they refer to, which resolves
+// relative and dirfd-based opens.
+func straceArgs(logPath string, cmd ...string) []string {
+	args := []string{"-f", "-qq", "-y", "-s", "4096", "-e", "trace=%file,%network", "-o", logPath}
+	return append(args, cmd...)
+}
+
+// auditReport classifies acc for task, which ran in execDir. inputs are the
+// task's resolved inputs and outputs its declared outputs, both as seen from
+// workDir.
+func auditReport(task *Task, acc *TaskAccesses, execDir, workDir string, inputs *InputSet) AuditReport {
+	rep := AuditReport{Task: task.Name, Network: acc.Network}
+	declared := make(map[string]bool)
+	if inputs != nil {
+		for _, in := range inputs.Inputs {
+			p := filepath.Clean(filepath.FromSlash(in.Path))
+			declared[p] = true
+			if rel, ok := relWithin(workDir, p); ok {
+				declared[filepath.Join(execDir, rel)] = true
+			}
+		}
+	}
+	outputs := make([]string, 0, len(task.Outputs))
+	for _, o := range ManifestOutputs(task.Outputs) {
+		if filepath.IsAbs(filepath.FromSlash(o)) {
+			if rel, ok := relWithin(workDir, filepath.FromSlash(o)); ok {
+				o = rel
+			}
+		}
+		outputs = append(outputs, o)
+	}
+
+	written := make(map[string]bool)
+	for _, f := range acc.Files {
+		if f.Write {
+			written[f.Path] = true
+		}
+	}
+	reads, writes, external := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, f := range acc.Files {
+		rel, inside := relWithin(execDir, f.Path)
+		switch {
+		case !inside:
+			if !f.Write && !declared[f.Path] && !systemPath(f.Path) {
+				external[f.Path] = true
+			}
+		case rel == "." || coveredBy(rel, outputs):
+		case f.Write:
+			writes[rel] = true
+		case !declared[f.Path] && !written[f.Path]:
+			reads[rel] = true
+		}
+	}
+	rep.UndeclaredReads = sortedSet(reads)
+	rep.UndeclaredWrites = sortedSet(writes)
+	rep.ExternalReads = sortedSet(external)
+	return rep
+}
+
+// relWithin returns p relative to root, slash-separated, when p is root or
+// lies beneath it.
+func relWithin(root, p string) (string, bool) {
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// systemPath reports whether p is under a kernel pseudo filesystem, whose
+// reads say nothing about a task's inputs.
+func systemPath(p string) bool {
+	for _, dir := range []string{"/proc", "/sys", "/dev"} {
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedSet(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+var (
+	// straceLine splits "PID name(args) = ret" into its parts.
+	straceLine = regexp.MustCompile(`^(\d+)\s+(\w+)\((.*)\)\s+=\s+(.*)$`)
+	// straceFD matches a file descriptor annotated by -y, e.g. 3</etc/hosts>.
+	straceFD    = regexp.MustCompile(`^(?:\d+|AT_FDCWD)<(.*)>$`)
+	sockPort    = regexp.MustCompile(`sin6?_port=htons\((\d+)\)`)
+	sockAddr4   = regexp.MustCompile(`inet_addr\("([^"]*)"\)`)
+	sockAddr6   = regexp.MustCompile(`inet_pton\(AF_INET6,\s*"([^"]*)"`)
+	sockFamily  = regexp.MustCompile(`sa_family=(AF_INET6?)\b`)
+	writeFlags  = regexp.MustCompile(`\b(O_WRONLY|O_RDWR|O_CREAT|O_TRUNC)\b`)
+	socketFlags = regexp.MustCompile(`^(AF_INET6?)\b`)
+)
+
+// parseStraceLog reads a log written by strace with straceArgs. Relative
+// paths the log cannot resolve through -y annotations are taken relative to
+// cwd, the directory the task started in.
+func parseStraceLog(r io.Reader, cwd string) (*TaskAccesses, error) {
+	acc := &TaskAccesses{}
+	network := make(map[string]bool)
+	pending := make(map[string]string)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		pid, rest, _ := strings.Cut(line, " ")
+		rest = strings.TrimSpace(rest)
+		switch {
+		case strings.HasSuffix(rest, "<unfinished ...>"):
+			pending[pid] = strings.TrimSuffix(rest, "<unfinished ...>")
+			continue
+		case strings.HasPrefix(rest, "<... "):
+			_, tail, ok := strings.Cut(rest, " resumed>")
+			if !ok {
+				continue
+			}
+			rest = pending[pid] + tail
+			delete(pending, pid)
+		}
+		m := straceLine.FindStringSubmatch(pid + " " + rest)
+		if m == nil {
+			continue
+		}
+		name, args, ret := m[2], splitStraceArgs(m[3]), m[4]
+		failed := strings.HasPrefix(ret, "-1")
+		switch name {
+		case "socket":
+			if len(args) > 0 {
+				if f := socketFlags.FindStringSubmatch(args[0]); f != nil {
+					network["socket "+f[1]] = true
+				}
+			}
+		case "connect", "bind", "sendto", "sendmsg":
+			if op := networkOp(name, m[3]); op != "" {
+				network[op] = true
+			}
+		}
+		if failed {
+			continue
+		}
+		var files []FileAccess
+		switch name {
+		case "open", "creat":
+			files = openAccess(name, args, 0, ret, cwd)
+		case "openat", "openat2":
+			files = openAccess(name, args, 1, ret, cwd)
+		case "execve":
+			files = pathAccess(args, -1, 0, false, cwd)
+		case "execveat":
+			files = pathAccess(args, 0, 1, false, cwd)
+		case "unlink", "rmdir":
+			files = pathAccess(args, -1, 0, true, cwd)
+		case "unlinkat":
+			files = pathAccess(args, 0, 1, true, cwd)
+		case "rename":
+			files = append(pathAccess(args, -1, 0, true, cwd), pathAccess(args, -1, 1, true, cwd)...)
+		case "renameat", "renameat2":
+			files = append(pathAccess(args, 0, 1, true, cwd), pathAccess(args, 2, 3, true, cwd)...)
+		}
+		acc.Files = append(acc.Files, files...)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading strace log: %w", err)
+	}
+	acc.Network = sortedSet(network)
+	return acc, nil
+}
+
+// openAccess records an open whose path is args[pathArg] and whose flags
+// follow it. The path the returned descriptor is annotated with wins over the
+// argument. Directory opens are not recorded.
+func openAccess(name string, args []string, pathArg int, ret, cwd string) []FileAccess {
+	if len(args) <= pathArg {
+		return nil
+	}
+	flags := strings.Join(args[pathArg+1:], ",")
+	if strings.Contains(flags, "O_DIRECTORY") {
+		return nil
+	}
+	write := name == "creat" || writeFlags.MatchString(flags)
+	if m := straceFD.FindStringSubmatch(strings.TrimSpace(ret)); m != nil && filepath.IsAbs(m[1]) {
+		return []FileAccess{{Path: filepath.Clean(m[1]), Write: write}}
+	}
+	dirArg := -1
+	if pathArg > 0 {
+		dirArg = pathArg - 1
+	}
+	return pathAccess(args, dirArg, pathArg, write, cwd)
+}
+
+// pathAccess records the path in args[pathArg], resolved against the
+// directory descriptor in args[dirArg] (none when dirArg < 0) or cwd.
+func pathAccess(args []string, dirArg, pathArg int, write bool, cwd string) []FileAccess {
+	if pathArg >= len(args) {
+		return nil
+	}
+	p, ok := straceString(args[pathArg])
+	if !ok || p == "" {
+		return nil
+	}
+	if !filepath.IsAbs(p) {
+		base := cwd
+		if dirArg >= 0 && dirArg < len(args) {
+			if m := straceFD.FindStringSubmatch(strings.TrimSpace(args[dirArg])); m != nil && filepath.IsAbs(m[1]) {
+				base = m[1]
+			}
+		}
+		p = filepath.Join(base, p)
+	}
+	return []FileAccess{{Path: filepath.Clean(p), Write: write}}
+}
+
+// networkOp describes a connect, bind, sendto or sendmsg on an internet
+// socket; other address families yield "".
+func networkOp(name, args string) string {
+	f := sockFamily.FindStringSubmatch(args)
+	if f == nil {
+		return ""
+	}
+	addr := ""
+	if f[1] == "AF_INET6" {
+		if m := sockAddr6.FindStringSubmatch(args); m != nil {
+			addr = "[" + m[1] + "]"
+		}
+	} else if m := sockAddr4.FindStringSubmatch(args); m != nil {
+		addr = m[1]
+	}
+	if m := sockPort.FindStringSubmatch(args); m != nil && addr != "" {
+		return name + " " + addr + ":" + m[1]
+	}
+	return name + " " + f[1]
+}
+
+// splitStraceArgs splits a syscall's argument list at top-level commas,
+// respecting quoted strings, braces and brackets.
+func splitStraceArgs(s string) []string {
+	var args []string
+	depth, start, quoted := 0, 0, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quoted:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quoted = false
+			}
+		case c == '"':
+			quoted = true
+		case c == '{' || c == '[' || c == '(':
+			depth++
+		case c == '}' || c == ']' || c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(args, strings.TrimSpace(s[start:]))
+}
+
+// straceString decodes a quoted strace string argument. Truncated strings
+// (followed by "...") are rejected.
+func straceString(arg string) (string, bool) {
+	if len(arg) < 2 || arg[0] != '"' || arg[len(arg)-1] != '"' {
+		return "", false
+	}
+	if s, err := strconv.Unquote(arg); err == nil {
+		return s, true
+	}
+	return arg[1 : len(arg)-1], true
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleStraceLog = `101 execve("/usr/bin/sh", ["sh", "-c", "cat in.txt extra.txt > out/a.txt"], 0x7ffd /* 0 vars */) = 0
+101 openat(AT_FDCWD, "/etc/ld.so.cache", O_RDONLY|O_CLOEXEC) = 3</etc/ld.so.cache>
+101 openat(AT_FDCWD, "/proc/self/maps", O_RDONLY) = 3</proc/self/maps>
+101 openat(AT_FDCWD, "out/a.txt", O_WRONLY|O_CREAT|O_TRUNC, 0666) = 3</work/out/a.txt>
+102 openat(AT_FDCWD, "in.txt", O_RDONLY <unfinished ...>
+101 openat(AT_FDCWD, ".", O_RDONLY|O_DIRECTORY) = 4</work>
+102 <... openat resumed>) = 3</work/in.txt>
+102 openat(AT_FDCWD, "extra.txt", O_RDONLY) = 3</work/extra.txt>
+102 openat(AT_FDCWD, "missing.txt", O_RDONLY) = -1 ENOENT (No such file or directory)
+102 openat(3</work/sub>, "nested.txt", O_RDONLY) = -1 EBADF (Bad file descriptor)
+102 open("rel.txt", O_RDONLY) = 5
+102 unlinkat(AT_FDCWD, "stale.tmp", 0) = 0
+102 socket(AF_INET, SOCK_STREAM|SOCK_CLOEXEC, IPPROTO_TCP) = 6<TCP:[7]>
+102 connect(6<TCP:[7]>, {sa_family=AF_INET, sin_port=htons(443), sin_addr=inet_addr("10.0.0.1")}, 16) = -1 ENETUNREACH (Network is unreachable)
+102 socket(AF_UNIX, SOCK_STREAM, 0) = 7<UNIX:[8]>
+102 connect(7<UNIX:[8]>, {sa_family=AF_UNIX, sun_path="/run/nscd/socket"}, 110) = -1 ENOENT (No such file or directory)
+102 +++ exited with 0 +++
+`
+
+func TestParseStraceLog(t *testing.T) {
+	acc, err := parseStraceLog(strings.NewReader(sampleStraceLog), "/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileAccess{
+		{Path: "/usr/bin/sh"},
+		{Path: "/etc/ld.so.cache"},
+		{Path: "/proc/self/maps"},
+		{Path: "/work/out/a.txt", Write: true},
+		{Path: "/work/in.txt"},
+		{Path: "/work/extra.txt"},
+		{Path: "/work/rel.txt"},
+		{Path: "/work/stale.tmp", Write: true},
+	}
+	if !reflect.DeepEqual(acc.Files, want) {
+		t.Fatalf("files = %+v\nwant %+v", acc.Files, want)
+	}
+	if want := []string{"connect 10.0.0.1:443", "socket AF_INET"}; !reflect.DeepEqual(acc.Network, want) {
+		t.Fatalf("network = %v, want %v", acc.Network, want)
+	}
+}
+
+func TestAuditReport_ClassifiesAccesses(t *testing.T) {
+	acc, err := parseStraceLog(strings.NewReader(sampleStraceLog), "/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := &Task{Name: "build", Inputs: []string{"in.txt"}, Outputs: []string{"out/"}}
+	inputs := &InputSet{Inputs: []Input{{Path: "/work/in.txt"}, {Path: "/usr/bin/sh"}}}
+	got := auditReport(task, acc, "/work", "/work", inputs)
+	want := AuditReport{
+		Task:             "build",
+		UndeclaredReads:  []string{"extra.txt", "rel.txt"},
+		UndeclaredWrites: []string{"stale.tmp"},
+		ExternalReads:    []string{"/etc/ld.so.cache"},
+		Network:          []string{"connect 10.0.0.1:443", "socket AF_INET"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("report = %+v\nwant %+v", got, want)
+	}
+	if got.Hermetic() {
+		t.Fatal("report with undeclared accesses must not be hermetic")
+	}
+}
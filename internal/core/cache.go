@@ -2,6 +2,9 @@
 package core
 
 import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -33,6 +36,12 @@ type CacheEntry struct {
 
 	// Artifacts contains the harvested output files.
 	Artifacts []CachedArtifact `json:"artifacts"`
+
+	// DeclaredOutputs is the task's declared output list (sorted) at the time
+	// the entry was stored. It is the entry's expected-outputs manifest and is
+	// checked against the task's current declarations before restoring.
+	// Entries written before this field existed leave it empty.
+	DeclaredOutputs []string `json:"declared_outputs,omitempty"`
 }
 
 // CachedArtifact represents a single artifact stored in the cache.
@@ -42,6 +51,27 @@ type CachedArtifact struct {
 
 	// Content is the artifact file content.
 	Content []byte `json:"content"`
+
+	// SHA256 is the hex digest of Content, recorded by FileCache so blobs can
+	// be verified independently of a run. Empty for entries written before it
+	// was recorded.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Compression is the codec the blob is stored with (empty for raw). SHA256
+	// always covers the uncompressed content.
+	Compression string `json:"compression,omitempty"`
+
+	// Mode is the artifact's normalized permission bits. Zero (entries written
+	// before modes were recorded, and symlinks) restores as ArtifactModeRegular.
+	Mode os.FileMode `json:"mode,omitempty"`
+
+	// LinkTarget marks a symlink artifact; it is restored as a symlink to this
+	// target and its blob is empty.
+	LinkTarget string `json:"link_target,omitempty"`
+
+	// source is the file a streamed artifact is read from when Content is nil
+	// (see Streamed and Open).
+	source string
 }
 
 // Cache provides storage and retrieval of task execution results.
@@ -80,11 +110,28 @@ type Cache interface {
 type FileCache struct {
 	// CacheDir is the root directory for cache storage.
 	CacheDir string
+
+	// Compression is the codec used for blobs written by Put (CompressionNone
+	// or CompressionGzip). Get reads each blob with the codec recorded in its
+	// metadata, so entries written with different settings coexist.
+	Compression string
+
+	// StreamThreshold is the blob size at or above which Get leaves the
+	// artifact on disk (Streamed) rather than loading it; zero loads every
+	// blob. Streamed blobs are digest-checked when read, not by Get.
+	StreamThreshold int64
+
+	// SigningKey, when set, signs the metadata of every entry written by Put.
+	SigningKey ed25519.PrivateKey
+
+	// TrustedKeys, when non-empty, makes Get and Verify reject entries not
+	// signed by one of these keys with a *CacheSignatureError.
+	TrustedKeys []ed25519.PublicKey
 }
 
 // NewFileCache creates a new filesystem-based cache.
 func NewFileCache(cacheDir string) *FileCache {
-	return &FileCache{CacheDir: cacheDir}
+	return &FileCache{CacheDir: cacheDir, StreamThreshold: DefaultStreamThreshold}
 }
 
 // Has checks if a cache entry exists for the given hash.
@@ -104,6 +151,11 @@ func (c *FileCache) Has(hash TaskHash) (bool, error) {
 }
 
 // Get retrieves a cache entry by hash.
+//
+// Damaged entries (unparseable metadata, missing blobs, digest mismatches)
+// return an error wrapping *CacheCorruptError so callers can tell corruption
+// from I/O failures. With TrustedKeys set, entries not signed by one of them
+// return a *CacheSignatureError before anything else is read.
 func (c *FileCache) Get(hash TaskHash) (*CacheEntry, error) {
 	entryDir := c.entryPath(hash)
 	metadataPath := filepath.Join(entryDir, "metadata.json")
@@ -116,21 +168,48 @@ func (c *FileCache) Get(hash TaskHash) (*CacheEntry, error) {
 		}
 		return nil, fmt.Errorf("reading cache metadata: %w", err)
 	}
+	if err := c.verifyEntrySignature(hash, entryDir, data); err != nil {
+		return nil, err
+	}
 
 	var entry CacheEntry
 	if err := json.Unmarshal(data, &entry); err != nil {
-		return nil, fmt.Errorf("parsing cache metadata: %w", err)
+		return nil, fmt.Errorf("parsing cache metadata: %w", &CacheCorruptError{Hash: hash, Reason: err.Error()})
+	}
+	if entry.Hash != hash {
+		return nil, &CacheCorruptError{Hash: hash, Reason: fmt.Sprintf("metadata hash %q does not match entry key", entry.Hash)}
 	}
 
 	// Read artifact contents
 	artifactsDir := filepath.Join(entryDir, "artifacts")
 	for i := range entry.Artifacts {
 		blobPath := filepath.Join(artifactsDir, fmt.Sprintf("%d.blob", i))
+		if c.StreamThreshold > 0 {
+			info, err := os.Stat(blobPath)
+			if err == nil && info.Size() >= c.StreamThreshold {
+				entry.Artifacts[i].source = blobPath
+				continue
+			}
+		}
 		content, err := os.ReadFile(blobPath)
 		if err != nil {
+			if os.IsNotExist(err) {
+				err = &CacheCorruptError{Hash: hash, Reason: fmt.Sprintf("artifact %d: blob missing", i)}
+			}
 			return nil, fmt.Errorf("reading artifact %d: %w", i, err)
 		}
+		content, err = decompressBlob(entry.Artifacts[i].Compression, content)
+		if err != nil {
+			return nil, &CacheCorruptError{Hash: hash, Reason: fmt.Sprintf("artifact %d: decompressing blob: %v", i, err)}
+		}
+		if want := entry.Artifacts[i].SHA256; want != "" {
+			sum := sha256.Sum256(content)
+			if got := hex.EncodeToString(sum[:]); got != want {
+				return nil, &CacheCorruptError{Hash: hash, Reason: fmt.Sprintf("artifact %d: sha256 %s does not match recorded %s", i, got, want)}
+			}
+		}
 		entry.Artifacts[i].Content = content
+		entry.Artifacts[i].Compression = CompressionNone
 	}
 
 	return &entry, nil
@@ -171,9 +250,18 @@ func (c *FileCache) Put(entry *CacheEntry) error {
 	}
 
 	// Write artifact blobs first (so metadata only appears after blobs succeed).
+	// Content is streamed through the encoder and hashed in one pass, so
+	// streamed artifacts are never buffered in memory.
+	digests := make([]string, len(entry.Artifacts))
 	for i, artifact := range entry.Artifacts {
 		blobPath := filepath.Join(artifactsDir, fmt.Sprintf("%d.blob", i))
-		if err := writeFileAtomic(blobPath, artifact.Content, 0644); err != nil {
+		src, err := artifact.Open()
+		if err != nil {
+			return fmt.Errorf("opening artifact %d: %w", i, err)
+		}
+		digests[i], err = writeStreamAtomic(blobPath, c.Compression, src, 0644, "")
+		_ = src.Close()
+		if err != nil {
 			return fmt.Errorf("writing artifact %d: %w", i, err)
 		}
 	}
@@ -185,11 +273,16 @@ func (c *FileCache) Put(entry *CacheEntry) error {
 		Stderr:   entry.Stderr,
 		ExitCode: entry.ExitCode,
 		Artifacts: make([]CachedArtifact, len(entry.Artifacts)),
+		DeclaredOutputs: entry.DeclaredOutputs,
 	}
 	for i, a := range entry.Artifacts {
 		metadata.Artifacts[i] = CachedArtifact{
-			Path:    a.Path,
-			Content: nil, // Content stored in blob files
+			Path:        a.Path,
+			Content:     nil, // Content stored in blob files
+			SHA256:      digests[i],
+			Compression: c.Compression,
+			Mode:        a.Mode,
+			LinkTarget:  a.LinkTarget,
 		}
 	}
 
@@ -203,6 +296,11 @@ func (c *FileCache) Put(entry *CacheEntry) error {
 	if err := writeFileAtomic(metadataPath, data, 0644); err != nil {
 		return fmt.Errorf("writing cache metadata: %w", err)
 	}
+	if c.SigningKey != nil {
+		if err := writeFileAtomic(filepath.Join(tmpDir, signatureFile), signMetadata(c.SigningKey, data), 0644); err != nil {
+			return fmt.Errorf("writing cache signature: %w", err)
+		}
+	}
 
 	// Best-effort remove of any existing entry; a crash between remove and rename
 	// yields a cache miss (safe), not corruption.
@@ -285,11 +383,29 @@ func (c *MemoryCache) Put(entry *CacheEntry) error {
 	if entry == nil {
 		return fmt.Errorf("cache entry is nil")
 	}
+	// Streamed artifacts are materialized: this cache holds everything in memory.
+	materialized := *entry
+	materialized.Artifacts = append([]CachedArtifact(nil), entry.Artifacts...)
+	for i, a := range materialized.Artifacts {
+		if !a.Streamed() {
+			continue
+		}
+		content, err := a.readAll()
+		if err != nil {
+			return fmt.Errorf("reading artifact %d: %w", i, err)
+		}
+		materialized.Artifacts[i] = CachedArtifact{Path: a.Path, Content: content, SHA256: a.SHA256, Mode: a.Mode, LinkTarget: a.LinkTarget}
+	}
 	// Store a copy to prevent mutation
-	c.entries[entry.Hash] = c.copyEntry(entry)
+	c.entries[entry.Hash] = c.copyEntry(&materialized)
 	return nil
 }
 
+// Delete removes the entry for hash, if any.
+func (c *MemoryCache) Delete(hash TaskHash) {
+	delete(c.entries, hash)
+}
+
 // copyEntry creates a deep copy of a cache entry.
 func (c *MemoryCache) copyEntry(entry *CacheEntry) *CacheEntry {
 	copy := &CacheEntry{
@@ -299,6 +415,9 @@ func (c *MemoryCache) copyEntry(entry *CacheEntry) *CacheEntry {
 		ExitCode:  entry.ExitCode,
 		Artifacts: make([]CachedArtifact, len(entry.Artifacts)),
 	}
+	if entry.DeclaredOutputs != nil {
+		copy.DeclaredOutputs = append([]string(nil), entry.DeclaredOutputs...)
+	}
 	
 	// Use the built-in copy function for byte slices
 	builtinCopy(copy.Stdout, entry.Stdout)
@@ -306,8 +425,11 @@ func (c *MemoryCache) copyEntry(entry *CacheEntry) *CacheEntry {
 	
 	for i, a := range entry.Artifacts {
 		copy.Artifacts[i] = CachedArtifact{
-			Path:    a.Path,
-			Content: make([]byte, len(a.Content)),
+			Path:       a.Path,
+			SHA256:     a.SHA256,
+			Content:    make([]byte, len(a.Content)),
+			Mode:       a.Mode,
+			LinkTarget: a.LinkTarget,
 		}
 		builtinCopy(copy.Artifacts[i].Content, a.Content)
 	}
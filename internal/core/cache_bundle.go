@@ -0,0 +1,388 @@
+package core
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CacheBundleVersion is the bundle format written by Export.
+const CacheBundleVersion = 1
+
+// cacheBundleManifestName is the first member of every bundle.
+const cacheBundleManifestName = "bundle.json"
+
+// cacheBundleEntryFile matches the files of an entry directory a bundle may
+// carry, relative to the entry directory.
+var cacheBundleEntryFile = regexp.MustCompile(`^(metadata\.json|metadata\.json\.sig|artifacts/[0-9]+\.blob)$`)
+
+// CacheBundleManifest is bundle.json: the entries in a bundle and the digest
+// of every file they consist of.
+type CacheBundleManifest struct {
+	Version int `json:"version"`
+	// Entries is sorted by hash.
+	Entries []CacheBundleEntry `json:"entries"`
+}
+
+// CacheBundleEntry is one cache entry in a bundle.
+type CacheBundleEntry struct {
+	Hash TaskHash `json:"hash"`
+	// Tasks are the names the entry was exported for, sorted.
+	Tasks []string `json:"tasks"`
+	// Files are the entry's on-disk files as stored, sorted by name.
+	Files []CacheBundleFile `json:"files"`
+}
+
+// CacheBundleFile is one file of an entry directory.
+type CacheBundleFile struct {
+	// Name is relative to the entry directory, with forward slashes.
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// CacheBundleError describes a bundle that is malformed or does not match
+// its manifest. Damaged entries inside a well-formed bundle are reported as
+// *CacheCorruptError instead.
+type CacheBundleError struct {
+	Reason string
+}
+
+func (e *CacheBundleError) Error() string {
+	return fmt.Sprintf("invalid cache bundle: %s", e.Reason)
+}
+
+// CacheImportResult lists the entries of an imported bundle.
+type CacheImportResult struct {
+	// Imported entries were added to the cache.
+	Imported []TaskHash `json:"imported"`
+	// Skipped entries were already present and were left untouched.
+	Skipped []TaskHash `json:"skipped"`
+}
+
+// Export writes the entries for tasks (task name to hash) as a gzipped tar
+// bundle to w and returns its manifest.
+//
+// Entries are copied as stored: blobs keep their compression and signed
+// entries keep their signature. Every entry is checked with Verify first, so
+// missing, corrupt or (with TrustedKeys) untrusted entries fail the export
+// before anything is written. The bundle contains no timestamps or owners:
+// the same entries always produce the same bytes.
+func (c *FileCache) Export(w io.Writer, tasks map[string]TaskHash) (CacheBundleManifest, error) {
+	byHash := make(map[TaskHash][]string)
+	for name, hash := range tasks {
+		if err := validateBundleHash(hash); err != nil {
+			return CacheBundleManifest{}, fmt.Errorf("task %q: %w", name, err)
+		}
+		byHash[hash] = append(byHash[hash], name)
+	}
+	hashes := make([]TaskHash, 0, len(byHash))
+	for hash := range byHash {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+
+	m := CacheBundleManifest{Version: CacheBundleVersion, Entries: []CacheBundleEntry{}}
+	for _, hash := range hashes {
+		names := byHash[hash]
+		sort.Strings(names)
+		if err := c.Verify(hash); err != nil {
+			if errors.Is(err, ErrCacheEntryMissing) {
+				return CacheBundleManifest{}, fmt.Errorf("task %q: cache entry %s: %w", names[0], hash, err)
+			}
+			return CacheBundleManifest{}, fmt.Errorf("task %q: %w", names[0], err)
+		}
+		files, err := c.entryFiles(hash)
+		if err != nil {
+			return CacheBundleManifest{}, err
+		}
+		m.Entries = append(m.Entries, CacheBundleEntry{Hash: hash, Tasks: names, Files: files})
+	}
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return CacheBundleManifest{}, err
+	}
+	gz, err := gzip.NewWriterLevel(w, gzip.DefaultCompression)
+	if err != nil {
+		return CacheBundleManifest{}, err
+	}
+	tw := tar.NewWriter(gz)
+	if err := writeBundleMember(tw, cacheBundleManifestName, int64(len(manifest)), bytes.NewReader(manifest)); err != nil {
+		return CacheBundleManifest{}, err
+	}
+	for _, e := range m.Entries {
+		entryDir := c.entryPath(e.Hash)
+		for _, f := range e.Files {
+			if err := c.exportFile(tw, e.Hash, entryDir, f); err != nil {
+				return CacheBundleManifest{}, err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return CacheBundleManifest{}, err
+	}
+	if err := gz.Close(); err != nil {
+		return CacheBundleManifest{}, err
+	}
+	return m, nil
+}
+
+// entryFiles lists and hashes the files of the entry for hash.
+func (c *FileCache) entryFiles(hash TaskHash) ([]CacheBundleFile, error) {
+	entryDir := c.entryPath(hash)
+	files := []CacheBundleFile{}
+	err := filepath.WalkDir(entryDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(entryDir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !d.Type().IsRegular() || !cacheBundleEntryFile.MatchString(name) {
+			return &CacheCorruptError{Hash: hash, Reason: fmt.Sprintf("unexpected file %q", name)}
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sum, err := blobDigest(p, CompressionNone)
+		if err != nil {
+			return err
+		}
+		files = append(files, CacheBundleFile{Name: name, Size: info.Size(), SHA256: sum})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing cache entry %s: %w", hash, err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// exportFile copies one entry file into the bundle, failing if it no longer
+// matches the digest recorded in the manifest.
+func (c *FileCache) exportFile(tw *tar.Writer, hash TaskHash, entryDir string, f CacheBundleFile) error {
+	src, err := os.Open(filepath.Join(entryDir, filepath.FromSlash(f.Name)))
+	if err != nil {
+		return fmt.Errorf("exporting cache entry %s: %w", hash, err)
+	}
+	defer src.Close()
+	h := sha256.New()
+	r := io.TeeReader(io.LimitReader(src, f.Size), h)
+	if err := writeBundleMember(tw, path.Join("entries", string(hash), f.Name), f.Size, r); err != nil {
+		return fmt.Errorf("exporting cache entry %s: %w", hash, err)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != f.SHA256 {
+		return fmt.Errorf("exporting cache entry %s: %s changed during export", hash, f.Name)
+	}
+	return nil
+}
+
+func writeBundleMember(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	hdr := &tar.Header{
+		Name:     name,
+		Mode:     0o644,
+		Size:     size,
+		ModTime:  time.Unix(0, 0),
+		Typeflag: tar.TypeReg,
+		Format:   tar.FormatUSTAR,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, r)
+	return err
+}
+
+// Import adds the entries of a bundle written by Export to the cache.
+//
+// Every file is checked against the bundle manifest and every entry with
+// Verify (including TrustedKeys) in a staging directory before any entry is
+// added, so a damaged or untrusted bundle leaves the cache unchanged. Entries
+// already in the cache are content-addressed and are kept as they are.
+func (c *FileCache) Import(r io.Reader) (CacheImportResult, error) {
+	bad := func(format string, args ...any) error {
+		return &CacheBundleError{Reason: fmt.Sprintf(format, args...)}
+	}
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return CacheImportResult{}, bad("%v", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil {
+		return CacheImportResult{}, bad("reading %s: %v", cacheBundleManifestName, err)
+	}
+	if hdr.Name != cacheBundleManifestName {
+		return CacheImportResult{}, bad("first member is %q, want %s", hdr.Name, cacheBundleManifestName)
+	}
+	m, err := readBundleManifest(tr)
+	if err != nil {
+		return CacheImportResult{}, bad("%s: %v", cacheBundleManifestName, err)
+	}
+	expected := make(map[string]CacheBundleFile)
+	for _, e := range m.Entries {
+		for _, f := range e.Files {
+			expected[path.Join("entries", string(e.Hash), f.Name)] = f
+		}
+	}
+
+	if err := os.MkdirAll(c.CacheDir, 0o755); err != nil {
+		return CacheImportResult{}, fmt.Errorf("creating cache directory: %w", err)
+	}
+	stagingDir, err := os.MkdirTemp(c.CacheDir, "tmp-import-")
+	if err != nil {
+		return CacheImportResult{}, fmt.Errorf("creating staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+	staging := &FileCache{CacheDir: stagingDir, TrustedKeys: c.TrustedKeys}
+
+	seen := make(map[string]bool, len(expected))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return CacheImportResult{}, bad("%v", err)
+		}
+		f, ok := expected[hdr.Name]
+		switch {
+		case !ok:
+			return CacheImportResult{}, bad("member %q is not listed in %s", hdr.Name, cacheBundleManifestName)
+		case seen[hdr.Name]:
+			return CacheImportResult{}, bad("duplicate member %q", hdr.Name)
+		case hdr.Typeflag != tar.TypeReg:
+			return CacheImportResult{}, bad("member %q is not a regular file", hdr.Name)
+		case hdr.Size != f.Size:
+			return CacheImportResult{}, bad("member %q has size %d, manifest says %d", hdr.Name, hdr.Size, f.Size)
+		}
+		seen[hdr.Name] = true
+		// Staged entries mirror the cache layout, so Verify finds them.
+		parts := strings.SplitN(strings.TrimPrefix(hdr.Name, "entries/"), "/", 2)
+		dst := filepath.Join(staging.entryPath(TaskHash(parts[0])), filepath.FromSlash(parts[1]))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return CacheImportResult{}, err
+		}
+		out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return CacheImportResult{}, err
+		}
+		sum, err := hashAndCopy(out, tr)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return CacheImportResult{}, bad("reading member %q: %v", hdr.Name, err)
+		}
+		if sum != f.SHA256 {
+			return CacheImportResult{}, bad("member %q has sha256 %s, manifest says %s", hdr.Name, sum, f.SHA256)
+		}
+	}
+	for _, name := range sortedKeys(expected) {
+		if !seen[name] {
+			return CacheImportResult{}, bad("member %q is missing", name)
+		}
+	}
+
+	for _, e := range m.Entries {
+		if err := staging.Verify(e.Hash); err != nil {
+			return CacheImportResult{}, err
+		}
+	}
+	res := CacheImportResult{Imported: []TaskHash{}, Skipped: []TaskHash{}}
+	for _, e := range m.Entries {
+		ok, err := c.Has(e.Hash)
+		if err != nil {
+			return res, err
+		}
+		if ok {
+			res.Skipped = append(res.Skipped, e.Hash)
+			continue
+		}
+		dst := c.entryPath(e.Hash)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return res, fmt.Errorf("creating cache directory: %w", err)
+		}
+		// A partially written entry without metadata.json is a miss; replace it.
+		_ = os.RemoveAll(dst)
+		if err := os.Rename(staging.entryPath(e.Hash), dst); err != nil {
+			return res, fmt.Errorf("committing cache entry %s: %w", e.Hash, err)
+		}
+		res.Imported = append(res.Imported, e.Hash)
+	}
+	return res, nil
+}
+
+// readBundleManifest decodes and validates bundle.json strictly.
+func readBundleManifest(r io.Reader) (CacheBundleManifest, error) {
+	var m CacheBundleManifest
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return CacheBundleManifest{}, err
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return CacheBundleManifest{}, errors.New("trailing data")
+	}
+	if m.Version != CacheBundleVersion {
+		return CacheBundleManifest{}, fmt.Errorf("unsupported version %d (expected %d)", m.Version, CacheBundleVersion)
+	}
+	hashes := make(map[TaskHash]bool, len(m.Entries))
+	for _, e := range m.Entries {
+		if err := validateBundleHash(e.Hash); err != nil {
+			return CacheBundleManifest{}, err
+		}
+		if hashes[e.Hash] {
+			return CacheBundleManifest{}, fmt.Errorf("duplicate entry %s", e.Hash)
+		}
+		hashes[e.Hash] = true
+		names := make(map[string]bool, len(e.Files))
+		for _, f := range e.Files {
+			if !cacheBundleEntryFile.MatchString(f.Name) || names[f.Name] {
+				return CacheBundleManifest{}, fmt.Errorf("entry %s: invalid or duplicate file %q", e.Hash, f.Name)
+			}
+			names[f.Name] = true
+		}
+		if !names["metadata.json"] {
+			return CacheBundleManifest{}, fmt.Errorf("entry %s: metadata.json is missing", e.Hash)
+		}
+	}
+	return m, nil
+}
+
+// validateBundleHash rejects hashes that cannot name a cache entry directory.
+func validateBundleHash(hash TaskHash) error {
+	h := string(hash)
+	if len(h) < 2 || strings.ContainsAny(h, `/\`) || h == ".." {
+		return fmt.Errorf("invalid cache entry hash %q", h)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
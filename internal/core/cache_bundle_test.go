@@ -0,0 +1,144 @@
+package core
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFileCache_ExportImport(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := NewFileCache(t.TempDir())
+	src.Compression = CompressionGzip
+	src.SigningKey = priv
+	for _, e := range []*CacheEntry{
+		{Hash: "aa11", Stdout: []byte("one"), Artifacts: []CachedArtifact{{Path: "dist/a.txt", Content: []byte("A")}}},
+		{Hash: "bb22", ExitCode: 1, Stderr: []byte("two")},
+	} {
+		if err := src.Put(e); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	tasks := map[string]TaskHash{"build": "aa11", "lint": "bb22", "build-copy": "aa11"}
+
+	var bundle bytes.Buffer
+	m, err := src.Export(&bundle, tasks)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if len(m.Entries) != 2 || m.Entries[0].Hash != "aa11" || strings.Join(m.Entries[0].Tasks, ",") != "build,build-copy" {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+	var again bytes.Buffer
+	if _, err := src.Export(&again, tasks); err != nil || !bytes.Equal(bundle.Bytes(), again.Bytes()) {
+		t.Fatalf("export is not deterministic (err=%v)", err)
+	}
+
+	dst := NewFileCache(t.TempDir())
+	res, err := dst.Import(bytes.NewReader(bundle.Bytes()))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(res.Imported) != 2 || len(res.Skipped) != 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	got, err := dst.Get("aa11")
+	if err != nil || got == nil || string(got.Artifacts[0].Content) != "A" || string(got.Stdout) != "one" {
+		t.Fatalf("Get after import: %+v, %v", got, err)
+	}
+	res, err = dst.Import(bytes.NewReader(bundle.Bytes()))
+	if err != nil || len(res.Imported) != 0 || len(res.Skipped) != 2 {
+		t.Fatalf("re-import: %+v, %v", res, err)
+	}
+
+	t.Run("missing entry", func(t *testing.T) {
+		if _, err := src.Export(io.Discard, map[string]TaskHash{"gone": "cc33"}); !errors.Is(err, ErrCacheEntryMissing) {
+			t.Fatalf("expected ErrCacheEntryMissing, got %v", err)
+		}
+	})
+
+	t.Run("tampered member", func(t *testing.T) {
+		tampered := rewriteBundle(t, bundle.Bytes(), func(name string, data []byte) []byte {
+			if strings.HasSuffix(name, "metadata.json") {
+				return bytes.Replace(data, []byte(`"exit_code": 1`), []byte(`"exit_code": 0`), 1)
+			}
+			return data
+		})
+		c := NewFileCache(t.TempDir())
+		var be *CacheBundleError
+		if _, err := c.Import(bytes.NewReader(tampered)); !errors.As(err, &be) {
+			t.Fatalf("expected *CacheBundleError, got %v", err)
+		}
+		if ok, _ := c.Has("aa11"); ok {
+			t.Fatal("a rejected bundle must not add entries")
+		}
+	})
+
+	t.Run("untrusted signer", func(t *testing.T) {
+		c := NewFileCache(t.TempDir())
+		c.TrustedKeys = []ed25519.PublicKey{otherPub}
+		if _, err := c.Import(bytes.NewReader(bundle.Bytes())); !IsCacheSignatureInvalid(err) {
+			t.Fatalf("expected signature error, got %v", err)
+		}
+		entries, _ := os.ReadDir(c.CacheDir)
+		if len(entries) != 0 {
+			t.Fatalf("cache dir not left empty: %v", entries)
+		}
+	})
+}
+
+// rewriteBundle re-packs a bundle with each member passed through edit,
+// keeping bundle.json as is.
+func rewriteBundle(t *testing.T, bundle []byte, edit func(name string, data []byte) []byte) []byte {
+	t.Helper()
+	gr, err := gzip.NewReader(bytes.NewReader(bundle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = edit(hdr.Name, data)
+		hdr.Size = int64(len(data))
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
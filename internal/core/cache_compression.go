@@ -0,0 +1,86 @@
+package core
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Blob compression codecs for FileCache.
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+)
+
+// ParseCacheCompression validates a user-supplied codec name ("none" or
+// "gzip") and returns its canonical form.
+//
+// zstd is recognized but rejected: it is not in the standard library and this
+// module has no third-party dependencies.
+func ParseCacheCompression(raw string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "none":
+		return CompressionNone, nil
+	case CompressionGzip:
+		return CompressionGzip, nil
+	case "zstd":
+		return "", fmt.Errorf("cache compression %q is not supported in this build (expected none|gzip)", raw)
+	default:
+		return "", fmt.Errorf("unknown cache compression %q (expected none|gzip)", raw)
+	}
+}
+
+// newBlobWriter wraps w so bytes written are encoded with codec. Close
+// flushes the encoder but not w. gzip output carries no timestamp or name, so
+// identical content always produces identical blobs.
+func newBlobWriter(codec string, w io.Writer) (io.WriteCloser, error) {
+	switch codec {
+	case CompressionNone:
+		return nopWriteCloser{w}, nil
+	case CompressionGzip:
+		return gzip.NewWriterLevel(w, gzip.BestSpeed)
+	default:
+		return nil, fmt.Errorf("unknown compression %q", codec)
+	}
+}
+
+// newBlobReader wraps r so reads return content decoded with codec.
+func newBlobReader(codec string, r io.Reader) (io.ReadCloser, error) {
+	switch codec {
+	case CompressionNone:
+		return io.NopCloser(r), nil
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	default:
+		return nil, fmt.Errorf("unknown compression %q", codec)
+	}
+}
+
+// decompressBlob decodes an in-memory blob.
+func decompressBlob(codec string, blob []byte) ([]byte, error) {
+	if codec == CompressionNone {
+		return blob, nil
+	}
+	r, err := newBlobReader(codec, bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// isBlobDecodeError reports whether err came from decoding a damaged blob
+// rather than from I/O.
+func isBlobDecodeError(err error) bool {
+	var corrupt flate.CorruptInputError
+	return errors.Is(err, gzip.ErrHeader) || errors.Is(err, gzip.ErrChecksum) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &corrupt)
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
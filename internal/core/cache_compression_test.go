@@ -0,0 +1,71 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileCache_GzipCompression(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte(strings.Repeat("build output line\n", 512))
+	c := &FileCache{CacheDir: dir, Compression: CompressionGzip}
+	if err := c.Put(&CacheEntry{Hash: "abcdef", Artifacts: []CachedArtifact{{Path: "out.txt", Content: content}}}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	blob, err := os.ReadFile(filepath.Join(c.entryPath("abcdef"), "artifacts", "0.blob"))
+	if err != nil {
+		t.Fatalf("read blob: %v", err)
+	}
+	if len(blob) >= len(content) {
+		t.Fatalf("expected compressed blob, got %d bytes for %d bytes of content", len(blob), len(content))
+	}
+
+	// Reading is driven by metadata, not by the reader's own setting.
+	got, err := NewFileCache(dir).Get("abcdef")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	a := got.Artifacts[0]
+	if !bytes.Equal(a.Content, content) || a.Compression != CompressionNone {
+		t.Fatalf("unexpected artifact after round trip: compression=%q len=%d", a.Compression, len(a.Content))
+	}
+	if err := c.Verify("abcdef"); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	// Same content compresses to the same bytes.
+	c2 := &FileCache{CacheDir: t.TempDir(), Compression: CompressionGzip}
+	if err := c2.Put(&CacheEntry{Hash: "abcdef", Artifacts: []CachedArtifact{{Path: "out.txt", Content: content}}}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	blob2, err := os.ReadFile(filepath.Join(c2.entryPath("abcdef"), "artifacts", "0.blob"))
+	if err != nil {
+		t.Fatalf("read blob: %v", err)
+	}
+	if !bytes.Equal(blob, blob2) {
+		t.Fatalf("expected deterministic compressed blobs")
+	}
+
+	writeTestFile(t, filepath.Join(c.entryPath("abcdef"), "artifacts", "0.blob"), "not gzip")
+	if _, err := c.Get("abcdef"); !IsCacheCorrupt(err) {
+		t.Fatalf("expected CacheCorruptError for undecodable blob, got %v", err)
+	}
+}
+
+func TestParseCacheCompression(t *testing.T) {
+	for raw, want := range map[string]string{"": CompressionNone, "none": CompressionNone, "GZIP": CompressionGzip} {
+		got, err := ParseCacheCompression(raw)
+		if err != nil || got != want {
+			t.Fatalf("ParseCacheCompression(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"zstd", "lz4"} {
+		if _, err := ParseCacheCompression(raw); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+}
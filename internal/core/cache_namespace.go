@@ -0,0 +1,53 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// CacheNamespaceGraph is the namespace that partitions a cache by graph
+// hash: every graph gets its own namespace.
+const CacheNamespaceGraph = "graph"
+
+// cacheNamespacesDir holds the namespaces of a cache directory.
+const cacheNamespacesDir = "namespaces"
+
+var cacheNamespaceName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ParseCacheNamespace validates a user-supplied namespace: empty (no
+// namespace), CacheNamespaceGraph, or a name of letters, digits, '.', '_'
+// and '-' that starts with a letter or digit.
+func ParseCacheNamespace(raw string) (string, error) {
+	ns := strings.TrimSpace(raw)
+	if ns == "" || cacheNamespaceName.MatchString(ns) {
+		return ns, nil
+	}
+	return "", fmt.Errorf("invalid cache namespace %q (expected %s or a name of letters, digits, '.', '_' and '-')", raw, CacheNamespaceGraph)
+}
+
+// ResolveCacheNamespace returns the namespace entries of a graph with
+// graphHash are stored under: graphHash for CacheNamespaceGraph, namespace
+// itself otherwise.
+func ResolveCacheNamespace(namespace, graphHash string) string {
+	if namespace == CacheNamespaceGraph {
+		return graphHash
+	}
+	return namespace
+}
+
+// NamespacedCacheDir returns the directory a FileCache for the resolved
+// namespace uses inside cacheDir: cacheDir itself without a namespace,
+// {cacheDir}/namespaces/{namespace} with one.
+//
+// Each namespace is a self-contained FileCache, so unrelated projects
+// sharing cacheDir can be measured and deleted independently. Entries are
+// not shared across namespaces: the same task in two namespaces is stored
+// twice.
+func NamespacedCacheDir(cacheDir, resolved string) string {
+	if resolved == "" {
+		return cacheDir
+	}
+	return filepath.Join(cacheDir, cacheNamespacesDir, resolved)
+}
@@ -0,0 +1,32 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCacheNamespace(t *testing.T) {
+	for _, ok := range []string{"", "graph", "proj-a", "team.build_2", "0abc"} {
+		if _, err := ParseCacheNamespace(ok); err != nil {
+			t.Errorf("ParseCacheNamespace(%q): %v", ok, err)
+		}
+	}
+	for _, bad := range []string{".", "..", "a/b", "-x", ".hidden", "with space"} {
+		if _, err := ParseCacheNamespace(bad); err == nil {
+			t.Errorf("ParseCacheNamespace(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestNamespacedCacheDir(t *testing.T) {
+	root := filepath.Join("tmp", "cache")
+	if got := NamespacedCacheDir(root, ResolveCacheNamespace("", "abc")); got != root {
+		t.Fatalf("no namespace: %s", got)
+	}
+	if got, want := NamespacedCacheDir(root, ResolveCacheNamespace(CacheNamespaceGraph, "abc")), filepath.Join(root, "namespaces", "abc"); got != want {
+		t.Fatalf("graph namespace: %s, want %s", got, want)
+	}
+	if got, want := NamespacedCacheDir(root, ResolveCacheNamespace("proj", "abc")), filepath.Join(root, "namespaces", "proj"); got != want {
+		t.Fatalf("fixed namespace: %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// signatureFile holds the hex ed25519 signature of an entry's metadata.json.
+// The metadata records every blob's SHA-256, so the signature covers the
+// whole entry.
+const signatureFile = "metadata.json.sig"
+
+// signatureContext separates cache entry signatures from any other use of
+// the same key.
+const signatureContext = "scriptweaver cache entry v1\n"
+
+// CacheSignatureError describes an entry that is not signed by a trusted key
+// (unsigned, tampered with, or signed by an unknown key). Unlike a
+// CacheCorruptError it is never healed by re-execution: it means the cache
+// itself cannot be trusted.
+type CacheSignatureError struct {
+	Hash   TaskHash
+	Reason string
+}
+
+func (e *CacheSignatureError) Error() string {
+	return fmt.Sprintf("cache entry %s failed signature verification: %s", e.Hash, e.Reason)
+}
+
+// IsCacheSignatureInvalid reports whether err wraps a *CacheSignatureError.
+func IsCacheSignatureInvalid(err error) bool {
+	var se *CacheSignatureError
+	return errors.As(err, &se)
+}
+
+func signMetadata(key ed25519.PrivateKey, metadata []byte) []byte {
+	sig := ed25519.Sign(key, append([]byte(signatureContext), metadata...))
+	return []byte(hex.EncodeToString(sig) + "\n")
+}
+
+// verifyEntrySignature checks the signature stored in entryDir against the
+// raw metadata bytes. It is a no-op when c trusts no keys.
+func (c *FileCache) verifyEntrySignature(hash TaskHash, entryDir string, metadata []byte) error {
+	if len(c.TrustedKeys) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(entryDir, signatureFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &CacheSignatureError{Hash: hash, Reason: "entry is not signed"}
+		}
+		return fmt.Errorf("reading cache signature: %w", err)
+	}
+	sig, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return &CacheSignatureError{Hash: hash, Reason: "malformed signature"}
+	}
+	msg := append([]byte(signatureContext), metadata...)
+	for _, k := range c.TrustedKeys {
+		if ed25519.Verify(k, msg, sig) {
+			return nil
+		}
+	}
+	return &CacheSignatureError{Hash: hash, Reason: "signature does not match any trusted key"}
+}
+
+// LoadSigningKey reads a PEM-encoded PKCS#8 ed25519 private key, as written
+// by "openssl genpkey -algorithm ed25519".
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	k, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	priv, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ed25519 private key", path)
+	}
+	return priv, nil
+}
+
+// LoadVerifyKey reads a PEM-encoded PKIX ed25519 public key, as written by
+// "openssl pkey -pubout".
+func LoadVerifyKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	k, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	pub, ok := k.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ed25519 public key", path)
+	}
+	return pub, nil
+}
+
+func readPEM(path, blockType string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, rest := pem.Decode(data)
+	if block == nil || block.Type != blockType || len(bytes.TrimSpace(rest)) != 0 {
+		return nil, fmt.Errorf("%s: expected a single PEM %q block", path, blockType)
+	}
+	return block.Bytes, nil
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileCache_Signing(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := func() *CacheEntry {
+		return &CacheEntry{Hash: "abcdef", Stdout: []byte("out"), Artifacts: []CachedArtifact{{Path: "dist/a.txt", Content: []byte("A")}}}
+	}
+	put := func(t *testing.T, key ed25519.PrivateKey) string {
+		t.Helper()
+		dir := t.TempDir()
+		c := NewFileCache(dir)
+		c.SigningKey = key
+		if err := c.Put(entry()); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		return dir
+	}
+	get := func(dir string, trusted ...ed25519.PublicKey) error {
+		c := NewFileCache(dir)
+		c.TrustedKeys = trusted
+		if _, err := c.Get("abcdef"); err != nil {
+			return err
+		}
+		return c.Verify("abcdef")
+	}
+
+	t.Run("signed by a trusted key", func(t *testing.T) {
+		if err := get(put(t, priv), otherPub, pub); err != nil {
+			t.Fatalf("expected trusted entry, got %v", err)
+		}
+	})
+	t.Run("no trusted keys accepts unsigned entries", func(t *testing.T) {
+		if err := get(put(t, nil)); err != nil {
+			t.Fatalf("expected entry, got %v", err)
+		}
+	})
+
+	cases := map[string]struct {
+		dir    func(t *testing.T) string
+		reason string
+	}{
+		"unsigned": {
+			dir:    func(t *testing.T) string { return put(t, nil) },
+			reason: "entry is not signed",
+		},
+		"untrusted key": {
+			dir:    func(t *testing.T) string { return put(t, otherPriv) },
+			reason: "signature does not match any trusted key",
+		},
+		"tampered metadata": {
+			dir: func(t *testing.T) string {
+				dir := put(t, priv)
+				p := filepath.Join(NewFileCache(dir).entryPath("abcdef"), "metadata.json")
+				b, err := os.ReadFile(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte(strings.Replace(string(b), `"exit_code": 0`, `"exit_code": 1`, 1)), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return dir
+			},
+			reason: "signature does not match any trusted key",
+		},
+		"malformed signature": {
+			dir: func(t *testing.T) string {
+				dir := put(t, priv)
+				writeTestFile(t, filepath.Join(NewFileCache(dir).entryPath("abcdef"), signatureFile), "zz")
+				return dir
+			},
+			reason: "malformed signature",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := get(tc.dir(t), pub)
+			if !IsCacheSignatureInvalid(err) || IsCacheCorrupt(err) {
+				t.Fatalf("expected *CacheSignatureError, got %v", err)
+			}
+			want := "cache entry abcdef failed signature verification: " + tc.reason
+			if err.Error() != want {
+				t.Fatalf("error = %q, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestLoadSigningAndVerifyKeys(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	der, _ := x509.MarshalPKCS8PrivateKey(priv)
+	privPath := filepath.Join(dir, "key.pem")
+	writeTestFile(t, privPath, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+	der, _ = x509.MarshalPKIXPublicKey(pub)
+	pubPath := filepath.Join(dir, "key.pub")
+	writeTestFile(t, pubPath, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+
+	gotPriv, err := LoadSigningKey(privPath)
+	if err != nil || !gotPriv.Equal(priv) {
+		t.Fatalf("LoadSigningKey: %v", err)
+	}
+	gotPub, err := LoadVerifyKey(pubPath)
+	if err != nil || !gotPub.Equal(pub) {
+		t.Fatalf("LoadVerifyKey: %v", err)
+	}
+	if _, err := LoadVerifyKey(privPath); err == nil {
+		t.Fatalf("a private key must not load as a public key")
+	}
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"os"
+	"sync"
+)
+
+// CacheStats counts the traffic through a StatsCache.
+type CacheStats struct {
+	// Lookups is the number of Has calls; Hits and Misses split them.
+	Lookups int64 `json:"lookups"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	// Gets counts Get calls that returned an entry.
+	Gets int64 `json:"gets"`
+	// Puts counts successful Put calls.
+	Puts int64 `json:"puts"`
+	// BytesRead and BytesWritten cover stdout, stderr and artifact content of
+	// the entries returned by Get and stored by Put.
+	BytesRead    int64 `json:"bytes_read"`
+	BytesWritten int64 `json:"bytes_written"`
+}
+
+// Add returns the field-wise sum of s and o.
+func (s CacheStats) Add(o CacheStats) CacheStats {
+	return CacheStats{
+		Lookups:      s.Lookups + o.Lookups,
+		Hits:         s.Hits + o.Hits,
+		Misses:       s.Misses + o.Misses,
+		Gets:         s.Gets + o.Gets,
+		Puts:         s.Puts + o.Puts,
+		BytesRead:    s.BytesRead + o.BytesRead,
+		BytesWritten: s.BytesWritten + o.BytesWritten,
+	}
+}
+
+// StatsCache wraps a Cache and counts its lookups, hits, misses, puts and
+// bytes moved. Errors from the wrapped cache are passed through and not
+// counted. It is safe for concurrent use.
+type StatsCache struct {
+	Cache
+
+	mu    sync.Mutex
+	stats CacheStats
+}
+
+// NewStatsCache returns a StatsCache over c with all counters at zero.
+func NewStatsCache(c Cache) *StatsCache {
+	return &StatsCache{Cache: c}
+}
+
+// Stats returns a snapshot of the counters.
+func (s *StatsCache) Stats() CacheStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+func (s *StatsCache) Has(hash TaskHash) (bool, error) {
+	ok, err := s.Cache.Has(hash)
+	if err != nil {
+		return ok, err
+	}
+	s.mu.Lock()
+	s.stats.Lookups++
+	if ok {
+		s.stats.Hits++
+	} else {
+		s.stats.Misses++
+	}
+	s.mu.Unlock()
+	return ok, nil
+}
+
+func (s *StatsCache) Get(hash TaskHash) (*CacheEntry, error) {
+	entry, err := s.Cache.Get(hash)
+	if err != nil || entry == nil {
+		return entry, err
+	}
+	n := entrySize(entry)
+	s.mu.Lock()
+	s.stats.Gets++
+	s.stats.BytesRead += n
+	s.mu.Unlock()
+	return entry, nil
+}
+
+func (s *StatsCache) Put(entry *CacheEntry) error {
+	if err := s.Cache.Put(entry); err != nil {
+		return err
+	}
+	n := entrySize(entry)
+	s.mu.Lock()
+	s.stats.Puts++
+	s.stats.BytesWritten += n
+	s.mu.Unlock()
+	return nil
+}
+
+// entrySize is the size of an entry's streams and artifact contents. Streamed
+// artifacts are sized from their source file, which may be compressed.
+func entrySize(e *CacheEntry) int64 {
+	if e == nil {
+		return 0
+	}
+	n := int64(len(e.Stdout) + len(e.Stderr))
+	for _, a := range e.Artifacts {
+		if a.Streamed() {
+			if info, err := os.Stat(a.source); err == nil {
+				n += info.Size()
+			}
+			continue
+		}
+		n += int64(len(a.Content))
+	}
+	return n
+}
@@ -0,0 +1,37 @@
+package core
+
+import "testing"
+
+func TestStatsCache_CountsTraffic(t *testing.T) {
+	c := NewStatsCache(NewMemoryCache())
+	entry := &CacheEntry{
+		Hash:      "h1",
+		Stdout:    []byte("out"),
+		Stderr:    []byte("e"),
+		Artifacts: []CachedArtifact{{Path: "a.txt", Content: []byte("12345")}},
+	}
+
+	if ok, err := c.Has("h1"); err != nil || ok {
+		t.Fatalf("Has before Put: ok=%v err=%v", ok, err)
+	}
+	if err := c.Put(entry); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if ok, err := c.Has("h1"); err != nil || !ok {
+		t.Fatalf("Has after Put: ok=%v err=%v", ok, err)
+	}
+	if _, err := c.Get("h1"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got, err := c.Get("missing"); err != nil || got != nil {
+		t.Fatalf("Get missing: entry=%v err=%v", got, err)
+	}
+
+	want := CacheStats{Lookups: 2, Hits: 1, Misses: 1, Gets: 1, Puts: 1, BytesRead: 9, BytesWritten: 9}
+	if got := c.Stats(); got != want {
+		t.Fatalf("stats = %+v, want %+v", got, want)
+	}
+	if got := want.Add(want); got.Lookups != 4 || got.BytesRead != 18 {
+		t.Fatalf("Add = %+v", got)
+	}
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrCacheEntryMissing indicates that no entry exists for a hash.
+var ErrCacheEntryMissing = errors.New("cache entry missing")
+
+// CacheCorruptError describes a cache entry that exists but cannot be trusted.
+type CacheCorruptError struct {
+	Hash   TaskHash
+	Reason string
+}
+
+func (e *CacheCorruptError) Error() string {
+	return fmt.Sprintf("cache entry %s is corrupt: %s", e.Hash, e.Reason)
+}
+
+// IsCacheCorrupt reports whether err wraps a *CacheCorruptError.
+func IsCacheCorrupt(err error) bool {
+	var ce *CacheCorruptError
+	return errors.As(err, &ce)
+}
+
+// Verify checks the on-disk entry for hash without restoring anything.
+//
+// It validates metadata.json strictly (no unknown fields or trailing data, hash
+// matching the entry key, safe artifact paths), checks that every artifact blob
+// exists, recomputes blob digests where the metadata records them, and rejects
+// unexpected files in the artifacts directory.
+//
+// Returns ErrCacheEntryMissing if there is no entry, a *CacheSignatureError if
+// the entry is not signed by one of TrustedKeys, a *CacheCorruptError if the
+// entry is damaged, or another error if the filesystem could not be read.
+func (c *FileCache) Verify(hash TaskHash) error {
+	entryDir := c.entryPath(hash)
+	corrupt := func(format string, args ...any) error {
+		return &CacheCorruptError{Hash: hash, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	data, err := os.ReadFile(filepath.Join(entryDir, "metadata.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrCacheEntryMissing
+		}
+		return fmt.Errorf("reading cache metadata: %w", err)
+	}
+	if err := c.verifyEntrySignature(hash, entryDir, data); err != nil {
+		return err
+	}
+
+	var entry CacheEntry
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&entry); err != nil {
+		return corrupt("invalid metadata.json: %v", err)
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return corrupt("invalid metadata.json: trailing data")
+	}
+	if entry.Hash != hash {
+		return corrupt("metadata hash %q does not match entry key", entry.Hash)
+	}
+
+	expected := make(map[string]struct{}, len(entry.Artifacts))
+	for i, a := range entry.Artifacts {
+		if err := validateArtifactPath(a.Path); err != nil {
+			return corrupt("artifact %d: %v", i, err)
+		}
+		if len(a.Content) != 0 {
+			return corrupt("artifact %d: content must be stored in a blob", i)
+		}
+		name := fmt.Sprintf("%d.blob", i)
+		expected[name] = struct{}{}
+		sum, err := blobDigest(filepath.Join(entryDir, "artifacts", name), a.Compression)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return corrupt("artifact %d (%s): blob missing", i, a.Path)
+			}
+			if isBlobDecodeError(err) {
+				return corrupt("artifact %d (%s): decompressing blob: %v", i, a.Path, err)
+			}
+			return fmt.Errorf("reading artifact %d: %w", i, err)
+		}
+		if a.SHA256 != "" && sum != a.SHA256 {
+			return corrupt("artifact %d (%s): sha256 %s does not match recorded %s", i, a.Path, sum, a.SHA256)
+		}
+	}
+
+	blobs, err := os.ReadDir(filepath.Join(entryDir, "artifacts"))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("listing artifacts: %w", err)
+	}
+	for _, b := range blobs {
+		if _, ok := expected[b.Name()]; !ok {
+			return corrupt("unexpected file %q in artifacts", b.Name())
+		}
+	}
+	return nil
+}
+
+// blobDigest streams the blob at path through its codec and returns the digest
+// of the decoded content.
+func blobDigest(path, codec string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	r, err := newBlobReader(codec, f)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	return hashAndCopy(io.Discard, r)
+}
+
+// Remove deletes the entry for hash. Removing a missing entry is not an error.
+func (c *FileCache) Remove(hash TaskHash) error {
+	if err := os.RemoveAll(c.entryPath(hash)); err != nil {
+		return fmt.Errorf("removing cache entry: %w", err)
+	}
+	return nil
+}
+
+// MoveEntry moves the entry for hash out of the cache to dst, which must not
+// exist, so a damaged entry can be inspected after it stops being served.
+// The entry is renamed when dst is on the cache's filesystem; otherwise it
+// is copied, and removed from the cache only once the copy is complete.
+func (c *FileCache) MoveEntry(hash TaskHash, dst string) error {
+	src := c.entryPath(hash)
+	if _, err := os.Stat(src); err != nil {
+		if os.IsNotExist(err) {
+			return ErrCacheEntryMissing
+		}
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("moving cache entry: %w", err)
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	err := filepath.WalkDir(src, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+	if err != nil {
+		return fmt.Errorf("moving cache entry: %w", err)
+	}
+	return c.Remove(hash)
+}
+
+func validateArtifactPath(p string) error {
+	if p == "" {
+		return errors.New("empty path")
+	}
+	if strings.HasPrefix(p, "/") || filepath.IsAbs(p) {
+		return fmt.Errorf("absolute path %q", p)
+	}
+	clean := path.Clean(p)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("path %q escapes the working directory", p)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileCache_Verify(t *testing.T) {
+	putEntry := func(t *testing.T) (*FileCache, string) {
+		t.Helper()
+		c := NewFileCache(t.TempDir())
+		entry := &CacheEntry{
+			Hash:      "abcdef",
+			Stdout:    []byte("out"),
+			Artifacts: []CachedArtifact{{Path: "dist/a.txt", Content: []byte("A")}},
+		}
+		if err := c.Put(entry); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		return c, c.entryPath("abcdef")
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		c, _ := putEntry(t)
+		if err := c.Verify("abcdef"); err != nil {
+			t.Fatalf("expected valid entry, got %v", err)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		c := NewFileCache(t.TempDir())
+		if err := c.Verify("abcdef"); !errors.Is(err, ErrCacheEntryMissing) {
+			t.Fatalf("expected ErrCacheEntryMissing, got %v", err)
+		}
+	})
+
+	corruptions := map[string]struct {
+		mutate func(t *testing.T, dir string)
+		want   string
+	}{
+		"tampered blob": {
+			mutate: func(t *testing.T, dir string) {
+				writeTestFile(t, filepath.Join(dir, "artifacts", "0.blob"), "B")
+			},
+			want: "does not match recorded",
+		},
+		"missing blob": {
+			mutate: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, "artifacts", "0.blob")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: "blob missing",
+		},
+		"unexpected blob": {
+			mutate: func(t *testing.T, dir string) {
+				writeTestFile(t, filepath.Join(dir, "artifacts", "7.blob"), "x")
+			},
+			want: "unexpected file",
+		},
+		"truncated metadata": {
+			mutate: func(t *testing.T, dir string) {
+				writeTestFile(t, filepath.Join(dir, "metadata.json"), `{"hash":"abcdef"`)
+			},
+			want: "invalid metadata.json",
+		},
+		"hash mismatch": {
+			mutate: func(t *testing.T, dir string) {
+				writeTestFile(t, filepath.Join(dir, "metadata.json"), `{"hash":"other","stdout":null,"stderr":null,"exit_code":0,"artifacts":[]}`)
+				if err := os.RemoveAll(filepath.Join(dir, "artifacts")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: "does not match entry key",
+		},
+		"escaping artifact path": {
+			mutate: func(t *testing.T, dir string) {
+				writeTestFile(t, filepath.Join(dir, "metadata.json"), `{"hash":"abcdef","stdout":null,"stderr":null,"exit_code":0,"artifacts":[{"path":"../x","content":null}]}`)
+			},
+			want: "escapes",
+		},
+	}
+	for name, tc := range corruptions {
+		t.Run(name, func(t *testing.T) {
+			c, dir := putEntry(t)
+			tc.mutate(t, dir)
+			err := c.Verify("abcdef")
+			var ce *CacheCorruptError
+			if !errors.As(err, &ce) || !strings.Contains(ce.Reason, tc.want) {
+				t.Fatalf("expected corrupt error containing %q, got %v", tc.want, err)
+			}
+			if err := c.Remove("abcdef"); err != nil {
+				t.Fatalf("Remove: %v", err)
+			}
+			if ok, _ := c.Has("abcdef"); ok {
+				t.Fatalf("expected entry removed")
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestRunner_CorruptEntry(t *testing.T) {
+	setup := func(t *testing.T) (*Runner, *FileCache, *Task, TaskHash) {
+		t.Helper()
+		workDir := t.TempDir()
+		cache := NewFileCache(t.TempDir())
+		runner := NewRunner(workDir, cache)
+		task := &Task{Name: "producer", Run: "printf fresh > out.txt", Outputs: []string{"out.txt"}}
+		first, err := runner.Run(context.Background(), task)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		writeTestFile(t, filepath.Join(cache.entryPath(first.Hash), "artifacts", "0.blob"), "tampered")
+		return runner, cache, task, first.Hash
+	}
+
+	t.Run("hard error without healing", func(t *testing.T) {
+		runner, _, task, _ := setup(t)
+		if _, err := runner.Run(context.Background(), task); !IsCacheCorrupt(err) {
+			t.Fatalf("expected CacheCorruptError, got %v", err)
+		}
+	})
+
+	t.Run("healing re-executes and rewrites", func(t *testing.T) {
+		runner, cache, task, hash := setup(t)
+		runner.HealCorruptEntries = true
+		res, err := runner.Run(context.Background(), task)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if res.FromCache || !res.Invalidated || !res.CacheCorrupt {
+			t.Fatalf("expected corrupt entry to be re-executed, got %+v", res)
+		}
+		if err := cache.Verify(hash); err != nil {
+			t.Fatalf("expected rewritten entry to verify, got %v", err)
+		}
+		again, err := runner.Run(context.Background(), task)
+		if err != nil || !again.FromCache {
+			t.Fatalf("expected cache hit after rewrite, got %+v (err=%v)", again, err)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package core
+
+import "fmt"
+
+// Condition gates a task on facts known once it is ready to run. Every
+// clause that is set must hold; a task whose condition is false is skipped
+// rather than executed, and so are the tasks downstream of it.
+type Condition struct {
+	// UpstreamSucceeded names upstream tasks (direct or transitive
+	// dependencies) that must have succeeded (exit code 0 or one of their
+	// SuccessExitCodes).
+	UpstreamSucceeded []string `json:"upstream_succeeded,omitempty" yaml:"upstream_succeeded,omitempty"`
+
+	// InputsExist lists patterns, each one of the task's declared inputs,
+	// that must match at least one file when the task is scheduled.
+	InputsExist []string `json:"inputs_exist,omitempty" yaml:"inputs_exist,omitempty"`
+
+	// Equals holds two operands that must be equal, typically a param
+	// placeholder and a value (e.g. ["${params.target}", "linux"]). Params are
+	// substituted at load time, so the clause is decided before any task runs.
+	Equals []string `json:"equals,omitempty" yaml:"equals,omitempty"`
+}
+
+// ValidateWhen checks the task's When clause: Equals has exactly two
+// operands, InputsExist only names declared inputs and UpstreamSucceeded
+// names are non-empty. Whether they are upstream of the task is checked by
+// the graph.
+func (t *Task) ValidateWhen() error {
+	c := t.When
+	if c == nil {
+		return nil
+	}
+	if c.Equals != nil && len(c.Equals) != 2 {
+		return fmt.Errorf("when.equals must have exactly two operands, got %d", len(c.Equals))
+	}
+	declared := make(map[string]bool, len(t.Inputs))
+	for _, in := range t.Inputs {
+		declared[in] = true
+	}
+	for _, p := range c.InputsExist {
+		if !declared[p] {
+			return fmt.Errorf("when.inputs_exist %q is not a declared input", p)
+		}
+	}
+	for _, name := range c.UpstreamSucceeded {
+		if name == "" || name == t.Name {
+			return fmt.Errorf("when.upstream_succeeded %q must name an upstream task", name)
+		}
+	}
+	return nil
+}
+
+// EqualsHolds reports whether the Equals clause, if any, holds.
+func (c *Condition) EqualsHolds() bool {
+	return c == nil || len(c.Equals) != 2 || c.Equals[0] == c.Equals[1]
+}
+
+// InputsPresent reports whether every InputsExist pattern matches at least
+// one file visible to r.
+func (c *Condition) InputsPresent(r *InputResolver) (bool, error) {
+	if c == nil {
+		return true, nil
+	}
+	for _, p := range c.InputsExist {
+		matches, err := r.expandPattern(p)
+		if err != nil {
+			return false, fmt.Errorf("when.inputs_exist %q: %w", p, err)
+		}
+		if len(matches) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Replace returns a copy of c with operand applied to its InputsExist and
+// Equals values and name applied to its UpstreamSucceeded task names. A nil
+// func leaves those values unchanged; a nil condition stays nil.
+func (c *Condition) Replace(operand, name func(string) string) *Condition {
+	if c == nil {
+		return nil
+	}
+	apply := func(in []string, f func(string) string) []string {
+		if in == nil {
+			return nil
+		}
+		out := make([]string, len(in))
+		for i, v := range in {
+			if f != nil {
+				v = f(v)
+			}
+			out[i] = v
+		}
+		return out
+	}
+	return &Condition{
+		UpstreamSucceeded: apply(c.UpstreamSucceeded, name),
+		InputsExist:       apply(c.InputsExist, operand),
+		Equals:            apply(c.Equals, operand),
+	}
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ContainerWorkDir is where the task's working directory is mounted inside
+// the container; commands run there.
+const ContainerWorkDir = "/work"
+
+// ContainerConfig configures container execution for tasks with an Image.
+//
+// The task's working directory (the shared workdir, or the scratch directory
+// under Runner.Isolated) is bind-mounted at ContainerWorkDir, so inputs are
+// visible and declared outputs land where the Harvester collects them. The
+// container sees only the task's declared env, runs without network, and runs
+// as the invoking user so outputs are not root-owned.
+type ContainerConfig struct {
+	// Engine is the Docker-compatible CLI to invoke ("docker" or "podman");
+	// empty means "docker".
+	Engine string
+
+	// Network is passed as --network; empty means "none". A task's own
+	// Network overrides it (see containerNetwork).
+	Network string
+}
+
+func (c *ContainerConfig) engine() string {
+	if c.Engine == "" {
+		return "docker"
+	}
+	return c.Engine
+}
+
+// args builds the engine arguments for running task in workDir. Env entries
+// are sorted so the invocation is deterministic.
+func (c *ContainerConfig) args(workDir string, task *Task) []string {
+	args := []string{
+		"run", "--rm", "--network", c.containerNetwork(task),
+		"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
+		"-v", workDir + ":" + ContainerWorkDir,
+		"-w", ContainerWorkDir,
+	}
+	keys := make([]string, 0, len(task.Env))
+	for k := range task.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "-e", k+"="+task.Env[k])
+	}
+	if task.Stdin != nil {
+		args = append(args, "-i")
+	}
+	return append(args, task.Image, "sh", "-c", task.Run)
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContainerConfig_Args(t *testing.T) {
+	c := &ContainerConfig{}
+	if got := c.engine(); got != "docker" {
+		t.Fatalf("expected default engine docker, got %q", got)
+	}
+	task := &Task{Name: "t", Run: "make", Image: "alpine:3", Env: map[string]string{"B": "2", "A": "1"}}
+	want := []string{
+		"run", "--rm", "--network", "none",
+		"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
+		"-v", "/src:" + ContainerWorkDir,
+		"-w", ContainerWorkDir,
+		"-e", "A=1", "-e", "B=2",
+		"alpine:3", "sh", "-c", "make",
+	}
+	if got := c.args("/src", task); !reflect.DeepEqual(got, want) {
+		t.Fatalf("args mismatch:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestRunner_ContainerTask(t *testing.T) {
+	workDir := t.TempDir()
+	writeTestFile(t, filepath.Join(workDir, "in.txt"), "hello")
+
+	// The fake engine logs its arguments and runs the trailing shell command
+	// in place, standing in for a container with the workdir mounted.
+	logPath := filepath.Join(t.TempDir(), "engine.log")
+	engine := filepath.Join(t.TempDir(), "fake-engine")
+	writeTestFile(t, engine, "#!/bin/sh\necho \"$@\" >> "+logPath+"\neval last=\\${$#}\nexec sh -c \"$last\"\n")
+	if err := os.Chmod(engine, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := NewRunner(workDir, NewMemoryCache())
+	task := &Task{Name: "c", Inputs: []string{"in.txt"}, Run: "cat in.txt > out.txt", Outputs: []string{"out.txt"}, Image: "alpine:3"}
+
+	if _, err := runner.Run(context.Background(), task); err == nil || !strings.Contains(err.Error(), "no container engine") {
+		t.Fatalf("expected missing-engine error, got %v", err)
+	}
+
+	runner.Executor.Container = &ContainerConfig{Engine: engine}
+	res, err := runner.Run(context.Background(), task)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.ExitCode != 0 {
+		t.Fatalf("expected success, got exit %d: %s", res.ExitCode, res.Stderr)
+	}
+	if b, err := os.ReadFile(filepath.Join(workDir, "out.txt")); err != nil || string(b) != "hello" {
+		t.Fatalf("expected harvested output, got %q (err=%v)", b, err)
+	}
+	logged, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(logged), "-v "+workDir+":"+ContainerWorkDir) || !strings.Contains(string(logged), " alpine:3 sh -c ") {
+		t.Fatalf("unexpected engine invocation: %s", logged)
+	}
+
+	// The image is part of the task identity; host tasks keep their hash.
+	hostTask := *task
+	hostTask.Image = ""
+	hostHash, _, err := runner.TaskHash(&hostTask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostHash == res.Hash {
+		t.Fatalf("expected image to change the task hash")
+	}
+	other := *task
+	other.Image = "alpine:4"
+	otherHash, _, err := runner.TaskHash(&other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherHash == res.Hash {
+		t.Fatalf("expected a different image to change the task hash")
+	}
+}
@@ -0,0 +1,30 @@
+package core
+
+// EffectLedger remembers the effect keys (Task.EffectKey) of tasks that
+// executed successfully, so an external side effect such as a deployment is
+// applied once even across resumed runs.
+type EffectLedger interface {
+	// EffectApplied reports whether key was recorded by an earlier success.
+	EffectApplied(key string) (bool, error)
+
+	// RecordEffect records key as applied by the named task.
+	RecordEffect(key, task string) error
+}
+
+// EffectApplied reports whether task has an effect key already recorded in
+// r.Effects. It is false without a ledger.
+func (r *Runner) EffectApplied(task *Task) (bool, error) {
+	if task.EffectKey == "" || r.Effects == nil {
+		return false, nil
+	}
+	return r.Effects.EffectApplied(task.EffectKey)
+}
+
+// RecordEffect records task's effect key in r.Effects if exitCode is a
+// success. It does nothing without a key or a ledger.
+func (r *Runner) RecordEffect(task *Task, exitCode int) error {
+	if task.EffectKey == "" || r.Effects == nil || !task.Succeeded(exitCode) {
+		return nil
+	}
+	return r.Effects.RecordEffect(task.EffectKey, task.Name)
+}
@@ -2,9 +2,10 @@
 package core
 
 import (
-	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"syscall"
 )
@@ -32,6 +33,14 @@ type ExecutionResult struct {
 
 	// Hash is the TaskHash that was used for this execution.
 	Hash TaskHash
+
+	// OutputTruncated is set when stdout or stderr exceeded the output limit
+	// and was cut short (see Executor.OutputLimit).
+	OutputTruncated bool
+
+	// Accesses records the files and network the task used when it ran
+	// under Executor.Strace; nil otherwise.
+	Accesses *TaskAccesses
 }
 
 // Executor runs tasks in an isolated, deterministic environment.
@@ -45,6 +54,29 @@ type ExecutionResult struct {
 type Executor struct {
 	// WorkingDir is the directory where tasks are executed.
 	WorkingDir string
+
+	// Container runs tasks that declare an Image inside that image. Tasks
+	// with an Image fail to execute when it is nil.
+	Container *ContainerConfig
+
+	// OutputLimit caps the bytes kept from each of stdout and stderr for
+	// tasks that do not set MaxOutputBytes; zero is unlimited. Output past
+	// the limit is discarded and replaced by a truncation marker.
+	OutputLimit int64
+
+	// OutputDir is the path OutputDirPlaceholder expands to in the tasks
+	// this executor runs (see expandPaths).
+	OutputDir string
+
+	// LiveOutput, when set, returns a writer receiving a copy of a task's
+	// raw stdout and stderr as the task produces them, for display only.
+	// It never affects the captured output; a nil writer skips the task.
+	LiveOutput func(task string) io.Writer
+
+	// Strace, when set, is the strace binary tasks without an image run
+	// under, recording their file and network accesses in
+	// ExecutionResult.Accesses. It slows tasks down and is for audits only.
+	Strace string
 }
 
 // NewExecutor creates a new Executor with the given working directory.
@@ -60,7 +92,9 @@ func NewExecutor(workingDir string) *Executor {
 //   - If PATH is not in env, the task sees no PATH.
 //
 // This is an ALLOWLIST approach: the environment starts empty and only
-// declared variables are added.
+// declared variables are added. The exceptions are the pinned variables TZ,
+// LC_ALL, SEED and SOURCE_DATE_EPOCH, set unless the task declares them (see
+// withPinnedEnv).
 func (e *Executor) Execute(ctx context.Context, task *Task, hash TaskHash) (*ExecutionResult, error) {
 	if task == nil {
 		return nil, fmt.Errorf("task is nil")
@@ -70,28 +104,79 @@ func (e *Executor) Execute(ctx context.Context, task *Task, hash TaskHash) (*Exe
 		return nil, fmt.Errorf("task.Run is empty")
 	}
 
-	// Create command
-	// Using "sh -c" to interpret the command string as a shell command
-	cmd := exec.CommandContext(ctx, "sh", "-c", task.Run)
+	if task.Kind == KindContainer && task.Image == "" {
+		return nil, fmt.Errorf("task %q is of kind %q but declares no image", task.Name, KindContainer)
+	}
+	task = withPinnedEnv(expandPaths(task, e.WorkingDir, e.OutputDir), hash)
+
+	var cmd *exec.Cmd
+	var straceLog string
+	if task.Image != "" {
+		if e.Container == nil {
+			return nil, fmt.Errorf("task %q declares image %q but no container engine is configured", task.Name, task.Image)
+		}
+		// The allowlisted env is passed into the container by args;
+		// the engine client itself needs the host env to reach its daemon.
+		cmd = exec.CommandContext(ctx, e.Container.engine(), e.Container.args(e.WorkingDir, task)...)
+		cmd.Dir = e.WorkingDir
+	} else {
+		// Create command
+		// Using "sh -c" to interpret the command string as a shell command
+		cmd = exec.CommandContext(ctx, "sh", "-c", task.Run)
+		if e.Strace != "" {
+			log, err := os.CreateTemp("", "scriptweaver-strace-*.log")
+			if err != nil {
+				return nil, fmt.Errorf("creating strace log: %w", err)
+			}
+			log.Close()
+			straceLog = log.Name()
+			defer os.Remove(straceLog)
+			cmd = exec.CommandContext(ctx, e.Strace, straceArgs(straceLog, "sh", "-c", task.Run)...)
+		}
 
-	// Set working directory
-	cmd.Dir = e.WorkingDir
+		// Set working directory
+		cmd.Dir = e.WorkingDir
 
-	// CRITICAL: Build environment from ALLOWLIST only
-	// Start with EMPTY environment, NOT os.Environ()
-	// Only add variables explicitly declared in task.Env
-	cmd.Env = buildIsolatedEnv(task.Env)
+		// CRITICAL: Build environment from ALLOWLIST only
+		// Start with EMPTY environment, NOT os.Environ()
+		// Only add variables explicitly declared in task.Env
+		cmd.Env = buildIsolatedEnv(task.Env)
+	}
 
 	// Set process group so we can kill the entire process tree on cancellation
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if task.Image == "" && task.Network == NetworkNone {
+		isolateNetwork(cmd.SysProcAttr)
+	}
 
 	// Capture stdout and stderr
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	limit := e.outputLimit(task)
+	stdout, stderr := &limitedBuffer{limit: limit}, &limitedBuffer{limit: limit}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if e.LiveOutput != nil {
+		if live := e.LiveOutput(task.Name); live != nil {
+			live = ignoreWriteErrors{live}
+			cmd.Stdout = io.MultiWriter(stdout, live)
+			cmd.Stderr = io.MultiWriter(stderr, live)
+		}
+	}
+
+	// Pipe in the declared stdin, if any; otherwise the task reads nothing.
+	if task.Stdin != nil {
+		in, release, err := task.Stdin.open(e.WorkingDir)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		cmd.Stdin = in
+	}
 
 	// Start the command
 	if err := cmd.Start(); err != nil {
+		if task.Image == "" && task.Network == NetworkNone {
+			return nil, fmt.Errorf("failed to start command in a network namespace (network %q): %w", NetworkNone, err)
+		}
 		return nil, fmt.Errorf("failed to start command: %w", err)
 	}
 
@@ -126,12 +211,24 @@ func (e *Executor) Execute(ctx context.Context, task *Task, hash TaskHash) (*Exe
 		}
 	}
 
-	return &ExecutionResult{
-		Stdout:   stdout.Bytes(),
-		Stderr:   stderr.Bytes(),
-		ExitCode: exitCode,
-		Hash:     hash,
-	}, nil
+	res := &ExecutionResult{
+		Stdout:          stdout.Bytes("stdout"),
+		Stderr:          stderr.Bytes("stderr"),
+		ExitCode:        exitCode,
+		Hash:            hash,
+		OutputTruncated: stdout.Truncated() || stderr.Truncated(),
+	}
+	if straceLog != "" {
+		f, err := os.Open(straceLog)
+		if err != nil {
+			return nil, fmt.Errorf("reading strace log: %w", err)
+		}
+		defer f.Close()
+		if res.Accesses, err = parseStraceLog(f, e.WorkingDir); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
 }
 
 // buildIsolatedEnv constructs an isolated environment from the declared variables.
@@ -0,0 +1,37 @@
+package core
+
+import "fmt"
+
+// Succeeded reports whether exitCode counts as success for the task: zero,
+// or one of its SuccessExitCodes.
+func (t *Task) Succeeded(exitCode int) bool {
+	if exitCode == 0 {
+		return true
+	}
+	for _, c := range t.SuccessExitCodes {
+		if c == exitCode {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateSuccessExitCodes checks that the task's accepted exit codes are
+// distinct process exit codes (1-255) and not one of the codes scriptweaver
+// reports for its own failures (UndeclaredOutputsExitCode,
+// ExpectedOutputsExitCode, ModifiedInputsExitCode).
+func (t *Task) ValidateSuccessExitCodes() error {
+	seen := make(map[int]bool, len(t.SuccessExitCodes))
+	for _, c := range t.SuccessExitCodes {
+		switch {
+		case c < 1 || c > 255:
+			return fmt.Errorf("success exit code %d must be between 1 and 255", c)
+		case c == UndeclaredOutputsExitCode || c == ExpectedOutputsExitCode || c == ModifiedInputsExitCode:
+			return fmt.Errorf("success exit code %d is reserved", c)
+		case seen[c]:
+			return fmt.Errorf("duplicate success exit code %d", c)
+		}
+		seen[c] = true
+	}
+	return nil
+}
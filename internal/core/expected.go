@@ -0,0 +1,128 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ExpectedOutputsExitCode is the exit code reported for a task that ran (or
+// was restored) successfully but whose artifacts do not match
+// Task.ExpectedOutputs.
+const ExpectedOutputsExitCode = 98
+
+// OutputMismatch is an artifact whose content digest differs from the one
+// expected for it. Actual is empty when the artifact was not produced.
+type OutputMismatch struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+// ValidateExpectedOutputs checks that every expectation names a relative
+// artifact path under one of the task's declared outputs and a lowercase
+// hex SHA-256 digest.
+func (t *Task) ValidateExpectedOutputs() error {
+	paths := make([]string, 0, len(t.ExpectedOutputs))
+	for p := range t.ExpectedOutputs {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	outputs := ManifestOutputs(t.Outputs)
+	for _, p := range paths {
+		if p == "" || path.IsAbs(p) || path.Clean(p) != p || p == ".." || strings.HasPrefix(p, "../") {
+			return fmt.Errorf("expected output %q must be a clean relative path", p)
+		}
+		if !coveredBy(p, outputs) {
+			return fmt.Errorf("expected output %q is not under a declared output", p)
+		}
+		if d := t.ExpectedOutputs[p]; len(d) != 64 || strings.Trim(d, "0123456789abcdef") != "" {
+			return fmt.Errorf("expected output %q: %q is not a lowercase hex sha256", p, d)
+		}
+	}
+	return nil
+}
+
+// CheckExpectedOutputs compares a successful entry's artifacts with the
+// task's expectations and returns the mismatches, sorted by path. Failed
+// entries and tasks without expectations have none.
+//
+// Symlink artifacts are compared by the digest of their link target.
+func CheckExpectedOutputs(task *Task, entry *CacheEntry) ([]OutputMismatch, error) {
+	if task == nil || entry == nil || !task.Succeeded(entry.ExitCode) || len(task.ExpectedOutputs) == 0 {
+		return nil, nil
+	}
+	actual := make(map[string]string, len(entry.Artifacts))
+	for _, a := range entry.Artifacts {
+		if _, ok := task.ExpectedOutputs[a.Path]; !ok {
+			continue
+		}
+		d, err := artifactDigest(a)
+		if err != nil {
+			return nil, fmt.Errorf("hashing artifact %q: %w", a.Path, err)
+		}
+		actual[a.Path] = d
+	}
+	var out []OutputMismatch
+	for p, want := range task.ExpectedOutputs {
+		if got := actual[p]; got != want {
+			out = append(out, OutputMismatch{Path: p, Expected: want, Actual: got})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+func artifactDigest(a CachedArtifact) (string, error) {
+	switch {
+	case a.LinkTarget != "":
+		return sha256Hex([]byte(a.LinkTarget)), nil
+	case a.SHA256 != "":
+		return a.SHA256, nil
+	case !a.Streamed():
+		return sha256Hex(a.Content), nil
+	}
+	src, err := a.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, src); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ExpectedOutputsReport is appended to the stderr of a task whose artifacts
+// do not match its expectations.
+func ExpectedOutputsReport(mismatches []OutputMismatch) []byte {
+	var b strings.Builder
+	b.WriteString("scriptweaver: task outputs do not match their expected digests:\n")
+	for _, m := range mismatches {
+		actual := m.Actual
+		if actual == "" {
+			actual = "(missing)"
+		}
+		fmt.Fprintf(&b, "  %s: expected %s, got %s\n", m.Path, m.Expected, actual)
+	}
+	return []byte(b.String())
+}
+
+// applyExpectedOutputs fails res when entry's artifacts do not match task's
+// expectations. The cache entry itself is left as is: it records what the
+// task produced, and is re-checked on every hit.
+func applyExpectedOutputs(res *RunResult, task *Task, entry *CacheEntry) error {
+	m, err := CheckExpectedOutputs(task, entry)
+	if err != nil || len(m) == 0 {
+		return err
+	}
+	res.ExitCode = ExpectedOutputsExitCode
+	res.Stderr = append(append([]byte(nil), res.Stderr...), ExpectedOutputsReport(m)...)
+	res.OutputMismatches = m
+	return nil
+}
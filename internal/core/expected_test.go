@@ -0,0 +1,77 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+const digestV1 = "3bfc269594ef649228e9a74bab00f042efc91d5acc6fbee31a382e80d42388fe" // sha256("v1")
+
+func TestTask_ValidateExpectedOutputs(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected map[string]string
+		ok       bool
+	}{
+		{"none", nil, true},
+		{"under declared dir", map[string]string{"dist/a.txt": digestV1}, true},
+		{"glob match", map[string]string{"logs/x.log": digestV1}, true},
+		{"undeclared", map[string]string{"other.txt": digestV1}, false},
+		{"absolute", map[string]string{"/dist/a.txt": digestV1}, false},
+		{"unclean", map[string]string{"dist/../dist/a.txt": digestV1}, false},
+		{"bad digest", map[string]string{"dist/a.txt": "xyz"}, false},
+		{"uppercase digest", map[string]string{"dist/a.txt": "3BFC269594EF649228E9A74BAB00F042EFC91D5ACC6FBEE31A382E80D42388FE"}, false},
+	}
+	for _, tc := range tests {
+		task := &Task{Name: "t", Run: "true", Outputs: []string{"dist", "logs/*.log"}, ExpectedOutputs: tc.expected}
+		if err := task.ValidateExpectedOutputs(); (err == nil) != tc.ok {
+			t.Errorf("%s: ok=%v, err=%v", tc.name, tc.ok, err)
+		}
+	}
+}
+
+func TestRunner_ExpectedOutputs(t *testing.T) {
+	tmpDir := t.TempDir()
+	runner := NewRunner(tmpDir, NewMemoryCache())
+	task := &Task{Name: "t", Run: "printf v1 > a.txt", Outputs: []string{"a.txt"}, ExpectedOutputs: map[string]string{"a.txt": digestV1}}
+
+	res, err := runner.Run(context.Background(), task)
+	if err != nil || res.ExitCode != 0 || len(res.OutputMismatches) != 0 {
+		t.Fatalf("expected a match, got %+v (err=%v)", res, err)
+	}
+
+	// The same result, now restored from cache, is re-checked against a
+	// different expectation (expectations are not part of the hash).
+	task.ExpectedOutputs = map[string]string{"a.txt": sha256Hex([]byte("v2"))}
+	res, err = runner.Run(context.Background(), task)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !res.FromCache || res.ExitCode != ExpectedOutputsExitCode {
+		t.Fatalf("expected cached mismatch with exit %d, got %+v", ExpectedOutputsExitCode, res)
+	}
+	if len(res.OutputMismatches) != 1 || res.OutputMismatches[0].Actual != digestV1 {
+		t.Fatalf("unexpected mismatches: %+v", res.OutputMismatches)
+	}
+	if !bytes.Contains(res.Stderr, []byte("a.txt: expected")) {
+		t.Fatalf("expected mismatch report in stderr, got %q", res.Stderr)
+	}
+}
+
+func TestCheckExpectedOutputs_MissingArtifact(t *testing.T) {
+	task := &Task{Outputs: []string{"dist"}, ExpectedOutputs: map[string]string{"dist/b.txt": digestV1, "dist/a.txt": digestV1}}
+	entry := &CacheEntry{Artifacts: []CachedArtifact{{Path: "dist/a.txt", Content: []byte("v1")}}}
+	m, err := CheckExpectedOutputs(task, entry)
+	if err != nil {
+		t.Fatalf("CheckExpectedOutputs failed: %v", err)
+	}
+	if len(m) != 1 || m[0].Path != "dist/b.txt" || m[0].Actual != "" {
+		t.Fatalf("expected dist/b.txt reported missing, got %+v", m)
+	}
+
+	entry.ExitCode = 1
+	if m, _ := CheckExpectedOutputs(task, entry); len(m) != 0 {
+		t.Fatalf("failed entries are not checked, got %+v", m)
+	}
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// RemoteInput is a task input of the form
+//
+//	https://example.com/dl/tool.tar.gz#sha256=<hex>
+//
+// The content is pinned by its digest, so it is hashed without being
+// downloaded. Before the task executes, the file is fetched into the
+// DownloadCache, verified and placed in the task's directory under the URL's
+// base name (tool.tar.gz above).
+type RemoteInput struct {
+	URL    string
+	SHA256 string
+	// Name is the file name the task sees, the last element of the URL path.
+	Name string
+}
+
+// IsRemoteInput reports whether an input pattern is an http(s) URL.
+func IsRemoteInput(pattern string) bool {
+	return strings.HasPrefix(pattern, "https://") || strings.HasPrefix(pattern, "http://")
+}
+
+// ParseRemoteInput parses an http(s) input. The fragment must pin the
+// content with sha256=<lowercase hex>; it is not part of the fetched URL.
+func ParseRemoteInput(pattern string) (RemoteInput, error) {
+	raw, fragment, _ := strings.Cut(pattern, "#")
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return RemoteInput{}, fmt.Errorf("remote input %q is not a valid URL", pattern)
+	}
+	digest, ok := strings.CutPrefix(fragment, "sha256=")
+	if !ok || !isHexDigest(digest) {
+		return RemoteInput{}, fmt.Errorf("remote input %q must be pinned with #sha256=<lowercase hex digest>", pattern)
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" || name == ".." {
+		return RemoteInput{}, fmt.Errorf("remote input %q has no file name in its path", pattern)
+	}
+	return RemoteInput{URL: raw, SHA256: digest, Name: name}, nil
+}
+
+// ValidateRemoteInputs checks that the task's remote inputs are pinned and
+// that no two of them would be placed at the same name.
+func (t *Task) ValidateRemoteInputs() error {
+	names := map[string]string{}
+	for _, in := range t.Inputs {
+		if !IsRemoteInput(in) {
+			continue
+		}
+		ri, err := ParseRemoteInput(in)
+		if err != nil {
+			return err
+		}
+		if prev, dup := names[ri.Name]; dup && prev != ri.URL {
+			return fmt.Errorf("remote inputs %q and %q would both be placed at %q", prev, ri.URL, ri.Name)
+		}
+		names[ri.Name] = ri.URL
+	}
+	return nil
+}
+
+// DownloadCache stores remote inputs under Dir by content digest, so each
+// pinned file is downloaded once and later runs work offline.
+type DownloadCache struct {
+	Dir string
+	// Client fetches URLs; nil means http.DefaultClient.
+	Client *http.Client
+}
+
+// NewDownloadCache returns a download cache rooted at dir.
+func NewDownloadCache(dir string) *DownloadCache {
+	return &DownloadCache{Dir: dir}
+}
+
+// Path returns where the content with digest is stored.
+func (c *DownloadCache) Path(digest string) string {
+	return filepath.Join(c.Dir, digest)
+}
+
+// Fetch returns the path of ri's content, downloading it first if it is not
+// cached yet. Content whose digest differs from the pinned one is rejected
+// and not stored.
+func (c *DownloadCache) Fetch(ctx context.Context, ri RemoteInput) (string, error) {
+	dst := c.Path(ri.SHA256)
+	if _, err := os.Stat(dst); err == nil {
+		return dst, nil
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ri.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", ri.URL, resp.Status)
+	}
+
+	tmp, err := os.CreateTemp(c.Dir, ".download-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), resp.Body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("GET %s: %w", ri.URL, err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != ri.SHA256 {
+		return "", fmt.Errorf("GET %s: content has sha256 %s, expected %s", ri.URL, got, ri.SHA256)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// resolveRemote turns a remote input into an Input placed under BaseDir.
+// Nothing is downloaded: the pinned digest stands in for the content.
+func (r *InputResolver) resolveRemote(pattern string) (Input, error) {
+	ri, err := ParseRemoteInput(pattern)
+	if err != nil {
+		return Input{}, err
+	}
+	p := filepath.ToSlash(filepath.Join(r.BaseDir, ri.Name))
+	return Input{Path: p, Digest: ri.SHA256, Remote: &ri}, nil
+}
+
+// FetchInputs downloads inputs' remote inputs into Downloads, pointing each
+// at its cached content (see Input.Source), and places them in the working
+// directory unless the runner is Isolated, in which case they are
+// materialized into the scratch directory with the other inputs.
+func (r *Runner) FetchInputs(ctx context.Context, inputs *InputSet) error {
+	if inputs == nil {
+		return nil
+	}
+	for i, in := range inputs.Inputs {
+		if in.Remote == nil {
+			continue
+		}
+		if r.Downloads == nil {
+			return fmt.Errorf("remote input %s: no download cache configured", in.Remote.URL)
+		}
+		src, err := r.Downloads.Fetch(ctx, *in.Remote)
+		if err != nil {
+			return fmt.Errorf("fetching remote input: %w", err)
+		}
+		inputs.Inputs[i].Source = src
+		if r.Isolated {
+			continue
+		}
+		if err := placeFile(src, filepath.FromSlash(in.Path)); err != nil {
+			return fmt.Errorf("placing remote input %s: %w", in.Remote.Name, err)
+		}
+	}
+	return nil
+}
+
+// placeFile atomically replaces dst with a copy of src.
+func placeFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".scriptweaver-fetch-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, in)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), ArtifactModeRegular)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
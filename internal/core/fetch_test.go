@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunner_RemoteInputIsFetchedVerifiedAndHashedByPin(t *testing.T) {
+	body := []byte("remote data\n")
+	sum := sha256.Sum256(body)
+	digest := hex.EncodeToString(sum[:])
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	runner := NewRunner(dir, NewMemoryCache())
+	runner.Downloads = NewDownloadCache(filepath.Join(t.TempDir(), "downloads"))
+	task := &Task{Name: "use", Inputs: []string{srv.URL + "/dl/data.txt#sha256=" + digest}, Run: "cat data.txt > out.txt", Outputs: []string{"out.txt"}}
+
+	// Hashing needs no download.
+	hash, set, err := runner.TaskHash(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 0 || len(set.Inputs) != 1 || set.Inputs[0].ContentDigest() != digest {
+		t.Fatalf("hits %d inputs %+v", hits.Load(), set.Inputs)
+	}
+
+	res, err := runner.Run(context.Background(), task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != 0 || res.Hash != hash {
+		t.Fatalf("exit %d stderr %q", res.ExitCode, res.Stderr)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "out.txt")); string(b) != string(body) {
+		t.Fatalf("out.txt = %q", b)
+	}
+
+	// A rerun reuses the downloaded content, and isolated runs see it too.
+	srv.Close()
+	runner.Rerun = map[string]bool{"use": true}
+	runner.Isolated = true
+	if res, err := runner.Run(context.Background(), task); err != nil || res.ExitCode != 0 {
+		t.Fatalf("offline rerun: %v %+v", err, res)
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("downloaded %d times, want 1", hits.Load())
+	}
+}
+
+func TestDownloadCache_RejectsDigestMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("tampered"))
+	}))
+	defer srv.Close()
+
+	c := NewDownloadCache(t.TempDir())
+	ri := RemoteInput{URL: srv.URL + "/f", SHA256: strings.Repeat("0", 64), Name: "f"}
+	if _, err := c.Fetch(context.Background(), ri); err == nil || !strings.Contains(err.Error(), "expected "+ri.SHA256) {
+		t.Fatalf("expected a digest mismatch, got %v", err)
+	}
+	if entries, _ := os.ReadDir(c.Dir); len(entries) != 0 {
+		t.Fatalf("mismatched content was stored: %v", entries)
+	}
+}
+
+func TestValidateRemoteInputs(t *testing.T) {
+	pin := "#sha256=" + strings.Repeat("a", 64)
+	cases := map[string][]string{
+		"unpinned":     {"https://example.com/a.tar.gz"},
+		"bad digest":   {"https://example.com/a.tar.gz#sha256=xyz"},
+		"no file name": {"https://example.com/" + pin},
+		"same name":    {"https://a.example/x.zip" + pin, "https://b.example/x.zip" + pin},
+	}
+	for name, inputs := range cases {
+		if err := (&Task{Name: "t", Inputs: inputs}).ValidateRemoteInputs(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := (&Task{Name: "t", Inputs: []string{"src/*.go", "https://example.com/dl/a.tar.gz" + pin}}).ValidateRemoteInputs(); err != nil {
+		t.Fatal(err)
+	}
+}
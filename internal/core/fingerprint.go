@@ -0,0 +1,136 @@
+// Package core defines the domain models for deterministic task execution.
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FingerprintRacyWindow is how recently a file may have been modified and
+// still be fingerprinted. A file written within the filesystem's timestamp
+// granularity of being hashed could change again without its mtime moving,
+// so such files are re-hashed on every run until they settle.
+const FingerprintRacyWindow = 2 * time.Second
+
+// Fingerprint is the recorded identity of an input file: its content digest,
+// valid while the file's size and modification time are unchanged.
+type Fingerprint struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime_ns"`
+	Digest  string `json:"sha256"`
+}
+
+// FingerprintCache maps input paths to fingerprints so unchanged files are
+// not re-read to be hashed. It is persisted as a single JSON file and is a
+// pure optimization: a missing or unreadable cache only costs re-hashing.
+//
+// Safe for concurrent use.
+type FingerprintCache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]Fingerprint
+	dirty   bool
+}
+
+// LoadFingerprintCache reads the cache at path. A missing or corrupt file
+// yields an empty cache that Save will overwrite.
+func LoadFingerprintCache(path string) *FingerprintCache {
+	c := &FingerprintCache{path: path, entries: map[string]Fingerprint{}}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	var entries map[string]Fingerprint
+	if err := json.Unmarshal(b, &entries); err != nil || entries == nil {
+		return c
+	}
+	c.entries = entries
+	return c
+}
+
+// Lookup returns the recorded digest for path if info still matches it.
+func (c *FingerprintCache) Lookup(path string, info os.FileInfo) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fp, ok := c.entries[path]
+	if !ok || fp.Size != info.Size() || fp.ModTime != info.ModTime().UnixNano() || fp.Digest == "" {
+		return "", false
+	}
+	return fp.Digest, true
+}
+
+// Record stores path's digest unless the file was modified too recently to
+// be trusted (see FingerprintRacyWindow).
+func (c *FingerprintCache) Record(path string, info os.FileInfo, digest string) {
+	if time.Since(info.ModTime()) < FingerprintRacyWindow {
+		return
+	}
+	fp := Fingerprint{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Digest: digest}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries[path] != fp {
+		c.entries[path] = fp
+		c.dirty = true
+	}
+}
+
+// Len returns the number of recorded fingerprints.
+func (c *FingerprintCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Save writes the cache atomically if it changed, dropping entries for files
+// that no longer exist.
+func (c *FingerprintCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for p := range c.entries {
+		if _, err := os.Lstat(filepath.FromSlash(p)); os.IsNotExist(err) {
+			delete(c.entries, p)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+	b, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal fingerprints: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("create fingerprint dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".fingerprints-*")
+	if err != nil {
+		return fmt.Errorf("write fingerprints: %w", err)
+	}
+	_, werr := tmp.Write(append(b, '\n'))
+	if cerr := tmp.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr == nil {
+		werr = os.Rename(tmp.Name(), c.path)
+	}
+	if werr != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write fingerprints: %w", werr)
+	}
+	c.dirty = false
+	return nil
+}
+
+// contentDigest is the hex SHA-256 of an input's content, as hashed into
+// task identity.
+func contentDigest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
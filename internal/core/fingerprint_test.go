@@ -0,0 +1,107 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeAged(t *testing.T, path, content string, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolve_FingerprintCacheSkipsUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	path := filepath.Join(dir, "in.txt")
+	writeAged(t, path, "aaaa", old)
+	fresh := filepath.Join(dir, "fresh.txt")
+	if err := os.WriteFile(fresh, []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cachePath := filepath.Join(dir, "state", "fingerprints.json")
+	resolver := NewInputResolver(dir)
+	resolver.Fingerprints = LoadFingerprintCache(cachePath)
+	first, err := resolver.Resolve([]string{"*.txt"})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if resolver.Fingerprints.Len() != 1 {
+		t.Fatalf("expected only the settled file fingerprinted, got %d entries", resolver.Fingerprints.Len())
+	}
+	if err := resolver.Fingerprints.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Same size and mtime: the recorded digest is trusted without reading.
+	writeAged(t, path, "bbbb", old)
+	resolver = NewInputResolver(dir)
+	resolver.Fingerprints = LoadFingerprintCache(cachePath)
+	second, err := resolver.Resolve([]string{"*.txt"})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	in := second.Inputs[1]
+	if in.Content != nil || in.Digest != first.Inputs[1].ContentDigest() {
+		t.Fatalf("expected fingerprint hit for %s, got %+v", in.Path, in)
+	}
+	if got, err := in.ReadContent(); err != nil || string(got) != "bbbb" {
+		t.Fatalf("ReadContent: got %q (err=%v)", got, err)
+	}
+	if second.Inputs[0].Content == nil {
+		t.Fatalf("racily-fresh file must be read, got %+v", second.Inputs[0])
+	}
+
+	// A new mtime invalidates the fingerprint.
+	writeAged(t, path, "bbbb", old.Add(time.Second))
+	third, err := resolver.Resolve([]string{"in.txt"})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if string(third.Inputs[0].Content) != "bbbb" || third.Inputs[0].ContentDigest() == first.Inputs[1].ContentDigest() {
+		t.Fatalf("expected re-read after mtime change, got %+v", third.Inputs[0])
+	}
+}
+
+func TestFingerprintCache_SaveDropsMissingFilesAndToleratesCorruption(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	keep := filepath.Join(dir, "keep.txt")
+	gone := filepath.Join(dir, "gone.txt")
+	writeAged(t, keep, "k", old)
+	writeAged(t, gone, "g", old)
+
+	cachePath := filepath.Join(dir, "fingerprints.json")
+	c := LoadFingerprintCache(cachePath)
+	for _, p := range []string{keep, gone} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Record(filepath.ToSlash(p), info, "digest")
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if n := LoadFingerprintCache(cachePath).Len(); n != 1 {
+		t.Fatalf("expected 1 entry after save, got %d", n)
+	}
+
+	if err := os.WriteFile(cachePath, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if n := LoadFingerprintCache(cachePath).Len(); n != 0 {
+		t.Fatalf("corrupt cache must load empty, got %d entries", n)
+	}
+}
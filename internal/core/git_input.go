@@ -0,0 +1,65 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitInputPrefix marks an input pattern that expands to the files git
+// tracks under a path: "git:src" is every tracked file under src and "git:"
+// (or "git:.") every tracked file under the resolver's BaseDir. Content is
+// read from the working tree, so uncommitted edits count, but untracked and
+// ignored files never do. Tracked files deleted from the working tree are
+// left out.
+const GitInputPrefix = "git:"
+
+// IsGitInput reports whether an input pattern is a git tracked-files pattern.
+func IsGitInput(pattern string) bool {
+	return strings.HasPrefix(pattern, GitInputPrefix)
+}
+
+// expandGitPattern lists the files git tracks under the pattern's path. The
+// path is taken literally, not as a git pathspec glob.
+func (r *InputResolver) expandGitPattern(pattern string) ([]string, error) {
+	path := strings.TrimPrefix(pattern, GitInputPrefix)
+	if path == "" {
+		path = "."
+	}
+	cmd := exec.Command("git", "--literal-pathspecs", "ls-files", "-z", "--", filepath.FromSlash(path))
+	cmd.Dir = r.BaseDir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git ls-files: %s", msg)
+		}
+		return nil, fmt.Errorf("git ls-files: %w", err)
+	}
+
+	var files []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name == "" {
+			continue
+		}
+		full := filepath.Join(r.BaseDir, filepath.FromSlash(name))
+		info, err := os.Stat(full)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("stat %q: %w", full, err)
+		}
+		// Submodules are listed as directories.
+		if info.IsDir() {
+			continue
+		}
+		files = append(files, filepath.ToSlash(full))
+	}
+	return files, nil
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolve_GitInputListsTrackedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	write(".gitignore", "build/\n")
+	write("src/b.go", "b")
+	write("src/a.go", "a")
+	write("src/gone.go", "gone")
+	write("src/[x].go", "literal")
+	write("docs/readme", "docs")
+	git("add", ".")
+	write("src/untracked.go", "junk")
+	write("src/build/out.o", "junk")
+	write("src/a.go", "edited")
+	if err := os.Remove(filepath.Join(dir, "src", "gone.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := NewInputResolver(dir).Resolve([]string{"git:src"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, in := range set.Inputs {
+		rel, _ := filepath.Rel(dir, filepath.FromSlash(in.Path))
+		got = append(got, filepath.ToSlash(rel)+"="+string(in.Content))
+	}
+	want := []string{"src/[x].go=literal", "src/a.go=edited", "src/b.go=b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if set, err := NewInputResolver(dir).Resolve([]string{"git:"}); err != nil || len(set.Inputs) != 5 {
+		t.Fatalf("git: resolved %v, err %v", set, err)
+	}
+	if _, err := NewInputResolver(t.TempDir()).Resolve([]string{"git:"}); err == nil {
+		t.Fatal("expected an error outside a git repository")
+	}
+}
@@ -28,6 +28,12 @@ type Harvester struct {
 	// Normalizer is used to normalize artifact contents.
 	// If nil, no normalization is applied (raw bytes preserved).
 	Normalizer OutputNormalizer
+
+	// StreamThreshold is the file size at or above which an artifact is not
+	// read into memory but returned with SourcePath set. Streaming applies
+	// only without a Normalizer (normalization needs the whole content); zero
+	// disables it.
+	StreamThreshold int64
 }
 
 // OutputNormalizer defines the interface for normalizing output content.
@@ -41,16 +47,18 @@ type OutputNormalizer interface {
 // NewHarvester creates a new Harvester with the given base directory.
 func NewHarvester(baseDir string) *Harvester {
 	return &Harvester{
-		BaseDir:    baseDir,
-		Normalizer: nil, // Default: no normalization (raw bytes)
+		BaseDir:         baseDir,
+		Normalizer:      nil, // Default: no normalization (raw bytes)
+		StreamThreshold: DefaultStreamThreshold,
 	}
 }
 
 // NewHarvesterWithNormalizer creates a Harvester with a custom normalizer.
 func NewHarvesterWithNormalizer(baseDir string, normalizer OutputNormalizer) *Harvester {
 	return &Harvester{
-		BaseDir:    baseDir,
-		Normalizer: normalizer,
+		BaseDir:         baseDir,
+		Normalizer:      normalizer,
+		StreamThreshold: DefaultStreamThreshold,
 	}
 }
 
@@ -61,10 +69,14 @@ func NewHarvesterWithNormalizer(baseDir string, normalizer OutputNormalizer) *Ha
 //
 // The harvesting process:
 //  1. Each declared output path is resolved relative to BaseDir
-//  2. If the path is a file, it is collected
-//  3. If the path is a directory, all files within are collected recursively
+//  2. Glob patterns (e.g. "dist/*.tar.gz") are expanded; a pattern matching
+//     nothing is an error, like a missing literal output
+//  3. A file is collected; a directory's files are collected recursively
 //  4. All collected paths are sorted for determinism
-//  5. File contents are read and optionally normalized
+//  5. File contents are read and optionally normalized; files at or above
+//     StreamThreshold are left on disk (SourcePath) when not normalizing
+//  6. Each artifact records its normalized mode, or its target if it is a
+//     symlink (symlinks are never followed)
 //
 // Returns an error if:
 //   - A declared output does not exist (task failed to produce it)
@@ -84,25 +96,39 @@ func (h *Harvester) Harvest(declaredOutputs []string) (*ArtifactSet, error) {
 			fullPath = filepath.Join(h.BaseDir, output)
 		}
 
-		// Check if path exists
-		info, err := os.Stat(fullPath)
-		if err != nil {
-			if os.IsNotExist(err) {
-				return nil, fmt.Errorf("declared output does not exist: %s", output)
+		matches := []string{fullPath}
+		if containsGlobChar(output) {
+			var err error
+			matches, err = expandOutputPattern(fullPath)
+			if err != nil {
+				return nil, fmt.Errorf("expanding output %q: %w", output, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("declared output pattern matched nothing: %s", output)
 			}
-			return nil, fmt.Errorf("stat output %q: %w", output, err)
 		}
 
-		if info.IsDir() {
-			// Collect all files in directory recursively
-			files, err := h.collectFilesFromDir(fullPath)
+		for _, match := range matches {
+			// Check if path exists. Symlinks are recorded as links, not followed.
+			info, err := os.Lstat(match)
 			if err != nil {
-				return nil, fmt.Errorf("collecting files from %q: %w", output, err)
+				if os.IsNotExist(err) {
+					return nil, fmt.Errorf("declared output does not exist: %s", output)
+				}
+				return nil, fmt.Errorf("stat output %q: %w", output, err)
+			}
+
+			if info.IsDir() {
+				// Collect all files in directory recursively
+				files, err := h.collectFilesFromDir(match)
+				if err != nil {
+					return nil, fmt.Errorf("collecting files from %q: %w", output, err)
+				}
+				allPaths = append(allPaths, files...)
+			} else {
+				// Single file
+				allPaths = append(allPaths, match)
 			}
-			allPaths = append(allPaths, files...)
-		} else {
-			// Single file
-			allPaths = append(allPaths, fullPath)
 		}
 	}
 
@@ -116,14 +142,39 @@ func (h *Harvester) Harvest(declaredOutputs []string) (*ArtifactSet, error) {
 	// Read and normalize file contents
 	artifacts := make([]Artifact, 0, len(allPaths))
 	for _, path := range allPaths {
-		content, err := os.ReadFile(path)
+		info, err := os.Lstat(path)
 		if err != nil {
-			return nil, fmt.Errorf("reading artifact %q: %w", path, err)
+			return nil, fmt.Errorf("stat artifact %q: %w", path, err)
 		}
+		var content []byte
+		var mode os.FileMode
+		sourcePath := ""
+		linkTarget := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			linkTarget, err = os.Readlink(path)
+			if err != nil {
+				return nil, fmt.Errorf("reading symlink %q: %w", path, err)
+			}
+			// Such a link could not be restored (see LinkWithinWorkDir).
+			if !LinkWithinWorkDir(h.BaseDir, path, linkTarget) {
+				return nil, fmt.Errorf("symlink %q points to %q: %w", path, linkTarget, ErrPathOutsideWorkDir)
+			}
+			content = []byte{}
+		} else if h.streams(info) {
+			sourcePath = path
+			mode = normalizeArtifactMode(info.Mode())
+		} else {
+			mode = normalizeArtifactMode(info.Mode())
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("reading artifact %q: %w", path, err)
+			}
+			content = b
 
-		// Normalize content if normalizer is configured
-		if h.Normalizer != nil {
-			content = h.Normalizer.Normalize(content)
+			// Normalize content if normalizer is configured
+			if h.Normalizer != nil {
+				content = h.Normalizer.Normalize(content)
+			}
 		}
 
 		// Store paths relative to BaseDir for portability and correct replay location.
@@ -140,14 +191,36 @@ func (h *Harvester) Harvest(declaredOutputs []string) (*ArtifactSet, error) {
 		normPath := filepath.ToSlash(rel)
 
 		artifacts = append(artifacts, Artifact{
-			Path:    normPath,
-			Content: content,
+			Path:       normPath,
+			Content:    content,
+			SourcePath: sourcePath,
+			Mode:       mode,
+			LinkTarget: linkTarget,
 		})
 	}
 
 	return &ArtifactSet{Artifacts: artifacts}, nil
 }
 
+// streams reports whether the regular file described by info should be
+// harvested as a streamed artifact rather than read into memory.
+func (h *Harvester) streams(info os.FileInfo) bool {
+	if h.StreamThreshold <= 0 || h.Normalizer != nil {
+		return false
+	}
+	return info.Size() >= h.StreamThreshold
+}
+
+// expandOutputPattern expands a declared output glob into sorted matches.
+func expandOutputPattern(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern: %w", err)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
 // collectFilesFromDir recursively collects all files in a directory.
 // Returns paths sorted for determinism.
 func (h *Harvester) collectFilesFromDir(dir string) ([]string, error) {
@@ -313,3 +313,39 @@ func TestHarvest_DoesNotUseGitStatus(t *testing.T) {
 		}
 	}
 }
+
+func TestHarvest_GlobOutputs(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "dist", "docs-1.2"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(tmpDir, "dist", "app-1.2.tar.gz"), "b")
+	writeTestFile(t, filepath.Join(tmpDir, "dist", "app-1.10.tar.gz"), "a")
+	writeTestFile(t, filepath.Join(tmpDir, "dist", "notes.txt"), "n")
+	writeTestFile(t, filepath.Join(tmpDir, "dist", "docs-1.2", "index.html"), "d")
+
+	result, err := NewHarvester(tmpDir).Harvest([]string{"dist/*.tar.gz", "dist/docs-*"})
+	if err != nil {
+		t.Fatalf("Harvest failed: %v", err)
+	}
+	var got []string
+	for _, a := range result.Artifacts {
+		got = append(got, a.Path)
+	}
+	want := []string{"dist/app-1.10.tar.gz", "dist/app-1.2.tar.gz", "dist/docs-1.2/index.html"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	if _, err := NewHarvester(tmpDir).Harvest([]string{"dist/*.zip"}); err == nil {
+		t.Fatalf("expected error for pattern matching nothing")
+	}
+	if _, err := NewHarvester(tmpDir).Harvest([]string{"dist/[.tar"}); err == nil {
+		t.Fatalf("expected error for malformed pattern")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"sort"
+	"strconv"
 )
 
 // TaskHash represents a deterministic identifier for a task execution.
@@ -58,7 +59,44 @@ type HashInput struct {
 	// WorkingDir is the working directory identity.
 	// This is included to ensure tasks with different working directories
 	// produce different hashes even with identical other inputs.
+	// Runner passes RelativeWorkDirToken unless AbsoluteHashPaths is set.
 	WorkingDir string
+
+	// Image is the container image the task runs in (Task.Image), empty for
+	// host execution. It is hashed only when set, so host-task hashes are
+	// unchanged by its introduction.
+	Image string
+
+	// Kind is the task's runner kind (Task.Kind). The default kind (empty or
+	// KindShell) is not hashed, so existing hashes are unchanged.
+	Kind string
+
+	// Normalize is the task's normalize rules (Task.Normalize), hashed in
+	// order and only when present.
+	Normalize []NormalizeRule
+
+	// MaxOutputBytes is the task's output limit (Task.MaxOutputBytes),
+	// hashed only when set.
+	MaxOutputBytes int64
+
+	// SuccessExitCodes is the task's accepted non-zero exit codes
+	// (Task.SuccessExitCodes), hashed in order and only when present.
+	SuccessExitCodes []int
+
+	// NotCacheable is set for tasks opted out of caching (Task.Cacheable),
+	// hashed only when set.
+	NotCacheable bool
+
+	// Stdin is the task's stdin (Task.Stdin), hashed only when set.
+	Stdin *Stdin
+
+	// Tools is the task's resolved tools (Task.Tools, see
+	// Runner.ResolveTools), sorted by name and hashed only when present.
+	Tools []ResolvedTool
+
+	// Network is the task's network mode (Task.Network), hashed only when
+	// set.
+	Network string
 }
 
 // ComputeHash computes a deterministic TaskHash from the given inputs.
@@ -68,7 +106,16 @@ type HashInput struct {
 //  2. Command
 //  3. Sorted environment variables (key=value pairs)
 //  4. Sorted declared outputs
-//  5. For each input (already sorted): path + content
+//  5. For each input (already sorted): path + content SHA-256
+//  6. Container image, when set
+//  7. Runner kind, unless it is the default
+//  8. Normalize rules, in declaration order, when present
+//  9. Output limit, when set
+//  10. Accepted non-zero exit codes, in declaration order, when present
+//  11. The cache opt-out, when set
+//  12. Stdin text or file path, when set
+//  13. Tool declarations and resolved binary digests, when present
+//  14. Network mode, when set
 //
 // All components are length-prefixed to prevent ambiguity.
 //
@@ -128,7 +175,11 @@ func (h *TaskHasher) ComputeHash(input HashInput) TaskHash {
 		writeField([]byte(out))
 	}
 
-	// 5. Inputs - path and content for each (already sorted by InputResolver)
+	// 5. Inputs - path and content digest for each (already sorted by
+	// InputResolver). Hashing the digest rather than the content lets
+	// unchanged files be identified by fingerprint without being read.
+	// Earlier releases hashed the raw content, so this changed every
+	// TaskHash (see the migration note in CHANGELOG.md).
 	inputCount := 0
 	if input.Inputs != nil {
 		inputCount = len(input.Inputs.Inputs)
@@ -139,10 +190,82 @@ func (h *TaskHasher) ComputeHash(input HashInput) TaskHash {
 		for _, inp := range input.Inputs.Inputs {
 			// Both path and content contribute to identity
 			writeField([]byte(inp.Path))
-			writeField(inp.Content)
+			writeField([]byte(inp.ContentDigest()))
+		}
+	}
+
+	// 6. Container image (only when set, keeping host-task hashes stable)
+	if input.Image != "" {
+		writeField([]byte("image"))
+		writeField([]byte(input.Image))
+	}
+
+	// 7. Runner kind (only when not the default, keeping shell-task hashes stable)
+	if input.Kind != "" && input.Kind != KindShell {
+		writeField([]byte("kind"))
+		writeField([]byte(input.Kind))
+	}
+
+	// 8. Normalize rules (only when present, keeping existing hashes stable)
+	if len(input.Normalize) > 0 {
+		writeField([]byte("normalize"))
+		writeField([]byte{byte(len(input.Normalize))})
+		for _, rule := range input.Normalize {
+			writeField([]byte(rule.Pattern))
+			writeField([]byte(rule.Replacement))
+		}
+	}
+
+	// 9. Output limit (only when set)
+	if input.MaxOutputBytes > 0 {
+		writeField([]byte("max_output_bytes"))
+		writeField([]byte(strconv.FormatInt(input.MaxOutputBytes, 10)))
+	}
+
+	// 10. Accepted exit codes (only when present)
+	if len(input.SuccessExitCodes) > 0 {
+		writeField([]byte("success_exit_codes"))
+		writeField([]byte{byte(len(input.SuccessExitCodes))})
+		for _, c := range input.SuccessExitCodes {
+			writeField([]byte(strconv.Itoa(c)))
 		}
 	}
 
+	// 11. Cache opt-out (only when set)
+	if input.NotCacheable {
+		writeField([]byte("not_cacheable"))
+	}
+
+	// 12. Stdin (only when set)
+	if input.Stdin != nil {
+		writeField([]byte("stdin"))
+		writeField([]byte(input.Stdin.Text))
+		writeField([]byte(input.Stdin.File))
+	}
+
+	// 13. Tools (only when present). The resolved path is not hashed: the
+	// same binary installed elsewhere produces the same results.
+	if len(input.Tools) > 0 {
+		writeField([]byte("tools"))
+		writeField([]byte(strconv.Itoa(len(input.Tools))))
+		for _, tool := range input.Tools {
+			writeField([]byte(tool.Name))
+			writeField([]byte(tool.Version))
+			writeField([]byte(strconv.Itoa(len(tool.VersionArgs))))
+			for _, arg := range tool.VersionArgs {
+				writeField([]byte(arg))
+			}
+			writeField([]byte(tool.SHA256))
+			writeField([]byte(tool.Digest))
+		}
+	}
+
+	// 14. Network mode (only when set)
+	if input.Network != "" {
+		writeField([]byte("network"))
+		writeField([]byte(input.Network))
+	}
+
 	// Compute final hash
 	sum := hasher.Sum(nil)
 	return TaskHash(hex.EncodeToString(sum))
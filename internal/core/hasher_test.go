@@ -1,6 +1,7 @@
 package core
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -394,3 +395,129 @@ func TestComputeHash_HashFormat(t *testing.T) {
 		}
 	}
 }
+
+// TestComputeHash_KindChangeInvalidatesHash verifies a non-default runner kind
+// affects the hash while the default kind does not.
+func TestComputeHash_KindChangeInvalidatesHash(t *testing.T) {
+	hasher := NewTaskHasher()
+	input := HashInput{
+		Inputs:     &InputSet{Inputs: []Input{}},
+		Command:    "build",
+		Env:        map[string]string{},
+		WorkingDir: "/work",
+	}
+	base := hasher.ComputeHash(input)
+
+	input.Kind = KindShell
+	if hasher.ComputeHash(input) != base {
+		t.Error("explicit shell kind changed hash")
+	}
+
+	input.Kind = "plugin"
+	if hasher.ComputeHash(input) == base {
+		t.Error("kind change did not invalidate hash")
+	}
+}
+
+// TestComputeHash_DigestOnlyInputMatchesContent verifies that an input known
+// only by its fingerprinted digest hashes like the same content read in full.
+func TestComputeHash_DigestOnlyInputMatchesContent(t *testing.T) {
+	hasher := NewTaskHasher()
+	content := []byte("content1")
+	withContent := HashInput{Inputs: &InputSet{Inputs: []Input{{Path: "/a/file1.txt", Content: content}}}, Command: "echo"}
+	withDigest := HashInput{Inputs: &InputSet{Inputs: []Input{{Path: "/a/file1.txt", Digest: contentDigest(content)}}}, Command: "echo"}
+
+	if a, b := hasher.ComputeHash(withContent), hasher.ComputeHash(withDigest); a != b {
+		t.Errorf("digest-only input hashed differently: %s != %s", a, b)
+	}
+}
+
+// TestComputeHash_NormalizeRulesAffectHash verifies normalize rules are part
+// of task identity, in order, without changing hashes of tasks that have none.
+func TestComputeHash_NormalizeRulesAffectHash(t *testing.T) {
+	hasher := NewTaskHasher()
+	base := HashInput{Command: "make", WorkingDir: "/work"}
+	a := NormalizeRule{Pattern: "a+", Replacement: "A"}
+	b := NormalizeRule{Pattern: "b+", Replacement: "B"}
+
+	with := base
+	with.Normalize = []NormalizeRule{a, b}
+	swapped := base
+	swapped.Normalize = []NormalizeRule{b, a}
+	empty := base
+	empty.Normalize = []NormalizeRule{}
+
+	if hasher.ComputeHash(with) == hasher.ComputeHash(base) {
+		t.Error("normalize rules must change the hash")
+	}
+	if hasher.ComputeHash(with) == hasher.ComputeHash(swapped) {
+		t.Error("rule order must change the hash")
+	}
+	if hasher.ComputeHash(empty) != hasher.ComputeHash(base) {
+		t.Error("an empty rule list must not change the hash")
+	}
+}
+
+// TestComputeHash_OutputLimitAffectsHash verifies a task's output limit is
+// part of its identity only when set.
+func TestComputeHash_OutputLimitAffectsHash(t *testing.T) {
+	hasher := NewTaskHasher()
+	base := HashInput{Command: "make", WorkingDir: "/work"}
+	limited := base
+	limited.MaxOutputBytes = 1024
+	other := base
+	other.MaxOutputBytes = 2048
+
+	if hasher.ComputeHash(limited) == hasher.ComputeHash(base) {
+		t.Error("an output limit must change the hash")
+	}
+	if hasher.ComputeHash(limited) == hasher.ComputeHash(other) {
+		t.Error("different output limits must produce different hashes")
+	}
+}
+
+func TestComputeHash_SuccessExitCodesAffectHash(t *testing.T) {
+	hasher := NewTaskHasher()
+	base := HashInput{Command: "grep -q x file", WorkingDir: "/work"}
+	accepting := base
+	accepting.SuccessExitCodes = []int{1}
+
+	if hasher.ComputeHash(accepting) == hasher.ComputeHash(base) {
+		t.Error("accepted exit codes must change the hash")
+	}
+	if hasher.ComputeHash(HashInput{Command: "grep -q x file", WorkingDir: "/work", SuccessExitCodes: []int{}}) != hasher.ComputeHash(base) {
+		t.Error("an empty list must hash like no list")
+	}
+}
+
+func TestComputeHash_CacheOptOutAffectsHash(t *testing.T) {
+	hasher := NewTaskHasher()
+	base := HashInput{Command: "./deploy.sh", WorkingDir: "/work"}
+	optedOut := base
+	optedOut.NotCacheable = true
+
+	if hasher.ComputeHash(optedOut) == hasher.ComputeHash(base) {
+		t.Error("opting out of the cache must change the hash")
+	}
+}
+
+// BenchmarkTaskHasher_ComputeHash measures hashing a task with 1000 small
+// inputs, the per-task overhead paid on every cache probe.
+func BenchmarkTaskHasher_ComputeHash(b *testing.B) {
+	inputs := make([]Input, 1000)
+	for i := range inputs {
+		inputs[i] = Input{Path: fmt.Sprintf("/src/file%04d.go", i), Content: []byte(fmt.Sprintf("package src // %d", i))}
+	}
+	input := HashInput{
+		Inputs:     &InputSet{Inputs: inputs},
+		Command:    "go build ./...",
+		Env:        map[string]string{"GOOS": "linux", "GOARCH": "amd64"},
+		Outputs:    []string{"bin/app"},
+		WorkingDir: "/src",
+	}
+	hasher := NewTaskHasher()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hasher.ComputeHash(input)
+	}
+}
@@ -1,6 +1,11 @@
 // Package core defines the domain models for deterministic task execution.
 package core
 
+import (
+	"os"
+	"path/filepath"
+)
+
 // Input represents a resolved file whose content contributes to task identity.
 //
 // From data-dictionary.md:
@@ -17,7 +22,42 @@ type Input struct {
 
 	// Content is the raw file content.
 	// Used for computing task identity; file metadata is excluded.
+	// It is nil when Digest came from the fingerprint cache and the file was
+	// not read; use ReadContent.
 	Content []byte
+
+	// Digest is the hex SHA-256 of the content. When empty it is computed
+	// from Content.
+	Digest string
+
+	// Remote is set for a checksum-pinned remote input; Digest is then its
+	// pinned digest and Path where it is placed for the task.
+	Remote *RemoteInput
+
+	// Source, when set, is the file holding the content, such as a remote
+	// input's entry in the download cache.
+	Source string
+}
+
+// ContentDigest returns the hex SHA-256 of the input's content, which is
+// what the task hash covers.
+func (in Input) ContentDigest() string {
+	if in.Digest != "" {
+		return in.Digest
+	}
+	return contentDigest(in.Content)
+}
+
+// ReadContent returns the input's content, reading the file if the resolver
+// skipped it because its fingerprint was unchanged or it is remote.
+func (in Input) ReadContent() ([]byte, error) {
+	if in.Content != nil || in.Digest == "" {
+		return in.Content, nil
+	}
+	if in.Source != "" {
+		return os.ReadFile(in.Source)
+	}
+	return os.ReadFile(filepath.FromSlash(in.Path))
 }
 
 // InputSet represents the complete set of resolved inputs for a task.
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// InputMatches reports whether path, absolute or relative to baseDir, is
+// covered by any of the input patterns: a glob or literal pattern matches it
+// as Resolve would expand it, and a git tracked-files pattern matches
+// anything under its path. Remote inputs never match a local path.
+//
+// Unlike Resolve, nothing is read from disk, so a deleted file still matches
+// the patterns that used to resolve it.
+func InputMatches(baseDir string, patterns []string, path string) (bool, error) {
+	full := path
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(baseDir, full)
+	}
+	full = filepath.Clean(full)
+
+	for _, pattern := range patterns {
+		switch {
+		case IsRemoteInput(pattern):
+			continue
+		case IsGitInput(pattern):
+			dir := filepath.Join(baseDir, filepath.FromSlash(strings.TrimPrefix(pattern, GitInputPrefix)))
+			if full == dir || strings.HasPrefix(full, dir+string(filepath.Separator)) {
+				return true, nil
+			}
+		default:
+			fullPattern := pattern
+			if !filepath.IsAbs(pattern) {
+				fullPattern = filepath.Join(baseDir, pattern)
+			}
+			if !containsGlobChar(pattern) {
+				if filepath.Clean(fullPattern) == full {
+					return true, nil
+				}
+				continue
+			}
+			ok, err := filepath.Match(fullPattern, full)
+			if err != nil {
+				return false, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+			}
+			if ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
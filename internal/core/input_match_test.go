@@ -0,0 +1,35 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestInputMatches(t *testing.T) {
+	base := filepath.FromSlash("/repo")
+	patterns := []string{"src/*.go", "README.md", "git:docs", "https://example.com/tool.tar.gz#sha256=" + "00"}
+	for path, want := range map[string]bool{
+		"src/main.go":         true,
+		"src/sub/main.go":     false,
+		"README.md":           true,
+		"./README.md":         true,
+		"docs/guide/intro.md": true,
+		"docs":                true,
+		"docsite/index.md":    false,
+		"tool.tar.gz":         false,
+		"/repo/src/util.go":   true,
+		"/elsewhere/src/a.go": false,
+	} {
+		got, err := InputMatches(base, patterns, filepath.FromSlash(path))
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if got != want {
+			t.Errorf("InputMatches(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	if _, err := InputMatches(base, []string{"src/[.go"}, "src/a.go"); err == nil {
+		t.Fatalf("expected an error for a malformed pattern")
+	}
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// materializeScratch creates a fresh scratch directory under root (the system
+// temp directory if empty) holding only the resolved inputs, at their paths
+// relative to workingDir, with their executable bit preserved.
+//
+// Inputs outside workingDir are not copied; a task referring to them by
+// absolute path still reads the host file, as it does without isolation.
+func materializeScratch(root, workingDir string, inputs *InputSet) (string, error) {
+	scratch, err := os.MkdirTemp(root, "scriptweaver-task-*")
+	if err != nil {
+		return "", fmt.Errorf("creating scratch directory: %w", err)
+	}
+	if inputs == nil {
+		return scratch, nil
+	}
+	for _, in := range inputs.Inputs {
+		src := filepath.FromSlash(in.Path)
+		if !filepath.IsAbs(src) {
+			src = filepath.Join(workingDir, src)
+		}
+		rel, err := filepath.Rel(workingDir, src)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		perm := ArtifactModeRegular
+		if info, err := os.Stat(src); err == nil {
+			perm = normalizeArtifactMode(info.Mode())
+		}
+		dst := filepath.Join(scratch, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			_ = os.RemoveAll(scratch)
+			return "", fmt.Errorf("materializing input %q: %w", in.Path, err)
+		}
+		content, err := in.ReadContent()
+		if err != nil {
+			_ = os.RemoveAll(scratch)
+			return "", fmt.Errorf("materializing input %q: %w", in.Path, err)
+		}
+		if err := os.WriteFile(dst, content, perm); err != nil {
+			_ = os.RemoveAll(scratch)
+			return "", fmt.Errorf("materializing input %q: %w", in.Path, err)
+		}
+		if err := os.Chmod(dst, perm); err != nil {
+			_ = os.RemoveAll(scratch)
+			return "", fmt.Errorf("materializing input %q: %w", in.Path, err)
+		}
+	}
+	return scratch, nil
+}
+
+// validateIsolatedOutputs rejects absolute declared outputs, which would be
+// harvested from the host rather than from the scratch directory.
+func validateIsolatedOutputs(outputs []string) error {
+	for _, o := range outputs {
+		if filepath.IsAbs(o) {
+			return fmt.Errorf("isolated execution requires relative outputs, got %q", o)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunner_IsolatedExecution(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workDir, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(workDir, "src", "in.txt"), "declared")
+	writeTestFile(t, filepath.Join(workDir, "undeclared.txt"), "hidden")
+	writeTestFile(t, filepath.Join(workDir, "gen.sh"), "#!/bin/sh\ncat src/in.txt > out/result.txt\n")
+	if err := os.Chmod(filepath.Join(workDir, "gen.sh"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := NewRunner(workDir, NewMemoryCache())
+	runner.Isolated = true
+	runner.ScratchRoot = t.TempDir()
+	task := &Task{
+		Name:    "gen",
+		Inputs:  []string{"src/in.txt", "gen.sh"},
+		Run:     "mkdir -p out && ./gen.sh && { test -e undeclared.txt && echo leaked > out/leak.txt; true; } && echo scratch > stray.txt",
+		Outputs: []string{"out"},
+	}
+
+	res, err := runner.Run(context.Background(), task)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.ExitCode != 0 {
+		t.Fatalf("expected success, got exit %d: %s", res.ExitCode, res.Stderr)
+	}
+	if b, err := os.ReadFile(filepath.Join(workDir, "out", "result.txt")); err != nil || string(b) != "declared" {
+		t.Fatalf("expected declared output copied back, got %q (err=%v)", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "out", "leak.txt")); !os.IsNotExist(err) {
+		t.Fatalf("task must not see undeclared workdir files")
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "stray.txt")); !os.IsNotExist(err) {
+		t.Fatalf("undeclared writes must stay in the scratch directory")
+	}
+	if left, _ := os.ReadDir(runner.ScratchRoot); len(left) != 0 {
+		t.Fatalf("expected scratch directory removed, found %d entries", len(left))
+	}
+
+	// Absolute outputs cannot be harvested from the scratch directory.
+	abs := &Task{Name: "abs", Run: "true", Outputs: []string{filepath.Join(workDir, "x")}}
+	if _, err := runner.Run(context.Background(), abs); err == nil || !strings.Contains(err.Error(), "relative outputs") {
+		t.Fatalf("expected relative-outputs error, got %v", err)
+	}
+}
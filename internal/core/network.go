@@ -0,0 +1,47 @@
+package core
+
+import (
+	"fmt"
+)
+
+// Task network modes (see Task.Network).
+const (
+	// NetworkNone runs the task without network access.
+	NetworkNone = "none"
+
+	// NetworkAllowed gives the task the network, for fetch-style work.
+	NetworkAllowed = "allowed"
+)
+
+// ValidateNetwork checks that Network is empty or a known mode. Services
+// cannot run without network: their readiness check and dependents reach
+// them over it.
+func (t *Task) ValidateNetwork() error {
+	switch t.Network {
+	case "", NetworkAllowed:
+		return nil
+	case NetworkNone:
+		if t.NormalizedKind() == KindService {
+			return fmt.Errorf("network %q is not supported for services", NetworkNone)
+		}
+		return nil
+	default:
+		return fmt.Errorf("network must be %q or %q, got %q", NetworkNone, NetworkAllowed, t.Network)
+	}
+}
+
+// containerNetwork is the engine --network value for task: "none" unless the
+// task allows network, which uses the configured network or the engine's
+// default bridge.
+func (c *ContainerConfig) containerNetwork(task *Task) string {
+	network := c.Network
+	switch {
+	case task.Network == NetworkNone:
+		return NetworkNone
+	case task.Network == NetworkAllowed && (network == "" || network == "none"):
+		return "bridge"
+	case network == "":
+		return "none"
+	}
+	return network
+}
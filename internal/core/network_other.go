@@ -0,0 +1,14 @@
+//go:build !linux
+
+package core
+
+import (
+	"syscall"
+)
+
+// isolateNetwork is a no-op: network namespaces are Linux-only, so
+// NetworkNone is not enforced on other platforms.
+func isolateNetwork(attr *syscall.SysProcAttr) {}
+
+// networkIsolated reports whether isolateNetwork enforces NetworkNone.
+const networkIsolated = false
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTask_ValidateNetwork(t *testing.T) {
+	for _, task := range []Task{{}, {Network: NetworkNone}, {Network: NetworkAllowed}, {Kind: KindService, Network: NetworkAllowed}} {
+		if err := task.ValidateNetwork(); err != nil {
+			t.Fatalf("%+v: %v", task, err)
+		}
+	}
+	for _, task := range []Task{{Network: "host"}, {Kind: KindService, Network: NetworkNone}} {
+		if err := task.ValidateNetwork(); err == nil {
+			t.Fatalf("%+v: expected an error", task)
+		}
+	}
+}
+
+func TestComputeHash_NetworkModeChangesHash(t *testing.T) {
+	h := NewTaskHasher()
+	base := h.ComputeHash(HashInput{Command: "true"})
+	none := h.ComputeHash(HashInput{Command: "true", Network: NetworkNone})
+	allowed := h.ComputeHash(HashInput{Command: "true", Network: NetworkAllowed})
+	if base == none || base == allowed || none == allowed {
+		t.Fatalf("network modes must hash differently: %s %s %s", base, none, allowed)
+	}
+}
+
+func TestContainerConfig_NetworkFollowsTask(t *testing.T) {
+	for _, tc := range []struct{ configured, task, want string }{
+		{"", "", "none"},
+		{"", NetworkAllowed, "bridge"},
+		{"host", "", "host"},
+		{"host", NetworkNone, "none"},
+		{"host", NetworkAllowed, "host"},
+	} {
+		c := &ContainerConfig{Network: tc.configured}
+		if got := c.containerNetwork(&Task{Network: tc.task}); got != tc.want {
+			t.Fatalf("configured %q, task %q: got %q, want %q", tc.configured, tc.task, got, tc.want)
+		}
+	}
+}
+
+func TestExecutor_NetworkNoneHasOnlyLoopback(t *testing.T) {
+	if !networkIsolated {
+		t.Skip("network namespaces are Linux-only")
+	}
+	ex := NewExecutor(t.TempDir())
+	// /proc/net/dev lists the interfaces of the process's network namespace.
+	task := &Task{Name: "offline", Run: "tail -n +3 /proc/net/dev | cut -d: -f1 | tr -d ' '", Network: NetworkNone}
+	res, err := ex.Execute(context.Background(), task, "h")
+	if err != nil {
+		t.Skipf("network namespaces unavailable: %v", err)
+	}
+	if got := strings.TrimSpace(string(res.Stdout)); got != "lo" {
+		t.Fatalf("interfaces = %q, want only lo", got)
+	}
+}
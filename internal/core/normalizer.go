@@ -3,6 +3,7 @@ package core
 
 import (
 	"bytes"
+	"fmt"
 	"regexp"
 )
 
@@ -120,3 +121,55 @@ func (n *StreamNormalizer) Normalize(content []byte) []byte {
 
 	return result
 }
+
+// NormalizeRule replaces every match of Pattern (RE2 syntax) in an output
+// with the literal Replacement, e.g. a build ID with "<BUILD_ID>".
+type NormalizeRule struct {
+	Pattern     string `json:"pattern" yaml:"pattern"`
+	Replacement string `json:"replacement" yaml:"replacement"`
+}
+
+// RuleNormalizer applies Inner (if any) and then its rules in declaration
+// order. RE2 matching has no backtracking or locale dependence, so the
+// result depends only on the rules and the content.
+type RuleNormalizer struct {
+	Inner    OutputNormalizer
+	patterns []*normPattern
+}
+
+// NewRuleNormalizer compiles rules into a normalizer applied after inner.
+func NewRuleNormalizer(rules []NormalizeRule, inner OutputNormalizer) (*RuleNormalizer, error) {
+	n := &RuleNormalizer{Inner: inner, patterns: make([]*normPattern, 0, len(rules))}
+	for i, rule := range rules {
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("normalize rule %d: pattern is required", i)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("normalize rule %d: %w", i, err)
+		}
+		if re.MatchString("") {
+			return nil, fmt.Errorf("normalize rule %d: pattern %q matches the empty string", i, rule.Pattern)
+		}
+		n.patterns = append(n.patterns, &normPattern{regex: re, replacement: []byte(rule.Replacement)})
+	}
+	return n, nil
+}
+
+// Normalize applies Inner, then each rule's literal replacement in order.
+func (n *RuleNormalizer) Normalize(content []byte) []byte {
+	result := content
+	if n.Inner != nil {
+		result = n.Inner.Normalize(result)
+	}
+	for _, p := range n.patterns {
+		result = p.regex.ReplaceAllLiteral(result, p.replacement)
+	}
+	return result
+}
+
+// ValidateNormalizeRules checks that the task's normalize rules compile.
+func (t *Task) ValidateNormalizeRules() error {
+	_, err := NewRuleNormalizer(t.Normalize, nil)
+	return err
+}
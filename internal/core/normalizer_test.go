@@ -226,3 +226,31 @@ func TestNormalization_IdenticalAcrossRuns(t *testing.T) {
 		t.Errorf("normalized outputs differ:\nrun1: %s\nrun2: %s", normalized1, normalized2)
 	}
 }
+
+// TestRuleNormalizer_AppliesRulesInOrderAfterInner verifies configured rules
+// run after the inner normalizer, in declaration order, as literal text.
+func TestRuleNormalizer_AppliesRulesInOrderAfterInner(t *testing.T) {
+	n, err := NewRuleNormalizer([]NormalizeRule{
+		{Pattern: `build-[0-9a-f]{8}`, Replacement: "<BUILD_ID>"},
+		{Pattern: `<BUILD_ID>`, Replacement: "$1<ID>"},
+	}, NewDefaultNormalizer())
+	if err != nil {
+		t.Fatalf("NewRuleNormalizer: %v", err)
+	}
+	got := string(n.Normalize([]byte("build-deadbeef at 2024-12-13T10:30:45Z")))
+	if want := "$1<ID> at <TIMESTAMP>"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRuleNormalizer_RejectsInvalidRules(t *testing.T) {
+	for _, rule := range []NormalizeRule{
+		{Pattern: "", Replacement: "x"},
+		{Pattern: "(", Replacement: "x"},
+		{Pattern: "a*", Replacement: "x"},
+	} {
+		if _, err := NewRuleNormalizer([]NormalizeRule{rule}, nil); err == nil {
+			t.Errorf("expected pattern %q to be rejected", rule.Pattern)
+		}
+	}
+}
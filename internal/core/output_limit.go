@@ -0,0 +1,64 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// limitedBuffer keeps the first limit bytes written to it and counts the
+// rest, so a task that floods a stream cannot exhaust memory. Writes never
+// fail: the task sees the same pipe behaviour whether or not it is truncated.
+type limitedBuffer struct {
+	buf     bytes.Buffer
+	limit   int64
+	dropped int64
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.limit <= 0 {
+		return b.buf.Write(p)
+	}
+	room := b.limit - int64(b.buf.Len())
+	if room < 0 {
+		room = 0
+	}
+	keep := int64(len(p))
+	if keep > room {
+		keep = room
+	}
+	b.buf.Write(p[:keep])
+	b.dropped += int64(len(p)) - keep
+	return len(p), nil
+}
+
+// Truncated reports whether any output was discarded.
+func (b *limitedBuffer) Truncated() bool { return b.dropped > 0 }
+
+// Bytes returns the kept output followed, when truncated, by a marker
+// naming the stream and the number of bytes omitted. The marker depends
+// only on the output, so truncated results stay deterministic.
+func (b *limitedBuffer) Bytes(stream string) []byte {
+	if !b.Truncated() {
+		return b.buf.Bytes()
+	}
+	return append(b.buf.Bytes(), fmt.Sprintf("\n[scriptweaver: %s truncated: %d bytes omitted]\n", stream, b.dropped)...)
+}
+
+// outputLimit is the per-stream byte limit for task: its own MaxOutputBytes
+// when set, otherwise the executor-wide OutputLimit (zero is unlimited).
+func (e *Executor) outputLimit(task *Task) int64 {
+	if task.MaxOutputBytes > 0 {
+		return task.MaxOutputBytes
+	}
+	return e.OutputLimit
+}
+
+// ignoreWriteErrors reports every write to w as complete, so a failing
+// display writer cannot cut a task's captured output short.
+type ignoreWriteErrors struct{ w io.Writer }
+
+func (i ignoreWriteErrors) Write(p []byte) (int, error) {
+	_, _ = i.w.Write(p)
+	return len(p), nil
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestExecutor_OutputLimitTruncatesDeterministically(t *testing.T) {
+	ex := NewExecutor(t.TempDir())
+	ex.OutputLimit = 10
+	task := &Task{Name: "flood", Run: "yes x | head -c 100000; printf 'short' >&2"}
+
+	first, err := ex.Execute(context.Background(), task, "h")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	want := strings.Repeat("x\n", 5) + "\n[scriptweaver: stdout truncated: 99990 bytes omitted]\n"
+	if string(first.Stdout) != want {
+		t.Fatalf("stdout = %q, want %q", first.Stdout, want)
+	}
+	if string(first.Stderr) != "short" || !first.OutputTruncated {
+		t.Fatalf("stderr = %q truncated=%v", first.Stderr, first.OutputTruncated)
+	}
+
+	second, err := ex.Execute(context.Background(), task, "h")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !bytes.Equal(first.Stdout, second.Stdout) {
+		t.Fatalf("truncated output differs across runs")
+	}
+
+	// The task's own limit wins over the executor's.
+	task.MaxOutputBytes = 4
+	res, err := ex.Execute(context.Background(), task, "h")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !bytes.HasPrefix(res.Stdout, []byte("x\nx\n\n[scriptweaver: stdout truncated: 99996 bytes omitted]")) {
+		t.Fatalf("stdout = %q", res.Stdout)
+	}
+}
+
+func TestExecutor_NoOutputLimitKeepsEverything(t *testing.T) {
+	ex := NewExecutor(t.TempDir())
+	res, err := ex.Execute(context.Background(), &Task{Name: "t", Run: "yes x | head -c 100000"}, "h")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if len(res.Stdout) != 100000 || res.OutputTruncated {
+		t.Fatalf("expected all output, got %d bytes truncated=%v", len(res.Stdout), res.OutputTruncated)
+	}
+}
+
+type failingWriter struct{ bytes.Buffer }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.Buffer.Write(p)
+	return 0, io.ErrClosedPipe
+}
+
+func TestExecutor_LiveOutputCopiesWithoutAffectingCapture(t *testing.T) {
+	ex := NewExecutor(t.TempDir())
+	live := &failingWriter{}
+	ex.LiveOutput = func(task string) io.Writer {
+		if task != "talk" {
+			t.Errorf("unexpected task %q", task)
+		}
+		return live
+	}
+	res, err := ex.Execute(context.Background(), &Task{Name: "talk", Run: "echo out; echo err >&2"}, "h")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if string(res.Stdout) != "out\n" || string(res.Stderr) != "err\n" {
+		t.Fatalf("captured output changed: stdout=%q stderr=%q", res.Stdout, res.Stderr)
+	}
+	if got := live.String(); !strings.Contains(got, "out\n") || !strings.Contains(got, "err\n") {
+		t.Fatalf("live output = %q", got)
+	}
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	pathpkg "path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrStaleCacheEntry indicates a cache entry whose artifact set no longer
+// matches the task's declared outputs. Such an entry must not be restored;
+// the task is executed instead.
+var ErrStaleCacheEntry = errors.New("stale cache entry")
+
+// ManifestOutputs returns the canonical expected-outputs manifest for a task's
+// declared outputs: cleaned, slash-separated, sorted and de-duplicated.
+func ManifestOutputs(outputs []string) []string {
+	if len(outputs) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(outputs))
+	for _, o := range outputs {
+		out = append(out, filepath.ToSlash(filepath.Clean(o)))
+	}
+	sort.Strings(out)
+	return deduplicateSorted(out)
+}
+
+// VerifyDeclaredOutputs checks that entry was produced for the given declared
+// outputs, with artifact paths relative to baseDir.
+//
+// Two checks are applied:
+//   - If the entry recorded its DeclaredOutputs, they must equal the current
+//     declarations (outputs added or removed make the entry stale).
+//   - Every artifact must lie at or under a declared output (or a directory a
+//     declared glob matches); an artifact no output covers belongs to an
+//     outdated declaration set.
+//
+// The returned error wraps ErrStaleCacheEntry and names the first difference.
+func VerifyDeclaredOutputs(entry *CacheEntry, outputs []string, baseDir string) error {
+	if entry == nil {
+		return fmt.Errorf("cache entry is nil")
+	}
+	declared := ManifestOutputs(outputs)
+
+	if len(entry.DeclaredOutputs) > 0 {
+		recorded := ManifestOutputs(entry.DeclaredOutputs)
+		if added, removed := diffSorted(recorded, declared); len(added) > 0 || len(removed) > 0 {
+			return fmt.Errorf("%w: declared outputs changed (added %v, removed %v)", ErrStaleCacheEntry, added, removed)
+		}
+	}
+
+	covering := coveringOutputs(declared, baseDir)
+	for _, a := range entry.Artifacts {
+		if !coveredBy(a.Path, covering) {
+			return fmt.Errorf("%w: artifact %q is not covered by any declared output", ErrStaleCacheEntry, a.Path)
+		}
+	}
+	return nil
+}
+
+// OutputsCover reports whether the artifact path p, slash-separated and
+// relative to baseDir, lies at or under one of outputs or under a directory
+// a declared glob matches.
+func OutputsCover(outputs []string, baseDir, p string) bool {
+	return coveredBy(p, coveringOutputs(ManifestOutputs(outputs), baseDir))
+}
+
+// coveringOutputs returns the manifest outputs with absolute ones made
+// relative to baseDir, to compare against artifact paths.
+func coveringOutputs(declared []string, baseDir string) []string {
+	covering := make([]string, 0, len(declared))
+	for _, o := range declared {
+		if filepath.IsAbs(filepath.FromSlash(o)) && baseDir != "" {
+			if rel, err := filepath.Rel(baseDir, filepath.FromSlash(o)); err == nil {
+				o = filepath.ToSlash(rel)
+			}
+		}
+		covering = append(covering, o)
+	}
+	return covering
+}
+
+func coveredBy(path string, outputs []string) bool {
+	for _, o := range outputs {
+		if o == "." || path == o || strings.HasPrefix(path, o+"/") {
+			return true
+		}
+		if containsGlobChar(o) && globCovers(o, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// globCovers reports whether pattern matches p or one of its parent
+// directories (a matched directory covers the files under it).
+func globCovers(pattern, p string) bool {
+	for {
+		if ok, _ := pathpkg.Match(pattern, p); ok {
+			return true
+		}
+		parent := pathpkg.Dir(p)
+		if parent == p || parent == "." || parent == "/" {
+			return false
+		}
+		p = parent
+	}
+}
+
+// diffSorted returns the elements only in b (added) and only in a (removed).
+func diffSorted(a, b []string) (added, removed []string) {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case a[i] < b[j]:
+			removed = append(removed, a[i])
+			i++
+		default:
+			added = append(added, b[j])
+			j++
+		}
+	}
+	removed = append(removed, a[i:]...)
+	added = append(added, b[j:]...)
+	return added, removed
+}
+
+// OutputsOverlap reports whether two declared outputs can name the same
+// artifact: they are equal, one lies under the other, or a glob component
+// of one matches the literal component of the other. Two glob components
+// only overlap when their text is equal, so "dist/*.js" and "dist/*.css"
+// are disjoint even though no file is checked.
+func OutputsOverlap(a, b string) bool {
+	a, b = filepath.ToSlash(filepath.Clean(a)), filepath.ToSlash(filepath.Clean(b))
+	if a == "." || b == "." {
+		return true
+	}
+	ac, bc := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(ac) && i < len(bc); i++ {
+		if !componentsOverlap(ac[i], bc[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func componentsOverlap(a, b string) bool {
+	if a == b {
+		return true
+	}
+	switch ga, gb := containsGlobChar(a), containsGlobChar(b); {
+	case ga && gb:
+		return false
+	case ga:
+		ok, _ := pathpkg.Match(a, b)
+		return ok
+	case gb:
+		ok, _ := pathpkg.Match(b, a)
+		return ok
+	}
+	return false
+}
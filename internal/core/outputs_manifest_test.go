@@ -0,0 +1,128 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVerifyDeclaredOutputs(t *testing.T) {
+	entry := &CacheEntry{
+		Hash:            "h",
+		DeclaredOutputs: []string{"dist", "out.txt"},
+		Artifacts: []CachedArtifact{
+			{Path: "dist/a.js"},
+			{Path: "out.txt"},
+		},
+	}
+
+	if err := VerifyDeclaredOutputs(entry, []string{"out.txt", "./dist/"}, ""); err != nil {
+		t.Fatalf("expected match, got %v", err)
+	}
+	if err := VerifyDeclaredOutputs(entry, []string{"out.txt"}, ""); !errors.Is(err, ErrStaleCacheEntry) {
+		t.Fatalf("expected stale on removed output, got %v", err)
+	}
+	if err := VerifyDeclaredOutputs(entry, []string{"dist", "out.txt", "extra.txt"}, ""); !errors.Is(err, ErrStaleCacheEntry) {
+		t.Fatalf("expected stale on added output, got %v", err)
+	}
+
+	// Globs cover matching files and files under matching directories.
+	globbed := &CacheEntry{Hash: "h", Artifacts: []CachedArtifact{{Path: "dist/app-1.2.tar.gz"}, {Path: "dist/docs-1.2/index.html"}}}
+	if err := VerifyDeclaredOutputs(globbed, []string{"dist/*.tar.gz", "dist/docs-*"}, ""); err != nil {
+		t.Fatalf("expected glob coverage, got %v", err)
+	}
+	if err := VerifyDeclaredOutputs(globbed, []string{"dist/*.zip", "dist/docs-*"}, ""); !errors.Is(err, ErrStaleCacheEntry) {
+		t.Fatalf("expected uncovered artifact to be stale, got %v", err)
+	}
+
+	// Entries without a recorded manifest fall back to artifact coverage.
+	legacy := &CacheEntry{Hash: "h", Artifacts: []CachedArtifact{{Path: "dist/a.js"}}}
+	if err := VerifyDeclaredOutputs(legacy, []string{"dist"}, ""); err != nil {
+		t.Fatalf("expected legacy entry to match, got %v", err)
+	}
+	if err := VerifyDeclaredOutputs(legacy, []string{"distribution"}, ""); !errors.Is(err, ErrStaleCacheEntry) {
+		t.Fatalf("expected uncovered artifact to be stale, got %v", err)
+	}
+}
+
+func TestRunner_StaleOutputsManifestForcesExecution(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache := NewMemoryCache()
+	runner := NewRunner(tmpDir, cache)
+
+	task := &Task{
+		Name:    "producer",
+		Run:     "echo fresh > out.txt",
+		Outputs: []string{"out.txt"},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first, err := runner.Run(ctx, task)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	entry, err := cache.Get(first.Hash)
+	if err != nil || entry == nil {
+		t.Fatalf("expected cache entry, got %v (err=%v)", entry, err)
+	}
+	if len(entry.DeclaredOutputs) != 1 || entry.DeclaredOutputs[0] != "out.txt" {
+		t.Fatalf("expected declared outputs manifest, got %v", entry.DeclaredOutputs)
+	}
+
+	// Simulate an entry produced under an older declaration set.
+	entry.DeclaredOutputs = []string{"old.txt", "out.txt"}
+	entry.Artifacts = append(entry.Artifacts, CachedArtifact{Path: "old.txt", Content: []byte("stale\n")})
+	if err := cache.Put(entry); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := os.Remove(filepath.Join(tmpDir, "out.txt")); err != nil {
+		t.Fatalf("remove output: %v", err)
+	}
+
+	second, err := runner.Run(ctx, task)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if second.FromCache || !second.Invalidated {
+		t.Fatalf("expected re-execution with Invalidated, got FromCache=%v Invalidated=%v", second.FromCache, second.Invalidated)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "old.txt")); !os.IsNotExist(err) {
+		t.Fatalf("stale artifact must not be restored, stat err=%v", err)
+	}
+
+	third, err := runner.Run(ctx, task)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !third.FromCache || third.Invalidated {
+		t.Fatalf("expected refreshed entry to replay, got FromCache=%v Invalidated=%v", third.FromCache, third.Invalidated)
+	}
+}
+
+func TestOutputsOverlap(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{"dist/a.txt", "dist/a.txt", true},
+		{"dist", "dist/a.txt", true},
+		{"./dist/", "dist/sub/a.txt", true},
+		{".", "anything", true},
+		{"dist/*.txt", "dist/a.txt", true},
+		{"dist/a.txt", "dist/b.txt", false},
+		{"dist/*.js", "dist/*.css", false},
+		{"dist/*.txt", "dist/a.bin", false},
+		{"build", "dist", false},
+	} {
+		if got := OutputsOverlap(tc.a, tc.b); got != tc.want {
+			t.Errorf("OutputsOverlap(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+		if got := OutputsOverlap(tc.b, tc.a); got != tc.want {
+			t.Errorf("OutputsOverlap(%q, %q) = %v, want %v", tc.b, tc.a, got, tc.want)
+		}
+	}
+}
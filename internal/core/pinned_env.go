@@ -0,0 +1,86 @@
+package core
+
+import (
+	"strconv"
+)
+
+// Variables the executor pins in every task's environment unless the task
+// declares them in Env (see withPinnedEnv).
+const (
+	// SeedEnvVar carries the task's random seed (see TaskSeed).
+	SeedEnvVar = "SEED"
+	// SourceDateEpochEnvVar carries the timestamp reproducible-build tools
+	// embed instead of the current time (see TaskSourceDateEpoch).
+	SourceDateEpochEnvVar = "SOURCE_DATE_EPOCH"
+	// PinnedTZ is the value of TZ, so times render the same on every host.
+	PinnedTZ = "UTC"
+	// PinnedLocale is the value of LC_ALL, so sorting, number formatting and
+	// messages do not depend on the host's locale.
+	PinnedLocale = "C"
+)
+
+// minSourceDateEpoch is 1980-01-01T00:00:00Z, the earliest time a ZIP
+// archive can record; derived epochs never precede it.
+const minSourceDateEpoch = 315532800
+
+// TaskSeed derives the deterministic random seed for a task from its hash:
+// the first 16 hex digits of the hash read as an unsigned 64-bit integer,
+// in decimal. The seed changes exactly when the hash does. ok is false when
+// the hash does not start with 16 hex digits.
+func TaskSeed(hash TaskHash) (seed string, ok bool) {
+	n, ok := hashDigits(hash, 0, 16)
+	if !ok {
+		return "", false
+	}
+	return strconv.FormatUint(n, 10), true
+}
+
+// TaskSourceDateEpoch derives a task's SOURCE_DATE_EPOCH from its hash:
+// hex digits 17 to 24 of the hash, modulo 2^30, added to 315532800
+// (1980-01-01T00:00:00Z). The result is a fixed time between 1980 and 2014,
+// in decimal seconds. ok is false when the hash is shorter than 24 hex digits.
+func TaskSourceDateEpoch(hash TaskHash) (epoch string, ok bool) {
+	n, ok := hashDigits(hash, 16, 24)
+	if !ok {
+		return "", false
+	}
+	return strconv.FormatUint(minSourceDateEpoch+n%(1<<30), 10), true
+}
+
+// hashDigits parses hash[from:to] as hex.
+func hashDigits(hash TaskHash, from, to int) (uint64, bool) {
+	if len(hash) < to {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(string(hash[from:to]), 16, 64)
+	return n, err == nil
+}
+
+// withPinnedEnv returns task with TZ, LC_ALL, SeedEnvVar and
+// SourceDateEpochEnvVar set, or task itself when it declares all of them.
+// A variable the task declares keeps its value, and the seed and epoch are
+// omitted when hash yields none.
+func withPinnedEnv(task *Task, hash TaskHash) *Task {
+	pinned := map[string]string{"TZ": PinnedTZ, "LC_ALL": PinnedLocale}
+	if seed, ok := TaskSeed(hash); ok {
+		pinned[SeedEnvVar] = seed
+	}
+	if epoch, ok := TaskSourceDateEpoch(hash); ok {
+		pinned[SourceDateEpochEnvVar] = epoch
+	}
+	for k := range task.Env {
+		delete(pinned, k)
+	}
+	if len(pinned) == 0 {
+		return task
+	}
+	t := *task
+	t.Env = make(map[string]string, len(task.Env)+len(pinned))
+	for k, v := range task.Env {
+		t.Env[k] = v
+	}
+	for k, v := range pinned {
+		t.Env[k] = v
+	}
+	return &t
+}
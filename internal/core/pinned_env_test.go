@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTaskSeed(t *testing.T) {
+	seed, ok := TaskSeed("00000000000000ffdeadbeef")
+	if !ok || seed != "255" {
+		t.Fatalf("TaskSeed = %q, %v; want 255, true", seed, ok)
+	}
+	seed, ok = TaskSeed("ffffffffffffffff")
+	if !ok || seed != "18446744073709551615" {
+		t.Fatalf("TaskSeed = %q, %v", seed, ok)
+	}
+	for _, h := range []TaskHash{"", "abc", "not-a-hex-hash-at-all"} {
+		if seed, ok := TaskSeed(h); ok {
+			t.Fatalf("TaskSeed(%q) = %q, want none", h, seed)
+		}
+	}
+}
+
+func TestTaskSourceDateEpoch(t *testing.T) {
+	epoch, ok := TaskSourceDateEpoch(TaskHash(strings.Repeat("f", 16) + "00000010"))
+	if !ok || epoch != "315532816" {
+		t.Fatalf("TaskSourceDateEpoch = %q, %v; want 315532816, true", epoch, ok)
+	}
+	epoch, ok = TaskSourceDateEpoch(TaskHash(strings.Repeat("f", 24)))
+	if !ok || epoch != "1389274623" {
+		t.Fatalf("TaskSourceDateEpoch = %q, %v; want 1389274623, true", epoch, ok)
+	}
+	if epoch, ok := TaskSourceDateEpoch(TaskHash(strings.Repeat("0", 20))); ok {
+		t.Fatalf("TaskSourceDateEpoch(short) = %q, want none", epoch)
+	}
+}
+
+func TestExecutor_PinsEnvFromHash(t *testing.T) {
+	ex := NewExecutor(t.TempDir())
+	task := &Task{Name: "sample", Run: `echo "seed=$SEED tz=$TZ lc=$LC_ALL sde=$SOURCE_DATE_EPOCH"`, Env: map[string]string{"A": "1"}}
+	hash := TaskHash(strings.Repeat("0", 14) + "2a" + "00000010" + strings.Repeat("f", 40))
+
+	res, err := ex.Execute(context.Background(), task, hash)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got, want := string(res.Stdout), "seed=42 tz=UTC lc=C sde=315532816\n"; got != want {
+		t.Fatalf("stdout = %q, want %q", got, want)
+	}
+	if _, ok := task.Env[SeedEnvVar]; ok {
+		t.Fatalf("Execute mutated the task's env: %v", task.Env)
+	}
+
+	task.Env[SeedEnvVar] = "7"
+	task.Env["TZ"] = "Europe/Madrid"
+	task.Env[SourceDateEpochEnvVar] = "0"
+	res, err = ex.Execute(context.Background(), task, hash)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got, want := string(res.Stdout), "seed=7 tz=Europe/Madrid lc=C sde=0\n"; got != want {
+		t.Fatalf("declared env overridden: stdout = %q, want %q", got, want)
+	}
+}
+
+func TestExecutor_StartServicePinsClockAndLocale(t *testing.T) {
+	dir := t.TempDir()
+	ex := NewExecutor(dir)
+	task := &Task{
+		Name:    "db",
+		Kind:    KindService,
+		Run:     `echo "tz=$TZ lc=$LC_ALL seed=$SEED sde=$SOURCE_DATE_EPOCH" > env.txt; sleep 30`,
+		Service: &ServiceConfig{Ready: "test -s env.txt", ReadyIntervalMillis: 10},
+	}
+	svc, res, err := ex.StartService(context.Background(), task)
+	if err != nil || svc == nil {
+		t.Fatalf("StartService: svc=%v res=%+v err=%v", svc, res, err)
+	}
+	defer svc.Stop()
+
+	b, err := os.ReadFile(filepath.Join(dir, "env.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "tz=UTC lc=C seed= sde=\n"; got != want {
+		t.Fatalf("service env = %q, want %q", got, want)
+	}
+}
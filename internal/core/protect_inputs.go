@@ -0,0 +1,129 @@
+package core
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ModifiedInputsExitCode is the exit code reported for a task that ran
+// successfully but modified or removed its own declared inputs under
+// ProtectInputs.
+const ModifiedInputsExitCode = 95
+
+// inputGuard clears the write permission bits of the input files of running
+// tasks. Files shared by concurrent tasks are reference counted, so a file's
+// original mode is restored only once the last task reading it finishes.
+type inputGuard struct {
+	mu   sync.Mutex
+	held map[string]*guardedFile
+}
+
+// protectedInputs is shared by every Runner: file modes are process-wide
+// state, and runners sharing a working directory share its inputs.
+var protectedInputs inputGuard
+
+type guardedFile struct {
+	mode fs.FileMode
+	refs int
+}
+
+// localInputPaths returns the OS paths of inputs' files in the working tree;
+// remote inputs are read from the download cache and are not guarded.
+func localInputPaths(inputs *InputSet) []string {
+	if inputs == nil {
+		return nil
+	}
+	paths := make([]string, 0, len(inputs.Inputs))
+	for _, in := range inputs.Inputs {
+		if in.Remote != nil || in.Source != "" {
+			continue
+		}
+		paths = append(paths, filepath.FromSlash(in.Path))
+	}
+	return paths
+}
+
+// acquire makes paths read-only until the returned release is called. It is
+// best effort: a file that cannot be changed is left as is, and the guard
+// does not stop root, deletions or renames. modifiedInputs is the
+// authoritative check.
+func (g *inputGuard) acquire(paths []string) (release func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.held == nil {
+		g.held = make(map[string]*guardedFile)
+	}
+	var acquired []string
+	for _, p := range paths {
+		if f, ok := g.held[p]; ok {
+			f.refs++
+			acquired = append(acquired, p)
+			continue
+		}
+		info, err := os.Stat(p)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if err := os.Chmod(p, info.Mode().Perm()&^0o222); err != nil {
+			continue
+		}
+		g.held[p] = &guardedFile{mode: info.Mode().Perm(), refs: 1}
+		acquired = append(acquired, p)
+	}
+	return func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		for _, p := range acquired {
+			f := g.held[p]
+			if f.refs--; f.refs > 0 {
+				continue
+			}
+			delete(g.held, p)
+			// The task may have replaced or removed the file.
+			_ = os.Chmod(p, f.mode)
+		}
+	}
+}
+
+// modifiedInputs returns the sorted paths, relative to root when under it,
+// of inputs' local files whose content no longer matches the digest they
+// were hashed with, or that no longer exist.
+func modifiedInputs(inputs *InputSet, root string) []string {
+	if inputs == nil {
+		return nil
+	}
+	var modified []string
+	for _, in := range inputs.Inputs {
+		if in.Remote != nil || in.Source != "" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.FromSlash(in.Path))
+		if err == nil && contentDigest(content) == in.ContentDigest() {
+			continue
+		}
+		p := filepath.FromSlash(in.Path)
+		if rel, err := filepath.Rel(root, p); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			p = rel
+		}
+		modified = append(modified, filepath.ToSlash(p))
+	}
+	sort.Strings(modified)
+	return modified
+}
+
+// modifiedInputsReport is appended to the stderr of a task that modified
+// its inputs.
+func modifiedInputsReport(paths []string) []byte {
+	var b strings.Builder
+	b.WriteString("scriptweaver: task modified its declared inputs:\n")
+	for _, p := range paths {
+		b.WriteString("  ")
+		b.WriteString(p)
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunner_ProtectInputs(t *testing.T) {
+	newRunner := func(t *testing.T) (*Runner, *MemoryCache, string) {
+		t.Helper()
+		workDir := t.TempDir()
+		writeTestFile(t, filepath.Join(workDir, "in.txt"), "original")
+		if err := os.Chmod(filepath.Join(workDir, "in.txt"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cache := NewMemoryCache()
+		runner := NewRunner(workDir, cache)
+		runner.ProtectInputs = true
+		return runner, cache, workDir
+	}
+
+	t.Run("modification fails and is not cached", func(t *testing.T) {
+		runner, cache, workDir := newRunner(t)
+		// chmod first so the write also succeeds when not running as root.
+		task := &Task{Name: "mutate", Run: "chmod u+w in.txt; echo more >> in.txt", Inputs: []string{"in.txt"}}
+		res, err := runner.Run(context.Background(), task)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if res.ExitCode != ModifiedInputsExitCode {
+			t.Fatalf("expected exit %d, got %d", ModifiedInputsExitCode, res.ExitCode)
+		}
+		if want := []string{"in.txt"}; !reflect.DeepEqual(res.ModifiedInputs, want) {
+			t.Fatalf("expected %v, got %v", want, res.ModifiedInputs)
+		}
+		if !strings.Contains(string(res.Stderr), "in.txt") {
+			t.Fatalf("expected modified paths in stderr, got %q", res.Stderr)
+		}
+		if ok, _ := cache.Has(res.Hash); ok {
+			t.Fatalf("result of a task that modified its inputs must not be cached")
+		}
+		info, err := os.Stat(filepath.Join(workDir, "in.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0o644 {
+			t.Fatalf("expected input mode restored to 0644, got %v", info.Mode().Perm())
+		}
+	})
+
+	t.Run("inputs are read-only while the task runs", func(t *testing.T) {
+		runner, cache, workDir := newRunner(t)
+		task := &Task{Name: "read", Run: "stat -c %a in.txt > mode.txt", Inputs: []string{"in.txt"}, Outputs: []string{"mode.txt"}}
+		res, err := runner.Run(context.Background(), task)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if res.ExitCode != 0 || len(res.ModifiedInputs) != 0 {
+			t.Fatalf("expected success, got exit=%d modified=%v stderr=%q", res.ExitCode, res.ModifiedInputs, res.Stderr)
+		}
+		mode, err := os.ReadFile(filepath.Join(workDir, "mode.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSpace(string(mode)); got != "444" {
+			t.Fatalf("expected input mode 444 during the run, got %s", got)
+		}
+		if ok, _ := cache.Has(res.Hash); !ok {
+			t.Fatalf("expected result cached")
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package core
+
+import "path/filepath"
+
+// RelativeWorkDirToken stands in for the working directory in TaskHashes,
+// unless Runner.AbsoluteHashPaths is set.
+const RelativeWorkDirToken = WorkDirPlaceholder
+
+// relativeHashInputs returns inputs with each path under workDir rewritten
+// relative to it, for hashing only. Paths outside workDir stay absolute:
+// they name the same file wherever the project is checked out.
+func relativeHashInputs(inputs *InputSet, workDir string) *InputSet {
+	if inputs == nil {
+		return nil
+	}
+	out := &InputSet{Inputs: make([]Input, len(inputs.Inputs))}
+	for i, in := range inputs.Inputs {
+		p := filepath.FromSlash(in.Path)
+		if filepath.IsAbs(p) && WithinWorkDir(workDir, p) {
+			if rel, err := filepath.Rel(workDir, p); err == nil {
+				in.Path = filepath.ToSlash(rel)
+			}
+		}
+		out.Inputs[i] = in
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunner_HashesPortableAcrossCheckouts(t *testing.T) {
+	cache := NewMemoryCache()
+	task := &Task{Name: "copy", Inputs: []string{"src/*.txt"}, Run: "cat src/a.txt > out.txt", Outputs: []string{"out.txt"}}
+	checkout := func() string {
+		workDir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(workDir, "src"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, filepath.Join(workDir, "src", "a.txt"), "same")
+		return workDir
+	}
+
+	first, second := checkout(), checkout()
+	res1, err := NewRunner(first, cache).Run(context.Background(), task)
+	if err != nil || res1.ExitCode != 0 {
+		t.Fatalf("first run: %+v (err=%v)", res1, err)
+	}
+	res2, err := NewRunner(second, cache).Run(context.Background(), task)
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if res1.Hash != res2.Hash || !res2.FromCache {
+		t.Fatalf("expected a cache hit from another checkout: hashes %s/%s fromCache=%v", res1.Hash, res2.Hash, res2.FromCache)
+	}
+	if b, err := os.ReadFile(filepath.Join(second, "out.txt")); err != nil || string(b) != "same" {
+		t.Fatalf("expected artifact restored into the second checkout, got %q (err=%v)", b, err)
+	}
+
+	legacy := NewRunner(second, cache)
+	legacy.AbsoluteHashPaths = true
+	abs1, _, err := legacy.TaskHash(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy = NewRunner(first, cache)
+	legacy.AbsoluteHashPaths = true
+	abs2, _, err := legacy.TaskHash(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if abs1 == abs2 || abs1 == res1.Hash {
+		t.Fatalf("absolute hashes should depend on the checkout path")
+	}
+}
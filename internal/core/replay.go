@@ -4,6 +4,7 @@ package core
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -26,6 +27,10 @@ type ReplayResult struct {
 
 	// ArtifactsRestored is the number of artifacts restored to the workspace.
 	ArtifactsRestored int
+
+	// ArtifactsVerified is the number of artifacts already present in the
+	// workspace with the cached content and mode, which were left untouched.
+	ArtifactsVerified int
 }
 
 // Replayer restores cached execution results to the workspace.
@@ -55,7 +60,8 @@ func NewReplayer(workingDir string) *Replayer {
 //  1. Restore each artifact to its original path (relative to WorkingDir)
 //  2. Return stdout, stderr, and exit code exactly as cached
 //
-// Artifacts are restored with their exact cached content (bit-for-bit identical).
+// Artifacts are restored with their exact cached content (bit-for-bit identical)
+// and recorded mode; symlink artifacts are recreated as symlinks.
 // Parent directories are created as needed.
 //
 // Returns an error if:
@@ -66,7 +72,7 @@ func (r *Replayer) Replay(entry *CacheEntry) (*ReplayResult, error) {
 		return nil, fmt.Errorf("cache entry is nil")
 	}
 
-	restored, err := r.RestoreArtifacts(entry.Hash.String(), entry)
+	restored, verified, err := r.VerifyOrRestoreArtifacts(entry.Hash.String(), entry)
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +83,7 @@ func (r *Replayer) Replay(entry *CacheEntry) (*ReplayResult, error) {
 		ExitCode:          entry.ExitCode,
 		Hash:              entry.Hash,
 		ArtifactsRestored: restored,
+		ArtifactsVerified: verified,
 	}, nil
 }
 
@@ -89,47 +96,135 @@ func (r *Replayer) Replay(entry *CacheEntry) (*ReplayResult, error) {
 //
 // taskID is used only for error messages.
 func (r *Replayer) RestoreArtifacts(taskID string, entry *CacheEntry) (int, error) {
+	restored, _, err := r.VerifyOrRestoreArtifacts(taskID, entry)
+	return restored, err
+}
+
+// VerifyOrRestoreArtifacts is RestoreArtifacts that also reports how many
+// artifacts were verified in place: already present with the cached content
+// hash and mode, and so not rewritten. A replay with no restored and only
+// verified artifacts left the workspace untouched.
+func (r *Replayer) VerifyOrRestoreArtifacts(taskID string, entry *CacheEntry) (restored, verified int, err error) {
 	if r == nil {
-		return 0, fmt.Errorf("replayer is nil")
+		return 0, 0, fmt.Errorf("replayer is nil")
 	}
 	if entry == nil {
-		return 0, fmt.Errorf("cache entry is nil")
+		return 0, 0, fmt.Errorf("cache entry is nil")
 	}
 
-	restored := 0
 	for _, artifact := range entry.Artifacts {
 		if artifact.Path == "" {
-			return restored, fmt.Errorf("task %q: artifact path is empty", taskID)
+			return restored, verified, fmt.Errorf("task %q: artifact path is empty", taskID)
 		}
-		if artifact.Content == nil {
-			return restored, fmt.Errorf("task %q: artifact %q missing content in cache entry", taskID, artifact.Path)
+		if artifact.Content == nil && !artifact.Streamed() {
+			return restored, verified, fmt.Errorf("task %q: artifact %q missing content in cache entry", taskID, artifact.Path)
 		}
 
 		targetPath, err := r.targetPathForArtifact(artifact.Path)
 		if err != nil {
-			return restored, fmt.Errorf("task %q: resolving artifact %q target path: %w", taskID, artifact.Path, err)
+			return restored, verified, fmt.Errorf("task %q: resolving artifact %q target path: %w", taskID, artifact.Path, err)
+		}
+
+		if artifact.LinkTarget != "" {
+			changed, err := restoreSymlink(r.WorkingDir, targetPath, artifact.LinkTarget)
+			if err != nil {
+				return restored, verified, fmt.Errorf("task %q: restoring symlink %q: %w", taskID, artifact.Path, err)
+			}
+			if changed {
+				restored++
+			} else {
+				verified++
+			}
+			continue
+		}
+
+		perm := artifactPerm(artifact.Mode)
+		if artifact.Streamed() {
+			wrote, err := restoreStreamed(entry.Hash, targetPath, artifact)
+			if err != nil {
+				return restored, verified, fmt.Errorf("task %q: restoring artifact %q: %w", taskID, artifact.Path, err)
+			}
+			if wrote {
+				restored++
+			} else {
+				verified++
+			}
+			continue
 		}
 
-		wantHash := sha256Hex(artifact.Content)
-		haveHash, ok, err := fileSHA256HexIfExists(targetPath)
+		contentOK, modeOK, err := existingFileState(targetPath, sha256Hex(artifact.Content), perm)
 		if err != nil {
-			return restored, fmt.Errorf("task %q: hashing existing artifact %q: %w", taskID, artifact.Path, err)
+			return restored, verified, fmt.Errorf("task %q: hashing existing artifact %q: %w", taskID, artifact.Path, err)
 		}
-		if ok && haveHash == wantHash {
+		if contentOK && modeOK {
+			verified++
+			continue
+		}
+		if contentOK {
+			if err := os.Chmod(targetPath, perm); err != nil {
+				return restored, verified, fmt.Errorf("task %q: restoring mode of %q: %w", taskID, artifact.Path, err)
+			}
+			restored++
 			continue
 		}
 
-		if err := atomicWriteFile(targetPath, artifact.Content, 0644); err != nil {
-			return restored, fmt.Errorf("task %q: restoring artifact %q: %w", taskID, artifact.Path, err)
+		if err := atomicWriteFile(targetPath, artifact.Content, perm); err != nil {
+			return restored, verified, fmt.Errorf("task %q: restoring artifact %q: %w", taskID, artifact.Path, err)
 		}
 		restored++
 	}
 
-	return restored, nil
+	return restored, verified, nil
+}
+
+// restoreStreamed copies a streamed artifact to targetPath in chunks, checking
+// the recorded digest on the way; a mismatch leaves the target untouched and
+// returns a *CacheCorruptError. It reports whether the target was written (an
+// existing file with the recorded digest is kept as is).
+func restoreStreamed(hash TaskHash, targetPath string, artifact CachedArtifact) (bool, error) {
+	perm := artifactPerm(artifact.Mode)
+	if artifact.SHA256 != "" {
+		contentOK, modeOK, err := existingFileState(targetPath, artifact.SHA256, perm)
+		if err != nil {
+			return false, fmt.Errorf("hashing existing artifact: %w", err)
+		}
+		if contentOK {
+			if modeOK {
+				return false, nil
+			}
+			return true, os.Chmod(targetPath, perm)
+		}
+	}
+
+	src, err := artifact.Open()
+	if err != nil {
+		return false, err
+	}
+	defer src.Close()
+	got, err := writeStreamAtomic(targetPath, CompressionNone, src, perm, artifact.SHA256)
+	if errors.Is(err, errDigestMismatch) {
+		return false, &CacheCorruptError{Hash: hash, Reason: fmt.Sprintf("artifact %s: sha256 %s does not match recorded %s", artifact.Path, got, artifact.SHA256)}
+	}
+	if err != nil {
+		if isBlobDecodeError(err) {
+			return false, &CacheCorruptError{Hash: hash, Reason: fmt.Sprintf("artifact %s: decompressing blob: %v", artifact.Path, err)}
+		}
+		return false, err
+	}
+	return true, nil
 }
 
-// restoreArtifact writes a cached artifact to the workspace.
+// targetPathForArtifact resolves a cached artifact path under WorkingDir
+// and creates its parent directory. Paths that resolve outside WorkingDir,
+// which the Harvester never records, are rejected with
+// ErrPathOutsideWorkDir rather than written wherever they point. So are
+// paths whose parent directory leads outside it through a symlink, such as
+// one restored from an earlier artifact.
 func (r *Replayer) targetPathForArtifact(artifactPath string) (string, error) {
+	if !WithinWorkDir(r.WorkingDir, filepath.FromSlash(artifactPath)) {
+		return "", ErrPathOutsideWorkDir
+	}
+
 	// Determine target path
 	targetPath := artifactPath
 	if !filepath.IsAbs(artifactPath) {
@@ -139,8 +234,12 @@ func (r *Replayer) targetPathForArtifact(artifactPath string) (string, error) {
 	// Convert forward slashes to OS path separator
 	targetPath = filepath.FromSlash(targetPath)
 
-	// Create parent directories
+	// Create parent directories, never through a symlink leading elsewhere:
+	// MkdirAll and the writes below would follow it.
 	parentDir := filepath.Dir(targetPath)
+	if err := realDirWithin(r.WorkingDir, parentDir); err != nil {
+		return "", err
+	}
 	if err := os.MkdirAll(parentDir, 0755); err != nil {
 		return "", fmt.Errorf("creating parent directory: %w", err)
 	}
@@ -148,6 +247,34 @@ func (r *Replayer) targetPathForArtifact(artifactPath string) (string, error) {
 	return targetPath, nil
 }
 
+// realDirWithin checks that dir, once its deepest existing ancestor is
+// resolved through symlinks, lies at or under the resolved workDir. Missing
+// directories below that ancestor are created as real directories.
+func realDirWithin(workDir, dir string) error {
+	root, err := filepath.Abs(workDir)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return fmt.Errorf("resolving working directory: %w", err)
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		real, err := filepath.EvalSymlinks(d)
+		if err == nil {
+			if !WithinWorkDir(root, real) {
+				return ErrPathOutsideWorkDir
+			}
+			return nil
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		if filepath.Dir(d) == d {
+			return nil
+		}
+	}
+}
+
 func sha256Hex(data []byte) string {
 	sum := sha256.Sum256(data)
 	return hex.EncodeToString(sum[:])
@@ -2,6 +2,7 @@ package core
 
 import (
 	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -206,6 +207,38 @@ func TestReplay_CreatesParentDirectories(t *testing.T) {
 	}
 }
 
+// TestReplay_RefusesSymlinkedParents verifies an artifact is not written
+// through a directory symlink leading outside the workspace.
+func TestReplay_RefusesSymlinkedParents(t *testing.T) {
+	workDir, outside := t.TempDir(), t.TempDir()
+	replayer := NewReplayer(workDir)
+
+	if err := os.Symlink(outside, filepath.Join(workDir, "out")); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"out/x.txt", "out/new/x.txt"} {
+		entry := &CacheEntry{Hash: TaskHash("b"), Artifacts: []CachedArtifact{{Path: p, Content: []byte("x")}}}
+		if _, err := replayer.RestoreArtifacts("t", entry); !errors.Is(err, ErrPathOutsideWorkDir) {
+			t.Fatalf("%s: expected ErrPathOutsideWorkDir, got %v", p, err)
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Fatalf("artifacts written outside the workspace: %v", entries)
+	}
+
+	// Symlinks that stay inside the workspace are still followed.
+	if err := os.Mkdir(filepath.Join(workDir, "real"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("real", filepath.Join(workDir, "alias")); err != nil {
+		t.Fatal(err)
+	}
+	entry := &CacheEntry{Hash: TaskHash("c"), Artifacts: []CachedArtifact{{Path: "alias/x.txt", Content: []byte("x")}}}
+	if _, err := replayer.RestoreArtifacts("t", entry); err != nil {
+		t.Fatalf("RestoreArtifacts: %v", err)
+	}
+}
+
 // TestReplay_NilEntryFails returns error.
 func TestReplay_NilEntryFails(t *testing.T) {
 	replayer := NewReplayer("/tmp")
@@ -304,3 +337,36 @@ func TestReplay_OverwritesExistingFiles(t *testing.T) {
 		t.Errorf("file not overwritten: %s", content)
 	}
 }
+
+// TestReplayer_VerifyOrRestoreArtifacts verifies matching files are counted as
+// verified and left untouched, while missing or differing ones are rewritten.
+func TestReplayer_VerifyOrRestoreArtifacts(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "same.txt"), []byte("same"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "diff.txt"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entry := &CacheEntry{Artifacts: []CachedArtifact{
+		{Path: "same.txt", Content: []byte("same"), Mode: 0644},
+		{Path: "diff.txt", Content: []byte("new"), Mode: 0644},
+		{Path: "missing.txt", Content: []byte("m"), Mode: 0644},
+	}}
+
+	restored, verified, err := NewReplayer(tmpDir).VerifyOrRestoreArtifacts("t", entry)
+	if err != nil {
+		t.Fatalf("VerifyOrRestoreArtifacts failed: %v", err)
+	}
+	if restored != 2 || verified != 1 {
+		t.Fatalf("expected 2 restored and 1 verified, got %d and %d", restored, verified)
+	}
+
+	restored, verified, err = NewReplayer(tmpDir).VerifyOrRestoreArtifacts("t", entry)
+	if err != nil {
+		t.Fatalf("VerifyOrRestoreArtifacts failed: %v", err)
+	}
+	if restored != 0 || verified != 3 {
+		t.Fatalf("expected everything verified in place, got %d restored and %d verified", restored, verified)
+	}
+}
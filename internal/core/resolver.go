@@ -22,6 +22,14 @@ type InputResolver struct {
 	// BaseDir is the working directory for resolving relative paths.
 	// All paths are resolved relative to this directory.
 	BaseDir string
+
+	// Fingerprints, when set, supplies digests for files whose size and
+	// mtime are unchanged, so they are not read to be hashed.
+	Fingerprints *FingerprintCache
+
+	// overlay holds cached artifacts that shadow the filesystem, keyed by
+	// normalized absolute path (see AddOverlay).
+	overlay map[string]CachedArtifact
 }
 
 // NewInputResolver creates a new InputResolver with the given base directory.
@@ -50,8 +58,17 @@ func (r *InputResolver) Resolve(patterns []string) (*InputSet, error) {
 
 	// Collect all expanded paths
 	pathSet := make(map[string]struct{})
+	remote := make(map[string]Input)
 
 	for _, pattern := range patterns {
+		if IsRemoteInput(pattern) {
+			in, err := r.resolveRemote(pattern)
+			if err != nil {
+				return nil, err
+			}
+			remote[in.Path] = in
+			continue
+		}
 		expanded, err := r.expandPattern(pattern)
 		if err != nil {
 			return nil, fmt.Errorf("expanding pattern %q: %w", pattern, err)
@@ -63,8 +80,13 @@ func (r *InputResolver) Resolve(patterns []string) (*InputSet, error) {
 
 	// Extract and sort paths deterministically
 	// CRITICAL: Must sort explicitly, do not rely on OS directory ordering
-	paths := make([]string, 0, len(pathSet))
+	paths := make([]string, 0, len(pathSet)+len(remote))
 	for p := range pathSet {
+		if _, ok := remote[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	for p := range remote {
 		paths = append(paths, p)
 	}
 	sort.Strings(paths)
@@ -72,22 +94,47 @@ func (r *InputResolver) Resolve(patterns []string) (*InputSet, error) {
 	// Read file contents (content-based identity)
 	inputs := make([]Input, 0, len(paths))
 	for _, path := range paths {
-		content, err := r.readFileContent(path)
+		if in, ok := remote[path]; ok {
+			inputs = append(inputs, in)
+			continue
+		}
+		in, err := r.readInput(path)
 		if err != nil {
 			return nil, fmt.Errorf("reading input %q: %w", path, err)
 		}
-		inputs = append(inputs, Input{
-			Path:    path,
-			Content: content,
-		})
+		inputs = append(inputs, in)
 	}
 
 	return &InputSet{Inputs: inputs}, nil
 }
 
+// AddOverlay makes entry's artifacts visible to Resolve as if they had been
+// restored under BaseDir, without touching the filesystem: they match input
+// patterns and their cached content shadows whatever is on disk.
+//
+// This lets resume planning hash downstream tasks against the outputs of the
+// tasks it will reuse before anything is restored. Content is read from the
+// cache only for artifacts an input pattern matches.
+func (r *InputResolver) AddOverlay(entry *CacheEntry) {
+	if entry == nil {
+		return
+	}
+	if r.overlay == nil {
+		r.overlay = make(map[string]CachedArtifact, len(entry.Artifacts))
+	}
+	for _, a := range entry.Artifacts {
+		r.overlay[filepath.ToSlash(filepath.Join(r.BaseDir, filepath.FromSlash(a.Path)))] = a
+	}
+}
+
 // expandPattern expands a single glob pattern into a sorted list of file paths.
 // If the pattern contains no glob characters, it is treated as a literal path.
+// Git tracked-files patterns (see GitInputPrefix) are listed by git instead.
 func (r *InputResolver) expandPattern(pattern string) ([]string, error) {
+	if IsGitInput(pattern) {
+		return r.expandGitPattern(pattern)
+	}
+
 	// Resolve relative to base directory
 	fullPattern := pattern
 	if !filepath.IsAbs(pattern) {
@@ -125,12 +172,67 @@ func (r *InputResolver) expandPattern(pattern string) ([]string, error) {
 		normalized = append(normalized, normPath)
 	}
 
+	// Overlay files need not exist on disk; Resolve dedupes paths on both.
+	for key := range r.overlay {
+		if ok, _ := filepath.Match(fullPattern, filepath.FromSlash(key)); ok {
+			normalized = append(normalized, key)
+		}
+	}
+
 	return normalized, nil
 }
 
+// readInput reads one resolved input, consulting the fingerprint cache for
+// files on disk.
+func (r *InputResolver) readInput(path string) (Input, error) {
+	_, overlaid := r.overlay[path]
+	if r.Fingerprints == nil || overlaid {
+		content, err := r.readFileContent(path)
+		return Input{Path: path, Content: content}, err
+	}
+	info, err := os.Stat(filepath.FromSlash(path))
+	if err != nil {
+		return Input{}, err
+	}
+	if digest, ok := r.Fingerprints.Lookup(path, info); ok {
+		return Input{Path: path, Digest: digest}, nil
+	}
+	content, err := r.readFileContent(path)
+	if err != nil {
+		return Input{}, err
+	}
+	digest := contentDigest(content)
+	r.Fingerprints.Record(path, info, digest)
+	return Input{Path: path, Content: content, Digest: digest}, nil
+}
+
 // readFileContent reads the content of a file.
 // Only content is read; metadata (mtime, permissions) is ignored for determinism.
 func (r *InputResolver) readFileContent(path string) ([]byte, error) {
+	// Overlay symlinks resolve like restored ones would: relative to the
+	// link's directory, against the overlay first.
+	for hops := 0; hops < 40; hops++ {
+		a, ok := r.overlay[path]
+		if !ok {
+			break
+		}
+		if a.LinkTarget == "" {
+			content, err := a.readAll()
+			if err != nil {
+				return nil, fmt.Errorf("reading cached artifact %q: %w", a.Path, err)
+			}
+			if content == nil {
+				content = []byte{}
+			}
+			return content, nil
+		}
+		target := filepath.FromSlash(a.LinkTarget)
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(filepath.FromSlash(path)), target)
+		}
+		path = filepath.ToSlash(target)
+	}
+
 	// Convert back to OS path for reading
 	osPath := filepath.FromSlash(path)
 	content, err := os.ReadFile(osPath)
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -233,3 +234,66 @@ func TestResolve_NormalizesPathSeparators(t *testing.T) {
 		}
 	}
 }
+
+// TestResolve_OverlayShadowsFilesystem verifies that overlaid cache artifacts
+// match patterns and supply content without being written to disk.
+func TestResolve_OverlayShadowsFilesystem(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "out"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "out", "a.txt"), []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "out", "disk.txt"), []byte("disk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := NewInputResolver(tmpDir)
+	resolver.AddOverlay(&CacheEntry{Artifacts: []CachedArtifact{
+		{Path: "out/a.txt", Content: []byte("cached")},
+		{Path: "out/b.txt", Content: []byte("only-in-cache")},
+		{Path: "out/link.txt", LinkTarget: "b.txt"},
+	}})
+	result, err := resolver.Resolve([]string{"out/*.txt"})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	want := map[string]string{"a.txt": "cached", "b.txt": "only-in-cache", "disk.txt": "disk", "link.txt": "only-in-cache"}
+	if len(result.Inputs) != len(want) {
+		t.Fatalf("expected %d inputs, got %+v", len(want), result.Inputs)
+	}
+	for i, in := range result.Inputs {
+		if i > 0 && result.Inputs[i-1].Path >= in.Path {
+			t.Fatalf("inputs not strictly sorted: %q before %q", result.Inputs[i-1].Path, in.Path)
+		}
+		if got := string(in.Content); got != want[filepath.Base(in.Path)] {
+			t.Fatalf("%s: got content %q want %q", in.Path, got, want[filepath.Base(in.Path)])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "out", "b.txt")); !os.IsNotExist(err) {
+		t.Fatalf("overlay must not write to disk, stat err=%v", err)
+	}
+}
+
+// BenchmarkInputResolver_Resolve measures expanding and reading a 1000-file
+// glob without the fingerprint cache.
+func BenchmarkInputResolver_Resolve(b *testing.B) {
+	dir := b.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0o755); err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		if err := os.WriteFile(filepath.Join(dir, "src", fmt.Sprintf("file%04d.go", i)), []byte(fmt.Sprintf("package src // %d", i)), 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	resolver := NewInputResolver(dir)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := resolver.Resolve([]string{"src/*.go"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -4,6 +4,7 @@ package core
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 )
@@ -43,6 +44,86 @@ type Runner struct {
 
 	// Normalizer for output normalization (optional).
 	Normalizer OutputNormalizer
+
+	// HealCorruptEntries treats a corrupt cache entry (see CacheCorruptError)
+	// as a miss: the task is re-executed and the entry rewritten, and the
+	// result is marked CacheCorrupt. When false, corruption is a hard error.
+	HealCorruptEntries bool
+
+	// StrictOutputs snapshots WorkingDir before and after execution and fails
+	// a task that created, modified or deleted files outside its declared
+	// Outputs; see RunResult.UndeclaredOutputs. Nothing else may write to
+	// WorkingDir while a task runs (use serial execution).
+	StrictOutputs bool
+
+	// ProtectInputs makes a task's local input files read-only while it
+	// executes and, afterwards, fails a task whose inputs no longer match the
+	// digests it was hashed with; see RunResult.ModifiedInputs. Any change
+	// made while the task runs is attributed to it.
+	ProtectInputs bool
+
+	// StrictIgnore lists paths (relative to WorkingDir, or absolute) excluded
+	// from strict snapshots, such as the cache and state directories.
+	StrictIgnore []string
+
+	// CleanOutputs removes a task's declared outputs before it executes and
+	// prunes them to exactly the cached artifacts before a replay, so results
+	// do not depend on what earlier runs left behind. Set it when the output
+	// directory is not wiped before each run.
+	CleanOutputs bool
+
+	// Isolated runs each executed task in a fresh scratch directory holding
+	// only its resolved inputs; on success the declared outputs are harvested
+	// there and copied back into WorkingDir. Upstream artifacts are visible
+	// only if declared as inputs, which also keeps them in the task hash.
+	Isolated bool
+
+	// AbsoluteHashPaths hashes WorkingDir and absolute input paths, so
+	// TaskHashes differ between checkout paths. By default
+	// RelativeWorkDirToken and input paths relative to WorkingDir are hashed
+	// instead, so the same project checked out at different paths produces
+	// the same TaskHashes and shares cache entries.
+	AbsoluteHashPaths bool
+
+	// ScratchRoot is where isolated scratch directories are created; empty
+	// uses the system temp directory.
+	ScratchRoot string
+
+	// StreamNormalizer, when set, normalizes captured stdout and stderr
+	// (followed by the task's normalize rules) before they are cached,
+	// replayed or reported. It is not part of the task hash: entries cached
+	// before it was set keep their raw streams.
+	StreamNormalizer OutputNormalizer
+
+	// Rerun names tasks that execute even when the cache holds a result for
+	// their hash; the new result replaces the cached entry.
+	Rerun map[string]bool
+
+	// Effects, when set, guards tasks with an effect key: a task whose key is
+	// already recorded is not executed, and a successful execution records
+	// its key.
+	Effects EffectLedger
+
+	// Downloads caches checksum-pinned remote inputs (see RemoteInput). A
+	// task with remote inputs fails to execute when it is nil.
+	Downloads *DownloadCache
+
+	// Audit, when set, receives an AuditReport for every task that executed
+	// with recorded accesses (see Executor.Strace), whatever its outcome. It
+	// may be called concurrently and never affects the result.
+	Audit func(AuditReport)
+
+	// Logger, when set, receives a debug record for every cache lookup,
+	// replay and store; nil logs nothing.
+	Logger *slog.Logger
+}
+
+// debug logs a cache operation on task to r.Logger, if any.
+func (r *Runner) debug(msg string, task *Task, hash TaskHash, args ...any) {
+	if r.Logger == nil {
+		return
+	}
+	r.Logger.Debug(msg, append([]any{"task", task.Name, "hash", string(hash)}, args...)...)
 }
 
 // NewRunner creates a Runner with the given working directory and cache.
@@ -86,6 +167,50 @@ type RunResult struct {
 
 	// ArtifactsRestored is the number of artifacts (for cached results).
 	ArtifactsRestored int
+
+	// ArtifactsVerified is the number of cached artifacts found already in
+	// place and not rewritten (see Replayer.VerifyOrRestoreArtifacts).
+	ArtifactsVerified int
+
+	// Invalidated indicates a cache entry existed for the hash but could not be
+	// used (it did not match the task's declared outputs, or was corrupt), so
+	// the task was executed instead.
+	Invalidated bool
+
+	// CacheCorrupt narrows Invalidated: the entry was corrupt and has been
+	// rewritten (only with HealCorruptEntries).
+	CacheCorrupt bool
+
+	// UndeclaredOutputs lists, sorted, the paths a task wrote outside its
+	// declared outputs under StrictOutputs. When set, the result is not cached,
+	// the paths are appended to Stderr, and a zero exit code is replaced by
+	// UndeclaredOutputsExitCode.
+	UndeclaredOutputs []string
+
+	// OutputMismatches lists the artifacts that did not match the task's
+	// ExpectedOutputs; the task then fails with ExpectedOutputsExitCode.
+	OutputMismatches []OutputMismatch
+
+	// ModifiedInputs lists, sorted, the declared inputs a task modified or
+	// removed under ProtectInputs. Like UndeclaredOutputs, the result is not
+	// cached, the paths are appended to Stderr, and a successful exit code is
+	// replaced by ModifiedInputsExitCode.
+	ModifiedInputs []string
+
+	// OutputTruncated is set when the task executed and its stdout or stderr
+	// was cut at the output limit. Replays of the entry keep the truncated
+	// streams but do not set it.
+	OutputTruncated bool
+
+	// EffectApplied is set when the task was due to execute but its effect
+	// key was already recorded (see Runner.Effects), so nothing ran and the
+	// result is an empty success.
+	EffectApplied bool
+
+	// ToolMismatches lists the declared tools that were missing or did not
+	// match; the task then did not execute and fails with
+	// ToolMismatchExitCode. Such results are not cached.
+	ToolMismatches []ToolMismatch
 }
 
 // Run executes a task or replays from cache.
@@ -94,11 +219,18 @@ type RunResult struct {
 //  1. Validate task
 //  2. Resolve inputs
 //  3. Compute hash
-//  4. Check cache → if hit, replay and return
-//  5. Execute task
+//  4. Check cache → if hit and the entry matches the declared outputs, replay and return
+//     (a stale entry, or a corrupt one with HealCorruptEntries, is discarded and
+//     the task re-executed with Invalidated set); tasks named in Rerun, and
+//     tasks that are not cacheable, always miss
+//  5. Execute task (in a scratch directory holding only its inputs when Isolated)
 //  6. If success (exit code 0): harvest artifacts, cache, return
 //  7. If failure (non-zero): cache stdout/stderr/exitcode (NO artifacts), return
 //
+// Nothing is stored for a task that is not cacheable (see Task.Cacheable).
+// A task due to execute whose effect key is already applied does not run
+// (see Runner.Effects).
+//
 // From spec.md Failure Behavior:
 //
 //	"Failed tasks MUST NOT partially update artifacts."
@@ -111,35 +243,119 @@ func (r *Runner) Run(ctx context.Context, task *Task) (*RunResult, error) {
 		return nil, err
 	}
 
-	// Resolve inputs
-	inputSet, err := r.Resolver.Resolve(task.Inputs)
+	// Resolve inputs and compute hash
+	hash, inputSet, err := r.TaskHash(task)
 	if err != nil {
-		return nil, fmt.Errorf("resolving inputs: %w", err)
-	}
-
-	// Compute hash
-	hashInput := HashInput{
-		Inputs:     inputSet,
-		Command:    task.Run,
-		Env:        task.Env,
-		Outputs:    task.Outputs,
-		WorkingDir: r.WorkingDir,
+		return nil, err
 	}
-	hash := r.Hasher.ComputeHash(hashInput)
 
 	// Check cache
 	exists, err := r.Cache.Has(hash)
 	if err != nil {
 		return nil, fmt.Errorf("checking cache: %w", err)
 	}
+	switch {
+	case !exists:
+		r.debug("cache miss", task, hash)
+	case r.Rerun[task.Name]:
+		r.debug("cache bypassed", task, hash, "reason", "rerun")
+		exists = false
+	case !task.IsCacheable():
+		r.debug("cache bypassed", task, hash, "reason", "not cacheable")
+		exists = false
+	}
 
 	if exists {
-		// Cache hit - replay
-		return r.replayFromCache(hash)
+		entry, err := r.Cache.Get(hash)
+		if err != nil {
+			if r.HealCorruptEntries && IsCacheCorrupt(err) {
+				r.debug("cache entry corrupt, re-executing", task, hash, "error", err)
+				return r.healCorruptEntry(ctx, task, hash, inputSet)
+			}
+			return nil, fmt.Errorf("retrieving cache entry: %w", err)
+		}
+		if entry == nil {
+			return nil, fmt.Errorf("cache entry disappeared")
+		}
+		verr := VerifyDeclaredOutputs(entry, task.Outputs, r.WorkingDir)
+		if verr == nil {
+			// Cache hit - replay. Streamed blobs are only digest-checked here.
+			if r.CleanOutputs {
+				if err := r.PruneOutputs(task.Outputs, entry); err != nil {
+					return nil, fmt.Errorf("pruning outputs: %w", err)
+				}
+			}
+			res, err := r.replayEntry(hash, entry)
+			if err != nil && r.HealCorruptEntries && IsCacheCorrupt(err) {
+				r.debug("cache entry corrupt, re-executing", task, hash, "error", err)
+				return r.healCorruptEntry(ctx, task, hash, inputSet)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if err := applyExpectedOutputs(res, task, entry); err != nil {
+				return nil, err
+			}
+			r.debug("cache hit", task, hash, "exit_code", res.ExitCode, "artifacts", res.ArtifactsRestored)
+			return res, nil
+		}
+		// Stale entry - fall through and overwrite it.
+		r.debug("cache entry stale, re-executing", task, hash, "reason", verr)
+		res, err := r.executeAndCache(ctx, task, hash, inputSet)
+		if err != nil {
+			return nil, err
+		}
+		res.Invalidated = true
+		return res, nil
 	}
 
 	// Cache miss - execute
-	return r.executeAndCache(ctx, task, hash)
+	return r.executeAndCache(ctx, task, hash, inputSet)
+}
+
+// TaskHash resolves task's inputs and computes its TaskHash. The resolved
+// InputSet is returned for callers that go on to execute the task.
+func (r *Runner) TaskHash(task *Task) (TaskHash, *InputSet, error) {
+	inputSet, err := r.Resolver.Resolve(task.Inputs)
+	if err != nil {
+		return "", nil, fmt.Errorf("resolving inputs: %w", err)
+	}
+	tools, err := r.ResolveTools(task)
+	if err != nil {
+		return "", nil, fmt.Errorf("resolving tools: %w", err)
+	}
+	hashInputs, workDir := inputSet, r.WorkingDir
+	if !r.AbsoluteHashPaths {
+		hashInputs, workDir = relativeHashInputs(inputSet, r.WorkingDir), RelativeWorkDirToken
+	}
+	hash := r.Hasher.ComputeHash(HashInput{
+		Inputs:           hashInputs,
+		Command:          task.Run,
+		Env:              task.Env,
+		Outputs:          task.Outputs,
+		WorkingDir:       workDir,
+		Image:            task.Image,
+		Kind:             task.Kind,
+		Normalize:        task.Normalize,
+		MaxOutputBytes:   task.MaxOutputBytes,
+		SuccessExitCodes: task.SuccessExitCodes,
+		NotCacheable:     !task.IsCacheable(),
+		Stdin:            task.Stdin,
+		Tools:            tools,
+		Network:          task.Network,
+	})
+	return hash, inputSet, nil
+}
+
+// healCorruptEntry re-executes task and overwrites its corrupt cache entry.
+func (r *Runner) healCorruptEntry(ctx context.Context, task *Task, hash TaskHash, inputSet *InputSet) (*RunResult, error) {
+	res, err := r.executeAndCache(ctx, task, hash, inputSet)
+	if err != nil {
+		return nil, err
+	}
+	res.Invalidated = true
+	res.CacheCorrupt = true
+	return res, nil
 }
 
 // validateTask ensures the task is valid before execution.
@@ -153,19 +369,32 @@ func (r *Runner) validateTask(task *Task) error {
 	if task.Run == "" {
 		return fmt.Errorf("task run command is required")
 	}
-	return nil
-}
-
-// replayFromCache retrieves and replays a cached result.
-func (r *Runner) replayFromCache(hash TaskHash) (*RunResult, error) {
-	entry, err := r.Cache.Get(hash)
-	if err != nil {
-		return nil, fmt.Errorf("retrieving cache entry: %w", err)
+	if err := task.ValidateNormalizeRules(); err != nil {
+		return err
+	}
+	if task.MaxOutputBytes < 0 {
+		return fmt.Errorf("max_output_bytes must not be negative")
 	}
-	if entry == nil {
-		return nil, fmt.Errorf("cache entry disappeared")
+	if err := task.ValidateSuccessExitCodes(); err != nil {
+		return err
 	}
+	if err := task.ValidateStdin(); err != nil {
+		return err
+	}
+	if err := task.ValidateTools(); err != nil {
+		return err
+	}
+	if err := task.ValidateRemoteInputs(); err != nil {
+		return err
+	}
+	if err := task.ValidateNetwork(); err != nil {
+		return err
+	}
+	return task.ValidateExpectedOutputs()
+}
 
+// replayEntry replays an already-retrieved cache entry.
+func (r *Runner) replayEntry(hash TaskHash, entry *CacheEntry) (*RunResult, error) {
 	replayResult, err := r.Replayer.Replay(entry)
 	if err != nil {
 		return nil, fmt.Errorf("replaying cached result: %w", err)
@@ -178,6 +407,7 @@ func (r *Runner) replayFromCache(hash TaskHash) (*RunResult, error) {
 		ExitCode:          replayResult.ExitCode,
 		FromCache:         true,
 		ArtifactsRestored: replayResult.ArtifactsRestored,
+		ArtifactsVerified: replayResult.ArtifactsVerified,
 	}, nil
 }
 
@@ -185,25 +415,139 @@ func (r *Runner) replayFromCache(hash TaskHash) (*RunResult, error) {
 //
 // CRITICAL: Failed tasks (non-zero exit) are cached WITHOUT artifacts.
 // This ensures "Failed tasks MUST NOT partially update artifacts."
-func (r *Runner) executeAndCache(ctx context.Context, task *Task, hash TaskHash) (*RunResult, error) {
+//
+// A task whose effect key is already applied is not executed; a successful
+// execution records its key.
+func (r *Runner) executeAndCache(ctx context.Context, task *Task, hash TaskHash, inputSet *InputSet) (*RunResult, error) {
+	applied, err := r.EffectApplied(task)
+	if err != nil {
+		return nil, fmt.Errorf("checking effect key: %w", err)
+	}
+	if applied {
+		return &RunResult{Hash: hash, EffectApplied: true}, nil
+	}
+	if mismatches, err := r.verifyTools(ctx, task); err != nil || len(mismatches) > 0 {
+		if err != nil {
+			return nil, err
+		}
+		// Not cached: the toolchain, not the task, is at fault.
+		return &RunResult{Hash: hash, Stderr: ToolMismatchReport(mismatches), ExitCode: ToolMismatchExitCode, ToolMismatches: mismatches}, nil
+	}
+	if err := r.FetchInputs(ctx, inputSet); err != nil {
+		return nil, err
+	}
+	if r.CleanOutputs {
+		if err := r.CleanArtifacts(task.Outputs); err != nil {
+			return nil, fmt.Errorf("cleaning outputs: %w", err)
+		}
+	}
+	execDir, executor, harvester := r.WorkingDir, r.Executor, r.Harvester
+	if len(task.Normalize) > 0 {
+		n, err := r.ArtifactNormalizer(task)
+		if err != nil {
+			return nil, err
+		}
+		h := *r.Harvester
+		h.Normalizer = n
+		harvester = &h
+	}
+	ignore := strictIgnoreList(r.WorkingDir, r.StrictIgnore)
+	if r.Isolated {
+		if err := validateIsolatedOutputs(task.Outputs); err != nil {
+			return nil, err
+		}
+		scratch, err := materializeScratch(r.ScratchRoot, r.WorkingDir, inputSet)
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(scratch)
+		ex := *r.Executor
+		ex.WorkingDir = scratch
+		execDir, executor = scratch, &ex
+		h := *harvester
+		h.BaseDir = scratch
+		harvester = &h
+		ignore = nil
+	}
+
+	var before treeSnapshot
+	if r.StrictOutputs {
+		var err error
+		if before, err = snapshotTree(execDir, ignore); err != nil {
+			return nil, err
+		}
+	}
+
 	// Execute task
-	execResult, err := r.Executor.Execute(ctx, task, hash)
+	release := func() {}
+	if r.ProtectInputs {
+		release = protectedInputs.acquire(localInputPaths(inputSet))
+	}
+	execResult, err := executor.Execute(ctx, task, hash)
+	release()
 	if err != nil {
 		return nil, fmt.Errorf("executing task: %w", err)
 	}
+	if execResult.Stdout, execResult.Stderr, err = r.NormalizeStreams(task, execResult.Stdout, execResult.Stderr); err != nil {
+		return nil, err
+	}
+	if r.Audit != nil && execResult.Accesses != nil {
+		r.Audit(auditReport(task, execResult.Accesses, execDir, r.WorkingDir, inputSet))
+	}
+
+	if r.StrictOutputs {
+		after, err := snapshotTree(execDir, ignore)
+		if err != nil {
+			return nil, err
+		}
+		if undeclared := undeclaredWrites(changedPaths(before, after), task.Outputs, execDir); len(undeclared) > 0 {
+			// Not cached: the violation must be reported again on every run.
+			exitCode := execResult.ExitCode
+			if task.Succeeded(exitCode) {
+				exitCode = UndeclaredOutputsExitCode
+			}
+			return &RunResult{
+				Hash:              hash,
+				Stdout:            execResult.Stdout,
+				Stderr:            append(append([]byte(nil), execResult.Stderr...), undeclaredOutputsReport(undeclared)...),
+				ExitCode:          exitCode,
+				UndeclaredOutputs: undeclared,
+				OutputTruncated:   execResult.OutputTruncated,
+			}, nil
+		}
+	}
+
+	if r.ProtectInputs {
+		if modified := modifiedInputs(inputSet, r.WorkingDir); len(modified) > 0 {
+			// Not cached: the hash no longer describes what the task read.
+			exitCode := execResult.ExitCode
+			if task.Succeeded(exitCode) {
+				exitCode = ModifiedInputsExitCode
+			}
+			return &RunResult{
+				Hash:            hash,
+				Stdout:          execResult.Stdout,
+				Stderr:          append(append([]byte(nil), execResult.Stderr...), modifiedInputsReport(modified)...),
+				ExitCode:        exitCode,
+				ModifiedInputs:  modified,
+				OutputTruncated: execResult.OutputTruncated,
+			}, nil
+		}
+	}
 
 	// Prepare cache entry
 	entry := &CacheEntry{
-		Hash:     hash,
-		Stdout:   execResult.Stdout,
-		Stderr:   execResult.Stderr,
-		ExitCode: execResult.ExitCode,
+		Hash:            hash,
+		Stdout:          execResult.Stdout,
+		Stderr:          execResult.Stderr,
+		ExitCode:        execResult.ExitCode,
+		DeclaredOutputs: ManifestOutputs(task.Outputs),
 	}
 
 	// Handle artifacts based on exit code
-	if execResult.ExitCode == 0 {
+	if task.Succeeded(execResult.ExitCode) {
 		// SUCCESS: Harvest artifacts
-		artifacts, err := r.harvestArtifacts(task.Outputs)
+		artifacts, err := harvestArtifacts(harvester, task.Outputs)
 		if err != nil {
 			return nil, fmt.Errorf("harvesting artifacts: %w", err)
 		}
@@ -216,27 +560,79 @@ func (r *Runner) executeAndCache(ctx context.Context, task *Task, hash TaskHash)
 	}
 
 	// Store in cache
-	if err := r.Cache.Put(entry); err != nil {
-		return nil, fmt.Errorf("caching result: %w", err)
+	if task.IsCacheable() {
+		if err := r.Cache.Put(entry); err != nil {
+			return nil, fmt.Errorf("caching result: %w", err)
+		}
+		r.debug("cache store", task, hash, "exit_code", execResult.ExitCode, "artifacts", len(entry.Artifacts))
 	}
 
-	return &RunResult{
+	// Isolated: copy the declared outputs back before the scratch dir goes.
+	if r.Isolated && task.Succeeded(execResult.ExitCode) {
+		if _, err := r.Replayer.RestoreArtifacts(task.Name, entry); err != nil {
+			return nil, fmt.Errorf("copying outputs back: %w", err)
+		}
+	}
+
+	res := &RunResult{
 		Hash:              hash,
 		Stdout:            execResult.Stdout,
 		Stderr:            execResult.Stderr,
 		ExitCode:          execResult.ExitCode,
 		FromCache:         false,
 		ArtifactsRestored: 0,
-	}, nil
+		OutputTruncated:   execResult.OutputTruncated,
+	}
+	if err := applyExpectedOutputs(res, task, entry); err != nil {
+		return nil, err
+	}
+	if err := r.RecordEffect(task, res.ExitCode); err != nil {
+		return nil, fmt.Errorf("recording effect key: %w", err)
+	}
+	return res, nil
+}
+
+// verifyTools resolves task's tools and checks them against their
+// declarations.
+func (r *Runner) verifyTools(ctx context.Context, task *Task) ([]ToolMismatch, error) {
+	tools, err := r.ResolveTools(task)
+	if err != nil {
+		return nil, fmt.Errorf("resolving tools: %w", err)
+	}
+	return r.CheckTools(ctx, task, tools), nil
+}
+
+// ArtifactNormalizer returns the normalizer for task's artifacts: the
+// harvester's, followed by the task's normalize rules. It is nil when neither
+// normalizes.
+func (r *Runner) ArtifactNormalizer(task *Task) (OutputNormalizer, error) {
+	if len(task.Normalize) == 0 {
+		return r.Harvester.Normalizer, nil
+	}
+	return NewRuleNormalizer(task.Normalize, r.Harvester.Normalizer)
+}
+
+// NormalizeStreams applies StreamNormalizer and then task's normalize rules
+// to captured stdout and stderr. Without a StreamNormalizer they are returned
+// unchanged.
+func (r *Runner) NormalizeStreams(task *Task, stdout, stderr []byte) ([]byte, []byte, error) {
+	if r.StreamNormalizer == nil {
+		return stdout, stderr, nil
+	}
+	n, err := NewRuleNormalizer(task.Normalize, r.StreamNormalizer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return n.Normalize(stdout), n.Normalize(stderr), nil
 }
 
 // harvestArtifacts collects artifacts from declared outputs.
-func (r *Runner) harvestArtifacts(outputs []string) ([]CachedArtifact, error) {
+func harvestArtifacts(h *Harvester, outputs []string) ([]CachedArtifact, error) {
 	if len(outputs) == 0 {
 		return []CachedArtifact{}, nil
 	}
 
-	artifactSet, err := r.Harvester.Harvest(outputs)
+	artifactSet, err := h.Harvest(outputs)
 	if err != nil {
 		return nil, err
 	}
@@ -244,8 +640,11 @@ func (r *Runner) harvestArtifacts(outputs []string) ([]CachedArtifact, error) {
 	cached := make([]CachedArtifact, len(artifactSet.Artifacts))
 	for i, a := range artifactSet.Artifacts {
 		cached[i] = CachedArtifact{
-			Path:    a.Path,
-			Content: a.Content,
+			Path:       a.Path,
+			Content:    a.Content,
+			Mode:       a.Mode,
+			LinkTarget: a.LinkTarget,
+			source:     a.SourcePath,
 		}
 	}
 
@@ -263,9 +662,76 @@ func (r *Runner) CleanArtifacts(outputs []string) error {
 			fullPath = filepath.Join(r.WorkingDir, output)
 		}
 
+		// Globs remove every current match, so stale version-stamped files
+		// from earlier runs cannot be harvested.
+		matches := []string{fullPath}
+		if containsGlobChar(output) {
+			var err error
+			if matches, err = expandOutputPattern(fullPath); err != nil {
+				return fmt.Errorf("expanding %q: %w", output, err)
+			}
+		}
+
 		// Remove if exists (ignore if doesn't exist)
-		if err := os.RemoveAll(fullPath); err != nil {
-			return fmt.Errorf("removing %q: %w", output, err)
+		for _, m := range matches {
+			if err := os.RemoveAll(m); err != nil {
+				return fmt.Errorf("removing %q: %w", output, err)
+			}
+		}
+	}
+	return nil
+}
+
+// PruneOutputs removes everything under the declared outputs that entry does
+// not restore: files that are not artifacts, and directories holding none.
+// A nil entry, or one without artifacts (a failed task), removes them all.
+func (r *Runner) PruneOutputs(outputs []string, entry *CacheEntry) error {
+	keep := make(map[string]bool)
+	if entry != nil {
+		for _, a := range entry.Artifacts {
+			p := filepath.Join(r.WorkingDir, filepath.FromSlash(a.Path))
+			for ; !keep[p]; p = filepath.Dir(p) {
+				keep[p] = true
+				if p == r.WorkingDir || p == filepath.Dir(p) {
+					break
+				}
+			}
+		}
+	}
+	for _, output := range outputs {
+		fullPath := output
+		if !filepath.IsAbs(output) {
+			fullPath = filepath.Join(r.WorkingDir, output)
+		}
+		matches := []string{fullPath}
+		if containsGlobChar(output) {
+			var err error
+			if matches, err = expandOutputPattern(fullPath); err != nil {
+				return fmt.Errorf("expanding %q: %w", output, err)
+			}
+		}
+		for _, m := range matches {
+			err := filepath.WalkDir(m, func(p string, d os.DirEntry, err error) error {
+				if err != nil {
+					if os.IsNotExist(err) {
+						return nil
+					}
+					return err
+				}
+				if keep[p] {
+					return nil
+				}
+				if err := os.RemoveAll(p); err != nil {
+					return err
+				}
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("pruning %q: %w", output, err)
+			}
 		}
 	}
 	return nil
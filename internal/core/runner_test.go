@@ -371,6 +371,48 @@ func TestRunner_CleanArtifacts(t *testing.T) {
 	}
 }
 
+// TestRunner_PruneOutputs verifies only cached artifacts survive under the
+// declared outputs, and a failed entry removes them entirely.
+func TestRunner_PruneOutputs(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, p := range []string{"dist/keep.txt", "dist/sub/extra.txt", "dist/extra.txt", "logs/a.log", "logs/b.log", "other.txt"} {
+		full := filepath.Join(tmpDir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(p), 0644); err != nil {
+			t.Fatalf("write %s: %v", p, err)
+		}
+	}
+
+	runner := NewRunner(tmpDir, NewMemoryCache())
+	entry := &CacheEntry{Artifacts: []CachedArtifact{{Path: "dist/keep.txt"}, {Path: "logs/a.log"}}}
+	if err := runner.PruneOutputs([]string{"dist", "logs/*.log"}, entry); err != nil {
+		t.Fatalf("PruneOutputs failed: %v", err)
+	}
+	for p, want := range map[string]bool{
+		"dist/keep.txt":      true,
+		"dist/sub":           false,
+		"dist/extra.txt":     false,
+		"logs/a.log":         true,
+		"logs/b.log":         false,
+		"other.txt":          true,
+		"dist/sub/extra.txt": false,
+	} {
+		_, err := os.Stat(filepath.Join(tmpDir, filepath.FromSlash(p)))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s: exists=%v, want %v", p, exists, want)
+		}
+	}
+
+	if err := runner.PruneOutputs([]string{"dist"}, &CacheEntry{ExitCode: 1}); err != nil {
+		t.Fatalf("PruneOutputs failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "dist")); !os.IsNotExist(err) {
+		t.Error("dist should be removed for an entry without artifacts")
+	}
+}
+
 // TestRunner_ReplayRestoresArtifacts verifies artifacts are restored on replay.
 func TestRunner_ReplayRestoresArtifacts(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "runner-test-*")
@@ -433,3 +475,75 @@ func TestRunner_ReplayRestoresArtifacts(t *testing.T) {
 		t.Errorf("hash mismatch: %s != %s", result1.Hash, result2.Hash)
 	}
 }
+
+func TestRunner_GlobOutputsCachedAndCleaned(t *testing.T) {
+	workDir := t.TempDir()
+	runner := NewRunner(workDir, NewMemoryCache())
+	task := &Task{Name: "pack", Run: "mkdir -p dist && printf pkg > dist/app-1.2.tar.gz", Outputs: []string{"dist/*.tar.gz"}}
+
+	if _, err := runner.Run(context.Background(), task); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "dist", "app-1.1.tar.gz"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.CleanArtifacts(task.Outputs); err != nil {
+		t.Fatalf("CleanArtifacts: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(workDir, "dist")); len(entries) != 0 {
+		t.Fatalf("expected glob matches removed, found %d entries", len(entries))
+	}
+
+	res, err := runner.Run(context.Background(), task)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !res.FromCache || res.ArtifactsRestored != 1 {
+		t.Fatalf("expected cached glob artifact restored, got %+v", res)
+	}
+	if b, err := os.ReadFile(filepath.Join(workDir, "dist", "app-1.2.tar.gz")); err != nil || string(b) != "pkg" {
+		t.Fatalf("unexpected restored content %q (err=%v)", b, err)
+	}
+}
+
+// TestRunner_StreamNormalizerScrubsCachedStreams verifies stdout and stderr
+// pass through the stream normalizer and the task's rules before caching.
+func TestRunner_StreamNormalizerScrubsCachedStreams(t *testing.T) {
+	cache := NewMemoryCache()
+	runner := NewRunner(t.TempDir(), cache)
+	runner.StreamNormalizer = NewStreamNormalizer(NewDefaultNormalizer())
+	task := &Task{
+		Name:      "log",
+		Run:       `printf 'started 2024-12-13T10:30:45Z\r\nrequest req-42\n'; echo "pid 4242" >&2`,
+		Normalize: []NormalizeRule{{Pattern: `req-[0-9]+`, Replacement: "<REQ>"}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := runner.Run(ctx, task)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got, want := string(res.Stdout), "started <TIMESTAMP>\nrequest <REQ>\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if got, want := string(res.Stderr), "pid <PID>\n"; got != want {
+		t.Errorf("stderr = %q, want %q", got, want)
+	}
+	entry, err := cache.Get(res.Hash)
+	if err != nil || entry == nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !bytes.Equal(entry.Stdout, res.Stdout) || !bytes.Equal(entry.Stderr, res.Stderr) {
+		t.Errorf("cached streams differ from reported ones: %q %q", entry.Stdout, entry.Stderr)
+	}
+
+	raw := NewRunner(t.TempDir(), NewMemoryCache())
+	res, err = raw.Run(ctx, task)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !bytes.Contains(res.Stdout, []byte("req-42")) {
+		t.Errorf("without a stream normalizer stdout must stay raw, got %q", res.Stdout)
+	}
+}
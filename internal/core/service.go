@@ -0,0 +1,184 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// Readiness polling defaults for ServiceConfig.
+const (
+	DefaultReadyAttempts       = 50
+	DefaultReadyIntervalMillis = 100
+)
+
+// serviceStopGrace is how long a service gets to exit after SIGTERM before
+// its process group is killed.
+const serviceStopGrace = 5 * time.Second
+
+// ServiceConfig configures a KindService task.
+type ServiceConfig struct {
+	// Ready is the readiness command, run with "sh -c" in the working
+	// directory and the task's Env until it exits 0. Its output from the
+	// successful attempt is the task's output, so it should be deterministic.
+	Ready string `json:"ready" yaml:"ready"`
+
+	// ReadyAttempts bounds how many times Ready is run before the service is
+	// considered failed (DefaultReadyAttempts when zero).
+	ReadyAttempts int `json:"ready_attempts,omitempty" yaml:"ready_attempts,omitempty"`
+
+	// ReadyIntervalMillis is the pause between readiness attempts
+	// (DefaultReadyIntervalMillis when zero).
+	ReadyIntervalMillis int `json:"ready_interval_ms,omitempty" yaml:"ready_interval_ms,omitempty"`
+}
+
+// ValidateService checks that a KindService task declares a readiness
+// command and nothing a service cannot honour, and that only service tasks
+// carry service settings.
+func (t *Task) ValidateService() error {
+	if t.NormalizedKind() != KindService {
+		if t.Service != nil {
+			return fmt.Errorf("service settings require kind %q", KindService)
+		}
+		return nil
+	}
+	switch {
+	case t.Service == nil || t.Service.Ready == "":
+		return fmt.Errorf("service task must declare a readiness command")
+	case t.Service.ReadyAttempts < 0:
+		return fmt.Errorf("service ready_attempts must not be negative")
+	case t.Service.ReadyIntervalMillis < 0:
+		return fmt.Errorf("service ready_interval_ms must not be negative")
+	case len(t.Outputs) > 0:
+		return fmt.Errorf("service task must not declare outputs; services are not cached")
+	case t.Image != "":
+		return fmt.Errorf("service task must not declare an image")
+	case t.EffectKey != "":
+		return fmt.Errorf("service task must not declare an effect_key")
+	case t.Stdin != nil:
+		return fmt.Errorf("service task must not declare stdin")
+	case len(t.Tools) > 0:
+		return fmt.Errorf("service task must not declare tools")
+	case slices.ContainsFunc(t.Inputs, IsRemoteInput):
+		return fmt.Errorf("service task must not declare remote inputs")
+	}
+	return nil
+}
+
+// Service is a running KindService process started by StartService.
+type Service struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+	err  error
+}
+
+// StartService starts task.Run in its own process group with the task's
+// isolated environment, then runs the readiness command until it succeeds.
+// Services have no task hash, so TZ and LC_ALL are pinned but SeedEnvVar and
+// SourceDateEpochEnvVar are not (see withPinnedEnv).
+//
+// On success the service is left running and the successful readiness
+// attempt is returned as the result. If the service exits first or never
+// becomes ready, it is stopped and the result carries the failure: the
+// service's exit code (1 if it exited cleanly) or the last readiness
+// attempt's. The service's own stdout and stderr are discarded.
+func (e *Executor) StartService(ctx context.Context, task *Task) (*Service, *ExecutionResult, error) {
+	if task == nil {
+		return nil, nil, fmt.Errorf("task is nil")
+	}
+	if task.Run == "" {
+		return nil, nil, fmt.Errorf("task.Run is empty")
+	}
+	if err := task.ValidateService(); err != nil {
+		return nil, nil, fmt.Errorf("task %q: %w", task.Name, err)
+	}
+	task = withPinnedEnv(expandPaths(task, e.WorkingDir, e.OutputDir), "")
+
+	cmd := exec.Command("sh", "-c", task.Run)
+	cmd.Dir = e.WorkingDir
+	cmd.Env = buildIsolatedEnv(task.Env)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to start service: %w", err)
+	}
+	svc := &Service{cmd: cmd, done: make(chan struct{})}
+	go func() {
+		svc.err = cmd.Wait()
+		close(svc.done)
+	}()
+
+	attempts := task.Service.ReadyAttempts
+	if attempts == 0 {
+		attempts = DefaultReadyAttempts
+	}
+	interval := time.Duration(task.Service.ReadyIntervalMillis) * time.Millisecond
+	if task.Service.ReadyIntervalMillis == 0 {
+		interval = DefaultReadyIntervalMillis * time.Millisecond
+	}
+	probe := &Task{Name: task.Name, Run: task.Service.Ready, Env: task.Env, MaxOutputBytes: task.MaxOutputBytes}
+
+	var last *ExecutionResult
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				_ = svc.Stop()
+				return nil, nil, fmt.Errorf("execution cancelled: %w", ctx.Err())
+			case <-svc.done:
+			case <-time.After(interval):
+			}
+		}
+		select {
+		case <-svc.done:
+			return nil, svc.exitedResult(), nil
+		default:
+		}
+		res, err := e.Execute(ctx, probe, "")
+		if err != nil {
+			_ = svc.Stop()
+			return nil, nil, fmt.Errorf("readiness check: %w", err)
+		}
+		if res.ExitCode == 0 {
+			return svc, res, nil
+		}
+		last = res
+	}
+	_ = svc.Stop()
+	last.Stderr = append(last.Stderr, []byte(fmt.Sprintf("service not ready after %d attempts\n", attempts))...)
+	return nil, last, nil
+}
+
+// exitedResult is the failed result of a service that exited before it was
+// ready.
+func (s *Service) exitedResult() *ExecutionResult {
+	code := 1
+	if exitErr, ok := s.err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 {
+		code = exitErr.ExitCode()
+	}
+	return &ExecutionResult{Stderr: []byte("service exited before becoming ready\n"), ExitCode: code}
+}
+
+// Stop tears the service down: its process group gets SIGTERM, then SIGKILL
+// if it has not exited within a grace period. Stopping an exited service is
+// a no-op.
+func (s *Service) Stop() error {
+	select {
+	case <-s.done:
+		return nil
+	default:
+	}
+	pgid := -s.cmd.Process.Pid
+	if err := syscall.Kill(pgid, syscall.SIGTERM); err != nil && err != syscall.ESRCH {
+		return fmt.Errorf("signalling service: %w", err)
+	}
+	select {
+	case <-s.done:
+	case <-time.After(serviceStopGrace):
+		_ = syscall.Kill(pgid, syscall.SIGKILL)
+		<-s.done
+	}
+	return nil
+}
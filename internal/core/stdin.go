@@ -0,0 +1,59 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Stdin is piped into a task's process, so a task like `psql < schema.sql`
+// needs no shell redirection. Exactly one of Text and File is set.
+type Stdin struct {
+	// Text is piped in literally.
+	Text string `json:"text,omitempty" yaml:"text,omitempty"`
+
+	// File is piped in by content. It must be one of the task's declared
+	// inputs, written the same way, so its content is part of the task hash.
+	// Relative paths are resolved against the directory the task executes in.
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+}
+
+// ValidateStdin checks that the task's Stdin, if any, sets exactly one of
+// Text and File, and that File is a declared input.
+func (t *Task) ValidateStdin() error {
+	s := t.Stdin
+	if s == nil {
+		return nil
+	}
+	if (s.Text == "") == (s.File == "") {
+		return fmt.Errorf("stdin must set exactly one of text or file")
+	}
+	if s.File == "" {
+		return nil
+	}
+	for _, in := range t.Inputs {
+		if in == s.File {
+			return nil
+		}
+	}
+	return fmt.Errorf("stdin.file %q is not a declared input", s.File)
+}
+
+// open returns the reader to pipe into a task executing in dir, and a
+// function releasing it.
+func (s *Stdin) open(dir string) (io.Reader, func(), error) {
+	if s.File == "" {
+		return strings.NewReader(s.Text), func() {}, nil
+	}
+	path := filepath.FromSlash(s.File)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening stdin: %w", err)
+	}
+	return f, func() { f.Close() }, nil
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExecute_PipesStdin(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "schema.sql"), []byte("create table t;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := `while read -r l; do echo "got $l"; done`
+	cases := map[string]struct {
+		stdin *Stdin
+		want  string
+	}{
+		"none": {nil, ""},
+		"text": {&Stdin{Text: "a\nb\n"}, "got a\ngot b\n"},
+		"file": {&Stdin{File: "schema.sql"}, "got create table t;\n"},
+	}
+	for name, tc := range cases {
+		res, err := NewExecutor(dir).Execute(context.Background(), &Task{Name: name, Run: run, Stdin: tc.stdin}, "h")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if string(res.Stdout) != tc.want {
+			t.Errorf("%s: stdout = %q, want %q", name, res.Stdout, tc.want)
+		}
+	}
+}
+
+func TestValidateStdin(t *testing.T) {
+	cases := map[string]struct {
+		task Task
+		ok   bool
+	}{
+		"text":             {Task{Stdin: &Stdin{Text: "x"}}, true},
+		"declared file":    {Task{Inputs: []string{"in.sql"}, Stdin: &Stdin{File: "in.sql"}}, true},
+		"undeclared file":  {Task{Stdin: &Stdin{File: "in.sql"}}, false},
+		"both":             {Task{Inputs: []string{"in.sql"}, Stdin: &Stdin{Text: "x", File: "in.sql"}}, false},
+		"neither":          {Task{Stdin: &Stdin{}}, false},
+		"stdin on service": {Task{Kind: KindService, Service: &ServiceConfig{Ready: "true"}, Stdin: &Stdin{Text: "x"}}, false},
+	}
+	for name, tc := range cases {
+		err := tc.task.ValidateStdin()
+		if err == nil {
+			err = tc.task.ValidateService()
+		}
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v, want ok=%v", name, err, tc.ok)
+		}
+	}
+}
+
+func TestComputeHash_StdinAffectsHash(t *testing.T) {
+	hasher := NewTaskHasher()
+	base := HashInput{Command: "psql", WorkingDir: "/work"}
+	text := base
+	text.Stdin = &Stdin{Text: "select 1;"}
+	file := base
+	file.Stdin = &Stdin{File: "select 1;"}
+
+	if hasher.ComputeHash(text) == hasher.ComputeHash(base) {
+		t.Error("stdin must change the hash")
+	}
+	if hasher.ComputeHash(text) == hasher.ComputeHash(file) {
+		t.Error("a literal and a file path must hash differently")
+	}
+}
+
+func TestRunner_StdinFileContentIsHashed(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "in.txt"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runner := NewRunner(dir, NewMemoryCache())
+	task := &Task{Name: "t", Inputs: []string{"in.txt"}, Run: `read -r l; echo "$l"`, Stdin: &Stdin{File: "in.txt"}}
+
+	write("one\n")
+	for i, wantCached := range []bool{false, true} {
+		res, err := runner.Run(context.Background(), task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.FromCache != wantCached || string(res.Stdout) != "one\n" {
+			t.Fatalf("run %d: cached=%v stdout=%q", i, res.FromCache, res.Stdout)
+		}
+	}
+	write("two\n")
+	res, err := runner.Run(context.Background(), task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FromCache || string(res.Stdout) != "two\n" {
+		t.Fatalf("after change: cached=%v stdout=%q", res.FromCache, res.Stdout)
+	}
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DefaultStreamThreshold is the artifact size at or above which NewHarvester
+// and NewFileCache leave content on disk and stream it on demand instead of
+// buffering it in memory.
+const DefaultStreamThreshold int64 = 64 << 20
+
+// Open returns a reader over the artifact's content, whether buffered in
+// Content or left on disk at SourcePath.
+func (a Artifact) Open() (io.ReadCloser, error) {
+	if a.SourcePath != "" && a.Content == nil {
+		return os.Open(a.SourcePath)
+	}
+	return io.NopCloser(bytes.NewReader(a.Content)), nil
+}
+
+// Streamed reports whether the artifact's content is not in memory and must be
+// read with Open.
+func (a CachedArtifact) Streamed() bool {
+	return a.Content == nil && a.source != ""
+}
+
+// Open returns a reader over the artifact's (uncompressed) content.
+//
+// For streamed artifacts the content is read from disk in chunks: from the
+// harvested output file before Put, or from the cache blob after Get. Blob
+// digests are not checked here; consumers compare against SHA256 as they copy.
+func (a CachedArtifact) Open() (io.ReadCloser, error) {
+	if !a.Streamed() {
+		if a.Content == nil {
+			return nil, fmt.Errorf("artifact %q has no content", a.Path)
+		}
+		return io.NopCloser(bytes.NewReader(a.Content)), nil
+	}
+	f, err := os.Open(a.source)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newBlobReader(a.Compression, f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &stackedReadCloser{Reader: r, closers: []io.Closer{r, f}}, nil
+}
+
+// readAll materializes the artifact's content.
+func (a CachedArtifact) readAll() ([]byte, error) {
+	if !a.Streamed() {
+		return a.Content, nil
+	}
+	rc, err := a.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+type stackedReadCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (s *stackedReadCloser) Close() error {
+	var first error
+	for _, c := range s.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// hashAndCopy copies src to dst in chunks and returns the hex SHA-256 of the
+// bytes copied.
+func hashAndCopy(dst io.Writer, src io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(dst, h), src); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeStreamAtomic streams src into path via a temp file in the same
+// directory, encoding with codec, and returns the digest of the unencoded
+// bytes. If want is non-empty and does not match, path is left untouched and
+// errDigestMismatch is returned.
+func writeStreamAtomic(path, codec string, src io.Reader, perm os.FileMode, want string) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp.*")
+	if err != nil {
+		return "", err
+	}
+	tmpName := tmp.Name()
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+	}()
+
+	w, err := newBlobWriter(codec, tmp)
+	if err != nil {
+		return "", err
+	}
+	sum, err := hashAndCopy(w, src)
+	if err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	if want != "" && sum != want {
+		return sum, errDigestMismatch
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return "", err
+	}
+	_ = tmp.Sync() // best-effort durability
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	return sum, os.Rename(tmpName, path)
+}
+
+var errDigestMismatch = errors.New("content digest mismatch")
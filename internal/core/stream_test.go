@@ -0,0 +1,118 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHarvester_StreamsLargeFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "big.bin"), strings.Repeat("x", 64))
+	writeTestFile(t, filepath.Join(dir, "small.txt"), "s")
+
+	h := NewHarvester(dir)
+	h.StreamThreshold = 32
+	set, err := h.Harvest([]string{"big.bin", "small.txt"})
+	if err != nil {
+		t.Fatalf("Harvest: %v", err)
+	}
+	big, small := set.Artifacts[0], set.Artifacts[1]
+	if big.Content != nil || big.SourcePath != filepath.Join(dir, "big.bin") {
+		t.Fatalf("expected big.bin streamed, got %+v", big)
+	}
+	if string(small.Content) != "s" || small.SourcePath != "" {
+		t.Fatalf("expected small.txt buffered, got %+v", small)
+	}
+
+	// A normalizer needs the whole content, so nothing is streamed.
+	h.Normalizer = NewDefaultNormalizer()
+	set, err = h.Harvest([]string{"big.bin"})
+	if err != nil {
+		t.Fatalf("Harvest: %v", err)
+	}
+	if set.Artifacts[0].SourcePath != "" || len(set.Artifacts[0].Content) != 64 {
+		t.Fatalf("expected buffered artifact with normalizer, got %+v", set.Artifacts[0])
+	}
+}
+
+func TestRunner_StreamedArtifactsRoundTrip(t *testing.T) {
+	for _, codec := range []string{CompressionNone, CompressionGzip} {
+		t.Run("codec="+codec, func(t *testing.T) {
+			workDir := t.TempDir()
+			cache := NewFileCache(t.TempDir())
+			cache.Compression = codec
+			cache.StreamThreshold = 1
+			runner := NewRunner(workDir, cache)
+			runner.Harvester.StreamThreshold = 1
+			task := &Task{Name: "gen", Run: "head -c 200000 /dev/urandom > out.bin", Outputs: []string{"out.bin"}}
+
+			first, err := runner.Run(context.Background(), task)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			want, err := os.ReadFile(filepath.Join(workDir, "out.bin"))
+			if err != nil {
+				t.Fatalf("read output: %v", err)
+			}
+			if err := cache.Verify(first.Hash); err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+
+			entry, err := cache.Get(first.Hash)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if !entry.Artifacts[0].Streamed() {
+				t.Fatalf("expected Get to leave the blob on disk")
+			}
+
+			if err := os.Remove(filepath.Join(workDir, "out.bin")); err != nil {
+				t.Fatal(err)
+			}
+			second, err := runner.Run(context.Background(), task)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			got, err := os.ReadFile(filepath.Join(workDir, "out.bin"))
+			if err != nil {
+				t.Fatalf("read restored output: %v", err)
+			}
+			if !second.FromCache || second.ArtifactsRestored != 1 || !bytes.Equal(got, want) {
+				t.Fatalf("expected bit-for-bit restore from cache, got %+v", second)
+			}
+		})
+	}
+}
+
+func TestReplayer_StreamedArtifactDigestMismatch(t *testing.T) {
+	workDir := t.TempDir()
+	cache := NewFileCache(t.TempDir())
+	cache.StreamThreshold = 1
+	runner := NewRunner(workDir, cache)
+	task := &Task{Name: "gen", Run: "printf original > out.txt", Outputs: []string{"out.txt"}}
+
+	first, err := runner.Run(context.Background(), task)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	writeTestFile(t, filepath.Join(cache.entryPath(first.Hash), "artifacts", "0.blob"), "tampered")
+	writeTestFile(t, filepath.Join(workDir, "out.txt"), "local edit")
+
+	if _, err := runner.Run(context.Background(), task); !IsCacheCorrupt(err) {
+		t.Fatalf("expected CacheCorruptError, got %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(workDir, "out.txt"))
+	if err != nil || string(got) != "local edit" {
+		t.Fatalf("target must be untouched on digest mismatch, got %q (err=%v)", got, err)
+	}
+
+	runner.HealCorruptEntries = true
+	res, err := runner.Run(context.Background(), task)
+	if err != nil || !res.CacheCorrupt {
+		t.Fatalf("expected healed re-execution, got %+v (err=%v)", res, err)
+	}
+}
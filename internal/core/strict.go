@@ -0,0 +1,137 @@
+package core
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// UndeclaredOutputsExitCode is the exit code reported for a task that ran
+// successfully but wrote outside its declared outputs under StrictOutputs.
+const UndeclaredOutputsExitCode = 97
+
+// fileStamp is the observable state of one working-tree entry. Any change to
+// it between snapshots counts as a write.
+type fileStamp struct {
+	mode    fs.FileMode
+	size    int64
+	modTime int64
+	link    string
+}
+
+// treeSnapshot maps slash-separated paths relative to the snapshot root to
+// their stamps. Directories are not recorded; only files and symlinks are.
+type treeSnapshot map[string]fileStamp
+
+// snapshotTree records every file and symlink under root, skipping the
+// entries in ignore (slash-separated, relative to root) and everything below
+// them.
+func snapshotTree(root string, ignore []string) (treeSnapshot, error) {
+	snap := make(treeSnapshot)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "." && coveredBy(rel, ignore) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		st := fileStamp{mode: info.Mode(), size: info.Size(), modTime: info.ModTime().UnixNano()}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if st.link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		snap[rel] = st
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("snapshotting %s: %w", root, err)
+	}
+	return snap, nil
+}
+
+// changedPaths returns the sorted paths created, modified or deleted between
+// before and after.
+func changedPaths(before, after treeSnapshot) []string {
+	var changed []string
+	for p, st := range after {
+		if prev, ok := before[p]; !ok || prev != st {
+			changed = append(changed, p)
+		}
+	}
+	for p := range before {
+		if _, ok := after[p]; !ok {
+			changed = append(changed, p)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// strictIgnoreList resolves the runner's StrictIgnore entries against root.
+// Entries outside root are dropped (they cannot appear in a snapshot).
+func strictIgnoreList(root string, ignore []string) []string {
+	out := make([]string, 0, len(ignore))
+	for _, p := range ignore {
+		if filepath.IsAbs(p) {
+			rel, err := filepath.Rel(root, p)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			p = rel
+		}
+		out = append(out, filepath.ToSlash(filepath.Clean(p)))
+	}
+	return out
+}
+
+// undeclaredWrites filters changed down to the paths no declared output
+// covers. Outputs are matched like VerifyDeclaredOutputs matches artifacts.
+func undeclaredWrites(changed, outputs []string, root string) []string {
+	covering := make([]string, 0, len(outputs))
+	for _, o := range ManifestOutputs(outputs) {
+		if filepath.IsAbs(filepath.FromSlash(o)) {
+			if rel, err := filepath.Rel(root, filepath.FromSlash(o)); err == nil {
+				o = filepath.ToSlash(rel)
+			}
+		}
+		covering = append(covering, o)
+	}
+	var out []string
+	for _, p := range changed {
+		if !coveredBy(p, covering) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// undeclaredOutputsReport is appended to a strict-mode violator's stderr.
+func undeclaredOutputsReport(paths []string) []byte {
+	var b strings.Builder
+	b.WriteString("scriptweaver: task wrote outside its declared outputs:\n")
+	for _, p := range paths {
+		b.WriteString("  ")
+		b.WriteString(p)
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
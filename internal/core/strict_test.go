@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunner_StrictOutputs(t *testing.T) {
+	newRunner := func(t *testing.T) (*Runner, *MemoryCache, string) {
+		t.Helper()
+		workDir := t.TempDir()
+		writeTestFile(t, filepath.Join(workDir, "victim.txt"), "keep")
+		if err := os.MkdirAll(filepath.Join(workDir, "cache"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		cache := NewMemoryCache()
+		runner := NewRunner(workDir, cache)
+		runner.StrictOutputs = true
+		runner.StrictIgnore = []string{filepath.Join(workDir, "cache")}
+		return runner, cache, workDir
+	}
+
+	t.Run("violation fails and is not cached", func(t *testing.T) {
+		runner, cache, _ := newRunner(t)
+		task := &Task{
+			Name:    "leaky",
+			Run:     "mkdir -p dist && echo ok > dist/app && echo x > stray.log && rm victim.txt && echo c > cache/tmp",
+			Outputs: []string{"dist"},
+		}
+		res, err := runner.Run(context.Background(), task)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if res.ExitCode != UndeclaredOutputsExitCode {
+			t.Fatalf("expected exit %d, got %d", UndeclaredOutputsExitCode, res.ExitCode)
+		}
+		if want := []string{"stray.log", "victim.txt"}; !reflect.DeepEqual(res.UndeclaredOutputs, want) {
+			t.Fatalf("expected %v, got %v", want, res.UndeclaredOutputs)
+		}
+		if !strings.Contains(string(res.Stderr), "stray.log") {
+			t.Fatalf("expected offending paths in stderr, got %q", res.Stderr)
+		}
+		if ok, _ := cache.Has(res.Hash); ok {
+			t.Fatalf("violating result must not be cached")
+		}
+	})
+
+	t.Run("declared outputs only", func(t *testing.T) {
+		runner, cache, _ := newRunner(t)
+		task := &Task{Name: "clean", Run: "mkdir -p dist && echo ok > dist/app-1.0.tgz", Outputs: []string{"dist/*.tgz"}}
+		res, err := runner.Run(context.Background(), task)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if res.ExitCode != 0 || len(res.UndeclaredOutputs) != 0 {
+			t.Fatalf("expected success, got exit=%d undeclared=%v", res.ExitCode, res.UndeclaredOutputs)
+		}
+		if ok, _ := cache.Has(res.Hash); !ok {
+			t.Fatalf("expected compliant result cached")
+		}
+	})
+}
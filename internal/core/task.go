@@ -31,7 +31,8 @@ type Task struct {
 	Inputs []string `json:"inputs" yaml:"inputs"`
 
 	// Run is the command string to execute.
-	// Interpreted exactly as provided.
+	// Interpreted exactly as provided, apart from the placeholders
+	// described at TaskPlaceholder.
 	Run string `json:"run" yaml:"run"`
 
 	// Env is a map of environment variables explicitly provided to the task.
@@ -39,8 +40,144 @@ type Task struct {
 	// Optional field.
 	Env map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
 
-	// Outputs is a list of file paths or directories expected to be produced.
+	// Outputs is a list of file paths, directories or glob patterns (e.g.
+	// "dist/*.tar.gz") expected to be produced. Patterns are expanded and
+	// sorted at harvest time and contribute to the task hash by their text.
 	// Only declared outputs are eligible for artifact capture and caching.
 	// Optional field.
 	Outputs []string `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+
+	// Image is an optional container image to run the task in (see
+	// ContainerConfig). It is part of the task hash by its text, so pin it by
+	// digest (e.g. "alpine@sha256:...") for reproducible results.
+	// Optional field.
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+
+	// Kind selects the TaskRunner that executes the task when a graph mixes
+	// runners (see dag.RunnerRegistry). Empty is equivalent to KindShell.
+	// Kinds other than KindShell are part of the task hash.
+	// Optional field.
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
+
+	// Network is NetworkNone to run the task without network access, or
+	// NetworkAllowed to let it reach the network. Empty keeps the default:
+	// host tasks have the network and container tasks use the configured
+	// container network. Host tasks are isolated in a network namespace on
+	// Linux only. It is part of the task hash when set.
+	// Optional field.
+	Network string `json:"network,omitempty" yaml:"network,omitempty"`
+
+	// ExpectedOutputs maps artifact paths (relative to the working directory,
+	// slash-separated, under a declared output) to their expected SHA-256.
+	// After the task executes or is restored, a mismatching or missing
+	// artifact fails it with ExpectedOutputsExitCode. Expectations check
+	// results rather than define them, so they are not part of the task hash.
+	// Optional field.
+	ExpectedOutputs map[string]string `json:"expected_outputs,omitempty" yaml:"expected_outputs,omitempty"`
+
+	// Normalize lists rules applied, in order, to the task's artifacts before
+	// they are cached, after the runner's own normalizer. They change what is
+	// cached, so they are part of the task hash.
+	// Optional field.
+	Normalize []NormalizeRule `json:"normalize,omitempty" yaml:"normalize,omitempty"`
+
+	// MaxOutputBytes caps the bytes kept from each of stdout and stderr,
+	// overriding Executor.OutputLimit; output past it is replaced by a
+	// truncation marker. It changes what is cached, so it is part of the task
+	// hash when set.
+	// Optional field.
+	MaxOutputBytes int64 `json:"max_output_bytes,omitempty" yaml:"max_output_bytes,omitempty"`
+
+	// SuccessExitCodes lists non-zero exit codes that also count as success,
+	// e.g. 1 for a grep-style check whose "no match" is not an error. A task
+	// exiting with one is harvested, cached and unblocks its dependents like
+	// exit code 0, and the code itself is kept in its result and trace. They
+	// are part of the task hash when set.
+	// Optional field.
+	SuccessExitCodes []int `json:"success_exit_codes,omitempty" yaml:"success_exit_codes,omitempty"`
+
+	// AllowFailure marks a best-effort task (e.g. an optional lint step): if
+	// it fails, its dependents still run and the run's exit code is
+	// unaffected, but the failure is cached, checkpointed and traced (reason
+	// AllowedFailure) as usual. It is part of the graph hash, not the task
+	// hash.
+	// Optional field.
+	AllowFailure bool `json:"allow_failure,omitempty" yaml:"allow_failure,omitempty"`
+
+	// Cacheable set to false opts the task out of the cache: it is never
+	// looked up or stored and always executes (trace reason NotCacheable),
+	// for inherently nondeterministic or side-effecting work such as a
+	// deployment. Nil means true. It is part of the task hash when false.
+	// Optional field.
+	Cacheable *bool `json:"cacheable,omitempty" yaml:"cacheable,omitempty"`
+
+	// EffectKey names the external side effect of the task (e.g.
+	// "deploy-prod-v1.2.3"). Once the task executes successfully, the key is
+	// recorded (see EffectLedger), and later executions with the same key
+	// are skipped (trace reason EffectApplied) instead of applying the
+	// effect twice. Cache replays neither check nor record it. It is part of
+	// the graph hash, not the task hash.
+	// Optional field.
+	EffectKey string `json:"effect_key,omitempty" yaml:"effect_key,omitempty"`
+
+	// Stdin is piped into the task's process (see Stdin). It is part of the
+	// task hash when set: a literal by its text, a file by its path (its
+	// content is hashed as a declared input).
+	// Optional field.
+	Stdin *Stdin `json:"stdin,omitempty" yaml:"stdin,omitempty"`
+
+	// Tools lists the binaries the task requires (see Tool). Before the task
+	// executes, each is resolved from the declared PATH and checked against
+	// its declared version and digest; a mismatch fails the task with
+	// ToolMismatchExitCode without executing it. The declarations and the
+	// resolved binaries' digests are part of the task hash when set.
+	// Optional field.
+	Tools []Tool `json:"tools,omitempty" yaml:"tools,omitempty"`
+
+	// Group names the stage the task belongs to. Group barriers are expanded
+	// into edges when the graph is loaded, so the label itself only shows up
+	// in traces and summaries and is not part of the task hash.
+	// Optional field.
+	Group string `json:"group,omitempty" yaml:"group,omitempty"`
+
+	// When gates the task on a Condition evaluated when it is ready to run;
+	// if it is false the task is skipped (reason ConditionFalse) along with
+	// everything downstream of it. It is part of the graph hash, not the
+	// task hash, since it decides whether the task runs, not what it makes.
+	// Optional field.
+	When *Condition `json:"when,omitempty" yaml:"when,omitempty"`
+
+	// Service configures a KindService task's readiness check. Services are
+	// never cached, so it is part of the graph hash, not the task hash.
+	// Required for, and only allowed on, KindService tasks.
+	Service *ServiceConfig `json:"service,omitempty" yaml:"service,omitempty"`
+}
+
+// Built-in task kinds.
+const (
+	// KindShell runs Task.Run with "sh -c" on the host.
+	KindShell = "shell"
+
+	// KindContainer runs Task.Run inside Task.Image (see ContainerConfig).
+	KindContainer = "container"
+
+	// KindService starts Task.Run as a long-lived background process (e.g. a
+	// database for integration tests) that stays up while its dependents run
+	// and is torn down after the graph finishes (see ServiceConfig).
+	KindService = "service"
+)
+
+// IsCacheable reports whether the task's results may be cached: Cacheable
+// is unset or true.
+func (t *Task) IsCacheable() bool {
+	return t.Cacheable == nil || *t.Cacheable
+}
+
+// NormalizedKind returns the task's kind with the empty default resolved to
+// KindShell.
+func (t *Task) NormalizedKind() string {
+	if t.Kind == "" {
+		return KindShell
+	}
+	return t.Kind
 }
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+)
+
+// Placeholders expanded in a task's Run and Env values. Graph params
+// ("${params.<name>}") and artifact references ("${<task>:<artifact>}") are
+// resolved by the graph loader; any other "${...}" is left to the shell.
+//
+// TaskPlaceholder is expanded when the graph is built (ExpandTaskName), so
+// the task's name enters its hash. WorkDirPlaceholder and
+// OutputDirPlaceholder are expanded only when the task executes: the hash
+// covers the placeholder, not the machine-specific path it stands for.
+//
+// Prefixing a placeholder with another "$" (e.g. "$${workdir}") escapes it
+// to the literal text.
+const (
+	TaskPlaceholder      = "${task}"
+	WorkDirPlaceholder   = "${workdir}"
+	OutputDirPlaceholder = "${output_dir}"
+)
+
+var templatePlaceholder = regexp.MustCompile(`\$?\$\{(task|workdir|output_dir)\}`)
+
+// expandTemplate replaces each placeholder named in values, and unescapes
+// its "$$" form. Placeholders not in values are left untouched.
+func expandTemplate(s string, values map[string]string) string {
+	return templatePlaceholder.ReplaceAllStringFunc(s, func(m string) string {
+		escaped := m[1] == '$'
+		if escaped {
+			m = m[1:]
+		}
+		v, ok := values[m[2:len(m)-1]]
+		switch {
+		case !ok:
+			if escaped {
+				return "$" + m
+			}
+			return m
+		case escaped:
+			return m
+		default:
+			return v
+		}
+	})
+}
+
+// expandTask returns a copy of task with values expanded in Run and Env, or
+// task itself when neither references a placeholder.
+func expandTask(task *Task, values map[string]string) *Task {
+	uses := templatePlaceholder.MatchString(task.Run)
+	for _, v := range task.Env {
+		uses = uses || templatePlaceholder.MatchString(v)
+	}
+	if !uses {
+		return task
+	}
+	t := *task
+	t.Run = expandTemplate(task.Run, values)
+	if task.Env != nil {
+		t.Env = make(map[string]string, len(task.Env))
+		for k, v := range task.Env {
+			t.Env[k] = expandTemplate(v, values)
+		}
+	}
+	return &t
+}
+
+// ExpandTaskName returns a copy of t with TaskPlaceholder expanded to its
+// name in Run and Env.
+func (t Task) ExpandTaskName() Task {
+	return *expandTask(&t, map[string]string{"task": t.Name})
+}
+
+// expandPaths expands WorkDirPlaceholder and OutputDirPlaceholder in task
+// for execution in workDir. Inside a container the working directory is
+// ContainerWorkDir, and an output dir under workDir is mapped beneath it.
+// OutputDirPlaceholder expands to "" when no output dir is configured.
+func expandPaths(task *Task, workDir, outputDir string) *Task {
+	if task.Image != "" {
+		if outputDir != "" {
+			if rel, err := filepath.Rel(workDir, outputDir); err == nil && WithinWorkDir(workDir, outputDir) {
+				outputDir = path.Join(ContainerWorkDir, filepath.ToSlash(rel))
+			}
+		}
+		workDir = ContainerWorkDir
+	}
+	return expandTask(task, map[string]string{"workdir": workDir, "output_dir": outputDir})
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	values := map[string]string{"workdir": "/src", "output_dir": "/src/out"}
+	for in, want := range map[string]string{
+		"cd ${workdir} && ls ${output_dir}": "cd /src && ls /src/out",
+		"echo $${workdir}":                  "echo ${workdir}",
+		"echo ${task} $${task}":             "echo ${task} $${task}",
+		"echo ${HOME} ${params.x} ${a:b}":   "echo ${HOME} ${params.x} ${a:b}",
+		"echo ${workdir}${workdir}":         "echo /src/src",
+	} {
+		if got := expandTemplate(in, values); got != want {
+			t.Errorf("expandTemplate(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTask_ExpandTaskName(t *testing.T) {
+	task := Task{Name: "build", Run: "echo ${task} > ${workdir}/${task}.txt", Env: map[string]string{"NAME": "${task}"}}
+	got := task.ExpandTaskName()
+	if got.Run != "echo build > ${workdir}/build.txt" || got.Env["NAME"] != "build" {
+		t.Fatalf("unexpected expansion: run=%q env=%v", got.Run, got.Env)
+	}
+	if task.Env["NAME"] != "${task}" {
+		t.Fatalf("ExpandTaskName modified the original task's env")
+	}
+}
+
+func TestExpandPaths_Container(t *testing.T) {
+	task := &Task{Name: "c", Image: "alpine:3", Run: "ls ${workdir} ${output_dir}"}
+	if got := expandPaths(task, "/src", "/src/dist").Run; got != "ls "+ContainerWorkDir+" "+ContainerWorkDir+"/dist" {
+		t.Fatalf("unexpected container expansion %q", got)
+	}
+}
+
+func TestRunner_PathPlaceholdersExpandAtExecution(t *testing.T) {
+	workDir := t.TempDir()
+	cache := NewMemoryCache()
+	task := &Task{
+		Name:    "write",
+		Run:     "printf %s \"$OUT\" > ${workdir}/out.txt",
+		Env:     map[string]string{"OUT": "${output_dir}/bin"},
+		Outputs: []string{"out.txt"},
+	}
+
+	var hashes []TaskHash
+	for _, outDir := range []string{"dist", "build"} {
+		runner := NewRunner(workDir, cache)
+		runner.Executor.OutputDir = filepath.Join(workDir, outDir)
+		res, err := runner.Run(context.Background(), task)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if res.ExitCode != 0 {
+			t.Fatalf("expected success, got exit %d: %s", res.ExitCode, res.Stderr)
+		}
+		hashes = append(hashes, res.Hash)
+	}
+	b, err := os.ReadFile(filepath.Join(workDir, "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The second run hits the cache: the hash covers the placeholder.
+	if string(b) != filepath.Join(workDir, "dist", "bin") {
+		t.Fatalf("expected expanded output dir, got %q", b)
+	}
+	if hashes[0] != hashes[1] {
+		t.Fatalf("hash depends on the output dir: %s vs %s", hashes[0], hashes[1])
+	}
+	if task.Run != "printf %s \"$OUT\" > ${workdir}/out.txt" {
+		t.Fatalf("Run modified the task: %q", task.Run)
+	}
+}
@@ -0,0 +1,216 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ToolMismatchExitCode is the exit code reported for a task whose declared
+// tools are missing or do not match their declarations. The task is not
+// executed.
+const ToolMismatchExitCode = 96
+
+// Tool is a binary a task requires, such as its compiler. Tools are resolved
+// from the task's declared PATH (Env["PATH"]), never the host's, and the
+// digest of each resolved binary is part of the task hash, so upgrading a
+// compiler invalidates the results it produced.
+type Tool struct {
+	// Name is the binary looked up on the declared PATH, or an absolute path.
+	Name string `json:"name" yaml:"name"`
+
+	// Version, when set, must occur in the output (stdout and stderr) of
+	// running the tool with VersionArgs, e.g. "go1.22.5".
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+
+	// VersionArgs are the arguments printing the version; nil means
+	// ["--version"].
+	VersionArgs []string `json:"version_args,omitempty" yaml:"version_args,omitempty"`
+
+	// SHA256, when set, is the lowercase hex digest the resolved binary must
+	// have.
+	SHA256 string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+}
+
+// ResolvedTool is a declared Tool and the binary it resolved to. Path and
+// Digest are empty when the tool was not found.
+type ResolvedTool struct {
+	Tool
+	Path   string
+	Digest string
+}
+
+// ToolMismatch is a declared tool that is missing or does not match its
+// declaration.
+type ToolMismatch struct {
+	Name   string
+	Reason string
+}
+
+// ValidateTools checks that every tool has a unique name that is a bare
+// binary name or an absolute path, that version arguments come with a
+// version, and that digests are lowercase hex SHA-256.
+func (t *Task) ValidateTools() error {
+	seen := make(map[string]bool, len(t.Tools))
+	for _, tool := range t.Tools {
+		switch {
+		case tool.Name == "":
+			return fmt.Errorf("tool name is required")
+		case seen[tool.Name]:
+			return fmt.Errorf("duplicate tool %q", tool.Name)
+		case strings.ContainsRune(tool.Name, '/') && !filepath.IsAbs(tool.Name):
+			return fmt.Errorf("tool %q must be a bare name or an absolute path", tool.Name)
+		case tool.VersionArgs != nil && tool.Version == "":
+			return fmt.Errorf("tool %q declares version_args without a version", tool.Name)
+		case tool.SHA256 != "" && !isHexDigest(tool.SHA256):
+			return fmt.Errorf("tool %q sha256 must be a lowercase hex SHA-256 digest", tool.Name)
+		}
+		seen[tool.Name] = true
+	}
+	if len(t.Tools) > 0 && t.Image != "" {
+		return fmt.Errorf("tools are resolved on the host and cannot be declared for a task with an image")
+	}
+	return nil
+}
+
+func isHexDigest(s string) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == sha256.Size && strings.ToLower(s) == s
+}
+
+// ResolveTools resolves task's tools, sorted by name, against its declared
+// PATH. Relative PATH entries are relative to the working directory. Digests
+// of unchanged binaries come from Resolver.Fingerprints when it is set.
+func (r *Runner) ResolveTools(task *Task) ([]ResolvedTool, error) {
+	if len(task.Tools) == 0 {
+		return nil, nil
+	}
+	resolved := make([]ResolvedTool, 0, len(task.Tools))
+	for _, tool := range task.Tools {
+		rt := ResolvedTool{Tool: tool, Path: lookTool(tool.Name, task.Env["PATH"], r.WorkingDir)}
+		if rt.Path != "" {
+			digest, err := r.toolDigest(rt.Path)
+			if err != nil {
+				return nil, fmt.Errorf("tool %s: %w", tool.Name, err)
+			}
+			rt.Digest = digest
+		}
+		resolved = append(resolved, rt)
+	}
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].Name < resolved[j].Name })
+	return resolved, nil
+}
+
+// lookTool returns the first executable regular file named name on pathList,
+// or name itself when it is absolute. It is empty if none is found.
+func lookTool(name, pathList, workDir string) string {
+	candidates := []string{name}
+	if !filepath.IsAbs(name) {
+		candidates = nil
+		if pathList == "" {
+			return ""
+		}
+		for _, dir := range filepath.SplitList(pathList) {
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(workDir, dir)
+			}
+			candidates = append(candidates, filepath.Join(dir, name))
+		}
+	}
+	for _, p := range candidates {
+		if info, err := os.Stat(p); err == nil && info.Mode().IsRegular() && info.Mode()&0o111 != 0 {
+			return filepath.Clean(p)
+		}
+	}
+	return ""
+}
+
+func (r *Runner) toolDigest(path string) (string, error) {
+	fingerprints := r.Resolver.Fingerprints
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if fingerprints != nil {
+		if digest, ok := fingerprints.Lookup(path, info); ok {
+			return digest, nil
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+	if fingerprints != nil {
+		fingerprints.Record(path, info, digest)
+	}
+	return digest, nil
+}
+
+// CheckTools verifies resolved tools against their declarations, running
+// each tool that declares a version with the task's declared environment.
+// The result is sorted by tool name.
+func (r *Runner) CheckTools(ctx context.Context, task *Task, tools []ResolvedTool) []ToolMismatch {
+	var mismatches []ToolMismatch
+	for _, tool := range tools {
+		if reason := r.checkTool(ctx, task, tool); reason != "" {
+			mismatches = append(mismatches, ToolMismatch{Name: tool.Name, Reason: reason})
+		}
+	}
+	return mismatches
+}
+
+func (r *Runner) checkTool(ctx context.Context, task *Task, tool ResolvedTool) string {
+	if tool.Path == "" {
+		if filepath.IsAbs(tool.Name) {
+			return "not found"
+		}
+		return fmt.Sprintf("not found on the declared PATH %q", task.Env["PATH"])
+	}
+	if tool.SHA256 != "" && tool.SHA256 != tool.Digest {
+		return fmt.Sprintf("%s has sha256 %s, expected %s", tool.Path, tool.Digest, tool.SHA256)
+	}
+	if tool.Version == "" {
+		return ""
+	}
+	args := tool.VersionArgs
+	if args == nil {
+		args = []string{"--version"}
+	}
+	cmd := exec.CommandContext(ctx, tool.Path, args...)
+	cmd.Dir = r.WorkingDir
+	cmd.Env = buildIsolatedEnv(task.Env)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Sprintf("%s %s failed: %v", tool.Path, strings.Join(args, " "), err)
+	}
+	if !bytes.Contains(out, []byte(tool.Version)) {
+		firstLine, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+		return fmt.Sprintf("version %q not found in the output of %s %s: %q", tool.Version, tool.Path, strings.Join(args, " "), firstLine)
+	}
+	return ""
+}
+
+// ToolMismatchReport is the stderr of a task whose tools do not match their
+// declarations.
+func ToolMismatchReport(mismatches []ToolMismatch) []byte {
+	var b strings.Builder
+	b.WriteString("scriptweaver: task tools do not match their declarations:\n")
+	for _, m := range mismatches {
+		fmt.Fprintf(&b, "  %s: %s\n", m.Name, m.Reason)
+	}
+	return []byte(b.String())
+}
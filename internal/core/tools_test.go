@@ -0,0 +1,129 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTool installs an executable script printing version into dir/bin.
+func writeTool(t *testing.T, dir, name, version string) string {
+	t.Helper()
+	bin := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(bin, name)
+	if err := os.WriteFile(p, []byte("#!/bin/sh\necho \""+name+" version "+version+"\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRunner_ToolsAreVerifiedAndHashed(t *testing.T) {
+	dir := t.TempDir()
+	writeTool(t, dir, "cc", "1.2.3")
+	runner := NewRunner(dir, NewMemoryCache())
+	task := &Task{Name: "build", Run: "cc", Env: map[string]string{"PATH": "bin:/bin:/usr/bin"}, Tools: []Tool{{Name: "cc", Version: "1.2.3"}}}
+
+	res, err := runner.Run(context.Background(), task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != 0 || string(res.Stdout) != "cc version 1.2.3\n" {
+		t.Fatalf("exit %d stdout %q stderr %q", res.ExitCode, res.Stdout, res.Stderr)
+	}
+	if res, _ := runner.Run(context.Background(), task); !res.FromCache {
+		t.Fatal("expected an unchanged toolchain to hit the cache")
+	}
+
+	// Upgrading the binary changes the hash; the new version no longer
+	// matches the declaration, so the task fails without executing.
+	writeTool(t, dir, "cc", "2.0.0")
+	res, err = runner.Run(context.Background(), task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FromCache || res.ExitCode != ToolMismatchExitCode || len(res.Stdout) != 0 {
+		t.Fatalf("cached=%v exit %d stdout %q", res.FromCache, res.ExitCode, res.Stdout)
+	}
+	if len(res.ToolMismatches) != 1 || !strings.Contains(string(res.Stderr), `version "1.2.3" not found`) {
+		t.Fatalf("mismatches %+v stderr %q", res.ToolMismatches, res.Stderr)
+	}
+	// The mismatch is not cached: it is reported again.
+	if res, _ := runner.Run(context.Background(), task); res.FromCache || res.ExitCode != ToolMismatchExitCode {
+		t.Fatalf("second mismatch: cached=%v exit %d", res.FromCache, res.ExitCode)
+	}
+}
+
+func TestRunner_ToolDigestAndMissingTool(t *testing.T) {
+	dir := t.TempDir()
+	p := writeTool(t, dir, "gen", "1")
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(b)
+	digest := hex.EncodeToString(sum[:])
+	runner := NewRunner(dir, NewMemoryCache())
+	env := map[string]string{"PATH": filepath.Join(dir, "bin")}
+
+	cases := map[string]struct {
+		tools []Tool
+		ok    bool
+		want  string
+	}{
+		"matching digest": {[]Tool{{Name: "gen", SHA256: digest}}, true, ""},
+		"absolute path":   {[]Tool{{Name: p}}, true, ""},
+		"other digest":    {[]Tool{{Name: "gen", SHA256: strings.Repeat("0", 64)}}, false, "expected " + strings.Repeat("0", 64)},
+		"missing":         {[]Tool{{Name: "ld"}}, false, "ld: not found on the declared PATH"},
+	}
+	for name, tc := range cases {
+		task := &Task{Name: name, Run: "true", Env: env, Tools: tc.tools}
+		res, err := runner.Run(context.Background(), task)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if (res.ExitCode == 0) != tc.ok || !strings.Contains(string(res.Stderr), tc.want) {
+			t.Errorf("%s: exit %d stderr %q", name, res.ExitCode, res.Stderr)
+		}
+	}
+
+	// A bare name is never looked up on the host PATH.
+	res, err := runner.Run(context.Background(), &Task{Name: "host", Run: "true", Tools: []Tool{{Name: "sh"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != ToolMismatchExitCode {
+		t.Fatalf("expected sh to be missing without a declared PATH, got exit %d", res.ExitCode)
+	}
+}
+
+func TestValidateTools(t *testing.T) {
+	cases := map[string]struct {
+		task Task
+		ok   bool
+	}{
+		"bare name":        {Task{Tools: []Tool{{Name: "go", Version: "go1.22"}}}, true},
+		"absolute":         {Task{Tools: []Tool{{Name: "/usr/bin/go"}}}, true},
+		"relative path":    {Task{Tools: []Tool{{Name: "bin/go"}}}, false},
+		"duplicate":        {Task{Tools: []Tool{{Name: "go"}, {Name: "go"}}}, false},
+		"args no version":  {Task{Tools: []Tool{{Name: "go", VersionArgs: []string{"version"}}}}, false},
+		"bad digest":       {Task{Tools: []Tool{{Name: "go", SHA256: "ABC"}}}, false},
+		"with image":       {Task{Image: "alpine", Tools: []Tool{{Name: "go"}}}, false},
+		"tools on service": {Task{Kind: KindService, Service: &ServiceConfig{Ready: "true"}, Tools: []Tool{{Name: "go"}}}, false},
+	}
+	for name, tc := range cases {
+		err := tc.task.ValidateTools()
+		if err == nil {
+			err = tc.task.ValidateService()
+		}
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v, want ok=%v", name, err, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrPathOutsideWorkDir indicates an input pattern, declared output or
+// artifact path that resolves outside the working directory.
+var ErrPathOutsideWorkDir = errors.New("path resolves outside the working directory")
+
+// WithinWorkDir reports whether p, relative to workDir unless absolute,
+// lies at or under workDir. The check is lexical: ".." segments are
+// resolved, symlinks are not followed.
+func WithinWorkDir(workDir, p string) bool {
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(workDir, p)
+	}
+	rel, err := filepath.Rel(filepath.Clean(workDir), filepath.Clean(p))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// LinkWithinWorkDir reports whether a symlink at linkPath, relative to
+// workDir unless absolute, pointing at linkTarget resolves at or under
+// workDir. Absolute targets are rejected outright: a cached link is restored
+// on other hosts, where an absolute path names something else. Like
+// WithinWorkDir, the check is lexical.
+func LinkWithinWorkDir(workDir, linkPath, linkTarget string) bool {
+	target := filepath.FromSlash(linkTarget)
+	if target == "" || filepath.IsAbs(target) {
+		return false
+	}
+	linkPath = filepath.FromSlash(linkPath)
+	if !filepath.IsAbs(linkPath) {
+		linkPath = filepath.Join(workDir, linkPath)
+	}
+	return WithinWorkDir(workDir, filepath.Join(filepath.Dir(linkPath), target))
+}
+
+// ValidateWorkDirPaths rejects input patterns and declared outputs that
+// resolve outside workDir, such as "../../etc/passwd" or an absolute path
+// elsewhere, with an error wrapping ErrPathOutsideWorkDir. Remote inputs
+// are exempt, and git inputs are checked by their path.
+//
+// With allowInputs, inputs may lie outside workDir. Outputs never may:
+// their artifacts could not be cached or restored (see Harvester).
+func (t *Task) ValidateWorkDirPaths(workDir string, allowInputs bool) error {
+	if !allowInputs {
+		for _, in := range t.Inputs {
+			if IsRemoteInput(in) {
+				continue
+			}
+			p := strings.TrimPrefix(in, GitInputPrefix)
+			if !WithinWorkDir(workDir, filepath.FromSlash(p)) {
+				return fmt.Errorf("task %q: input %q: %w", t.Name, in, ErrPathOutsideWorkDir)
+			}
+		}
+	}
+	for _, out := range t.Outputs {
+		if !WithinWorkDir(workDir, filepath.FromSlash(out)) {
+			return fmt.Errorf("task %q: output %q: %w", t.Name, out, ErrPathOutsideWorkDir)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTask_ValidateWorkDirPaths(t *testing.T) {
+	workDir := t.TempDir()
+	for _, tc := range []struct {
+		name        string
+		task        Task
+		allowInputs bool
+		wantErr     bool
+	}{
+		{name: "relative", task: Task{Inputs: []string{"src/*.go", "git:docs"}, Outputs: []string{"dist", "out/../dist/a.txt"}}},
+		{name: "absolute inside", task: Task{Inputs: []string{filepath.Join(workDir, "a.txt")}, Outputs: []string{filepath.Join(workDir, "dist")}}},
+		{name: "remote input", task: Task{Inputs: []string{"https://example.com/a.tgz#sha256=00"}}},
+		{name: "input traversal", task: Task{Inputs: []string{"../../etc/passwd"}}, wantErr: true},
+		{name: "git input traversal", task: Task{Inputs: []string{"git:../other"}}, wantErr: true},
+		{name: "absolute input elsewhere", task: Task{Inputs: []string{"/etc/passwd"}}, wantErr: true},
+		{name: "allowed input", task: Task{Inputs: []string{"/etc/passwd"}}, allowInputs: true},
+		{name: "output traversal", task: Task{Outputs: []string{"dist/../../x"}}, allowInputs: true, wantErr: true},
+		{name: "absolute output elsewhere", task: Task{Outputs: []string{"/tmp/x"}}, allowInputs: true, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.task.Name = "t"
+			err := tc.task.ValidateWorkDirPaths(workDir, tc.allowInputs)
+			if tc.wantErr != errors.Is(err, ErrPathOutsideWorkDir) {
+				t.Fatalf("ValidateWorkDirPaths() = %v, want error: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestReplayer_RejectsArtifactsOutsideWorkDir(t *testing.T) {
+	root := t.TempDir()
+	workDir := filepath.Join(root, "work")
+	outside := filepath.Join(root, "outside.txt")
+	for _, p := range []string{"../outside.txt", outside} {
+		entry := &CacheEntry{Hash: "h", Artifacts: []CachedArtifact{{Path: p, Content: []byte("x")}}}
+		if _, _, err := NewReplayer(workDir).VerifyOrRestoreArtifacts("t", entry); !errors.Is(err, ErrPathOutsideWorkDir) {
+			t.Fatalf("%s: expected ErrPathOutsideWorkDir, got %v", p, err)
+		}
+		if _, err := os.Stat(outside); !os.IsNotExist(err) {
+			t.Fatalf("%s: artifact written outside the working directory (stat err=%v)", p, err)
+		}
+	}
+}
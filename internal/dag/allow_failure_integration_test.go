@@ -0,0 +1,53 @@
+package dag
+
+import (
+	"context"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/trace"
+)
+
+func TestExecutor_AllowFailureDoesNotSkipDependents(t *testing.T) {
+	tasks := []core.Task{
+		{Name: "lint", Run: "exit 4", AllowFailure: true},
+		{Name: "build", Run: "true"},
+		{Name: "report", Run: "true", When: &core.Condition{UpstreamSucceeded: []string{"lint"}}},
+	}
+	edges := []Edge{{From: "lint", To: "build"}, {From: "lint", To: "report"}}
+	for _, parallel := range []bool{false, true} {
+		cacheRunner, err := NewCacheAwareRunner(core.NewRunner(t.TempDir(), core.NewMemoryCache()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		g, err := NewTaskGraph(tasks, edges)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		exec, err := NewExecutor(g, cacheRunner)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var res *GraphResult
+		if parallel {
+			res, err = exec.RunParallel(context.Background(), 2)
+		} else {
+			res, err = exec.RunSerial(context.Background())
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]TaskState{"lint": TaskFailedAllowed, "build": TaskCompleted, "report": TaskConditionFalse}
+		for name, st := range want {
+			if res.FinalState[name] != st {
+				t.Fatalf("parallel=%v: %s state = %s, want %s", parallel, name, res.FinalState[name], st)
+			}
+		}
+		if res.ExitCode["lint"] != 4 {
+			t.Fatalf("parallel=%v: lint exit code = %d, want 4", parallel, res.ExitCode["lint"])
+		}
+		if !hasTraceEvent(t, res.TraceBytes, trace.EventTaskFailed, "lint", trace.ReasonAllowedFailure) {
+			t.Fatalf("parallel=%v: expected lint failed with AllowedFailure, trace=%s", parallel, res.TraceBytes)
+		}
+	}
+}
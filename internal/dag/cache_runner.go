@@ -21,6 +21,54 @@ type NodeResult struct {
 
 	FromCache         bool
 	ArtifactsRestored int
+
+	// ArtifactsVerified counts cached artifacts already in place, which were
+	// left untouched instead of rewritten.
+	ArtifactsVerified int
+
+	// Invalidated is set when a cache entry existed but could not be used (it
+	// no longer matched the task's declared outputs, or was corrupt), so the
+	// task was executed instead of restored.
+	Invalidated bool
+
+	// CacheCorrupt narrows Invalidated to a corrupt entry that was rewritten.
+	CacheCorrupt bool
+
+	// UndeclaredOutputs lists paths written outside the task's declared
+	// outputs under strict mode (see core.Runner.StrictOutputs).
+	UndeclaredOutputs []string
+
+	// OutputMismatches lists artifacts that did not match the task's
+	// ExpectedOutputs (see core.CheckExpectedOutputs).
+	OutputMismatches []core.OutputMismatch
+
+	// ModifiedInputs lists the declared inputs the task modified while it
+	// executed (see core.Runner.ProtectInputs).
+	ModifiedInputs []string
+
+	// OutputTruncated is set when the task executed and its stdout or stderr
+	// was cut at the output limit (see core.Executor.OutputLimit).
+	OutputTruncated bool
+
+	// ServiceReady is set when a service task's readiness command succeeded
+	// and the service was left running (see ServiceRunner).
+	ServiceReady bool
+
+	// Rerun is set when the task executed without consulting the cache
+	// because the user invalidated it (see core.Runner.Rerun).
+	Rerun bool
+
+	// NotCacheable is set when the task executed because it opted out of the
+	// cache (see core.Task.Cacheable).
+	NotCacheable bool
+
+	// EffectApplied is set when the task was not executed because its effect
+	// key was already recorded (see core.Runner.Effects).
+	EffectApplied bool
+
+	// DuplicateOf names the task with identical hash inputs whose result
+	// this one shares; the task itself was not run (see HashRunner).
+	DuplicateOf string
 }
 
 // CacheAwareRunner adapts the Sprint-00 core.Runner to the DAG executor.
@@ -56,53 +104,102 @@ func (r *CacheAwareRunner) Run(ctx context.Context, task core.Task) (*NodeResult
 		ExitCode:          res.ExitCode,
 		FromCache:         res.FromCache,
 		ArtifactsRestored: res.ArtifactsRestored,
+		ArtifactsVerified: res.ArtifactsVerified,
+		Invalidated:       res.Invalidated,
+		CacheCorrupt:      res.CacheCorrupt,
+		UndeclaredOutputs: res.UndeclaredOutputs,
+		OutputMismatches:  res.OutputMismatches,
+		ModifiedInputs:    res.ModifiedInputs,
+		OutputTruncated:   res.OutputTruncated,
+		Rerun:             r.Runner.Rerun[task.Name],
+		NotCacheable:      !task.IsCacheable(),
+		EffectApplied:     res.EffectApplied,
 	}, nil
 }
 
+// TaskHash computes task's hash without running it (see HashRunner).
+func (r *CacheAwareRunner) TaskHash(task core.Task) (core.TaskHash, error) {
+	if r == nil || r.Runner == nil {
+		return "", fmt.Errorf("nil core runner")
+	}
+	hash, _, err := r.Runner.TaskHash(&task)
+	return hash, err
+}
+
 // Restore restores artifacts and outputs for a task from cache using the task's computed hash.
 //
 // This is used by Sprint-02 incremental orchestration when a node is explicitly planned
-// as ReuseCache.
+// as ReuseCache. If the entry's artifact set no longer matches the task's declared
+// outputs (or is corrupt and the runner heals corrupt entries), nothing is restored:
+// the task is executed and the result is marked Invalidated. A task that is
+// not cacheable has no entry and is always executed.
 func (r *CacheAwareRunner) Restore(ctx context.Context, task core.Task) (*NodeResult, error) {
 	if r == nil || r.Runner == nil {
 		return nil, fmt.Errorf("nil core runner")
 	}
-
-	inputSet, err := r.Runner.Resolver.Resolve(task.Inputs)
-	if err != nil {
-		return nil, fmt.Errorf("resolving inputs: %w", err)
+	if !task.IsCacheable() {
+		return r.Run(ctx, task)
 	}
 
-	hashInput := core.HashInput{
-		Inputs:     inputSet,
-		Command:    task.Run,
-		Env:        task.Env,
-		Outputs:    task.Outputs,
-		WorkingDir: r.Runner.WorkingDir,
+	hash, _, err := r.Runner.TaskHash(&task)
+	if err != nil {
+		return nil, err
 	}
-	hash := r.Runner.Hasher.ComputeHash(hashInput)
 
 	entry, err := r.Runner.Cache.Get(hash)
 	if err != nil {
+		if r.Runner.HealCorruptEntries && core.IsCacheCorrupt(err) {
+			return r.Run(ctx, task)
+		}
 		return nil, fmt.Errorf("retrieving cache entry: %w", err)
 	}
 	if entry == nil {
 		return nil, fmt.Errorf("cache entry missing for hash %s", hash)
 	}
+	if err := core.VerifyDeclaredOutputs(entry, task.Outputs, r.Runner.WorkingDir); err != nil {
+		return r.Run(ctx, task)
+	}
 
-	restored, err := r.Runner.Replayer.RestoreArtifacts(task.Name, entry)
+	if r.Runner.CleanOutputs {
+		if err := r.Runner.PruneOutputs(task.Outputs, entry); err != nil {
+			return nil, fmt.Errorf("pruning outputs: %w", err)
+		}
+	}
+	restored, verified, err := r.Runner.Replayer.VerifyOrRestoreArtifacts(task.Name, entry)
 	if err != nil {
+		// Streamed blobs are digest-checked only while restoring.
+		if r.Runner.HealCorruptEntries && core.IsCacheCorrupt(err) {
+			return r.Run(ctx, task)
+		}
 		return nil, err
 	}
 
-	return &NodeResult{
+	res := &NodeResult{
 		Hash:              hash,
 		Stdout:            entry.Stdout,
 		Stderr:            entry.Stderr,
 		ExitCode:          entry.ExitCode,
 		FromCache:         true,
 		ArtifactsRestored: restored,
-	}, nil
+		ArtifactsVerified: verified,
+	}
+	if err := ApplyExpectedOutputs(res, &task, entry); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ApplyExpectedOutputs fails res with core.ExpectedOutputsExitCode when
+// entry's artifacts do not match task's ExpectedOutputs.
+func ApplyExpectedOutputs(res *NodeResult, task *core.Task, entry *core.CacheEntry) error {
+	m, err := core.CheckExpectedOutputs(task, entry)
+	if err != nil || len(m) == 0 {
+		return err
+	}
+	res.ExitCode = core.ExpectedOutputsExitCode
+	res.Stderr = append(append([]byte(nil), res.Stderr...), core.ExpectedOutputsReport(m)...)
+	res.OutputMismatches = m
+	return nil
 }
 
 func (r *CacheAwareRunner) Probe(ctx context.Context, task core.Task) (*NodeResult, bool, error) {
@@ -116,35 +213,45 @@ func (r *CacheAwareRunner) Probe(ctx context.Context, task core.Task) (*NodeResu
 		return nil, false, fmt.Errorf("task run command is required")
 	}
 
-	inputSet, err := r.Runner.Resolver.Resolve(task.Inputs)
+	hash, _, err := r.Runner.TaskHash(&task)
 	if err != nil {
-		return nil, false, fmt.Errorf("resolving inputs: %w", err)
-	}
-
-	hashInput := core.HashInput{
-		Inputs:     inputSet,
-		Command:    task.Run,
-		Env:        task.Env,
-		Outputs:    task.Outputs,
-		WorkingDir: r.Runner.WorkingDir,
+		return nil, false, err
 	}
-	hash := r.Runner.Hasher.ComputeHash(hashInput)
 
 	exists, err := r.Runner.Cache.Has(hash)
 	if err != nil {
 		return nil, false, fmt.Errorf("checking cache: %w", err)
 	}
-	if !exists {
+	if !exists || r.Runner.Rerun[task.Name] || !task.IsCacheable() {
 		return nil, false, nil
 	}
 
 	entry, err := r.Runner.Cache.Get(hash)
 	if err != nil {
+		if r.Runner.HealCorruptEntries && core.IsCacheCorrupt(err) {
+			// Report a miss; Run re-detects the corruption and rewrites the entry.
+			return nil, false, nil
+		}
 		return nil, false, fmt.Errorf("retrieving cache entry: %w", err)
 	}
 	if entry == nil {
 		return nil, false, fmt.Errorf("cache entry disappeared")
 	}
+	if err := core.VerifyDeclaredOutputs(entry, task.Outputs, r.Runner.WorkingDir); err != nil {
+		// Stale entry: report a miss so the executor runs the task, which
+		// re-detects the mismatch and marks the result Invalidated.
+		return nil, false, nil
+	}
+	if m, err := core.CheckExpectedOutputs(&task, entry); err != nil || len(m) > 0 {
+		// Report a miss: Run replays the entry and fails the task, so the
+		// failure propagates like any other.
+		return nil, false, err
+	}
+	if r.Runner.CleanOutputs {
+		if err := r.Runner.PruneOutputs(task.Outputs, entry); err != nil {
+			return nil, false, fmt.Errorf("pruning outputs: %w", err)
+		}
+	}
 
 	replayResult, err := r.Runner.Replayer.Replay(entry)
 	if err != nil {
@@ -158,5 +265,6 @@ func (r *CacheAwareRunner) Probe(ctx context.Context, task core.Task) (*NodeResu
 		ExitCode:          replayResult.ExitCode,
 		FromCache:         true,
 		ArtifactsRestored: replayResult.ArtifactsRestored,
+		ArtifactsVerified: replayResult.ArtifactsVerified,
 	}, true, nil
 }
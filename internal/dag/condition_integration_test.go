@@ -0,0 +1,82 @@
+package dag
+
+import (
+	"context"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/trace"
+)
+
+func TestExecutor_WhenConditionsSkipTasks(t *testing.T) {
+	tasks := []core.Task{
+		{Name: "A", Run: "touch marker"},
+		{Name: "B", Run: "true", Inputs: []string{"marker"}, When: &core.Condition{InputsExist: []string{"marker"}}},
+		{Name: "C", Run: "true", When: &core.Condition{Equals: []string{"linux", "darwin"}}},
+		{Name: "D", Run: "true"},
+		{Name: "E", Run: "true", Inputs: []string{"missing.txt"}, When: &core.Condition{InputsExist: []string{"missing.txt"}}},
+		{Name: "F", Run: "true", When: &core.Condition{UpstreamSucceeded: []string{"A"}}},
+	}
+	edges := []Edge{{From: "A", To: "B"}, {From: "C", To: "D"}, {From: "A", To: "F"}}
+	for _, parallel := range []bool{false, true} {
+		workDir := t.TempDir()
+		cacheRunner, err := NewCacheAwareRunner(core.NewRunner(workDir, core.NewMemoryCache()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		g, err := NewTaskGraph(tasks, edges)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		exec, err := NewExecutor(g, cacheRunner)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		exec.WorkDir = workDir
+		var res *GraphResult
+		if parallel {
+			res, err = exec.RunParallel(context.Background(), 2)
+		} else {
+			res, err = exec.RunSerial(context.Background())
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]TaskState{"A": TaskCompleted, "B": TaskCompleted, "C": TaskConditionFalse, "D": TaskSkipped, "E": TaskConditionFalse, "F": TaskCompleted}
+		for name, st := range want {
+			if res.FinalState[name] != st {
+				t.Fatalf("parallel=%v: %s state = %s, want %s", parallel, name, res.FinalState[name], st)
+			}
+		}
+		for _, name := range []string{"C", "D", "E"} {
+			if !hasTraceEvent(t, res.TraceBytes, trace.EventTaskSkipped, name, trace.ReasonConditionFalse) {
+				t.Fatalf("parallel=%v: expected %s skipped with ConditionFalse, trace=%s", parallel, name, res.TraceBytes)
+			}
+		}
+	}
+}
+
+func TestNewTaskGraph_RejectsInvalidConditions(t *testing.T) {
+	cases := map[string]struct {
+		tasks []core.Task
+		edges []Edge
+	}{
+		"equals arity": {
+			tasks: []core.Task{{Name: "A", Run: "true", When: &core.Condition{Equals: []string{"x"}}}},
+		},
+		"undeclared input": {
+			tasks: []core.Task{{Name: "A", Run: "true", When: &core.Condition{InputsExist: []string{"x"}}}},
+		},
+		"unknown upstream": {
+			tasks: []core.Task{{Name: "A", Run: "true", When: &core.Condition{UpstreamSucceeded: []string{"Z"}}}},
+		},
+		"not upstream": {
+			tasks: []core.Task{{Name: "A", Run: "true"}, {Name: "B", Run: "true", When: &core.Condition{UpstreamSucceeded: []string{"A"}}}},
+		},
+	}
+	for name, tc := range cases {
+		if _, err := NewTaskGraph(tc.tasks, tc.edges); err == nil {
+			t.Fatalf("%s: expected condition to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package dag
+
+import (
+	"fmt"
+
+	"scriptweaver/internal/core"
+)
+
+// ContainerRunner is a CacheAwareRunner whose tasks with an Image run inside
+// that image via a Docker-compatible engine. Tasks without an Image run on
+// the host exactly as with CacheAwareRunner.
+//
+// Hashing, caching and replay are unchanged: the image is part of the task
+// hash, the working directory is mounted into the container, and declared
+// outputs are harvested from it after the container exits.
+type ContainerRunner struct {
+	*CacheAwareRunner
+}
+
+// NewContainerRunner configures r's executor to run image tasks with engine
+// ("docker" when empty) and wraps it for the DAG executor.
+func NewContainerRunner(r *core.Runner, engine string) (*ContainerRunner, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil core runner")
+	}
+	if r.Executor == nil {
+		return nil, fmt.Errorf("nil core executor")
+	}
+	r.Executor.Container = &core.ContainerConfig{Engine: engine}
+	cr, err := NewCacheAwareRunner(r)
+	if err != nil {
+		return nil, err
+	}
+	return &ContainerRunner{CacheAwareRunner: cr}, nil
+}
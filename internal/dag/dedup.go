@@ -0,0 +1,139 @@
+package dag
+
+import (
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/trace"
+)
+
+// HashRunner is implemented by runners that can compute a task's
+// core.TaskHash without running it. The executor uses it to deduplicate
+// tasks with identical hash inputs; without it every task runs.
+//
+// An empty hash with a nil error means the task cannot be deduplicated.
+type HashRunner interface {
+	TaskHash(task core.Task) (core.TaskHash, error)
+}
+
+// dedupIndex tracks, for one execution, the first task to claim each task
+// hash, so later tasks with identical hash inputs share its result instead
+// of running the same work twice.
+//
+// Only cacheable, non-service tasks whose DefinitionHash matches another
+// node's are candidates; their task hash is computed when they become ready
+// and confirms the match. Graphs without duplicate definitions are never
+// hashed ahead of running.
+type dedupIndex struct {
+	hasher     HashRunner
+	candidates map[string]bool
+	primary    map[core.TaskHash]string
+	results    map[string]*NodeResult
+}
+
+func newDedupIndex(g *TaskGraph, runner TaskRunner) *dedupIndex {
+	d := &dedupIndex{
+		candidates: make(map[string]bool),
+		primary:    make(map[core.TaskHash]string),
+		results:    make(map[string]*NodeResult),
+	}
+	hasher, ok := runner.(HashRunner)
+	if !ok {
+		return d
+	}
+	d.hasher = hasher
+	byDef := make(map[TaskDefHash][]string)
+	for _, n := range g.nodes {
+		if dedupCandidate(n.Task) {
+			byDef[n.DefinitionHash] = append(byDef[n.DefinitionHash], n.Name)
+		}
+	}
+	for _, names := range byDef {
+		if len(names) < 2 {
+			continue
+		}
+		for _, name := range names {
+			d.candidates[name] = true
+		}
+	}
+	return d
+}
+
+// dedupCandidate reports whether t may share another task's result: it is
+// cacheable and not a service.
+func dedupCandidate(t core.Task) bool {
+	return t.IsCacheable() && t.NormalizedKind() != core.KindService
+}
+
+// lookup returns the task that claimed task's hash earlier in the execution,
+// or claims the hash for task and returns "". A task whose hash cannot be
+// computed is never deduplicated; running it surfaces the error.
+func (d *dedupIndex) lookup(task core.Task) string {
+	if d.hasher == nil || !d.candidates[task.Name] {
+		return ""
+	}
+	hash, err := d.hasher.TaskHash(task)
+	if err != nil || hash == "" {
+		return ""
+	}
+	if p, ok := d.primary[hash]; ok && p != task.Name {
+		return p
+	}
+	d.primary[hash] = task.Name
+	return ""
+}
+
+// record keeps name's result for the tasks deduplicated against it.
+func (d *dedupIndex) record(name string, res *NodeResult) {
+	if d.candidates[name] && res != nil {
+		d.results[name] = res
+	}
+}
+
+// shared returns the result a task deduplicated against primary takes, or
+// nil when primary has not produced one (it is still running, or its
+// restore failed outright), in which case the task runs itself.
+//
+// Duplicates declare the same outputs in the same working directory, so the
+// primary's artifacts are already in place and nothing is restored.
+func (d *dedupIndex) shared(primary string) *NodeResult {
+	res := d.results[primary]
+	if res == nil {
+		return nil
+	}
+	return &NodeResult{
+		Hash:              res.Hash,
+		Stdout:            res.Stdout,
+		Stderr:            res.Stderr,
+		ExitCode:          res.ExitCode,
+		FromCache:         true,
+		UndeclaredOutputs: res.UndeclaredOutputs,
+		OutputMismatches:  res.OutputMismatches,
+		ModifiedInputs:    res.ModifiedInputs,
+		OutputTruncated:   res.OutputTruncated,
+		DuplicateOf:       primary,
+	}
+}
+
+// shareDuplicate commits res, a result shared by dedupIndex.shared, as the
+// outcome of name without running it: a success ends CACHED with
+// ReasonTaskDeduplicated events naming the primary as CauseTaskID, and a
+// failure fails name like the primary. It reports whether name is
+// checkpointed like a successful task. Callers hold e.mu; name is PENDING.
+func (e *Executor) shareDuplicate(rec trace.Sink, name string, task core.Task, res *NodeResult) (bool, error) {
+	if task.Succeeded(res.ExitCode) {
+		if err := e.gs.Transition(name, TaskPending, TaskCached); err != nil {
+			return false, err
+		}
+		trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskCached, TaskID: name, Reason: trace.ReasonTaskDeduplicated, CauseTaskID: res.DuplicateOf})
+		trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskArtifactsRestored, TaskID: name, Reason: trace.ReasonTaskDeduplicated, CauseTaskID: res.DuplicateOf})
+		return true, nil
+	}
+	if err := e.gs.Transition(name, TaskPending, TaskRunning); err != nil {
+		return false, err
+	}
+	if task.AllowFailure {
+		return e.failTask(rec, name, task, res)
+	}
+	trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskFailed, TaskID: name, Reason: trace.ReasonTaskDeduplicated, CauseTaskID: res.DuplicateOf})
+	_, err := e.gs.FailAndPropagate(name)
+	return false, err
+}
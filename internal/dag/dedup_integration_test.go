@@ -0,0 +1,72 @@
+package dag
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+func TestExecutor_DeduplicatesIdenticalTasks(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		parallel bool
+		run      string
+		want     ExecutionState
+	}{
+		{name: "serial", run: "echo run >> runs.log; printf built > out.txt", want: ExecutionState{"a": TaskCompleted, "b": TaskCached, "use": TaskCompleted}},
+		{name: "parallel", parallel: true, run: "echo run >> runs.log; printf built > out.txt", want: ExecutionState{"a": TaskCompleted, "b": TaskCached, "use": TaskCompleted}},
+		{name: "shared failure", run: "echo run >> runs.log; exit 3", want: ExecutionState{"a": TaskFailed, "b": TaskFailed, "use": TaskSkipped}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			workDir := t.TempDir()
+			runner, err := NewCacheAwareRunner(core.NewRunner(workDir, core.NewMemoryCache()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g, err := NewTaskGraph([]core.Task{
+				{Name: "a", Run: tc.run, Outputs: []string{"out.txt"}},
+				{Name: "b", Run: tc.run, Outputs: []string{"out.txt"}},
+				{Name: "use", Run: "cat out.txt", Inputs: []string{"out.txt"}},
+			}, []Edge{{From: "a", To: "use"}, {From: "b", To: "use"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			exec, err := NewExecutor(g, runner)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res *GraphResult
+			if tc.parallel {
+				res, err = exec.RunParallel(context.Background(), 2)
+			} else {
+				res, err = exec.RunSerial(context.Background())
+			}
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			for name, want := range tc.want {
+				if got := res.FinalState[name]; got != want {
+					t.Fatalf("%s: state %s, want %s", name, got, want)
+				}
+			}
+			log, err := os.ReadFile(filepath.Join(workDir, "runs.log"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := strings.Count(string(log), "run\n"); n != 1 {
+				t.Fatalf("identical tasks ran %d times, want once", n)
+			}
+			if res.TaskHashes["a"] != res.TaskHashes["b"] || res.ExitCode["a"] != res.ExitCode["b"] {
+				t.Fatalf("b does not share a's result: hashes %s/%s exit %d/%d", res.TaskHashes["a"], res.TaskHashes["b"], res.ExitCode["a"], res.ExitCode["b"])
+			}
+			if !bytes.Contains(res.TraceBytes, []byte(`"TaskDeduplicated"`)) || !bytes.Contains(res.TraceBytes, []byte(`"causeTaskId":"a"`)) {
+				t.Fatalf("trace does not record the deduplication: %s", res.TraceBytes)
+			}
+		})
+	}
+}
@@ -6,4 +6,6 @@
 //
 // The graph identity (GraphHash) is computed from task definition content and
 // canonicalized edge structure, making it invariant to insertion order.
+// TaskGraph.SubtreeHash applies the same scheme to one task's upstream
+// closure, identifying that part of the graph independently of the rest.
 package dag
@@ -9,6 +9,10 @@ import (
 var (
 	ErrInvalidGraph = errors.New("invalid task graph")
 	ErrCycleFound   = errors.New("cycle detected")
+
+	// ErrOutputOverlap rejects tasks that may write the same artifact
+	// concurrently (see TaskGraph.validateOutputs).
+	ErrOutputOverlap = errors.New("overlapping outputs")
 )
 
 // GraphError wraps deterministic graph validation failures.
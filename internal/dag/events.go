@@ -0,0 +1,107 @@
+package dag
+
+import "scriptweaver/internal/core"
+
+// NodeEventKind classifies a NodeEvent.
+type NodeEventKind string
+
+const (
+	// NodeStarted: the task was dispatched (RUNNING), to execute or to
+	// restore planned cache reuse.
+	NodeStarted NodeEventKind = "started"
+
+	// NodeFinished: the task ended COMPLETED.
+	NodeFinished NodeEventKind = "finished"
+
+	// NodeCached: the task ended CACHED without being dispatched.
+	NodeCached NodeEventKind = "cached"
+
+	// NodeFailed: the task ended FAILED or FAILED_ALLOWED.
+	NodeFailed NodeEventKind = "failed"
+
+	// NodeSkipped: the task ended SKIPPED or CONDITION_FALSE.
+	NodeSkipped NodeEventKind = "skipped"
+)
+
+// NodeEvent is one task state change published to Executor.Observers.
+type NodeEvent struct {
+	Kind NodeEventKind
+	Task core.Task
+
+	// From and To are the task's states before and after the change.
+	From TaskState
+	To   TaskState
+
+	// ExitCode and Hash are the task's result. HasResult is false for
+	// started and skipped events, which have none.
+	HasResult bool
+	ExitCode  int
+	Hash      core.TaskHash
+}
+
+// NodeEventObserver subscribes to every task state change of a run.
+//
+// Events of a run are delivered in the order the changes happen, from the
+// goroutine running the graph and without the executor's lock held.
+// Subscribers cannot fail the run; unlike NodeObserver they are for
+// progress, metrics and similar side channels, and must not block for long.
+type NodeEventObserver interface {
+	OnNodeEvent(ev NodeEvent)
+}
+
+// NodeEventObserverFunc adapts a function to NodeEventObserver.
+type NodeEventObserverFunc func(ev NodeEvent)
+
+// OnNodeEvent calls f(ev).
+func (f NodeEventObserverFunc) OnNodeEvent(ev NodeEvent) { f(ev) }
+
+// nodeEventKind maps a state change to its event kind.
+func nodeEventKind(to TaskState) (NodeEventKind, bool) {
+	switch to {
+	case TaskRunning:
+		return NodeStarted, true
+	case TaskCompleted:
+		return NodeFinished, true
+	case TaskCached:
+		return NodeCached, true
+	case TaskFailed, TaskFailedAllowed:
+		return NodeFailed, true
+	case TaskSkipped, TaskConditionFalse:
+		return NodeSkipped, true
+	default:
+		return "", false
+	}
+}
+
+// queueEvent records a state change for delivery by unlock. It is called by
+// GraphState with e.mu held.
+func (e *Executor) queueEvent(name string, from, to TaskState) {
+	if len(e.Observers) == 0 {
+		return
+	}
+	kind, ok := nodeEventKind(to)
+	if !ok {
+		return
+	}
+	e.pending = append(e.pending, NodeEvent{Kind: kind, Task: e.Graph.nodesByName[name].Task, From: from, To: to})
+}
+
+// unlock releases e.mu and delivers the events queued while it was held.
+// Results are looked up at this point, after the run loop has recorded them.
+func (e *Executor) unlock() {
+	events := e.pending
+	e.pending = nil
+	for i := range events {
+		if ev := &events[i]; ev.Kind != NodeStarted && ev.Kind != NodeSkipped && e.exitCodes != nil {
+			ev.ExitCode, ev.HasResult = e.exitCodes[ev.Task.Name]
+			ev.Hash = e.taskHashes[ev.Task.Name]
+		}
+	}
+	e.mu.Unlock()
+
+	for _, ev := range events {
+		for _, o := range e.Observers {
+			o.OnNodeEvent(ev)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package dag
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+type eventLog struct{ events []string }
+
+func (l *eventLog) OnNodeEvent(ev NodeEvent) {
+	s := fmt.Sprintf("%s:%s", ev.Kind, ev.Task.Name)
+	if ev.HasResult {
+		s += fmt.Sprintf(":%d", ev.ExitCode)
+	}
+	l.events = append(l.events, s)
+}
+
+func TestExecutor_ObserversReceiveAllNodeEvents(t *testing.T) {
+	edges := []Edge{{From: "a", To: "b"}, {From: "b", To: "c"}}
+	for _, parallel := range []bool{false, true} {
+		cacheRunner, err := NewCacheAwareRunner(core.NewRunner(t.TempDir(), core.NewMemoryCache()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := [][]string{
+			{"started:a", "finished:a:0", "started:b", "failed:b:3", "skipped:c"},
+			{"cached:a:0", "started:b", "failed:b:3", "skipped:c"},
+		}
+		for run, wantEvents := range want {
+			// b's env differs per run so only a is replayed from the cache.
+			g, err := NewTaskGraph([]core.Task{
+				{Name: "a", Run: "true"},
+				{Name: "b", Run: "exit 3", Env: map[string]string{"RUN": fmt.Sprint(run)}},
+				{Name: "c", Run: "echo c"},
+			}, edges)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			exec, err := NewExecutor(g, cacheRunner)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			first, second := &eventLog{}, &eventLog{}
+			exec.Observers = []NodeEventObserver{first, second}
+			if parallel {
+				_, err = exec.RunParallel(context.Background(), 2)
+			} else {
+				_, err = exec.RunSerial(context.Background())
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(first.events, wantEvents) {
+				t.Fatalf("parallel=%v run %d: events = %v, want %v", parallel, run, first.events, wantEvents)
+			}
+			if !reflect.DeepEqual(second.events, first.events) {
+				t.Fatalf("parallel=%v run %d: subscribers saw different events: %v vs %v", parallel, run, first.events, second.events)
+			}
+		}
+	}
+}
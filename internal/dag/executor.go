@@ -88,18 +88,35 @@ type Executor struct {
 	// crash recovery semantics (system failure resumable if checkpoints exist).
 	Observer NodeObserver
 
+	// WorkDir is where When inputs_exist clauses are matched; empty uses the
+	// process working directory.
+	WorkDir string
+
 	// Hooks provides optional lifecycle hook points.
 	// Hook implementations are responsible for isolation (panic recovery, logging).
 	Hooks LifecycleHooks
 
+	// Observers receive every task state change (started, finished, cached,
+	// failed, skipped). Unlike Observer they cannot fail the run, so any
+	// number of them can coexist.
+	Observers []NodeEventObserver
+
 	mu    sync.Mutex
 	state ExecutionState
+	gs    *GraphState // memoized view of state; all mutations go through it
+
+	// Event delivery state, guarded by mu: changes queued for Observers and
+	// the current run's results.
+	pending    []NodeEvent
+	exitCodes  map[string]int
+	taskHashes map[string]core.TaskHash
 }
 
 // NodeObserver is an optional execution observer.
 //
 // OnTaskTerminal is invoked after a task reaches a successful terminal state
-// (COMPLETED or CACHED) with exit code 0.
+// (COMPLETED or CACHED) with a success exit code, and after an allowed
+// failure (FAILED_ALLOWED).
 //
 // The traceEvents are a point-in-time snapshot of the trace recorder.
 // Implementations must be deterministic and should avoid heavy IO.
@@ -107,6 +124,181 @@ type NodeObserver interface {
 	OnTaskTerminal(task core.Task, result *NodeResult, traceEvents []trace.TraceEvent) error
 }
 
+// recordFailed emits TaskFailed for a task whose result has a non-zero exit
+// code, with ReasonUndeclaredOutputs when strict mode caused the failure,
+// ReasonInputsModified when the task modified its inputs,
+// ReasonExpectedOutputMismatch when its artifacts missed their expectations
+// and otherwise ReasonOutputTruncated when its output hit the limit.
+func recordFailed(rec trace.Sink, name string, res *NodeResult) {
+	ev := trace.TraceEvent{Kind: trace.EventTaskFailed, TaskID: name}
+	switch {
+	case res == nil:
+	case len(res.UndeclaredOutputs) > 0:
+		ev.Reason = trace.ReasonUndeclaredOutputs
+	case len(res.ModifiedInputs) > 0:
+		ev.Reason = trace.ReasonInputsModified
+	case len(res.OutputMismatches) > 0:
+		ev.Reason = trace.ReasonExpectedOutputMismatch
+	case res.OutputTruncated:
+		ev.Reason = trace.ReasonOutputTruncated
+	}
+	trace.SafeRecord(rec, ev)
+}
+
+// failTask records a failed result for name. A task that allows failure
+// ends FAILED_ALLOWED with ReasonAllowedFailure and its dependents still run;
+// any other ends FAILED and everything downstream is skipped. It reports
+// whether the failure was allowed, in which case the task is checkpointed
+// like a successful one. Callers hold e.mu.
+func (e *Executor) failTask(rec trace.Sink, name string, task core.Task, res *NodeResult) (bool, error) {
+	if task.AllowFailure {
+		trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskFailed, TaskID: name, Reason: trace.ReasonAllowedFailure})
+		return true, e.gs.Transition(name, TaskRunning, TaskFailedAllowed)
+	}
+	recordFailed(rec, name, res)
+	_, err := e.gs.FailAndPropagate(name)
+	return false, err
+}
+
+// recordInvalidated emits a TaskInvalidated event when res replaced an unusable
+// cache entry: a corrupt one, or one whose artifacts no longer match the
+// declared outputs. It also marks a task the user invalidated, whether or not
+// it had an entry.
+func recordInvalidated(rec trace.Sink, name string, res *NodeResult) {
+	if res != nil && res.Rerun {
+		trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskInvalidated, TaskID: name, Reason: trace.ReasonUserInvalidated})
+		return
+	}
+	if res == nil || !res.Invalidated {
+		return
+	}
+	reason := trace.ReasonDeclaredOutputsChanged
+	if res.CacheCorrupt {
+		reason = trace.ReasonCacheCorrupt
+	}
+	trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskInvalidated, TaskID: name, Reason: reason})
+}
+
+// restoreReason is the TaskArtifactsRestored reason for a cache hit: reason,
+// unless every artifact was verified in place and nothing was rewritten.
+func restoreReason(res *NodeResult, reason string) string {
+	if res != nil && res.ArtifactsRestored == 0 && res.ArtifactsVerified > 0 {
+		return trace.ReasonVerifiedInPlace
+	}
+	return reason
+}
+
+// executedReason is the TaskExecuted reason for res: reason, unless its
+// effect was already applied, it is a service that became ready, its stdout
+// or stderr was truncated at the output limit, or the task is not cacheable.
+func executedReason(res *NodeResult, reason string) string {
+	if res != nil && res.EffectApplied {
+		return trace.ReasonEffectApplied
+	}
+	if res != nil && res.ServiceReady {
+		return trace.ReasonServiceReady
+	}
+	if res != nil && res.OutputTruncated {
+		return trace.ReasonOutputTruncated
+	}
+	if res != nil && res.NotCacheable {
+		return trace.ReasonNotCacheable
+	}
+	return reason
+}
+
+// conditionHolds evaluates task's When clause against the upstream states
+// and exit codes recorded so far. Callers hold e.mu.
+func (e *Executor) conditionHolds(task core.Task, exitCodes map[string]int) (bool, error) {
+	c := task.When
+	if c == nil {
+		return true, nil
+	}
+	if !c.EqualsHolds() {
+		return false, nil
+	}
+	for _, up := range c.UpstreamSucceeded {
+		upTask := e.Graph.nodesByName[up].Task
+		if !IsSuccessful(e.state[up]) || !upTask.Succeeded(exitCodes[up]) {
+			return false, nil
+		}
+	}
+	return c.InputsPresent(core.NewInputResolver(e.WorkDir))
+}
+
+// skipReason is the TaskSkipped reason for a task skipped because of cause.
+func skipReason(state ExecutionState, cause string) string {
+	if state[cause] == TaskConditionFalse {
+		return trace.ReasonConditionFalse
+	}
+	return trace.ReasonUpstreamFailed
+}
+
+// annotateEvents labels each task event with its task's group, and the
+// success events of a task that exited with an accepted non-zero code with
+// that code.
+func (e *Executor) annotateEvents(t *trace.ExecutionTrace, exitCodes map[string]int) {
+	for i := range t.Events {
+		ev := &t.Events[i]
+		if n, ok := e.Graph.Node(ev.TaskID); ok {
+			ev.Group = n.Task.Group
+		}
+		if ev.Kind == trace.EventTaskExecuted || ev.Kind == trace.EventTaskArtifactsRestored {
+			ev.ExitCode = exitCodes[ev.TaskID]
+		}
+	}
+}
+
+// traceSnapshot copies the events recorded so far for the Observer. Without
+// an Observer nothing reads the copy, so none is made; copying on every
+// completion would make large runs quadratic.
+func (e *Executor) traceSnapshot(rec *trace.Recorder) []trace.TraceEvent {
+	if e.Observer == nil {
+		return nil
+	}
+	return rec.Snapshot()
+}
+
+// notifyObserver invokes the Observer, if any, for a task that completed
+// successfully during parallel execution.
+func (e *Executor) notifyObserver(name string, res *NodeResult, traceSnap []trace.TraceEvent) error {
+	if e.Observer == nil {
+		return nil
+	}
+	n, ok := e.Graph.Node(name)
+	if !ok {
+		return fmt.Errorf("unknown task %q", name)
+	}
+	return e.Observer.OnTaskTerminal(n.Task, res, traceSnap)
+}
+
+// stopServices tears down the services the runner left running, in reverse
+// topological order so a service outlives the services it depends on. With
+// a non-nil rec each teardown is recorded as a ServiceStopped event; the
+// deferred call on abort passes nil. Stopping is idempotent.
+func (e *Executor) stopServices(ctx context.Context, rec trace.Sink) error {
+	stopper, ok := e.Runner.(ServiceStopper)
+	if !ok {
+		return nil
+	}
+	order := e.Graph.TopologicalOrder()
+	var firstErr error
+	for i := len(order) - 1; i >= 0; i-- {
+		task := e.Graph.nodesByName[order[i]].Task
+		if task.NormalizedKind() != core.KindService {
+			continue
+		}
+		stopped, err := stopper.StopService(ctx, task)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("stopping service %q: %w", task.Name, err)
+		}
+		if stopped && rec != nil {
+			trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventServiceStopped, TaskID: task.Name})
+		}
+	}
+	return firstErr
+}
+
 // NewExecutor creates an executor with all nodes initialized to PENDING.
 func NewExecutor(g *TaskGraph, runner TaskRunner) (*Executor, error) {
 	if g == nil {
@@ -115,13 +307,26 @@ func NewExecutor(g *TaskGraph, runner TaskRunner) (*Executor, error) {
 	if runner == nil {
 		return nil, fmt.Errorf("nil runner")
 	}
+	// Without a HashRunner identical tasks all run, so their outputs must
+	// not overlap either.
+	if _, ok := runner.(HashRunner); !ok {
+		if err := g.validateOutputs(false); err != nil {
+			return nil, err
+		}
+	}
 
 	state := make(ExecutionState, len(g.nodes))
 	for _, n := range g.nodes {
 		state[n.Name] = TaskPending
 	}
 
-	return &Executor{Graph: g, Runner: runner, state: state}, nil
+	gs, err := NewGraphState(g, state)
+	if err != nil {
+		return nil, err
+	}
+	e := &Executor{Graph: g, Runner: runner, state: state, gs: gs}
+	gs.onChange = e.queueEvent
+	return e, nil
 }
 
 // StateSnapshot returns a copy of the current execution state.
@@ -152,65 +357,49 @@ func (e *Executor) RunSerial(ctx context.Context) (*GraphResult, error) {
 		hooks.BeforeRun(ctx)
 		defer hooks.AfterRun(ctx)
 	}
+	// Services still running when the run is aborted are torn down untraced.
+	defer func() { _ = e.stopServices(context.Background(), nil) }()
 
 	rec := trace.NewRecorder()
-	skipCause := make(map[string]string)
+	dedup := newDedupIndex(e.Graph, e.Runner)
 
 	order := make([]string, 0, len(e.Graph.nodes))
 	taskHashes := make(map[string]core.TaskHash, len(e.Graph.nodes))
 	stdout := make(map[string][]byte, len(e.Graph.nodes))
 	stderr := make(map[string][]byte, len(e.Graph.nodes))
 	exitCodes := make(map[string]int, len(e.Graph.nodes))
-
-	// noteSkipped updates the stable skip cause for all currently-skipped downstream nodes.
-	// This is crucial for the "race to failure" case: if multiple upstream failures can skip the same node,
-	// we choose a deterministic cause independent of completion ordering.
-	noteSkipped := func(cause string) error {
-		downstream, err := downstreamReachable(e.Graph, cause)
-		if err != nil {
-			return err
-		}
-		for _, name := range downstream {
-			if e.state[name] != TaskSkipped {
-				continue
-			}
-			prev, ok := skipCause[name]
-			if !ok || cause < prev {
-				skipCause[name] = cause
-			}
-		}
-		return nil
-	}
+	e.mu.Lock()
+	e.exitCodes, e.taskHashes = exitCodes, taskHashes
+	e.mu.Unlock()
 
 	for {
 		// 1) Lock state + 2) poll scheduler
 		e.mu.Lock()
-		ready := GetReadyTasks(e.Graph, e.state)
+		next, ok := e.gs.Next()
 
-		if len(ready) == 0 {
+		if !ok {
 			// No runnable tasks: either we are finished, or deadlocked due to inconsistent state.
-			allTerminal := true
-			for _, st := range e.state {
-				if !IsTerminal(st) {
-					allTerminal = false
-					break
-				}
-			}
-			e.mu.Unlock()
+			allTerminal := e.gs.AllTerminal()
+			e.unlock()
 
 			if allTerminal {
+				if err := e.stopServices(ctx, rec); err != nil {
+					return nil, err
+				}
 				graphHash := e.Graph.Hash().String()
 				// Emit deferred TaskSkipped events in deterministic order.
+				skipCause := skipCauses(e.Graph, e.state)
 				skippedNames := make([]string, 0, len(skipCause))
 				for name := range skipCause {
 					skippedNames = append(skippedNames, name)
 				}
 				sort.Strings(skippedNames)
 				for _, name := range skippedNames {
-					trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskSkipped, TaskID: name, Reason: "UpstreamFailed", CauseTaskID: skipCause[name]})
+					trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskSkipped, TaskID: name, Reason: skipReason(e.state, skipCause[name]), CauseTaskID: skipCause[name]})
 				}
 
 				execTrace := rec.Trace(graphHash)
+				e.annotateEvents(&execTrace, exitCodes)
 				traceBytes, _ := execTrace.CanonicalJSON()
 				traceHash := trace.ComputeTraceHash(traceBytes)
 
@@ -230,25 +419,68 @@ func (e *Executor) RunSerial(ctx context.Context) (*GraphResult, error) {
 			return nil, fmt.Errorf("no ready tasks but graph not finished")
 		}
 
-		next := ready[0]
+		task := e.Graph.nodesByName[next].Task
+
+		// When conditions are decided once the task is ready, before any cache lookup.
+		holds, err := e.conditionHolds(task, exitCodes)
+		if err != nil {
+			e.unlock()
+			return nil, fmt.Errorf("evaluating condition for %q: %w", next, err)
+		}
+		if !holds {
+			trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskSkipped, TaskID: next, Reason: trace.ReasonConditionFalse})
+			_, err = e.gs.SkipUnmetCondition(next)
+			e.unlock()
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		if hooks != nil {
 			hooks.BeforeNode(ctx, next)
 		}
-		task := e.Graph.nodesByName[next].Task
+
+		// A task with the same hash as one already finished shares its result.
+		if primary := dedup.lookup(task); primary != "" {
+			if res := dedup.shared(primary); res != nil {
+				taskHashes[next] = res.Hash
+				stdout[next] = res.Stdout
+				stderr[next] = res.Stderr
+				exitCodes[next] = res.ExitCode
+				checkpoint, err := e.shareDuplicate(rec, next, task, res)
+				if err != nil {
+					e.unlock()
+					return nil, err
+				}
+				obs := e.Observer
+				traceSnap := e.traceSnapshot(rec)
+				e.unlock()
+				if obs != nil && checkpoint {
+					if err := obs.OnTaskTerminal(task, res, traceSnap); err != nil {
+						return nil, err
+					}
+				}
+				if hooks != nil {
+					hooks.AfterNode(ctx, next)
+				}
+				continue
+			}
+		}
 
 		// Incremental plan mode: obey the precomputed decision overlay.
 		if e.Plan != nil {
 			decision := e.Plan.Decisions[next]
 			if decision == incremental.DecisionReuseCache {
 				// Logical decision: cache reuse (explicitly records why the task was not executed).
-				trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskCached, TaskID: next, Reason: "PlannedReuseCache"})
+				trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskCached, TaskID: next, Reason: trace.ReasonPlannedReuseCache})
 
 				// Treat restoration as a deterministic "run" step so failures propagate via Sprint-01 rules.
-				if err := Transition(e.state, next, TaskPending, TaskRunning); err != nil {
-					e.mu.Unlock()
+				if err := e.gs.Transition(next, TaskPending, TaskRunning); err != nil {
+					e.unlock()
 					return nil, err
 				}
-				e.mu.Unlock()
+				e.unlock()
 
 				restoreRunner, ok := e.Runner.(interface {
 					Restore(ctx context.Context, task core.Task) (*NodeResult, error)
@@ -265,18 +497,12 @@ func (e *Executor) RunSerial(ctx context.Context) (*GraphResult, error) {
 					stderr[next] = []byte(err.Error())
 					exitCodes[next] = 1
 					trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskFailed, TaskID: next})
-					ferr := func() error {
-						_, err := FailAndPropagate(e.Graph, e.state, next)
-						if err != nil {
-							return err
-						}
-						return noteSkipped(next)
-					}()
+					_, ferr := e.gs.FailAndPropagate(next)
 					if ferr != nil {
-						e.mu.Unlock()
+						e.unlock()
 						return nil, ferr
 					}
-					e.mu.Unlock()
+					e.unlock()
 					continue
 				}
 				if res == nil {
@@ -285,37 +511,37 @@ func (e *Executor) RunSerial(ctx context.Context) (*GraphResult, error) {
 					stderr[next] = []byte("nil restore result")
 					exitCodes[next] = 1
 					trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskFailed, TaskID: next})
-					ferr := func() error {
-						_, err := FailAndPropagate(e.Graph, e.state, next)
-						if err != nil {
-							return err
-						}
-						return noteSkipped(next)
-					}()
+					_, ferr := e.gs.FailAndPropagate(next)
 					if ferr != nil {
-						e.mu.Unlock()
+						e.unlock()
 						return nil, ferr
 					}
-					e.mu.Unlock()
+					e.unlock()
 					continue
 				}
 
 				e.mu.Lock()
 				order = append(order, next)
+				dedup.record(next, res)
 				taskHashes[next] = res.Hash
 				stdout[next] = res.Stdout
 				stderr[next] = res.Stderr
 				exitCodes[next] = res.ExitCode
+				recordInvalidated(rec, next, res)
 
-				if res.ExitCode == 0 {
-					trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskArtifactsRestored, TaskID: next, Reason: "CacheRestore"})
-					if err := Transition(e.state, next, TaskRunning, TaskCompleted); err != nil {
-						e.mu.Unlock()
+				if task.Succeeded(res.ExitCode) {
+					if res.Invalidated {
+						trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskExecuted, TaskID: next, Reason: executedReason(res, trace.ReasonFreshWork)})
+					} else {
+						trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskArtifactsRestored, TaskID: next, Reason: restoreReason(res, trace.ReasonCacheRestore)})
+					}
+					if err := e.gs.Transition(next, TaskRunning, TaskCompleted); err != nil {
+						e.unlock()
 						return nil, err
 					}
 					obs := e.Observer
-					traceSnap := rec.Snapshot()
-					e.mu.Unlock()
+					traceSnap := e.traceSnapshot(rec)
+					e.unlock()
 					if obs != nil {
 						if err := obs.OnTaskTerminal(task, res, traceSnap); err != nil {
 							return nil, err
@@ -326,15 +552,18 @@ func (e *Executor) RunSerial(ctx context.Context) (*GraphResult, error) {
 					}
 					continue
 				}
-				trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskFailed, TaskID: next})
-				if _, err := FailAndPropagate(e.Graph, e.state, next); err == nil {
-					err = noteSkipped(next)
-				}
+				allowed, err := e.failTask(rec, next, task, res)
 				if err != nil {
-					e.mu.Unlock()
+					e.unlock()
 					return nil, err
 				}
-				e.mu.Unlock()
+				traceSnap := e.traceSnapshot(rec)
+				e.unlock()
+				if allowed {
+					if err := e.notifyObserver(next, res, traceSnap); err != nil {
+						return nil, err
+					}
+				}
 				if hooks != nil {
 					hooks.AfterNode(ctx, next)
 				}
@@ -343,11 +572,11 @@ func (e *Executor) RunSerial(ctx context.Context) (*GraphResult, error) {
 
 			// DecisionExecute: do not probe cache. Always execute.
 			if decision == incremental.DecisionExecute {
-				if err := Transition(e.state, next, TaskPending, TaskRunning); err != nil {
-					e.mu.Unlock()
+				if err := e.gs.Transition(next, TaskPending, TaskRunning); err != nil {
+					e.unlock()
 					return nil, err
 				}
-				e.mu.Unlock()
+				e.unlock()
 
 				runRes, err := e.Runner.Run(ctx, task)
 				if err != nil {
@@ -359,20 +588,22 @@ func (e *Executor) RunSerial(ctx context.Context) (*GraphResult, error) {
 
 				e.mu.Lock()
 				order = append(order, next)
+				dedup.record(next, runRes)
 				taskHashes[next] = runRes.Hash
 				stdout[next] = runRes.Stdout
 				stderr[next] = runRes.Stderr
 				exitCodes[next] = runRes.ExitCode
+				recordInvalidated(rec, next, runRes)
 
-				if runRes.ExitCode == 0 {
-					trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskExecuted, TaskID: next, Reason: "PlannedExecute"})
-					if err := Transition(e.state, next, TaskRunning, TaskCompleted); err != nil {
-						e.mu.Unlock()
+				if task.Succeeded(runRes.ExitCode) {
+					trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskExecuted, TaskID: next, Reason: executedReason(runRes, trace.ReasonPlannedExecute)})
+					if err := e.gs.Transition(next, TaskRunning, TaskCompleted); err != nil {
+						e.unlock()
 						return nil, err
 					}
 					obs := e.Observer
-					traceSnap := rec.Snapshot()
-					e.mu.Unlock()
+					traceSnap := e.traceSnapshot(rec)
+					e.unlock()
 					if obs != nil {
 						if err := obs.OnTaskTerminal(task, runRes, traceSnap); err != nil {
 							return nil, err
@@ -383,15 +614,18 @@ func (e *Executor) RunSerial(ctx context.Context) (*GraphResult, error) {
 					}
 					continue
 				}
-				trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskFailed, TaskID: next})
-				if _, err := FailAndPropagate(e.Graph, e.state, next); err == nil {
-					err = noteSkipped(next)
-				}
+				allowed, err := e.failTask(rec, next, task, runRes)
 				if err != nil {
-					e.mu.Unlock()
+					e.unlock()
 					return nil, err
 				}
-				e.mu.Unlock()
+				traceSnap := e.traceSnapshot(rec)
+				e.unlock()
+				if allowed {
+					if err := e.notifyObserver(next, runRes, traceSnap); err != nil {
+						return nil, err
+					}
+				}
 				if hooks != nil {
 					hooks.AfterNode(ctx, next)
 				}
@@ -402,31 +636,32 @@ func (e *Executor) RunSerial(ctx context.Context) (*GraphResult, error) {
 		// Default mode: probe cache on-the-fly.
 		probeRes, cached, err := e.Runner.Probe(ctx, task)
 		if err != nil {
-			e.mu.Unlock()
+			e.unlock()
 			return nil, fmt.Errorf("probing cache for %q: %w", next, err)
 		}
 		if cached {
 			if probeRes == nil {
-				e.mu.Unlock()
+				e.unlock()
 				return nil, fmt.Errorf("probing cache for %q: nil result", next)
 			}
-			if err := Transition(e.state, next, TaskPending, TaskCached); err != nil {
-				e.mu.Unlock()
+			if err := e.gs.Transition(next, TaskPending, TaskCached); err != nil {
+				e.unlock()
 				return nil, err
 			}
-			trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskCached, TaskID: next, Reason: "CacheHit"})
-			trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskArtifactsRestored, TaskID: next, Reason: "CacheReplay"})
+			trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskCached, TaskID: next, Reason: trace.ReasonCacheHit})
+			trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskArtifactsRestored, TaskID: next, Reason: restoreReason(probeRes, trace.ReasonCacheReplay)})
+			dedup.record(next, probeRes)
 			taskHashes[next] = probeRes.Hash
 			stdout[next] = probeRes.Stdout
 			stderr[next] = probeRes.Stderr
 			exitCodes[next] = probeRes.ExitCode
 			obs := e.Observer
-			traceSnap := rec.Snapshot()
-			e.mu.Unlock()
+			traceSnap := e.traceSnapshot(rec)
+			e.unlock()
 			if hooks != nil {
 				hooks.AfterNode(ctx, next)
 			}
-			if obs != nil && probeRes.ExitCode == 0 {
+			if obs != nil && task.Succeeded(probeRes.ExitCode) {
 				if err := obs.OnTaskTerminal(task, probeRes, traceSnap); err != nil {
 					return nil, err
 				}
@@ -434,11 +669,11 @@ func (e *Executor) RunSerial(ctx context.Context) (*GraphResult, error) {
 			continue
 		}
 
-		if err := Transition(e.state, next, TaskPending, TaskRunning); err != nil {
-			e.mu.Unlock()
+		if err := e.gs.Transition(next, TaskPending, TaskRunning); err != nil {
+			e.unlock()
 			return nil, err
 		}
-		e.mu.Unlock()
+		e.unlock()
 
 		// 3) execute task (outside lock)
 		runRes, err := e.Runner.Run(ctx, task)
@@ -452,20 +687,22 @@ func (e *Executor) RunSerial(ctx context.Context) (*GraphResult, error) {
 		// 4) update state (under lock)
 		e.mu.Lock()
 		order = append(order, next)
+		dedup.record(next, runRes)
 		taskHashes[next] = runRes.Hash
 		stdout[next] = runRes.Stdout
 		stderr[next] = runRes.Stderr
 		exitCodes[next] = runRes.ExitCode
+		recordInvalidated(rec, next, runRes)
 
-		if runRes.ExitCode == 0 {
-			trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskExecuted, TaskID: next, Reason: "FreshWork"})
-			if err := Transition(e.state, next, TaskRunning, TaskCompleted); err != nil {
-				e.mu.Unlock()
+		if task.Succeeded(runRes.ExitCode) {
+			trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskExecuted, TaskID: next, Reason: executedReason(runRes, trace.ReasonFreshWork)})
+			if err := e.gs.Transition(next, TaskRunning, TaskCompleted); err != nil {
+				e.unlock()
 				return nil, err
 			}
 			obs := e.Observer
-			traceSnap := rec.Snapshot()
-			e.mu.Unlock()
+			traceSnap := e.traceSnapshot(rec)
+			e.unlock()
 			if obs != nil {
 				if err := obs.OnTaskTerminal(task, runRes, traceSnap); err != nil {
 					return nil, err
@@ -477,16 +714,19 @@ func (e *Executor) RunSerial(ctx context.Context) (*GraphResult, error) {
 			continue
 		}
 
-		// Failure: mark failed and propagate skipped.
-		trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskFailed, TaskID: next})
-		if _, err := FailAndPropagate(e.Graph, e.state, next); err == nil {
-			err = noteSkipped(next)
-		}
+		// Failure: mark failed and propagate skipped (unless the task allows failure).
+		allowed, err := e.failTask(rec, next, task, runRes)
 		if err != nil {
-			e.mu.Unlock()
+			e.unlock()
 			return nil, err
 		}
-		e.mu.Unlock()
+		traceSnap := e.traceSnapshot(rec)
+		e.unlock()
+		if allowed {
+			if err := e.notifyObserver(next, runRes, traceSnap); err != nil {
+				return nil, err
+			}
+		}
 		if hooks != nil {
 			hooks.AfterNode(ctx, next)
 		}
@@ -527,26 +767,11 @@ func (e *Executor) RunParallel(ctx context.Context, concurrency int) (*GraphResu
 		hooks.BeforeRun(ctx)
 		defer hooks.AfterRun(ctx)
 	}
+	// Services still running when the run is aborted are torn down untraced.
+	defer func() { _ = e.stopServices(context.Background(), nil) }()
 
 	rec := trace.NewRecorder()
-	skipCause := make(map[string]string)
-
-	noteSkipped := func(cause string) error {
-		downstream, err := downstreamReachable(e.Graph, cause)
-		if err != nil {
-			return err
-		}
-		for _, name := range downstream {
-			if e.state[name] != TaskSkipped {
-				continue
-			}
-			prev, ok := skipCause[name]
-			if !ok || cause < prev {
-				skipCause[name] = cause
-			}
-		}
-		return nil
-	}
+	dedup := newDedupIndex(e.Graph, e.Runner)
 
 	maxDepth := 0
 	for _, d := range e.Graph.depth {
@@ -608,19 +833,11 @@ func (e *Executor) RunParallel(ctx context.Context, concurrency int) (*GraphResu
 	stdout := make(map[string][]byte, len(e.Graph.nodes))
 	stderr := make(map[string][]byte, len(e.Graph.nodes))
 	exitCodes := make(map[string]int, len(e.Graph.nodes))
+	e.mu.Lock()
+	e.exitCodes, e.taskHashes = exitCodes, taskHashes
+	e.mu.Unlock()
 	inFlight := 0
 
-	// Helper: check dependency success for a node index.
-	depsSatisfied := func(idx int) bool {
-		for _, p := range e.Graph.incoming[idx] {
-			pst := e.state[e.Graph.nodes[p].Name]
-			if !IsSuccessful(pst) {
-				return false
-			}
-		}
-		return true
-	}
-
 	// Coordinator loop: stage by depth.
 	for depth := 0; depth <= maxDepth; depth++ {
 		names := byDepth[depth]
@@ -640,16 +857,55 @@ func (e *Executor) RunParallel(ctx context.Context, concurrency int) (*GraphResu
 					continue
 				}
 				if st != TaskPending {
-					e.mu.Unlock()
+					e.unlock()
 					stopWorkers()
 					return nil, fmt.Errorf("unexpected non-pending state for %q: %s", name, st)
 				}
-				if !depsSatisfied(node.canonicalIndex) {
-					e.mu.Unlock()
+				if !e.gs.DepsSatisfied(name) {
+					e.unlock()
 					stopWorkers()
 					return nil, fmt.Errorf("task %q at depth %d is pending but dependencies are not successful", name, depth)
 				}
 
+				holds, err := e.conditionHolds(node.Task, exitCodes)
+				if err != nil {
+					e.unlock()
+					stopWorkers()
+					return nil, fmt.Errorf("evaluating condition for %q: %w", name, err)
+				}
+				if !holds {
+					trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskSkipped, TaskID: name, Reason: trace.ReasonConditionFalse})
+					_, err = e.gs.SkipUnmetCondition(name)
+					if err != nil {
+						e.unlock()
+						stopWorkers()
+						return nil, err
+					}
+					nextToStart++
+					continue
+				}
+
+				// A task with the same hash as one already dispatched shares
+				// its result; dispatch stalls until a running primary completes.
+				if primary := dedup.lookup(node.Task); primary != "" {
+					if e.state[primary] == TaskRunning {
+						break
+					}
+					if res := dedup.shared(primary); res != nil {
+						taskHashes[name] = res.Hash
+						stdout[name] = res.Stdout
+						stderr[name] = res.Stderr
+						exitCodes[name] = res.ExitCode
+						if _, err := e.shareDuplicate(rec, name, node.Task, res); err != nil {
+							e.unlock()
+							stopWorkers()
+							return nil, err
+						}
+						nextToStart++
+						continue
+					}
+				}
+
 				// Incremental plan mode: do not probe cache; schedule based on decision.
 				reuseCache := false
 				if e.Plan != nil {
@@ -657,23 +913,24 @@ func (e *Executor) RunParallel(ctx context.Context, concurrency int) (*GraphResu
 				} else {
 					res, cached, err := e.Runner.Probe(ctx, node.Task)
 					if err != nil {
-						e.mu.Unlock()
+						e.unlock()
 						stopWorkers()
 						return nil, fmt.Errorf("probing cache for %q: %w", name, err)
 					}
 					if cached {
 						if res == nil {
-							e.mu.Unlock()
+							e.unlock()
 							stopWorkers()
 							return nil, fmt.Errorf("probing cache for %q: nil result", name)
 						}
-						if err := Transition(e.state, name, TaskPending, TaskCached); err != nil {
-							e.mu.Unlock()
+						if err := e.gs.Transition(name, TaskPending, TaskCached); err != nil {
+							e.unlock()
 							stopWorkers()
 							return nil, err
 						}
-						trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskCached, TaskID: name, Reason: "CacheHit"})
-						trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskArtifactsRestored, TaskID: name, Reason: "CacheReplay"})
+						trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskCached, TaskID: name, Reason: trace.ReasonCacheHit})
+						trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskArtifactsRestored, TaskID: name, Reason: restoreReason(res, trace.ReasonCacheReplay)})
+						dedup.record(name, res)
 						taskHashes[name] = res.Hash
 						stdout[name] = res.Stdout
 						stderr[name] = res.Stderr
@@ -685,15 +942,15 @@ func (e *Executor) RunParallel(ctx context.Context, concurrency int) (*GraphResu
 
 				if reuseCache {
 						// Logical decision: cache reuse (explicitly records why the task was not executed).
-						trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskCached, TaskID: name, Reason: "PlannedReuseCache"})
+						trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskCached, TaskID: name, Reason: trace.ReasonPlannedReuseCache})
 				}
 
 				if hooks != nil {
 					hooks.BeforeNode(ctx, name)
 				}
 
-				if err := Transition(e.state, name, TaskPending, TaskRunning); err != nil {
-					e.mu.Unlock()
+				if err := e.gs.Transition(name, TaskPending, TaskRunning); err != nil {
+					e.unlock()
 					stopWorkers()
 					return nil, err
 				}
@@ -705,7 +962,7 @@ func (e *Executor) RunParallel(ctx context.Context, concurrency int) (*GraphResu
 
 			// Are we done with this depth stage?
 			stageDone := (nextToStart >= len(names) && inFlight == 0)
-			e.mu.Unlock()
+			e.unlock()
 			if stageDone {
 				break
 			}
@@ -728,53 +985,77 @@ func (e *Executor) RunParallel(ctx context.Context, concurrency int) (*GraphResu
 				e.mu.Lock()
 				cur := e.state[r.name]
 				if cur != TaskRunning {
-					e.mu.Unlock()
+					e.unlock()
 					stopWorkers()
 					return nil, fmt.Errorf("completion for %q but state is %s", r.name, cur)
 				}
 
 				// Record result data.
+				dedup.record(r.name, r.result)
 				taskHashes[r.name] = r.result.Hash
 				stdout[r.name] = r.result.Stdout
 				stderr[r.name] = r.result.Stderr
 				exitCodes[r.name] = r.result.ExitCode
+				recordInvalidated(rec, r.name, r.result)
 
-				if r.result.ExitCode == 0 {
-					if e.Plan != nil && (e.Plan.Decisions[r.name] == incremental.DecisionReuseCache) {
-						trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskArtifactsRestored, TaskID: r.name, Reason: "CacheRestore"})
+				if task := e.Graph.nodesByName[r.name].Task; task.Succeeded(r.result.ExitCode) {
+					if e.Plan != nil && (e.Plan.Decisions[r.name] == incremental.DecisionReuseCache) && !r.result.Invalidated {
+						trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskArtifactsRestored, TaskID: r.name, Reason: restoreReason(r.result, trace.ReasonCacheRestore)})
 						// Do NOT emit TaskExecuted for cached reuse.
-						if err := Transition(e.state, r.name, TaskRunning, TaskCompleted); err != nil {
-							e.mu.Unlock()
+						if err := e.gs.Transition(r.name, TaskRunning, TaskCompleted); err != nil {
+							e.unlock()
 							stopWorkers()
 							return nil, err
 						}
 						inFlight--
-						e.mu.Unlock()
+						traceSnap := e.traceSnapshot(rec)
+						e.unlock()
+						if err := e.notifyObserver(r.name, r.result, traceSnap); err != nil {
+							stopWorkers()
+							return nil, err
+						}
 						continue
 					}
-					trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskExecuted, TaskID: r.name, Reason: "FreshWork"})
-					if err := Transition(e.state, r.name, TaskRunning, TaskCompleted); err != nil {
-						e.mu.Unlock()
+					trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskExecuted, TaskID: r.name, Reason: executedReason(r.result, trace.ReasonFreshWork)})
+					if err := e.gs.Transition(r.name, TaskRunning, TaskCompleted); err != nil {
+						e.unlock()
 						stopWorkers()
 						return nil, err
 					}
+					inFlight--
+					traceSnap := e.traceSnapshot(rec)
+					e.unlock()
+					if err := e.notifyObserver(r.name, r.result, traceSnap); err != nil {
+						stopWorkers()
+						return nil, err
+					}
+					if hooks != nil {
+						hooks.AfterNode(ctx, r.name)
+					}
+					continue
 				} else {
-					trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskFailed, TaskID: r.name})
-						ferr := func() error {
-							_, err := FailAndPropagate(e.Graph, e.state, r.name)
-							if err != nil {
-								return err
-							}
-							return noteSkipped(r.name)
-						}()
-						if ferr != nil {
-						e.mu.Unlock()
+					allowed, ferr := e.failTask(rec, r.name, e.Graph.nodesByName[r.name].Task, r.result)
+					if ferr != nil {
+						e.unlock()
 						stopWorkers()
-							return nil, ferr
+						return nil, ferr
+					}
+					if allowed {
+						inFlight--
+						traceSnap := e.traceSnapshot(rec)
+						e.unlock()
+						if err := e.notifyObserver(r.name, r.result, traceSnap); err != nil {
+							stopWorkers()
+							return nil, err
+						}
+						if hooks != nil {
+							hooks.AfterNode(ctx, r.name)
+						}
+						continue
 					}
 				}
 				inFlight--
-				e.mu.Unlock()
+				e.unlock()
 				if hooks != nil {
 					hooks.AfterNode(ctx, r.name)
 				}
@@ -783,20 +1064,25 @@ func (e *Executor) RunParallel(ctx context.Context, concurrency int) (*GraphResu
 	}
 
 	stopWorkers()
+	if err := e.stopServices(ctx, rec); err != nil {
+		return nil, err
+	}
 
 	final := e.StateSnapshot()
 	graphHash := e.Graph.Hash().String()
 	// Emit deferred TaskSkipped events in deterministic order.
+	skipCause := skipCauses(e.Graph, final)
 	skippedNames := make([]string, 0, len(skipCause))
 	for name := range skipCause {
 		skippedNames = append(skippedNames, name)
 	}
 	sort.Strings(skippedNames)
 	for _, name := range skippedNames {
-		trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskSkipped, TaskID: name, Reason: "UpstreamFailed", CauseTaskID: skipCause[name]})
+		trace.SafeRecord(rec, trace.TraceEvent{Kind: trace.EventTaskSkipped, TaskID: name, Reason: skipReason(final, skipCause[name]), CauseTaskID: skipCause[name]})
 	}
 
 	execTrace := rec.Trace(graphHash)
+	e.annotateEvents(&execTrace, exitCodes)
 	traceBytes, _ := execTrace.CanonicalJSON()
 	traceHash := trace.ComputeTraceHash(traceBytes)
 	return &GraphResult{
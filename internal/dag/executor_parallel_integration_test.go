@@ -3,13 +3,16 @@ package dag
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"reflect"
 	"runtime"
+	"sort"
 	"sync"
 	"testing"
 	"time"
 
 	"scriptweaver/internal/core"
+	"scriptweaver/internal/trace"
 )
 
 type sleepyCountingRunner struct {
@@ -293,3 +296,93 @@ func TestExecutorParallel_RaceToFailure_StableSkipCauseAndTrace(t *testing.T) {
 		t.Fatalf("expected TaskSkipped for C")
 	}
 }
+
+type collectingObserver struct {
+	names []string
+}
+
+func (o *collectingObserver) OnTaskTerminal(task core.Task, _ *NodeResult, _ []trace.TraceEvent) error {
+	o.names = append(o.names, task.Name)
+	return nil
+}
+
+func TestExecutorParallel_NotifiesObserverOnSuccess(t *testing.T) {
+	g, err := NewTaskGraph([]core.Task{
+		{Name: "A", Run: "a"},
+		{Name: "B", Run: "b"},
+		{Name: "C", Run: "c"},
+	}, []Edge{{From: "A", To: "C"}})
+	if err != nil {
+		t.Fatalf("NewTaskGraph: %v", err)
+	}
+	exec, err := NewExecutor(g, &fakeRunner{exit: map[string]int{"B": 1}})
+	if err != nil {
+		t.Fatalf("NewExecutor: %v", err)
+	}
+	obs := &collectingObserver{}
+	exec.Observer = obs
+	if _, err := exec.RunParallel(context.Background(), 2); err != nil {
+		t.Fatalf("RunParallel: %v", err)
+	}
+	sort.Strings(obs.names)
+	if !reflect.DeepEqual(obs.names, []string{"A", "C"}) {
+		t.Fatalf("expected observer for successful tasks only, got %v", obs.names)
+	}
+}
+
+// layeredBenchGraph is a 10k-node graph of 100 layers, each task depending
+// on two tasks of the layer above.
+func layeredBenchGraph(b *testing.B) *TaskGraph {
+	b.Helper()
+	const layers, width = 100, 100
+	tasks := make([]core.Task, 0, layers*width)
+	edges := make([]Edge, 0, (layers-1)*width*2)
+	for l := 0; l < layers; l++ {
+		for w := 0; w < width; w++ {
+			name := fmt.Sprintf("t%03d_%03d", l, w)
+			tasks = append(tasks, core.Task{Name: name, Run: name})
+			if l > 0 {
+				edges = append(edges,
+					Edge{From: fmt.Sprintf("t%03d_%03d", l-1, w), To: name},
+					Edge{From: fmt.Sprintf("t%03d_%03d", l-1, (w+1)%width), To: name})
+			}
+		}
+	}
+	g, err := NewTaskGraph(tasks, edges)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return g
+}
+
+// BenchmarkExecutor_RunParallel measures scheduler and trace overhead on a
+// 10k-node layered graph whose tasks do no work.
+func BenchmarkExecutor_RunParallel(b *testing.B) {
+	g := layeredBenchGraph(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		exec, err := NewExecutor(g, &fakeRunner{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := exec.RunParallel(context.Background(), 8); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkExecutor_RunSerial is BenchmarkExecutor_RunParallel for the
+// serial loop, which polls the ready set after every task.
+func BenchmarkExecutor_RunSerial(b *testing.B) {
+	g := layeredBenchGraph(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		exec, err := NewExecutor(g, &fakeRunner{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := exec.RunSerial(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
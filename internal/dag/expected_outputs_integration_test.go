@@ -0,0 +1,63 @@
+package dag
+
+import (
+	"context"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/trace"
+)
+
+func TestExecutor_ExpectedOutputMismatch_FailsTaskOnCacheHit(t *testing.T) {
+	workDir := t.TempDir()
+	cacheRunner, err := NewCacheAwareRunner(core.NewRunner(workDir, core.NewMemoryCache()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks := func(digest string) []core.Task {
+		return []core.Task{
+			{Name: "A", Run: "printf v1 > a.txt", Outputs: []string{"a.txt"}, ExpectedOutputs: map[string]string{"a.txt": digest}},
+			{Name: "B", Run: "true"},
+		}
+	}
+	run := func(digest string, parallel bool) *GraphResult {
+		t.Helper()
+		g, err := NewTaskGraph(tasks(digest), []Edge{{From: "A", To: "B"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		exec, err := NewExecutor(g, cacheRunner)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var res *GraphResult
+		if parallel {
+			res, err = exec.RunParallel(context.Background(), 2)
+		} else {
+			res, err = exec.RunSerial(context.Background())
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return res
+	}
+
+	const good = "3bfc269594ef649228e9a74bab00f042efc91d5acc6fbee31a382e80d42388fe"
+	const bad = "0000000000000000000000000000000000000000000000000000000000000000"
+	if res := run(good, false); res.FinalState["A"] != TaskCompleted || res.FinalState["B"] != TaskCompleted {
+		t.Fatalf("expected a clean run, got %v", res.FinalState)
+	}
+	for _, parallel := range []bool{false, true} {
+		res := run(bad, parallel)
+		if res.FinalState["A"] != TaskFailed || res.FinalState["B"] != TaskSkipped {
+			t.Fatalf("parallel=%v: expected A failed and B skipped, got %v", parallel, res.FinalState)
+		}
+		if !hasTraceEvent(t, res.TraceBytes, trace.EventTaskFailed, "A", trace.ReasonExpectedOutputMismatch) {
+			t.Fatalf("parallel=%v: expected ExpectedOutputMismatch, trace=%s", parallel, res.TraceBytes)
+		}
+	}
+
+	if _, err := NewTaskGraph([]core.Task{{Name: "A", Run: "true", ExpectedOutputs: map[string]string{"a.txt": good}}}, nil); err == nil {
+		t.Fatalf("expected an error for an expectation outside the declared outputs")
+	}
+}
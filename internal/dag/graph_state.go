@@ -0,0 +1,194 @@
+package dag
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+)
+
+// GraphState is the mutable runtime status for a specific execution attempt.
+//
+// It is designed so that the same TaskGraph can be executed multiple times
+// without mutating the graph definition.
+//
+// Besides the per-task status it memoizes, per task, how many dependencies
+// are not yet successful, the set of ready tasks and the number of
+// non-terminal tasks. Every state change must go through its methods so the
+// counters stay in step; in exchange Ready is O(ready) rather than a rescan
+// of the graph, Next is O(log ready), and AllTerminal and DepsSatisfied are
+// O(1).
+type GraphState struct {
+	// Status holds per-node state keyed by task name. It must only be read
+	// directly; mutate it through the GraphState methods.
+	Status ExecutionState
+
+	g           *TaskGraph
+	unsatisfied []int  // by canonical index
+	ready       []bool // by canonical index
+	rank        []int  // by canonical index: position in (depth, name) order
+	byRank      []int  // rank -> canonical index
+	queue       intMinHeap
+	nonTerminal int
+
+	// onChange, when set, is called after every state change.
+	onChange func(name string, from, to TaskState)
+}
+
+// NewGraphState builds the memoized state for status, which must hold a
+// state for every task in g.
+func NewGraphState(g *TaskGraph, status ExecutionState) (*GraphState, error) {
+	if g == nil {
+		return nil, fmt.Errorf("nil graph")
+	}
+	s := &GraphState{
+		Status:      status,
+		g:           g,
+		unsatisfied: make([]int, len(g.nodes)),
+		ready:       make([]bool, len(g.nodes)),
+		rank:        make([]int, len(g.nodes)),
+		byRank:      make([]int, len(g.nodes)),
+	}
+	for i := range s.byRank {
+		s.byRank[i] = i
+	}
+	sort.Slice(s.byRank, func(i, j int) bool {
+		a, b := g.nodes[s.byRank[i]], g.nodes[s.byRank[j]]
+		if g.depth[a.canonicalIndex] != g.depth[b.canonicalIndex] {
+			return g.depth[a.canonicalIndex] < g.depth[b.canonicalIndex]
+		}
+		return a.Name < b.Name
+	})
+	for r, idx := range s.byRank {
+		s.rank[idx] = r
+	}
+	for _, n := range g.nodes {
+		st, ok := status[n.Name]
+		if !ok {
+			return nil, fmt.Errorf("missing state for %q", n.Name)
+		}
+		if !IsTerminal(st) {
+			s.nonTerminal++
+		}
+		for _, p := range g.incoming[n.canonicalIndex] {
+			if !IsSuccessful(status[g.nodes[p].Name]) {
+				s.unsatisfied[n.canonicalIndex]++
+			}
+		}
+		if st == TaskPending && s.unsatisfied[n.canonicalIndex] == 0 {
+			s.markReady(n.canonicalIndex)
+		}
+	}
+	return s, nil
+}
+
+// markReady adds the task at idx to the ready set. The queue drops entries
+// for tasks that are no longer ready lazily, in Next.
+func (s *GraphState) markReady(idx int) {
+	s.ready[idx] = true
+	heap.Push(&s.queue, s.rank[idx])
+}
+
+// Ready returns the tasks eligible to run, in the same order and under the
+// same policy as GetReadyTasks.
+func (s *GraphState) Ready() []string {
+	ranks := make([]int, 0, len(s.queue))
+	for _, r := range s.queue {
+		if s.ready[s.byRank[r]] {
+			ranks = append(ranks, r)
+		}
+	}
+	sort.Ints(ranks)
+	ready := make([]string, len(ranks))
+	for i, r := range ranks {
+		ready[i] = s.g.nodes[s.byRank[r]].Name
+	}
+	return ready
+}
+
+// Next returns the first task Ready would return, if any, without building
+// the whole list.
+func (s *GraphState) Next() (string, bool) {
+	for s.queue.Len() > 0 {
+		idx := s.byRank[s.queue[0]]
+		if s.ready[idx] {
+			return s.g.nodes[idx].Name, true
+		}
+		heap.Pop(&s.queue)
+	}
+	return "", false
+}
+
+// AllTerminal reports whether every task has reached a terminal state.
+func (s *GraphState) AllTerminal() bool {
+	return s.nonTerminal == 0
+}
+
+// DepsSatisfied reports whether every dependency of name is successful.
+func (s *GraphState) DepsSatisfied(name string) bool {
+	n, ok := s.g.nodesByName[name]
+	return ok && s.unsatisfied[n.canonicalIndex] == 0
+}
+
+// Transition applies Transition to the status and updates the counters.
+func (s *GraphState) Transition(name string, from, to TaskState) error {
+	if err := Transition(s.Status, name, from, to); err != nil {
+		return err
+	}
+	s.changed(name, from, to)
+	return nil
+}
+
+// FailAndPropagate applies FailAndPropagate to the status and updates the
+// counters for the failed task and every task it skipped.
+func (s *GraphState) FailAndPropagate(name string) ([]string, error) {
+	from := s.Status[name]
+	skipped, err := FailAndPropagate(s.g, s.Status, name)
+	if err != nil {
+		return nil, err
+	}
+	s.changed(name, from, TaskFailed)
+	for _, d := range skipped {
+		s.changed(d, TaskPending, TaskSkipped)
+	}
+	return skipped, nil
+}
+
+// SkipUnmetCondition applies SkipUnmetCondition to the status and updates
+// the counters for the task and every task it skipped.
+func (s *GraphState) SkipUnmetCondition(name string) ([]string, error) {
+	skipped, err := SkipUnmetCondition(s.g, s.Status, name)
+	if err != nil {
+		return nil, err
+	}
+	s.changed(name, TaskPending, TaskConditionFalse)
+	for _, d := range skipped {
+		s.changed(d, TaskPending, TaskSkipped)
+	}
+	return skipped, nil
+}
+
+// changed updates the counters after name moved from one state to another.
+func (s *GraphState) changed(name string, from, to TaskState) {
+	if from == to {
+		return
+	}
+	if s.onChange != nil {
+		s.onChange(name, from, to)
+	}
+	idx := s.g.nodesByName[name].canonicalIndex
+	if from == TaskPending {
+		s.ready[idx] = false
+	}
+	if !IsTerminal(from) && IsTerminal(to) {
+		s.nonTerminal--
+	}
+	if IsSuccessful(from) || !IsSuccessful(to) {
+		return
+	}
+	for _, c := range s.g.outgoing[idx] {
+		s.unsatisfied[c]--
+		if s.unsatisfied[c] == 0 && s.Status[s.g.nodes[c].Name] == TaskPending {
+			s.markReady(c)
+		}
+	}
+}
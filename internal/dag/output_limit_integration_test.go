@@ -0,0 +1,54 @@
+package dag
+
+import (
+	"context"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/trace"
+)
+
+func TestExecutor_OutputTruncatedIsTraced(t *testing.T) {
+	workDir := t.TempDir()
+	tasks := []core.Task{
+		{Name: "A", Run: "yes | head -c 1000", MaxOutputBytes: 8},
+		{Name: "B", Run: "yes | head -c 1000; exit 3", MaxOutputBytes: 8},
+		{Name: "C", Run: "echo ok"},
+	}
+	for _, parallel := range []bool{false, true} {
+		cacheRunner, err := NewCacheAwareRunner(core.NewRunner(workDir, core.NewMemoryCache()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		g, err := NewTaskGraph(tasks, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		exec, err := NewExecutor(g, cacheRunner)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var res *GraphResult
+		if parallel {
+			res, err = exec.RunParallel(context.Background(), 3)
+		} else {
+			res, err = exec.RunSerial(context.Background())
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !hasTraceEvent(t, res.TraceBytes, trace.EventTaskExecuted, "A", trace.ReasonOutputTruncated) {
+			t.Fatalf("parallel=%v: expected A executed with OutputTruncated, trace=%s", parallel, res.TraceBytes)
+		}
+		if !hasTraceEvent(t, res.TraceBytes, trace.EventTaskFailed, "B", trace.ReasonOutputTruncated) {
+			t.Fatalf("parallel=%v: expected B failed with OutputTruncated, trace=%s", parallel, res.TraceBytes)
+		}
+		if !hasTraceEvent(t, res.TraceBytes, trace.EventTaskExecuted, "C", trace.ReasonFreshWork) {
+			t.Fatalf("parallel=%v: expected C executed with FreshWork, trace=%s", parallel, res.TraceBytes)
+		}
+	}
+
+	if _, err := NewTaskGraph([]core.Task{{Name: "A", Run: "true", MaxOutputBytes: -1}}, nil); err == nil {
+		t.Fatalf("expected a negative limit to be rejected")
+	}
+}
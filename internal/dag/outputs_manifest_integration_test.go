@@ -0,0 +1,161 @@
+package dag
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/incremental"
+	"scriptweaver/internal/trace"
+)
+
+// staleEntryFixture runs task A once, then rewrites its cache entry as if it had
+// been produced under an older output declaration set.
+func staleEntryFixture(t *testing.T) (string, *TaskGraph, *CacheAwareRunner) {
+	t.Helper()
+	workDir := t.TempDir()
+	cache := core.NewMemoryCache()
+	cacheRunner, err := NewCacheAwareRunner(core.NewRunner(workDir, cache))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	g, err := NewTaskGraph([]core.Task{{Name: "A", Run: "printf v1 > a.txt", Outputs: []string{"a.txt"}}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, err := cacheRunner.Run(context.Background(), g.nodesByName["A"].Task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entry, err := cache.Get(res.Hash)
+	if err != nil || entry == nil {
+		t.Fatalf("expected cache entry (err=%v)", err)
+	}
+	entry.DeclaredOutputs = []string{"a.txt", "b.txt"}
+	entry.Artifacts = append(entry.Artifacts, core.CachedArtifact{Path: "b.txt", Content: []byte("old")})
+	if err := cache.Put(entry); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	return workDir, g, cacheRunner
+}
+
+func hasTraceEvent(t *testing.T, traceBytes []byte, kind trace.TraceEventKind, taskID, reason string) bool {
+	t.Helper()
+	var tr struct {
+		Events []struct {
+			Kind   string `json:"kind"`
+			TaskID string `json:"taskId"`
+			Reason string `json:"reason"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(traceBytes, &tr); err != nil {
+		t.Fatalf("unmarshal trace: %v", err)
+	}
+	for _, e := range tr.Events {
+		if e.Kind == string(kind) && e.TaskID == taskID && e.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func TestExecutorSerial_StaleOutputsManifest_ForcesExecution(t *testing.T) {
+	workDir, g, cacheRunner := staleEntryFixture(t)
+
+	exec, err := NewExecutor(g, cacheRunner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, err := exec.RunSerial(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.FinalState["A"] != TaskCompleted {
+		t.Fatalf("expected A executed, got %s", res.FinalState["A"])
+	}
+	if !hasTraceEvent(t, res.TraceBytes, trace.EventTaskInvalidated, "A", trace.ReasonDeclaredOutputsChanged) {
+		t.Fatalf("expected TaskInvalidated event, trace=%s", res.TraceBytes)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "b.txt")); !os.IsNotExist(err) {
+		t.Fatalf("stale artifact restored, stat err=%v", err)
+	}
+}
+
+func TestExecutor_PlannedReuse_StaleOutputsManifest_ForcesExecution(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		workDir, g, cacheRunner := staleEntryFixture(t)
+
+		exec, err := NewExecutor(g, cacheRunner)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		exec.Plan = &incremental.IncrementalPlan{
+			Order:     []string{"A"},
+			Decisions: map[string]incremental.NodeExecutionDecision{"A": incremental.DecisionReuseCache},
+		}
+		var res *GraphResult
+		if parallel {
+			res, err = exec.RunParallel(context.Background(), 2)
+		} else {
+			res, err = exec.RunSerial(context.Background())
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.FinalState["A"] != TaskCompleted {
+			t.Fatalf("parallel=%v: expected A completed, got %s", parallel, res.FinalState["A"])
+		}
+		if !hasTraceEvent(t, res.TraceBytes, trace.EventTaskInvalidated, "A", trace.ReasonDeclaredOutputsChanged) {
+			t.Fatalf("parallel=%v: expected TaskInvalidated event, trace=%s", parallel, res.TraceBytes)
+		}
+		if hasTraceEvent(t, res.TraceBytes, trace.EventTaskArtifactsRestored, "A", trace.ReasonCacheRestore) {
+			t.Fatalf("parallel=%v: stale entry must not be reported as restored", parallel)
+		}
+		if _, err := os.Stat(filepath.Join(workDir, "b.txt")); !os.IsNotExist(err) {
+			t.Fatalf("parallel=%v: stale artifact restored, stat err=%v", parallel, err)
+		}
+	}
+}
+
+func TestExecutorSerial_CorruptEntry_HealedAndTraced(t *testing.T) {
+	workDir := t.TempDir()
+	cache := core.NewFileCache(t.TempDir())
+	runner := core.NewRunner(workDir, cache)
+	runner.HealCorruptEntries = true
+	cacheRunner, err := NewCacheAwareRunner(runner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	g, err := NewTaskGraph([]core.Task{{Name: "A", Run: "printf v1 > a.txt", Outputs: []string{"a.txt"}}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first, err := cacheRunner.Run(context.Background(), g.nodesByName["A"].Task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cache.CacheDir, string(first.Hash)[:2], string(first.Hash), "metadata.json"), []byte("{"), 0o644); err != nil {
+		t.Fatalf("corrupt metadata: %v", err)
+	}
+
+	exec, err := NewExecutor(g, cacheRunner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, err := exec.RunSerial(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.FinalState["A"] != TaskCompleted {
+		t.Fatalf("expected A executed, got %s", res.FinalState["A"])
+	}
+	if !hasTraceEvent(t, res.TraceBytes, trace.EventTaskInvalidated, "A", trace.ReasonCacheCorrupt) {
+		t.Fatalf("expected CacheCorrupt invalidation, trace=%s", res.TraceBytes)
+	}
+	if _, err := cache.Get(first.Hash); err != nil {
+		t.Fatalf("expected entry rewritten, got %v", err)
+	}
+}
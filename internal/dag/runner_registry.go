@@ -0,0 +1,139 @@
+package dag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"scriptweaver/internal/core"
+)
+
+// RunnerRegistry dispatches each task to the TaskRunner registered for its
+// kind (core.Task.Kind), so one graph can mix shell, container, plugin or
+// other runners. It is itself a TaskRunner and can be handed to an Executor.
+//
+// Tasks with an empty kind use the core.KindShell runner. Registration is
+// expected to complete before the graph runs; the registry is not safe for
+// concurrent mutation.
+type RunnerRegistry struct {
+	runners map[string]TaskRunner
+}
+
+// NewRunnerRegistry returns a registry with shell registered as the runner
+// for core.KindShell (and therefore for tasks without a kind).
+func NewRunnerRegistry(shell TaskRunner) (*RunnerRegistry, error) {
+	r := &RunnerRegistry{runners: make(map[string]TaskRunner)}
+	if err := r.Register(core.KindShell, shell); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Register assigns runner to kind. Registering a kind twice is an error.
+func (r *RunnerRegistry) Register(kind string, runner TaskRunner) error {
+	if kind == "" {
+		return fmt.Errorf("runner kind is required")
+	}
+	if runner == nil {
+		return fmt.Errorf("nil runner for kind %q", kind)
+	}
+	if _, exists := r.runners[kind]; exists {
+		return fmt.Errorf("runner for kind %q is already registered", kind)
+	}
+	r.runners[kind] = runner
+	return nil
+}
+
+// Kinds returns the registered kinds, sorted.
+func (r *RunnerRegistry) Kinds() []string {
+	kinds := make([]string, 0, len(r.runners))
+	for k := range r.runners {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Lookup returns the runner for task's kind.
+func (r *RunnerRegistry) Lookup(task core.Task) (TaskRunner, error) {
+	kind := task.NormalizedKind()
+	runner, ok := r.runners[kind]
+	if !ok {
+		return nil, fmt.Errorf("task %q: no runner registered for kind %q", task.Name, kind)
+	}
+	return runner, nil
+}
+
+// Validate checks that every task in g has a registered runner, so a graph
+// with an unknown kind is rejected before any task executes.
+func (r *RunnerRegistry) Validate(g *TaskGraph) error {
+	if g == nil {
+		return invalidf("nil graph")
+	}
+	for _, n := range g.Nodes() {
+		if _, ok := r.runners[n.Task.NormalizedKind()]; !ok {
+			return invalidf("task %q: unknown kind %q (registered: %v)", n.Name, n.Task.NormalizedKind(), r.Kinds())
+		}
+	}
+	return nil
+}
+
+func (r *RunnerRegistry) Probe(ctx context.Context, task core.Task) (*NodeResult, bool, error) {
+	runner, err := r.Lookup(task)
+	if err != nil {
+		return nil, false, err
+	}
+	return runner.Probe(ctx, task)
+}
+
+func (r *RunnerRegistry) Run(ctx context.Context, task core.Task) (*NodeResult, error) {
+	runner, err := r.Lookup(task)
+	if err != nil {
+		return nil, err
+	}
+	return runner.Run(ctx, task)
+}
+
+// Restore delegates to the kind's runner when it supports planned cache
+// reuse (see CacheAwareRunner.Restore).
+func (r *RunnerRegistry) Restore(ctx context.Context, task core.Task) (*NodeResult, error) {
+	runner, err := r.Lookup(task)
+	if err != nil {
+		return nil, err
+	}
+	restorer, ok := runner.(interface {
+		Restore(ctx context.Context, task core.Task) (*NodeResult, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("runner for kind %q does not support Restore", task.NormalizedKind())
+	}
+	return restorer.Restore(ctx, task)
+}
+
+// TaskHash delegates to the kind's runner when it computes hashes ahead of
+// running (see HashRunner); tasks of other kinds are never deduplicated.
+func (r *RunnerRegistry) TaskHash(task core.Task) (core.TaskHash, error) {
+	runner, err := r.Lookup(task)
+	if err != nil {
+		return "", err
+	}
+	hasher, ok := runner.(HashRunner)
+	if !ok {
+		return "", nil
+	}
+	return hasher.TaskHash(task)
+}
+
+// StopService delegates to the kind's runner when it leaves services running
+// (see ServiceStopper).
+func (r *RunnerRegistry) StopService(ctx context.Context, task core.Task) (bool, error) {
+	runner, err := r.Lookup(task)
+	if err != nil {
+		return false, err
+	}
+	stopper, ok := runner.(ServiceStopper)
+	if !ok {
+		return false, nil
+	}
+	return stopper.StopService(ctx, task)
+}
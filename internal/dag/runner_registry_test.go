@@ -0,0 +1,88 @@
+package dag
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+// kindRunner records which tasks it ran.
+type kindRunner struct {
+	ran []string
+}
+
+func (r *kindRunner) Probe(_ context.Context, _ core.Task) (*NodeResult, bool, error) {
+	return nil, false, nil
+}
+
+func (r *kindRunner) Run(_ context.Context, task core.Task) (*NodeResult, error) {
+	r.ran = append(r.ran, task.Name)
+	return &NodeResult{Hash: core.TaskHash("hash:" + task.Name)}, nil
+}
+
+func TestRunnerRegistry_DispatchesByKind(t *testing.T) {
+	shell, http := &kindRunner{}, &kindRunner{}
+	reg, err := NewRunnerRegistry(shell)
+	if err != nil {
+		t.Fatalf("NewRunnerRegistry: %v", err)
+	}
+	if err := reg.Register("http-call", http); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := reg.Register("http-call", http); err == nil {
+		t.Fatalf("expected duplicate registration to fail")
+	}
+
+	g, err := NewTaskGraph([]core.Task{
+		{Name: "A", Run: "echo a"},
+		{Name: "B", Run: "echo b", Kind: core.KindShell},
+		{Name: "C", Run: "GET /health", Kind: "http-call"},
+	}, []Edge{{From: "A", To: "C"}})
+	if err != nil {
+		t.Fatalf("NewTaskGraph: %v", err)
+	}
+	if err := reg.Validate(g); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	exec, err := NewExecutor(g, reg)
+	if err != nil {
+		t.Fatalf("NewExecutor: %v", err)
+	}
+	if _, err := exec.RunSerial(context.Background()); err != nil {
+		t.Fatalf("RunSerial: %v", err)
+	}
+	if len(shell.ran) != 2 || len(http.ran) != 1 || http.ran[0] != "C" {
+		t.Fatalf("unexpected dispatch: shell=%v http=%v", shell.ran, http.ran)
+	}
+
+	// Restore requires the selected runner to support it.
+	if _, err := reg.Restore(context.Background(), core.Task{Name: "C", Run: "x", Kind: "http-call"}); err == nil {
+		t.Fatalf("expected Restore error for a runner without Restore")
+	}
+}
+
+func TestRunnerRegistry_ValidateRejectsUnknownKind(t *testing.T) {
+	reg, err := NewRunnerRegistry(&kindRunner{})
+	if err != nil {
+		t.Fatalf("NewRunnerRegistry: %v", err)
+	}
+	g, err := NewTaskGraph([]core.Task{{Name: "A", Run: "x", Kind: "plugin"}}, nil)
+	if err != nil {
+		t.Fatalf("NewTaskGraph: %v", err)
+	}
+	if err := reg.Validate(g); !errors.Is(err, ErrInvalidGraph) {
+		t.Fatalf("expected ErrInvalidGraph, got %v", err)
+	}
+}
+
+func TestTaskDefHash_KindAffectsDefinition(t *testing.T) {
+	base := computeTaskDefHash(core.Task{Inputs: []string{"in"}, Run: "run"})
+	if got := computeTaskDefHash(core.Task{Inputs: []string{"in"}, Run: "run", Kind: core.KindShell}); got != base {
+		t.Fatalf("explicit shell kind must not change the definition hash")
+	}
+	if got := computeTaskDefHash(core.Task{Inputs: []string{"in"}, Run: "run", Kind: "plugin"}); got == base {
+		t.Fatalf("expected kind to change the definition hash")
+	}
+}
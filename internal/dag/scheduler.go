@@ -14,7 +14,8 @@ type ExecutionState map[string]TaskState
 // eligible to run.
 //
 // Policy:
-//   - A task is ready iff it is PENDING and all its dependencies are COMPLETED or CACHED.
+//   - A task is ready iff it is PENDING and all its dependencies are COMPLETED,
+//     CACHED or FAILED_ALLOWED (see IsSuccessful).
 //   - The returned list is sorted by (topological depth asc, task name asc).
 //
 // This function is pure: it does not mutate graph or state.
@@ -35,7 +36,7 @@ func GetReadyTasks(g *TaskGraph, state ExecutionState) []string {
 		for _, parentIdx := range g.incoming[idx] {
 			parentName := g.nodes[parentIdx].Name
 			pst, ok := state[parentName]
-			if !ok || (pst != TaskCompleted && pst != TaskCached) {
+			if !ok || !IsSuccessful(pst) {
 				depsOK = false
 				break
 			}
@@ -102,3 +102,70 @@ func TestScheduler_DiamondConvergence_WaitsForAllParents(t *testing.T) {
 		t.Fatalf("unexpected ready list after C cached: %v", got)
 	}
 }
+
+func TestGraphState_ReadyMatchesGetReadyTasks(t *testing.T) {
+	// A -> B -> D, A -> C -> D, E -> F, C -> G.
+	g, err := NewTaskGraph(
+		[]core.Task{
+			{Name: "A", Run: "run-a"},
+			{Name: "B", Run: "run-b"},
+			{Name: "C", Run: "run-c"},
+			{Name: "D", Run: "run-d"},
+			{Name: "E", Run: "run-e"},
+			{Name: "F", Run: "run-f"},
+			{Name: "G", Run: "run-g"},
+		},
+		[]Edge{{From: "A", To: "B"}, {From: "A", To: "C"}, {From: "B", To: "D"}, {From: "C", To: "D"}, {From: "E", To: "F"}, {From: "C", To: "G"}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state := ExecutionState{}
+	for _, name := range g.TopologicalOrder() {
+		state[name] = TaskPending
+	}
+	gs, err := NewGraphState(g, state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	check := func(step string) {
+		t.Helper()
+		want := GetReadyTasks(g, state)
+		if got := gs.Ready(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: Ready() = %v, want %v", step, got, want)
+		}
+		next, ok := gs.Next()
+		if ok != (len(want) > 0) || (ok && next != want[0]) {
+			t.Fatalf("%s: Next() = %q, %v; want first of %v", step, next, ok, want)
+		}
+	}
+	run := func(name string, to TaskState) {
+		t.Helper()
+		if err := gs.Transition(name, TaskPending, TaskRunning); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if to == TaskFailed {
+			if _, err := gs.FailAndPropagate(name); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		} else if err := gs.Transition(name, TaskRunning, to); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		check("after " + name)
+	}
+
+	check("start")
+	run("A", TaskCompleted)
+	run("E", TaskFailed)
+	if !gs.DepsSatisfied("B") || gs.DepsSatisfied("D") {
+		t.Fatalf("unexpected DepsSatisfied for B/D")
+	}
+	run("B", TaskCompleted)
+	if _, err := gs.SkipUnmetCondition("C"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	check("after C condition false")
+	if !gs.AllTerminal() {
+		t.Fatalf("expected all terminal, state=%v", state)
+	}
+}
@@ -0,0 +1,167 @@
+package dag
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/trace"
+)
+
+func newServiceRegistry(t *testing.T, workDir string) *RunnerRegistry {
+	t.Helper()
+	runner := core.NewRunner(workDir, core.NewMemoryCache())
+	cacheRunner, err := NewCacheAwareRunner(runner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	services, err := NewServiceRunner(runner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	registry, err := NewRunnerRegistry(cacheRunner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := registry.Register(core.KindService, services); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return registry
+}
+
+func TestExecutor_ServiceRunsUntilDependentsFinishThenStops(t *testing.T) {
+	tasks := []core.Task{
+		{
+			Name:    "db",
+			Kind:    core.KindService,
+			Run:     "trap 'echo down > db.state; exit 0' TERM; echo up > db.state; while :; do sleep 0.05; done",
+			Service: &core.ServiceConfig{Ready: "test -f db.state && echo ready", ReadyIntervalMillis: 10},
+		},
+		{Name: "it", Run: "grep -q up db.state && echo passed > it.out", Outputs: []string{"it.out"}},
+	}
+	edges := []Edge{{From: "db", To: "it"}}
+	for _, parallel := range []bool{false, true} {
+		workDir := t.TempDir()
+		g, err := NewTaskGraph(tasks, edges)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		exec, err := NewExecutor(g, newServiceRegistry(t, workDir))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var res *GraphResult
+		if parallel {
+			res, err = exec.RunParallel(context.Background(), 2)
+		} else {
+			res, err = exec.RunSerial(context.Background())
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.FinalState["db"] != TaskCompleted || res.FinalState["it"] != TaskCompleted {
+			t.Fatalf("parallel=%v: final state = %v", parallel, res.FinalState)
+		}
+		if got := string(res.Stdout["db"]); got != "ready\n" {
+			t.Fatalf("parallel=%v: db stdout = %q, want readiness output", parallel, got)
+		}
+		state, err := os.ReadFile(filepath.Join(workDir, "db.state"))
+		if err != nil {
+			t.Fatalf("read db.state: %v", err)
+		}
+		if strings.TrimSpace(string(state)) != "down" {
+			t.Fatalf("parallel=%v: db.state = %q, want service torn down", parallel, state)
+		}
+		if !hasTraceEvent(t, res.TraceBytes, trace.EventTaskExecuted, "db", trace.ReasonServiceReady) {
+			t.Fatalf("parallel=%v: expected db executed with ServiceReady, trace=%s", parallel, res.TraceBytes)
+		}
+		if !hasTraceEvent(t, res.TraceBytes, trace.EventServiceStopped, "db", "") {
+			t.Fatalf("parallel=%v: expected ServiceStopped for db, trace=%s", parallel, res.TraceBytes)
+		}
+	}
+}
+
+func TestExecutor_ServiceIsNeverCached(t *testing.T) {
+	workDir := t.TempDir()
+	registry := newServiceRegistry(t, workDir)
+	tasks := []core.Task{{
+		Name:    "db",
+		Kind:    core.KindService,
+		Run:     "trap 'rm -f ready; exit 0' TERM; echo start >> starts.log; touch ready; while :; do sleep 0.05; done",
+		Service: &core.ServiceConfig{Ready: "test -f ready", ReadyIntervalMillis: 10},
+	}}
+	g, err := NewTaskGraph(tasks, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		exec, err := NewExecutor(g, registry)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		res, err := exec.RunSerial(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.FinalState["db"] != TaskCompleted {
+			t.Fatalf("run %d: db state = %s, want %s", i, res.FinalState["db"], TaskCompleted)
+		}
+	}
+	starts, err := os.ReadFile(filepath.Join(workDir, "starts.log"))
+	if err != nil {
+		t.Fatalf("read starts.log: %v", err)
+	}
+	if n := strings.Count(string(starts), "start"); n != 2 {
+		t.Fatalf("service started %d times, want 2 (services are not cached)", n)
+	}
+}
+
+func TestExecutor_ServiceNeverReadyFailsAndSkipsDependents(t *testing.T) {
+	tasks := []core.Task{
+		{
+			Name:    "db",
+			Kind:    core.KindService,
+			Run:     "while :; do sleep 0.05; done",
+			Service: &core.ServiceConfig{Ready: "exit 3", ReadyAttempts: 2, ReadyIntervalMillis: 1},
+		},
+		{Name: "it", Run: "true"},
+	}
+	g, err := NewTaskGraph(tasks, []Edge{{From: "db", To: "it"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exec, err := NewExecutor(g, newServiceRegistry(t, t.TempDir()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, err := exec.RunSerial(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.FinalState["db"] != TaskFailed || res.FinalState["it"] != TaskSkipped {
+		t.Fatalf("final state = %v", res.FinalState)
+	}
+	if res.ExitCode["db"] != 3 {
+		t.Fatalf("db exit code = %d, want last readiness exit code 3", res.ExitCode["db"])
+	}
+	if hasTraceEvent(t, res.TraceBytes, trace.EventServiceStopped, "db", "") {
+		t.Fatalf("unexpected ServiceStopped for a service that never became ready, trace=%s", res.TraceBytes)
+	}
+}
+
+func TestNewTaskGraph_ValidatesServiceSettings(t *testing.T) {
+	cases := map[string]core.Task{
+		"no readiness":      {Name: "db", Kind: core.KindService, Run: "true"},
+		"outputs":           {Name: "db", Kind: core.KindService, Run: "true", Outputs: []string{"x"}, Service: &core.ServiceConfig{Ready: "true"}},
+		"settings on shell": {Name: "db", Run: "true", Service: &core.ServiceConfig{Ready: "true"}},
+	}
+	for name, task := range cases {
+		if _, err := NewTaskGraph([]core.Task{task}, nil); !errors.Is(err, ErrInvalidGraph) {
+			t.Fatalf("%s: expected invalid graph error, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package dag
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"scriptweaver/internal/core"
+)
+
+// ServiceStopper is implemented by runners that leave core.KindService tasks
+// running after Run returns. Once the graph has finished (or the run is
+// aborted), the executor stops every service task, dependents first, and
+// records a ServiceStopped event for each one that was still running.
+type ServiceStopper interface {
+	StopService(ctx context.Context, task core.Task) (stopped bool, err error)
+}
+
+// ServiceRunner runs core.KindService tasks: Run starts the service, waits
+// for its readiness command and leaves it running until StopService.
+//
+// Services are never cached: Probe always misses, Restore starts the service
+// like Run, and no artifacts are harvested.
+type ServiceRunner struct {
+	Runner *core.Runner
+
+	mu       sync.Mutex
+	services map[string]*core.Service
+}
+
+func NewServiceRunner(r *core.Runner) (*ServiceRunner, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil core runner")
+	}
+	if r.Executor == nil {
+		return nil, fmt.Errorf("nil core executor")
+	}
+	return &ServiceRunner{Runner: r, services: make(map[string]*core.Service)}, nil
+}
+
+func (r *ServiceRunner) Probe(ctx context.Context, task core.Task) (*NodeResult, bool, error) {
+	return nil, false, nil
+}
+
+func (r *ServiceRunner) Run(ctx context.Context, task core.Task) (*NodeResult, error) {
+	hash, _, err := r.Runner.TaskHash(&task)
+	if err != nil {
+		return nil, err
+	}
+	// A service re-run in the same process replaces the previous instance.
+	if _, err := r.StopService(ctx, task); err != nil {
+		return nil, err
+	}
+	svc, res, err := r.Runner.Executor.StartService(ctx, &task)
+	if err != nil {
+		return nil, err
+	}
+	if svc != nil {
+		r.mu.Lock()
+		r.services[task.Name] = svc
+		r.mu.Unlock()
+	}
+	return &NodeResult{
+		Hash:            hash,
+		Stdout:          res.Stdout,
+		Stderr:          res.Stderr,
+		ExitCode:        res.ExitCode,
+		OutputTruncated: res.OutputTruncated,
+		ServiceReady:    svc != nil,
+	}, nil
+}
+
+// Restore starts the service: there is nothing cached to restore.
+func (r *ServiceRunner) Restore(ctx context.Context, task core.Task) (*NodeResult, error) {
+	return r.Run(ctx, task)
+}
+
+// StopService tears down task's service if it is running.
+func (r *ServiceRunner) StopService(ctx context.Context, task core.Task) (bool, error) {
+	r.mu.Lock()
+	svc, ok := r.services[task.Name]
+	delete(r.services, task.Name)
+	r.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	return true, svc.Stop()
+}
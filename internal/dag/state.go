@@ -16,13 +16,12 @@ const (
 	TaskFailed    TaskState = "FAILED"
 	TaskSkipped   TaskState = "SKIPPED"
 	TaskCached    TaskState = "CACHED"
-)
 
-// GraphState is the mutable runtime status for a specific execution attempt.
-//
-// It is designed so that the same TaskGraph can be executed multiple times
-// without mutating the graph definition.
-type GraphState struct {
-	// Status holds per-node state keyed by task name.
-	Status map[string]TaskState
-}
+	// TaskConditionFalse is the terminal state of a task whose When
+	// condition did not hold; it was never run.
+	TaskConditionFalse TaskState = "CONDITION_FALSE"
+
+	// TaskFailedAllowed is the terminal state of a failed task that allows
+	// failure; it satisfies its dependents like a successful one.
+	TaskFailedAllowed TaskState = "FAILED_ALLOWED"
+)
@@ -8,17 +8,18 @@ import (
 // IsTerminal reports whether the state is terminal (finished).
 func IsTerminal(s TaskState) bool {
 	switch s {
-	case TaskCompleted, TaskFailed, TaskSkipped, TaskCached:
+	case TaskCompleted, TaskFailed, TaskSkipped, TaskCached, TaskConditionFalse, TaskFailedAllowed:
 		return true
 	default:
 		return false
 	}
 }
 
-// IsSuccessful reports whether the state satisfies dependencies.
+// IsSuccessful reports whether the state satisfies dependencies. An allowed
+// failure does, though it is not a success for When conditions.
 func IsSuccessful(s TaskState) bool {
 	switch s {
-	case TaskCompleted, TaskCached:
+	case TaskCompleted, TaskCached, TaskFailedAllowed:
 		return true
 	default:
 		return false
@@ -47,9 +48,9 @@ func Transition(state ExecutionState, taskName string, from, to TaskState) error
 func isAllowedTransition(from, to TaskState) bool {
 	switch from {
 	case TaskPending:
-		return to == TaskRunning || to == TaskCached || to == TaskSkipped
+		return to == TaskRunning || to == TaskCached || to == TaskSkipped || to == TaskConditionFalse
 	case TaskRunning:
-		return to == TaskCompleted || to == TaskFailed
+		return to == TaskCompleted || to == TaskFailed || to == TaskFailedAllowed
 	default:
 		return false
 	}
@@ -84,7 +85,32 @@ func FailAndPropagate(g *TaskGraph, state ExecutionState, taskName string) ([]st
 	if cur == TaskRunning {
 		state[taskName] = TaskFailed
 	}
+	return skipDownstream(g, state, node)
+}
+
+// SkipUnmetCondition transitions taskName from PENDING to CONDITION_FALSE
+// and marks all downstream dependents as SKIPPED, like FailAndPropagate.
+func SkipUnmetCondition(g *TaskGraph, state ExecutionState, taskName string) ([]string, error) {
+	if g == nil {
+		return nil, fmt.Errorf("nil graph")
+	}
+	node, ok := g.nodesByName[taskName]
+	if !ok {
+		return nil, fmt.Errorf("unknown task: %q", taskName)
+	}
+	if err := Transition(state, taskName, TaskPending, TaskConditionFalse); err != nil {
+		return nil, err
+	}
+	return skipDownstream(g, state, node)
+}
 
+// skipDownstream marks every PENDING task reachable from node as SKIPPED,
+// in canonical index order.
+//
+// The traversal stops at tasks that were already SKIPPED: whoever skipped
+// them also skipped everything below them, so each task is expanded at most
+// once per run however many of its ancestors fail.
+func skipDownstream(g *TaskGraph, state ExecutionState, node *TaskNode) ([]string, error) {
 	start := node.canonicalIndex
 	visited := make([]bool, len(g.nodes))
 	visited[start] = true
@@ -114,6 +140,8 @@ func FailAndPropagate(g *TaskGraph, state ExecutionState, taskName string) ([]st
 		case TaskPending:
 			state[name] = TaskSkipped
 			skipped = append(skipped, name)
+		case TaskSkipped:
+			continue
 		case TaskRunning:
 			return nil, fmt.Errorf("invariant violation: downstream task %q is RUNNING during failure propagation", name)
 		default:
@@ -129,3 +157,39 @@ func FailAndPropagate(g *TaskGraph, state ExecutionState, taskName string) ([]st
 
 	return skipped, nil
 }
+
+// skipCauses returns the cause of every SKIPPED task in state: the
+// lexicographically smallest FAILED or CONDITION_FALSE task it is downstream
+// of. The result does not depend on which failure happened first, so it is
+// stable under any completion order.
+//
+// Every task between a cause and a task it skipped was itself skipped, so
+// one pass in topological order that carries the smallest cause along
+// SKIPPED tasks finds them all in O(V+E).
+func skipCauses(g *TaskGraph, state ExecutionState) map[string]string {
+	causes := make(map[string]string)
+	for _, u := range g.topoOrderIndices() {
+		name := g.nodes[u].Name
+		if state[name] != TaskSkipped {
+			continue
+		}
+		best := ""
+		for _, p := range g.incoming[u] {
+			up := g.nodes[p].Name
+			cause := ""
+			switch state[up] {
+			case TaskFailed, TaskConditionFalse:
+				cause = up
+			case TaskSkipped:
+				cause = causes[up]
+			}
+			if cause != "" && (best == "" || cause < best) {
+				best = cause
+			}
+		}
+		if best != "" {
+			causes[name] = best
+		}
+	}
+	return causes
+}
@@ -144,3 +144,45 @@ func TestFailurePropagation_DetectsRunningDownstreamInvariantViolation(t *testin
 		t.Fatalf("expected error")
 	}
 }
+
+func TestSkipCauses_SmallestCauseRegardlessOfFailureOrder(t *testing.T) {
+	// A -> C -> D, B -> C; E -> F (condition false on E).
+	g, err := NewTaskGraph(
+		[]core.Task{
+			{Name: "A", Run: "run-a"},
+			{Name: "B", Run: "run-b"},
+			{Name: "C", Run: "run-c"},
+			{Name: "D", Run: "run-d"},
+			{Name: "E", Run: "run-e"},
+			{Name: "F", Run: "run-f"},
+		},
+		[]Edge{{From: "A", To: "C"}, {From: "B", To: "C"}, {From: "C", To: "D"}, {From: "E", To: "F"}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, first := range []string{"A", "B"} {
+		second := map[string]string{"A": "B", "B": "A"}[first]
+		state := ExecutionState{"A": TaskRunning, "B": TaskRunning, "C": TaskPending, "D": TaskPending, "E": TaskPending, "F": TaskPending}
+		if _, err := FailAndPropagate(g, state, first); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// The second failure finds C and D already skipped.
+		skipped, err := FailAndPropagate(g, state, second)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(skipped) != 0 {
+			t.Fatalf("first=%s: second failure skipped %v, want nothing new", first, skipped)
+		}
+		if _, err := SkipUnmetCondition(g, state, "E"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := map[string]string{"C": "A", "D": "A", "F": "E"}
+		if got := skipCauses(g, state); !reflect.DeepEqual(got, want) {
+			t.Fatalf("first=%s: skipCauses = %v, want %v", first, got, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package dag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/trace"
+)
+
+func TestExecutor_SuccessExitCodesAreAccepted(t *testing.T) {
+	tasks := []core.Task{
+		{Name: "A", Run: "echo found > out.txt; exit 1", Outputs: []string{"out.txt"}, SuccessExitCodes: []int{1}},
+		{Name: "B", Run: "true", Inputs: []string{"out.txt"}},
+		{Name: "C", Run: "exit 2", SuccessExitCodes: []int{1}},
+	}
+	edges := []Edge{{From: "A", To: "B"}}
+	for _, parallel := range []bool{false, true} {
+		workDir := t.TempDir()
+		cache := core.NewMemoryCache()
+		for attempt := 0; attempt < 2; attempt++ {
+			if attempt == 1 {
+				// The accepted result is cached with its artifacts.
+				if err := os.Remove(filepath.Join(workDir, "out.txt")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			cacheRunner, err := NewCacheAwareRunner(core.NewRunner(workDir, cache))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			g, err := NewTaskGraph(tasks, edges)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			exec, err := NewExecutor(g, cacheRunner)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var res *GraphResult
+			if parallel {
+				res, err = exec.RunParallel(context.Background(), 2)
+			} else {
+				res, err = exec.RunSerial(context.Background())
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !IsSuccessful(res.FinalState["A"]) || !IsSuccessful(res.FinalState["B"]) || res.ExitCode["A"] != 1 {
+				t.Fatalf("parallel=%v attempt=%d: A=%s (exit %d) B=%s", parallel, attempt, res.FinalState["A"], res.ExitCode["A"], res.FinalState["B"])
+			}
+			if attempt == 0 && res.FinalState["C"] != TaskFailed {
+				t.Fatalf("parallel=%v attempt=%d: exit 2 is not accepted, C=%s", parallel, attempt, res.FinalState["C"])
+			}
+			tr, err := trace.ParseExecutionTrace(res.TraceBytes)
+			if err != nil {
+				t.Fatalf("parse trace: %v", err)
+			}
+			var recorded bool
+			for _, ev := range tr.Events {
+				if ev.TaskID == "A" && ev.ExitCode == 1 && (ev.Kind == trace.EventTaskExecuted || ev.Kind == trace.EventTaskArtifactsRestored) {
+					recorded = true
+				}
+			}
+			if !recorded {
+				t.Fatalf("parallel=%v attempt=%d: accepted exit code missing from trace=%s", parallel, attempt, res.TraceBytes)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(workDir, "out.txt")); err != nil {
+			t.Fatalf("parallel=%v: accepted artifact not restored: %v", parallel, err)
+		}
+	}
+
+	for _, codes := range [][]int{{0}, {256}, {core.ExpectedOutputsExitCode}, {1, 1}} {
+		if _, err := NewTaskGraph([]core.Task{{Name: "A", Run: "true", SuccessExitCodes: codes}}, nil); err == nil {
+			t.Fatalf("expected success exit codes %v to be rejected", codes)
+		}
+	}
+}
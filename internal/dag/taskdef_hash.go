@@ -4,16 +4,22 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"sort"
+	"strconv"
+
+	"scriptweaver/internal/core"
 )
 
-// computeTaskDefHash hashes only the declarative definition fields required by the
-// DAG prompt: inputs, env, run.
+// computeTaskDefHash hashes only the declarative definition fields of t
+// required by the DAG prompt: inputs, env, run (plus the container image, a
+// non-default runner kind, normalize rules, When condition, AllowFailure,
+// service settings, effect key and network mode, when set). The name and all
+// other fields are ignored.
 //
 // Determinism rules:
 //   - Inputs are treated as a set for identity and thus sorted.
 //   - Env map is sorted by key.
 //   - All fields are length-prefixed to avoid ambiguity.
-func computeTaskDefHash(inputs []string, env map[string]string, run string) TaskDefHash {
+func computeTaskDefHash(t core.Task) TaskDefHash {
 	h := sha256.New()
 
 	writeField := func(data []byte) {
@@ -33,8 +39,8 @@ func computeTaskDefHash(inputs []string, env map[string]string, run string) Task
 	}
 
 	// Inputs (sorted)
-	sortedInputs := make([]string, len(inputs))
-	copy(sortedInputs, inputs)
+	sortedInputs := make([]string, len(t.Inputs))
+	copy(sortedInputs, t.Inputs)
 	sort.Strings(sortedInputs)
 	writeField([]byte{byte(len(sortedInputs))})
 	for _, in := range sortedInputs {
@@ -42,19 +48,76 @@ func computeTaskDefHash(inputs []string, env map[string]string, run string) Task
 	}
 
 	// Env (sorted)
-	envKeys := make([]string, 0, len(env))
-	for k := range env {
+	envKeys := make([]string, 0, len(t.Env))
+	for k := range t.Env {
 		envKeys = append(envKeys, k)
 	}
 	sort.Strings(envKeys)
 	writeField([]byte{byte(len(envKeys))})
 	for _, k := range envKeys {
 		writeField([]byte(k))
-		writeField([]byte(env[k]))
+		writeField([]byte(t.Env[k]))
 	}
 
 	// Run
-	writeField([]byte(run))
+	writeField([]byte(t.Run))
+
+	// Image (only when set, keeping host-task hashes stable)
+	if t.Image != "" {
+		writeField([]byte(t.Image))
+	}
+
+	// Kind (only when not the default, keeping shell-task hashes stable)
+	if t.Kind != "" && t.Kind != core.KindShell {
+		writeField([]byte("kind"))
+		writeField([]byte(t.Kind))
+	}
+
+	// Normalize rules (only when set, in declaration order)
+	if len(t.Normalize) > 0 {
+		writeField([]byte("normalize"))
+		writeField([]byte{byte(len(t.Normalize))})
+		for _, rule := range t.Normalize {
+			writeField([]byte(rule.Pattern))
+			writeField([]byte(rule.Replacement))
+		}
+	}
+
+	// When condition (only when set; clauses in declaration order)
+	if t.When != nil {
+		writeField([]byte("when"))
+		for _, clause := range [][]string{t.When.UpstreamSucceeded, t.When.InputsExist, t.When.Equals} {
+			writeField([]byte{byte(len(clause))})
+			for _, v := range clause {
+				writeField([]byte(v))
+			}
+		}
+	}
+
+	// AllowFailure (only when set)
+	if t.AllowFailure {
+		writeField([]byte("allow_failure"))
+	}
+
+	// Service settings (only when set)
+	if t.Service != nil {
+		writeField([]byte("service"))
+		writeField([]byte(t.Service.Ready))
+		writeField([]byte(strconv.Itoa(t.Service.ReadyAttempts)))
+		writeField([]byte(strconv.Itoa(t.Service.ReadyIntervalMillis)))
+	}
+
+	// Effect key (only when set)
+	if t.EffectKey != "" {
+		writeField([]byte("effect_key"))
+		writeField([]byte(t.EffectKey))
+	}
+
+	// Network mode (only when set)
+	if t.Network != "" {
+		writeField([]byte("network"))
+		writeField([]byte(t.Network))
+	}
 
 	sum := h.Sum(nil)
 	return TaskDefHash(hex.EncodeToString(sum))
@@ -38,6 +38,7 @@ type TaskGraph struct {
 //   - duplicate edges
 //   - self-loops
 //   - any cycle (direct or indirect)
+//   - overlapping outputs of tasks no edge orders
 func NewTaskGraph(tasks []core.Task, edges []Edge) (*TaskGraph, error) {
 	if len(tasks) == 0 {
 		return nil, invalidf("no tasks")
@@ -53,8 +54,39 @@ func NewTaskGraph(tasks []core.Task, edges []Edge) (*TaskGraph, error) {
 		if _, exists := nodesByName[t.Name]; exists {
 			return nil, invalidf("duplicate task name: %q", t.Name)
 		}
+		if err := t.ValidateExpectedOutputs(); err != nil {
+			return nil, invalidf("task %q: %v", t.Name, err)
+		}
+		if err := t.ValidateNormalizeRules(); err != nil {
+			return nil, invalidf("task %q: %v", t.Name, err)
+		}
+		if t.MaxOutputBytes < 0 {
+			return nil, invalidf("task %q: max_output_bytes must not be negative", t.Name)
+		}
 
-		defHash := computeTaskDefHash(t.Inputs, t.Env, t.Run)
+		if err := t.ValidateSuccessExitCodes(); err != nil {
+			return nil, invalidf("task %q: %v", t.Name, err)
+		}
+		if err := t.ValidateWhen(); err != nil {
+			return nil, invalidf("task %q: %v", t.Name, err)
+		}
+		if err := t.ValidateService(); err != nil {
+			return nil, invalidf("task %q: %v", t.Name, err)
+		}
+		if err := t.ValidateStdin(); err != nil {
+			return nil, invalidf("task %q: %v", t.Name, err)
+		}
+		if err := t.ValidateTools(); err != nil {
+			return nil, invalidf("task %q: %v", t.Name, err)
+		}
+		if err := t.ValidateRemoteInputs(); err != nil {
+			return nil, invalidf("task %q: %v", t.Name, err)
+		}
+		if err := t.ValidateNetwork(); err != nil {
+			return nil, invalidf("task %q: %v", t.Name, err)
+		}
+
+		defHash := computeTaskDefHash(t)
 		node := &TaskNode{Name: t.Name, Task: t, DefinitionHash: defHash}
 		nodesByName[t.Name] = node
 		nodes = append(nodes, node)
@@ -137,12 +169,52 @@ func NewTaskGraph(tasks []core.Task, edges []Edge) (*TaskGraph, error) {
 		return nil, err
 	}
 
+	if err := g.validateConditions(); err != nil {
+		return nil, err
+	}
+
+	if err := g.validateOutputs(true); err != nil {
+		return nil, err
+	}
+
 	g.depth = g.computeDepth()
 
 	g.hash = g.computeGraphHash()
 	return g, nil
 }
 
+// validateConditions rejects When clauses that wait on a task which is not
+// upstream of the conditioned task, since its outcome would not be known yet.
+func (g *TaskGraph) validateConditions() error {
+	for _, n := range g.nodes {
+		if n.Task.When == nil {
+			continue
+		}
+		for _, up := range n.Task.When.UpstreamSucceeded {
+			if _, ok := g.nodesByName[up]; !ok {
+				return invalidf("task %q: when.upstream_succeeded references unknown task %q", n.Name, up)
+			}
+			downstream, err := downstreamReachable(g, up)
+			if err != nil {
+				return err
+			}
+			if !containsString(downstream, n.Name) {
+				return invalidf("task %q: when.upstream_succeeded %q is not upstream of it", n.Name, up)
+			}
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 // Hash returns the stable identity for this graph.
 func (g *TaskGraph) Hash() GraphHash { return g.hash }
 
@@ -208,6 +280,59 @@ func (g *TaskGraph) TopologicalOrder() []string {
 }
 
 func (g *TaskGraph) computeGraphHash() GraphHash {
+	all := make([]bool, len(g.nodes))
+	for i := range all {
+		all[i] = true
+	}
+	return g.hashSubgraph(all)
+}
+
+// SubtreeHash returns a stable hash over task's upstream closure: the
+// definitions of task and every task it transitively depends on, and the
+// edges between them. Tasks outside the closure do not affect it, so equal
+// subtree hashes identify the same part of two graphs. Like Hash it ignores
+// task names and declaration order; for a task that every other task is
+// upstream of, it equals Hash. It reports false for an unknown task.
+func (g *TaskGraph) SubtreeHash(task string) (GraphHash, bool) {
+	n, ok := g.nodesByName[task]
+	if !ok {
+		return "", false
+	}
+	closure := make([]bool, len(g.nodes))
+	stack := []int{n.canonicalIndex}
+	closure[n.canonicalIndex] = true
+	for len(stack) > 0 {
+		u := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, p := range g.incoming[u] {
+			if !closure[p] {
+				closure[p] = true
+				stack = append(stack, p)
+			}
+		}
+	}
+	return g.hashSubgraph(closure), true
+}
+
+// hashSubgraph hashes the nodes i with keep[i] and the edges between them.
+// Nodes are renumbered in canonical order, so a subgraph's hash does not
+// depend on the rest of the graph.
+func (g *TaskGraph) hashSubgraph(keep []bool) GraphHash {
+	local := make([]int, len(g.nodes))
+	count := 0
+	for i := range g.nodes {
+		if keep[i] {
+			local[i] = count
+			count++
+		}
+	}
+	var edges []edgeIndex
+	for _, e := range g.edges {
+		if keep[e.from] && keep[e.to] {
+			edges = append(edges, edgeIndex{from: local[e.from], to: local[e.to]})
+		}
+	}
+
 	h := sha256.New()
 
 	writeField := func(data []byte) {
@@ -227,14 +352,16 @@ func (g *TaskGraph) computeGraphHash() GraphHash {
 	}
 
 	// Nodes (canonical order)
-	writeField([]byte{byte(len(g.nodes))})
-	for _, n := range g.nodes {
-		writeField([]byte(n.DefinitionHash))
+	writeField([]byte{byte(count)})
+	for i, n := range g.nodes {
+		if keep[i] {
+			writeField([]byte(n.DefinitionHash))
+		}
 	}
 
 	// Edges (canonical order)
-	writeField([]byte{byte(len(g.edges))})
-	for _, e := range g.edges {
+	writeField([]byte{byte(len(edges))})
+	for _, e := range edges {
 		writeField([]byte{byte(e.from >> 24), byte(e.from >> 16), byte(e.from >> 8), byte(e.from)})
 		writeField([]byte{byte(e.to >> 24), byte(e.to >> 16), byte(e.to >> 8), byte(e.to)})
 	}
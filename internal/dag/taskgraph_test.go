@@ -2,6 +2,7 @@ package dag
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"scriptweaver/internal/core"
@@ -175,3 +176,95 @@ func TestCycleDetection_IndirectCycleRejected(t *testing.T) {
 		t.Fatalf("expected cycle error, got %v", err)
 	}
 }
+
+func TestOutputOverlap_RejectedUnlessOrdered(t *testing.T) {
+	tasks := []core.Task{
+		{Name: "A", Run: "run-a", Outputs: []string{"dist"}},
+		{Name: "B", Run: "run-b", Outputs: []string{"dist/b.txt"}},
+		{Name: "C", Run: "run-c", Outputs: []string{"dist/b.txt"}},
+	}
+	_, err := NewTaskGraph(tasks, []Edge{{From: "A", To: "B"}})
+	if !errors.Is(err, ErrOutputOverlap) {
+		t.Fatalf("expected overlapping outputs, got %v", err)
+	}
+	if want := `tasks "A" and "C" declare "dist" and "dist/b.txt"`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("error %v does not name the conflict %q", err, want)
+	}
+
+	// A path of edges orders every pair; identical definitions run once.
+	tasks = append(tasks, core.Task{Name: "D", Run: "run-c", Outputs: []string{"dist/b.txt"}})
+	g, err := NewTaskGraph(tasks, []Edge{{From: "A", To: "B"}, {From: "B", To: "C"}, {From: "B", To: "D"}})
+	if err != nil {
+		t.Fatalf("expected ordered outputs to be accepted, got %v", err)
+	}
+	// Identical tasks only run once when the runner can hash them.
+	if _, err := NewExecutor(g, &fakeRunner{}); !errors.Is(err, ErrOutputOverlap) {
+		t.Fatalf("expected a runner without TaskHash to reject identical overlapping tasks, got %v", err)
+	}
+
+	// Non-cacheable tasks are never deduplicated and both run, so identical
+	// definitions are no exemption.
+	noCache := false
+	_, err = NewTaskGraph([]core.Task{
+		{Name: "E1", Run: "run-e", Outputs: []string{"e.txt"}, Cacheable: &noCache},
+		{Name: "E2", Run: "run-e", Outputs: []string{"e.txt"}, Cacheable: &noCache},
+	}, nil)
+	if !errors.Is(err, ErrOutputOverlap) {
+		t.Fatalf("expected identical non-cacheable tasks to be rejected, got %v", err)
+	}
+}
+
+func TestSubtreeHash_CoversOnlyUpstreamClosure(t *testing.T) {
+	build := func(t *testing.T, tasks []core.Task, edges []Edge) *TaskGraph {
+		t.Helper()
+		g, err := NewTaskGraph(tasks, edges)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return g
+	}
+	base := []core.Task{
+		{Name: "A", Run: "echo A"},
+		{Name: "B", Run: "echo B"},
+		{Name: "C", Run: "echo C"},
+		{Name: "D", Run: "echo D"},
+	}
+	g := build(t, base, []Edge{{From: "A", To: "B"}, {From: "B", To: "D"}, {From: "C", To: "D"}})
+
+	// D depends on everything, so its subtree is the whole graph.
+	if h, ok := g.SubtreeHash("D"); !ok || h != g.Hash() {
+		t.Fatalf("SubtreeHash(D) = %s, %v; want the graph hash %s", h, ok, g.Hash())
+	}
+	if _, ok := g.SubtreeHash("missing"); ok {
+		t.Fatalf("expected an unknown task to report false")
+	}
+
+	// Changing C or adding downstream tasks leaves B's subtree alone.
+	other := append([]core.Task(nil), base...)
+	other[2].Run = "echo C2"
+	other = append(other, core.Task{Name: "E", Run: "echo E"})
+	g2 := build(t, other, []Edge{{From: "A", To: "B"}, {From: "B", To: "D"}, {From: "C", To: "D"}, {From: "B", To: "E"}})
+	hB, _ := g.SubtreeHash("B")
+	hB2, _ := g2.SubtreeHash("B")
+	if hB != hB2 {
+		t.Fatalf("B's subtree hash changed with tasks outside it: %s vs %s", hB, hB2)
+	}
+	hD, _ := g.SubtreeHash("D")
+	hD2, _ := g2.SubtreeHash("D")
+	if hD == hD2 {
+		t.Fatalf("D's subtree hash did not change with C's definition")
+	}
+
+	// The closure's edges count: the same two tasks without the edge differ.
+	g3 := build(t, base, []Edge{{From: "B", To: "D"}, {From: "C", To: "D"}})
+	if hB3, _ := g3.SubtreeHash("B"); hB3 == hB {
+		t.Fatalf("B's subtree hash ignores its upstream edge")
+	}
+
+	// Like the graph hash, the subtree hash ignores names.
+	renamed := []core.Task{{Name: "X", Run: "echo A"}, {Name: "Y", Run: "echo B"}}
+	g4 := build(t, renamed, []Edge{{From: "X", To: "Y"}})
+	if hY, _ := g4.SubtreeHash("Y"); hY != hB {
+		t.Fatalf("renamed subtree hash %s, want %s", hY, hB)
+	}
+}
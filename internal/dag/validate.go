@@ -2,6 +2,11 @@ package dag
 
 import (
 	"container/heap"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"scriptweaver/internal/core"
 )
 
 // validateAcyclic proves the graph has no cycles using Kahn's algorithm.
@@ -17,6 +22,79 @@ func (g *TaskGraph) validateAcyclic() error {
 	return cycleError(cyclePath)
 }
 
+// validateOutputs rejects two tasks that declare overlapping outputs (see
+// core.OutputsOverlap) unless a path of edges orders them: concurrent
+// writers to one artifact leave content that depends on timing.
+//
+// When dedup is set, dedup candidates (see dedupCandidate) whose definitions
+// are identical apart from their names are exempt: an executor whose runner
+// is a HashRunner runs only one of them. NewExecutor checks again without
+// the exemption for other runners.
+func (g *TaskGraph) validateOutputs(dedup bool) error {
+	names := make([]string, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		if len(n.Task.Outputs) > 0 {
+			names = append(names, n.Name)
+		}
+	}
+	sort.Strings(names)
+
+	reach := make(map[string][]string)
+	ordered := func(from, to string) (bool, error) {
+		down, ok := reach[from]
+		if !ok {
+			var err error
+			if down, err = downstreamReachable(g, from); err != nil {
+				return false, err
+			}
+			reach[from] = down
+		}
+		return containsString(down, to), nil
+	}
+
+	for i, a := range names {
+		ta := g.nodesByName[a].Task
+		for _, b := range names[i+1:] {
+			tb := g.nodesByName[b].Task
+			oa, ob, ok := overlappingOutputs(ta.Outputs, tb.Outputs)
+			if !ok || dedup && dedupCandidate(ta) && dedupCandidate(tb) && sameDefinition(ta, tb) {
+				continue
+			}
+			ab, err := ordered(a, b)
+			if err != nil {
+				return err
+			}
+			ba, err := ordered(b, a)
+			if err != nil {
+				return err
+			}
+			if !ab && !ba {
+				return &GraphError{Kind: ErrOutputOverlap, Msg: fmt.Sprintf("tasks %q and %q declare %q and %q but no edge orders them", a, b, oa, ob)}
+			}
+		}
+	}
+	return nil
+}
+
+// overlappingOutputs returns the first pair of outputs, one from each list,
+// that overlap.
+func overlappingOutputs(a, b []string) (string, string, bool) {
+	for _, oa := range a {
+		for _, ob := range b {
+			if core.OutputsOverlap(oa, ob) {
+				return oa, ob, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// sameDefinition reports whether a and b differ only by name.
+func sameDefinition(a, b core.Task) bool {
+	a.Name, b.Name = "", ""
+	return reflect.DeepEqual(a, b)
+}
+
 type intMinHeap []int
 
 func (h intMinHeap) Len() int           { return len(h) }
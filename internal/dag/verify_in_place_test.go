@@ -0,0 +1,84 @@
+package dag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/incremental"
+	"scriptweaver/internal/trace"
+)
+
+func TestExecutor_CacheHit_VerifiesArtifactsInPlace(t *testing.T) {
+	for _, planned := range []bool{false, true} {
+		for _, parallel := range []bool{false, true} {
+			workDir := t.TempDir()
+			cacheRunner, err := NewCacheAwareRunner(core.NewRunner(workDir, core.NewMemoryCache()))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			g, err := NewTaskGraph([]core.Task{{Name: "A", Run: "printf v1 > a.txt", Outputs: []string{"a.txt"}}}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			run := func() *GraphResult {
+				t.Helper()
+				exec, err := NewExecutor(g, cacheRunner)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if planned {
+					exec.Plan = &incremental.IncrementalPlan{
+						Order:     []string{"A"},
+						Decisions: map[string]incremental.NodeExecutionDecision{"A": incremental.DecisionReuseCache},
+					}
+				}
+				var res *GraphResult
+				if parallel {
+					res, err = exec.RunParallel(context.Background(), 2)
+				} else {
+					res, err = exec.RunSerial(context.Background())
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return res
+			}
+			if _, err := cacheRunner.Run(context.Background(), g.nodesByName["A"].Task); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			target := filepath.Join(workDir, "a.txt")
+			old := time.Now().Add(-time.Hour).Truncate(time.Second)
+			if err := os.Chtimes(target, old, old); err != nil {
+				t.Fatal(err)
+			}
+			res := run()
+			if !hasTraceEvent(t, res.TraceBytes, trace.EventTaskArtifactsRestored, "A", trace.ReasonVerifiedInPlace) {
+				t.Fatalf("planned=%v parallel=%v: expected VerifiedInPlace, trace=%s", planned, parallel, res.TraceBytes)
+			}
+			if info, err := os.Stat(target); err != nil || !info.ModTime().Equal(old) {
+				t.Fatalf("planned=%v parallel=%v: a.txt was rewritten (err=%v)", planned, parallel, err)
+			}
+
+			// A mismatching file is re-materialized under the ordinary reason.
+			if err := os.WriteFile(target, []byte("tampered"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			res = run()
+			want := trace.ReasonCacheReplay
+			if planned {
+				want = trace.ReasonCacheRestore
+			}
+			if !hasTraceEvent(t, res.TraceBytes, trace.EventTaskArtifactsRestored, "A", want) {
+				t.Fatalf("planned=%v parallel=%v: expected %s, trace=%s", planned, parallel, want, res.TraceBytes)
+			}
+			if b, err := os.ReadFile(target); err != nil || string(b) != "v1" {
+				t.Fatalf("planned=%v parallel=%v: expected restored content, got %q (err=%v)", planned, parallel, b, err)
+			}
+		}
+	}
+}
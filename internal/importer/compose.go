@@ -0,0 +1,233 @@
+package importer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// composeServiceKeys lists the service keys ParseCompose understands. Keys in
+// composeIgnoredKeys only concern how a long-running container is reached
+// or kept alive, so they are accepted and dropped; any other key is an
+// error.
+var (
+	composeServiceKeys = map[string]bool{"image": true, "command": true, "environment": true, "depends_on": true}
+	composeIgnoredKeys = map[string]bool{
+		"container_name": true, "restart": true, "ports": true, "expose": true, "networks": true,
+		"labels": true, "logging": true, "healthcheck": true, "hostname": true, "stdin_open": true, "tty": true,
+	}
+	composeTopLevelIgnored = map[string]bool{"version": true, "name": true, "networks": true}
+)
+
+// ParseCompose converts the services of a docker-compose file into a graph:
+// every service becomes a task of the same name running its command in its
+// image, and depends_on becomes edges.
+//
+// A task runs to completion before its dependents start, so every
+// depends_on condition is read as service_completed_successfully: the
+// import suits compose files that describe a pipeline of one-shot jobs, not
+// long-running servers. A service must set image and command (a string, run
+// with "sh -c", or a list of arguments); the image's default command cannot
+// be expressed as a task. environment (a mapping or a list of KEY=VALUE)
+// becomes the task's env. Build sections, volumes, entrypoints, env files,
+// profiles, extends and variable interpolation are errors.
+func ParseCompose(src []byte) (*Graph, error) {
+	doc, err := decodeYAML(src)
+	if err != nil {
+		return nil, fmt.Errorf("compose: %w", err)
+	}
+	root, ok := doc.(*yamlMap)
+	if !ok {
+		return nil, fmt.Errorf("compose: expected a mapping at the top level")
+	}
+	for _, k := range root.keys {
+		if k != "services" && !composeTopLevelIgnored[k] {
+			return nil, fmt.Errorf("compose: line %d: top-level key %q is not supported", root.line(k), k)
+		}
+	}
+	sv, _ := root.get("services")
+	services, ok := sv.(*yamlMap)
+	if !ok || len(services.keys) == 0 {
+		return nil, fmt.Errorf("compose: no services")
+	}
+
+	g := &Graph{Tasks: []core.Task{}, Edges: []dag.Edge{}}
+	for _, name := range services.keys {
+		v, _ := services.get(name)
+		svc, ok := v.(*yamlMap)
+		if !ok {
+			return nil, fmt.Errorf("compose: line %d: service %q must be a mapping", services.line(name), name)
+		}
+		t, deps, err := composeTask(name, svc)
+		if err != nil {
+			return nil, fmt.Errorf("compose: %w", err)
+		}
+		for _, d := range deps {
+			if _, ok := services.get(d); !ok {
+				return nil, fmt.Errorf("compose: line %d: service %q depends on unknown service %q", svc.line("depends_on"), name, d)
+			}
+			g.Edges = append(g.Edges, dag.Edge{From: d, To: name})
+		}
+		g.Tasks = append(g.Tasks, t)
+	}
+	if err := g.check(); err != nil {
+		return nil, fmt.Errorf("compose: %w", err)
+	}
+	return g, nil
+}
+
+// composeTask converts one service and returns the services it depends on.
+func composeTask(name string, svc *yamlMap) (core.Task, []string, error) {
+	t := core.Task{Name: name, Inputs: []string{}}
+	for _, k := range svc.keys {
+		if !composeServiceKeys[k] && !composeIgnoredKeys[k] {
+			return t, nil, fmt.Errorf("line %d: service %q: key %q is not supported", svc.line(k), name, k)
+		}
+	}
+	fail := func(key, format string, args ...any) (core.Task, []string, error) {
+		return t, nil, fmt.Errorf("line %d: service %q: %s: %s", svc.line(key), name, key, fmt.Sprintf(format, args...))
+	}
+
+	image, _ := svc.get("image")
+	s, ok := image.(string)
+	if !ok || s == "" {
+		return t, nil, fmt.Errorf("service %q: image is required", name)
+	}
+	var err error
+	if t.Image, err = composeValue(s); err != nil {
+		return fail("image", "%v", err)
+	}
+
+	command, ok := svc.get("command")
+	if !ok || command == nil {
+		return t, nil, fmt.Errorf("service %q: command is required (the image's default command cannot be expressed as a task)", name)
+	}
+	switch c := command.(type) {
+	case string:
+		t.Run, err = composeValue(c)
+	case []any:
+		args := make([]string, len(c))
+		for i, a := range c {
+			s, ok := a.(string)
+			if !ok {
+				return fail("command", "arguments must be scalars")
+			}
+			if s, err = composeValue(s); err != nil {
+				break
+			}
+			args[i] = shellQuote(s)
+		}
+		t.Run = strings.Join(args, " ")
+	default:
+		return fail("command", "must be a string or a list")
+	}
+	if err != nil {
+		return fail("command", "%v", err)
+	}
+
+	if env, ok := svc.get("environment"); ok && env != nil {
+		if t.Env, err = composeEnv(env); err != nil {
+			return fail("environment", "%v", err)
+		}
+	}
+
+	deps, err := composeDependsOn(svc)
+	if err != nil {
+		return fail("depends_on", "%v", err)
+	}
+	return t, deps, nil
+}
+
+// composeValue unescapes "$$" and rejects interpolation, which compose
+// resolves from the host environment at startup, and the forms checkCommand
+// rejects.
+func composeValue(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+		return "", fmt.Errorf("variable interpolation is not supported (in %q); write the value or escape it as $$", s)
+	}
+	return b.String(), checkCommand(b.String())
+}
+
+func composeEnv(v any) (map[string]string, error) {
+	env := map[string]string{}
+	switch e := v.(type) {
+	case *yamlMap:
+		for _, k := range e.keys {
+			val, _ := e.get(k)
+			s, ok := val.(string)
+			if !ok {
+				return nil, fmt.Errorf("%q must have a value (inheriting host variables is not supported)", k)
+			}
+			var err error
+			if env[k], err = composeValue(s); err != nil {
+				return nil, err
+			}
+		}
+	case []any:
+		for _, item := range e {
+			s, ok := item.(string)
+			k, val, hasValue := strings.Cut(s, "=")
+			if !ok || !hasValue {
+				return nil, fmt.Errorf("entries must be KEY=VALUE (inheriting host variables is not supported)")
+			}
+			var err error
+			if env[k], err = composeValue(val); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("must be a mapping or a list")
+	}
+	return env, nil
+}
+
+// composeDependsOn returns the services svc depends on, sorted. Both the
+// list form and the mapping form (with condition, restart and required)
+// are accepted.
+func composeDependsOn(svc *yamlMap) ([]string, error) {
+	v, ok := svc.get("depends_on")
+	if !ok || v == nil {
+		return nil, nil
+	}
+	var deps []string
+	switch d := v.(type) {
+	case []any:
+		for _, item := range d {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("entries must be service names")
+			}
+			deps = append(deps, s)
+		}
+	case *yamlMap:
+		for _, k := range d.keys {
+			opts, _ := d.get(k)
+			if m, ok := opts.(*yamlMap); ok {
+				for _, o := range m.keys {
+					if o != "condition" && o != "restart" && o != "required" {
+						return nil, fmt.Errorf("%s: option %q is not supported", k, o)
+					}
+				}
+			}
+			deps = append(deps, k)
+		}
+	default:
+		return nil, fmt.Errorf("must be a list or a mapping")
+	}
+	sort.Strings(deps)
+	return slices.Compact(deps), nil
+}
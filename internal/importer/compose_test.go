@@ -0,0 +1,68 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func TestParseCompose_ServicesAndDependsOn(t *testing.T) {
+	src := `version: "3.9"
+services:
+  migrate:
+    image: flyway/flyway:10
+    command: ["migrate", "-url=jdbc:postgresql://db/app", "it's"]
+    environment:
+      FLYWAY_USER: app
+  test:
+    image: golang:1.22
+    command: go test ./... && echo "$$HOME"
+    environment:
+      - CGO_ENABLED=0
+    depends_on:
+      migrate:
+        condition: service_completed_successfully
+    restart: "no"
+  report:
+    image: alpine:3
+    command: echo done
+    depends_on: [test, migrate]
+`
+	g, err := ParseCompose([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Graph{
+		Tasks: []core.Task{
+			{Name: "migrate", Inputs: []string{}, Image: "flyway/flyway:10", Run: `migrate -url=jdbc:postgresql://db/app 'it'\''s'`, Env: map[string]string{"FLYWAY_USER": "app"}},
+			{Name: "test", Inputs: []string{}, Image: "golang:1.22", Run: `go test ./... && echo "$HOME"`, Env: map[string]string{"CGO_ENABLED": "0"}},
+			{Name: "report", Inputs: []string{}, Image: "alpine:3", Run: "echo done"},
+		},
+		Edges: []dag.Edge{{From: "migrate", To: "test"}, {From: "migrate", To: "report"}, {From: "test", To: "report"}},
+	}
+	if !reflect.DeepEqual(g, want) {
+		t.Fatalf("graph =\n%+v\nwant\n%+v", g, want)
+	}
+}
+
+func TestParseCompose_RejectsUnsupportedServices(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{"services:\n  a:\n    build: .\n    command: x\n", `key "build" is not supported`},
+		{"services:\n  a:\n    image: postgres\n", "command is required"},
+		{"services:\n  a:\n    image: alpine\n    command: echo ${HOME}\n", "interpolation"},
+		{"services:\n  a:\n    image: alpine\n    command: x\n    environment:\n      - HOME\n", "KEY=VALUE"},
+		{"services:\n  a:\n    image: alpine\n    command: x\n    depends_on: [b]\n", `unknown service "b"`},
+		{"services:\n  a:\n    image: alpine\n    command: x\nvolumes:\n  data:\n", `top-level key "volumes"`},
+	} {
+		_, err := ParseCompose([]byte(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: err = %v, want %q", tc.src, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package importer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// Workflow and job keys ParseWorkflow accepts and drops: they concern
+// triggering, the hosted runner or reporting, not what a job runs.
+var (
+	workflowIgnoredKeys = map[string]bool{"name": true, "run-name": true, "on": true, "permissions": true, "concurrency": true}
+	jobIgnoredKeys      = map[string]bool{"name": true, "runs-on": true, "permissions": true, "concurrency": true, "timeout-minutes": true, "environment": true}
+	stepIgnoredKeys     = map[string]bool{"name": true, "id": true, "timeout-minutes": true}
+)
+
+// ParseWorkflow converts the jobs of a GitHub Actions workflow into a graph:
+// every job becomes a task of the same name running its steps, and needs
+// becomes edges.
+//
+// Run steps execute as on a Linux runner, with "bash --noprofile --norc -eo
+// pipefail" (or "sh -e" for shell: sh), in order, stopping at the first
+// failure; a step's env and working-directory apply to that step. The
+// workflow's and job's env become the task's env, and a job container
+// (container: image) becomes the task's image. Steps using
+// actions/checkout are dropped, since the working directory is the checkout,
+// and so are actions/setup-* steps: tools come from the host. Any other
+// action, expressions (${{ }}), conditions (if), matrices, services,
+// reusable workflows and job outputs are errors. runs-on is ignored: the
+// graph runs where scriptweaver runs.
+func ParseWorkflow(src []byte) (*Graph, error) {
+	doc, err := decodeYAML(src)
+	if err != nil {
+		return nil, fmt.Errorf("workflow: %w", err)
+	}
+	root, ok := doc.(*yamlMap)
+	if !ok {
+		return nil, fmt.Errorf("workflow: expected a mapping at the top level")
+	}
+	for _, k := range root.keys {
+		if k != "jobs" && k != "env" && !workflowIgnoredKeys[k] {
+			return nil, fmt.Errorf("workflow: line %d: top-level key %q is not supported", root.line(k), k)
+		}
+	}
+	env, err := workflowEnv(root)
+	if err != nil {
+		return nil, fmt.Errorf("workflow: %w", err)
+	}
+	jv, _ := root.get("jobs")
+	jobs, ok := jv.(*yamlMap)
+	if !ok || len(jobs.keys) == 0 {
+		return nil, fmt.Errorf("workflow: no jobs")
+	}
+
+	g := &Graph{Tasks: []core.Task{}, Edges: []dag.Edge{}}
+	for _, name := range jobs.keys {
+		v, _ := jobs.get(name)
+		job, ok := v.(*yamlMap)
+		if !ok {
+			return nil, fmt.Errorf("workflow: line %d: job %q must be a mapping", jobs.line(name), name)
+		}
+		t, needs, err := workflowTask(name, job, env)
+		if err != nil {
+			return nil, fmt.Errorf("workflow: %w", err)
+		}
+		for _, n := range needs {
+			if _, ok := jobs.get(n); !ok {
+				return nil, fmt.Errorf("workflow: line %d: job %q needs unknown job %q", job.line("needs"), name, n)
+			}
+			g.Edges = append(g.Edges, dag.Edge{From: n, To: name})
+		}
+		g.Tasks = append(g.Tasks, t)
+	}
+	if err := g.check(); err != nil {
+		return nil, fmt.Errorf("workflow: %w", err)
+	}
+	return g, nil
+}
+
+// workflowTask converts one job and returns the jobs it needs.
+func workflowTask(name string, job *yamlMap, inherited map[string]string) (core.Task, []string, error) {
+	t := core.Task{Name: name, Inputs: []string{}}
+	for _, k := range job.keys {
+		switch {
+		case jobIgnoredKeys[k]:
+		case k == "needs" || k == "steps" || k == "env" || k == "container":
+		default:
+			return t, nil, fmt.Errorf("line %d: job %q: key %q is not supported", job.line(k), name, k)
+		}
+	}
+
+	env, err := workflowEnv(job)
+	if err != nil {
+		return t, nil, fmt.Errorf("job %q: %w", name, err)
+	}
+	if len(inherited)+len(env) > 0 {
+		t.Env = make(map[string]string, len(inherited)+len(env))
+		for k, v := range inherited {
+			t.Env[k] = v
+		}
+		for k, v := range env {
+			t.Env[k] = v
+		}
+	}
+
+	if c, ok := job.get("container"); ok {
+		image, _ := c.(string)
+		if m, isMap := c.(*yamlMap); isMap && len(m.keys) == 1 && m.keys[0] == "image" {
+			v, _ := m.get("image")
+			image, _ = v.(string)
+		}
+		if image == "" {
+			return t, nil, fmt.Errorf("line %d: job %q: container must be an image name or a mapping with only image", job.line("container"), name)
+		}
+		if err := checkExpression(image); err != nil {
+			return t, nil, fmt.Errorf("line %d: job %q: container: %w", job.line("container"), name, err)
+		}
+		t.Image = image
+	}
+
+	sv, _ := job.get("steps")
+	steps, ok := sv.([]any)
+	if !ok || len(steps) == 0 {
+		return t, nil, fmt.Errorf("job %q: steps are required", name)
+	}
+	var commands []string
+	for i, s := range steps {
+		step, ok := s.(*yamlMap)
+		if !ok {
+			return t, nil, fmt.Errorf("job %q: step %d must be a mapping", name, i+1)
+		}
+		cmd, err := workflowStep(step)
+		if err != nil {
+			return t, nil, fmt.Errorf("job %q: step %d: %w", name, i+1, err)
+		}
+		if cmd != "" {
+			commands = append(commands, cmd)
+		}
+	}
+	t.Run = "true"
+	if len(commands) > 0 {
+		t.Run = strings.Join(commands, " && ")
+	}
+
+	needs, err := stringList(job, "needs")
+	if err != nil {
+		return t, nil, fmt.Errorf("job %q: %w", name, err)
+	}
+	sort.Strings(needs)
+	return t, slices.Compact(needs), nil
+}
+
+// workflowStep returns the command running step, or "" for a dropped step.
+func workflowStep(step *yamlMap) (string, error) {
+	for _, k := range step.keys {
+		switch {
+		case stepIgnoredKeys[k]:
+		case k == "run" || k == "uses" || k == "shell" || k == "env" || k == "working-directory" || k == "with":
+		default:
+			return "", fmt.Errorf("line %d: key %q is not supported", step.line(k), k)
+		}
+	}
+	if uses, ok := step.get("uses"); ok {
+		action, _ := uses.(string)
+		if _, hasRun := step.get("run"); hasRun {
+			return "", fmt.Errorf("line %d: a step cannot both use an action and run a script", step.line("uses"))
+		}
+		if strings.HasPrefix(action, "actions/checkout@") || strings.HasPrefix(action, "actions/setup-") {
+			return "", nil
+		}
+		return "", fmt.Errorf("line %d: action %q cannot run outside GitHub Actions; replace the step with a run step", step.line("uses"), action)
+	}
+	if _, ok := step.get("with"); ok {
+		return "", fmt.Errorf("line %d: with requires an action", step.line("with"))
+	}
+
+	rv, _ := step.get("run")
+	script, ok := rv.(string)
+	if !ok || strings.TrimSpace(script) == "" {
+		return "", fmt.Errorf("a step must run a script or use an action")
+	}
+	if err := checkExpression(script); err != nil {
+		return "", fmt.Errorf("line %d: run: %w", step.line("run"), err)
+	}
+	shell := "bash --noprofile --norc -eo pipefail -c"
+	if sv, ok := step.get("shell"); ok {
+		switch sv {
+		case "bash":
+		case "sh":
+			shell = "sh -e -c"
+		default:
+			return "", fmt.Errorf("line %d: shell %v is not supported (use bash or sh)", step.line("shell"), sv)
+		}
+	}
+	cmd := shell + " " + shellQuote(strings.TrimSuffix(script, "\n"))
+
+	env, err := workflowEnv(step)
+	if err != nil {
+		return "", err
+	}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var assigns []string
+	for _, k := range keys {
+		assigns = append(assigns, k+"="+shellQuote(env[k]))
+	}
+	if len(assigns) > 0 {
+		cmd = strings.Join(assigns, " ") + " " + cmd
+	}
+	if err := checkCommand(cmd); err != nil {
+		return "", fmt.Errorf("line %d: run: %w", step.line("run"), err)
+	}
+	if wd, ok := step.get("working-directory"); ok {
+		dir, _ := wd.(string)
+		if dir == "" || checkExpression(dir) != nil || checkCommand(dir) != nil {
+			return "", fmt.Errorf("line %d: working-directory must be a plain path", step.line("working-directory"))
+		}
+		return "(cd " + shellQuote(dir) + " && " + cmd + ")", nil
+	}
+	return cmd, nil
+}
+
+// workflowEnv returns the env mapping of a workflow, job or step.
+func workflowEnv(m *yamlMap) (map[string]string, error) {
+	v, ok := m.get("env")
+	if !ok || v == nil {
+		return nil, nil
+	}
+	em, ok := v.(*yamlMap)
+	if !ok {
+		return nil, fmt.Errorf("line %d: env must be a mapping", m.line("env"))
+	}
+	env := make(map[string]string, len(em.keys))
+	for _, k := range em.keys {
+		if !shellVarName.MatchString(k) {
+			return nil, fmt.Errorf("line %d: env name %q is not a valid variable name", em.line(k), k)
+		}
+		val, _ := em.get(k)
+		s, ok := val.(string)
+		if !ok {
+			s = ""
+		}
+		if err := checkExpression(s); err != nil {
+			return nil, fmt.Errorf("line %d: env %s: %w", em.line(k), k, err)
+		}
+		if err := checkCommand(s); err != nil {
+			return nil, fmt.Errorf("line %d: env %s: %w", em.line(k), k, err)
+		}
+		env[k] = s
+	}
+	return env, nil
+}
+
+// checkExpression rejects ${{ }} expressions, which only GitHub evaluates.
+func checkExpression(s string) error {
+	if strings.Contains(s, "${{") {
+		return fmt.Errorf("expressions (${{ }}) are not supported")
+	}
+	return nil
+}
+
+// stringList reads key of m as a string or a list of strings.
+func stringList(m *yamlMap, key string) ([]string, error) {
+	v, ok := m.get(key)
+	if !ok || v == nil {
+		return nil, nil
+	}
+	switch l := v.(type) {
+	case string:
+		return []string{l}, nil
+	case []any:
+		out := make([]string, 0, len(l))
+		for _, item := range l {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("line %d: %s must list names", m.line(key), key)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("line %d: %s must be a name or a list of names", m.line(key), key)
+}
@@ -0,0 +1,81 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+func TestParseWorkflow_JobsStepsAndNeeds(t *testing.T) {
+	src := `name: CI
+on:
+  push:
+    branches: [main]
+env:
+  GOFLAGS: -mod=mod
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version: "1.22"
+      - name: Build
+        run: go build ./...
+  test:
+    needs: build
+    runs-on: ubuntu-latest
+    container: golang:1.22
+    env:
+      CGO_ENABLED: "0"
+    steps:
+      - run: |
+          go vet ./...
+          go test ./...
+      - run: ./check.sh
+        shell: sh
+        working-directory: scripts
+        env:
+          MODE: strict
+`
+	g, err := ParseWorkflow([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Graph{
+		Tasks: []core.Task{
+			{Name: "build", Inputs: []string{}, Env: map[string]string{"GOFLAGS": "-mod=mod"}, Run: "bash --noprofile --norc -eo pipefail -c 'go build ./...'"},
+			{Name: "test", Inputs: []string{}, Env: map[string]string{"GOFLAGS": "-mod=mod", "CGO_ENABLED": "0"}, Image: "golang:1.22",
+				Run: "bash --noprofile --norc -eo pipefail -c 'go vet ./...\ngo test ./...' && (cd scripts && MODE=strict sh -e -c ./check.sh)"},
+		},
+		Edges: []dag.Edge{{From: "build", To: "test"}},
+	}
+	if !reflect.DeepEqual(g, want) {
+		t.Fatalf("graph =\n%+v\nwant\n%+v", g, want)
+	}
+}
+
+func TestParseWorkflow_RejectsWhatOnlyGitHubRuns(t *testing.T) {
+	job := "jobs:\n  a:\n    runs-on: ubuntu-latest\n"
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{job + "    steps:\n      - uses: docker/build-push-action@v5\n", "cannot run outside GitHub Actions"},
+		{job + "    steps:\n      - run: echo ${{ github.sha }}\n", "expressions"},
+		{job + "    if: github.ref == 'refs/heads/main'\n    steps:\n      - run: x\n", `key "if" is not supported`},
+		{job + "    strategy:\n      matrix:\n        go: [a]\n    steps:\n      - run: x\n", `key "strategy" is not supported`},
+		{job + "    needs: [b]\n    steps:\n      - run: x\n", `unknown job "b"`},
+		{job + "    steps:\n      - run: x\n        shell: pwsh\n", "shell pwsh"},
+		{"jobs:\n  a:\n    needs: b\n    steps:\n      - run: x\n  b:\n    needs: a\n    steps:\n      - run: y\n", "cycle"},
+	} {
+		_, err := ParseWorkflow([]byte(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: err = %v, want %q", tc.src, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Package importer converts the build definitions of other tools into
+// ScriptWeaver graph files, so an existing build can be migrated one piece at
+// a time.
+//
+// Importers read a restricted subset of their source format. Constructs
+// outside it are reported as errors with their line, rather than dropped, so
+// an imported graph never silently means less than its source; only settings
+// that cannot change what a task runs, listed by each importer, are ignored.
+// The result is a starting point: it is deterministic for a given source,
+// but inputs the source tool discovers at run time (e.g. compiler-generated
+// header dependencies) have to be added by hand.
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// Graph is an imported graph. It encodes as a graph file.
+type Graph struct {
+	Tasks []core.Task `json:"tasks"`
+	Edges []dag.Edge  `json:"edges"`
+}
+
+// check builds g as a task graph, so an imported graph with a cycle or an
+// edge to an unknown task fails at import rather than at its first run.
+func (g *Graph) check() error {
+	_, err := dag.NewTaskGraph(g.Tasks, g.Edges)
+	return err
+}
+
+var (
+	shellBraceVar = regexp.MustCompile(`\$\{([^}]*)\}`)
+	shellVarName  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// checkCommand rejects a command using "${...}" forms the graph loader would
+// substitute itself: placeholders such as ${task} and ${params.x}, and
+// anything with a colon, which reads as an artifact reference (including
+// shell defaults such as ${X:-y}). Plain shell variables pass.
+func checkCommand(cmd string) error {
+	for _, m := range shellBraceVar.FindAllStringSubmatch(cmd, -1) {
+		switch name := m[1]; {
+		case name == "task" || name == "workdir" || name == "output_dir":
+		case shellVarName.MatchString(name):
+			continue
+		}
+		return fmt.Errorf("command uses %s, which the graph loader would substitute; use a plain shell variable instead", m[0])
+	}
+	return nil
+}
+
+// shellQuote quotes s as one word for sh.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./=:,+@%") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
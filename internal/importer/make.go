@@ -0,0 +1,450 @@
+package importer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// makeRule is one target of a Makefile. Rules naming the same target merge,
+// as in make: prerequisites accumulate and at most one rule has a recipe.
+type makeRule struct {
+	target  string
+	prereqs []string
+	recipe  []recipeLine
+	// closed is set once a later rule names the target after its recipe;
+	// recipe lines following that rule would be a second recipe.
+	closed bool
+}
+
+type recipeLine struct {
+	text   string // unexpanded, without the "@"/"-" prefixes
+	ignore bool   // "-": failures are ignored
+	line   int
+}
+
+type makeVar struct {
+	value  string
+	simple bool // ":=": expanded once at assignment
+}
+
+// makeParser holds the state of ParseMakefile.
+type makeParser struct {
+	vars  map[string]makeVar
+	rules map[string]*makeRule
+	order []string // targets in order of first appearance
+	phony map[string]bool
+}
+
+// ignoredSpecialTargets do not change what is built or how its commands
+// run, so they are accepted and dropped.
+var ignoredSpecialTargets = map[string]bool{
+	".DELETE_ON_ERROR": true,
+	".SUFFIXES":        true,
+	".SILENT":          true,
+	".NOTPARALLEL":     true,
+}
+
+// ParseMakefile converts a Makefile into a graph: every target becomes a task
+// of the same name running its recipe, and prerequisites become inputs and
+// edges.
+//
+// The subset understood is: comments, line continuations, variable
+// assignments with =, :=, ::=, ?= and +=, references $(VAR) and ${VAR},
+// explicit rules (several targets share a recipe; several rules for one
+// target merge their prerequisites), "target: prereqs ; command" recipes,
+// the automatic variables $@, $< and $^, the "@" and "-" recipe prefixes and
+// .PHONY. Pattern, suffix and double-colon rules, order-only prerequisites,
+// target-specific variables, conditionals, include, define, functions and
+// variables not assigned in the Makefile (including make's built-in and
+// environment variables) are errors.
+//
+// A prerequisite that is another target becomes an edge; when that target is
+// a file (not .PHONY), its path is also an input, so the downstream task's
+// hash follows the file. Any other prerequisite is a source file and becomes
+// an input. A target that is not .PHONY declares itself as its output.
+//
+// Make runs each recipe line in its own shell; a task runs one command, so
+// a multi-line recipe becomes its lines in subshells joined with "&&". A
+// target without a recipe runs "true" and only orders its prerequisites.
+func ParseMakefile(src []byte) (*Graph, error) {
+	if !utf8.Valid(src) {
+		return nil, fmt.Errorf("makefile: not valid UTF-8")
+	}
+	p := &makeParser{vars: map[string]makeVar{}, rules: map[string]*makeRule{}, phony: map[string]bool{}}
+	var current []*makeRule // rules the next recipe lines belong to
+	lines := strings.Split(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := lines[i]
+		recipe := strings.HasPrefix(line, "\t")
+		// Join continuation lines. Recipe continuations are kept for the
+		// shell, minus the leading tab make strips too; elsewhere the
+		// backslash, newline and surrounding blanks become one space.
+		for strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\") && i+1 < len(lines) {
+			i++
+			if recipe {
+				line += "\n" + strings.TrimPrefix(lines[i], "\t")
+			} else {
+				line = strings.TrimRight(strings.TrimSuffix(line, "\\"), " \t") + " " + strings.TrimLeft(lines[i], " \t")
+			}
+		}
+		if recipe {
+			if current == nil {
+				if strings.TrimSpace(line) == "" {
+					continue
+				}
+				return nil, fmt.Errorf("makefile: line %d: recipe line outside a rule", lineNo)
+			}
+			if err := addRecipeLine(current, line[1:], lineNo); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+		rules, err := p.parseLine(line, lineNo)
+		if err != nil {
+			return nil, fmt.Errorf("makefile: line %d: %w", lineNo, err)
+		}
+		current = rules
+	}
+	return p.graph()
+}
+
+// stripComment cuts line at the first "#" not escaped with a backslash.
+func stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+var unsupportedDirectives = []string{
+	"ifeq", "ifneq", "ifdef", "ifndef", "else", "endif", "include", "-include", "sinclude",
+	"define", "endef", "export", "unexport", "override", "private", "vpath", "undefine",
+}
+
+// parseLine handles a line outside recipes: an assignment or a rule. It
+// returns the rules that following recipe lines belong to (none after an
+// assignment).
+func (p *makeParser) parseLine(line string, lineNo int) ([]*makeRule, error) {
+	word := strings.Fields(line)[0]
+	for _, d := range unsupportedDirectives {
+		if word == d {
+			return nil, fmt.Errorf("directive %q is not supported", d)
+		}
+	}
+	sep := strings.IndexAny(line, ":=")
+	if sep < 0 {
+		return nil, fmt.Errorf("expected a rule or a variable assignment")
+	}
+	switch {
+	case line[sep] == '=':
+		return nil, p.assign(line[:sep], line[sep+1:])
+	case strings.HasPrefix(line[sep:], "::="):
+		return nil, p.assign(line[:sep]+":", line[sep+3:])
+	case strings.HasPrefix(line[sep:], ":="):
+		return nil, p.assign(line[:sep]+":", line[sep+2:])
+	case strings.HasPrefix(line[sep:], "::"):
+		return nil, fmt.Errorf("double-colon rules are not supported")
+	}
+	return p.rule(line[:sep], line[sep+1:], lineNo)
+}
+
+// assign applies "name op value"; lhs ends with the operator's prefix
+// character ("?", "+" or ":" for simple assignment), if any.
+func (p *makeParser) assign(lhs, value string) error {
+	if strings.HasSuffix(lhs, "!") {
+		return fmt.Errorf("shell assignments (!=) are not supported")
+	}
+	op := ""
+	if n := len(lhs); n > 0 && strings.ContainsRune("?+:", rune(lhs[n-1])) {
+		op, lhs = lhs[n-1:], lhs[:n-1]
+	}
+	name := strings.TrimSpace(lhs)
+	if name == "" || strings.ContainsAny(name, " \t$") {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	value = strings.TrimSpace(value)
+	old, defined := p.vars[name]
+	switch op {
+	case "?":
+		if !defined {
+			p.vars[name] = makeVar{value: value}
+		}
+	case "+":
+		if old.simple {
+			v, err := p.expand(value, nil)
+			if err != nil {
+				return err
+			}
+			value = v
+		}
+		if defined && old.value != "" {
+			value = old.value + " " + value
+		}
+		p.vars[name] = makeVar{value: value, simple: old.simple}
+	case ":":
+		v, err := p.expand(value, nil)
+		if err != nil {
+			return err
+		}
+		p.vars[name] = makeVar{value: v, simple: true}
+	default:
+		p.vars[name] = makeVar{value: value}
+	}
+	return nil
+}
+
+// rule records "targets: prereqs [; command]".
+func (p *makeParser) rule(targetText, rest string, lineNo int) ([]*makeRule, error) {
+	prereqText, command, hasCommand := strings.Cut(rest, ";")
+	if strings.Contains(prereqText, "=") {
+		return nil, fmt.Errorf("target-specific variables are not supported")
+	}
+	expandedTargets, err := p.expand(targetText, nil)
+	if err != nil {
+		return nil, err
+	}
+	expandedPrereqs, err := p.expand(prereqText, nil)
+	if err != nil {
+		return nil, err
+	}
+	targets, prereqs := strings.Fields(expandedTargets), strings.Fields(expandedPrereqs)
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("rule without a target")
+	}
+	for _, name := range prereqs {
+		if name == "|" || strings.HasPrefix(name, "|") {
+			return nil, fmt.Errorf("order-only prerequisites are not supported")
+		}
+	}
+	if len(targets) == 1 && strings.HasPrefix(targets[0], ".") && !strings.Contains(targets[0], "/") {
+		switch t := targets[0]; {
+		case t == ".PHONY":
+			for _, name := range prereqs {
+				p.phony[name] = true
+			}
+			return nil, nil
+		case ignoredSpecialTargets[t] && !hasCommand:
+			return nil, nil
+		case strings.Count(t, ".") > 1 || t == strings.ToUpper(t):
+			return nil, fmt.Errorf("special target or suffix rule %q is not supported", t)
+		}
+	}
+
+	var rules []*makeRule
+	for _, t := range targets {
+		if strings.Contains(t, "%") {
+			return nil, fmt.Errorf("pattern rule %q is not supported", t)
+		}
+		r, ok := p.rules[t]
+		if !ok {
+			r = &makeRule{target: t}
+			p.rules[t] = r
+			p.order = append(p.order, t)
+		}
+		r.closed = len(r.recipe) > 0
+		for _, name := range prereqs {
+			if !slices.Contains(r.prereqs, name) {
+				r.prereqs = append(r.prereqs, name)
+			}
+		}
+		rules = append(rules, r)
+	}
+	if hasCommand {
+		if err := addRecipeLine(rules, strings.TrimLeft(command, " \t"), lineNo); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// addRecipeLine appends a recipe line to rules, which must not have a
+// recipe from an earlier rule.
+func addRecipeLine(rules []*makeRule, text string, lineNo int) error {
+	rl := recipeLine{line: lineNo}
+	for len(text) > 0 && strings.ContainsRune("@-+", rune(text[0])) {
+		switch text[0] {
+		case '-':
+			rl.ignore = true
+		case '+':
+			return fmt.Errorf("makefile: line %d: the %q recipe prefix is not supported", lineNo, "+")
+		}
+		text = strings.TrimLeft(text[1:], " \t")
+	}
+	rl.text = text
+	for _, r := range rules {
+		if r.closed {
+			return fmt.Errorf("makefile: line %d: target %q already has a recipe (line %d)", lineNo, r.target, r.recipe[0].line)
+		}
+		r.recipe = append(r.recipe, rl)
+	}
+	return nil
+}
+
+// expand replaces variable references in s. auto holds the automatic
+// variables of the recipe being expanded; outside recipes it is nil and
+// automatic variables are errors.
+func (p *makeParser) expand(s string, auto map[byte]string) (string, error) {
+	return p.expandDepth(s, auto, map[string]bool{})
+}
+
+func (p *makeParser) expandDepth(s string, auto map[byte]string, expanding map[string]bool) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 == len(s) {
+			return "", fmt.Errorf("trailing %q", "$")
+		}
+		i++
+		var name string
+		switch c := s[i]; c {
+		case '$':
+			b.WriteByte('$')
+			continue
+		case '(', '{':
+			closer := map[byte]byte{'(': ')', '{': '}'}[c]
+			end := strings.IndexByte(s[i:], closer)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable reference in %q", s)
+			}
+			name = s[i+1 : i+end]
+			i += end
+			if strings.ContainsAny(name, " \t,") {
+				return "", fmt.Errorf("function $%c%s%c is not supported", c, name, closer)
+			}
+			if strings.ContainsAny(name, ":$") {
+				return "", fmt.Errorf("reference $%c%s%c is not supported", c, name, closer)
+			}
+		default:
+			if auto != nil {
+				if v, ok := auto[c]; ok {
+					b.WriteString(v)
+					continue
+				}
+			}
+			if strings.IndexByte("@<^+*?%|", c) >= 0 {
+				return "", fmt.Errorf("automatic variable $%c is not supported here", c)
+			}
+			name = string(c)
+		}
+		v, ok := p.vars[name]
+		if !ok {
+			return "", fmt.Errorf("variable %q is not assigned in the Makefile (built-in and environment variables are not imported)", name)
+		}
+		if v.simple {
+			b.WriteString(v.value)
+			continue
+		}
+		if expanding[name] {
+			return "", fmt.Errorf("variable %q references itself", name)
+		}
+		expanding[name] = true
+		ev, err := p.expandDepth(v.value, auto, expanding)
+		delete(expanding, name)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(ev)
+	}
+	return b.String(), nil
+}
+
+// graph builds the graph of the parsed rules, in order of first appearance.
+func (p *makeParser) graph() (*Graph, error) {
+	phony := make([]string, 0, len(p.phony))
+	for name := range p.phony {
+		phony = append(phony, name)
+	}
+	sort.Strings(phony)
+	for _, name := range phony {
+		if _, ok := p.rules[name]; !ok {
+			return nil, fmt.Errorf("makefile: .PHONY target %q has no rule", name)
+		}
+	}
+	g := &Graph{Tasks: []core.Task{}, Edges: []dag.Edge{}}
+	for _, target := range p.order {
+		r := p.rules[target]
+		t := core.Task{Name: target, Inputs: []string{}, Run: "true"}
+		if !p.phony[target] {
+			t.Outputs = []string{target}
+		}
+		for _, pre := range r.prereqs {
+			if _, ok := p.rules[pre]; ok {
+				g.Edges = append(g.Edges, dag.Edge{From: pre, To: target})
+				if p.phony[pre] {
+					continue
+				}
+			}
+			t.Inputs = append(t.Inputs, pre)
+		}
+		if len(r.recipe) > 0 {
+			run, err := p.command(r)
+			if err != nil {
+				return nil, err
+			}
+			t.Run = run
+		}
+		g.Tasks = append(g.Tasks, t)
+	}
+	if err := g.check(); err != nil {
+		return nil, fmt.Errorf("makefile: %w", err)
+	}
+	return g, nil
+}
+
+// command expands r's recipe into one shell command.
+func (p *makeParser) command(r *makeRule) (string, error) {
+	auto := map[byte]string{'@': r.target, '^': strings.Join(r.prereqs, " ")}
+	if len(r.prereqs) > 0 {
+		auto['<'] = r.prereqs[0]
+	} else {
+		auto['<'] = ""
+	}
+	var parts []string
+	for _, rl := range r.recipe {
+		cmd, err := p.expand(rl.text, auto)
+		if err == nil {
+			err = checkCommand(cmd)
+		}
+		if err != nil {
+			return "", fmt.Errorf("makefile: line %d: target %q: %w", rl.line, r.target, err)
+		}
+		if strings.TrimSpace(cmd) == "" {
+			continue
+		}
+		if rl.ignore {
+			cmd = "{ (" + cmd + ") || true; }"
+		}
+		parts = append(parts, cmd)
+	}
+	switch {
+	case len(parts) == 0:
+		return "true", nil
+	case len(parts) == 1:
+		return parts[0], nil
+	}
+	for i, cmd := range parts {
+		if !strings.HasPrefix(cmd, "{ (") {
+			parts[i] = "(" + cmd + ")"
+		}
+	}
+	return strings.Join(parts, " && "), nil
+}
@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+const exampleMakefile = `# Build the app.
+CC := gcc
+CFLAGS = -O2 $(EXTRA)
+EXTRA = -Wall
+CFLAGS += -g
+OBJS = main.o util.o
+
+.PHONY: all test clean
+
+all: app
+
+app: $(OBJS)
+	$(CC) -o $@ $^
+
+main.o: main.c util.h
+	$(CC) $(CFLAGS) -c $< -o $@
+util.o: util.c util.h ; $(CC) $(CFLAGS) -c $< -o $@
+main.o: config.h
+
+test: app
+	@echo testing \
+	  quietly
+	-./app --self-test
+	echo "$${HOME}" done
+
+clean:
+	rm -f app $(OBJS)
+`
+
+func TestParseMakefile_TargetsPrerequisitesAndRecipes(t *testing.T) {
+	g, err := ParseMakefile([]byte(exampleMakefile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Graph{
+		Tasks: []core.Task{
+			{Name: "all", Inputs: []string{"app"}, Run: "true"},
+			{Name: "app", Inputs: []string{"main.o", "util.o"}, Run: "gcc -o app main.o util.o", Outputs: []string{"app"}},
+			{Name: "main.o", Inputs: []string{"main.c", "util.h", "config.h"}, Run: "gcc -O2 -Wall -g -c main.c -o main.o", Outputs: []string{"main.o"}},
+			{Name: "util.o", Inputs: []string{"util.c", "util.h"}, Run: "gcc -O2 -Wall -g -c util.c -o util.o", Outputs: []string{"util.o"}},
+			{Name: "test", Inputs: []string{"app"}, Run: "(echo testing \\\n  quietly) && { (./app --self-test) || true; } && (echo \"${HOME}\" done)"},
+			{Name: "clean", Inputs: []string{}, Run: "rm -f app main.o util.o"},
+		},
+		Edges: []dag.Edge{
+			{From: "app", To: "all"},
+			{From: "main.o", To: "app"},
+			{From: "util.o", To: "app"},
+			{From: "app", To: "test"},
+		},
+	}
+	if !reflect.DeepEqual(g, want) {
+		t.Fatalf("graph =\n%+v\nwant\n%+v", g, want)
+	}
+}
+
+func TestParseMakefile_PhonyPrerequisiteIsOnlyAnEdge(t *testing.T) {
+	g, err := ParseMakefile([]byte(".PHONY: gen\ngen:\n\ttouch gen.txt\nout.txt: gen\n\tcp gen.txt out.txt\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.Tasks[1].Inputs; len(got) != 0 {
+		t.Fatalf("inputs = %v, want none", got)
+	}
+	if !reflect.DeepEqual(g.Edges, []dag.Edge{{From: "gen", To: "out.txt"}}) {
+		t.Fatalf("edges = %v", g.Edges)
+	}
+}
+
+func TestParseMakefile_RejectsUnsupportedConstructs(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{"%.o: %.c\n\tcc -c $<\n", "pattern rule"},
+		{".c.o:\n\tcc -c $<\n", "suffix rule"},
+		{"a:: b\n\ttrue\n", "double-colon"},
+		{"a: | dir\n\ttrue\n", "order-only"},
+		{"a: X = 1\n", "target-specific"},
+		{"ifeq ($(X),1)\nendif\n", `directive "ifeq"`},
+		{"include other.mk\n", `directive "include"`},
+		{"SRCS = $(wildcard *.c)\nall: $(SRCS)\n", "function"},
+		{"a:\n\t$(MAKE) -C sub\n", `variable "MAKE" is not assigned`},
+		{"X = $(X) y\na: $(X)\n", "references itself"},
+		{"\techo stray\n", "recipe line outside a rule"},
+		{"a:\n\techo 1\na: b\n\techo 2\nb:\n", "already has a recipe"},
+		{"a: b\nb: a\n", "cycle"},
+		{"a:\n\techo $${X:-default}\n", "graph loader would substitute"},
+		{"a:\n\techo $${task}\n", "graph loader would substitute"},
+		{".PHONY: gone\n", "has no rule"},
+	} {
+		_, err := ParseMakefile([]byte(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: err = %v, want %q", tc.src, err, tc.want)
+		}
+	}
+}
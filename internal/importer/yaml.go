@@ -0,0 +1,392 @@
+package importer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// yamlMap is a decoded YAML mapping. Keys keep their source order and line.
+type yamlMap struct {
+	keys   []string
+	values map[string]any
+	lines  map[string]int
+}
+
+func (m *yamlMap) get(key string) (any, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// line is the source line of key, for error messages.
+func (m *yamlMap) line(key string) int {
+	return m.lines[key]
+}
+
+type yamlLine struct {
+	indent int
+	text   string // without indentation and comment
+	no     int    // 1-based line number
+}
+
+type yamlParser struct {
+	raw   []string   // source lines, for block scalars
+	lines []yamlLine // lines with content
+	pos   int
+}
+
+// decodeYAML decodes the subset of YAML that compose files and workflows
+// use: block mappings and sequences, plain, single- and double-quoted
+// scalars, flow sequences of scalars, "{}", literal (|) and folded (>)
+// block scalars, and comments. Scalars decode as strings and null as nil.
+// Anchors, aliases, tags, flow mappings, multi-line plain scalars and
+// multiple documents are errors, so a file never decodes to something other
+// than what it says.
+func decodeYAML(src []byte) (any, error) {
+	if !utf8.Valid(src) {
+		return nil, fmt.Errorf("not valid UTF-8")
+	}
+	p := &yamlParser{raw: strings.Split(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n")}
+	for i, raw := range p.raw {
+		trimmed := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", i+1)
+		}
+		text := strings.TrimSpace(stripYAMLComment(trimmed))
+		if text == "" {
+			continue
+		}
+		if text == "---" && len(p.lines) == 0 {
+			continue
+		}
+		if text == "---" || text == "..." {
+			return nil, fmt.Errorf("line %d: multiple documents are not supported", i+1)
+		}
+		p.lines = append(p.lines, yamlLine{indent: len(raw) - len(trimmed), text: text, no: i + 1})
+	}
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+	v, err := p.node(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].no)
+	}
+	return v, nil
+}
+
+// stripYAMLComment cuts s at a "#" that starts a comment: at the start or
+// after a blank, outside quotes.
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" \t[,:-", rune(s[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func (p *yamlParser) cur() *yamlLine {
+	if p.pos >= len(p.lines) {
+		return nil
+	}
+	return &p.lines[p.pos]
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitKey splits "key: rest" or "key:" outside quotes. ok is false when
+// text is not a mapping entry.
+func splitKey(text string) (key, rest string, ok bool, err error) {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == ':' && (i+1 == len(text) || text[i+1] == ' '):
+			k, err := parseKey(strings.TrimSpace(text[:i]))
+			return k, strings.TrimSpace(text[i+1:]), true, err
+		}
+	}
+	return "", "", false, nil
+}
+
+func parseKey(s string) (string, error) {
+	v, err := parseScalar(s)
+	if err != nil {
+		return "", err
+	}
+	k, ok := v.(string)
+	if !ok || k == "" {
+		return "", fmt.Errorf("mapping keys must be non-empty scalars")
+	}
+	return k, nil
+}
+
+// node parses the mapping or sequence starting at the current line.
+func (p *yamlParser) node(indent int) (any, error) {
+	l := p.cur()
+	if isSeqItem(l.text) {
+		return p.sequence(indent)
+	}
+	if _, _, ok, err := splitKey(l.text); err != nil || !ok {
+		if err == nil {
+			err = fmt.Errorf("expected a mapping entry or sequence item, got %q (multi-line plain scalars are not supported)", l.text)
+		}
+		return nil, fmt.Errorf("line %d: %w", l.no, err)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) mapping(indent int) (*yamlMap, error) {
+	m := &yamlMap{values: map[string]any{}, lines: map[string]int{}}
+	for l := p.cur(); l != nil && l.indent >= indent; l = p.cur() {
+		if l.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", l.no)
+		}
+		if isSeqItem(l.text) {
+			return nil, fmt.Errorf("line %d: sequence item in a mapping", l.no)
+		}
+		key, rest, ok, err := splitKey(l.text)
+		if err == nil && !ok {
+			err = fmt.Errorf("expected a mapping entry, got %q", l.text)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", l.no, err)
+		}
+		if _, dup := m.values[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", l.no, key)
+		}
+		p.pos++
+		v, err := p.value(rest, *l, indent, true)
+		if err != nil {
+			return nil, err
+		}
+		m.keys = append(m.keys, key)
+		m.values[key] = v
+		m.lines[key] = l.no
+	}
+	return m, nil
+}
+
+func (p *yamlParser) sequence(indent int) ([]any, error) {
+	seq := []any{}
+	for l := p.cur(); l != nil && l.indent >= indent; l = p.cur() {
+		if l.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", l.no)
+		}
+		if !isSeqItem(l.text) {
+			break
+		}
+		content := strings.TrimLeft(l.text[1:], " ")
+		if _, _, ok, _ := splitKey(content); ok && !strings.HasPrefix(content, "[") {
+			// "- key: value" starts a mapping indented to its key.
+			l.indent += len(l.text) - len(content)
+			l.text = content
+			m, err := p.mapping(l.indent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, m)
+			continue
+		}
+		p.pos++
+		v, err := p.value(content, *l, indent, false)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, v)
+	}
+	return seq, nil
+}
+
+// value parses what follows "key:" or "-" on line l: an inline scalar, a
+// block scalar, or a nested node on the following lines. A mapping value
+// may be a sequence at the mapping's own indentation.
+func (p *yamlParser) value(rest string, l yamlLine, indent int, inMapping bool) (any, error) {
+	next := p.cur()
+	if rest == "" {
+		switch {
+		case next != nil && next.indent > indent:
+			return p.node(next.indent)
+		case next != nil && inMapping && next.indent == indent && isSeqItem(next.text):
+			return p.sequence(indent)
+		}
+		return nil, nil
+	}
+	if rest[0] == '|' || rest[0] == '>' {
+		return p.blockScalar(rest, l, indent)
+	}
+	if next != nil && next.indent > indent {
+		return nil, fmt.Errorf("line %d: unexpected indentation (multi-line plain scalars are not supported)", next.no)
+	}
+	v, err := parseScalar(rest)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", l.no, err)
+	}
+	return v, nil
+}
+
+// blockScalar reads the literal or folded scalar introduced on line l from
+// the raw lines indented deeper than indent.
+func (p *yamlParser) blockScalar(header string, l yamlLine, indent int) (string, error) {
+	style, chomp := header[0], header[1:]
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return "", fmt.Errorf("line %d: block scalar header %q is not supported", l.no, header)
+	}
+	var body []string
+	contentIndent := -1
+	i := l.no // raw index of the line after the header
+	for ; i < len(p.raw); i++ {
+		raw := p.raw[i]
+		trimmed := strings.TrimLeft(raw, " ")
+		if trimmed == "" {
+			body = append(body, "")
+			continue
+		}
+		n := len(raw) - len(trimmed)
+		if contentIndent < 0 {
+			if n <= indent {
+				break
+			}
+			contentIndent = n
+		}
+		if n < contentIndent {
+			break
+		}
+		body = append(body, raw[contentIndent:])
+	}
+	for p.pos < len(p.lines) && p.lines[p.pos].no <= i {
+		p.pos++
+	}
+
+	trailing := 0
+	for trailing < len(body) && body[len(body)-1-trailing] == "" {
+		trailing++
+	}
+	lines := body[:len(body)-trailing]
+	if len(lines) == 0 {
+		return "", nil
+	}
+	var s string
+	if style == '|' {
+		s = strings.Join(lines, "\n")
+	} else {
+		var b strings.Builder
+		for i, line := range lines {
+			switch {
+			case line == "":
+				b.WriteByte('\n')
+			case i == 0 || lines[i-1] == "":
+			default:
+				b.WriteByte(' ')
+			}
+			b.WriteString(line)
+		}
+		s = b.String()
+	}
+	switch chomp {
+	case "-":
+		return s, nil
+	case "+":
+		return s + strings.Repeat("\n", trailing+1), nil
+	}
+	return s + "\n", nil
+}
+
+// parseScalar decodes an inline scalar or flow sequence.
+func parseScalar(s string) (any, error) {
+	switch {
+	case s == "" || s == "~" || s == "null" || s == "Null" || s == "NULL":
+		return nil, nil
+	case s == "{}":
+		return &yamlMap{values: map[string]any{}, lines: map[string]int{}}, nil
+	case s[0] == '"':
+		if len(s) < 2 || s[len(s)-1] != '"' {
+			return nil, fmt.Errorf("unterminated double-quoted scalar %s", s)
+		}
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported double-quoted scalar %s", s)
+		}
+		return v, nil
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("unterminated single-quoted scalar %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case s[0] == '[':
+		return parseFlowSequence(s)
+	case strings.ContainsRune("{&*!%@`|>", rune(s[0])):
+		return nil, fmt.Errorf("unsupported YAML syntax %q (flow mappings, anchors, aliases and tags are not supported)", s)
+	}
+	return s, nil
+}
+
+// parseFlowSequence decodes "[a, 'b', "c"]" holding scalars only.
+func parseFlowSequence(s string) ([]any, error) {
+	if s[len(s)-1] != ']' {
+		return nil, fmt.Errorf("unterminated flow sequence %s", s)
+	}
+	inner := strings.TrimSpace(s[1 : len(s)-1])
+	seq := []any{}
+	if inner == "" {
+		return seq, nil
+	}
+	var quote byte
+	start := 0
+	for i := 0; i <= len(inner); i++ {
+		if i < len(inner) {
+			c := inner[i]
+			switch {
+			case quote == '"' && c == '\\':
+				i++
+				continue
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+				continue
+			case c == '"' || c == '\'':
+				quote = c
+				continue
+			case c == '[' || c == '{':
+				return nil, fmt.Errorf("nested flow collections are not supported: %s", s)
+			case c != ',':
+				continue
+			}
+		}
+		v, err := parseScalar(strings.TrimSpace(inner[start:i]))
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, v)
+		start = i + 1
+	}
+	return seq, nil
+}
@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// plain converts decoded YAML into maps and slices for comparison.
+func plain(v any) any {
+	switch x := v.(type) {
+	case *yamlMap:
+		m := map[string]any{"_keys": strings.Join(x.keys, ",")}
+		for k, val := range x.values {
+			m[k] = plain(val)
+		}
+		return m
+	case []any:
+		out := make([]any, len(x))
+		for i, val := range x {
+			out[i] = plain(val)
+		}
+		return out
+	}
+	return v
+}
+
+func TestDecodeYAML_Subset(t *testing.T) {
+	src := `---
+# comment
+name: "quoted # not a comment"
+on: [push, 'pull_request']
+empty:
+nested:
+  list:
+  - a
+  - b: 1
+    c: it's
+  folded: >
+    one
+    two
+
+    three
+  literal: |-
+    echo "a"
+      indented # kept
+  kept: |+
+    x
+
+tail: ~
+`
+	v, err := decodeYAML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"_keys": "name,on,empty,nested,tail",
+		"name":  "quoted # not a comment",
+		"on":    []any{"push", "pull_request"},
+		"empty": nil,
+		"nested": map[string]any{
+			"_keys":   "list,folded,literal,kept",
+			"list":    []any{"a", map[string]any{"_keys": "b,c", "b": "1", "c": "it's"}},
+			"folded":  "one two\nthree\n",
+			"literal": "echo \"a\"\n  indented # kept",
+			"kept":    "x\n\n",
+		},
+		"tail": nil,
+	}
+	if got := plain(v); !reflect.DeepEqual(got, want) {
+		t.Fatalf("decoded =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestDecodeYAML_RejectsUnsupportedSyntax(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{"a: &x 1\nb: *x\n", "anchors"},
+		{"a: !!str 1\n", "tags"},
+		{"a: {b: 1}\n", "flow mappings"},
+		{"a: one\n  two\n", "multi-line plain scalars"},
+		{"a: 1\na: 2\n", "duplicate key"},
+		{"a: 1\n---\nb: 2\n", "multiple documents"},
+		{"a:\n\tb: 1\n", "tabs"},
+		{"a: [[1]]\n", "nested flow"},
+	} {
+		_, err := decodeYAML([]byte(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: err = %v, want %q", tc.src, err, tc.want)
+		}
+	}
+}
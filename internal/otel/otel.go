@@ -0,0 +1,203 @@
+// Package otel exports execution spans to an OpenTelemetry collector over
+// OTLP/HTTP with JSON encoding.
+//
+// Spans carry real wall-clock timings and random IDs, so they are purely
+// observational: nothing here feeds task hashes, caches or the canonical
+// execution trace.
+package otel
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ScopeName is the instrumentation scope of exported spans.
+const ScopeName = "scriptweaver"
+
+// Span is one span to export.
+type Span struct {
+	Name       string
+	Start, End time.Time
+	Attributes []Attribute
+	// Failed sets the span status to error; otherwise it is ok.
+	Failed bool
+}
+
+// Attribute is a span attribute. Value is a string, bool, int or int64.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Exporter posts spans to an OTLP/HTTP traces endpoint, such as
+// http://localhost:4318/v1/traces.
+type Exporter struct {
+	Endpoint string
+	// ServiceName is the service.name resource attribute.
+	ServiceName string
+	// Client sends requests; nil means http.DefaultClient.
+	Client *http.Client
+}
+
+// Export sends root and its children, all in one new trace, as a single
+// request.
+func (e *Exporter) Export(ctx context.Context, root Span, children []Span) error {
+	traceID, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+	rootID, err := randomHex(8)
+	if err != nil {
+		return err
+	}
+	spans := []otlpSpan{encodeSpan(root, traceID, rootID, "")}
+	for _, c := range children {
+		id, err := randomHex(8)
+		if err != nil {
+			return err
+		}
+		spans = append(spans, encodeSpan(c, traceID, id, rootID))
+	}
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: encodeAttributes([]Attribute{{Key: "service.name", Value: e.ServiceName}})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: ScopeName}, Spans: spans}},
+	}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("POST %s: %s: %s", e.Endpoint, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// OTLP JSON encoding (opentelemetry-proto, trace/v1). 64-bit integers are
+// encoded as decimal strings.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code int `json:"code"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+const (
+	spanKindInternal = 1
+	statusOK         = 1
+	statusError      = 2
+)
+
+func encodeSpan(s Span, traceID, spanID, parentID string) otlpSpan {
+	status := statusOK
+	if s.Failed {
+		status = statusError
+	}
+	return otlpSpan{
+		TraceID:           traceID,
+		SpanID:            spanID,
+		ParentSpanID:      parentID,
+		Name:              s.Name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+		Attributes:        encodeAttributes(s.Attributes),
+		Status:            otlpStatus{Code: status},
+	}
+}
+
+func encodeAttributes(attrs []Attribute) []otlpKeyValue {
+	out := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		var v otlpValue
+		switch x := a.Value.(type) {
+		case string:
+			v.StringValue = &x
+		case bool:
+			v.BoolValue = &x
+		case int:
+			s := strconv.Itoa(x)
+			v.IntValue = &s
+		case int64:
+			s := strconv.FormatInt(x, 10)
+			v.IntValue = &s
+		default:
+			s := fmt.Sprint(x)
+			v.StringValue = &s
+		}
+		out = append(out, otlpKeyValue{Key: a.Key, Value: v})
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package otel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExporter_PostsOTLPJSON(t *testing.T) {
+	var got otlpRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected %s request with content type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	start := time.Unix(100, 5)
+	e := &Exporter{Endpoint: srv.URL + "/v1/traces", ServiceName: "svc"}
+	root := Span{Name: "run", Start: start, End: start.Add(time.Second)}
+	child := Span{Name: "task", Start: start, End: start.Add(time.Millisecond), Failed: true, Attributes: []Attribute{{"name", "a"}, {"exit_code", 2}, {"cached", false}}}
+	if err := e.Export(context.Background(), root, []Span{child}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.ResourceSpans) != 1 || len(got.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected payload %+v", got)
+	}
+	if svc := got.ResourceSpans[0].Resource.Attributes[0]; svc.Key != "service.name" || *svc.Value.StringValue != "svc" {
+		t.Fatalf("resource attribute %+v", svc)
+	}
+	spans := got.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans", len(spans))
+	}
+	r, c := spans[0], spans[1]
+	if len(r.TraceID) != 32 || c.TraceID != r.TraceID || c.ParentSpanID != r.SpanID || r.ParentSpanID != "" || len(c.SpanID) != 16 {
+		t.Fatalf("bad IDs: root %+v child %+v", r, c)
+	}
+	if r.StartTimeUnixNano != "100000000005" || r.EndTimeUnixNano != "101000000005" || r.Status.Code != statusOK || c.Status.Code != statusError {
+		t.Fatalf("bad timing or status: root %+v child %+v", r, c)
+	}
+	if len(c.Attributes) != 3 || *c.Attributes[1].Value.IntValue != "2" || *c.Attributes[2].Value.BoolValue {
+		t.Fatalf("bad attributes %+v", c.Attributes)
+	}
+}
+
+func TestExporter_ReportsRejectedExport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	e := &Exporter{Endpoint: srv.URL}
+	if err := e.Export(context.Background(), Span{Name: "run"}, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,204 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// LockFileName is the workspace lock file under .scriptweaver.
+const LockFileName = "lock"
+
+// LockGuardFileName is the file AcquireLock holds an flock on while it
+// creates, inspects or takes over the lock file. It is never removed.
+const LockGuardFileName = "lock.guard"
+
+const (
+	// LockHeartbeatInterval is how often a held lock refreshes its mtime.
+	LockHeartbeatInterval = 10 * time.Second
+
+	// LockStaleAfter is how long a lock may go without a heartbeat before it
+	// is considered abandoned, even if its holder cannot be probed.
+	LockStaleAfter = 6 * LockHeartbeatInterval
+)
+
+// ErrWorkspaceLocked reports that another run holds the workspace lock.
+var ErrWorkspaceLocked = errors.New("workspace is locked by another run")
+
+// LockHolder is the content of the lock file.
+type LockHolder struct {
+	PID        int       `json:"pid"`
+	Hostname   string    `json:"hostname"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// LockedError describes the run holding the lock.
+type LockedError struct {
+	Path   string
+	Holder LockHolder
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%v: %s is held by pid %d on %q since %s", ErrWorkspaceLocked, e.Path, e.Holder.PID, e.Holder.Hostname, e.Holder.AcquiredAt.UTC().Format(time.RFC3339))
+}
+
+func (e *LockedError) Unwrap() error { return ErrWorkspaceLocked }
+
+// Lock is a held workspace lock. Release it when the run ends.
+type Lock struct {
+	path string
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// AcquireLock takes the workspace lock, so that concurrent runs do not race
+// on output directory clearing, cache writes and checkpoints.
+//
+// A lock left behind by a dead process on this host, or whose heartbeat is
+// older than LockStaleAfter, is stale and taken over. Otherwise a
+// *LockedError wrapping ErrWorkspaceLocked is returned.
+//
+// Acquisition runs under an exclusive flock on LockGuardFileName, so two
+// processes never both judge a lock stale and each remove the lock the
+// other just created.
+func AcquireLock(ws Workspace) (*Lock, error) {
+	guard, err := lockGuard(ws.Dir)
+	if err != nil {
+		return nil, err
+	}
+	defer guard.Close()
+
+	path := filepath.Join(ws.Dir, LockFileName)
+	host, _ := os.Hostname()
+	holder := LockHolder{PID: os.Getpid(), Hostname: host, AcquiredAt: time.Now().UTC()}
+	data, err := json.Marshal(holder)
+	if err != nil {
+		return nil, fmt.Errorf("marshal lock: %w", err)
+	}
+
+	// One retry: the first attempt may find a stale lock to remove.
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, werr := f.Write(data)
+			if cerr := f.Close(); werr == nil {
+				werr = cerr
+			}
+			if werr != nil {
+				_ = os.Remove(path)
+				return nil, fmt.Errorf("write lock: %w", werr)
+			}
+			l := &Lock{path: path, stop: make(chan struct{})}
+			l.wg.Add(1)
+			go l.heartbeat()
+			return l, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("create lock: %w", err)
+		}
+		current, stale, rerr := inspectLock(path)
+		if rerr != nil {
+			if os.IsNotExist(rerr) && attempt == 0 {
+				continue // released between our create and read
+			}
+			return nil, fmt.Errorf("read lock: %w", rerr)
+		}
+		if !stale || attempt > 0 {
+			return nil, &LockedError{Path: path, Holder: current}
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("remove stale lock: %w", err)
+		}
+	}
+}
+
+// lockGuard opens the guard file in dir and takes an exclusive flock on it,
+// released when the file is closed.
+func lockGuard(dir string) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, LockGuardFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock guard: %w", err)
+	}
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("lock guard: %w", err)
+	}
+	return f, nil
+}
+
+// inspectLock reads the lock file and reports whether it is stale. An
+// unreadable (e.g. half-written) lock is judged by its heartbeat alone.
+func inspectLock(path string) (LockHolder, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return LockHolder{}, false, err
+	}
+	expired := time.Since(info.ModTime()) > LockStaleAfter
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return LockHolder{}, false, err
+	}
+	var holder LockHolder
+	if err := json.Unmarshal(b, &holder); err != nil {
+		return holder, expired, nil
+	}
+	return holder, expired || !ProcessAlive(holder.PID, holder.Hostname), nil
+}
+
+// ProcessAlive reports whether process pid on host may still be running.
+//
+// Processes on another host cannot be probed and are assumed alive, so state
+// on shared workspaces is never reclaimed from the wrong machine.
+func ProcessAlive(pid int, hostname string) bool {
+	if pid <= 0 {
+		return false
+	}
+	if host, err := os.Hostname(); err != nil || host != hostname {
+		return true
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+func (l *Lock) heartbeat() {
+	defer l.wg.Done()
+	t := time.NewTicker(LockHeartbeatInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case now := <-t.C:
+			_ = os.Chtimes(l.path, now, now)
+		}
+	}
+}
+
+// Release stops the heartbeat and removes the lock file. It is safe to call
+// more than once and on a nil Lock.
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+	var err error
+	l.once.Do(func() {
+		close(l.stop)
+		l.wg.Wait()
+		if rerr := os.Remove(l.path); rerr != nil && !os.IsNotExist(rerr) {
+			err = rerr
+		}
+	})
+	return err
+}
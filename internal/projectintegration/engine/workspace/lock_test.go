@@ -0,0 +1,140 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeLock(t *testing.T, ws Workspace, holder LockHolder, mtime time.Time) {
+	t.Helper()
+	b, err := json.Marshal(holder)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	path := filepath.Join(ws.Dir, LockFileName)
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+func TestAcquireLock_ExcludesConcurrentRuns(t *testing.T) {
+	ws, err := EnsureWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatalf("EnsureWorkspace: %v", err)
+	}
+	lock, err := AcquireLock(ws)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	// The held lock must not make the workspace invalid.
+	if _, err := EnsureWorkspace(ws.ProjectRoot); err != nil {
+		t.Fatalf("EnsureWorkspace with lock held: %v", err)
+	}
+
+	_, err = AcquireLock(ws)
+	var locked *LockedError
+	if !errors.Is(err, ErrWorkspaceLocked) || !errors.As(err, &locked) || locked.Holder.PID != os.Getpid() {
+		t.Fatalf("expected LockedError held by this process, got %v", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("second Release: %v", err)
+	}
+	again, err := AcquireLock(ws)
+	if err != nil {
+		t.Fatalf("AcquireLock after release: %v", err)
+	}
+	_ = again.Release()
+}
+
+func TestAcquireLock_TakesOverStaleLocks(t *testing.T) {
+	ws, err := EnsureWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatalf("EnsureWorkspace: %v", err)
+	}
+	host, _ := os.Hostname()
+	dead := exec.Command("true")
+	if err := dead.Run(); err != nil {
+		t.Fatalf("run true: %v", err)
+	}
+	now := time.Now()
+
+	cases := []struct {
+		name   string
+		holder LockHolder
+		mtime  time.Time
+		stale  bool
+	}{
+		{"dead process", LockHolder{PID: dead.ProcessState.Pid(), Hostname: host}, now, true},
+		{"expired heartbeat", LockHolder{PID: 1, Hostname: host + "-elsewhere"}, now.Add(-2 * LockStaleAfter), true},
+		{"other host", LockHolder{PID: 1, Hostname: host + "-elsewhere"}, now, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeLock(t, ws, tc.holder, tc.mtime)
+			lock, err := AcquireLock(ws)
+			if tc.stale {
+				if err != nil {
+					t.Fatalf("expected stale lock taken over, got %v", err)
+				}
+				_ = lock.Release()
+				return
+			}
+			if !errors.Is(err, ErrWorkspaceLocked) {
+				t.Fatalf("expected ErrWorkspaceLocked, got %v", err)
+			}
+			_ = os.Remove(filepath.Join(ws.Dir, LockFileName))
+		})
+	}
+}
+
+func TestAcquireLock_ConcurrentTakeoverHasOneWinner(t *testing.T) {
+	ws, err := EnsureWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatalf("EnsureWorkspace: %v", err)
+	}
+	host, _ := os.Hostname()
+	for round := 0; round < 20; round++ {
+		writeLock(t, ws, LockHolder{PID: 1, Hostname: host + "-elsewhere"}, time.Now().Add(-2*LockStaleAfter))
+
+		const n = 8
+		locks := make(chan *Lock, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if l, err := AcquireLock(ws); err == nil {
+					locks <- l
+				} else if !errors.Is(err, ErrWorkspaceLocked) {
+					t.Errorf("AcquireLock: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		close(locks)
+		var held []*Lock
+		for l := range locks {
+			held = append(held, l)
+		}
+		if len(held) != 1 {
+			t.Fatalf("round %d: %d processes took over the stale lock, want 1", round, len(held))
+		}
+		_ = held[0].Release()
+	}
+	if _, err := EnsureWorkspace(ws.ProjectRoot); err != nil {
+		t.Fatalf("EnsureWorkspace with the lock guard present: %v", err)
+	}
+}
@@ -20,6 +20,24 @@ type Workspace struct {
 	ConfigPath  string
 }
 
+// FingerprintsFileName is the input fingerprint cache under .scriptweaver.
+const FingerprintsFileName = "fingerprints.json"
+
+// RunSequencesFileName holds the per-graph run ID sequence numbers under
+// .scriptweaver (see state.RunIDGraph).
+const RunSequencesFileName = "run_sequences.json"
+
+// DownloadsDirName is the remote input download cache under .scriptweaver.
+const DownloadsDirName = "downloads"
+
+// QuarantineDirName holds the damaged run state moved aside by `runs
+// repair` under .scriptweaver (see state.Store.QuarantineDir).
+const QuarantineDirName = "quarantine"
+
+// DaemonSocketName is the Unix socket `scriptweaver daemon` serves on under
+// .scriptweaver.
+const DaemonSocketName = "daemon.sock"
+
 var (
 	ErrInvalidProjectRoot     = errors.New("invalid project root")
 	ErrInvalidWorkspace       = errors.New("invalid .scriptweaver workspace")
@@ -51,7 +69,9 @@ func DetectProjectRoot() (string, error) {
 // not exist, they are created.
 //
 // Rejection behavior: if the workspace contains any unauthorized files or
-// directories (other than optional config.json), initialization fails.
+// directories (other than optional config.json, the run lock, the input
+// fingerprint cache, the effects ledger, the download cache and the daemon
+// socket), initialization fails.
 func EnsureWorkspace(projectRoot string) (Workspace, error) {
 	root := projectRoot
 	if root == "" {
@@ -133,11 +153,11 @@ func validateWorkspaceTopLevel(workspaceDir string) error {
 	for _, entry := range entries {
 		name := entry.Name()
 		switch name {
-		case "cache", "runs", "logs", "graphs":
+		case "cache", "runs", "logs", "graphs", "effects", "index", DownloadsDirName, QuarantineDirName:
 			if !entry.IsDir() {
 				return fmt.Errorf("%w: %s must be a directory", ErrInvalidWorkspace, filepath.Join(workspaceDir, name))
 			}
-		case "config.json":
+		case "config.json", LockFileName, LockGuardFileName, FingerprintsFileName, DaemonSocketName, RunSequencesFileName:
 			if entry.IsDir() {
 				return fmt.Errorf("%w: %s must be a file", ErrInvalidWorkspace, filepath.Join(workspaceDir, name))
 			}
@@ -0,0 +1,294 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// IndexedCheckpoint is one node's entry in a CheckpointIndex.
+type IndexedCheckpoint struct {
+	// Runs lists the runs holding this checkpoint, sorted. Runs that
+	// checkpoint an unchanged task again share one entry, which keeps the
+	// newest timestamp.
+	Runs       []string   `json:"runs"`
+	Checkpoint Checkpoint `json:"checkpoint"`
+}
+
+// CheckpointIndex is the compacted view of a graph's checkpoints: for each
+// node, the newest valid checkpoint among the runs folded into it, under
+// <baseDir>/.scriptweaver/index/<sha256(graph hash)>.checkpoints.json.
+// Resume planning reads this one document instead of every checkpoint file
+// of the run it resumes. Like the result index, entries outlive the runs
+// that wrote them.
+type CheckpointIndex struct {
+	GraphHash string `json:"graph_hash"`
+	// Runs maps each folded run to the nodes it holds a valid checkpoint
+	// for, sorted.
+	Runs  map[string][]string          `json:"runs"`
+	Nodes map[string]IndexedCheckpoint `json:"nodes"`
+	// Subtrees maps each task of the graph to the hash of its upstream
+	// closure (see dag.TaskGraph.SubtreeHash), so the checkpoints can be
+	// matched to the tasks of an edited graph. It is recorded by runs; see
+	// RecordSubtrees.
+	Subtrees map[string]string `json:"subtrees,omitempty"`
+}
+
+func newCheckpointIndex(graphHash string) CheckpointIndex {
+	return CheckpointIndex{GraphHash: graphHash, Runs: map[string][]string{}, Nodes: map[string]IndexedCheckpoint{}}
+}
+
+func (x CheckpointIndex) Validate() error {
+	var errs []error
+	if strings.TrimSpace(x.GraphHash) == "" {
+		errs = append(errs, errors.New("graph_hash is required"))
+	}
+	if x.Runs == nil {
+		errs = append(errs, errors.New("runs must be an object (not null)"))
+	}
+	for id, nodes := range x.Runs {
+		if nodes == nil || !slices.IsSorted(nodes) {
+			errs = append(errs, fmt.Errorf("runs[%q] must be a sorted array", id))
+		}
+	}
+	if x.Nodes == nil {
+		errs = append(errs, errors.New("nodes must be an object (not null)"))
+	}
+	for node, e := range x.Nodes {
+		if len(e.Runs) == 0 || !slices.IsSorted(e.Runs) {
+			errs = append(errs, fmt.Errorf("nodes[%q].runs must be a sorted, non-empty array", node))
+		}
+		for _, id := range e.Runs {
+			if _, ok := x.Runs[id]; !ok {
+				errs = append(errs, fmt.Errorf("nodes[%q].runs: run %q is not folded", node, id))
+			}
+		}
+		if e.Checkpoint.NodeID != node {
+			errs = append(errs, fmt.Errorf("nodes[%q] holds the checkpoint of %q", node, e.Checkpoint.NodeID))
+		}
+		if err := e.Checkpoint.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("nodes[%q]: %w", node, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fold merges the valid checkpoints of runID into x. A checkpoint with the
+// cache keys and output hash of a node's entry joins it; any other replaces
+// the entry when it is newer, equal timestamps being broken by the greater
+// run ID, so the result does not depend on the order runs are folded in.
+func (x *CheckpointIndex) fold(runID string, checkpoints map[string]Checkpoint) {
+	nodes := []string{}
+	for node, cp := range checkpoints {
+		if !cp.Valid {
+			continue
+		}
+		nodes = append(nodes, node)
+		if cp.CacheKeys == nil {
+			cp.CacheKeys = []string{}
+		}
+		cur, ok := x.Nodes[node]
+		switch {
+		case !ok:
+		case slices.Equal(cur.Checkpoint.CacheKeys, cp.CacheKeys) && cur.Checkpoint.OutputHash == cp.OutputHash:
+			if i, found := slices.BinarySearch(cur.Runs, runID); !found {
+				cur.Runs = slices.Insert(cur.Runs, i, runID)
+			}
+			if cp.Timestamp.After(cur.Checkpoint.Timestamp) {
+				cur.Checkpoint.Timestamp = cp.Timestamp
+			}
+			x.Nodes[node] = cur
+			continue
+		case cur.Checkpoint.Timestamp.After(cp.Timestamp):
+			continue
+		case cur.Checkpoint.Timestamp.Equal(cp.Timestamp) && cur.Runs[len(cur.Runs)-1] > runID:
+			continue
+		}
+		x.Nodes[node] = IndexedCheckpoint{Runs: []string{runID}, Checkpoint: cp}
+	}
+	slices.Sort(nodes)
+	x.Runs[runID] = nodes
+}
+
+func (s *Store) checkpointIndexPath(graphHash string) string {
+	sum := sha256.Sum256([]byte(graphHash))
+	return filepath.Join(s.baseDir, ".scriptweaver", "index", hex.EncodeToString(sum[:])+".checkpoints.json")
+}
+
+// LoadCheckpointIndex reads the checkpoint index of graphHash. A graph with
+// no folded runs has an empty index.
+func (s *Store) LoadCheckpointIndex(graphHash string) (CheckpointIndex, error) {
+	if strings.TrimSpace(graphHash) == "" {
+		return CheckpointIndex{}, errors.New("graphHash is required")
+	}
+	var x CheckpointIndex
+	err := readJSONStrict(s.checkpointIndexPath(graphHash), &x)
+	if os.IsNotExist(err) {
+		return newCheckpointIndex(graphHash), nil
+	}
+	if err != nil {
+		return CheckpointIndex{}, fmt.Errorf("read checkpoint index: %w", err)
+	}
+	if err := x.Validate(); err != nil {
+		return CheckpointIndex{}, fmt.Errorf("invalid checkpoint index on disk: %w", err)
+	}
+	if x.GraphHash != graphHash {
+		return CheckpointIndex{}, fmt.Errorf("checkpoint index for %q holds graph hash %q", graphHash, x.GraphHash)
+	}
+	return x, nil
+}
+
+// FoldCheckpoints merges the checkpoints of runID, a run of the graph with
+// graphHash, into the graph's checkpoint index and returns the result.
+// Folding a run again is harmless. Callers serialize updates through the
+// workspace lock.
+func (s *Store) FoldCheckpoints(graphHash, runID string) (CheckpointIndex, error) {
+	x, err := s.LoadCheckpointIndex(graphHash)
+	if err != nil {
+		return CheckpointIndex{}, err
+	}
+	cps, err := s.LoadAllCheckpoints(runID)
+	if err != nil {
+		return CheckpointIndex{}, err
+	}
+	x.fold(runID, cps)
+	return x, s.saveCheckpointIndex(x)
+}
+
+// RecordSubtrees records the subtree hash of each task of the graph with
+// graphHash in its checkpoint index. The hashes follow from the graph, so
+// once recorded they are kept.
+func (s *Store) RecordSubtrees(graphHash string, subtrees map[string]string) error {
+	x, err := s.LoadCheckpointIndex(graphHash)
+	if err != nil {
+		return err
+	}
+	if len(x.Subtrees) > 0 {
+		return nil
+	}
+	x.Subtrees = subtrees
+	return s.saveCheckpointIndex(x)
+}
+
+// LatestCheckpoints returns the newest valid checkpoint of each node across
+// all runs of the graph with graphHash, folding the runs its checkpoint index
+// does not cover yet. Runs whose record or checkpoints cannot be read are
+// skipped, as is an unreadable index, which is rebuilt from the runs.
+func (s *Store) LatestCheckpoints(graphHash string) (map[string]Checkpoint, error) {
+	x, err := s.LoadCheckpointIndex(graphHash)
+	if err != nil {
+		x = newCheckpointIndex(graphHash)
+	}
+	ids, err := s.ListRunIDs()
+	if err != nil {
+		return nil, err
+	}
+	folded := false
+	for _, id := range ids {
+		if _, ok := x.Runs[id]; ok {
+			continue
+		}
+		run, err := s.LoadRun(id)
+		if err != nil || run.GraphHash != graphHash {
+			continue
+		}
+		cps, err := s.LoadAllCheckpoints(id)
+		if err != nil {
+			continue
+		}
+		x.fold(id, cps)
+		folded = true
+	}
+	if folded {
+		if err := s.saveCheckpointIndex(x); err != nil {
+			return nil, err
+		}
+	}
+	checkpoints := make(map[string]Checkpoint, len(x.Nodes))
+	for node, e := range x.Nodes {
+		checkpoints[node] = e.Checkpoint
+	}
+	return checkpoints, nil
+}
+
+// CompactCheckpoints rebuilds the checkpoint index of every graph with
+// recorded runs by folding in each run's checkpoints, and removes the
+// indexes of graphs without any, e.g. after runs were pruned or repaired.
+// Runs whose record or checkpoints cannot be read are left out, and recorded
+// subtree hashes are kept. The rebuilt
+// indexes are returned sorted by graph hash.
+func (s *Store) CompactCheckpoints() ([]CheckpointIndex, error) {
+	ids, err := s.ListRunIDs()
+	if err != nil {
+		return nil, err
+	}
+	byGraph := make(map[string]*CheckpointIndex)
+	for _, id := range ids {
+		run, err := s.LoadRun(id)
+		if err != nil {
+			continue
+		}
+		cps, err := s.LoadAllCheckpoints(id)
+		if err != nil {
+			continue
+		}
+		x, ok := byGraph[run.GraphHash]
+		if !ok {
+			nx := newCheckpointIndex(run.GraphHash)
+			if old, err := s.LoadCheckpointIndex(run.GraphHash); err == nil {
+				nx.Subtrees = old.Subtrees
+			}
+			x = &nx
+			byGraph[run.GraphHash] = x
+		}
+		x.fold(id, cps)
+	}
+
+	keep := make(map[string]bool, len(byGraph))
+	out := make([]CheckpointIndex, 0, len(byGraph))
+	for _, x := range byGraph {
+		if err := s.saveCheckpointIndex(*x); err != nil {
+			return nil, err
+		}
+		keep[filepath.Base(s.checkpointIndexPath(x.GraphHash))] = true
+		out = append(out, *x)
+	}
+	slices.SortFunc(out, func(a, b CheckpointIndex) int { return strings.Compare(a.GraphHash, b.GraphHash) })
+
+	dir := filepath.Join(s.baseDir, ".scriptweaver", "index")
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range entries {
+		if name := e.Name(); strings.HasSuffix(name, ".checkpoints.json") && !keep[name] {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("remove checkpoint index: %w", err)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *Store) saveCheckpointIndex(x CheckpointIndex) error {
+	if err := x.Validate(); err != nil {
+		return fmt.Errorf("invalid checkpoint index: %w", err)
+	}
+	path := s.checkpointIndexPath(x.GraphHash)
+	if err := ensureDirDurable(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ensure index dir: %w", err)
+	}
+	data, err := jsonMarshalStable(x)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint index: %w", err)
+	}
+	if err := writeFileAtomicDurable(path, data, 0o644); err != nil {
+		return fmt.Errorf("write checkpoint index: %w", err)
+	}
+	return nil
+}
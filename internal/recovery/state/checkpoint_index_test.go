@@ -0,0 +1,98 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStore_CheckpointIndex(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cp := func(node, key string, at time.Duration) Checkpoint {
+		return Checkpoint{NodeID: node, Timestamp: t0.Add(at), CacheKeys: []string{key}, OutputHash: "o-" + key, Valid: true}
+	}
+	save := func(runID, graphHash string, cps ...Checkpoint) {
+		t.Helper()
+		if err := store.SaveRun(Run{RunID: runID, GraphHash: graphHash, StartTime: t0, Mode: ExecutionModeIncremental, Status: "failed"}); err != nil {
+			t.Fatalf("SaveRun: %v", err)
+		}
+		for _, c := range cps {
+			if err := store.SaveCheckpoint(runID, c); err != nil {
+				t.Fatalf("SaveCheckpoint: %v", err)
+			}
+		}
+	}
+
+	x, err := store.LoadCheckpointIndex("g1")
+	if err != nil || len(x.Runs) != 0 || len(x.Nodes) != 0 {
+		t.Fatalf("empty index: %+v err=%v", x, err)
+	}
+
+	// r2 checkpoints a unchanged and b with a new result; its invalid c
+	// checkpoint is left out.
+	save("r1", "g1", cp("a", "ka", 1*time.Second), cp("b", "kb1", 2*time.Second))
+	invalid := cp("c", "kc", 5*time.Second)
+	invalid.Valid = false
+	save("r2", "g1", cp("a", "ka", 3*time.Second), cp("b", "kb2", 4*time.Second), invalid)
+	if _, err := store.FoldCheckpoints("g1", "r2"); err != nil {
+		t.Fatalf("FoldCheckpoints r2: %v", err)
+	}
+	if _, err := store.FoldCheckpoints("g1", "r1"); err != nil {
+		t.Fatalf("FoldCheckpoints r1: %v", err)
+	}
+	x, err = store.LoadCheckpointIndex("g1")
+	if err != nil {
+		t.Fatalf("LoadCheckpointIndex: %v", err)
+	}
+	want := CheckpointIndex{
+		GraphHash: "g1",
+		Runs:      map[string][]string{"r1": {"a", "b"}, "r2": {"a", "b"}},
+		Nodes: map[string]IndexedCheckpoint{
+			"a": {Runs: []string{"r1", "r2"}, Checkpoint: cp("a", "ka", 3*time.Second)},
+			"b": {Runs: []string{"r2"}, Checkpoint: cp("b", "kb2", 4*time.Second)},
+		},
+	}
+	if !reflect.DeepEqual(x, want) {
+		t.Fatalf("index =\n%+v\nwant\n%+v", x, want)
+	}
+
+	// Runs the index does not cover yet are folded in when merging.
+	save("r0", "g1", cp("c", "kc", 0))
+	latest, err := store.LatestCheckpoints("g1")
+	if err != nil || len(latest) != 3 || latest["b"].CacheKeys[0] != "kb2" || latest["c"].CacheKeys[0] != "kc" {
+		t.Fatalf("LatestCheckpoints = %+v err=%v", latest, err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, ".scriptweaver", "runs", "r0")); err != nil {
+		t.Fatal(err)
+	}
+	delete(want.Nodes, "c")
+
+	// Compaction rebuilds from the runs on disk and drops indexes of graphs
+	// without runs.
+	save("r3", "g2", cp("a", "kx", time.Second))
+	if _, err := store.FoldCheckpoints("g2", "r3"); err != nil {
+		t.Fatalf("FoldCheckpoints r3: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, ".scriptweaver", "runs", "r3")); err != nil {
+		t.Fatal(err)
+	}
+	indexes, err := store.CompactCheckpoints()
+	if err != nil || len(indexes) != 1 || !reflect.DeepEqual(indexes[0], want) {
+		t.Fatalf("CompactCheckpoints = %+v err=%v", indexes, err)
+	}
+	if _, err := os.Stat(store.checkpointIndexPath("g2")); !os.IsNotExist(err) {
+		t.Fatalf("index of g2 survived compaction: %v", err)
+	}
+
+	runs, err := store.ListRunIDs()
+	if err != nil || !reflect.DeepEqual(runs, []string{"r1", "r2"}) {
+		t.Fatalf("the index must not appear as a run: %v err=%v", runs, err)
+	}
+}
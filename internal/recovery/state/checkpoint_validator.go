@@ -7,6 +7,8 @@ import (
 	"errors"
 	"fmt"
 	"hash"
+	"io"
+	"os"
 	"strings"
 	"time"
 
@@ -39,6 +41,13 @@ type CheckpointInput struct {
 	ExitCode        int
 	FromCache       bool
 	TraceEvents     []trace.TraceEvent
+
+	// SuccessExitCodes are the task's accepted non-zero exit codes.
+	SuccessExitCodes []int
+
+	// AllowedFailure marks the failed result of a task that allows failure;
+	// it is checkpointed so a resumed run replays the failure.
+	AllowedFailure bool
 }
 
 // CreateAndSave validates the provided evidence and, if valid, writes a checkpoint.
@@ -73,7 +82,7 @@ func (v *CheckpointValidator) CreateAndSave(in CheckpointInput) (Checkpoint, err
 	}
 
 	// 1) Verify node execution success.
-	if in.ExitCode != 0 {
+	if !in.AllowedFailure && !(&core.Task{SuccessExitCodes: in.SuccessExitCodes}).Succeeded(in.ExitCode) {
 		errs = append(errs, fmt.Errorf("node did not succeed (exit_code=%d)", in.ExitCode))
 	}
 
@@ -85,8 +94,10 @@ func (v *CheckpointValidator) CreateAndSave(in CheckpointInput) (Checkpoint, err
 		if err != nil {
 			errs = append(errs, fmt.Errorf("harvesting outputs: %w", err))
 		} else {
-			outputHash = computeArtifactSetHash(artifactSet)
-			if strings.TrimSpace(outputHash) == "" {
+			outputHash, err = computeArtifactSetHash(artifactSet)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("hashing outputs: %w", err))
+			} else if strings.TrimSpace(outputHash) == "" {
 				errs = append(errs, errors.New("output hash is empty"))
 			}
 		}
@@ -104,7 +115,7 @@ func (v *CheckpointValidator) CreateAndSave(in CheckpointInput) (Checkpoint, err
 
 	// 4) Verify trace entry completion.
 	if len(errs) == 0 {
-		if err := validateTraceForCheckpoint(in.TraceEvents, in.NodeID, in.FromCache); err != nil {
+		if err := validateTraceForCheckpoint(in.TraceEvents, in.NodeID, in.FromCache, in.AllowedFailure); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -126,7 +137,7 @@ func (v *CheckpointValidator) CreateAndSave(in CheckpointInput) (Checkpoint, err
 	return cp, nil
 }
 
-func validateTraceForCheckpoint(events []trace.TraceEvent, nodeID string, fromCache bool) error {
+func validateTraceForCheckpoint(events []trace.TraceEvent, nodeID string, fromCache, allowedFailure bool) error {
 	seenFailed := false
 	seenExecuted := false
 	seenArtifactsRestored := false
@@ -145,6 +156,12 @@ func validateTraceForCheckpoint(events []trace.TraceEvent, nodeID string, fromCa
 		}
 	}
 
+	if allowedFailure {
+		if !seenFailed {
+			return errors.New("trace entry incomplete: expected TaskFailed")
+		}
+		return nil
+	}
 	if seenFailed {
 		return errors.New("trace indicates task failure")
 	}
@@ -160,18 +177,49 @@ func validateTraceForCheckpoint(events []trace.TraceEvent, nodeID string, fromCa
 	return nil
 }
 
-func computeArtifactSetHash(set *core.ArtifactSet) string {
-	// Deterministic hash over the harvested artifacts.
+func computeArtifactSetHash(set *core.ArtifactSet) (string, error) {
+	// Deterministic hash over the harvested artifacts. Streamed artifacts are
+	// hashed from disk and yield the same digest as buffered ones.
 	h := sha256.New()
 	if set == nil {
 		h.Write([]byte("nil"))
-		return hex.EncodeToString(h.Sum(nil))
+		return hex.EncodeToString(h.Sum(nil)), nil
 	}
 	for _, a := range set.Artifacts {
 		writeLenPrefixed(h, []byte(a.Path))
-		writeLenPrefixed(h, a.Content)
+		writeLenPrefixed(h, a.ArtifactMetadata())
+		if a.SourcePath == "" || a.Content != nil {
+			writeLenPrefixed(h, a.Content)
+			continue
+		}
+		if err := writeLenPrefixedFile(h, a.SourcePath); err != nil {
+			return "", fmt.Errorf("artifact %q: %w", a.Path, err)
+		}
 	}
-	return hex.EncodeToString(h.Sum(nil))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func writeLenPrefixedFile(h hash.Hash, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(info.Size()))
+	_, _ = h.Write(n[:])
+	written, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if written != info.Size() {
+		return fmt.Errorf("file changed while hashing")
+	}
+	return nil
 }
 
 func writeLenPrefixed(h hash.Hash, b []byte) {
@@ -176,3 +176,31 @@ func TestCheckpointValidator_Smoke_WithRunnerCache(t *testing.T) {
 		t.Fatalf("CreateAndSave: %v", err)
 	}
 }
+
+func TestComputeArtifactSetHash_StreamedMatchesBuffered(t *testing.T) {
+	base := t.TempDir()
+	if err := os.WriteFile(filepath.Join(base, "out.bin"), []byte("artifact bytes"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	buffered := core.NewHarvester(base)
+	buffered.StreamThreshold = 0
+	streamed := core.NewHarvester(base)
+	streamed.StreamThreshold = 1
+
+	var hashes []string
+	for _, h := range []*core.Harvester{buffered, streamed} {
+		set, err := h.Harvest([]string{"out.bin"})
+		if err != nil {
+			t.Fatalf("Harvest: %v", err)
+		}
+		sum, err := computeArtifactSetHash(set)
+		if err != nil {
+			t.Fatalf("computeArtifactSetHash: %v", err)
+		}
+		hashes = append(hashes, sum)
+	}
+	if hashes[0] != hashes[1] {
+		t.Fatalf("streamed output hash %s differs from buffered %s", hashes[1], hashes[0])
+	}
+}
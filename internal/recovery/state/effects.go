@@ -0,0 +1,75 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Effect records that a task with an effect key (core.Task.EffectKey)
+// succeeded, under <baseDir>/.scriptweaver/effects/<sha256(key)>.json.
+//
+// Effects are workspace-wide rather than per run, so a key applied by any
+// earlier run, resumed or not, is not applied again.
+type Effect struct {
+	Key    string `json:"key"`
+	TaskID string `json:"task_id"`
+}
+
+func (e Effect) Validate() error {
+	var errs []error
+	if strings.TrimSpace(e.Key) == "" {
+		errs = append(errs, errors.New("key is required"))
+	}
+	if strings.TrimSpace(e.TaskID) == "" {
+		errs = append(errs, errors.New("task_id is required"))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Store) effectPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.baseDir, ".scriptweaver", "effects", hex.EncodeToString(sum[:])+".json")
+}
+
+// RecordEffect durably records key as applied by task. It implements
+// core.EffectLedger.
+func (s *Store) RecordEffect(key, task string) error {
+	e := Effect{Key: key, TaskID: task}
+	if err := e.Validate(); err != nil {
+		return fmt.Errorf("invalid effect: %w", err)
+	}
+	path := s.effectPath(key)
+	if err := ensureDirDurable(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ensure effects dir: %w", err)
+	}
+	data, err := jsonMarshalStable(e)
+	if err != nil {
+		return fmt.Errorf("marshal effect: %w", err)
+	}
+	if err := writeFileAtomicDurable(path, data, 0o644); err != nil {
+		return fmt.Errorf("write effect: %w", err)
+	}
+	return nil
+}
+
+// EffectApplied reports whether key has been recorded. It implements
+// core.EffectLedger.
+func (s *Store) EffectApplied(key string) (bool, error) {
+	var e Effect
+	err := readJSONStrict(s.effectPath(key), &e)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("read effect: %w", err)
+	}
+	if e.Key != key {
+		return false, fmt.Errorf("effect record for %q holds key %q", key, e.Key)
+	}
+	return true, nil
+}
@@ -0,0 +1,25 @@
+package state
+
+import "testing"
+
+func TestStore_RecordEffect(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if applied, err := store.EffectApplied("deploy/prod"); err != nil || applied {
+		t.Fatalf("EffectApplied before recording = %v, %v", applied, err)
+	}
+	if err := store.RecordEffect("deploy/prod", "deploy"); err != nil {
+		t.Fatalf("RecordEffect: %v", err)
+	}
+	if applied, err := store.EffectApplied("deploy/prod"); err != nil || !applied {
+		t.Fatalf("EffectApplied after recording = %v, %v", applied, err)
+	}
+	if applied, _ := store.EffectApplied("deploy/staging"); applied {
+		t.Fatal("an unrecorded key must not be applied")
+	}
+	if err := store.RecordEffect("", "deploy"); err == nil {
+		t.Fatal("expected an empty key to be rejected")
+	}
+}
@@ -37,7 +37,14 @@ func (r *FailureRecorder) StartRun(run Run) error {
 	if err := run.Validate(); err != nil {
 		return fmt.Errorf("invalid run: %w", err)
 	}
-	return r.Store.SaveRun(run)
+	if err := r.Store.SaveRun(run); err != nil {
+		return err
+	}
+	if run.Status == RunStatusRunning {
+		// The owner lets a later process tell a live run from a crashed one.
+		return r.Store.SaveOwner(run.RunID, CurrentOwner())
+	}
+	return nil
 }
 
 func (r *FailureRecorder) RecordFailure(runID string, err error) error {
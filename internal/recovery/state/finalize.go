@@ -0,0 +1,189 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"scriptweaver/internal/projectintegration/engine/workspace"
+)
+
+// RunOwner identifies the process executing a run (owner.json).
+//
+// It is written when a run starts executing and lets a later process decide
+// whether a run still marked "running" is live or was abandoned by a crash.
+type RunOwner struct {
+	PID      int    `json:"pid"`
+	Hostname string `json:"hostname"`
+}
+
+// RunEnd is the end-of-run record (end.json).
+//
+// It is the commit point of finalization: it is written atomically before
+// run.json's status is updated, so a crash between the two writes is
+// repaired from end.json by RecoverStaleRuns.
+type RunEnd struct {
+	RunID    string    `json:"run_id"`
+	Status   RunStatus `json:"status"`
+	ExitCode int       `json:"exit_code"`
+	EndTime  time.Time `json:"end_time"`
+}
+
+func (e RunEnd) Validate() error {
+	var errs []error
+	if strings.TrimSpace(e.RunID) == "" {
+		errs = append(errs, errors.New("run_id is required"))
+	}
+	if !e.Status.Terminal() {
+		errs = append(errs, fmt.Errorf("status %q is not terminal", e.Status))
+	}
+	if e.EndTime.IsZero() {
+		errs = append(errs, errors.New("end_time is required"))
+	}
+	return errors.Join(errs...)
+}
+
+// CurrentOwner describes the calling process.
+func CurrentOwner() RunOwner {
+	host, _ := os.Hostname()
+	return RunOwner{PID: os.Getpid(), Hostname: host}
+}
+
+// ProcessAlive reports whether the owner's process may still be running;
+// owners on another host are assumed alive.
+func ProcessAlive(o RunOwner) bool {
+	return workspace.ProcessAlive(o.PID, o.Hostname)
+}
+
+func (s *Store) ownerPath(runID string) string {
+	return filepath.Join(s.runDir(runID), "owner.json")
+}
+
+func (s *Store) endPath(runID string) string {
+	return filepath.Join(s.runDir(runID), "end.json")
+}
+
+func (s *Store) SaveOwner(runID string, owner RunOwner) error {
+	if strings.TrimSpace(runID) == "" {
+		return errors.New("runID is required")
+	}
+	if err := ensureDirDurable(s.runDir(runID), 0o755); err != nil {
+		return fmt.Errorf("ensure run dir: %w", err)
+	}
+	data, err := jsonMarshalStable(owner)
+	if err != nil {
+		return fmt.Errorf("marshal owner: %w", err)
+	}
+	if err := writeFileAtomicDurable(s.ownerPath(runID), data, 0o644); err != nil {
+		return fmt.Errorf("write owner: %w", err)
+	}
+	return nil
+}
+
+// LoadOwner reads a run's owner record. Runs recorded before owners existed
+// return an error satisfying os.IsNotExist.
+func (s *Store) LoadOwner(runID string) (RunOwner, error) {
+	var owner RunOwner
+	if strings.TrimSpace(runID) == "" {
+		return RunOwner{}, errors.New("runID is required")
+	}
+	if err := readJSONStrict(s.ownerPath(runID), &owner); err != nil {
+		return RunOwner{}, err
+	}
+	return owner, nil
+}
+
+// LoadRunEnd reads a run's end-of-run record. Unfinalized runs return an
+// error satisfying os.IsNotExist.
+func (s *Store) LoadRunEnd(runID string) (RunEnd, error) {
+	var end RunEnd
+	if strings.TrimSpace(runID) == "" {
+		return RunEnd{}, errors.New("runID is required")
+	}
+	if err := readJSONStrict(s.endPath(runID), &end); err != nil {
+		return RunEnd{}, err
+	}
+	if err := end.Validate(); err != nil {
+		return RunEnd{}, fmt.Errorf("invalid run end on disk: %w", err)
+	}
+	return end, nil
+}
+
+// FinalizeRun records the run's terminal status: end.json is written
+// atomically, then run.json's status is updated to match.
+func (s *Store) FinalizeRun(end RunEnd) error {
+	if s == nil {
+		return errors.New("nil Store")
+	}
+	if err := end.Validate(); err != nil {
+		return fmt.Errorf("invalid run end: %w", err)
+	}
+	run, err := s.LoadRun(end.RunID)
+	if err != nil {
+		return fmt.Errorf("load run: %w", err)
+	}
+	data, err := jsonMarshalStable(end)
+	if err != nil {
+		return fmt.Errorf("marshal run end: %w", err)
+	}
+	if err := writeFileAtomicDurable(s.endPath(end.RunID), data, 0o644); err != nil {
+		return fmt.Errorf("write run end: %w", err)
+	}
+	run.Status = end.Status
+	return s.SaveRun(run)
+}
+
+// RecoverStaleRuns finalizes runs left in "running" by a process that is no
+// longer alive and returns their IDs, sorted.
+//
+// A run with an end.json only had its run.json update interrupted; its status
+// is restored from end.json. A run without one whose owner is dead is marked
+// crashed, with a resumable "Crashed" system failure so resume can pick up
+// from its checkpoints. Runs without an owner record predate owner tracking
+// and are left alone.
+func (s *Store) RecoverStaleRuns(alive func(RunOwner) bool) ([]string, error) {
+	if s == nil {
+		return nil, errors.New("nil Store")
+	}
+	if alive == nil {
+		alive = ProcessAlive
+	}
+	ids, err := s.ListRunIDs()
+	if err != nil {
+		return nil, err
+	}
+	var recovered []string
+	for _, id := range ids {
+		run, err := s.LoadRun(id)
+		if err != nil || run.Status != RunStatusRunning {
+			continue
+		}
+		if end, err := s.LoadRunEnd(id); err == nil {
+			run.Status = end.Status
+			if err := s.SaveRun(run); err != nil {
+				return recovered, fmt.Errorf("repairing run %q: %w", id, err)
+			}
+			recovered = append(recovered, id)
+			continue
+		}
+		owner, err := s.LoadOwner(id)
+		if err != nil || alive(owner) {
+			continue
+		}
+		if _, err := s.LoadFailure(id); err != nil {
+			crash := &SystemFailureError{Code: "Crashed", Message: fmt.Sprintf("process %d exited without finalizing the run", owner.PID)}
+			f, _ := failureFromError(crash)
+			if err := s.SaveFailure(id, f); err != nil {
+				return recovered, fmt.Errorf("recording crash of run %q: %w", id, err)
+			}
+		}
+		if err := s.FinalizeRun(RunEnd{RunID: id, Status: RunStatusCrashed, ExitCode: -1, EndTime: time.Now().UTC()}); err != nil {
+			return recovered, fmt.Errorf("finalizing run %q: %w", id, err)
+		}
+		recovered = append(recovered, id)
+	}
+	return recovered, nil
+}
@@ -0,0 +1,119 @@
+package state
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStore_FinalizeRun_WritesEndAndStatus(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	rec := &FailureRecorder{Store: store}
+	start := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	if err := rec.StartRun(Run{RunID: "r1", GraphHash: "g", StartTime: start, Mode: ExecutionModeIncremental, Status: RunStatusRunning}); err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	if owner, err := store.LoadOwner("r1"); err != nil || owner != CurrentOwner() {
+		t.Fatalf("expected owner %+v, got %+v (err=%v)", CurrentOwner(), owner, err)
+	}
+	if _, err := store.LoadRunEnd("r1"); !os.IsNotExist(err) {
+		t.Fatalf("expected no end record before finalization, got %v", err)
+	}
+
+	end := RunEnd{RunID: "r1", Status: RunStatusSucceeded, ExitCode: 0, EndTime: start.Add(time.Minute)}
+	if err := store.FinalizeRun(end); err != nil {
+		t.Fatalf("FinalizeRun: %v", err)
+	}
+	got, err := store.LoadRunEnd("r1")
+	if err != nil || !reflect.DeepEqual(got, end) {
+		t.Fatalf("end record: got %+v (err=%v) want %+v", got, err, end)
+	}
+	run, err := store.LoadRun("r1")
+	if err != nil || run.Status != RunStatusSucceeded {
+		t.Fatalf("expected status succeeded, got %q (err=%v)", run.Status, err)
+	}
+
+	if err := store.FinalizeRun(RunEnd{RunID: "r1", Status: RunStatusRunning, EndTime: start}); err == nil {
+		t.Fatalf("expected non-terminal status to be rejected")
+	}
+}
+
+func TestStore_RecoverStaleRuns(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	start := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	save := func(id string, status RunStatus, owner *RunOwner) {
+		t.Helper()
+		if err := store.SaveRun(Run{RunID: id, GraphHash: "g", StartTime: start, Mode: ExecutionModeIncremental, Status: status}); err != nil {
+			t.Fatalf("SaveRun %s: %v", id, err)
+		}
+		if owner != nil {
+			if err := store.SaveOwner(id, *owner); err != nil {
+				t.Fatalf("SaveOwner %s: %v", id, err)
+			}
+		}
+	}
+	dead := RunOwner{PID: 100, Hostname: "h"}
+	live := RunOwner{PID: 200, Hostname: "h"}
+	save("dead", RunStatusRunning, &dead)
+	save("live", RunStatusRunning, &live)
+	save("legacy", RunStatusRunning, nil)
+	save("done", RunStatusFailed, &dead)
+	// "torn" crashed between writing end.json and updating run.json.
+	save("torn", RunStatusRunning, &dead)
+	if err := store.FinalizeRun(RunEnd{RunID: "torn", Status: RunStatusSucceeded, EndTime: start}); err != nil {
+		t.Fatalf("FinalizeRun: %v", err)
+	}
+	torn, _ := store.LoadRun("torn")
+	torn.Status = RunStatusRunning
+	if err := store.SaveRun(torn); err != nil {
+		t.Fatalf("SaveRun torn: %v", err)
+	}
+
+	alive := func(o RunOwner) bool { return o.PID == live.PID }
+	got, err := store.RecoverStaleRuns(alive)
+	if err != nil {
+		t.Fatalf("RecoverStaleRuns: %v", err)
+	}
+	if want := []string{"dead", "torn"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("recovered: got %v want %v", got, want)
+	}
+
+	want := map[string]RunStatus{"dead": RunStatusCrashed, "live": RunStatusRunning, "legacy": RunStatusRunning, "done": RunStatusFailed, "torn": RunStatusSucceeded}
+	for id, status := range want {
+		run, err := store.LoadRun(id)
+		if err != nil || run.Status != status {
+			t.Fatalf("%s: got status %q (err=%v) want %q", id, run.Status, err, status)
+		}
+	}
+	f, err := store.LoadFailure("dead")
+	if err != nil || f.ErrorCode != "Crashed" || f.FailureClass != FailureClassSystem || !f.Resumable {
+		t.Fatalf("expected resumable Crashed failure, got %+v (err=%v)", f, err)
+	}
+	if _, err := store.LoadFailure("torn"); !os.IsNotExist(err) {
+		t.Fatalf("a finalized run must not get a crash failure, got %v", err)
+	}
+
+	// Recovery is idempotent.
+	if got, err := store.RecoverStaleRuns(alive); err != nil || len(got) != 0 {
+		t.Fatalf("second recovery: got %v (err=%v)", got, err)
+	}
+}
+
+func TestProcessAlive(t *testing.T) {
+	if !ProcessAlive(CurrentOwner()) {
+		t.Fatalf("the current process must be alive")
+	}
+	if ProcessAlive(RunOwner{PID: 0, Hostname: CurrentOwner().Hostname}) {
+		t.Fatalf("pid 0 must not be alive")
+	}
+	if !ProcessAlive(RunOwner{PID: 1 << 30, Hostname: CurrentOwner().Hostname + "-elsewhere"}) {
+		t.Fatalf("processes on other hosts must be assumed alive")
+	}
+}
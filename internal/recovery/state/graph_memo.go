@@ -0,0 +1,103 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GraphMemo records the latest fully successful run of a graph, under
+// <baseDir>/.scriptweaver/index/<sha256(graph hash)>.memo.json, so that a
+// later run with the same params and task hashes can restore every task's
+// result and replay the run's trace without scheduling any task.
+//
+// The task hashes cover each task's resolved inputs; Outcome digests the
+// task fields that are in neither the graph hash nor the task hashes but
+// still shape the run's result or trace. Trace holds the run's canonical
+// trace bytes as they were written.
+type GraphMemo struct {
+	GraphHash  string            `json:"graph_hash"`
+	RunID      string            `json:"run_id"`
+	Params     map[string]string `json:"params"`
+	TaskHashes map[string]string `json:"task_hashes"`
+	Outcome    string            `json:"outcome,omitempty"`
+	Trace      []byte            `json:"trace"`
+}
+
+func (m GraphMemo) Validate() error {
+	var errs []error
+	if strings.TrimSpace(m.GraphHash) == "" {
+		errs = append(errs, errors.New("graph_hash is required"))
+	}
+	if strings.TrimSpace(m.RunID) == "" {
+		errs = append(errs, errors.New("run_id is required"))
+	}
+	if m.Params == nil {
+		errs = append(errs, errors.New("params must be an object (not null)"))
+	}
+	if len(m.TaskHashes) == 0 {
+		errs = append(errs, errors.New("task_hashes must not be empty"))
+	}
+	for name, h := range m.TaskHashes {
+		if strings.TrimSpace(h) == "" {
+			errs = append(errs, fmt.Errorf("task_hashes[%q] is empty", name))
+		}
+	}
+	if len(m.Trace) == 0 {
+		errs = append(errs, errors.New("trace is required"))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Store) graphMemoPath(graphHash string) string {
+	sum := sha256.Sum256([]byte(graphHash))
+	return filepath.Join(s.baseDir, ".scriptweaver", "index", hex.EncodeToString(sum[:])+".memo.json")
+}
+
+// LoadGraphMemo reads the memo of graphHash. ok is false when no fully
+// successful run of the graph was memoized.
+func (s *Store) LoadGraphMemo(graphHash string) (memo GraphMemo, ok bool, err error) {
+	if strings.TrimSpace(graphHash) == "" {
+		return GraphMemo{}, false, errors.New("graphHash is required")
+	}
+	err = readJSONStrict(s.graphMemoPath(graphHash), &memo)
+	if os.IsNotExist(err) {
+		return GraphMemo{}, false, nil
+	}
+	if err != nil {
+		return GraphMemo{}, false, fmt.Errorf("read graph memo: %w", err)
+	}
+	if err := memo.Validate(); err != nil {
+		return GraphMemo{}, false, fmt.Errorf("invalid graph memo on disk: %w", err)
+	}
+	if memo.GraphHash != graphHash {
+		return GraphMemo{}, false, fmt.Errorf("graph memo for %q holds graph hash %q", graphHash, memo.GraphHash)
+	}
+	return memo, true, nil
+}
+
+// SaveGraphMemo replaces the memo of memo.GraphHash.
+func (s *Store) SaveGraphMemo(memo GraphMemo) error {
+	if memo.Params == nil {
+		memo.Params = map[string]string{}
+	}
+	if err := memo.Validate(); err != nil {
+		return fmt.Errorf("invalid graph memo: %w", err)
+	}
+	path := s.graphMemoPath(memo.GraphHash)
+	if err := ensureDirDurable(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ensure index dir: %w", err)
+	}
+	data, err := jsonMarshalStable(memo)
+	if err != nil {
+		return fmt.Errorf("marshal graph memo: %w", err)
+	}
+	if err := writeFileAtomicDurable(path, data, 0o644); err != nil {
+		return fmt.Errorf("write graph memo: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStore_GraphMemo(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if _, ok, err := store.LoadGraphMemo("g1"); ok || err != nil {
+		t.Fatalf("missing memo: ok=%v err=%v", ok, err)
+	}
+
+	memo := GraphMemo{GraphHash: "g1", RunID: "r1", TaskHashes: map[string]string{"a": "h1"}, Trace: []byte("{\n  \"events\": []\n}\n")}
+	if err := store.SaveGraphMemo(memo); err != nil {
+		t.Fatalf("SaveGraphMemo: %v", err)
+	}
+	got, ok, err := store.LoadGraphMemo("g1")
+	memo.Params = map[string]string{}
+	if err != nil || !ok || !reflect.DeepEqual(got, memo) {
+		t.Fatalf("LoadGraphMemo = %+v ok=%v err=%v", got, ok, err)
+	}
+	if _, ok, err := store.LoadGraphMemo("g2"); ok || err != nil {
+		t.Fatalf("other graph: ok=%v err=%v", ok, err)
+	}
+
+	if err := store.SaveGraphMemo(GraphMemo{GraphHash: "g1", RunID: "r2", TaskHashes: map[string]string{"a": "h1"}}); err == nil {
+		t.Fatalf("expected a memo without trace to be rejected")
+	}
+}
@@ -0,0 +1,37 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// HookResult is the outcome of one graph-level hook command.
+type HookResult struct {
+	// Phase is "pre_run", "post_run" or "on_failure".
+	Phase    string `json:"phase"`
+	Command  string `json:"command"`
+	ExitCode int    `json:"exit_code"`
+
+	// Error is set when the command could not be run at all.
+	Error string `json:"error,omitempty"`
+}
+
+// RecordHooks appends hooks to the run record of runID.
+func (s *Store) RecordHooks(runID string, hooks []HookResult) error {
+	if s == nil {
+		return errors.New("nil Store")
+	}
+	if strings.TrimSpace(runID) == "" {
+		return errors.New("runID is required")
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+	run, err := s.LoadRun(runID)
+	if err != nil {
+		return fmt.Errorf("load run: %w", err)
+	}
+	run.Hooks = append(run.Hooks, hooks...)
+	return s.SaveRun(run)
+}
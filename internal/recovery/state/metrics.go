@@ -0,0 +1,142 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// TaskOutcome is the terminal outcome of a task within one run.
+type TaskOutcome string
+
+const (
+	TaskOutcomeExecuted TaskOutcome = "executed"
+	TaskOutcomeCached   TaskOutcome = "cached"
+	TaskOutcomeFailed   TaskOutcome = "failed"
+	TaskOutcomeSkipped  TaskOutcome = "skipped"
+)
+
+// TaskMetric is the per-task record in a run's metrics sidecar.
+//
+// DurationMillis is wall-clock time spent in the runner (execute, replay or
+// restore); skipped tasks report 0.
+type TaskMetric struct {
+	NodeID         string      `json:"node_id"`
+	Outcome        TaskOutcome `json:"outcome"`
+	DurationMillis int64       `json:"duration_ms"`
+	Group          string      `json:"group,omitempty"`
+}
+
+// RunMetrics is the metrics sidecar (metrics.json) written next to run.json.
+//
+// Unlike run.json, it carries host timing data and is never part of any
+// deterministic artifact; it only feeds operational reporting (stats export).
+type RunMetrics struct {
+	RunID string       `json:"run_id"`
+	Tasks []TaskMetric `json:"tasks"`
+	// Cache is the run's cache traffic; nil for runs recorded before it was
+	// collected.
+	Cache *CacheMetrics `json:"cache,omitempty"`
+	// GraphReplayed marks a run that matched a memoized run of its graph
+	// (see GraphMemo): every task was restored and the trace replayed
+	// without scheduling.
+	GraphReplayed bool `json:"graph_replayed,omitempty"`
+}
+
+// CacheMetrics is a run's cache traffic, as counted by core.StatsCache, plus
+// Restores: the number of tasks whose result was restored from the cache.
+type CacheMetrics struct {
+	// Namespace is the resolved cache namespace the run used (see
+	// --cache-namespace); empty without one.
+	Namespace    string `json:"namespace,omitempty"`
+	Lookups      int64  `json:"lookups"`
+	Hits         int64  `json:"hits"`
+	Misses       int64  `json:"misses"`
+	Gets         int64  `json:"gets"`
+	Puts         int64  `json:"puts"`
+	BytesRead    int64  `json:"bytes_read"`
+	BytesWritten int64  `json:"bytes_written"`
+	Restores     int64  `json:"restores"`
+}
+
+func (m RunMetrics) Validate() error {
+	var errs []error
+	if strings.TrimSpace(m.RunID) == "" {
+		errs = append(errs, errors.New("run_id is required"))
+	}
+	if m.Tasks == nil {
+		errs = append(errs, errors.New("tasks must be an array (not null)"))
+	}
+	seen := make(map[string]struct{}, len(m.Tasks))
+	for i, t := range m.Tasks {
+		if strings.TrimSpace(t.NodeID) == "" {
+			errs = append(errs, fmt.Errorf("tasks[%d].node_id is required", i))
+		}
+		if _, ok := seen[t.NodeID]; ok {
+			errs = append(errs, fmt.Errorf("tasks[%d].node_id %q is duplicated", i, t.NodeID))
+		}
+		seen[t.NodeID] = struct{}{}
+		switch t.Outcome {
+		case TaskOutcomeExecuted, TaskOutcomeCached, TaskOutcomeFailed, TaskOutcomeSkipped:
+			// ok
+		default:
+			errs = append(errs, fmt.Errorf("tasks[%d]: invalid outcome %q", i, t.Outcome))
+		}
+		if t.DurationMillis < 0 {
+			errs = append(errs, fmt.Errorf("tasks[%d].duration_ms must be >= 0", i))
+		}
+	}
+	if c := m.Cache; c != nil {
+		if c.Lookups < 0 || c.Hits < 0 || c.Misses < 0 || c.Gets < 0 || c.Puts < 0 || c.BytesRead < 0 || c.BytesWritten < 0 || c.Restores < 0 {
+			errs = append(errs, errors.New("cache counters must be >= 0"))
+		}
+		if c.Hits+c.Misses != c.Lookups {
+			errs = append(errs, fmt.Errorf("cache hits (%d) and misses (%d) must add up to lookups (%d)", c.Hits, c.Misses, c.Lookups))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Store) metricsPath(runID string) string {
+	return filepath.Join(s.runDir(runID), "metrics.json")
+}
+
+func (s *Store) SaveMetrics(metrics RunMetrics) error {
+	if metrics.Tasks == nil {
+		metrics.Tasks = []TaskMetric{}
+	}
+	if err := metrics.Validate(); err != nil {
+		return fmt.Errorf("invalid metrics: %w", err)
+	}
+	if err := ensureDirDurable(s.runDir(metrics.RunID), 0o755); err != nil {
+		return fmt.Errorf("ensure run dir: %w", err)
+	}
+	data, err := jsonMarshalStable(metrics)
+	if err != nil {
+		return fmt.Errorf("marshal metrics: %w", err)
+	}
+	if err := writeFileAtomicDurable(s.metricsPath(metrics.RunID), data, 0o644); err != nil {
+		return fmt.Errorf("write metrics: %w", err)
+	}
+	return nil
+}
+
+// LoadMetrics reads a run's metrics sidecar. Runs recorded before sidecars
+// existed return an error satisfying os.IsNotExist.
+func (s *Store) LoadMetrics(runID string) (RunMetrics, error) {
+	var metrics RunMetrics
+	if strings.TrimSpace(runID) == "" {
+		return RunMetrics{}, errors.New("runID is required")
+	}
+	if err := readJSONStrict(s.metricsPath(runID), &metrics); err != nil {
+		return RunMetrics{}, err
+	}
+	if err := metrics.Validate(); err != nil {
+		return RunMetrics{}, fmt.Errorf("invalid metrics on disk: %w", err)
+	}
+	return metrics, nil
+}
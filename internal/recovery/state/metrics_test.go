@@ -0,0 +1,47 @@
+package state
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStore_SaveAndLoadMetrics(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	if _, err := store.LoadMetrics("run-1"); !os.IsNotExist(err) {
+		t.Fatalf("expected not-exist for missing sidecar, got %v", err)
+	}
+
+	m := RunMetrics{RunID: "run-1", Tasks: []TaskMetric{
+		{NodeID: "build/compile", Outcome: TaskOutcomeExecuted, DurationMillis: 120},
+		{NodeID: "test", Outcome: TaskOutcomeSkipped},
+	}}
+	if err := store.SaveMetrics(m); err != nil {
+		t.Fatalf("SaveMetrics: %v", err)
+	}
+	loaded, err := store.LoadMetrics("run-1")
+	if err != nil {
+		t.Fatalf("LoadMetrics: %v", err)
+	}
+	if len(loaded.Tasks) != 2 || loaded.Tasks[0] != m.Tasks[0] || loaded.Tasks[1] != m.Tasks[1] {
+		t.Fatalf("metrics mismatch: %+v", loaded)
+	}
+}
+
+func TestRunMetrics_Validate(t *testing.T) {
+	cases := []RunMetrics{
+		{RunID: "", Tasks: []TaskMetric{}},
+		{RunID: "r", Tasks: nil},
+		{RunID: "r", Tasks: []TaskMetric{{NodeID: "a", Outcome: "bogus"}}},
+		{RunID: "r", Tasks: []TaskMetric{{NodeID: "a", Outcome: TaskOutcomeExecuted, DurationMillis: -1}}},
+		{RunID: "r", Tasks: []TaskMetric{{NodeID: "a", Outcome: TaskOutcomeCached}, {NodeID: "a", Outcome: TaskOutcomeCached}}},
+	}
+	for i, m := range cases {
+		if err := m.Validate(); err == nil {
+			t.Fatalf("case %d: expected validation error", i)
+		}
+	}
+}
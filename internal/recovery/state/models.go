@@ -17,6 +17,24 @@ const (
 
 type RunStatus string
 
+const (
+	RunStatusRunning   RunStatus = "running"
+	RunStatusSucceeded RunStatus = "succeeded"
+	RunStatusFailed    RunStatus = "failed"
+
+	// RunStatusCrashed marks a run whose process died before finalizing it.
+	RunStatusCrashed RunStatus = "crashed"
+)
+
+// Terminal reports whether the status is final.
+func (s RunStatus) Terminal() bool {
+	switch s {
+	case RunStatusSucceeded, RunStatusFailed, RunStatusCrashed:
+		return true
+	}
+	return false
+}
+
 // Run is the persistent execution attempt metadata.
 //
 // Schema constraints (frozen): must include run_id, graph_hash, start_time, mode,
@@ -29,6 +47,27 @@ type Run struct {
 	RetryCount    int           `json:"retry_count"`
 	Status        RunStatus     `json:"status"`
 	PreviousRunID *string       `json:"previous_run_id"`
+
+	// Invocation echoes the run's resolved settings, so the effect of
+	// scriptweaver.toml on a run can be seen after the fact.
+	Invocation []InvocationSetting `json:"invocation,omitempty"`
+
+	// Labels are the key=value pairs the run was tagged with (--label), such
+	// as a CI branch, commit SHA or pipeline ID, for filtering runs later.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Hooks records the graph-level hook commands the run executed, in
+	// order (see RecordHooks).
+	Hooks []HookResult `json:"hooks,omitempty"`
+}
+
+// InvocationSetting is one resolved setting of a run: a flag or param, its
+// value, and whether the value came from the command line ("flag"), the
+// config file ("config") or the built-in default ("default").
+type InvocationSetting struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
 }
 
 func (r Run) Validate() error {
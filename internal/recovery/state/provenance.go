@@ -0,0 +1,85 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Provenance is the environment a run executed in (provenance.json).
+//
+// It answers "where did this cached result come from" for old runs: tool
+// versions, platform and the graph and params the run was invoked with. It
+// is host-specific and never part of the canonical trace or any hash.
+type Provenance struct {
+	RunID     string    `json:"run_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ToolVersion string `json:"tool_version"`
+	GoVersion   string `json:"go_version"`
+	OS          string `json:"os"`
+	Arch        string `json:"arch"`
+
+	// Tools maps external tools the graph uses (e.g. the container engine)
+	// to the first line of their version output.
+	Tools map[string]string `json:"tools,omitempty"`
+
+	Mode            ExecutionMode     `json:"mode"`
+	GraphPath       string            `json:"graph_path"`
+	GraphHash       string            `json:"graph_hash"`
+	GraphFileSHA256 string            `json:"graph_file_sha256"`
+	Params          map[string]string `json:"params,omitempty"`
+}
+
+func (p Provenance) Validate() error {
+	var errs []error
+	if strings.TrimSpace(p.RunID) == "" {
+		errs = append(errs, errors.New("run_id is required"))
+	}
+	if p.CreatedAt.IsZero() {
+		errs = append(errs, errors.New("created_at is required"))
+	}
+	if strings.TrimSpace(p.GraphHash) == "" {
+		errs = append(errs, errors.New("graph_hash is required"))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Store) provenancePath(runID string) string {
+	return filepath.Join(s.runDir(runID), "provenance.json")
+}
+
+func (s *Store) SaveProvenance(p Provenance) error {
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("invalid provenance: %w", err)
+	}
+	if err := ensureDirDurable(s.runDir(p.RunID), 0o755); err != nil {
+		return fmt.Errorf("ensure run dir: %w", err)
+	}
+	data, err := jsonMarshalStable(p)
+	if err != nil {
+		return fmt.Errorf("marshal provenance: %w", err)
+	}
+	if err := writeFileAtomicDurable(s.provenancePath(p.RunID), data, 0o644); err != nil {
+		return fmt.Errorf("write provenance: %w", err)
+	}
+	return nil
+}
+
+// LoadProvenance reads a run's provenance record. Runs recorded without one
+// return an error satisfying os.IsNotExist.
+func (s *Store) LoadProvenance(runID string) (Provenance, error) {
+	var p Provenance
+	if strings.TrimSpace(runID) == "" {
+		return Provenance{}, errors.New("runID is required")
+	}
+	if err := readJSONStrict(s.provenancePath(runID), &p); err != nil {
+		return Provenance{}, err
+	}
+	if err := p.Validate(); err != nil {
+		return Provenance{}, fmt.Errorf("invalid provenance on disk: %w", err)
+	}
+	return p, nil
+}
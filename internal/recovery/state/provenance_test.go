@@ -0,0 +1,43 @@
+package state
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStore_SaveAndLoadProvenance(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	if _, err := store.LoadProvenance("run-1"); !os.IsNotExist(err) {
+		t.Fatalf("expected not-exist for missing provenance, got %v", err)
+	}
+	if err := store.SaveProvenance(Provenance{RunID: "run-1"}); err == nil {
+		t.Fatalf("expected validation error")
+	}
+
+	p := Provenance{
+		RunID:     "run-1",
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		GoVersion: "go1.22",
+		OS:        "linux",
+		Arch:      "amd64",
+		Tools:     map[string]string{"docker": "Docker version 25.0.0"},
+		Mode:      ExecutionModeIncremental,
+		GraphHash: "abc",
+		Params:    map[string]string{"target": "prod"},
+	}
+	if err := store.SaveProvenance(p); err != nil {
+		t.Fatalf("SaveProvenance: %v", err)
+	}
+	loaded, err := store.LoadProvenance("run-1")
+	if err != nil {
+		t.Fatalf("LoadProvenance: %v", err)
+	}
+	if !loaded.CreatedAt.Equal(p.CreatedAt) || loaded.Tools["docker"] != p.Tools["docker"] || loaded.Params["target"] != "prod" || loaded.GraphHash != "abc" {
+		t.Fatalf("provenance mismatch: %+v", loaded)
+	}
+}
@@ -0,0 +1,146 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Kinds of state moved into quarantine.
+const (
+	QuarantinedRun        = "run"
+	QuarantinedCheckpoint = "checkpoint"
+	QuarantinedCacheEntry = "cache_entry"
+)
+
+// QuarantineEntry records one piece of damaged state moved into quarantine.
+type QuarantineEntry struct {
+	Kind string `json:"kind"`
+	// Node is the checkpointed task of a checkpoint.
+	Node string `json:"node,omitempty"`
+	// Hash and Namespace name a cache entry, or the one a checkpoint
+	// referenced.
+	Hash      string `json:"hash,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// CheckpointFile is one file in a run's checkpoints directory.
+type CheckpointFile struct {
+	// Name is the file name, e.g. "build.json".
+	Name   string
+	NodeID string
+	// Checkpoint is the loaded record; Err, when set, is why loading failed.
+	Checkpoint Checkpoint
+	Err        error
+}
+
+// QuarantineDir is the directory damaged state of runID is moved into:
+//
+//	<baseDir>/.scriptweaver/quarantine/<run-id>/
+//
+// Quarantined files are never read again; they are kept for inspection and
+// can be deleted at any time.
+func (s *Store) QuarantineDir(runID string) string {
+	return filepath.Join(s.baseDir, ".scriptweaver", "quarantine", runID)
+}
+
+// ScanCheckpoints loads every checkpoint file of runID, recording a load
+// failure per file instead of stopping at the first, unlike
+// LoadAllCheckpoints.
+//
+// Determinism: files are returned in filename order.
+func (s *Store) ScanCheckpoints(runID string) ([]CheckpointFile, error) {
+	if s == nil {
+		return nil, errors.New("nil Store")
+	}
+	if strings.TrimSpace(runID) == "" {
+		return nil, errors.New("runID is required")
+	}
+	entries, err := os.ReadDir(s.checkpointsDir(runID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []CheckpointFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		nodeID, err := url.PathUnescape(strings.TrimSuffix(name, ".json"))
+		if err != nil || strings.TrimSpace(nodeID) == "" {
+			continue
+		}
+		f := CheckpointFile{Name: name, NodeID: nodeID}
+		f.Checkpoint, f.Err = s.LoadCheckpoint(runID, nodeID)
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// QuarantineCheckpoint moves the checkpoint file name of runID to
+// checkpoints/ under QuarantineDir. The move is a rename, so the checkpoint
+// is either still in place or gone; a resumed run treats its task as never
+// checkpointed.
+func (s *Store) QuarantineCheckpoint(runID, name string) error {
+	if strings.TrimSpace(runID) == "" {
+		return errors.New("runID is required")
+	}
+	if name != filepath.Base(name) || !strings.HasSuffix(name, ".json") {
+		return fmt.Errorf("invalid checkpoint file name %q", name)
+	}
+	dst := filepath.Join(s.QuarantineDir(runID), "checkpoints")
+	if err := ensureDirDurable(dst, 0o755); err != nil {
+		return fmt.Errorf("ensure quarantine dir: %w", err)
+	}
+	if err := os.Rename(filepath.Join(s.checkpointsDir(runID), name), filepath.Join(dst, name)); err != nil {
+		return fmt.Errorf("quarantine checkpoint: %w", err)
+	}
+	return fsyncDir(s.checkpointsDir(runID))
+}
+
+// QuarantineRun moves the whole directory of runID to run/ under
+// QuarantineDir, for a run whose record cannot be read.
+func (s *Store) QuarantineRun(runID string) error {
+	if strings.TrimSpace(runID) == "" {
+		return errors.New("runID is required")
+	}
+	dir := s.QuarantineDir(runID)
+	if err := ensureDirDurable(dir, 0o755); err != nil {
+		return fmt.Errorf("ensure quarantine dir: %w", err)
+	}
+	if err := os.Rename(s.runDir(runID), filepath.Join(dir, "run")); err != nil {
+		return fmt.Errorf("quarantine run: %w", err)
+	}
+	return fsyncDir(s.runsRootDir())
+}
+
+func (s *Store) quarantineLogPath(runID string) string {
+	return filepath.Join(s.QuarantineDir(runID), "repair.json")
+}
+
+// AppendQuarantineLog adds entries to repair.json under QuarantineDir, so
+// the quarantine of a run explains every file in it across repairs.
+func (s *Store) AppendQuarantineLog(runID string, entries []QuarantineEntry) error {
+	if strings.TrimSpace(runID) == "" {
+		return errors.New("runID is required")
+	}
+	var log []QuarantineEntry
+	if err := readJSONStrict(s.quarantineLogPath(runID), &log); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read quarantine log: %w", err)
+	}
+	data, err := jsonMarshalStable(append(log, entries...))
+	if err != nil {
+		return fmt.Errorf("marshal quarantine log: %w", err)
+	}
+	if err := writeFileAtomicDurable(s.quarantineLogPath(runID), data, 0o644); err != nil {
+		return fmt.Errorf("write quarantine log: %w", err)
+	}
+	return nil
+}
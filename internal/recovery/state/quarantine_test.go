@@ -0,0 +1,53 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_QuarantineCheckpointMovesDamagedFile(t *testing.T) {
+	base := t.TempDir()
+	store, err := NewStore(base)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	cp := Checkpoint{NodeID: "ns/build", Timestamp: time.Unix(1, 0).UTC(), CacheKeys: []string{"h1"}, OutputHash: "o1", Valid: true}
+	if err := store.SaveCheckpoint("run-1", cp); err != nil {
+		t.Fatalf("SaveCheckpoint: %v", err)
+	}
+	bad := filepath.Join(base, ".scriptweaver", "runs", "run-1", "checkpoints", "test.json")
+	if err := os.WriteFile(bad, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := store.ScanCheckpoints("run-1")
+	if err != nil {
+		t.Fatalf("ScanCheckpoints: %v", err)
+	}
+	if len(files) != 2 || files[0].NodeID != "ns/build" || files[0].Err != nil || files[1].NodeID != "test" || files[1].Err == nil {
+		t.Fatalf("files = %+v", files)
+	}
+
+	if err := store.QuarantineCheckpoint("run-1", files[1].Name); err != nil {
+		t.Fatalf("QuarantineCheckpoint: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(store.QuarantineDir("run-1"), "checkpoints", "test.json")); err != nil {
+		t.Fatalf("quarantined file: %v", err)
+	}
+	all, err := store.LoadAllCheckpoints("run-1")
+	if err != nil || len(all) != 1 {
+		t.Fatalf("LoadAllCheckpoints after quarantine = %v, %v", all, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := store.AppendQuarantineLog("run-1", []QuarantineEntry{{Kind: QuarantinedCheckpoint, Node: "test", Reason: "unreadable"}}); err != nil {
+			t.Fatalf("AppendQuarantineLog: %v", err)
+		}
+	}
+	var log []QuarantineEntry
+	if err := readJSONStrict(filepath.Join(store.QuarantineDir("run-1"), "repair.json"), &log); err != nil || len(log) != 2 {
+		t.Fatalf("log = %+v, %v", log, err)
+	}
+}
@@ -0,0 +1,125 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TaskResult is the latest recorded result of one task in a graph.
+type TaskResult struct {
+	TaskHash string      `json:"task_hash"`
+	RunID    string      `json:"run_id"`
+	Outcome  TaskOutcome `json:"outcome"`
+	ExitCode int         `json:"exit_code"`
+}
+
+// ResultIndex maps a graph's task names to their latest results, under
+// <baseDir>/.scriptweaver/index/<sha256(graph hash)>.json.
+//
+// It lets callers look up a task's history without scanning every run
+// directory. Like effects, it is workspace-wide and outlives the runs it
+// refers to: RunID may name a run that has since been pruned.
+type ResultIndex struct {
+	GraphHash string                `json:"graph_hash"`
+	Tasks     map[string]TaskResult `json:"tasks"`
+}
+
+func (x ResultIndex) Validate() error {
+	var errs []error
+	if strings.TrimSpace(x.GraphHash) == "" {
+		errs = append(errs, errors.New("graph_hash is required"))
+	}
+	if x.Tasks == nil {
+		errs = append(errs, errors.New("tasks must be an object (not null)"))
+	}
+	for name, r := range x.Tasks {
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, errors.New("task name is required"))
+		}
+		if strings.TrimSpace(r.TaskHash) == "" {
+			errs = append(errs, fmt.Errorf("tasks[%q].task_hash is required", name))
+		}
+		if strings.TrimSpace(r.RunID) == "" {
+			errs = append(errs, fmt.Errorf("tasks[%q].run_id is required", name))
+		}
+		switch r.Outcome {
+		case TaskOutcomeExecuted, TaskOutcomeCached, TaskOutcomeFailed:
+			// ok
+		default:
+			errs = append(errs, fmt.Errorf("tasks[%q]: invalid outcome %q", name, r.Outcome))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Store) resultIndexPath(graphHash string) string {
+	sum := sha256.Sum256([]byte(graphHash))
+	return filepath.Join(s.baseDir, ".scriptweaver", "index", hex.EncodeToString(sum[:])+".json")
+}
+
+// LoadResultIndex reads the index of graphHash. A graph with no recorded
+// results has an empty index.
+func (s *Store) LoadResultIndex(graphHash string) (ResultIndex, error) {
+	if strings.TrimSpace(graphHash) == "" {
+		return ResultIndex{}, errors.New("graphHash is required")
+	}
+	var x ResultIndex
+	err := readJSONStrict(s.resultIndexPath(graphHash), &x)
+	if os.IsNotExist(err) {
+		return ResultIndex{GraphHash: graphHash, Tasks: map[string]TaskResult{}}, nil
+	}
+	if err != nil {
+		return ResultIndex{}, fmt.Errorf("read result index: %w", err)
+	}
+	if err := x.Validate(); err != nil {
+		return ResultIndex{}, fmt.Errorf("invalid result index on disk: %w", err)
+	}
+	if x.GraphHash != graphHash {
+		return ResultIndex{}, fmt.Errorf("result index for %q holds graph hash %q", graphHash, x.GraphHash)
+	}
+	return x, nil
+}
+
+// LatestTaskResult returns the latest recorded result of task in the graph
+// with graphHash; ok is false when the task has none.
+func (s *Store) LatestTaskResult(graphHash, task string) (result TaskResult, ok bool, err error) {
+	x, err := s.LoadResultIndex(graphHash)
+	if err != nil {
+		return TaskResult{}, false, err
+	}
+	result, ok = x.Tasks[task]
+	return result, ok, nil
+}
+
+// UpdateResultIndex records results as the latest for their tasks in the
+// graph with graphHash. Tasks not in results keep their previous entries.
+// Callers serialize updates through the workspace lock.
+func (s *Store) UpdateResultIndex(graphHash string, results map[string]TaskResult) error {
+	x, err := s.LoadResultIndex(graphHash)
+	if err != nil {
+		return err
+	}
+	for name, r := range results {
+		x.Tasks[name] = r
+	}
+	if err := x.Validate(); err != nil {
+		return fmt.Errorf("invalid result index: %w", err)
+	}
+	path := s.resultIndexPath(graphHash)
+	if err := ensureDirDurable(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ensure index dir: %w", err)
+	}
+	data, err := jsonMarshalStable(x)
+	if err != nil {
+		return fmt.Errorf("marshal result index: %w", err)
+	}
+	if err := writeFileAtomicDurable(path, data, 0o644); err != nil {
+		return fmt.Errorf("write result index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStore_ResultIndex(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	x, err := store.LoadResultIndex("g1")
+	if err != nil || x.GraphHash != "g1" || len(x.Tasks) != 0 {
+		t.Fatalf("empty index: %+v err=%v", x, err)
+	}
+
+	first := map[string]TaskResult{
+		"build": {TaskHash: "h1", RunID: "r1", Outcome: TaskOutcomeExecuted},
+		"test":  {TaskHash: "h2", RunID: "r1", Outcome: TaskOutcomeFailed, ExitCode: 1},
+	}
+	if err := store.UpdateResultIndex("g1", first); err != nil {
+		t.Fatalf("UpdateResultIndex: %v", err)
+	}
+	// A later run that only reaches test leaves build's entry alone.
+	if err := store.UpdateResultIndex("g1", map[string]TaskResult{"test": {TaskHash: "h3", RunID: "r2", Outcome: TaskOutcomeExecuted}}); err != nil {
+		t.Fatalf("UpdateResultIndex: %v", err)
+	}
+
+	got, ok, err := store.LatestTaskResult("g1", "test")
+	if err != nil || !ok || got != (TaskResult{TaskHash: "h3", RunID: "r2", Outcome: TaskOutcomeExecuted}) {
+		t.Fatalf("LatestTaskResult(test) = %+v ok=%v err=%v", got, ok, err)
+	}
+	got, ok, err = store.LatestTaskResult("g1", "build")
+	if err != nil || !ok || got != first["build"] {
+		t.Fatalf("LatestTaskResult(build) = %+v ok=%v err=%v", got, ok, err)
+	}
+	if _, ok, err := store.LatestTaskResult("g2", "build"); err != nil || ok {
+		t.Fatalf("other graph: ok=%v err=%v", ok, err)
+	}
+
+	runs, err := store.ListRunIDs()
+	if err != nil || !reflect.DeepEqual(runs, []string(nil)) {
+		t.Fatalf("the index must not appear as a run: %v err=%v", runs, err)
+	}
+
+	if err := store.UpdateResultIndex("g1", map[string]TaskResult{"lint": {TaskHash: "h", RunID: "r", Outcome: TaskOutcomeSkipped}}); err == nil {
+		t.Fatalf("expected skipped outcomes to be rejected")
+	}
+}
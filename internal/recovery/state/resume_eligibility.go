@@ -14,7 +14,7 @@ import (
 // ResumeEligibilityChecker determines whether a new run may resume from a previous run.
 //
 // Enforces frozen sprint-08 Resume Eligibility Rules:
-//   - Graph hash unchanged
+//   - Graph hash unchanged, unless the request allows graph edits
 //   - Workspace intact and validated
 //   - previous_run_id linked and exists
 //   - No upstream invalidation markers exist
@@ -38,6 +38,10 @@ type ResumeEligibilityRequest struct {
 	// used to verify that no upstream invalidation exists.
 	Graph        *incremental.GraphSnapshot
 	Invalidation incremental.InvalidationMap
+
+	// AllowGraphEdits permits a previous run of a different graph, whose
+	// checkpoints the caller matched to the new graph task by task.
+	AllowGraphEdits bool
 }
 
 func (c *ResumeEligibilityChecker) Check(req ResumeEligibilityRequest) error {
@@ -78,7 +82,7 @@ func (c *ResumeEligibilityChecker) Check(req ResumeEligibilityRequest) error {
 	}
 
 	// Graph hash must be unchanged.
-	if prevRun.GraphHash != req.NewRun.GraphHash {
+	if prevRun.GraphHash != req.NewRun.GraphHash && !req.AllowGraphEdits {
 		return fmt.Errorf("graph hash mismatch (prev=%s new=%s)", prevRun.GraphHash, req.NewRun.GraphHash)
 	}
 
@@ -67,10 +67,16 @@ func TestResumeEligibilityChecker_Rejects_WhenGraphHashDiffers(t *testing.T) {
 	newRun := Run{RunID: "new", GraphHash: "gh2", StartTime: time.Unix(2, 0).UTC(), Mode: ExecutionModeIncremental, RetryCount: 1, Status: "running", PreviousRunID: &prevID}
 
 	checker := &ResumeEligibilityChecker{Store: store, ProjectRoot: root}
-	err := checker.Check(ResumeEligibilityRequest{NewRun: newRun, ResumeFromNodeID: "A", Graph: &incremental.GraphSnapshot{Nodes: map[string]incremental.NodeSnapshot{"A": {Name: "A"}}}, Invalidation: incremental.InvalidationMap{"A": {Invalidated: false}}})
+	req := ResumeEligibilityRequest{NewRun: newRun, ResumeFromNodeID: "A", Graph: &incremental.GraphSnapshot{Nodes: map[string]incremental.NodeSnapshot{"A": {Name: "A"}}}, Invalidation: incremental.InvalidationMap{"A": {Invalidated: false}}}
+	err := checker.Check(req)
 	if err == nil {
 		t.Fatalf("expected error")
 	}
+
+	req.AllowGraphEdits = true
+	if err := checker.Check(req); err != nil {
+		t.Fatalf("expected graph edits to be allowed, got %v", err)
+	}
 }
 
 func TestResumeEligibilityChecker_Rejects_WhenUpstreamInvalidated(t *testing.T) {
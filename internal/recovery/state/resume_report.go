@@ -0,0 +1,122 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ResumeDecision is the outcome of a run's resume planning.
+type ResumeDecision string
+
+const (
+	// ResumeResumed: the run reuses work of the previous run.
+	ResumeResumed ResumeDecision = "resumed"
+	// ResumeScratch: the run executes from scratch (incremental mode falls
+	// back to this; cached results are still replayed).
+	ResumeScratch ResumeDecision = "scratch"
+	// ResumeRejected: the run had to resume (resume-only or --resume-from)
+	// and failed because it could not.
+	ResumeRejected ResumeDecision = "rejected"
+)
+
+// ResumeReport explains a run's resume planning (resume.json): what was
+// found of the previous run and why its work was or was not reused. It holds
+// no timestamps, so the same workspace state always yields the same report.
+type ResumeReport struct {
+	RunID      string        `json:"run_id"`
+	Mode       ExecutionMode `json:"mode"`
+	ResumeFrom string        `json:"resume_from,omitempty"`
+
+	// PreviousRunFound is set when a failed, crashed or interrupted run
+	// exists; PreviousRunID names the latest one, preferring runs of the
+	// same graph. GraphHashMatched is false when only runs of other graphs
+	// failed.
+	PreviousRunFound bool   `json:"previous_run_found"`
+	PreviousRunID    string `json:"previous_run_id,omitempty"`
+	GraphHashMatched bool   `json:"graph_hash_matched"`
+
+	// Checkpoints counts the previous run's checkpoints; CheckpointsValid is
+	// false when they are unreadable or their cache entries are missing.
+	Checkpoints      int  `json:"checkpoints"`
+	CheckpointsValid bool `json:"checkpoints_valid"`
+
+	// CheckpointNode ends the reused prefix of the topological order.
+	// InvalidatedNode is the first task after it, which is not reused; its
+	// entry in Nodes says why.
+	CheckpointNode  string `json:"checkpoint_node,omitempty"`
+	InvalidatedNode string `json:"invalidated_node,omitempty"`
+
+	// Nodes is every task's planned decision, in topological order. It is
+	// empty when planning stopped before tasks were considered.
+	Nodes []ResumeNodeReport `json:"nodes,omitempty"`
+
+	Decision ResumeDecision `json:"decision"`
+	// Reason says why the run did not resume; empty when it did.
+	Reason string `json:"reason,omitempty"`
+}
+
+// ResumeNodeReport is one task's resume planning decision.
+type ResumeNodeReport struct {
+	Name string `json:"name"`
+	// Reused is set for tasks restored from the previous run's checkpoint.
+	Reused bool `json:"reused"`
+	// Reason says why a task is not reused.
+	Reason string `json:"reason,omitempty"`
+}
+
+func (r ResumeReport) Validate() error {
+	var errs []error
+	if strings.TrimSpace(r.RunID) == "" {
+		errs = append(errs, errors.New("run_id is required"))
+	}
+	switch r.Decision {
+	case ResumeResumed, ResumeScratch, ResumeRejected:
+	default:
+		errs = append(errs, fmt.Errorf("unknown decision %q", r.Decision))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Store) resumeReportPath(runID string) string {
+	return filepath.Join(s.runDir(runID), "resume.json")
+}
+
+// SaveResumeReport writes r to the run directory of r.RunID.
+func (s *Store) SaveResumeReport(r ResumeReport) error {
+	if s == nil {
+		return errors.New("nil Store")
+	}
+	if err := r.Validate(); err != nil {
+		return fmt.Errorf("invalid resume report: %w", err)
+	}
+	if err := ensureDirDurable(s.runDir(r.RunID), 0o755); err != nil {
+		return fmt.Errorf("ensure run dir: %w", err)
+	}
+	data, err := jsonMarshalStable(r)
+	if err != nil {
+		return fmt.Errorf("marshal resume report: %w", err)
+	}
+	if err := writeFileAtomicDurable(s.resumeReportPath(r.RunID), data, 0o644); err != nil {
+		return fmt.Errorf("write resume report: %w", err)
+	}
+	return nil
+}
+
+// LoadResumeReport reads a run's resume report. Runs that did not plan a
+// resume (clean mode, or failures before planning) return an error
+// satisfying os.IsNotExist.
+func (s *Store) LoadResumeReport(runID string) (ResumeReport, error) {
+	var r ResumeReport
+	if strings.TrimSpace(runID) == "" {
+		return ResumeReport{}, errors.New("runID is required")
+	}
+	if err := readJSONStrict(s.resumeReportPath(runID), &r); err != nil {
+		return ResumeReport{}, err
+	}
+	if err := r.Validate(); err != nil {
+		return ResumeReport{}, fmt.Errorf("invalid resume report on disk: %w", err)
+	}
+	return r, nil
+}
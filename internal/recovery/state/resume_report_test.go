@@ -0,0 +1,48 @@
+package state
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestStore_SaveAndLoadResumeReport(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	if _, err := store.LoadResumeReport("run-2"); !os.IsNotExist(err) {
+		t.Fatalf("expected not-exist for missing report, got %v", err)
+	}
+	if err := store.SaveResumeReport(ResumeReport{RunID: "run-2"}); err == nil {
+		t.Fatalf("expected validation error for a missing decision")
+	}
+
+	r := ResumeReport{
+		RunID:            "run-2",
+		Mode:             ExecutionModeIncremental,
+		PreviousRunFound: true,
+		PreviousRunID:    "run-1",
+		GraphHashMatched: true,
+		Checkpoints:      1,
+		CheckpointsValid: true,
+		InvalidatedNode:  "a",
+		Nodes: []ResumeNodeReport{
+			{Name: "a", Reason: "task hash changed since the checkpoint"},
+			{Name: "b", Reason: `upstream task "a" is not reused`},
+		},
+		Decision: ResumeScratch,
+		Reason:   "no task can be reused",
+	}
+	if err := store.SaveResumeReport(r); err != nil {
+		t.Fatalf("SaveResumeReport: %v", err)
+	}
+	loaded, err := store.LoadResumeReport("run-2")
+	if err != nil {
+		t.Fatalf("LoadResumeReport: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, r) {
+		t.Fatalf("report mismatch:\n got %+v\nwant %+v", loaded, r)
+	}
+}
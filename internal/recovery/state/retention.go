@@ -0,0 +1,137 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultKeepRuns is the number of runs kept per graph hash when no
+// retention policy is configured explicitly.
+const DefaultKeepRuns = 50
+
+// RetentionPolicy bounds the runs kept under .scriptweaver/runs.
+//
+// A run is pruned when either limit selects it. Zero disables a limit.
+type RetentionPolicy struct {
+	// KeepLast is the number of most recent runs kept per graph hash.
+	KeepLast int
+
+	// MaxAge prunes runs that started more than MaxAge before now.
+	MaxAge time.Duration
+}
+
+// Enabled reports whether the policy prunes anything at all.
+func (p RetentionPolicy) Enabled() bool {
+	return p.KeepLast > 0 || p.MaxAge > 0
+}
+
+// Validate rejects negative limits.
+func (p RetentionPolicy) Validate() error {
+	var errs []error
+	if p.KeepLast < 0 {
+		errs = append(errs, errors.New("keep_last must be >= 0"))
+	}
+	if p.MaxAge < 0 {
+		errs = append(errs, errors.New("max_age must be >= 0"))
+	}
+	return errors.Join(errs...)
+}
+
+// SelectPrunable returns the IDs of runs the policy removes at time now,
+// sorted lexicographically.
+//
+// Determinism: within a graph hash, runs are ordered newest first by start
+// time, ties broken by run ID, and the first KeepLast are kept. Runs whose
+// run.json cannot be read are never selected, and neither are the protected
+// IDs (e.g. the current run and the run it resumes from).
+func (s *Store) SelectPrunable(p RetentionPolicy, now time.Time, protected ...string) ([]string, error) {
+	if s == nil {
+		return nil, errors.New("nil Store")
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if !p.Enabled() {
+		return nil, nil
+	}
+	ids, err := s.ListRunIDs()
+	if err != nil {
+		return nil, err
+	}
+	keep := make(map[string]bool, len(protected))
+	for _, id := range protected {
+		keep[id] = true
+	}
+
+	byGraph := make(map[string][]Run)
+	for _, id := range ids {
+		r, err := s.LoadRun(id)
+		if err != nil {
+			continue
+		}
+		byGraph[r.GraphHash] = append(byGraph[r.GraphHash], r)
+	}
+
+	var prune []string
+	for _, runs := range byGraph {
+		sort.Slice(runs, func(i, j int) bool {
+			if !runs[i].StartTime.Equal(runs[j].StartTime) {
+				return runs[i].StartTime.After(runs[j].StartTime)
+			}
+			return runs[i].RunID < runs[j].RunID
+		})
+		for i, r := range runs {
+			if keep[r.RunID] {
+				continue
+			}
+			tooMany := p.KeepLast > 0 && i >= p.KeepLast
+			tooOld := p.MaxAge > 0 && now.Sub(r.StartTime) > p.MaxAge
+			if tooMany || tooOld {
+				prune = append(prune, r.RunID)
+			}
+		}
+	}
+	sort.Strings(prune)
+	return prune, nil
+}
+
+// DeleteRun removes a run and everything recorded under it.
+//
+// run.json is removed first, so an interrupted deletion leaves a directory
+// that loaders skip rather than a run with missing checkpoints.
+func (s *Store) DeleteRun(runID string) error {
+	if s == nil {
+		return errors.New("nil Store")
+	}
+	if runID == "" || runID == "." || runID == ".." || strings.ContainsAny(runID, "/"+string(os.PathSeparator)) {
+		return fmt.Errorf("invalid run id %q", runID)
+	}
+	if err := os.Remove(s.runPath(runID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.RemoveAll(s.runDir(runID)); err != nil {
+		return err
+	}
+	return fsyncDir(s.runsRootDir())
+}
+
+// Prune deletes the runs SelectPrunable selects and returns their IDs.
+// Deletion stops at the first error; the IDs deleted so far are returned.
+func (s *Store) Prune(p RetentionPolicy, now time.Time, protected ...string) ([]string, error) {
+	ids, err := s.SelectPrunable(p, now, protected...)
+	if err != nil {
+		return nil, err
+	}
+	deleted := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if err := s.DeleteRun(id); err != nil {
+			return deleted, fmt.Errorf("deleting run %q: %w", id, err)
+		}
+		deleted = append(deleted, id)
+	}
+	return deleted, nil
+}
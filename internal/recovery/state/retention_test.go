@@ -0,0 +1,65 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStore_SelectPrunable_Deterministic(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	base := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	save := func(id, graph string, age time.Duration) {
+		t.Helper()
+		r := Run{RunID: id, GraphHash: graph, StartTime: base.Add(-age), Mode: ExecutionModeIncremental, Status: "completed"}
+		if err := store.SaveRun(r); err != nil {
+			t.Fatalf("SaveRun %s: %v", id, err)
+		}
+	}
+	save("a1", "g1", 1*time.Hour)
+	save("a2", "g1", 2*time.Hour)
+	save("a3", "g1", 3*time.Hour)
+	save("a4", "g1", 3*time.Hour) // ties with a3; broken by run ID, a3 ranks first
+	save("b1", "g2", 100*time.Hour)
+
+	got, err := store.SelectPrunable(RetentionPolicy{KeepLast: 2}, base)
+	if err != nil {
+		t.Fatalf("SelectPrunable: %v", err)
+	}
+	if want := []string{"a3", "a4"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("keep-last: got %v want %v", got, want)
+	}
+
+	got, err = store.SelectPrunable(RetentionPolicy{KeepLast: 3, MaxAge: 48 * time.Hour}, base, "b1")
+	if err != nil {
+		t.Fatalf("SelectPrunable: %v", err)
+	}
+	if want := []string{"a4"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("protected/max-age: got %v want %v", got, want)
+	}
+
+	deleted, err := store.Prune(RetentionPolicy{MaxAge: 48 * time.Hour}, base)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if want := []string{"b1"}; !reflect.DeepEqual(deleted, want) {
+		t.Fatalf("prune: got %v want %v", deleted, want)
+	}
+	ids, err := store.ListRunIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a1", "a2", "a3", "a4"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("remaining runs: got %v want %v", ids, want)
+	}
+
+	if got, _ := store.SelectPrunable(RetentionPolicy{}, base); len(got) != 0 {
+		t.Fatalf("disabled policy must prune nothing, got %v", got)
+	}
+	if err := store.DeleteRun("../escape"); err == nil {
+		t.Fatalf("expected invalid run id to be rejected")
+	}
+}
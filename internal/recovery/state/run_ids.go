@@ -0,0 +1,97 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"scriptweaver/internal/projectintegration/engine/workspace"
+)
+
+// RunIDStrategy selects how new run IDs are formed.
+type RunIDStrategy string
+
+const (
+	// RunIDRandom uses a random 128-bit hex string (the default).
+	RunIDRandom RunIDStrategy = "random"
+
+	// RunIDGraph derives the ID from the graph hash and a per-graph sequence
+	// number persisted in the store, e.g. "3f2a9c01d4e5b6a7-000004". The
+	// same sequence of runs on the same graph produces the same IDs.
+	RunIDGraph RunIDStrategy = "graph"
+)
+
+// ParseRunIDStrategy parses a run ID strategy name; empty means RunIDRandom.
+func ParseRunIDStrategy(raw string) (RunIDStrategy, error) {
+	switch s := RunIDStrategy(strings.ToLower(strings.TrimSpace(raw))); s {
+	case "":
+		return RunIDRandom, nil
+	case RunIDRandom, RunIDGraph:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unknown run ID strategy %q (expected random|graph)", raw)
+	}
+}
+
+// graphRunIDPrefixLen is how many graph hash characters prefix a RunIDGraph
+// run ID.
+const graphRunIDPrefixLen = 16
+
+// GraphRunID formats the RunIDGraph run ID for the seq'th run of graphHash.
+func GraphRunID(graphHash string, seq int) string {
+	prefix := graphHash
+	if len(prefix) > graphRunIDPrefixLen {
+		prefix = prefix[:graphRunIDPrefixLen]
+	}
+	return fmt.Sprintf("%s-%06d", prefix, seq)
+}
+
+// runSequences is the persisted last sequence number per graph hash.
+type runSequences struct {
+	Graphs map[string]int `json:"graphs"`
+}
+
+func (s *Store) runSequencesPath() string {
+	return filepath.Join(s.baseDir, ".scriptweaver", workspace.RunSequencesFileName)
+}
+
+// NextGraphRunID allocates the next RunIDGraph run ID for graphHash and
+// persists its sequence number, so IDs are not reused after runs are pruned.
+// A sequence whose run directory already exists (e.g. after the sequence
+// file was deleted) is skipped. Callers must hold the workspace lock.
+func (s *Store) NextGraphRunID(graphHash string) (string, error) {
+	if s == nil {
+		return "", errors.New("nil Store")
+	}
+	if strings.TrimSpace(graphHash) == "" {
+		return "", errors.New("graph hash is required")
+	}
+	seqs := runSequences{}
+	if err := readJSONStrict(s.runSequencesPath(), &seqs); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("read run sequences: %w", err)
+	}
+	if seqs.Graphs == nil {
+		seqs.Graphs = make(map[string]int)
+	}
+	seq := seqs.Graphs[graphHash] + 1
+	for {
+		if _, err := os.Stat(s.runDir(GraphRunID(graphHash, seq))); os.IsNotExist(err) {
+			break
+		}
+		seq++
+	}
+	seqs.Graphs[graphHash] = seq
+	data, err := jsonMarshalStable(seqs)
+	if err != nil {
+		return "", err
+	}
+	if err := ensureDirDurable(filepath.Dir(s.runSequencesPath()), 0o755); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomicDurable(s.runSequencesPath(), data, 0o644); err != nil {
+		return "", err
+	}
+	return GraphRunID(graphHash, seq), nil
+}
@@ -0,0 +1,61 @@
+package state
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStore_NextGraphRunID(t *testing.T) {
+	base := t.TempDir()
+	store, err := NewStore(base)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	const graph = "3f2a9c01d4e5b6a7c8d9e0f1a2b3c4d5"
+	next := func(graph string) string {
+		t.Helper()
+		id, err := store.NextGraphRunID(graph)
+		if err != nil {
+			t.Fatalf("NextGraphRunID: %v", err)
+		}
+		return id
+	}
+
+	if got, want := next(graph), "3f2a9c01d4e5b6a7-000001"; got != want {
+		t.Fatalf("first ID = %q, want %q", got, want)
+	}
+	if got, want := next(graph), "3f2a9c01d4e5b6a7-000002"; got != want {
+		t.Fatalf("second ID = %q, want %q", got, want)
+	}
+	if got, want := next("other"), "other-000001"; got != want {
+		t.Fatalf("other graph ID = %q, want %q", got, want)
+	}
+
+	// Without the sequence file, existing run directories are not reused.
+	if err := store.SaveRun(Run{RunID: "other-000001", GraphHash: "other", StartTime: time.Now().UTC(), Mode: ExecutionModeClean, Status: RunStatusSucceeded}); err != nil {
+		t.Fatalf("SaveRun: %v", err)
+	}
+	if err := os.Remove(store.runSequencesPath()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := next("other"), "other-000002"; got != want {
+		t.Fatalf("ID after losing the sequence file = %q, want %q", got, want)
+	}
+
+	if _, err := store.NextGraphRunID(""); err == nil {
+		t.Fatalf("expected an error for an empty graph hash")
+	}
+}
+
+func TestParseRunIDStrategy(t *testing.T) {
+	for raw, want := range map[string]RunIDStrategy{"": RunIDRandom, "random": RunIDRandom, " Graph ": RunIDGraph} {
+		got, err := ParseRunIDStrategy(raw)
+		if err != nil || got != want {
+			t.Errorf("ParseRunIDStrategy(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := ParseRunIDStrategy("time"); err == nil {
+		t.Errorf("expected an error for an unknown strategy")
+	}
+}
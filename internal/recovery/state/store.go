@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -78,8 +79,9 @@ func (s *Store) checkpointsDir(runID string) string {
 }
 
 func (s *Store) checkpointPath(runID, nodeID string) string {
-	// Assumption (documented in notes): node_id is a stable identifier safe to use as a filename.
-	return filepath.Join(s.checkpointsDir(runID), nodeID+".json")
+	// Node IDs may contain path separators (e.g. namespaced "ns/task"), so they are
+	// path-escaped into a single filename. Plain identifiers are unchanged.
+	return filepath.Join(s.checkpointsDir(runID), url.PathEscape(nodeID)+".json")
 }
 
 // LoadAllCheckpoints loads all checkpoint records for a given run.
@@ -110,8 +112,8 @@ func (s *Store) LoadAllCheckpoints(runID string) (map[string]Checkpoint, error)
 		if !strings.HasSuffix(name, ".json") {
 			continue
 		}
-		nodeID := strings.TrimSuffix(name, ".json")
-		if strings.TrimSpace(nodeID) == "" {
+		nodeID, err := url.PathUnescape(strings.TrimSuffix(name, ".json"))
+		if err != nil || strings.TrimSpace(nodeID) == "" {
 			continue
 		}
 		cp, err := s.LoadCheckpoint(runID, nodeID)
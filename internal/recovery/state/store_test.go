@@ -109,3 +109,30 @@ func TestStore_SaveAndLoadFailure_NodeIDOptional(t *testing.T) {
 		t.Fatalf("loaded failure mismatch: %+v", loaded)
 	}
 }
+
+func TestStore_Checkpoint_NamespacedNodeIDRoundTrips(t *testing.T) {
+	base := t.TempDir()
+	store, err := NewStore(base)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	cp := Checkpoint{
+		NodeID:     "build/compile",
+		Timestamp:  time.Unix(10, 0).UTC(),
+		CacheKeys:  []string{"k"},
+		OutputHash: "h",
+		Valid:      true,
+	}
+	if err := store.SaveCheckpoint("run-1", cp); err != nil {
+		t.Fatalf("SaveCheckpoint: %v", err)
+	}
+	all, err := store.LoadAllCheckpoints("run-1")
+	if err != nil {
+		t.Fatalf("LoadAllCheckpoints: %v", err)
+	}
+	got, ok := all["build/compile"]
+	if !ok || got.NodeID != "build/compile" {
+		t.Fatalf("expected namespaced checkpoint to round-trip, got %+v", all)
+	}
+}
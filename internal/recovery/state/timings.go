@@ -0,0 +1,156 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TaskTiming is one task's wall-clock span within a run, in milliseconds
+// relative to the run's start.
+type TaskTiming struct {
+	NodeID         string `json:"node_id"`
+	StartMillis    int64  `json:"start_ms"`
+	EndMillis      int64  `json:"end_ms"`
+	DurationMillis int64  `json:"duration_ms"`
+}
+
+// TimingLedger is the timing ledger (timings.json) written next to run.json.
+//
+// It is explicitly non-canonical: Canonical is always false, and nothing in it
+// feeds hashes, traces, checkpoints or any other deterministic artifact. Its
+// layout is deterministic (tasks sorted by start offset, then name) so it can
+// be diffed, but its values are host timings and differ between runs.
+type TimingLedger struct {
+	RunID string `json:"run_id"`
+	// Canonical marks the file as outside the deterministic record; it must be
+	// false.
+	Canonical bool `json:"canonical"`
+	// TotalMillis is the wall time from the run's start to its last task's end.
+	TotalMillis int64        `json:"total_ms"`
+	Tasks       []TaskTiming `json:"tasks"`
+	// Slowest names the tasks with the longest durations, slowest first.
+	Slowest []string `json:"slowest"`
+}
+
+// SlowestTaskCount is how many tasks NewTimingLedger lists in Slowest.
+const SlowestTaskCount = 10
+
+// NewTimingLedger sorts tasks and fills in the summary fields.
+func NewTimingLedger(runID string, totalMillis int64, tasks []TaskTiming) TimingLedger {
+	l := TimingLedger{RunID: runID, TotalMillis: totalMillis, Tasks: append([]TaskTiming{}, tasks...)}
+	sort.Slice(l.Tasks, func(i, j int) bool {
+		if l.Tasks[i].StartMillis != l.Tasks[j].StartMillis {
+			return l.Tasks[i].StartMillis < l.Tasks[j].StartMillis
+		}
+		return l.Tasks[i].NodeID < l.Tasks[j].NodeID
+	})
+	l.Slowest = l.SlowestTasks(SlowestTaskCount)
+	return l
+}
+
+// SlowestTasks returns up to n task names by descending duration; names
+// break ties. n <= 0 returns every task.
+func (l TimingLedger) SlowestTasks(n int) []string {
+	byDuration := append([]TaskTiming(nil), l.Tasks...)
+	sort.Slice(byDuration, func(i, j int) bool {
+		if byDuration[i].DurationMillis != byDuration[j].DurationMillis {
+			return byDuration[i].DurationMillis > byDuration[j].DurationMillis
+		}
+		return byDuration[i].NodeID < byDuration[j].NodeID
+	})
+	if n > 0 && len(byDuration) > n {
+		byDuration = byDuration[:n]
+	}
+	names := make([]string, 0, len(byDuration))
+	for _, t := range byDuration {
+		names = append(names, t.NodeID)
+	}
+	return names
+}
+
+func (l TimingLedger) Validate() error {
+	var errs []error
+	if strings.TrimSpace(l.RunID) == "" {
+		errs = append(errs, errors.New("run_id is required"))
+	}
+	if l.Canonical {
+		errs = append(errs, errors.New("canonical must be false"))
+	}
+	if l.TotalMillis < 0 {
+		errs = append(errs, errors.New("total_ms must be >= 0"))
+	}
+	if l.Tasks == nil {
+		errs = append(errs, errors.New("tasks must be an array (not null)"))
+	}
+	seen := make(map[string]struct{}, len(l.Tasks))
+	for i, t := range l.Tasks {
+		if strings.TrimSpace(t.NodeID) == "" {
+			errs = append(errs, fmt.Errorf("tasks[%d].node_id is required", i))
+		}
+		if _, ok := seen[t.NodeID]; ok {
+			errs = append(errs, fmt.Errorf("tasks[%d].node_id %q is duplicated", i, t.NodeID))
+		}
+		seen[t.NodeID] = struct{}{}
+		if t.StartMillis < 0 || t.EndMillis < t.StartMillis {
+			errs = append(errs, fmt.Errorf("tasks[%d]: span [%d, %d] is invalid", i, t.StartMillis, t.EndMillis))
+		}
+		if t.DurationMillis < 0 {
+			errs = append(errs, fmt.Errorf("tasks[%d].duration_ms must be >= 0", i))
+		}
+	}
+	for i, name := range l.Slowest {
+		if _, ok := seen[name]; !ok {
+			errs = append(errs, fmt.Errorf("slowest[%d]: unknown task %q", i, name))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Store) timingsPath(runID string) string {
+	return filepath.Join(s.runDir(runID), "timings.json")
+}
+
+func (s *Store) SaveTimings(ledger TimingLedger) error {
+	if ledger.Tasks == nil {
+		ledger.Tasks = []TaskTiming{}
+	}
+	if ledger.Slowest == nil {
+		ledger.Slowest = []string{}
+	}
+	if err := ledger.Validate(); err != nil {
+		return fmt.Errorf("invalid timings: %w", err)
+	}
+	if err := ensureDirDurable(s.runDir(ledger.RunID), 0o755); err != nil {
+		return fmt.Errorf("ensure run dir: %w", err)
+	}
+	data, err := jsonMarshalStable(ledger)
+	if err != nil {
+		return fmt.Errorf("marshal timings: %w", err)
+	}
+	if err := writeFileAtomicDurable(s.timingsPath(ledger.RunID), data, 0o644); err != nil {
+		return fmt.Errorf("write timings: %w", err)
+	}
+	return nil
+}
+
+// LoadTimings reads a run's timing ledger. Runs recorded before ledgers
+// existed return an error satisfying os.IsNotExist.
+func (s *Store) LoadTimings(runID string) (TimingLedger, error) {
+	var ledger TimingLedger
+	if strings.TrimSpace(runID) == "" {
+		return TimingLedger{}, errors.New("runID is required")
+	}
+	if err := readJSONStrict(s.timingsPath(runID), &ledger); err != nil {
+		return TimingLedger{}, err
+	}
+	if err := ledger.Validate(); err != nil {
+		return TimingLedger{}, fmt.Errorf("invalid timings on disk: %w", err)
+	}
+	return ledger, nil
+}
@@ -0,0 +1,58 @@
+package state
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestStore_SaveAndLoadTimings(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if _, err := store.LoadTimings("run-1"); !os.IsNotExist(err) {
+		t.Fatalf("expected not-exist for missing ledger, got %v", err)
+	}
+
+	l := NewTimingLedger("run-1", 300, []TaskTiming{
+		{NodeID: "test", StartMillis: 100, EndMillis: 300, DurationMillis: 200},
+		{NodeID: "lint", StartMillis: 0, EndMillis: 50, DurationMillis: 50},
+		{NodeID: "build", StartMillis: 0, EndMillis: 100, DurationMillis: 100},
+	})
+	if got := []string{l.Tasks[0].NodeID, l.Tasks[1].NodeID, l.Tasks[2].NodeID}; !reflect.DeepEqual(got, []string{"build", "lint", "test"}) {
+		t.Fatalf("tasks not ordered by start, then name: %v", got)
+	}
+	if want := []string{"test", "build", "lint"}; !reflect.DeepEqual(l.Slowest, want) {
+		t.Fatalf("slowest = %v, want %v", l.Slowest, want)
+	}
+	if got := l.SlowestTasks(1); !reflect.DeepEqual(got, []string{"test"}) {
+		t.Fatalf("SlowestTasks(1) = %v", got)
+	}
+
+	if err := store.SaveTimings(l); err != nil {
+		t.Fatalf("SaveTimings: %v", err)
+	}
+	loaded, err := store.LoadTimings("run-1")
+	if err != nil {
+		t.Fatalf("LoadTimings: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, l) {
+		t.Fatalf("ledger mismatch:\n got %+v\nwant %+v", loaded, l)
+	}
+}
+
+func TestTimingLedger_Validate(t *testing.T) {
+	for name, l := range map[string]TimingLedger{
+		"canonical":    {RunID: "r", Canonical: true, Tasks: []TaskTiming{}},
+		"no run id":    {Tasks: []TaskTiming{}},
+		"null tasks":   {RunID: "r"},
+		"bad span":     {RunID: "r", Tasks: []TaskTiming{{NodeID: "a", StartMillis: 5, EndMillis: 1}}},
+		"duplicate":    {RunID: "r", Tasks: []TaskTiming{{NodeID: "a"}, {NodeID: "a"}}},
+		"unknown slow": {RunID: "r", Tasks: []TaskTiming{}, Slowest: []string{"a"}},
+	} {
+		if err := l.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
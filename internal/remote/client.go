@@ -0,0 +1,80 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client sends ExecuteRequests to one worker.
+type Client struct {
+	// Endpoint is the worker's base URL, e.g. "http://build-7:8420".
+	Endpoint string
+
+	// HTTP is the client used for requests; nil means http.DefaultClient.
+	HTTP *http.Client
+
+	// Token, when set, is sent as a bearer token (see Worker.Token).
+	Token string
+}
+
+// NewClient returns a Client for the worker at endpoint.
+func NewClient(endpoint string) (*Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing worker endpoint: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("worker endpoint must be an http(s) URL, got %q", endpoint)
+	}
+	return &Client{Endpoint: strings.TrimSuffix(endpoint, "/")}, nil
+}
+
+// Execute runs req on the worker. A task that ran and failed is a response
+// with a non-zero ExitCode, not an error.
+func (c *Client) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encoding request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint+ExecutePath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	httpResp, err := hc.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("contacting worker: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		var e errorResponse
+		b, _ := io.ReadAll(httpResp.Body)
+		if json.Unmarshal(b, &e) == nil && e.Error != "" {
+			return nil, fmt.Errorf("worker: %s", e.Error)
+		}
+		return nil, fmt.Errorf("worker: unexpected status %s", httpResp.Status)
+	}
+	var resp ExecuteResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding worker response: %w", err)
+	}
+	if resp.Version != ProtocolVersion {
+		return nil, fmt.Errorf("worker replied with protocol version %d, want %d", resp.Version, ProtocolVersion)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,102 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Executor runs one ExecuteRequest somewhere. Client and Pool implement it.
+type Executor interface {
+	Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error)
+}
+
+// Pool spreads requests across a fleet of workers.
+//
+// Workers pull work rather than being assigned it: each request goes to the
+// first idle worker, so a fast worker takes on more tasks than a slow one
+// and no worker sits idle while tasks are ready. When a worker cannot be
+// reached or rejects a request, the request moves to the next idle worker
+// it has not tried; it fails only after every worker has failed it.
+//
+// Which worker ran a task never affects the result: the coordinator owns the
+// hash, cache and trace, and workers execute in fresh scratch directories.
+//
+// Inputs and artifacts travel inline in each request and response; there is
+// no remote cache for workers to upload to or download from. An input read
+// by several tasks is therefore sent once per task, and every artifact
+// passes through the coordinator, which alone writes the cache.
+type Pool struct {
+	clients []*Client
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	busy []bool
+}
+
+// NewPool returns a Pool over clients, in order of preference when several
+// are idle.
+func NewPool(clients []*Client) (*Pool, error) {
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("pool needs at least one worker")
+	}
+	for _, c := range clients {
+		if c == nil {
+			return nil, fmt.Errorf("nil worker client")
+		}
+	}
+	p := &Pool{clients: clients, busy: make([]bool, len(clients))}
+	p.cond = sync.NewCond(&p.mu)
+	return p, nil
+}
+
+// Size is the number of workers, and so the useful execution concurrency.
+func (p *Pool) Size() int { return len(p.clients) }
+
+func (p *Pool) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	tried := make([]bool, len(p.clients))
+	var failures []string
+	for len(failures) < len(p.clients) {
+		i, err := p.acquire(ctx, tried)
+		if err != nil {
+			return nil, err
+		}
+		tried[i] = true
+		resp, err := p.clients[i].Execute(ctx, req)
+		p.release(i)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", p.clients[i].Endpoint, err))
+	}
+	return nil, fmt.Errorf("all %d workers failed: %s", len(p.clients), strings.Join(failures, "; "))
+}
+
+// acquire waits for the first idle worker not in tried and marks it busy.
+func (p *Pool) acquire(ctx context.Context, tried []bool) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		for i := range p.clients {
+			if !p.busy[i] && !tried[i] {
+				p.busy[i] = true
+				return i, nil
+			}
+		}
+		p.cond.Wait()
+	}
+}
+
+func (p *Pool) release(i int) {
+	p.mu.Lock()
+	p.busy[i] = false
+	p.mu.Unlock()
+	p.cond.Broadcast()
+}
@@ -0,0 +1,75 @@
+package remote
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+func TestPool_FailsOverAndSpreadsWork(t *testing.T) {
+	broken := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		writeJSON(rw, http.StatusServiceUnavailable, errorResponse{Error: "draining"})
+	}))
+	defer broken.Close()
+
+	var served [2]int32
+	newWorker := func(i int) *Client {
+		w := &Worker{ScratchRoot: t.TempDir()}
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&served[i], 1)
+			w.ServeHTTP(rw, req)
+		}))
+		t.Cleanup(srv.Close)
+		c, err := NewClient(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	bad, err := NewClient(broken.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, err := NewPool([]*Client{bad, newWorker(0), newWorker(1)})
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+
+	const n = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := pool.Execute(context.Background(), &ExecuteRequest{Version: ProtocolVersion, Task: core.Task{Name: "t", Run: "sleep 0.05; echo ok"}})
+			if err == nil && string(resp.Stdout) != "ok\n" {
+				t.Errorf("unexpected stdout %q", resp.Stdout)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+	if served[0]+served[1] != n || served[0] == 0 || served[1] == 0 {
+		t.Fatalf("expected work spread across healthy workers, got %v", served)
+	}
+
+	only, err := NewPool([]*Client{bad})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := only.Execute(context.Background(), &ExecuteRequest{Version: ProtocolVersion, Task: core.Task{Name: "t", Run: "true"}}); err == nil {
+		t.Fatalf("expected failure when every worker fails")
+	}
+}
@@ -0,0 +1,83 @@
+// Package remote executes tasks on worker machines over HTTP.
+//
+// The coordinator keeps everything that defines a run's identity: input
+// resolution, hashing, the cache and trace generation. A worker only
+// receives a task definition plus its resolved input files, runs the task in
+// a fresh scratch directory, and returns the process result and harvested
+// artifacts. The exchange is a single JSON request/response per task
+// (ExecuteRequest / ExecuteResponse) on ExecutePath.
+package remote
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"scriptweaver/internal/core"
+)
+
+// ProtocolVersion is the wire protocol version. Workers reject requests with
+// a different version rather than guess at their meaning.
+const ProtocolVersion = 1
+
+// ExecutePath is the worker endpoint that accepts an ExecuteRequest.
+const ExecutePath = "/v1/execute"
+
+// File is a file on the wire: a resolved input or a harvested artifact.
+// Paths are slash-separated and relative to the task's working directory.
+type File struct {
+	Path       string      `json:"path"`
+	Content    []byte      `json:"content"`
+	Mode       os.FileMode `json:"mode,omitempty"`
+	LinkTarget string      `json:"link_target,omitempty"`
+}
+
+// ExecuteRequest asks a worker to run one task.
+type ExecuteRequest struct {
+	Version int `json:"version"`
+
+	// Hash is the coordinator's TaskHash, passed through to the executor and
+	// echoed in the response. Workers do not recompute it.
+	Hash core.TaskHash `json:"hash"`
+
+	Task core.Task `json:"task"`
+
+	// Inputs are the task's resolved inputs, materialized by the worker.
+	Inputs []File `json:"inputs"`
+
+	// OutputLimit is the coordinator's core.Executor.OutputLimit, applied to
+	// tasks without their own MaxOutputBytes.
+	OutputLimit int64 `json:"output_limit,omitempty"`
+}
+
+// ExecuteResponse is the outcome of an ExecuteRequest. Artifacts are only
+// returned when the exit code counts as success for the task (see
+// core.Task.Succeeded), mirroring local execution. The coordinator rejects
+// artifacts that no declared output covers.
+type ExecuteResponse struct {
+	Version   int           `json:"version"`
+	Hash      core.TaskHash `json:"hash"`
+	Stdout    []byte        `json:"stdout"`
+	Stderr    []byte        `json:"stderr"`
+	ExitCode  int           `json:"exit_code"`
+	Artifacts []File        `json:"artifacts"`
+
+	// OutputTruncated reports that stdout or stderr hit the output limit.
+	OutputTruncated bool `json:"output_truncated,omitempty"`
+}
+
+// errorResponse is the body of a non-200 worker response.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// validateRelPath rejects paths a worker must not write or read: absolute
+// paths and paths escaping the scratch directory.
+func validateRelPath(kind, p string) error {
+	clean := path.Clean(p)
+	if p == "" || path.IsAbs(p) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("%s path %q must be relative to the working directory", kind, p)
+	}
+	return nil
+}
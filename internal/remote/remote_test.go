@@ -0,0 +1,197 @@
+package remote
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"scriptweaver/internal/core"
+)
+
+func newTestWorker(t *testing.T) (*Client, *int32) {
+	t.Helper()
+	var calls int32
+	w := &Worker{ScratchRoot: t.TempDir()}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.ServeHTTP(rw, req)
+	}))
+	t.Cleanup(srv.Close)
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client, &calls
+}
+
+func TestRunner_ExecutesMissesOnWorker(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workDir, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "src", "in.txt"), []byte("payload"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "gen.sh"), []byte("#!/bin/sh\nmkdir -p out && cat src/in.txt > out/result.txt && pwd > out/where.txt\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	client, calls := newTestWorker(t)
+	runner, err := NewRunner(core.NewRunner(workDir, core.NewMemoryCache()), client)
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	task := core.Task{Name: "gen", Inputs: []string{"src/in.txt", "gen.sh"}, Run: "./gen.sh", Outputs: []string{"out"}}
+
+	res, err := runner.Run(context.Background(), task)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.ExitCode != 0 || res.FromCache {
+		t.Fatalf("expected fresh success, got exit %d fromCache=%v: %s", res.ExitCode, res.FromCache, res.Stderr)
+	}
+	if b, err := os.ReadFile(filepath.Join(workDir, "out", "result.txt")); err != nil || string(b) != "payload" {
+		t.Fatalf("expected artifact restored locally, got %q (err=%v)", b, err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(workDir, "out", "where.txt")); strings.HasPrefix(string(b), workDir) {
+		t.Fatalf("task ran in the coordinator's workdir, not on the worker")
+	}
+	localHash, _, err := runner.Local.Runner.TaskHash(&task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Hash != localHash {
+		t.Fatalf("hash must be computed by the coordinator")
+	}
+
+	// The result is cached on the coordinator; a second run never reaches the worker.
+	res, err = runner.Run(context.Background(), task)
+	if err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+	if !res.FromCache || atomic.LoadInt32(calls) != 1 {
+		t.Fatalf("expected cache hit without worker call, fromCache=%v calls=%d", res.FromCache, atomic.LoadInt32(calls))
+	}
+}
+
+func TestRunner_FailedTaskHasNoArtifacts(t *testing.T) {
+	workDir := t.TempDir()
+	client, _ := newTestWorker(t)
+	runner, err := NewRunner(core.NewRunner(workDir, core.NewMemoryCache()), client)
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	res, err := runner.Run(context.Background(), core.Task{Name: "bad", Run: "echo partial > out.txt; echo oops >&2; exit 3", Outputs: []string{"out.txt"}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.ExitCode != 3 || string(res.Stderr) != "oops\n" {
+		t.Fatalf("unexpected result: exit %d stderr %q", res.ExitCode, res.Stderr)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "out.txt")); !os.IsNotExist(err) {
+		t.Fatalf("failed remote task must not produce local artifacts")
+	}
+}
+
+// hostileWorker answers every request with success and its artifacts.
+type hostileWorker struct{ artifacts []File }
+
+func (w hostileWorker) Execute(_ context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	return &ExecuteResponse{Version: ProtocolVersion, Hash: req.Hash, Artifacts: w.artifacts}, nil
+}
+
+func TestRunner_RejectsUndeclaredArtifacts(t *testing.T) {
+	cases := map[string]File{
+		"undeclared path":   {Path: ".scriptweaver/state.json", Content: []byte("{}")},
+		"absolute link":     {Path: "out/link", LinkTarget: "/etc/passwd"},
+		"escaping link":     {Path: "out/link", LinkTarget: "../../escape"},
+		"traversal":         {Path: "out/../../escape", Content: []byte("x")},
+		"sibling of output": {Path: "outside.txt", Content: []byte("x")},
+	}
+	for name, artifact := range cases {
+		t.Run(name, func(t *testing.T) {
+			workDir := t.TempDir()
+			runner, err := NewRunner(core.NewRunner(workDir, core.NewMemoryCache()), hostileWorker{artifacts: []File{artifact}})
+			if err != nil {
+				t.Fatalf("NewRunner: %v", err)
+			}
+			if _, err := runner.Run(context.Background(), core.Task{Name: "gen", Run: "true", Outputs: []string{"out"}}); err == nil {
+				t.Fatalf("expected artifact %+v to be rejected", artifact)
+			}
+			if entries, _ := os.ReadDir(workDir); len(entries) != 0 {
+				t.Fatalf("rejected artifact left files behind: %v", entries)
+			}
+		})
+	}
+}
+
+func TestWorker_RejectsInvalidRequests(t *testing.T) {
+	w := &Worker{ScratchRoot: t.TempDir()}
+	ctx := context.Background()
+	cases := map[string]*ExecuteRequest{
+		"version":   {Version: ProtocolVersion + 1, Task: core.Task{Name: "t", Run: "true"}},
+		"traversal": {Version: ProtocolVersion, Task: core.Task{Name: "t", Run: "true"}, Inputs: []File{{Path: "../escape", Content: []byte("x")}}},
+		"absolute":  {Version: ProtocolVersion, Task: core.Task{Name: "t", Run: "true", Outputs: []string{"/etc/out"}}},
+	}
+	for name, req := range cases {
+		if _, err := w.Execute(ctx, req); err == nil {
+			t.Fatalf("%s: expected rejection", name)
+		}
+	}
+
+	client, _ := newTestWorker(t)
+	if _, err := client.Execute(ctx, cases["version"]); err == nil || !strings.Contains(err.Error(), "protocol version") {
+		t.Fatalf("expected protocol version error over HTTP, got %v", err)
+	}
+	if _, err := NewClient("ftp://host"); err == nil {
+		t.Fatalf("expected non-http endpoint to be rejected")
+	}
+}
+
+func TestWorker_RequiresToken(t *testing.T) {
+	srv := httptest.NewServer(&Worker{ScratchRoot: t.TempDir(), Token: "s3cret"})
+	t.Cleanup(srv.Close)
+	req := &ExecuteRequest{Version: ProtocolVersion, Task: core.Task{Name: "t", Run: "true"}}
+	ctx := context.Background()
+
+	for _, token := range []string{"", "wrong"} {
+		client, err := NewClient(srv.URL)
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		client.Token = token
+		if _, err := client.Execute(ctx, req); err == nil || !strings.Contains(err.Error(), "token") {
+			t.Fatalf("token %q: expected the request to be refused, got %v", token, err)
+		}
+	}
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	client.Token = "s3cret"
+	if resp, err := client.Execute(ctx, req); err != nil || resp.ExitCode != 0 {
+		t.Fatalf("expected the request to run, got %+v (err=%v)", resp, err)
+	}
+}
+
+func TestWorker_RejectsOversizedRequests(t *testing.T) {
+	srv := httptest.NewServer(&Worker{ScratchRoot: t.TempDir(), MaxRequestBytes: 1024})
+	t.Cleanup(srv.Close)
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	req := &ExecuteRequest{
+		Version: ProtocolVersion,
+		Task:    core.Task{Name: "t", Inputs: []string{"big"}, Run: "true"},
+		Inputs:  []File{{Path: "big", Content: []byte(strings.Repeat("x", 4096))}},
+	}
+	if _, err := client.Execute(context.Background(), req); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Fatalf("expected oversized request to be rejected, got %v", err)
+	}
+}
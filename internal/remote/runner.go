@@ -0,0 +1,192 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+)
+
+// Runner is a dag.TaskRunner that executes cache misses on a worker.
+//
+// Hashing, the cache, replay and tracing stay on the coordinator: hits,
+// planned restores and stale or corrupt entries are handled by the local
+// CacheAwareRunner exactly as without a worker. Only a clean miss is sent to
+// the worker; its result is cached and its artifacts restored into the
+// local working directory, so downstream tasks see them as usual.
+type Runner struct {
+	Local  *dag.CacheAwareRunner
+	Client Executor
+}
+
+// NewRunner wraps local so that its cache misses execute through client, a
+// single worker or a Pool.
+func NewRunner(local *core.Runner, client Executor) (*Runner, error) {
+	if client == nil {
+		return nil, fmt.Errorf("nil remote client")
+	}
+	cr, err := dag.NewCacheAwareRunner(local)
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{Local: cr, Client: client}, nil
+}
+
+func (r *Runner) Probe(ctx context.Context, task core.Task) (*dag.NodeResult, bool, error) {
+	return r.Local.Probe(ctx, task)
+}
+
+func (r *Runner) Restore(ctx context.Context, task core.Task) (*dag.NodeResult, error) {
+	return r.Local.Restore(ctx, task)
+}
+
+func (r *Runner) TaskHash(task core.Task) (core.TaskHash, error) {
+	return r.Local.TaskHash(task)
+}
+
+func (r *Runner) Run(ctx context.Context, task core.Task) (*dag.NodeResult, error) {
+	local := r.Local.Runner
+	if task.Run == "" {
+		return nil, fmt.Errorf("task run command is required")
+	}
+	hash, inputSet, err := local.TaskHash(&task)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := local.Cache.Has(hash)
+	if err != nil {
+		return nil, fmt.Errorf("checking cache: %w", err)
+	}
+	if exists && !local.Rerun[task.Name] && task.IsCacheable() {
+		return r.Local.Run(ctx, task)
+	}
+
+	applied, err := local.EffectApplied(&task)
+	if err != nil {
+		return nil, fmt.Errorf("checking effect key: %w", err)
+	}
+	if applied {
+		return &dag.NodeResult{Hash: hash, EffectApplied: true, Rerun: local.Rerun[task.Name], NotCacheable: !task.IsCacheable()}, nil
+	}
+
+	if err := local.FetchInputs(ctx, inputSet); err != nil {
+		return nil, err
+	}
+	inputs, err := wireInputs(local.WorkingDir, inputSet)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.Client.Execute(ctx, &ExecuteRequest{Version: ProtocolVersion, Hash: hash, Task: task, Inputs: inputs, OutputLimit: local.Executor.OutputLimit})
+	if err != nil {
+		return nil, fmt.Errorf("remote execution of %q: %w", task.Name, err)
+	}
+	if resp.Hash != hash {
+		return nil, fmt.Errorf("remote execution of %q: worker answered for hash %s", task.Name, resp.Hash)
+	}
+
+	stdout, stderr, err := local.NormalizeStreams(&task, resp.Stdout, resp.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	entry := &core.CacheEntry{
+		Hash:            hash,
+		Stdout:          stdout,
+		Stderr:          stderr,
+		ExitCode:        resp.ExitCode,
+		Artifacts:       []core.CachedArtifact{},
+		DeclaredOutputs: core.ManifestOutputs(task.Outputs),
+	}
+	if task.Succeeded(resp.ExitCode) {
+		normalizer, err := local.ArtifactNormalizer(&task)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range resp.Artifacts {
+			if err := validateArtifact(&task, local.WorkingDir, a); err != nil {
+				return nil, fmt.Errorf("remote execution of %q: %w", task.Name, err)
+			}
+			content := a.Content
+			if content == nil {
+				content = []byte{}
+			}
+			if normalizer != nil && a.LinkTarget == "" {
+				content = normalizer.Normalize(content)
+			}
+			entry.Artifacts = append(entry.Artifacts, core.CachedArtifact{Path: a.Path, Content: content, Mode: a.Mode, LinkTarget: a.LinkTarget})
+		}
+	}
+	if task.IsCacheable() {
+		if err := local.Cache.Put(entry); err != nil {
+			return nil, fmt.Errorf("caching result: %w", err)
+		}
+	}
+	if local.CleanOutputs {
+		// A failed entry has no artifacts, so its outputs are removed.
+		if err := local.PruneOutputs(task.Outputs, entry); err != nil {
+			return nil, fmt.Errorf("pruning outputs: %w", err)
+		}
+	}
+	if task.Succeeded(resp.ExitCode) {
+		if _, err := local.Replayer.RestoreArtifacts(task.Name, entry); err != nil {
+			return nil, fmt.Errorf("restoring remote artifacts: %w", err)
+		}
+	}
+	res := &dag.NodeResult{Hash: hash, Stdout: stdout, Stderr: stderr, ExitCode: resp.ExitCode, OutputTruncated: resp.OutputTruncated, Rerun: local.Rerun[task.Name], NotCacheable: !task.IsCacheable()}
+	if err := dag.ApplyExpectedOutputs(res, &task, entry); err != nil {
+		return nil, err
+	}
+	if err := local.RecordEffect(&task, res.ExitCode); err != nil {
+		return nil, fmt.Errorf("recording effect key: %w", err)
+	}
+	return res, nil
+}
+
+// validateArtifact rejects a worker artifact the task could not have
+// produced locally: a path no declared output covers, which could overwrite
+// any file in the working directory, or a symlink leaving it.
+func validateArtifact(task *core.Task, workDir string, a File) error {
+	if err := validateRelPath("artifact", a.Path); err != nil {
+		return err
+	}
+	if !core.OutputsCover(task.Outputs, workDir, path.Clean(a.Path)) {
+		return fmt.Errorf("artifact %q is not covered by any declared output", a.Path)
+	}
+	if a.LinkTarget != "" && !core.LinkWithinWorkDir(workDir, a.Path, a.LinkTarget) {
+		return fmt.Errorf("symlink artifact %q points to %q: %w", a.Path, a.LinkTarget, core.ErrPathOutsideWorkDir)
+	}
+	return nil
+}
+
+// wireInputs converts resolved inputs to wire files relative to workDir.
+// Inputs outside workDir cannot be materialized on a worker.
+func wireInputs(workDir string, inputs *core.InputSet) ([]File, error) {
+	files := []File{}
+	if inputs == nil {
+		return files, nil
+	}
+	for _, in := range inputs.Inputs {
+		src := filepath.FromSlash(in.Path)
+		if !filepath.IsAbs(src) {
+			src = filepath.Join(workDir, src)
+		}
+		rel, err := filepath.Rel(workDir, src)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("input %q is outside the working directory and cannot be sent to a worker", in.Path)
+		}
+		mode := core.ArtifactModeRegular
+		if info, err := os.Stat(src); err == nil && info.Mode().Perm()&0o111 != 0 {
+			mode = core.ArtifactModeExecutable
+		}
+		content, err := in.ReadContent()
+		if err != nil {
+			return nil, fmt.Errorf("reading input %q: %w", in.Path, err)
+		}
+		files = append(files, File{Path: filepath.ToSlash(rel), Content: content, Mode: mode})
+	}
+	return files, nil
+}
@@ -0,0 +1,201 @@
+package remote
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"scriptweaver/internal/core"
+)
+
+// DefaultMaxRequestBytes bounds an ExecuteRequest, inputs included, when
+// Worker.MaxRequestBytes is zero.
+const DefaultMaxRequestBytes = 1 << 30
+
+// Worker executes ExecuteRequests. It is an http.Handler serving ExecutePath.
+//
+// Every request runs in its own scratch directory holding only the request's
+// inputs, which is removed afterwards, so concurrent requests never share
+// state.
+//
+// A worker runs any command it is sent, so it must only be reachable by
+// trusted clients: set Token unless the listener is loopback-only.
+type Worker struct {
+	// ScratchRoot is where scratch directories are created; empty means the
+	// system temp directory.
+	ScratchRoot string
+
+	// Container runs tasks that declare an Image; nil rejects them.
+	Container *core.ContainerConfig
+
+	// Token, when set, must be presented by every request as
+	// "Authorization: Bearer <Token>"; other requests get 401.
+	Token string
+
+	// MaxRequestBytes caps the request body; zero means
+	// DefaultMaxRequestBytes.
+	MaxRequestBytes int64
+}
+
+// NewServer returns an http.Server serving w on addr. Reading a request is
+// bounded by timeouts, but writing is not, because the response is only
+// written once the task finishes.
+func NewServer(addr string, w *Worker) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           w,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       10 * time.Minute,
+		IdleTimeout:       2 * time.Minute,
+	}
+}
+
+func (w *Worker) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if !w.authorized(req) {
+		rw.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(rw, http.StatusUnauthorized, errorResponse{Error: "missing or invalid token"})
+		return
+	}
+	if req.URL.Path != ExecutePath {
+		writeJSON(rw, http.StatusNotFound, errorResponse{Error: "unknown endpoint " + req.URL.Path})
+		return
+	}
+	if req.Method != http.MethodPost {
+		writeJSON(rw, http.StatusMethodNotAllowed, errorResponse{Error: "method must be POST"})
+		return
+	}
+	limit := w.MaxRequestBytes
+	if limit <= 0 {
+		limit = DefaultMaxRequestBytes
+	}
+	var in ExecuteRequest
+	if err := json.NewDecoder(http.MaxBytesReader(rw, req.Body, limit)).Decode(&in); err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeJSON(rw, status, errorResponse{Error: fmt.Sprintf("decoding request: %v", err)})
+		return
+	}
+	if err := validateRequest(&in); err != nil {
+		writeJSON(rw, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	out, err := w.Execute(req.Context(), &in)
+	if err != nil {
+		writeJSON(rw, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(rw, http.StatusOK, out)
+}
+
+// authorized reports whether req carries w.Token, or whether no token is set.
+func (w *Worker) authorized(req *http.Request) bool {
+	if w.Token == "" {
+		return true
+	}
+	got := []byte(req.Header.Get("Authorization"))
+	return subtle.ConstantTimeCompare(got, []byte("Bearer "+w.Token)) == 1
+}
+
+// Execute runs req in a fresh scratch directory.
+func (w *Worker) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+	scratch, err := os.MkdirTemp(w.ScratchRoot, "scriptweaver-remote-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	for _, f := range req.Inputs {
+		if err := writeInput(scratch, f); err != nil {
+			return nil, err
+		}
+	}
+
+	executor := core.NewExecutor(scratch)
+	executor.Container = w.Container
+	executor.OutputLimit = req.OutputLimit
+	res, err := executor.Execute(ctx, &req.Task, req.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("executing task: %w", err)
+	}
+
+	out := &ExecuteResponse{
+		Version:         ProtocolVersion,
+		Hash:            req.Hash,
+		Stdout:          res.Stdout,
+		Stderr:          res.Stderr,
+		ExitCode:        res.ExitCode,
+		Artifacts:       []File{},
+		OutputTruncated: res.OutputTruncated,
+	}
+	if !req.Task.Succeeded(res.ExitCode) || len(req.Task.Outputs) == 0 {
+		return out, nil
+	}
+
+	// Artifacts are sent inline, so nothing is left streamed on disk.
+	h := core.NewHarvester(scratch)
+	h.StreamThreshold = 0
+	set, err := h.Harvest(req.Task.Outputs)
+	if err != nil {
+		return nil, fmt.Errorf("harvesting artifacts: %w", err)
+	}
+	for _, a := range set.Artifacts {
+		out.Artifacts = append(out.Artifacts, File{Path: a.Path, Content: a.Content, Mode: a.Mode, LinkTarget: a.LinkTarget})
+	}
+	return out, nil
+}
+
+func validateRequest(req *ExecuteRequest) error {
+	if req.Version != ProtocolVersion {
+		return fmt.Errorf("unsupported protocol version %d (worker speaks %d)", req.Version, ProtocolVersion)
+	}
+	if req.Task.Run == "" {
+		return fmt.Errorf("task run command is required")
+	}
+	for _, f := range req.Inputs {
+		if err := validateRelPath("input", f.Path); err != nil {
+			return err
+		}
+	}
+	for _, o := range req.Task.Outputs {
+		if err := validateRelPath("output", o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeInput(scratch string, f File) error {
+	dst := filepath.Join(scratch, filepath.FromSlash(f.Path))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("materializing input %q: %w", f.Path, err)
+	}
+	perm := f.Mode.Perm()
+	if perm == 0 {
+		perm = core.ArtifactModeRegular
+	}
+	if err := os.WriteFile(dst, f.Content, perm); err != nil {
+		return fmt.Errorf("materializing input %q: %w", f.Path, err)
+	}
+	if err := os.Chmod(dst, perm); err != nil {
+		return fmt.Errorf("materializing input %q: %w", f.Path, err)
+	}
+	return nil
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(v)
+}
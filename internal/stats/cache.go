@@ -0,0 +1,146 @@
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"scriptweaver/internal/recovery/state"
+)
+
+// CacheReport is the cache traffic of the selected runs.
+type CacheReport struct {
+	// Runs is the number of runs considered.
+	Runs int `json:"runs"`
+	// PerRun lists the runs that recorded cache metrics, newest first.
+	PerRun []RunCacheStats `json:"per_run"`
+	// PerNamespace sums PerRun by cache namespace, sorted by namespace. Runs
+	// without a namespace are summed under the empty one. RunIDs are empty.
+	PerNamespace []RunCacheStats `json:"per_namespace"`
+	// Total sums PerRun; its RunID is empty.
+	Total RunCacheStats `json:"total"`
+}
+
+// RunCacheStats is one run's cache traffic and hit rate.
+type RunCacheStats struct {
+	RunID string `json:"run_id,omitempty"`
+	state.CacheMetrics
+	// Attempts is the number of tasks that reached the cache: executed,
+	// restored or failed.
+	Attempts int64 `json:"attempts"`
+	// HitRate is Restores / Attempts.
+	HitRate float64 `json:"hit_rate"`
+}
+
+// CollectCache gathers the cache metrics of the most recent since runs,
+// selected as Collect selects them. Runs recorded without cache metrics are
+// counted in Runs but otherwise ignored. A non-empty namespace keeps only the
+// runs that used that resolved cache namespace.
+func CollectCache(st *state.Store, since int, namespace string) (CacheReport, error) {
+	runs, err := selectRuns(st, since)
+	if err != nil {
+		return CacheReport{}, err
+	}
+	rep := CacheReport{Runs: len(runs), PerRun: []RunCacheStats{}, PerNamespace: []RunCacheStats{}}
+	byNamespace := make(map[string]*RunCacheStats)
+	for _, r := range runs {
+		m, err := st.LoadMetrics(r.RunID)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return CacheReport{}, fmt.Errorf("run %s: %w", r.RunID, err)
+		}
+		if m.Cache == nil || (namespace != "" && m.Cache.Namespace != namespace) {
+			continue
+		}
+		rs := RunCacheStats{RunID: r.RunID, CacheMetrics: *m.Cache}
+		for _, t := range m.Tasks {
+			if t.Outcome != state.TaskOutcomeSkipped {
+				rs.Attempts++
+			}
+		}
+		rs.HitRate = hitRate(rs.Restores, rs.Attempts)
+		rep.PerRun = append(rep.PerRun, rs)
+
+		rep.Total.add(rs)
+		ns, ok := byNamespace[rs.Namespace]
+		if !ok {
+			ns = &RunCacheStats{CacheMetrics: state.CacheMetrics{Namespace: rs.Namespace}}
+			byNamespace[rs.Namespace] = ns
+		}
+		ns.add(rs)
+	}
+	rep.Total.HitRate = hitRate(rep.Total.Restores, rep.Total.Attempts)
+	for _, ns := range byNamespace {
+		ns.HitRate = hitRate(ns.Restores, ns.Attempts)
+		rep.PerNamespace = append(rep.PerNamespace, *ns)
+	}
+	sort.Slice(rep.PerNamespace, func(i, j int) bool { return rep.PerNamespace[i].Namespace < rep.PerNamespace[j].Namespace })
+	return rep, nil
+}
+
+// add sums the counters of rs into s; HitRate is left to the caller.
+func (s *RunCacheStats) add(rs RunCacheStats) {
+	s.Lookups += rs.Lookups
+	s.Hits += rs.Hits
+	s.Misses += rs.Misses
+	s.Gets += rs.Gets
+	s.Puts += rs.Puts
+	s.BytesRead += rs.BytesRead
+	s.BytesWritten += rs.BytesWritten
+	s.Restores += rs.Restores
+	s.Attempts += rs.Attempts
+}
+
+func hitRate(restores, attempts int64) float64 {
+	if attempts == 0 {
+		return 0
+	}
+	return round4(float64(restores) / float64(attempts))
+}
+
+var cacheCSVHeader = []string{"run_id", "namespace", "lookups", "hits", "misses", "gets", "puts", "bytes_read", "bytes_written", "restores", "attempts", "hit_rate"}
+
+// Write encodes the report in the given format.
+func (r CacheReport) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(b, '\n'))
+		return err
+	case FormatCSV:
+		return r.writeCSV(w)
+	default:
+		return fmt.Errorf("unknown stats format %q (expected json|csv)", format)
+	}
+}
+
+// writeCSV writes one row per run followed by a "total" row.
+func (r CacheReport) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(cacheCSVHeader); err != nil {
+		return err
+	}
+	total := r.Total
+	total.RunID = "total"
+	for _, rs := range append(append([]RunCacheStats(nil), r.PerRun...), total) {
+		row := []string{rs.RunID, rs.Namespace}
+		for _, n := range []int64{rs.Lookups, rs.Hits, rs.Misses, rs.Gets, rs.Puts, rs.BytesRead, rs.BytesWritten, rs.Restores, rs.Attempts} {
+			row = append(row, strconv.FormatInt(n, 10))
+		}
+		row = append(row, strconv.FormatFloat(rs.HitRate, 'f', 4, 64))
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
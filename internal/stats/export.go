@@ -0,0 +1,70 @@
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Export formats.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+var csvHeader = []string{"task", "runs", "executed", "cached", "failed", "skipped", "cache_hit_rate", "duration_samples", "p50_ms", "p90_ms", "p99_ms"}
+
+// Write encodes the report in the given format.
+func (r Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatCSV:
+		return r.WriteCSV(w)
+	default:
+		return fmt.Errorf("unknown stats format %q (expected json|csv)", format)
+	}
+}
+
+// WriteJSON writes the report as indented JSON followed by a newline.
+func (r Report) WriteJSON(w io.Writer) error {
+	if r.Tasks == nil {
+		r.Tasks = []TaskStats{}
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// WriteCSV writes one row per task under a fixed header.
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, t := range r.Tasks {
+		row := []string{
+			t.Task,
+			strconv.Itoa(t.Runs),
+			strconv.Itoa(t.Executed),
+			strconv.Itoa(t.Cached),
+			strconv.Itoa(t.Failed),
+			strconv.Itoa(t.Skipped),
+			strconv.FormatFloat(t.CacheHitRate, 'f', 4, 64),
+			strconv.Itoa(t.Duration.Samples),
+			strconv.FormatInt(t.Duration.P50, 10),
+			strconv.FormatInt(t.Duration.P90, 10),
+			strconv.FormatInt(t.Duration.P99, 10),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
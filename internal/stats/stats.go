@@ -0,0 +1,178 @@
+// Package stats aggregates per-task statistics across recorded runs.
+//
+// Input is the recovery state under .scriptweaver/runs: run.json selects and
+// orders runs, metrics.json sidecars provide per-task outcomes and durations,
+// and failure.json is used as a fallback for runs recorded without a sidecar.
+//
+// Output is canonical: tasks are sorted by name, rates are rounded to four
+// decimal places, and nothing depends on directory listing order.
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"scriptweaver/internal/recovery/state"
+)
+
+// Report is the aggregate over the selected runs.
+type Report struct {
+	// Runs is the number of runs considered.
+	Runs int `json:"runs"`
+	// RunsWithMetrics is how many of those runs had a metrics sidecar.
+	RunsWithMetrics int `json:"runs_with_metrics"`
+	// Tasks is sorted by task name.
+	Tasks []TaskStats `json:"tasks"`
+}
+
+// TaskStats is the aggregate for a single task.
+type TaskStats struct {
+	Task     string `json:"task"`
+	Runs     int    `json:"runs"`
+	Executed int    `json:"executed"`
+	Cached   int    `json:"cached"`
+	Failed   int    `json:"failed"`
+	Skipped  int    `json:"skipped"`
+
+	// CacheHitRate is Cached / (Executed + Cached + Failed); skipped runs do
+	// not count because the task never reached the cache.
+	CacheHitRate float64 `json:"cache_hit_rate"`
+
+	// Duration covers executed (non-cached, successful) runs only.
+	Duration Percentiles `json:"duration_ms"`
+}
+
+// Percentiles are nearest-rank percentiles in milliseconds.
+type Percentiles struct {
+	Samples int   `json:"samples"`
+	P50     int64 `json:"p50"`
+	P90     int64 `json:"p90"`
+	P99     int64 `json:"p99"`
+}
+
+// Collect aggregates the most recent since runs (by start time, newest first;
+// run ID breaks ties). since <= 0 selects every run.
+//
+// Runs whose run.json cannot be read are ignored. A metrics sidecar that exists
+// but is invalid is an error, since silently dropping it would skew the report.
+func Collect(st *state.Store, since int) (Report, error) {
+	runs, err := selectRuns(st, since)
+	if err != nil {
+		return Report{}, err
+	}
+
+	acc := make(map[string]*TaskStats)
+	durations := make(map[string][]int64)
+	get := func(name string) *TaskStats {
+		ts, ok := acc[name]
+		if !ok {
+			ts = &TaskStats{Task: name}
+			acc[name] = ts
+		}
+		return ts
+	}
+
+	rep := Report{Runs: len(runs)}
+	for _, r := range runs {
+		m, err := st.LoadMetrics(r.RunID)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return Report{}, fmt.Errorf("run %s: %w", r.RunID, err)
+			}
+			// No sidecar: the only per-task signal is the recorded failing node.
+			if f, ferr := st.LoadFailure(r.RunID); ferr == nil && f.NodeID != nil {
+				ts := get(*f.NodeID)
+				ts.Runs++
+				ts.Failed++
+			}
+			continue
+		}
+		rep.RunsWithMetrics++
+		for _, t := range m.Tasks {
+			ts := get(t.NodeID)
+			ts.Runs++
+			switch t.Outcome {
+			case state.TaskOutcomeExecuted:
+				ts.Executed++
+				durations[t.NodeID] = append(durations[t.NodeID], t.DurationMillis)
+			case state.TaskOutcomeCached:
+				ts.Cached++
+			case state.TaskOutcomeFailed:
+				ts.Failed++
+			case state.TaskOutcomeSkipped:
+				ts.Skipped++
+			}
+		}
+	}
+
+	rep.Tasks = make([]TaskStats, 0, len(acc))
+	for name, ts := range acc {
+		if attempts := ts.Executed + ts.Cached + ts.Failed; attempts > 0 {
+			ts.CacheHitRate = round4(float64(ts.Cached) / float64(attempts))
+		}
+		ts.Duration = percentiles(durations[name])
+		rep.Tasks = append(rep.Tasks, *ts)
+	}
+	sort.Slice(rep.Tasks, func(i, j int) bool { return rep.Tasks[i].Task < rep.Tasks[j].Task })
+	return rep, nil
+}
+
+// selectRuns returns the most recent since runs (all when since <= 0), newest
+// first with run ID breaking ties. Unreadable runs are skipped.
+func selectRuns(st *state.Store, since int) ([]state.Run, error) {
+	if st == nil {
+		return nil, errors.New("nil store")
+	}
+	ids, err := st.ListRunIDs()
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]state.Run, 0, len(ids))
+	for _, id := range ids {
+		r, err := st.LoadRun(id)
+		if err != nil {
+			continue
+		}
+		runs = append(runs, r)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].StartTime.Equal(runs[j].StartTime) {
+			return runs[i].StartTime.After(runs[j].StartTime)
+		}
+		return runs[i].RunID < runs[j].RunID
+	})
+	if since > 0 && len(runs) > since {
+		runs = runs[:since]
+	}
+	return runs, nil
+}
+
+func percentiles(samples []int64) Percentiles {
+	if len(samples) == 0 {
+		return Percentiles{}
+	}
+	s := append([]int64(nil), samples...)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	return Percentiles{
+		Samples: len(s),
+		P50:     nearestRank(s, 50),
+		P90:     nearestRank(s, 90),
+		P99:     nearestRank(s, 99),
+	}
+}
+
+// nearestRank returns the p-th percentile of sorted using the nearest-rank method.
+func nearestRank(sorted []int64, p int) int64 {
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func round4(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
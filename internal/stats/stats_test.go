@@ -0,0 +1,119 @@
+package stats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"scriptweaver/internal/recovery/state"
+)
+
+func seedRun(t *testing.T, st *state.Store, id string, start int64, tasks []state.TaskMetric) {
+	t.Helper()
+	if err := st.SaveRun(state.Run{RunID: id, GraphHash: "g", StartTime: time.Unix(start, 0).UTC(), Mode: state.ExecutionModeIncremental, Status: "completed"}); err != nil {
+		t.Fatalf("SaveRun: %v", err)
+	}
+	if tasks != nil {
+		if err := st.SaveMetrics(state.RunMetrics{RunID: id, Tasks: tasks}); err != nil {
+			t.Fatalf("SaveMetrics: %v", err)
+		}
+	}
+}
+
+func TestCollect_AggregatesAcrossRuns(t *testing.T) {
+	st, err := state.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	seedRun(t, st, "r1", 1, []state.TaskMetric{
+		{NodeID: "a", Outcome: state.TaskOutcomeExecuted, DurationMillis: 100},
+		{NodeID: "b", Outcome: state.TaskOutcomeFailed, DurationMillis: 5},
+	})
+	seedRun(t, st, "r2", 2, []state.TaskMetric{
+		{NodeID: "a", Outcome: state.TaskOutcomeCached, DurationMillis: 1},
+		{NodeID: "b", Outcome: state.TaskOutcomeExecuted, DurationMillis: 40},
+	})
+	seedRun(t, st, "r3", 3, []state.TaskMetric{
+		{NodeID: "a", Outcome: state.TaskOutcomeExecuted, DurationMillis: 300},
+		{NodeID: "b", Outcome: state.TaskOutcomeSkipped},
+	})
+
+	rep, err := Collect(st, 0)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if rep.Runs != 3 || rep.RunsWithMetrics != 3 || len(rep.Tasks) != 2 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	a := rep.Tasks[0]
+	if a.Task != "a" || a.Executed != 2 || a.Cached != 1 || a.CacheHitRate != 0.3333 {
+		t.Fatalf("unexpected stats for a: %+v", a)
+	}
+	if a.Duration != (Percentiles{Samples: 2, P50: 100, P90: 300, P99: 300}) {
+		t.Fatalf("unexpected durations for a: %+v", a.Duration)
+	}
+	b := rep.Tasks[1]
+	if b.Failed != 1 || b.Skipped != 1 || b.CacheHitRate != 0 {
+		t.Fatalf("unexpected stats for b: %+v", b)
+	}
+
+	// --since keeps only the most recent runs.
+	rep, err = Collect(st, 1)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if rep.Runs != 1 || rep.Tasks[0].Runs != 1 || rep.Tasks[0].Duration.P50 != 300 {
+		t.Fatalf("expected only r3, got %+v", rep)
+	}
+}
+
+func TestCollect_FallsBackToFailureRecord(t *testing.T) {
+	st, err := state.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	seedRun(t, st, "legacy", 1, nil)
+	node := "deploy"
+	if err := st.SaveFailure("legacy", state.Failure{FailureClass: state.FailureClassExecution, NodeID: &node, ErrorCode: "NodeFailed", ErrorMessage: "node deploy failed"}); err != nil {
+		t.Fatalf("SaveFailure: %v", err)
+	}
+
+	rep, err := Collect(st, 0)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if rep.RunsWithMetrics != 0 || len(rep.Tasks) != 1 || rep.Tasks[0].Task != "deploy" || rep.Tasks[0].Failed != 1 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+}
+
+func TestReport_WriteIsCanonical(t *testing.T) {
+	rep := Report{Runs: 1, RunsWithMetrics: 1, Tasks: []TaskStats{
+		{Task: "a", Runs: 1, Executed: 1, Duration: Percentiles{Samples: 1, P50: 7, P90: 7, P99: 7}},
+	}}
+
+	var csvOut bytes.Buffer
+	if err := rep.Write(&csvOut, FormatCSV); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	want := "task,runs,executed,cached,failed,skipped,cache_hit_rate,duration_samples,p50_ms,p90_ms,p99_ms\na,1,1,0,0,0,0.0000,1,7,7,7\n"
+	if csvOut.String() != want {
+		t.Fatalf("csv mismatch:\n%s", csvOut.String())
+	}
+
+	var j1, j2 bytes.Buffer
+	if err := rep.Write(&j1, FormatJSON); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	if err := rep.Write(&j2, FormatJSON); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	if j1.String() != j2.String() || !strings.Contains(j1.String(), `"cache_hit_rate": 0`) {
+		t.Fatalf("unexpected json:\n%s", j1.String())
+	}
+
+	if err := rep.Write(&j1, "xml"); err == nil {
+		t.Fatalf("expected unknown format error")
+	}
+}
@@ -0,0 +1,135 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseExecutionTrace decodes a trace in its JSON encoding (as written by
+// CanonicalJSON) and returns it canonicalized.
+func ParseExecutionTrace(b []byte) (ExecutionTrace, error) {
+	var raw struct {
+		GraphHash string `json:"graphHash"`
+		Events    []struct {
+			Kind        string   `json:"kind"`
+			TaskID      string   `json:"taskId"`
+			Group       string   `json:"group"`
+			Reason      string   `json:"reason"`
+			CauseTaskID string   `json:"causeTaskId"`
+			ExitCode    int      `json:"exitCode"`
+			Artifacts   []string `json:"artifacts"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return ExecutionTrace{}, fmt.Errorf("decode trace: %w", err)
+	}
+	t := ExecutionTrace{GraphHash: raw.GraphHash}
+	for _, e := range raw.Events {
+		t.Events = append(t.Events, TraceEvent{Kind: TraceEventKind(e.Kind), TaskID: e.TaskID, Group: e.Group, Reason: e.Reason, CauseTaskID: e.CauseTaskID, ExitCode: e.ExitCode, Artifacts: e.Artifacts})
+	}
+	t.Canonicalize()
+	if err := t.Validate(); err != nil {
+		return ExecutionTrace{}, fmt.Errorf("invalid trace: %w", err)
+	}
+	return t, nil
+}
+
+// TraceDiff lists how an actual trace diverges from an expected one.
+type TraceDiff struct {
+	// GraphHashChanged is set when the traces describe different graphs.
+	GraphHashChanged bool
+
+	// Missing are expected events the actual trace lacks; Unexpected are
+	// actual events the expected trace lacks. Both are in canonical order.
+	Missing    []TraceEvent
+	Unexpected []TraceEvent
+}
+
+// Empty reports whether the traces are equivalent.
+func (d TraceDiff) Empty() bool {
+	return !d.GraphHashChanged && len(d.Missing) == 0 && len(d.Unexpected) == 0
+}
+
+// Diff compares two traces event by event, treating events as a multiset:
+// a repeated event must appear as many times in both.
+func Diff(expected, actual ExecutionTrace) (TraceDiff, error) {
+	exp, err := canonicalEvents(expected)
+	if err != nil {
+		return TraceDiff{}, fmt.Errorf("expected trace: %w", err)
+	}
+	act, err := canonicalEvents(actual)
+	if err != nil {
+		return TraceDiff{}, fmt.Errorf("actual trace: %w", err)
+	}
+	return TraceDiff{
+		GraphHashChanged: expected.GraphHash != actual.GraphHash,
+		Missing:          exp.without(act),
+		Unexpected:       act.without(exp),
+	}, nil
+}
+
+type keyedEvents struct {
+	events []TraceEvent
+	keys   []string
+}
+
+// without returns the events of k left after removing one occurrence per
+// event of other.
+func (k keyedEvents) without(other keyedEvents) []TraceEvent {
+	count := make(map[string]int, len(other.keys))
+	for _, key := range other.keys {
+		count[key]++
+	}
+	var out []TraceEvent
+	for i, key := range k.keys {
+		if count[key] > 0 {
+			count[key]--
+			continue
+		}
+		out = append(out, k.events[i])
+	}
+	return out
+}
+
+func canonicalEvents(t ExecutionTrace) (keyedEvents, error) {
+	c := ExecutionTrace{GraphHash: t.GraphHash, Events: append([]TraceEvent(nil), t.Events...)}
+	c.Canonicalize()
+	out := keyedEvents{events: c.Events}
+	for _, e := range c.Events {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return keyedEvents{}, err
+		}
+		out.keys = append(out.keys, string(b))
+	}
+	return out, nil
+}
+
+// String renders the event for diagnostics, e.g.
+// "TaskSkipped B (UpstreamFailed, cause A)".
+func (e TraceEvent) String() string {
+	var b strings.Builder
+	b.WriteString(string(e.Kind))
+	if e.TaskID != "" {
+		b.WriteString(" " + e.TaskID)
+	}
+	var details []string
+	if e.Reason != "" {
+		details = append(details, e.Reason)
+	}
+	if e.CauseTaskID != "" {
+		details = append(details, "cause "+e.CauseTaskID)
+	}
+	if e.ExitCode != 0 {
+		details = append(details, "exit "+strconv.Itoa(e.ExitCode))
+	}
+	if len(e.Artifacts) > 0 {
+		details = append(details, "artifacts "+strings.Join(e.Artifacts, ","))
+	}
+	if len(details) > 0 {
+		b.WriteString(" (" + strings.Join(details, ", ") + ")")
+	}
+	return b.String()
+}
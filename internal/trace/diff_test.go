@@ -0,0 +1,64 @@
+package trace
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseExecutionTrace_RoundTripsCanonicalJSON(t *testing.T) {
+	tr := ExecutionTrace{
+		GraphHash: "graph-abc",
+		Events: []TraceEvent{
+			{Kind: EventTaskSkipped, TaskID: "c", Reason: ReasonUpstreamFailed, CauseTaskID: "b"},
+			{Kind: EventTaskArtifactsRestored, TaskID: "a", Reason: ReasonCacheReplay, Artifacts: []string{"z", "y"}},
+		},
+	}
+	b, err := tr.CanonicalJSON()
+	if err != nil {
+		t.Fatalf("canonical json: %v", err)
+	}
+	parsed, err := ParseExecutionTrace(b)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	b2, err := parsed.CanonicalJSON()
+	if err != nil {
+		t.Fatalf("canonical json (parsed): %v", err)
+	}
+	if !bytes.Equal(b, b2) {
+		t.Fatalf("round trip changed bytes\n1=%s\n2=%s", b, b2)
+	}
+
+	if _, err := ParseExecutionTrace([]byte(`{"events":[]}`)); err == nil {
+		t.Fatalf("expected error for a trace without graphHash")
+	}
+}
+
+func TestDiff_ReportsMissingAndUnexpectedEvents(t *testing.T) {
+	expected := ExecutionTrace{GraphHash: "g", Events: []TraceEvent{
+		{Kind: EventTaskExecuted, TaskID: "a", Reason: ReasonFreshWork},
+		{Kind: EventTaskExecuted, TaskID: "b", Reason: ReasonFreshWork},
+	}}
+	actual := ExecutionTrace{GraphHash: "g", Events: []TraceEvent{
+		{Kind: EventTaskExecuted, TaskID: "b", Reason: ReasonFreshWork},
+		{Kind: EventTaskCached, TaskID: "a", Reason: ReasonCacheHit},
+	}}
+
+	d, err := Diff(expected, actual)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if d.Empty() || d.GraphHashChanged {
+		t.Fatalf("unexpected diff: %+v", d)
+	}
+	if len(d.Missing) != 1 || d.Missing[0].String() != "TaskExecuted a (FreshWork)" {
+		t.Fatalf("missing = %v", d.Missing)
+	}
+	if len(d.Unexpected) != 1 || d.Unexpected[0].String() != "TaskCached a (CacheHit)" {
+		t.Fatalf("unexpected = %v", d.Unexpected)
+	}
+
+	if d, err := Diff(expected, expected); err != nil || !d.Empty() {
+		t.Fatalf("expected an empty diff for identical traces, got %+v (err=%v)", d, err)
+	}
+}
@@ -0,0 +1,274 @@
+package trace
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Built-in reason codes emitted by the execution engine.
+//
+// The string values are part of the trace's canonical bytes; do not rename.
+const (
+	ReasonFreshWork         = "FreshWork"
+	ReasonPlannedExecute    = "PlannedExecute"
+	ReasonPlannedReuseCache = "PlannedReuseCache"
+	ReasonCacheHit          = "CacheHit"
+	ReasonCacheReplay       = "CacheReplay"
+	ReasonCacheRestore      = "CacheRestore"
+	ReasonUpstreamFailed    = "UpstreamFailed"
+
+	// ReasonVerifiedInPlace marks a TaskArtifactsRestored event for a cache
+	// hit whose artifacts were all already in the workspace with the cached
+	// content, so nothing was rewritten.
+	ReasonVerifiedInPlace = "VerifiedInPlace"
+
+	// ReasonDeclaredOutputsChanged marks a TaskInvalidated event for a cache
+	// entry whose artifact set no longer matches the task's declared outputs.
+	ReasonDeclaredOutputsChanged = "DeclaredOutputsChanged"
+
+	// ReasonCacheCorrupt marks a TaskInvalidated event for a corrupt cache
+	// entry that was re-executed and rewritten instead of aborting the run.
+	ReasonCacheCorrupt = "CacheCorrupt"
+
+	// ReasonUserInvalidated marks a TaskInvalidated event for a task that
+	// executed without consulting the cache because the invocation asked for
+	// it (--invalidate, or downstream of --resume-from).
+	ReasonUserInvalidated = "UserInvalidated"
+
+	// ReasonNotCacheable marks a TaskExecuted event for a task that opted out
+	// of the cache, so it executed without a lookup and stored nothing.
+	ReasonNotCacheable = "NotCacheable"
+
+	// ReasonEffectApplied marks a TaskExecuted event for a task that did not
+	// run because its effect key was recorded by an earlier success.
+	ReasonEffectApplied = "EffectApplied"
+
+	// ReasonUndeclaredOutputs marks a TaskFailed event for a task that wrote
+	// outside its declared outputs in strict mode.
+	ReasonUndeclaredOutputs = "UndeclaredOutputs"
+
+	// ReasonInputsModified marks a TaskFailed event for a task that modified
+	// or removed its own declared inputs under input protection.
+	ReasonInputsModified = "InputsModified"
+
+	// ReasonExpectedOutputMismatch marks a TaskFailed event for a task whose
+	// artifacts did not match its expected output digests.
+	ReasonExpectedOutputMismatch = "ExpectedOutputMismatch"
+
+	// ReasonOutputTruncated marks a TaskExecuted (or TaskFailed) event for a
+	// task whose stdout or stderr was cut at the output size limit.
+	ReasonOutputTruncated = "OutputTruncated"
+
+	// ReasonConditionFalse marks a TaskSkipped event for a task whose When
+	// condition was false, or (with CauseTaskID) one downstream of it.
+	ReasonConditionFalse = "ConditionFalse"
+
+	// ReasonAllowedFailure marks a TaskFailed event for a task that allows
+	// failure, so its dependents ran and the run's exit code is unaffected.
+	ReasonAllowedFailure = "AllowedFailure"
+
+	// ReasonServiceReady marks the TaskExecuted event of a service task whose
+	// readiness command succeeded; its teardown is a ServiceStopped event.
+	ReasonServiceReady = "ServiceReady"
+
+	// ReasonTaskDeduplicated marks the events of a task that did not run
+	// because a task with identical hash inputs (its CauseTaskID) already
+	// produced the result it shares.
+	ReasonTaskDeduplicated = "TaskDeduplicated"
+
+	// Invalidation reasons mirror incremental.InvalidationReasonType values.
+	ReasonInputChanged          = "InputChanged"
+	ReasonEnvChanged            = "EnvChanged"
+	ReasonDependencyInvalidated = "DependencyInvalidated"
+	ReasonGraphStructureChanged = "GraphStructureChanged"
+	ReasonCommandChanged        = "CommandChanged"
+	ReasonOutputChanged         = "OutputChanged"
+)
+
+func builtinReasons() []string {
+	return []string{
+		ReasonFreshWork,
+		ReasonPlannedExecute,
+		ReasonPlannedReuseCache,
+		ReasonCacheHit,
+		ReasonCacheReplay,
+		ReasonCacheRestore,
+		ReasonUpstreamFailed,
+		ReasonVerifiedInPlace,
+		ReasonDeclaredOutputsChanged,
+		ReasonCacheCorrupt,
+		ReasonUserInvalidated,
+		ReasonNotCacheable,
+		ReasonEffectApplied,
+		ReasonUndeclaredOutputs,
+		ReasonInputsModified,
+		ReasonExpectedOutputMismatch,
+		ReasonOutputTruncated,
+		ReasonConditionFalse,
+		ReasonAllowedFailure,
+		ReasonServiceReady,
+		ReasonTaskDeduplicated,
+		ReasonInputChanged,
+		ReasonEnvChanged,
+		ReasonDependencyInvalidated,
+		ReasonGraphStructureChanged,
+		ReasonCommandChanged,
+		ReasonOutputChanged,
+	}
+}
+
+// ReasonRegistry is the set of declared reason codes plus optional aliases.
+//
+// Embedders that add runners register their own reason codes so they are
+// declared once and stay stable. Aliases let a legacy or runner-specific
+// spelling be canonicalized to a registered code before the trace is sorted
+// and serialized.
+//
+// In strict mode, Validate rejects any reason that is not registered.
+// Strictness is opt-in so traces produced by older embedders keep validating.
+type ReasonRegistry struct {
+	mu      sync.RWMutex
+	codes   map[string]struct{}
+	aliases map[string]string
+	strict  bool
+}
+
+// NewReasonRegistry returns a registry seeded with the built-in reason codes.
+func NewReasonRegistry() *ReasonRegistry {
+	r := &ReasonRegistry{codes: make(map[string]struct{}), aliases: make(map[string]string)}
+	for _, c := range builtinReasons() {
+		r.codes[c] = struct{}{}
+	}
+	return r
+}
+
+// DefaultReasons is the process-wide registry consulted by Canonicalize and Validate.
+//
+// Registration is expected to happen during program initialization, before any
+// trace is produced; changing the registry mid-run changes canonical bytes.
+var DefaultReasons = NewReasonRegistry()
+
+// RegisterReason declares a reason code in DefaultReasons.
+func RegisterReason(code string) error { return DefaultReasons.Register(code) }
+
+// RegisterReasonAlias maps alias to a registered code in DefaultReasons.
+func RegisterReasonAlias(alias, code string) error { return DefaultReasons.RegisterAlias(alias, code) }
+
+// Register declares a reason code. Registering an existing code is a no-op.
+func (r *ReasonRegistry) Register(code string) error {
+	if r == nil {
+		return fmt.Errorf("nil reason registry")
+	}
+	if err := ValidateReasonSyntax(code); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.aliases[code]; ok {
+		return fmt.Errorf("reason %q is already registered as an alias", code)
+	}
+	r.codes[code] = struct{}{}
+	return nil
+}
+
+// RegisterAlias maps alias to code. The code must already be registered and
+// an alias must not shadow a registered code.
+func (r *ReasonRegistry) RegisterAlias(alias, code string) error {
+	if r == nil {
+		return fmt.Errorf("nil reason registry")
+	}
+	if err := ValidateReasonSyntax(alias); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.codes[code]; !ok {
+		return fmt.Errorf("alias %q targets unregistered reason %q", alias, code)
+	}
+	if _, ok := r.codes[alias]; ok {
+		return fmt.Errorf("alias %q shadows a registered reason", alias)
+	}
+	if prev, ok := r.aliases[alias]; ok && prev != code {
+		return fmt.Errorf("alias %q already maps to %q", alias, prev)
+	}
+	r.aliases[alias] = code
+	return nil
+}
+
+// SetStrict toggles rejection of unregistered reasons in Validate.
+func (r *ReasonRegistry) SetStrict(strict bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.strict = strict
+	r.mu.Unlock()
+}
+
+// Strict reports whether unregistered reasons are rejected.
+func (r *ReasonRegistry) Strict() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.strict
+}
+
+// Known reports whether reason is a registered code (aliases are not codes).
+func (r *ReasonRegistry) Known(reason string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.codes[reason]
+	return ok
+}
+
+// Canonical returns the registered code for reason, resolving aliases.
+// Unknown reasons are returned unchanged.
+func (r *ReasonRegistry) Canonical(reason string) string {
+	if r == nil || reason == "" {
+		return reason
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if code, ok := r.aliases[reason]; ok {
+		return code
+	}
+	return reason
+}
+
+// Codes returns all registered codes in sorted order.
+func (r *ReasonRegistry) Codes() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.codes))
+	for c := range r.codes {
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ValidateReasonSyntax checks that reason is a stable code: an ASCII letter
+// followed by ASCII letters or digits (e.g. "CacheHit").
+func ValidateReasonSyntax(reason string) error {
+	if reason == "" {
+		return fmt.Errorf("reason is empty")
+	}
+	for i, c := range reason {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return fmt.Errorf("reason %q is not a stable code (expected [A-Za-z][A-Za-z0-9]*)", reason)
+		}
+	}
+	return nil
+}
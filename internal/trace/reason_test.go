@@ -0,0 +1,101 @@
+package trace
+
+import (
+	"strings"
+	"testing"
+)
+
+func withDefaultReasons(t *testing.T, r *ReasonRegistry) {
+	t.Helper()
+	prev := DefaultReasons
+	DefaultReasons = r
+	t.Cleanup(func() { DefaultReasons = prev })
+}
+
+func TestReasonRegistry_BuiltinsKnown(t *testing.T) {
+	r := NewReasonRegistry()
+	for _, c := range []string{ReasonFreshWork, ReasonCacheHit, ReasonUpstreamFailed, ReasonInputChanged} {
+		if !r.Known(c) {
+			t.Fatalf("expected builtin %q to be known", c)
+		}
+	}
+	if r.Known("RemoteHit") {
+		t.Fatalf("unexpected known reason")
+	}
+}
+
+func TestReasonRegistry_RegisterRejectsMalformedCodes(t *testing.T) {
+	r := NewReasonRegistry()
+	for _, bad := range []string{"", "cache hit", "1Leading", "Cache-Hit"} {
+		if err := r.Register(bad); err == nil {
+			t.Fatalf("expected error registering %q", bad)
+		}
+	}
+	if err := r.Register("RemoteHit"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := r.Register("RemoteHit"); err != nil {
+		t.Fatalf("re-register should be idempotent: %v", err)
+	}
+}
+
+func TestReasonRegistry_AliasCanonicalizesTrace(t *testing.T) {
+	r := NewReasonRegistry()
+	if err := r.Register("RemoteHit"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := r.RegisterAlias("RemoteCacheHit", "RemoteHit"); err != nil {
+		t.Fatalf("alias: %v", err)
+	}
+	if err := r.RegisterAlias("Other", "Unregistered"); err == nil {
+		t.Fatalf("expected alias to unregistered code to fail")
+	}
+	if err := r.RegisterAlias(ReasonCacheHit, "RemoteHit"); err == nil {
+		t.Fatalf("expected alias shadowing a code to fail")
+	}
+	withDefaultReasons(t, r)
+
+	a := ExecutionTrace{GraphHash: "g", Events: []TraceEvent{{Kind: EventTaskCached, TaskID: "a", Reason: "RemoteCacheHit"}}}
+	b := ExecutionTrace{GraphHash: "g", Events: []TraceEvent{{Kind: EventTaskCached, TaskID: "a", Reason: "RemoteHit"}}}
+	ab, err := a.CanonicalJSON()
+	if err != nil {
+		t.Fatalf("canonical a: %v", err)
+	}
+	bb, err := b.CanonicalJSON()
+	if err != nil {
+		t.Fatalf("canonical b: %v", err)
+	}
+	if string(ab) != string(bb) {
+		t.Fatalf("alias not canonicalized\na=%s\nb=%s", ab, bb)
+	}
+}
+
+func TestValidate_StrictModeRejectsUnknownReasons(t *testing.T) {
+	r := NewReasonRegistry()
+	withDefaultReasons(t, r)
+
+	tr := ExecutionTrace{GraphHash: "g", Events: []TraceEvent{{Kind: EventTaskExecuted, TaskID: "a", Reason: "Mystery"}}}
+	if err := tr.Validate(); err != nil {
+		t.Fatalf("non-strict validate: %v", err)
+	}
+
+	r.SetStrict(true)
+	err := tr.Validate()
+	if err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Fatalf("expected unregistered reason error, got %v", err)
+	}
+
+	if err := r.Register("Mystery"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := tr.Validate(); err != nil {
+		t.Fatalf("strict validate after register: %v", err)
+	}
+}
+
+func TestValidate_RejectsMalformedReason(t *testing.T) {
+	tr := ExecutionTrace{GraphHash: "g", Events: []TraceEvent{{Kind: EventTaskExecuted, TaskID: "a", Reason: "fresh work"}}}
+	if err := tr.Validate(); err == nil {
+		t.Fatalf("expected malformed reason to be rejected")
+	}
+}
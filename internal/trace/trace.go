@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 )
 
 // ExecutionTrace is the canonical, deterministic record of a graph execution.
@@ -49,6 +50,10 @@ const (
 	EventTaskExecuted         TraceEventKind = "TaskExecuted"
 	EventTaskFailed           TraceEventKind = "TaskFailed"
 	EventTaskSkipped          TraceEventKind = "TaskSkipped"
+
+	// EventServiceStopped records the teardown of a service task started
+	// earlier in the run (see core.KindService).
+	EventServiceStopped TraceEventKind = "ServiceStopped"
 )
 
 // TraceEvent is a single logical transition/decision.
@@ -67,13 +72,19 @@ type TraceEvent struct {
 	// TaskID identifies the task/node this event refers to. For task-level events this is required.
 	TaskID string
 
+	// Group is the task's group label, if any. It is derived from the graph, so it is as stable as TaskID.
+	Group string
+
 	// Reason is a stable, logical reason code (e.g., "InputChanged", "UpstreamFailed").
-	// The set of allowed values is intentionally open in this sprint; producers must keep them stable.
+	// Codes are declared in a ReasonRegistry (see reason.go); embedders register their own.
 	Reason string
 
 	// CauseTaskID records a related upstream task (e.g., the failing upstream task causing a skip).
 	CauseTaskID string
 
+	// ExitCode is the accepted non-zero exit code of a successful task (see core.Task.SuccessExitCodes); zero is omitted.
+	ExitCode int
+
 	// Artifacts is a list of restored artifact identifiers. The producer must ensure identifiers are stable.
 	Artifacts []string
 }
@@ -94,6 +105,14 @@ func (t *ExecutionTrace) Validate() error {
 		if isTaskEvent(e.Kind) && e.TaskID == "" {
 			return fmt.Errorf("events[%d].taskId is required for kind %q", i, e.Kind)
 		}
+		if e.Reason != "" {
+			if err := ValidateReasonSyntax(e.Reason); err != nil {
+				return fmt.Errorf("events[%d].reason: %w", i, err)
+			}
+			if DefaultReasons.Strict() && !DefaultReasons.Known(DefaultReasons.Canonical(e.Reason)) {
+				return fmt.Errorf("events[%d].reason %q is not registered", i, e.Reason)
+			}
+		}
 		if len(e.Artifacts) > 0 {
 			for j, a := range e.Artifacts {
 				if a == "" {
@@ -107,7 +126,7 @@ func (t *ExecutionTrace) Validate() error {
 
 func isTaskEvent(kind TraceEventKind) bool {
 	switch kind {
-	case EventTaskInvalidated, EventTaskArtifactsRestored, EventTaskCached, EventTaskExecuted, EventTaskFailed, EventTaskSkipped:
+	case EventTaskInvalidated, EventTaskArtifactsRestored, EventTaskCached, EventTaskExecuted, EventTaskFailed, EventTaskSkipped, EventServiceStopped:
 		return true
 	default:
 		return true
@@ -120,6 +139,7 @@ func isTaskEvent(kind TraceEventKind) bool {
 // This implementation produces a total order over events, with TaskID as the primary key.
 //
 // Canonicalization rules:
+//   - Reason aliases are resolved to their registered code (DefaultReasons).
 //   - Artifacts are copied and sorted.
 //   - Empty Artifacts slices are normalized to nil.
 //   - Events are stably sorted by (taskId, kindOrder, reason, causeTaskId, artifactsLex).
@@ -128,6 +148,7 @@ func (t *ExecutionTrace) Canonicalize() {
 		return
 	}
 	for i := range t.Events {
+		t.Events[i].Reason = DefaultReasons.Canonical(t.Events[i].Reason)
 		if len(t.Events[i].Artifacts) == 0 {
 			t.Events[i].Artifacts = nil
 			continue
@@ -172,6 +193,8 @@ func kindOrder(k TraceEventKind) int {
 		return 50
 	case EventTaskSkipped:
 		return 60
+	case EventServiceStopped:
+		return 70
 	default:
 		return 1000
 	}
@@ -279,6 +302,14 @@ func (e TraceEvent) MarshalJSON() ([]byte, error) {
 		buf.Write(tb)
 	}
 
+	// group
+	if e.Group != "" {
+		buf.WriteByte(',')
+		buf.WriteString("\"group\":")
+		gb, _ := json.Marshal(e.Group)
+		buf.Write(gb)
+	}
+
 	// reason
 	if e.Reason != "" {
 		buf.WriteByte(',')
@@ -295,6 +326,13 @@ func (e TraceEvent) MarshalJSON() ([]byte, error) {
 		buf.Write(cb)
 	}
 
+	// exitCode
+	if e.ExitCode != 0 {
+		buf.WriteByte(',')
+		buf.WriteString("\"exitCode\":")
+		buf.WriteString(strconv.Itoa(e.ExitCode))
+	}
+
 	// artifacts
 	if len(artifacts) > 0 {
 		buf.WriteByte(',')
@@ -131,3 +131,25 @@ func TestEventArtifacts_CanonicalizedAndOmittedWhenEmpty(t *testing.T) {
 		t.Fatalf("unexpected canonical bytes\nexpected=%s\nactual  =%s", expected2, string(b2))
 	}
 }
+
+func TestEventGroup_SerializedAfterTaskIDAndParsed(t *testing.T) {
+	tr := ExecutionTrace{
+		GraphHash: "g",
+		Events:    []TraceEvent{{Kind: EventTaskExecuted, TaskID: "a", Group: "build", Reason: ReasonFreshWork}},
+	}
+	b, err := tr.CanonicalJSON()
+	if err != nil {
+		t.Fatalf("canonical json: %v", err)
+	}
+	expected := `{"graphHash":"g","events":[{"kind":"TaskExecuted","taskId":"a","group":"build","reason":"FreshWork"}]}`
+	if string(b) != expected {
+		t.Fatalf("unexpected canonical bytes\nexpected=%s\nactual  =%s", expected, string(b))
+	}
+	parsed, err := ParseExecutionTrace(b)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if parsed.Events[0].Group != "build" {
+		t.Fatalf("group lost on parse: %+v", parsed.Events[0])
+	}
+}
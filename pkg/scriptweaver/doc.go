@@ -0,0 +1,17 @@
+// Package scriptweaver is the public API for embedding ScriptWeaver in other
+// Go programs.
+//
+// It exposes:
+//   - Task, Edge and NewGraph to build a TaskGraph programmatically
+//   - TaskRunner and NodeObserver to plug custom execution and checkpointing
+//     into the engine, with runners selected per task Kind
+//   - NodeEventObserver to subscribe any number of listeners (progress,
+//     metrics, plugins) to task state changes
+//   - Engine to run a graph with ScriptWeaver's cache, replay and trace
+//     semantics, exactly as the CLI does
+//
+// The exported names are aliases of the engine's own types, so values built
+// here are interchangeable with what the engine produces. Only the identifiers
+// in this package are covered by compatibility guarantees; everything under
+// internal/ may change between releases.
+package scriptweaver
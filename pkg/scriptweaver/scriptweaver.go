@@ -0,0 +1,181 @@
+package scriptweaver
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"scriptweaver/internal/core"
+	"scriptweaver/internal/dag"
+	"scriptweaver/internal/trace"
+)
+
+// Graph definition.
+type (
+	// Task is a single unit of work. See the field docs for hashing rules.
+	Task = core.Task
+
+	// TaskHash is the deterministic identity of a task execution.
+	TaskHash = core.TaskHash
+
+	// Edge declares that To depends on From.
+	Edge = dag.Edge
+
+	// Graph is an immutable, validated task graph with a stable hash.
+	Graph = dag.TaskGraph
+)
+
+// Execution.
+type (
+	// TaskRunner executes (or restores) a single task for the engine.
+	TaskRunner = dag.TaskRunner
+
+	// NodeResult is the outcome of one task as reported by a TaskRunner.
+	NodeResult = dag.NodeResult
+
+	// NodeObserver is notified when a task reaches a successful terminal
+	// state, with a snapshot of the trace so far.
+	NodeObserver = dag.NodeObserver
+
+	// NodeEvent is a task state change delivered to NodeEventObservers.
+	NodeEvent = dag.NodeEvent
+
+	// NodeEventKind classifies a NodeEvent.
+	NodeEventKind = dag.NodeEventKind
+
+	// NodeEventObserver subscribes to every task state change of a run.
+	NodeEventObserver = dag.NodeEventObserver
+
+	// NodeEventObserverFunc adapts a function to NodeEventObserver.
+	NodeEventObserverFunc = dag.NodeEventObserverFunc
+
+	// LifecycleHooks are invoked around the run and each task.
+	LifecycleHooks = dag.LifecycleHooks
+
+	// GraphResult summarizes a run, including the canonical trace bytes.
+	GraphResult = dag.GraphResult
+
+	// TaskState is a task's state in GraphResult.FinalState.
+	TaskState = dag.TaskState
+
+	// TraceEvent is one entry of an execution trace.
+	TraceEvent = trace.TraceEvent
+)
+
+// Built-in task kinds.
+const (
+	KindShell     = core.KindShell
+	KindContainer = core.KindContainer
+)
+
+// Terminal task states.
+const (
+	TaskCompleted = dag.TaskCompleted
+	TaskCached    = dag.TaskCached
+	TaskFailed    = dag.TaskFailed
+	TaskSkipped   = dag.TaskSkipped
+)
+
+// Node event kinds.
+const (
+	NodeStarted  = dag.NodeStarted
+	NodeFinished = dag.NodeFinished
+	NodeCached   = dag.NodeCached
+	NodeFailed   = dag.NodeFailed
+	NodeSkipped  = dag.NodeSkipped
+)
+
+// NewGraph validates tasks and edges and returns the canonical graph.
+func NewGraph(tasks []Task, edges []Edge) (*Graph, error) {
+	return dag.NewTaskGraph(tasks, edges)
+}
+
+// Options configures an Engine.
+type Options struct {
+	// WorkDir is the absolute directory tasks run in and resolve inputs and
+	// outputs against. Required.
+	WorkDir string
+
+	// CacheDir stores cache entries on disk. Empty keeps the cache in memory
+	// for the lifetime of the Engine.
+	CacheDir string
+
+	// ContainerEngine runs tasks that declare an Image ("docker" when empty).
+	ContainerEngine string
+
+	// Observer is notified of successful task completions.
+	Observer NodeObserver
+
+	// Observers each receive every task state change: started, finished,
+	// cached, failed and skipped.
+	Observers []NodeEventObserver
+
+	// Hooks are invoked around the run and each task.
+	Hooks LifecycleHooks
+
+	// Parallelism bounds concurrent tasks; 0 or 1 runs serially.
+	Parallelism int
+}
+
+// Engine runs graphs with ScriptWeaver's cache-aware runner for shell and
+// container tasks, plus any runners registered for other kinds.
+type Engine struct {
+	opts     Options
+	registry *dag.RunnerRegistry
+}
+
+// New returns an Engine for opts.
+func New(opts Options) (*Engine, error) {
+	if opts.WorkDir == "" {
+		return nil, fmt.Errorf("WorkDir is required")
+	}
+	if !filepath.IsAbs(opts.WorkDir) {
+		return nil, fmt.Errorf("WorkDir must be an absolute path (got %q)", opts.WorkDir)
+	}
+	var cache core.Cache = core.NewMemoryCache()
+	if opts.CacheDir != "" {
+		cache = core.NewFileCache(opts.CacheDir)
+	}
+	runner := core.NewRunner(opts.WorkDir, cache)
+	runner.HealCorruptEntries = true
+	cacheRunner, err := dag.NewContainerRunner(runner, opts.ContainerEngine)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := dag.NewRunnerRegistry(cacheRunner)
+	if err != nil {
+		return nil, err
+	}
+	if err := registry.Register(core.KindContainer, cacheRunner); err != nil {
+		return nil, err
+	}
+	return &Engine{opts: opts, registry: registry}, nil
+}
+
+// RegisterRunner dispatches tasks of the given kind to runner. Kinds must be
+// unique; KindShell and KindContainer are registered by New.
+func (e *Engine) RegisterRunner(kind string, runner TaskRunner) error {
+	return e.registry.Register(kind, runner)
+}
+
+// Run executes g and returns its result. The canonical trace is in
+// GraphResult.TraceBytes. A task failure is reported through the result,
+// not the error; the error is reserved for engine and configuration faults.
+func (e *Engine) Run(ctx context.Context, g *Graph) (*GraphResult, error) {
+	if err := e.registry.Validate(g); err != nil {
+		return nil, err
+	}
+	exec, err := dag.NewExecutor(g, e.registry)
+	if err != nil {
+		return nil, err
+	}
+	exec.Observer = e.opts.Observer
+	exec.Observers = e.opts.Observers
+	if e.opts.Hooks != nil {
+		exec.Hooks = e.opts.Hooks
+	}
+	if e.opts.Parallelism > 1 {
+		return exec.RunParallel(ctx, e.opts.Parallelism)
+	}
+	return exec.RunSerial(ctx)
+}
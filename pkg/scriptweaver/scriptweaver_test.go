@@ -0,0 +1,107 @@
+package scriptweaver_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"scriptweaver/pkg/scriptweaver"
+)
+
+type echoRunner struct{ ran []string }
+
+func (r *echoRunner) Probe(context.Context, scriptweaver.Task) (*scriptweaver.NodeResult, bool, error) {
+	return nil, false, nil
+}
+
+func (r *echoRunner) Run(_ context.Context, task scriptweaver.Task) (*scriptweaver.NodeResult, error) {
+	r.ran = append(r.ran, task.Name)
+	return &scriptweaver.NodeResult{Hash: scriptweaver.TaskHash("echo:" + task.Name), Stdout: []byte(task.Run)}, nil
+}
+
+type countingObserver struct{ seen []string }
+
+func (o *countingObserver) OnTaskTerminal(task scriptweaver.Task, _ *scriptweaver.NodeResult, _ []scriptweaver.TraceEvent) error {
+	o.seen = append(o.seen, task.Name)
+	return nil
+}
+
+func TestEngine_RunsEmbeddedGraph(t *testing.T) {
+	workDir := t.TempDir()
+	obs := &countingObserver{}
+	var kinds []scriptweaver.NodeEventKind
+	events := scriptweaver.NodeEventObserverFunc(func(ev scriptweaver.NodeEvent) { kinds = append(kinds, ev.Kind) })
+	engine, err := scriptweaver.New(scriptweaver.Options{WorkDir: workDir, Observer: obs, Observers: []scriptweaver.NodeEventObserver{events}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	custom := &echoRunner{}
+	if err := engine.RegisterRunner("echo", custom); err != nil {
+		t.Fatalf("RegisterRunner: %v", err)
+	}
+
+	g, err := scriptweaver.NewGraph([]scriptweaver.Task{
+		{Name: "build", Run: "echo built > out.txt", Outputs: []string{"out.txt"}},
+		{Name: "announce", Run: "done", Kind: "echo"},
+	}, []scriptweaver.Edge{{From: "build", To: "announce"}})
+	if err != nil {
+		t.Fatalf("NewGraph: %v", err)
+	}
+
+	res, err := engine.Run(context.Background(), g)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.FinalState["build"] != scriptweaver.TaskCompleted || res.FinalState["announce"] != scriptweaver.TaskCompleted {
+		t.Fatalf("unexpected final state: %v", res.FinalState)
+	}
+	if len(custom.ran) != 1 || string(res.Stdout["announce"]) != "done" {
+		t.Fatalf("expected custom runner to handle announce, ran=%v", custom.ran)
+	}
+	if len(obs.seen) != 2 {
+		t.Fatalf("expected observer for both tasks, got %v", obs.seen)
+	}
+	if len(res.TraceBytes) == 0 {
+		t.Fatalf("expected trace bytes")
+	}
+	if want := []scriptweaver.NodeEventKind{scriptweaver.NodeStarted, scriptweaver.NodeFinished, scriptweaver.NodeStarted, scriptweaver.NodeFinished}; !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("expected events %v, got %v", want, kinds)
+	}
+
+	// The in-memory cache persists across runs of one Engine.
+	if err := os.Remove(filepath.Join(workDir, "out.txt")); err != nil {
+		t.Fatal(err)
+	}
+	res, err = engine.Run(context.Background(), g)
+	if err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+	if res.FinalState["build"] != scriptweaver.TaskCached {
+		t.Fatalf("expected build cached, got %v", res.FinalState["build"])
+	}
+	if b, err := os.ReadFile(filepath.Join(workDir, "out.txt")); err != nil || string(b) != "built\n" {
+		t.Fatalf("expected output replayed, got %q (err=%v)", b, err)
+	}
+}
+
+func TestEngine_RejectsUnknownKind(t *testing.T) {
+	engine, err := scriptweaver.New(scriptweaver.Options{WorkDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	g, err := scriptweaver.NewGraph([]scriptweaver.Task{{Name: "a", Run: "x", Kind: "missing"}}, nil)
+	if err != nil {
+		t.Fatalf("NewGraph: %v", err)
+	}
+	if _, err := engine.Run(context.Background(), g); err == nil {
+		t.Fatalf("expected unknown kind error")
+	}
+	if _, err := scriptweaver.New(scriptweaver.Options{}); err == nil {
+		t.Fatalf("expected WorkDir to be required")
+	}
+	if _, err := scriptweaver.New(scriptweaver.Options{WorkDir: "relative/dir"}); err == nil {
+		t.Fatalf("expected a relative WorkDir to be rejected")
+	}
+}